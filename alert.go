@@ -0,0 +1,192 @@
+package wtf
+
+import (
+	"context"
+	"time"
+)
+
+// Alert rule conditions.
+const (
+	// Fires when the dial value is at or above the threshold for the duration.
+	AlertConditionAbove = "above"
+
+	// Fires when the dial value is at or below the threshold for the duration.
+	AlertConditionBelow = "below"
+
+	// Fires when the dial value rises by at least the threshold within the duration.
+	AlertConditionRise = "rise"
+)
+
+// IsValidAlertCondition returns true if s is a supported alert rule condition.
+func IsValidAlertCondition(s string) bool {
+	switch s {
+	case AlertConditionAbove, AlertConditionBelow, AlertConditionRise:
+		return true
+	default:
+		return false
+	}
+}
+
+// Alert rule states.
+const (
+	AlertRuleStateOK     = "ok"
+	AlertRuleStateFiring = "firing"
+)
+
+// Alert rule constants.
+const (
+	MaxAlertRuleDuration = 24 * time.Hour
+)
+
+// AlertRule represents a condition on a dial's value that is watched over
+// time. When the condition is met, the rule fires & an alert is recorded.
+// The alert is resolved once the condition is no longer met.
+type AlertRule struct {
+	ID int `json:"id"`
+
+	// Dial that the rule watches. Only the dial owner & admins can manage rules.
+	DialID int   `json:"dialID"`
+	Dial   *Dial `json:"dial"`
+
+	// Condition to check against the dial value. The threshold is a dial
+	// value for the "above" & "below" conditions and the size of the change
+	// for the "rise" condition. The duration is how long the value must stay
+	// past the threshold or how quickly it must rise.
+	Condition string        `json:"condition"`
+	Threshold int           `json:"threshold"`
+	Duration  time.Duration `json:"duration"`
+
+	// Current state of the rule. Either "ok" or "firing".
+	State string `json:"state"`
+
+	// Timestamps for rule creation & last update.
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Validate returns an error if the rule contains invalid fields.
+// This only performs basic validation.
+func (r *AlertRule) Validate() error {
+	if r.DialID == 0 {
+		return Errorf(EINVALID, "Dial required for alert rule.")
+	} else if !IsValidAlertCondition(r.Condition) {
+		return Errorf(EINVALID, "Invalid alert condition.")
+	} else if r.Threshold < 0 || r.Threshold > 100 {
+		return Errorf(EINVALID, "Alert threshold must be between 0 & 100.")
+	} else if r.Duration < 0 || r.Duration > MaxAlertRuleDuration {
+		return Errorf(EINVALID, "Alert duration must be between zero & 24 hours.")
+	} else if r.Condition == AlertConditionRise && (r.Threshold == 0 || r.Duration == 0) {
+		return Errorf(EINVALID, "Rising alerts require a threshold & duration.")
+	}
+	return nil
+}
+
+// Evaluate returns true if the rule condition is met at the given time.
+//
+// The records must be sorted by timestamp & include the last value recorded
+// at or before the start of the rule's duration, if one exists, followed by
+// every value recorded after it.
+func (r *AlertRule) Evaluate(records []*DialValueRecord, now time.Time) bool {
+	if len(records) == 0 {
+		return false
+	}
+	start := now.Add(-r.Duration)
+
+	switch r.Condition {
+	case AlertConditionAbove, AlertConditionBelow:
+		// The value must have been past the threshold for the whole duration
+		// so there must be a value recorded at or before the start.
+		if records[0].Timestamp.After(start) {
+			return false
+		}
+		for _, record := range records {
+			if r.Condition == AlertConditionAbove && record.Value < r.Threshold {
+				return false
+			} else if r.Condition == AlertConditionBelow && record.Value > r.Threshold {
+				return false
+			}
+		}
+		return true
+
+	case AlertConditionRise:
+		// Compare the current value to the lowest value within the duration.
+		low := records[0].Value
+		for _, record := range records {
+			if record.Value < low {
+				low = record.Value
+			}
+		}
+		return records[len(records)-1].Value-low >= r.Threshold
+
+	default:
+		return false
+	}
+}
+
+// Alert represents a single firing of an alert rule. The alert is resolved
+// once the rule's condition is no longer met.
+type Alert struct {
+	ID int `json:"id"`
+
+	// Rule that fired & the dial it watches.
+	AlertRuleID int `json:"alertRuleID"`
+	DialID      int `json:"dialID"`
+
+	// Dial value when the alert fired.
+	Value int `json:"value"`
+
+	// Time the alert fired & the time it was resolved, if resolved.
+	FiredAt    time.Time  `json:"firedAt"`
+	ResolvedAt *time.Time `json:"resolvedAt"`
+}
+
+// AlertService represents a service for managing alert rules & their history.
+type AlertService interface {
+	// Retrieves a single alert rule by ID along with the associated dial.
+	// Returns ENOTFOUND if rule does not exist or user is not a member of
+	// the rule's dial.
+	FindAlertRuleByID(ctx context.Context, id int) (*AlertRule, error)
+
+	// Retrieves a list of alert rules by filter. Only returns rules on dials
+	// that the current user is a member of. Also returns the total count of
+	// matching rules which may differ if filter.Limit is specified.
+	FindAlertRules(ctx context.Context, filter AlertRuleFilter) ([]*AlertRule, int, error)
+
+	// Creates a new alert rule on a dial. Returns EUNAUTHORIZED if the user
+	// is not the owner or an admin of the dial.
+	CreateAlertRule(ctx context.Context, rule *AlertRule) error
+
+	// Permanently removes an alert rule & its alert history. Returns
+	// EUNAUTHORIZED if the user is not the owner or an admin of the dial.
+	DeleteAlertRule(ctx context.Context, id int) error
+
+	// Retrieves a list of alerts by filter, most recent first. Only returns
+	// alerts on dials that the current user is a member of.
+	FindAlerts(ctx context.Context, filter AlertFilter) ([]*Alert, int, error)
+
+	// Evaluates every alert rule against the current time. Rules are also
+	// evaluated when their dial value changes but this is required for
+	// duration-based rules to fire when the value stays the same.
+	// This is intended for background workers & ignores the current user.
+	EvaluateAlertRules(ctx context.Context) error
+}
+
+// AlertRuleFilter represents a filter used by FindAlertRules().
+type AlertRuleFilter struct {
+	ID     *int `json:"id"`
+	DialID *int `json:"dialID"`
+
+	// Restricts results to a subset of the total range.
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+}
+
+// AlertFilter represents a filter used by FindAlerts().
+type AlertFilter struct {
+	AlertRuleID *int `json:"alertRuleID"`
+	DialID      *int `json:"dialID"`
+
+	// Restricts results to a subset of the total range.
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+}
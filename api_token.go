@@ -0,0 +1,144 @@
+package wtf
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// API token constants.
+const (
+	MaxAPITokenNameLen = 100
+
+	// Prefix prepended to all generated tokens so they are easy to recognize.
+	// Tokens migrated from legacy user API keys do not have the prefix.
+	APITokenPrefix = "wtf_"
+
+	// Number of characters of the token stored in plaintext so that users can
+	// identify a token after creation.
+	APITokenDisplayPrefixLen = 12
+)
+
+// API token scopes. Each scope includes the permissions of the scopes above it.
+const (
+	// Allows reading dials, memberships & reports only. Resources which hold
+	// credentials, such as webhooks & API tokens, are not readable & invite
+	// codes are omitted from dials & organizations.
+	APITokenScopeRead = "read"
+
+	// Allows reading and setting the user's own membership values.
+	APITokenScopeSetValue = "set-value"
+
+	// Allows full access to the API on behalf of the user.
+	APITokenScopeAdmin = "admin"
+)
+
+// IsValidAPITokenScope returns true if s is a known API token scope.
+func IsValidAPITokenScope(s string) bool {
+	switch s {
+	case APITokenScopeRead, APITokenScopeSetValue, APITokenScopeAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
+// CanViewInviteCodes returns false if the current request was authenticated
+// with an API token which is not allowed to read invite codes. Invite codes
+// allow anyone to join a dial or organization so only the "admin" scope can
+// read them.
+func CanViewInviteCodes(ctx context.Context) bool {
+	scope := APITokenScopeFromContext(ctx)
+	return scope == "" || scope == APITokenScopeAdmin
+}
+
+// APIToken represents a named credential that allows API clients, such as the
+// CLI, to act on behalf of a user. A user can have many tokens & each token
+// can be restricted to a scope, given an expiration & revoked independently.
+//
+// Only a hash of the token is stored. The plaintext token is only available
+// on the object returned from CreateAPIToken().
+type APIToken struct {
+	ID int `json:"id"`
+
+	// User that the token authenticates as.
+	UserID int   `json:"userID"`
+	User   *User `json:"user,omitempty"`
+
+	// Label used to identify the token, such as the name of the machine.
+	Name string `json:"name"`
+
+	// Restricts the operations that can be performed with the token.
+	Scope string `json:"scope"`
+
+	// Plaintext token. Only set when the token is created.
+	Token string `json:"token,omitempty"`
+
+	// First few characters of the token. Used to identify the token in lists.
+	Prefix string `json:"prefix"`
+
+	// Time after which the token can no longer be used. Nil if it never expires.
+	ExpiresAt *time.Time `json:"expiresAt"`
+
+	// Time the token was last used to authenticate. Nil if never used.
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+
+	// Timestamp for token creation.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Validate returns an error if the token contains invalid fields.
+// This only performs basic validation.
+func (t *APIToken) Validate() error {
+	if t.Name == "" {
+		return Errorf(EINVALID, "API token name required.")
+	} else if len(t.Name) > MaxAPITokenNameLen {
+		return Errorf(EINVALID, "API token name too long.")
+	} else if !IsValidAPITokenScope(t.Scope) {
+		return Errorf(EINVALID, "Invalid API token scope.")
+	}
+	return nil
+}
+
+// IsExpired returns true if the token has an expiration at or before now.
+func (t *APIToken) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// HashAPIToken returns the hex-encoded SHA-256 hash of a plaintext token.
+// Tokens are stored & looked up by this hash.
+func HashAPIToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+// APITokenService represents a service for managing API tokens.
+type APITokenService interface {
+	// Retrieves a list of the current user's tokens by filter. Plaintext
+	// tokens are never returned. Also returns the total count of matching
+	// tokens which may differ if filter.Limit is specified.
+	FindAPITokens(ctx context.Context, filter APITokenFilter) ([]*APIToken, int, error)
+
+	// Creates a new token for the current user. The plaintext token is set on
+	// token.Token & cannot be retrieved again.
+	CreateAPIToken(ctx context.Context, token *APIToken) error
+
+	// Permanently revokes a token. Returns ENOTFOUND if the token does not
+	// exist or does not belong to the current user.
+	DeleteAPIToken(ctx context.Context, id int) error
+
+	// Looks up a token by its plaintext value & records its use. The token's
+	// user is attached. Does not require a user on the context. Returns
+	// ENOTFOUND if the token does not exist or EUNAUTHORIZED if it has expired.
+	AuthenticateAPIToken(ctx context.Context, token string) (*APIToken, error)
+}
+
+// APITokenFilter represents a filter used by FindAPITokens().
+type APITokenFilter struct {
+	ID *int `json:"id"`
+
+	// Restricts results to a subset of the total range.
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+}
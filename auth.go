@@ -6,10 +6,14 @@ import (
 	"time"
 )
 
-// Authentication providers.
-// Currently we only support GitHub but any OAuth provider could be supported.
+// Authentication providers. GitHub is built in. OpenID Connect providers are
+// configured by the operator & use their configured name as the source.
+//
+// Email auths are created by logging in with an emailed login link. The
+// source ID is the email address.
 const (
 	AuthSourceGitHub = "github"
+	AuthSourceEmail  = "email"
 )
 
 // Auth represents a set of OAuth credentials. These are linked to a User so a
@@ -17,8 +21,8 @@ const (
 //
 // The authentication system links users by email address, however, some GitHub
 // users don't provide their email publicly so we may not be able to link them
-// by email address. It's a moot point, however, as we only support GitHub as
-// an OAuth provider.
+// by email address. OpenID Connect providers only supply an email for linking
+// if it has been verified by the provider.
 type Auth struct {
 	ID int `json:"id"`
 
@@ -28,11 +32,12 @@ type Auth struct {
 	User   *User `json:"user"`
 
 	// The authentication source & the source provider's user ID.
-	// Source can only be "github" currently.
+	// Source is "github" or the name of an OpenID Connect provider.
 	Source   string `json:"source"`
 	SourceID string `json:"sourceID"`
 
-	// OAuth fields returned from the authentication provider.
+	// OAuth fields returned from the authentication provider. These are
+	// not set for email auths.
 	// GitHub does not use refresh tokens but the field exists for future providers.
 	AccessToken  string     `json:"-"`
 	RefreshToken string     `json:"-"`
@@ -52,7 +57,7 @@ func (a *Auth) Validate() error {
 		return Errorf(EINVALID, "Source required.")
 	} else if a.SourceID == "" {
 		return Errorf(EINVALID, "Source ID required.")
-	} else if a.AccessToken == "" {
+	} else if a.AccessToken == "" && a.Source != AuthSourceEmail {
 		return Errorf(EINVALID, "Access token required.")
 	}
 	return nil
@@ -84,6 +89,10 @@ type AuthService interface {
 	// the auth object is linked to an existing user. Otherwise a new user
 	// object is created.
 	//
+	// If auth.UserID is set then the auth is linked to that user, which must
+	// be the current user. Returns ECONFLICT if the source account is already
+	// linked to another user or if the user already has an auth for the source.
+	//
 	// On success, the auth.ID is set to the new authentication ID.
 	CreateAuth(ctx context.Context, auth *Auth) error
 
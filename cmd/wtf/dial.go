@@ -30,6 +30,8 @@ func (c *DialCommand) Run(ctx context.Context, args []string) error {
 		return (&DialMembersCommand{}).Run(ctx, args)
 	case "set":
 		return (&DialSetCommand{}).Run(ctx, args)
+	case "transfer":
+		return (&DialTransferCommand{}).Run(ctx, args)
 	case "help":
 		c.usage()
 		return flag.ErrHelp
@@ -54,5 +56,6 @@ The commands are:
 	delete      remove an existing dial
 	members     view list of members of a dial
 	set         set your WTF level for a dial
+	transfer    transfer ownership of a dial to a member
 `[1:])
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"strings"
 
 	"github.com/benbjohnson/wtf"
 	"github.com/benbjohnson/wtf/http"
@@ -11,21 +12,45 @@ import (
 
 // DialListCommand represents a command for listing dials.
 // This command provides a short output of just the name or a verbose output
-// which includes the id, name, & invite URL.
+// which includes the id, name, value, tags, & invite URL.
 type DialListCommand struct {
 	ConfigPath string
 }
 
 // Run executes the command.
 func (c *DialListCommand) Run(ctx context.Context, args []string) error {
-	// Build a flag set to retrieve the config path & verbose flag.
+	// Build a flag set to retrieve the config path, verbose flag & filters.
 	fs := flag.NewFlagSet("wtf-dial-list", flag.ContinueOnError)
 	verbose := fs.Bool("v", false, "verbose")
+	tag := fs.String("tag", "", "filter by tag")
+	owner := fs.Int("owner", 0, "filter by owner user ID")
+	name := fs.String("name", "", "filter by name")
+	minValue := fs.Int("min", -1, "minimum value")
+	maxValue := fs.Int("max", -1, "maximum value")
+	sortBy := fs.String("sort", "", "sort order")
 	attachConfigFlags(fs, &c.ConfigPath)
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
 
+	// Build filter from the flags that were set.
+	filter := wtf.DialFilter{SortBy: *sortBy}
+	if *tag != "" {
+		filter.Tag = tag
+	}
+	if *owner != 0 {
+		filter.UserID = owner
+	}
+	if *name != "" {
+		filter.Name = name
+	}
+	if *minValue >= 0 {
+		filter.MinValue = minValue
+	}
+	if *maxValue >= 0 {
+		filter.MaxValue = maxValue
+	}
+
 	// Load the configuration.
 	config, err := ReadConfigFile(c.ConfigPath)
 	if err != nil {
@@ -37,7 +62,7 @@ func (c *DialListCommand) Run(ctx context.Context, args []string) error {
 
 	// Build dial service and fetch list of dials user is a member of.
 	dialService := http.NewDialService(http.NewClient(config.URL))
-	dials, _, err := dialService.FindDials(ctx, wtf.DialFilter{})
+	dials, _, err := dialService.FindDials(ctx, filter)
 	if err != nil {
 		return err
 	}
@@ -52,9 +77,11 @@ func (c *DialListCommand) Run(ctx context.Context, args []string) error {
 
 		// If we are in verbose mode, print a tab-delimited list of fields.
 		fmt.Printf(
-			"%d\t%s\t%s\n",
+			"%d\t%s\t%d\t%s\t%s\n",
 			dial.ID,
 			dial.Name,
+			dial.Value,
+			strings.Join(dial.Tags, ","),
 			config.URL+"/invite/"+dial.InviteCode,
 		)
 	}
@@ -69,11 +96,28 @@ List dials you are a member of.
 
 Usage:
 
-	wtf dial list
+	wtf dial list [arguments]
 
 Arguments:
 
 	-v
 	    Enable verbose output.
+
+	-tag TAG
+	    Only list dials with the given tag.
+
+	-owner ID
+	    Only list dials owned by the given user ID.
+
+	-name TEXT
+	    Only list dials whose name contains the given text.
+
+	-min VALUE
+	-max VALUE
+	    Only list dials with a WTF level within the given range.
+
+	-sort ORDER
+	    Sort dials by "name_asc", "value_asc", "value_desc", or
+	    "updated_at_desc". Defaults to creation order.
 `[1:])
 }
@@ -51,11 +51,13 @@ func (c *DialMembersCommand) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
-	// Iterate over membrships and print the name & value.
+	// Iterate over membrships and print the user ID, name, role & value.
 	for _, membership := range dial.Memberships {
 		fmt.Printf(
-			"%s\t%d\n",
+			"%d\t%s\t%s\t%d\n",
+			membership.UserID,
 			membership.User.Name,
+			membership.Role,
 			membership.Value,
 		)
 	}
@@ -66,7 +68,7 @@ func (c *DialMembersCommand) Run(ctx context.Context, args []string) error {
 // usage prints command usage information to STDOUT.
 func (c *DialMembersCommand) usage() {
 	fmt.Println(`
-List members of a dial and their WTF level.
+List members of a dial along with their user ID, role & WTF level.
 
 Usage:
 
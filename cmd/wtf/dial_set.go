@@ -19,6 +19,7 @@ type DialSetCommand struct {
 func (c *DialSetCommand) Run(ctx context.Context, args []string) error {
 	// Create a flag set with parameters for the dial fields.
 	fs := flag.NewFlagSet("wtf-dial-set", flag.ContinueOnError)
+	note := fs.String("m", "", "note")
 	attachConfigFlags(fs, &c.ConfigPath)
 	if err := fs.Parse(args); err != nil {
 		return err
@@ -55,7 +56,7 @@ func (c *DialSetCommand) Run(ctx context.Context, args []string) error {
 
 	// Build dial from arguments and issue creation request over HTTP.
 	svc := http.NewDialService(http.NewClient(config.URL))
-	if err := svc.SetDialMembershipValue(ctx, id, value); err != nil {
+	if err := svc.SetDialMembershipValue(ctx, id, value, *note); err != nil {
 		return err
 	}
 
@@ -72,7 +73,12 @@ Sets your WTF level for a dial you are a member of.
 
 Usage:
 
-	wtf dial set DIAL_ID WTF_LEVEL
+	wtf dial set [-m NOTE] DIAL_ID WTF_LEVEL
+
+Arguments:
+
+	-m NOTE
+	    A short note explaining the change. Optional.
 
 `[1:])
 }
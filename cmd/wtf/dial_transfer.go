@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/benbjohnson/wtf"
+	"github.com/benbjohnson/wtf/http"
+)
+
+// DialTransferCommand represents a command for transferring dial ownership.
+type DialTransferCommand struct {
+	ConfigPath string
+}
+
+// Run executes the command.
+func (c *DialTransferCommand) Run(ctx context.Context, args []string) error {
+	// Create flag set to parse the config path & read the IDs.
+	fs := flag.NewFlagSet("wtf-dial-transfer", flag.ContinueOnError)
+	attachConfigFlags(fs, &c.ConfigPath)
+	if err := fs.Parse(args); err != nil {
+		return err
+	} else if fs.NArg() == 0 {
+		return fmt.Errorf("Dial ID required.")
+	} else if fs.NArg() == 1 {
+		return fmt.Errorf("User ID of new owner required.")
+	} else if fs.NArg() > 2 {
+		return fmt.Errorf("Please only specify the dial ID and user ID.")
+	}
+
+	// Parse the dial ID from the first arg.
+	id, err := strconv.Atoi(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("Invalid dial ID.")
+	}
+
+	// Parse the new owner's user ID from the second arg.
+	userID, err := strconv.Atoi(fs.Arg(1))
+	if err != nil {
+		return fmt.Errorf("Invalid user ID.")
+	}
+
+	// Load configuration file.
+	config, err := ReadConfigFile(c.ConfigPath)
+	if err != nil {
+		return err
+	}
+
+	// Authenticate user using the API key.
+	ctx = wtf.NewContextWithUser(ctx, &wtf.User{APIKey: config.APIKey})
+
+	// Instantiate HTTP service and issue transfer.
+	svc := http.NewDialService(http.NewClient(config.URL))
+	dial, err := svc.TransferDial(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	// Notify user of the new owner.
+	fmt.Printf("Your dial %q has been transferred to %s.\n", dial.Name, dial.User.Name)
+
+	return nil
+}
+
+// usage prints the command usage information to STDOUT.
+func (c *DialTransferCommand) usage() {
+	fmt.Println(`
+Transfer ownership of a dial to another member of the dial. You will remain
+a member of the dial as an admin.
+
+Use "wtf dial members" to find the user ID of the new owner.
+
+Usage:
+
+	wtf dial transfer DIAL_ID USER_ID
+`[1:])
+}
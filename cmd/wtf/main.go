@@ -63,6 +63,10 @@ func Run(ctx context.Context, args []string) error {
 	switch cmd {
 	case "dial":
 		return (&DialCommand{}).Run(ctx, args)
+	case "search":
+		return (&SearchCommand{}).Run(ctx, args)
+	case "token":
+		return (&TokenCommand{}).Run(ctx, args)
 	case "", "-h", "help":
 		usage()
 		return flag.ErrHelp
@@ -83,6 +87,8 @@ Usage:
 The commands are:
 
 	dial        manage your dial
+	search      search your dials, members & notes
+	token       manage your API tokens
 `[1:])
 }
 
@@ -91,7 +97,8 @@ type Config struct {
 	// Base URL of the server. This should be changed for local development.
 	URL string `toml:"url"`
 
-	// API key used for authentication. Users can find this key on the /settings page.
+	// API key or token used for authentication. Users can create scoped
+	// tokens on the /settings page or with the "wtf token create" command.
 	APIKey string `toml:"api-key"`
 }
 
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/benbjohnson/wtf"
+	"github.com/benbjohnson/wtf/http"
+)
+
+// SearchCommand represents a command for searching dials, members & notes.
+type SearchCommand struct {
+	ConfigPath string
+}
+
+// Run executes the command.
+func (c *SearchCommand) Run(ctx context.Context, args []string) error {
+	// Create a flag set to read the config path & the search query.
+	fs := flag.NewFlagSet("wtf-search", flag.ContinueOnError)
+	attachConfigFlags(fs, &c.ConfigPath)
+	if err := fs.Parse(args); err != nil {
+		return err
+	} else if fs.NArg() == 0 {
+		return fmt.Errorf("Search query required.")
+	}
+
+	// Load configuration file.
+	config, err := ReadConfigFile(c.ConfigPath)
+	if err != nil {
+		return err
+	}
+
+	// Authenticate user with API key.
+	ctx = wtf.NewContextWithUser(ctx, &wtf.User{APIKey: config.APIKey})
+
+	// Instantiate HTTP search service and search with all args as the query.
+	searchService := http.NewSearchService(http.NewClient(config.URL))
+	results, _, err := searchService.Search(ctx, wtf.SearchFilter{Query: strings.Join(fs.Args(), " ")})
+	if err != nil {
+		return err
+	}
+
+	// Iterate over results and print the dial ID & name along with the
+	// member name & note for membership matches.
+	for _, result := range results {
+		if result.IsDialMembership() {
+			fmt.Printf("%d\t%s\t%s\t%s\n", result.DialID, result.Dial.Name, result.Name, result.Note)
+		} else {
+			fmt.Printf("%d\t%s\n", result.DialID, result.Dial.Name)
+		}
+	}
+
+	return nil
+}
+
+// usage prints command usage information to STDOUT.
+func (c *SearchCommand) usage() {
+	fmt.Println(`
+Search the names of your dials along with the member names & notes of
+their members. Each term must match the start of a word.
+
+Usage:
+
+	wtf search QUERY
+`[1:])
+}
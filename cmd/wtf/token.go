@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// TokenCommand represents a collection of API token subcommands.
+type TokenCommand struct{}
+
+// Run executes the command which delegates to other subcommands.
+func (c *TokenCommand) Run(ctx context.Context, args []string) error {
+	// Shift off the subcommand name, if available.
+	var cmd string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
+
+	// Delegete to the appropriate subcommand.
+	switch cmd {
+	case "", "list":
+		return (&TokenListCommand{}).Run(ctx, args)
+	case "create":
+		return (&TokenCreateCommand{}).Run(ctx, args)
+	case "revoke":
+		return (&TokenRevokeCommand{}).Run(ctx, args)
+	case "help":
+		c.usage()
+		return flag.ErrHelp
+	default:
+		return fmt.Errorf("wtf token %s: unknown command", cmd)
+	}
+}
+
+// usage prints the subcommand usage to STDOUT.
+func (c *TokenCommand) usage() {
+	fmt.Println(`
+Manage API tokens used to access WTF Dial.
+
+Usage:
+
+	wtf token <command> [arguments]
+
+The commands are:
+
+	list        list your API tokens
+	create      create a new API token
+	revoke      permanently revoke an API token
+`[1:])
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+	"github.com/benbjohnson/wtf/http"
+)
+
+// TokenCreateCommand is a command for creating API tokens.
+type TokenCreateCommand struct {
+	ConfigPath string
+}
+
+// Run executes the command.
+func (c *TokenCreateCommand) Run(ctx context.Context, args []string) error {
+	// Create a flag set with parameters for the token fields.
+	fs := flag.NewFlagSet("wtf-token-create", flag.ContinueOnError)
+	name := fs.String("name", "", "token name")
+	scope := fs.String("scope", wtf.APITokenScopeRead, "token scope")
+	expires := fs.Duration("expires", 0, "token lifetime")
+	attachConfigFlags(fs, &c.ConfigPath)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// Load the configuration.
+	config, err := ReadConfigFile(c.ConfigPath)
+	if err != nil {
+		return err
+	}
+
+	// Authenticate the user with the API key from the config.
+	ctx = wtf.NewContextWithUser(ctx, &wtf.User{APIKey: config.APIKey})
+
+	// Build token from arguments and issue creation request over HTTP.
+	token := &wtf.APIToken{Name: *name, Scope: *scope}
+	if *expires > 0 {
+		expiresAt := time.Now().Add(*expires)
+		token.ExpiresAt = &expiresAt
+	}
+	svc := http.NewAPITokenService(http.NewClient(config.URL))
+	if err := svc.CreateAPIToken(ctx, token); err != nil {
+		return err
+	}
+
+	// Display the plaintext token since it cannot be retrieved again.
+	fmt.Printf("Your %q token has been created. Copy it now as it will not be shown again:\n\n", token.Name)
+	fmt.Printf("%s\n\n", token.Token)
+
+	return nil
+}
+
+// usage print usage information for the command to STDOUT.
+func (c *TokenCreateCommand) usage() {
+	fmt.Println(`
+Create a new API token.
+
+Usage:
+
+	wtf token create -name NAME [-scope SCOPE] [-expires DURATION]
+
+Arguments:
+
+	-name NAME
+	    The name of the token, such as the machine it is used on. Required.
+
+	-scope SCOPE
+	    One of "read", "set-value", or "admin". Defaults to "read".
+
+	-expires DURATION
+	    Time until the token expires (e.g. "720h"). Never expires if unset.
+`[1:])
+}
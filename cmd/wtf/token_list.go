@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+	"github.com/benbjohnson/wtf/http"
+)
+
+// TokenListCommand represents a command for listing API tokens.
+type TokenListCommand struct {
+	ConfigPath string
+}
+
+// Run executes the command.
+func (c *TokenListCommand) Run(ctx context.Context, args []string) error {
+	// Build a flag set to retrieve the config path.
+	fs := flag.NewFlagSet("wtf-token-list", flag.ContinueOnError)
+	attachConfigFlags(fs, &c.ConfigPath)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// Load the configuration.
+	config, err := ReadConfigFile(c.ConfigPath)
+	if err != nil {
+		return err
+	}
+
+	// Authenticate user with API key.
+	ctx = wtf.NewContextWithUser(ctx, &wtf.User{APIKey: config.APIKey})
+
+	// Fetch the list of the user's tokens.
+	svc := http.NewAPITokenService(http.NewClient(config.URL))
+	tokens, _, err := svc.FindAPITokens(ctx, wtf.APITokenFilter{})
+	if err != nil {
+		return err
+	}
+
+	// Print a tab-delimited list of fields for each token.
+	for _, token := range tokens {
+		expiresAt, lastUsedAt := "never", "never"
+		if token.ExpiresAt != nil {
+			expiresAt = token.ExpiresAt.Format(time.RFC3339)
+		}
+		if token.LastUsedAt != nil {
+			lastUsedAt = token.LastUsedAt.Format(time.RFC3339)
+		}
+
+		fmt.Printf(
+			"%d\t%s\t%s\t%s\texpires=%s\tlast-used=%s\n",
+			token.ID,
+			token.Name,
+			token.Prefix,
+			token.Scope,
+			expiresAt,
+			lastUsedAt,
+		)
+	}
+
+	return nil
+}
+
+// usage prints command usage information to STDOUT.
+func (c *TokenListCommand) usage() {
+	fmt.Println(`
+List your API tokens. Only the first few characters of each token are shown.
+
+Usage:
+
+	wtf token list
+`[1:])
+}
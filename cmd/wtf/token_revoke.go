@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/benbjohnson/wtf"
+	"github.com/benbjohnson/wtf/http"
+)
+
+// TokenRevokeCommand represents a command for revoking API tokens.
+type TokenRevokeCommand struct {
+	ConfigPath string
+}
+
+// Run executes the command.
+func (c *TokenRevokeCommand) Run(ctx context.Context, args []string) error {
+	// Create flag set to parse the config path & read the ID.
+	fs := flag.NewFlagSet("wtf-token-revoke", flag.ContinueOnError)
+	attachConfigFlags(fs, &c.ConfigPath)
+	if err := fs.Parse(args); err != nil {
+		return err
+	} else if fs.NArg() == 0 {
+		return fmt.Errorf("Token ID required.")
+	} else if fs.NArg() > 1 {
+		return fmt.Errorf("Only one token ID allowed.")
+	}
+
+	// Parse the token ID from the first arg.
+	id, err := strconv.Atoi(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("Invalid token ID.")
+	}
+
+	// Load configuration file.
+	config, err := ReadConfigFile(c.ConfigPath)
+	if err != nil {
+		return err
+	}
+
+	// Authenticate user using the API key.
+	ctx = wtf.NewContextWithUser(ctx, &wtf.User{APIKey: config.APIKey})
+
+	// Instantiate HTTP service and issue revocation.
+	svc := http.NewAPITokenService(http.NewClient(config.URL))
+	if err := svc.DeleteAPIToken(ctx, id); err != nil {
+		return err
+	}
+
+	// Notify user that token is revoked.
+	fmt.Printf("Your token has been revoked.\n")
+
+	return nil
+}
+
+// usage prints the command usage information to STDOUT.
+func (c *TokenRevokeCommand) usage() {
+	fmt.Println(`
+Permanently revoke an API token.
+
+Usage:
+
+	wtf token revoke TOKEN_ID
+`[1:])
+}
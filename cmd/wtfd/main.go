@@ -11,11 +11,14 @@ import (
 	"os/user"
 	"path/filepath"
 	"strings"
+	"time"
+	_ "time/tzdata" // embed timezone database for user timezone preferences
 
 	"github.com/benbjohnson/wtf"
 	"github.com/benbjohnson/wtf/http"
 	"github.com/benbjohnson/wtf/http/html"
 	"github.com/benbjohnson/wtf/inmem"
+	"github.com/benbjohnson/wtf/smtp"
 	"github.com/benbjohnson/wtf/sqlite"
 	"github.com/pelletier/go-toml"
 	"github.com/rollbar/rollbar-go"
@@ -84,7 +87,11 @@ type Main struct {
 	HTTPServer *http.Server
 
 	// Services exposed for end-to-end tests.
-	UserService wtf.UserService
+	SessionService wtf.SessionService
+	UserService    wtf.UserService
+
+	// Stops background workers started by Run().
+	cancel func()
 }
 
 // NewMain returns a new instance of Main.
@@ -100,6 +107,9 @@ func NewMain() *Main {
 
 // Close gracefully stops the program.
 func (m *Main) Close() error {
+	if m.cancel != nil {
+		m.cancel()
+	}
 	if m.HTTPServer != nil {
 		if err := m.HTTPServer.Close(); err != nil {
 			return err
@@ -182,8 +192,17 @@ func (m *Main) Run(ctx context.Context) (err error) {
 	dialService := sqlite.NewDialService(m.DB)
 	dialMembershipService := sqlite.NewDialMembershipService(m.DB)
 	userService := sqlite.NewUserService(m.DB)
-
-	// Attach user service to Main for testing.
+	alertService := sqlite.NewAlertService(m.DB)
+	apiTokenService := sqlite.NewAPITokenService(m.DB)
+	webhookService := sqlite.NewWebhookService(m.DB)
+	incomingWebhookService := sqlite.NewIncomingWebhookService(m.DB)
+	sessionService := sqlite.NewSessionService(m.DB)
+	loginTokenService := sqlite.NewLoginTokenService(m.DB)
+	organizationService := sqlite.NewOrganizationService(m.DB)
+	searchService := sqlite.NewSearchService(m.DB)
+
+	// Attach user & session services to Main for testing.
+	m.SessionService = sessionService
 	m.UserService = userService
 
 	// Set global GA settings.
@@ -197,12 +216,43 @@ func (m *Main) Run(ctx context.Context) (err error) {
 	m.HTTPServer.GitHubClientID = m.Config.GitHub.ClientID
 	m.HTTPServer.GitHubClientSecret = m.Config.GitHub.ClientSecret
 
+	// Register OpenID Connect providers in addition to GitHub.
+	for _, c := range m.Config.OIDC {
+		p := http.NewOIDCProvider(c.Name, c.Issuer, c.ClientID, c.ClientSecret)
+		p.Title = c.Title
+		p.Scopes = c.Scopes
+		m.HTTPServer.AuthProviders = append(m.HTTPServer.AuthProviders, p)
+	}
+
+	// Enable email login links. Links are printed to STDOUT instead of being
+	// sent if no SMTP server is configured.
+	if m.Config.Email.Enabled {
+		if m.Config.Email.SMTPAddr == "" {
+			m.HTTPServer.Mailer = smtp.NewLogMailer()
+		} else if m.Config.Email.From == "" {
+			return fmt.Errorf("email from address required")
+		} else {
+			mailer := smtp.NewMailer(m.Config.Email.SMTPAddr, m.Config.Email.From)
+			mailer.Username = m.Config.Email.SMTPUsername
+			mailer.Password = m.Config.Email.SMTPPassword
+			m.HTTPServer.Mailer = mailer
+		}
+	}
+
 	// Attach underlying services to the HTTP server.
+	m.HTTPServer.AlertService = alertService
+	m.HTTPServer.APITokenService = apiTokenService
 	m.HTTPServer.AuthService = authService
 	m.HTTPServer.DialService = dialService
 	m.HTTPServer.DialMembershipService = dialMembershipService
 	m.HTTPServer.EventService = eventService
+	m.HTTPServer.IncomingWebhookService = incomingWebhookService
+	m.HTTPServer.LoginTokenService = loginTokenService
+	m.HTTPServer.OrganizationService = organizationService
+	m.HTTPServer.SearchService = searchService
+	m.HTTPServer.SessionService = sessionService
 	m.HTTPServer.UserService = userService
+	m.HTTPServer.WebhookService = webhookService
 
 	// Start the HTTP server.
 	if err := m.HTTPServer.Open(); err != nil {
@@ -216,6 +266,14 @@ func (m *Main) Run(ctx context.Context) (err error) {
 		}()
 	}
 
+	// Periodically evaluate alert rules so that duration-based rules can fire
+	// even when dial values are not changing.
+	ctx, m.cancel = context.WithCancel(ctx)
+	go m.monitorAlertRules(ctx, alertService)
+
+	// Send queued webhook deliveries & retry failed ones in the background.
+	go m.deliverWebhooks(ctx, http.NewWebhookDispatcher(webhookService))
+
 	// Enable internal debug endpoints.
 	go func() { http.ListenAndServeDebug() }()
 
@@ -224,6 +282,52 @@ func (m *Main) Run(ctx context.Context) (err error) {
 	return nil
 }
 
+// monitorAlertRules runs in a goroutine and evaluates all alert rules on an
+// interval until ctx is canceled.
+func (m *Main) monitorAlertRules(ctx context.Context, alertService wtf.AlertService) {
+	ticker := time.NewTicker(AlertRuleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := alertService.EvaluateAlertRules(ctx); err != nil {
+			wtf.ReportError(ctx, fmt.Errorf("evaluate alert rules: %w", err))
+		}
+	}
+}
+
+// deliverWebhooks runs in a goroutine and sends pending webhook deliveries on
+// an interval until ctx is canceled.
+func (m *Main) deliverWebhooks(ctx context.Context, dispatcher *http.WebhookDispatcher) {
+	ticker := time.NewTicker(WebhookDeliveryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := dispatcher.DeliverPending(ctx); err != nil {
+			wtf.ReportError(ctx, fmt.Errorf("deliver webhooks: %w", err))
+		}
+	}
+}
+
+const (
+	// AlertRuleInterval is the time between background evaluations of alert rules.
+	AlertRuleInterval = 1 * time.Minute
+
+	// WebhookDeliveryInterval is the time between checks for pending webhook deliveries.
+	WebhookDeliveryInterval = 5 * time.Second
+)
+
 const (
 	// DefaultConfigPath is the default path to the application configuration.
 	DefaultConfigPath = "~/wtfd.conf"
@@ -254,6 +358,25 @@ type Config struct {
 		ClientSecret string `toml:"client-secret"`
 	} `toml:"github"`
 
+	// OpenID Connect identity providers, in the order shown on the login page.
+	OIDC []struct {
+		Name         string   `toml:"name"`
+		Title        string   `toml:"title"`
+		Issuer       string   `toml:"issuer"`
+		ClientID     string   `toml:"client-id"`
+		ClientSecret string   `toml:"client-secret"`
+		Scopes       []string `toml:"scopes"`
+	} `toml:"oidc"`
+
+	// Email login settings. Login links are sent through the SMTP server.
+	Email struct {
+		Enabled      bool   `toml:"enabled"`
+		From         string `toml:"from"`
+		SMTPAddr     string `toml:"smtp-addr"`
+		SMTPUsername string `toml:"smtp-username"`
+		SMTPPassword string `toml:"smtp-password"`
+	} `toml:"email"`
+
 	Rollbar struct {
 		Token string `toml:"token"`
 	} `toml:"rollbar"`
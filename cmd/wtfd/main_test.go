@@ -57,9 +57,16 @@ func MustCreateUser(tb testing.TB, m *main.Main, user *wtf.User) (*wtf.User, con
 // to the browser. This approach is used to avoid OAuth communication with GitHub.
 func Login(ctx context.Context, m *main.Main) chromedp.Action {
 	return chromedp.ActionFunc(func(ctx context.Context) error {
+		// Create a server-side session for the user.
+		session := &wtf.Session{Device: "Test"}
+		if err := m.SessionService.CreateSession(ctx, session); err != nil {
+			return err
+		}
+
 		// Generate cookie value from the server.
 		value, err := m.HTTPServer.MarshalSession(http.Session{
-			UserID: wtf.UserIDFromContext(ctx),
+			UserID:    wtf.UserIDFromContext(ctx),
+			SessionID: session.ID,
 		})
 		if err != nil {
 			return err
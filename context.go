@@ -1,6 +1,9 @@
 package wtf
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // contextKey represents an internal key for adding context fields.
 // This is considered best practice as it prevents other packages from
@@ -19,6 +22,13 @@ const (
 	// related but both the "http" and "http/html" packages use it so it is
 	// easier to move it to the root.
 	flashContextKey
+
+	// Stores a location that overrides the current user's timezone. This is
+	// used by API callers to align reports to a specific timezone.
+	locationContextKey
+
+	// Stores the scope of the API token used to authenticate the request.
+	apiTokenScopeContextKey
 )
 
 // NewContextWithUser returns a new context with the given user.
@@ -51,3 +61,42 @@ func FlashFromContext(ctx context.Context) string {
 	v, _ := ctx.Value(flashContextKey).(string)
 	return v
 }
+
+// NewContextWithLocation returns a new context with the given location. This
+// location takes precedence over the current user's timezone.
+func NewContextWithLocation(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, locationContextKey, loc)
+}
+
+// LocationOverrideFromContext returns the location set on the context.
+// Returns nil if no location has been set.
+func LocationOverrideFromContext(ctx context.Context) *time.Location {
+	loc, _ := ctx.Value(locationContextKey).(*time.Location)
+	return loc
+}
+
+// LocationFromContext returns the location for the current request. Returns
+// the location set on the context, if any. Otherwise returns the location of
+// the current user's timezone. Defaults to UTC.
+func LocationFromContext(ctx context.Context) *time.Location {
+	if loc := LocationOverrideFromContext(ctx); loc != nil {
+		return loc
+	} else if user := UserFromContext(ctx); user != nil {
+		return user.Location()
+	}
+	return time.UTC
+}
+
+// NewContextWithAPITokenScope returns a new context with the scope of the API
+// token used to authenticate the current request.
+func NewContextWithAPITokenScope(ctx context.Context, scope string) context.Context {
+	return context.WithValue(ctx, apiTokenScopeContextKey, scope)
+}
+
+// APITokenScopeFromContext returns the scope of the API token used to
+// authenticate the current request. Returns blank if the request was not
+// authenticated with an API token.
+func APITokenScopeFromContext(ctx context.Context) string {
+	v, _ := ctx.Value(apiTokenScopeContextKey).(string)
+	return v
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/csv"
 	"io"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/benbjohnson/wtf"
@@ -26,6 +27,7 @@ func NewDialEncoder(w io.Writer) *DialEncoder {
 		"created_by",
 		"created_at",
 		"updated_at",
+		"tags",
 	})
 
 	return enc
@@ -46,5 +48,108 @@ func (enc *DialEncoder) EncodeDial(dial *wtf.Dial) error {
 		dial.User.Name,
 		dial.CreatedAt.Format(time.RFC3339),
 		dial.UpdatedAt.Format(time.RFC3339),
+		strings.Join(dial.Tags, ","),
 	})
 }
+
+// DialValueEncoder encodes historical dial values in CSV format to a writer.
+// Each record of a series is written as a separate row. Statistics columns are
+// left blank if the record does not include statistics.
+type DialValueEncoder struct {
+	w *csv.Writer
+}
+
+// NewDialValueEncoder returns a new instance of DialValueEncoder that writes to w.
+func NewDialValueEncoder(w io.Writer) *DialValueEncoder {
+	enc := &DialValueEncoder{w: csv.NewWriter(w)}
+
+	// Write header to underlying writer.
+	_ = enc.w.Write([]string{
+		"dial_id",
+		"name",
+		"timestamp",
+		"value",
+		"min",
+		"max",
+		"median",
+		"stddev",
+		"count",
+	})
+
+	return enc
+}
+
+// Close flushes the underlying writer.
+func (enc *DialValueEncoder) Close() error {
+	enc.w.Flush()
+	return enc.w.Error()
+}
+
+// EncodeSeries encodes a row for each record in series to the underlying CSV writer.
+func (enc *DialValueEncoder) EncodeSeries(series *wtf.DialValueSeries) error {
+	for _, record := range series.Records {
+		row := []string{
+			strconv.Itoa(series.DialID),
+			series.Name,
+			record.Timestamp.Format(time.RFC3339),
+			strconv.Itoa(record.Value),
+			"", "", "", "", "",
+		}
+		if stats := record.Stats; stats != nil {
+			row[4] = strconv.Itoa(stats.Min)
+			row[5] = strconv.Itoa(stats.Max)
+			row[6] = strconv.FormatFloat(stats.Median, 'f', -1, 64)
+			row[7] = strconv.FormatFloat(stats.StdDev, 'f', 2, 64)
+			row[8] = strconv.Itoa(stats.Count)
+		}
+
+		if err := enc.w.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DialHeatmapEncoder encodes a dial heatmap report in CSV format to a writer.
+// Each hour of each day of the week is written as a separate row.
+type DialHeatmapEncoder struct {
+	w *csv.Writer
+}
+
+// NewDialHeatmapEncoder returns a new instance of DialHeatmapEncoder that writes to w.
+func NewDialHeatmapEncoder(w io.Writer) *DialHeatmapEncoder {
+	enc := &DialHeatmapEncoder{w: csv.NewWriter(w)}
+
+	// Write header to underlying writer.
+	_ = enc.w.Write([]string{
+		"day",
+		"hour",
+		"value",
+		"count",
+	})
+
+	return enc
+}
+
+// Close flushes the underlying writer.
+func (enc *DialHeatmapEncoder) Close() error {
+	enc.w.Flush()
+	return enc.w.Error()
+}
+
+// Encode encodes a row for each cell of report to the underlying CSV writer.
+func (enc *DialHeatmapEncoder) Encode(report *wtf.DialHeatmapReport) error {
+	for day := range report.Values {
+		for hour := range report.Values[day] {
+			if err := enc.w.Write([]string{
+				time.Weekday(day).String(),
+				strconv.Itoa(hour),
+				strconv.FormatFloat(report.Values[day][hour], 'f', 2, 64),
+				strconv.Itoa(report.Counts[day][hour]),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
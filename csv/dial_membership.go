@@ -0,0 +1,62 @@
+package csv
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+)
+
+// DialMembershipValueEncoder encodes historical membership values in CSV
+// format to a writer. Each record of a series is written as a separate row.
+type DialMembershipValueEncoder struct {
+	w *csv.Writer
+}
+
+// NewDialMembershipValueEncoder returns a new instance of
+// DialMembershipValueEncoder that writes to w.
+func NewDialMembershipValueEncoder(w io.Writer) *DialMembershipValueEncoder {
+	enc := &DialMembershipValueEncoder{w: csv.NewWriter(w)}
+
+	// Write header to underlying writer.
+	_ = enc.w.Write([]string{
+		"dial_membership_id",
+		"dial_id",
+		"user_id",
+		"user",
+		"timestamp",
+		"value",
+	})
+
+	return enc
+}
+
+// Close flushes the underlying writer.
+func (enc *DialMembershipValueEncoder) Close() error {
+	enc.w.Flush()
+	return enc.w.Error()
+}
+
+// EncodeSeries encodes a row for each record in series to the underlying CSV writer.
+func (enc *DialMembershipValueEncoder) EncodeSeries(series *wtf.DialMembershipValueSeries) error {
+	var name string
+	if series.User != nil {
+		name = series.User.Name
+	}
+
+	for _, record := range series.Records {
+		if err := enc.w.Write([]string{
+			strconv.Itoa(series.DialMembershipID),
+			strconv.Itoa(series.DialID),
+			strconv.Itoa(series.UserID),
+			name,
+			record.Timestamp.Format(time.RFC3339),
+			strconv.Itoa(record.Value),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -3,6 +3,9 @@ package wtf
 import (
 	"context"
 	"fmt"
+	"math"
+	"sort"
+	"strings"
 	"time"
 	"unicode/utf8"
 )
@@ -10,14 +13,46 @@ import (
 // Dial constants.
 const (
 	MaxDialNameLen = 100
+	MaxDialTags    = 10
+	MaxDialTagLen  = 30
 )
 
+// Report constants. Reports are limited in size as every interval between the
+// start & end time is returned, even if the value did not change.
+const (
+	MinReportInterval = time.Minute
+	MaxReportSlots    = 10080 // one week of one minute intervals
+)
+
+// Dial aggregation modes. These determine how the WTF levels of each member
+// are combined into the overall WTF level of the dial.
+const (
+	DialAggregationMean        = "mean"
+	DialAggregationMedian      = "median"
+	DialAggregationMax         = "max"
+	DialAggregationPercentile  = "percentile"
+	DialAggregationTrimmedMean = "trimmed_mean"
+)
+
+// IsValidDialAggregation returns true if s is a supported aggregation mode.
+func IsValidDialAggregation(s string) bool {
+	switch s {
+	case DialAggregationMean, DialAggregationMedian, DialAggregationMax,
+		DialAggregationPercentile, DialAggregationTrimmedMean:
+		return true
+	default:
+		return false
+	}
+}
+
 // Dial represents an aggregate WTF level. They are used to roll up the WTF
 // levels of multiple members and show an average WTF level.
 //
-// A dial is created by a user and can only be edited & deleted by the user who
-// created it. Members can be added by sharing an invite link and accepting the
-// invitation.
+// A dial is created by a user who becomes its owner. The dial can be edited by
+// the owner & any admins but it can only be deleted by the owner. Members can
+// be added by sharing an invite link and accepting the invitation. Dials can
+// also belong to an organization, in which case every member of the
+// organization can see & join the dial without an invite link.
 //
 // The WTF level for the dial will immediately change when a member's WTF level
 // changes and the change will be announced to all other members in real-time.
@@ -26,21 +61,50 @@ const (
 type Dial struct {
 	ID int `json:"id"`
 
-	// Owner of the dial. Only the owner may delete the dial.
+	// Owner of the dial. Only the owner may delete or transfer the dial.
 	UserID int   `json:"userID"`
 	User   *User `json:"user"`
 
 	// Human-readable name of the dial.
 	Name string `json:"name"`
 
+	// Tags used to group & filter dials. Tags are normalized to lowercase,
+	// deduplicated & sorted when the dial is saved.
+	Tags []string `json:"tags,omitempty"`
+
+	// Organization that the dial belongs to, if any. This can only be set
+	// when the dial is created & the owner must be a member.
+	OrganizationID int           `json:"organizationID,omitempty"`
+	Organization   *Organization `json:"organization,omitempty"`
+
 	// Code used to share the dial with other users.
 	// It allows the creation of a shareable link without explicitly inviting users.
 	InviteCode string `json:"inviteCode,omitempty"`
 
+	// Optional limits on the invite code. The code stops working after the
+	// expiration time or once it has been used InviteMaxUses times. A zero
+	// max uses allows unlimited uses. Rotating the code resets the use count.
+	InviteExpiresAt *time.Time `json:"inviteExpiresAt,omitempty"`
+	InviteMaxUses   int        `json:"inviteMaxUses,omitempty"`
+	InviteUseCount  int        `json:"inviteUseCount,omitempty"`
+
 	// Aggregate WTF level for the dial. This is a computed field based on the
-	// average value of each member's WTF level.
+	// member WTF levels using the dial's aggregation mode.
 	Value int `json:"value"`
 
+	// Role of the current user within the dial. This is a computed field and
+	// is blank if the current user is not a member.
+	Role string `json:"role,omitempty"`
+
+	// Mode used to aggregate member values. Defaults to the mean.
+	//
+	// Percentile is only used by the "percentile" mode & is the percentile of
+	// member values to use. Trim is only used by the "trimmed_mean" mode & is
+	// the percentage of values dropped from each end before averaging.
+	Aggregation string `json:"aggregation"`
+	Percentile  int    `json:"percentile"`
+	Trim        int    `json:"trim"`
+
 	// Timestamps for dial creation & last update.
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
@@ -69,38 +133,184 @@ func (d *Dial) Validate() error {
 		return Errorf(EINVALID, "Dial name too long.")
 	} else if d.UserID == 0 {
 		return Errorf(EINVALID, "Dial creator required.")
+	} else if !IsValidDialAggregation(d.Aggregation) {
+		return Errorf(EINVALID, "Invalid dial aggregation.")
+	} else if d.Percentile < 0 || d.Percentile > 100 {
+		return Errorf(EINVALID, "Dial percentile must be between 0 & 100.")
+	} else if d.Trim < 0 || d.Trim >= 50 {
+		return Errorf(EINVALID, "Dial trim must be between 0 & 49.")
+	} else if d.InviteMaxUses < 0 {
+		return Errorf(EINVALID, "Invite max uses cannot be negative.")
+	} else if len(d.Tags) > MaxDialTags {
+		return Errorf(EINVALID, "Dials cannot have more than %d tags.", MaxDialTags)
+	}
+
+	for _, tag := range d.Tags {
+		if !IsValidDialTag(tag) {
+			return Errorf(EINVALID, "Invalid dial tag %q. Tags may only contain lowercase letters, numbers, dashes & underscores.", tag)
+		}
+	}
+	return nil
+}
+
+// IsValidDialTag returns true if tag is a non-blank, normalized tag that is
+// within the maximum tag length.
+func IsValidDialTag(tag string) bool {
+	if tag == "" || len(tag) > MaxDialTagLen {
+		return false
+	}
+	for _, ch := range tag {
+		if (ch < 'a' || ch > 'z') && (ch < '0' || ch > '9') && ch != '-' && ch != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeDialTag returns tag with surrounding whitespace removed and
+// converted to lowercase.
+func NormalizeDialTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// NormalizeDialTags returns a sorted list of normalized tags with blank &
+// duplicate tags removed. Returns nil if there are no tags.
+func NormalizeDialTags(tags []string) []string {
+	var a []string
+	for _, tag := range tags {
+		if tag = NormalizeDialTag(tag); tag != "" {
+			a = append(a, tag)
+		}
+	}
+	sort.Strings(a)
+
+	// Remove duplicates from the sorted list.
+	var other []string
+	for i, tag := range a {
+		if i == 0 || tag != a[i-1] {
+			other = append(other, tag)
+		}
+	}
+	return other
+}
+
+// HasTag returns true if the dial has the given tag.
+func (d *Dial) HasTag(tag string) bool {
+	for _, t := range d.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateInvite returns an error if the dial's invite code can no longer be
+// used to join the dial at the given time.
+func (d *Dial) ValidateInvite(now time.Time) error {
+	if d.InviteExpiresAt != nil && !now.Before(*d.InviteExpiresAt) {
+		return Errorf(EINVALID, "Invite code has expired.")
+	} else if d.InviteMaxUses > 0 && d.InviteUseCount >= d.InviteMaxUses {
+		return Errorf(EINVALID, "Invite code has reached its maximum number of uses.")
 	}
 	return nil
 }
 
+// AggregateValue returns the combined value of a set of member values based
+// on the dial's aggregation mode. Each value is counted as many times as its
+// corresponding weight. Returns zero if there are no weighted values.
+func (d *Dial) AggregateValue(values, weights []int) int {
+	// Expand values by weight & sort so the caller's slices are unchanged.
+	var a []int
+	for i, v := range values {
+		for j := 0; j < weights[i]; j++ {
+			a = append(a, v)
+		}
+	}
+	if len(a) == 0 {
+		return 0
+	}
+	sort.Ints(a)
+
+	switch d.Aggregation {
+	case DialAggregationMedian:
+		if len(a)%2 == 1 {
+			return a[len(a)/2]
+		}
+		return mean(a[len(a)/2-1 : len(a)/2+1])
+
+	case DialAggregationMax:
+		return a[len(a)-1]
+
+	case DialAggregationPercentile:
+		// Use the nearest-rank method so the result is always a member value.
+		rank := int(math.Ceil(float64(d.Percentile) / 100 * float64(len(a))))
+		if rank < 1 {
+			rank = 1
+		}
+		return a[rank-1]
+
+	case DialAggregationTrimmedMean:
+		n := len(a) * d.Trim / 100
+		return mean(a[n : len(a)-n])
+
+	default:
+		return mean(a)
+	}
+}
+
+// mean returns the rounded average of a non-empty list of values.
+func mean(a []int) int {
+	var sum int
+	for _, v := range a {
+		sum += v
+	}
+	return int(math.Round(float64(sum) / float64(len(a))))
+}
+
 // CanEditDial returns true if the current user can edit the dial.
-// Only the dial owner can edit the dial.
+// Only the dial owner & admins can edit the dial.
 func CanEditDial(ctx context.Context, dial *Dial) bool {
+	return dial.UserID == UserIDFromContext(ctx) || dial.Role == DialMembershipRoleAdmin
+}
+
+// CanDeleteDial returns true if the current user can delete the dial.
+// Only the dial owner can delete the dial.
+func CanDeleteDial(ctx context.Context, dial *Dial) bool {
+	return dial.UserID == UserIDFromContext(ctx)
+}
+
+// CanTransferDial returns true if the current user can transfer the dial to
+// another member. Only the dial owner can transfer the dial.
+func CanTransferDial(ctx context.Context, dial *Dial) bool {
 	return dial.UserID == UserIDFromContext(ctx)
 }
 
 // DialService represents a service for managing dials.
 type DialService interface {
 	// Retrieves a single dial by ID along with associated memberships. Only
-	// the dial owner & members can see a dial. Returns ENOTFOUND if dial does
-	// not exist or user does not have permission to view it.
+	// the dial owner, members & members of the dial's organization can see a
+	// dial. Returns ENOTFOUND if dial does not exist or user does not have
+	// permission to view it.
 	FindDialByID(ctx context.Context, id int) (*Dial, error)
 
 	// Retrieves a list of dials based on a filter. Only returns dials that
-	// the user owns or is a member of. Also returns a count of total matching
-	// dials which may different from the number of returned dials if the
-	// "Limit" field is set.
+	// the user owns or is a member of unless filtering by organization, in
+	// which case all of the organization's dials are returned to its members.
+	// Also returns a count of total matching dials which may different from
+	// the number of returned dials if the "Limit" field is set.
 	FindDials(ctx context.Context, filter DialFilter) ([]*Dial, int, error)
 
 	// Creates a new dial and assigns the current user as the owner.
 	// The owner will automatically be added as a member of the new dial.
+	// Returns EUNAUTHORIZED if the dial is created within an organization
+	// that the user is not a member of.
 	CreateDial(ctx context.Context, dial *Dial) error
 
-	// Updates an existing dial by ID. Only the dial owner can update a dial.
-	// Returns the new dial state even if there was an error during update.
+	// Updates an existing dial by ID. Only the dial owner & admins can update
+	// a dial. Returns the new dial state even if there was an error during update.
 	//
 	// Returns ENOTFOUND if dial does not exist. Returns EUNAUTHORIZED if user
-	// is not the dial owner.
+	// is not the dial owner or an admin.
 	UpdateDial(ctx context.Context, id int, upd DialUpdate) (*Dial, error)
 
 	// Permanently removes a dial by ID. Only the dial owner may delete a dial.
@@ -108,40 +318,194 @@ type DialService interface {
 	// is not the dial owner.
 	DeleteDial(ctx context.Context, id int) error
 
-	// Sets the value of the user's membership in a dial. This works the same
-	// as calling UpdateDialMembership() although it doesn't require that the
-	// user know their membership ID. Only the dial ID.
+	// Transfers ownership of a dial to another member of the dial. Only the
+	// dial owner may transfer a dial & the previous owner remains as an admin.
+	// Returns ENOTFOUND if dial does not exist. Returns EUNAUTHORIZED if user
+	// is not the dial owner. Returns EINVALID if the new owner is not a member.
+	TransferDial(ctx context.Context, id, userID int) (*Dial, error)
+
+	// Generates a new invite code for the dial with optional limits. The
+	// previous code stops working immediately. Only the dial owner & admins
+	// can rotate the invite code. Returns ENOTFOUND if dial does not exist.
+	// Returns EUNAUTHORIZED if user is not the dial owner or an admin.
+	RotateDialInvite(ctx context.Context, id int, opt DialInviteOptions) (*Dial, error)
+
+	// Sets the value of the user's membership in a dial along with an optional
+	// note. This works the same as calling UpdateDialMembership() although it
+	// doesn't require that the user know their membership ID. Only the dial ID.
 	//
 	// Returns ENOTFOUND if the membership does not exist.
-	SetDialMembershipValue(ctx context.Context, dialID, value int) error
+	SetDialMembershipValue(ctx context.Context, dialID, value int, note string) error
 
 	// AverageDialValueReport returns a report of the average dial value across
 	// all dials that the user is a member of. Average values are computed
 	// between start & end time and are slotted into given intervals. The
 	// minimum interval size is one minute.
 	AverageDialValueReport(ctx context.Context, start, end time.Time, interval time.Duration) (*DialValueReport, error)
+
+	// DialValueReport returns a report with a separate series of historical
+	// values for each of the given dials. Values are slotted into intervals
+	// between start & end time. If no dial IDs are specified then every dial
+	// that the user is a member of is included. Each series includes a
+	// summary of the whole range & records optionally include statistics
+	// about the values within each interval. Returns ENOTFOUND if a dial
+	// does not exist or the user is not a member of it.
+	DialValueReport(ctx context.Context, dialIDs []int, start, end time.Time, interval time.Duration, opt DialValueReportOptions) (*DialValueReport, error)
+
+	// DialHeatmapReport returns the average value of the given dials for each
+	// hour of each day of the week between start & end time. Hours & days are
+	// based on the user's timezone. If no dial IDs are specified then every
+	// dial that the user is a member of is included. Returns ENOTFOUND if a
+	// dial does not exist or the user is not a member of it.
+	DialHeatmapReport(ctx context.Context, dialIDs []int, start, end time.Time) (*DialHeatmapReport, error)
 }
 
+// Dial sort options. Only specific sorting options are supported.
+const (
+	DialSortByNameAsc       = "name_asc"
+	DialSortByValueAsc      = "value_asc"
+	DialSortByValueDesc     = "value_desc"
+	DialSortByUpdatedAtDesc = "updated_at_desc"
+)
+
 // DialFilter represents a filter used by FindDials().
 type DialFilter struct {
 	// Filtering fields.
-	ID         *int    `json:"id"`
-	InviteCode *string `json:"inviteCode"`
+	ID             *int    `json:"id"`
+	InviteCode     *string `json:"inviteCode"`
+	OrganizationID *int    `json:"organizationID"`
+	UserID         *int    `json:"userID"`
+	Tag            *string `json:"tag"`
+
+	// Case-insensitive substring match against the dial name.
+	Name *string `json:"name"`
+
+	// Inclusive range of the aggregate dial value.
+	MinValue *int `json:"minValue"`
+	MaxValue *int `json:"maxValue"`
 
 	// Restrict to subset of range.
 	Offset int `json:"offset"`
 	Limit  int `json:"limit"`
+
+	// Sorting option for results. Defaults to sorting by ID.
+	SortBy string `json:"sortBy"`
+}
+
+// DialInviteOptions represents the limits applied to a new invite code.
+type DialInviteOptions struct {
+	// Time after which the invite code can no longer be used. Optional.
+	ExpiresAt *time.Time `json:"expiresAt"`
+
+	// Maximum number of times the code can be used. Zero is unlimited.
+	MaxUses int `json:"maxUses"`
 }
 
 // DialUpdate represents a set of fields to update on a dial.
 type DialUpdate struct {
-	Name *string `json:"name"`
+	Name *string   `json:"name"`
+	Tags *[]string `json:"tags"`
+
+	// Aggregation settings. Changing these will recompute the dial value.
+	Aggregation *string `json:"aggregation"`
+	Percentile  *int    `json:"percentile"`
+	Trim        *int    `json:"trim"`
 }
 
-// DialValueReport represents a report generated by AverageDialValueReport().
-// Each record represents the average value within an interval of time.
+// TruncateReportTime returns t rounded down to a multiple of interval based on
+// the wall clock time in loc. This aligns daily & weekly intervals to midnight
+// in the given location instead of midnight UTC. Weekly intervals start on
+// Monday.
+func TruncateReportTime(t time.Time, interval time.Duration, loc *time.Location) time.Time {
+	// Truncate the wall clock time as if it were UTC & then interpret the
+	// result as a wall clock time in loc.
+	t = t.In(loc)
+	u := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC).Truncate(interval)
+	return time.Date(u.Year(), u.Month(), u.Day(), u.Hour(), u.Minute(), u.Second(), u.Nanosecond(), loc)
+}
+
+// ReportSlotTimes returns the start time of each interval between start & end.
+// Daily & weekly intervals are stepped by calendar days in loc so each slot
+// starts at local midnight even if the range crosses a daylight saving time
+// change. Shorter intervals are stepped by elapsed time.
+func ReportSlotTimes(start, end time.Time, interval time.Duration, loc *time.Location) []time.Time {
+	a := make([]time.Time, 0)
+	if interval <= 0 {
+		return a
+	}
+
+	for t := start; t.Before(end); {
+		a = append(a, t)
+		if days := int(interval / (24 * time.Hour)); interval%(24*time.Hour) == 0 {
+			t = t.In(loc).AddDate(0, 0, days).In(start.Location())
+		} else {
+			t = t.Add(interval)
+		}
+	}
+	return a
+}
+
+// ReportSlotIndex returns the index of the slot from ReportSlotTimes() which
+// contains t. Returns -1 if t is before the first slot.
+func ReportSlotIndex(slots []time.Time, t time.Time) int {
+	return sort.Search(len(slots), func(i int) bool { return slots[i].After(t) }) - 1
+}
+
+// ValidateReportRange returns an error if interval is smaller than the minimum
+// report interval or if the time range contains too many intervals.
+func ValidateReportRange(start, end time.Time, interval time.Duration) error {
+	if interval < MinReportInterval {
+		return Errorf(EINVALID, "Report interval must be at least one minute.")
+	} else if end.Sub(start)/interval > MaxReportSlots {
+		return Errorf(EINVALID, "Report range contains too many intervals.")
+	}
+	return nil
+}
+
+// DialValueReport represents a report generated by AverageDialValueReport()
+// or DialValueReport(). For the average report, each record represents the
+// average value within an interval of time. For the per-dial report, each
+// dial has its own series of records instead.
 type DialValueReport struct {
-	Records []*DialValueRecord
+	Records []*DialValueRecord `json:"records"`
+	Series  []*DialValueSeries `json:"series,omitempty"`
+}
+
+// DialValueReportOptions represents options used by DialValueReport().
+type DialValueReportOptions struct {
+	// If true, each record includes statistics about the values within its interval.
+	Stats bool `json:"stats"`
+
+	// If set, the series summary includes the time spent above this value.
+	Threshold *int `json:"threshold"`
+}
+
+// DialValueSeries represents the value of a single dial within each interval
+// of a DialValueReport.
+type DialValueSeries struct {
+	DialID  int                `json:"dialID"`
+	Name    string             `json:"name"`
+	Summary *DialValueSummary  `json:"summary,omitempty"`
+	Records []*DialValueRecord `json:"records"`
+}
+
+// DialHeatmapReport represents a report generated by DialHeatmapReport(). Each
+// cell is indexed by the day of the week (Sunday is zero) & the hour of the day.
+type DialHeatmapReport struct {
+	// Average dial value within each hour. Zero if there are no samples.
+	Values [7][24]float64 `json:"values"`
+
+	// Number of hourly samples used to compute each average.
+	Counts [7][24]int `json:"counts"`
+}
+
+// Add adds an hourly value at the given time to the report & updates the
+// running average of the matching cell.
+func (r *DialHeatmapReport) Add(t time.Time, value int) {
+	day, hour := t.Weekday(), t.Hour()
+	n := r.Counts[day][hour]
+	r.Values[day][hour] = (r.Values[day][hour]*float64(n) + float64(value)) / float64(n+1)
+	r.Counts[day][hour] = n + 1
 }
 
 // DialValueRecord represents an average dial value at a given point in time
@@ -149,6 +513,101 @@ type DialValueReport struct {
 type DialValueRecord struct {
 	Value     int       `json:"value"`
 	Timestamp time.Time `json:"timestamp"`
+
+	// Statistics about the values within the interval. Only set if requested.
+	Stats *DialValueStats `json:"stats,omitempty"`
+}
+
+// DialValueStats represents statistics about the values of a dial within a
+// single interval. The samples include the value at the start of the interval
+// & every value the dial changed to within the interval.
+type DialValueStats struct {
+	Min    int     `json:"min"`
+	Max    int     `json:"max"`
+	Median float64 `json:"median"`
+	StdDev float64 `json:"stddev"`
+	Count  int     `json:"count"`
+}
+
+// NewDialValueStats returns statistics computed from a list of samples.
+// Returns nil if there are no samples.
+func NewDialValueStats(samples []int) *DialValueStats {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	// Sort a copy so the caller's samples are unchanged.
+	a := make([]int, len(samples))
+	copy(a, samples)
+	sort.Ints(a)
+
+	stats := &DialValueStats{Min: a[0], Max: a[len(a)-1], Count: len(a)}
+	if len(a)%2 == 1 {
+		stats.Median = float64(a[len(a)/2])
+	} else {
+		stats.Median = float64(a[len(a)/2-1]+a[len(a)/2]) / 2
+	}
+
+	// Compute population standard deviation from the mean.
+	var sum float64
+	for _, v := range a {
+		sum += float64(v)
+	}
+	avg := sum / float64(len(a))
+
+	var variance float64
+	for _, v := range a {
+		variance += (float64(v) - avg) * (float64(v) - avg)
+	}
+	stats.StdDev = math.Sqrt(variance / float64(len(a)))
+
+	return stats
+}
+
+// DialValueSummary represents a summary of a dial's value over the whole
+// time range of a DialValueReport.
+type DialValueSummary struct {
+	// Mean of the values of each interval.
+	Mean float64 `json:"mean"`
+
+	// Highest interval value & the start of the first interval it occurred in.
+	PeakValue int       `json:"peakValue"`
+	PeakTime  time.Time `json:"peakTime"`
+
+	// Threshold used & total time of the intervals above it. These are only
+	// set if a threshold is specified in the report options.
+	Threshold          *int          `json:"threshold,omitempty"`
+	TimeAboveThreshold time.Duration `json:"timeAboveThreshold,omitempty"`
+}
+
+// NewDialValueSummary returns a summary of a series of records slotted by
+// interval. If threshold is set then the time above the threshold is also
+// computed. Returns nil if there are no records.
+func NewDialValueSummary(records []*DialValueRecord, interval time.Duration, threshold *int) *DialValueSummary {
+	if len(records) == 0 {
+		return nil
+	}
+
+	summary := &DialValueSummary{
+		PeakValue: records[0].Value,
+		PeakTime:  records[0].Timestamp,
+		Threshold: threshold,
+	}
+
+	var sum int
+	for _, record := range records {
+		sum += record.Value
+
+		if record.Value > summary.PeakValue {
+			summary.PeakValue, summary.PeakTime = record.Value, record.Timestamp
+		}
+		if threshold != nil && record.Value > *threshold {
+			summary.TimeAboveThreshold += interval
+		}
+	}
+	summary.Mean = float64(sum) / float64(len(records))
+
+	return summary
 }
 
 // GoString prints a more easily readable representation for debugging.
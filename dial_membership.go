@@ -3,13 +3,44 @@ package wtf
 import (
 	"context"
 	"time"
+	"unicode/utf8"
 )
 
+// Dial membership constants.
+const (
+	DefaultDialMembershipWeight = 1
+	MaxDialMembershipWeight     = 10
+
+	MaxDialMembershipNoteLen = 140
+)
+
+// Dial membership roles. The owner is the user who owns the dial & there is
+// only one per dial. Admins can edit the dial & manage other members. Members
+// contribute a WTF level to the dial. Viewers can watch the dial but do not
+// contribute a value.
+const (
+	DialMembershipRoleOwner  = "owner"
+	DialMembershipRoleAdmin  = "admin"
+	DialMembershipRoleMember = "member"
+	DialMembershipRoleViewer = "viewer"
+)
+
+// IsValidDialMembershipRole returns true if s is a supported membership role.
+func IsValidDialMembershipRole(s string) bool {
+	switch s {
+	case DialMembershipRoleOwner, DialMembershipRoleAdmin,
+		DialMembershipRoleMember, DialMembershipRoleViewer:
+		return true
+	default:
+		return false
+	}
+}
+
 // DialMembership represents a contributor to a Dial. Each membership is
 // aggregated to determine the total WTF value of the parent dial.
 //
 // All members can view all other member's values in the dial. However, only the
-// membership owner can edit the membership value.
+// membership owner can edit the membership value & only if they are not a viewer.
 type DialMembership struct {
 	ID int `json:"id"`
 
@@ -25,27 +56,70 @@ type DialMembership struct {
 	// Updating this value will cause the parent dial's WTF level to be recomputed.
 	Value int `json:"value"`
 
+	// Optional short note explaining the most recent value change. Each change
+	// replaces the note & is recorded in the membership's value history.
+	Note string `json:"note,omitempty"`
+
+	// Relative weight of the membership value within the dial. A weight of 2
+	// counts double & a weight of 0 does not count at all. Only the dial owner
+	// & admins can change the weight.
+	Weight int `json:"weight"`
+
+	// Role of the member within the dial. Determines what the member can do
+	// with the dial & whether their value counts toward the dial value.
+	Role string `json:"role"`
+
+	// Invite code used to join the dial. When set on creation, the code is
+	// verified against the dial & counts toward the invite's maximum uses.
+	// This is blank for the dial owner's membership.
+	InviteCode string `json:"inviteCode,omitempty"`
+
 	// Timestamps for membership creation & last update.
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
 // CanEditDialMembership returns true if the current user can edit membership.
+// Viewers cannot set a value so they cannot edit their membership.
 func CanEditDialMembership(ctx context.Context, membership *DialMembership) bool {
-	return membership.UserID == UserIDFromContext(ctx)
+	return membership.UserID == UserIDFromContext(ctx) && membership.Role != DialMembershipRoleViewer
+}
+
+// CanEditDialMembershipWeight returns true if the current user can change the
+// weight of the membership. Only the dial owner & admins can change weights.
+func CanEditDialMembershipWeight(ctx context.Context, membership *DialMembership) bool {
+	return membership.Dial != nil && CanEditDial(ctx, membership.Dial)
+}
+
+// CanEditDialMembershipRole returns true if the current user can change the
+// role of the membership to role. The dial owner can change any other
+// membership. Admins can only move other users between member & viewer.
+func CanEditDialMembershipRole(ctx context.Context, membership *DialMembership, role string) bool {
+	if membership.Dial == nil || membership.Role == DialMembershipRoleOwner {
+		return false
+	} else if membership.Dial.UserID == UserIDFromContext(ctx) {
+		return true
+	} else if membership.Dial.Role != DialMembershipRoleAdmin {
+		return false
+	}
+	return membership.Role != DialMembershipRoleAdmin && role != DialMembershipRoleAdmin
 }
 
 // CanDeleteDialMembership returns true if the current user can delete membership.
 func CanDeleteDialMembership(ctx context.Context, membership *DialMembership) bool {
 	userID := UserIDFromContext(ctx)
-	if membership.Dial != nil {
-		if membership.Dial.UserID == membership.UserID {
-			return false // dial owner cannot delete membership
-		} else if membership.Dial.UserID == userID {
-			return true // dial owner can delete other memberships
-		}
+	if membership.Role == DialMembershipRoleOwner {
+		return false // dial owner cannot delete membership
+	} else if membership.UserID == userID {
+		return true // non-dial owner can delete own membership
+	} else if membership.Dial == nil {
+		return false
+	} else if membership.Dial.UserID == userID {
+		return true // dial owner can delete other memberships
 	}
-	return membership.UserID == userID // non-dial owner can delete own membership
+
+	// Admins can remove members & viewers but not other admins.
+	return membership.Dial.Role == DialMembershipRoleAdmin && membership.Role != DialMembershipRoleAdmin
 }
 
 // Validate returns an error if membership fields are invalid.
@@ -57,6 +131,12 @@ func (m *DialMembership) Validate() error {
 		return Errorf(EINVALID, "User required for membership.")
 	} else if m.Value < 0 || m.Value > 100 {
 		return Errorf(EINVALID, "Dial value must be between 0 & 100.")
+	} else if utf8.RuneCountInString(m.Note) > MaxDialMembershipNoteLen {
+		return Errorf(EINVALID, "Dial membership note too long.")
+	} else if m.Weight < 0 || m.Weight > MaxDialMembershipWeight {
+		return Errorf(EINVALID, "Dial membership weight must be between 0 & 10.")
+	} else if !IsValidDialMembershipRole(m.Role) {
+		return Errorf(EINVALID, "Invalid dial membership role.")
 	}
 	return nil
 }
@@ -75,17 +155,39 @@ type DialMembershipService interface {
 	FindDialMemberships(ctx context.Context, filter DialMembershipFilter) ([]*DialMembership, int, error)
 
 	// Creates a new membership on a dial for the current user. Returns
-	// EUNAUTHORIZED if there is no current user logged in.
+	// EUNAUTHORIZED if there is no current user logged in. Returns EINVALID
+	// if the invite code is invalid, expired, or has been used up. Returns
+	// ENOTFOUND if no invite code is given & the dial does not belong to one
+	// of the user's organizations.
 	CreateDialMembership(ctx context.Context, membership *DialMembership) error
 
-	// Updates the value of a membership. Only the owner of the membership can
-	// update the value. Returns EUNAUTHORIZED if user is not the owner. Returns
-	// ENOTFOUND if the membership does not exist.
+	// Updates the value of a membership along with an optional note. Only the
+	// owner of the membership can update the value. Returns EUNAUTHORIZED if user is not the owner or is a
+	// viewer. Returns ENOTFOUND if the membership does not exist.
 	UpdateDialMembership(ctx context.Context, id int, upd DialMembershipUpdate) (*DialMembership, error)
 
+	// Sets the weight of a membership. Only the owner & admins of the parent
+	// dial can set the weight. Returns EUNAUTHORIZED if user cannot manage the
+	// dial. Returns ENOTFOUND if the membership does not exist.
+	SetDialMembershipWeight(ctx context.Context, id, weight int) (*DialMembership, error)
+
+	// Sets the role of a membership. The owner role cannot be assigned or
+	// removed as the dial must be transferred instead. Returns EUNAUTHORIZED
+	// if user cannot change the role. Returns ENOTFOUND if the membership
+	// does not exist.
+	SetDialMembershipRole(ctx context.Context, id int, role string) (*DialMembership, error)
+
 	// Permanently deletes a membership by ID. Only the membership owner and
-	// the parent dial's owner can delete a membership.
+	// the parent dial's owner & admins can delete a membership. The dial
+	// owner's membership cannot be deleted.
 	DeleteDialMembership(ctx context.Context, id int) error
+
+	// DialMembershipValueReport returns the historical values of a single
+	// membership or of every membership on a dial. Values are slotted into
+	// intervals between start & end time. The minimum interval size is one
+	// minute. Returns ENOTFOUND if the membership or dial does not exist or
+	// the user is not a member of its dial.
+	DialMembershipValueReport(ctx context.Context, filter DialMembershipValueReportFilter, start, end time.Time, interval time.Duration) (*DialMembershipValueReport, error)
 }
 
 // Dial membership sort options. Only specific sorting options are supported.
@@ -107,7 +209,42 @@ type DialMembershipFilter struct {
 	SortBy string `json:"sortBy"`
 }
 
+// DialMembershipValueReportFilter represents a filter used by
+// DialMembershipValueReport(). Either a membership ID or dial ID is required.
+type DialMembershipValueReportFilter struct {
+	ID     *int `json:"id"`
+	DialID *int `json:"dialID"`
+}
+
+// Validate returns an error if the filter does not specify a membership or dial.
+func (f *DialMembershipValueReportFilter) Validate() error {
+	if f.ID == nil && f.DialID == nil {
+		return Errorf(EINVALID, "Dial membership or dial required for report.")
+	}
+	return nil
+}
+
+// DialMembershipValueReport represents a report generated by
+// DialMembershipValueReport(). Each series holds the values of one membership.
+type DialMembershipValueReport struct {
+	Series []*DialMembershipValueSeries `json:"series"`
+}
+
+// DialMembershipValueSeries represents the value of a membership within each
+// interval of a DialMembershipValueReport.
+type DialMembershipValueSeries struct {
+	DialMembershipID int   `json:"dialMembershipID"`
+	DialID           int   `json:"dialID"`
+	UserID           int   `json:"userID"`
+	User             *User `json:"user"`
+
+	Records []*DialValueRecord `json:"records"`
+}
+
 // DialMembershipUpdate represents a set of fields to update on a membership.
 type DialMembershipUpdate struct {
 	Value *int `json:"value"`
+
+	// Optional reason for the change. Replaces any note from a previous change.
+	Note *string `json:"note"`
 }
@@ -8,12 +8,14 @@ import (
 const (
 	EventTypeDialValueChanged           = "dial:value_changed"
 	EventTypeDialMembershipValueChanged = "dial_membership:value_changed"
+	EventTypeAlertFired                 = "alert:fired"
+	EventTypeAlertResolved              = "alert:resolved"
 )
 
-// Event represents an event that occurs in the system. Currently there are only
-// events for changes to a dial value or membership value. These events are
-// eventually propagated out to connected users via WebSockets whenever changes
-// occur so that the UI can update in real-time.
+// Event represents an event that occurs in the system. Currently there are
+// events for changes to a dial value or membership value & for alerts firing or
+// resolving. These events are eventually propagated out to connected users via
+// WebSockets whenever changes occur so that the UI can update in real-time.
 type Event struct {
 	// Specifies the type of event that is occurring.
 	Type string `json:"type"`
@@ -32,8 +34,18 @@ type DialValueChangedPayload struct {
 // DialMembershipValueChangedPayload represents the payload for an Event object
 // with a type of EventTypeDialMembershipValueChanged.
 type DialMembershipValueChangedPayload struct {
-	ID    int `json:"id"`
-	Value int `json:"value"`
+	ID    int    `json:"id"`
+	Value int    `json:"value"`
+	Note  string `json:"note,omitempty"`
+}
+
+// AlertPayload represents the payload for an Event object with a type of
+// EventTypeAlertFired or EventTypeAlertResolved.
+type AlertPayload struct {
+	ID          int `json:"id"`
+	AlertRuleID int `json:"alertRuleID"`
+	DialID      int `json:"dialID"`
+	Value       int `json:"value"`
 }
 
 // EventService represents a service for managing event dispatch and event
@@ -0,0 +1,385 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+	"github.com/gorilla/mux"
+)
+
+// registerAlertRoutes is a helper function for registering alert routes.
+func (s *Server) registerAlertRoutes(r *mux.Router) {
+	// API endpoints for listing & creating alert rules. Rules can also be
+	// created via the HTML form on the dial view page.
+	r.HandleFunc("/alert-rules", s.handleAlertRuleIndex).Methods("GET")
+	r.HandleFunc("/alert-rules", s.handleAlertRuleCreate).Methods("POST")
+
+	// View & remove a single alert rule.
+	r.HandleFunc("/alert-rules/{id}", s.handleAlertRuleView).Methods("GET")
+	r.HandleFunc("/alert-rules/{id}", s.handleAlertRuleDelete).Methods("DELETE")
+
+	// History of fired alerts.
+	r.HandleFunc("/alerts", s.handleAlertIndex).Methods("GET")
+}
+
+// handleAlertRuleIndex handles the "GET /alert-rules" route. This route
+// accepts an optional JSON filter and returns all matching rules on dials
+// that the current user is a member of.
+func (s *Server) handleAlertRuleIndex(w http.ResponseWriter, r *http.Request) {
+	// Force application/json output.
+	r.Header.Set("Accept", "application/json")
+
+	// Parse optional filter object.
+	var filter wtf.AlertRuleFilter
+	switch r.Header.Get("Content-type") {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&filter); err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid JSON body"))
+			return
+		}
+	default:
+		filter.Offset, _ = strconv.Atoi(r.URL.Query().Get("offset"))
+		filter.Limit = 20
+	}
+
+	// Fetch rules from database.
+	rules, n, err := s.AlertService.FindAlertRules(r.Context(), filter)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Write rules & total count as JSON response.
+	w.Header().Set("Content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(findAlertRulesResponse{
+		AlertRules: rules,
+		N:          n,
+	}); err != nil {
+		LogError(r, err)
+		return
+	}
+}
+
+// findAlertRulesResponse represents the output JSON struct for "GET /alert-rules".
+type findAlertRulesResponse struct {
+	AlertRules []*wtf.AlertRule `json:"alertRules"`
+	N          int              `json:"n"`
+}
+
+// handleAlertRuleView handles the "GET /alert-rules/:id" route.
+func (s *Server) handleAlertRuleView(w http.ResponseWriter, r *http.Request) {
+	// Parse rule ID from URL path.
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid ID format"))
+		return
+	}
+
+	// Force application/json output.
+	r.Header.Set("Accept", "application/json")
+
+	// Fetch rule from the database.
+	rule, err := s.AlertService.FindAlertRuleByID(r.Context(), id)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Write rule as JSON response.
+	w.Header().Set("Content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(rule); err != nil {
+		LogError(r, err)
+		return
+	}
+}
+
+// handleAlertRuleCreate handles the "POST /alert-rules" route. It reads the
+// rule as JSON or from the HTML form on the dial view page. The form specifies
+// the duration as a string (e.g. "10m").
+func (s *Server) handleAlertRuleCreate(w http.ResponseWriter, r *http.Request) {
+	var rule wtf.AlertRule
+	switch r.Header.Get("Content-type") {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid JSON body"))
+			return
+		}
+	default:
+		rule.DialID, _ = strconv.Atoi(r.PostFormValue("dialID"))
+		rule.Condition = r.PostFormValue("condition")
+		rule.Threshold, _ = strconv.Atoi(r.PostFormValue("threshold"))
+		if v := r.PostFormValue("duration"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid alert duration format"))
+				return
+			}
+			rule.Duration = d
+		}
+	}
+
+	// Create rule in the database.
+	if err := s.AlertService.CreateAlertRule(r.Context(), &rule); err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Render output to the client based on HTTP accept header.
+	switch r.Header.Get("Accept") {
+	case "application/json":
+		w.Header().Set("Content-type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(rule); err != nil {
+			LogError(r, err)
+			return
+		}
+
+	default:
+		SetFlash(w, "Alert rule successfully created.")
+		http.Redirect(w, r, fmt.Sprintf("/dials/%d", rule.DialID), http.StatusFound)
+	}
+}
+
+// handleAlertRuleDelete handles the "DELETE /alert-rules/:id" route. This
+// route deletes the rule & its history and redirects back to the dial.
+func (s *Server) handleAlertRuleDelete(w http.ResponseWriter, r *http.Request) {
+	// Parse rule ID from the URL.
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid ID format"))
+		return
+	}
+
+	// Look up rule by ID so we know which dial to redirect back to.
+	rule, err := s.AlertService.FindAlertRuleByID(r.Context(), id)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Delete rule.
+	if err := s.AlertService.DeleteAlertRule(r.Context(), id); err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Render output to the client based on HTTP accept header.
+	switch r.Header.Get("Accept") {
+	case "application/json":
+		w.Header().Set("Content-type", "application/json")
+		w.Write([]byte(`{}`))
+
+	default:
+		SetFlash(w, "Alert rule successfully deleted.")
+		http.Redirect(w, r, fmt.Sprintf("/dials/%d", rule.DialID), http.StatusFound)
+	}
+}
+
+// handleAlertIndex handles the "GET /alerts" route. This route accepts an
+// optional JSON filter and returns matching alerts, most recent first.
+func (s *Server) handleAlertIndex(w http.ResponseWriter, r *http.Request) {
+	// Force application/json output.
+	r.Header.Set("Accept", "application/json")
+
+	// Parse optional filter object.
+	var filter wtf.AlertFilter
+	switch r.Header.Get("Content-type") {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&filter); err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid JSON body"))
+			return
+		}
+	default:
+		filter.Offset, _ = strconv.Atoi(r.URL.Query().Get("offset"))
+		filter.Limit = 20
+	}
+
+	// Fetch alerts from database.
+	alerts, n, err := s.AlertService.FindAlerts(r.Context(), filter)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Write alerts & total count as JSON response.
+	w.Header().Set("Content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(findAlertsResponse{
+		Alerts: alerts,
+		N:      n,
+	}); err != nil {
+		LogError(r, err)
+		return
+	}
+}
+
+// findAlertsResponse represents the output JSON struct for "GET /alerts".
+type findAlertsResponse struct {
+	Alerts []*wtf.Alert `json:"alerts"`
+	N      int          `json:"n"`
+}
+
+// AlertService implements the wtf.AlertService over the HTTP protocol.
+type AlertService struct {
+	Client *Client
+}
+
+// NewAlertService returns a new instance of AlertService.
+func NewAlertService(client *Client) *AlertService {
+	return &AlertService{Client: client}
+}
+
+// FindAlertRuleByID retrieves a single alert rule by ID along with the
+// associated dial. Returns ENOTFOUND if rule does not exist or user is not a
+// member of the rule's dial.
+func (s *AlertService) FindAlertRuleByID(ctx context.Context, id int) (*wtf.AlertRule, error) {
+	// Create request with API key attached.
+	req, err := s.Client.newRequest(ctx, "GET", fmt.Sprintf("/alert-rules/%d", id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Issue request. If any other status besides 200, then treats as an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal the returned rule data.
+	var rule wtf.AlertRule
+	if err := json.NewDecoder(resp.Body).Decode(&rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// FindAlertRules retrieves a list of alert rules by filter. Only returns rules
+// on dials that the current user is a member of. Also returns a count of total
+// matching rules which may differ if "Limit" is specified on the filter.
+func (s *AlertService) FindAlertRules(ctx context.Context, filter wtf.AlertRuleFilter) ([]*wtf.AlertRule, int, error) {
+	// Marshal filter into JSON format.
+	body, err := json.Marshal(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "GET", "/alert-rules", bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Issue request. Any non-200 status code is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, 0, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal result set of rules & total rule count.
+	var jsonResponse findAlertRulesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&jsonResponse); err != nil {
+		return nil, 0, err
+	}
+	return jsonResponse.AlertRules, jsonResponse.N, nil
+}
+
+// CreateAlertRule creates a new alert rule on a dial. Returns EUNAUTHORIZED if
+// the user is not the owner or an admin of the dial.
+func (s *AlertService) CreateAlertRule(ctx context.Context, rule *wtf.AlertRule) error {
+	// Marshal rule data into JSON format.
+	body, err := json.Marshal(rule)
+	if err != nil {
+		return err
+	}
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "POST", "/alert-rules", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	// Issue request. Treat non-201 status codes as errors.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusCreated {
+		return parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal returned rule data.
+	if err := json.NewDecoder(resp.Body).Decode(&rule); err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteAlertRule permanently removes an alert rule & its alert history.
+// Returns EUNAUTHORIZED if the user is not the owner or an admin of the dial.
+func (s *AlertService) DeleteAlertRule(ctx context.Context, id int) error {
+	// Create a request with API key.
+	req, err := s.Client.newRequest(ctx, "DELETE", fmt.Sprintf("/alert-rules/%d", id), nil)
+	if err != nil {
+		return err
+	}
+
+	// Issue request. Any non-200 response is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// FindAlerts retrieves a list of alerts by filter, most recent first. Only
+// returns alerts on dials that the current user is a member of.
+func (s *AlertService) FindAlerts(ctx context.Context, filter wtf.AlertFilter) ([]*wtf.Alert, int, error) {
+	// Marshal filter into JSON format.
+	body, err := json.Marshal(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "GET", "/alerts", bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Issue request. Any non-200 status code is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, 0, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal result set of alerts & total alert count.
+	var jsonResponse findAlertsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&jsonResponse); err != nil {
+		return nil, 0, err
+	}
+	return jsonResponse.Alerts, jsonResponse.N, nil
+}
+
+// EvaluateAlertRules is not implemented by the HTTP client. Rules are
+// evaluated by the background worker running on the server.
+func (s *AlertService) EvaluateAlertRules(ctx context.Context) error {
+	return wtf.Errorf(wtf.ENOTIMPLEMENTED, "Not implemented.")
+}
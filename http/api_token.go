@@ -0,0 +1,319 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+	"github.com/benbjohnson/wtf/http/html"
+	"github.com/gorilla/mux"
+)
+
+// registerAPITokenRoutes is a helper function for registering API token routes.
+func (s *Server) registerAPITokenRoutes(r *mux.Router) {
+	// API endpoints for listing & creating the current user's tokens. Tokens
+	// can also be created via the HTML form on the settings page.
+	r.HandleFunc("/api-tokens", s.handleAPITokenIndex).Methods("GET")
+	r.HandleFunc("/api-tokens", s.handleAPITokenCreate).Methods("POST")
+
+	// Revoke a single token.
+	r.HandleFunc("/api-tokens/{id}", s.handleAPITokenDelete).Methods("DELETE")
+}
+
+// apiTokenReadRoutes are the read-only routes that can be accessed by tokens
+// with any scope. Routes which return credentials, such as API tokens,
+// sessions, webhook secrets & incoming webhook tokens, are excluded so that
+// they require the "admin" scope.
+var apiTokenReadRoutes = map[string]string{
+	"/dials":                   http.MethodGet,
+	"/dials/report":            http.MethodGet,
+	"/dials/heatmap":           http.MethodGet,
+	"/dials/{id}":              http.MethodGet,
+	"/dials/{id}/report":       http.MethodGet,
+	"/dials/{id}/heatmap":      http.MethodGet,
+	"/dial-memberships":        http.MethodGet,
+	"/dial-memberships/report": http.MethodGet,
+	"/dial-memberships/{id}":   http.MethodGet,
+	"/alert-rules":             http.MethodGet,
+	"/alert-rules/{id}":        http.MethodGet,
+	"/alerts":                  http.MethodGet,
+	"/orgs":                    http.MethodGet,
+	"/orgs/{id}":               http.MethodGet,
+	"/search":                  http.MethodGet,
+	"/users/me":                http.MethodGet,
+	"/users/{id}":              http.MethodGet,
+}
+
+// apiTokenSetValueRoutes are the routes, in addition to read-only routes,
+// that can be accessed by a token with the "set-value" scope.
+var apiTokenSetValueRoutes = map[string]string{
+	"/dials/{id}/membership": http.MethodPut,
+	"/dial-memberships/{id}": http.MethodPatch,
+}
+
+// apiTokenScopeAllows returns true if a token with the given scope can be
+// used for the request. Read-only routes are allowed for all scopes.
+func apiTokenScopeAllows(scope string, r *http.Request) bool {
+	if scope == wtf.APITokenScopeAdmin {
+		return true
+	}
+
+	// Determine the matched route so that path variables are ignored.
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return false
+	}
+	tmpl, err := route.GetPathTemplate()
+	if err != nil {
+		return false
+	}
+
+	switch scope {
+	case wtf.APITokenScopeSetValue:
+		if apiTokenSetValueRoutes[tmpl] == r.Method {
+			return true
+		}
+		fallthrough
+	case wtf.APITokenScopeRead:
+		return apiTokenReadRoutes[tmpl] == r.Method
+	default:
+		return false
+	}
+}
+
+// handleAPITokenIndex handles the "GET /api-tokens" route. This route accepts
+// an optional JSON filter and returns the current user's matching tokens.
+func (s *Server) handleAPITokenIndex(w http.ResponseWriter, r *http.Request) {
+	// Force application/json output.
+	r.Header.Set("Accept", "application/json")
+
+	// Parse optional filter object.
+	var filter wtf.APITokenFilter
+	switch r.Header.Get("Content-type") {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&filter); err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid JSON body"))
+			return
+		}
+	default:
+		filter.Offset, _ = strconv.Atoi(r.URL.Query().Get("offset"))
+		filter.Limit = 20
+	}
+
+	// Fetch tokens from database.
+	tokens, n, err := s.APITokenService.FindAPITokens(r.Context(), filter)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Write tokens & total count as JSON response.
+	w.Header().Set("Content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(findAPITokensResponse{
+		APITokens: tokens,
+		N:         n,
+	}); err != nil {
+		LogError(r, err)
+		return
+	}
+}
+
+// findAPITokensResponse represents the output JSON struct for "GET /api-tokens".
+type findAPITokensResponse struct {
+	APITokens []*wtf.APIToken `json:"apiTokens"`
+	N         int             `json:"n"`
+}
+
+// handleAPITokenCreate handles the "POST /api-tokens" route. It reads the
+// token as JSON or from the HTML form on the settings page. As the plaintext
+// token is only available once, the HTML form renders the settings page with
+// the new token instead of redirecting.
+func (s *Server) handleAPITokenCreate(w http.ResponseWriter, r *http.Request) {
+	var token wtf.APIToken
+	switch r.Header.Get("Content-type") {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&token); err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid JSON body"))
+			return
+		}
+	default:
+		if err := r.ParseForm(); err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid form body"))
+			return
+		}
+		token.Name = r.PostForm.Get("name")
+		token.Scope = r.PostForm.Get("scope")
+
+		// Expiration is specified as a number of days from now. Zero or blank
+		// means the token never expires.
+		if days, _ := strconv.Atoi(r.PostForm.Get("expiresIn")); days > 0 {
+			expiresAt := time.Now().AddDate(0, 0, days)
+			token.ExpiresAt = &expiresAt
+		}
+	}
+
+	// Create token in the database.
+	err := s.APITokenService.CreateAPIToken(r.Context(), &token)
+
+	// Render output to the client based on HTTP accept header.
+	switch r.Header.Get("Accept") {
+	case "application/json":
+		if err != nil {
+			Error(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(token); err != nil {
+			LogError(r, err)
+			return
+		}
+
+	default:
+		tmpl := html.SettingsTemplate{Timezone: wtf.UserFromContext(r.Context()).Timezone}
+		if wtf.ErrorCode(err) == wtf.EINTERNAL {
+			Error(w, r, err)
+			return
+		} else if err != nil {
+			tmpl.Err = err
+		} else {
+			tmpl.NewAPIToken = &token
+		}
+		s.renderSettings(w, r, tmpl)
+	}
+}
+
+// handleAPITokenDelete handles the "DELETE /api-tokens/:id" route. This route
+// revokes the token and redirects back to the settings page.
+func (s *Server) handleAPITokenDelete(w http.ResponseWriter, r *http.Request) {
+	// Parse token ID from the URL.
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid ID format"))
+		return
+	}
+
+	// Delete token.
+	if err := s.APITokenService.DeleteAPIToken(r.Context(), id); err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Render output to the client based on HTTP accept header.
+	switch r.Header.Get("Accept") {
+	case "application/json":
+		w.Header().Set("Content-type", "application/json")
+		w.Write([]byte(`{}`))
+
+	default:
+		SetFlash(w, "API token successfully revoked.")
+		http.Redirect(w, r, "/settings", http.StatusFound)
+	}
+}
+
+// APITokenService implements the wtf.APITokenService over the HTTP protocol.
+type APITokenService struct {
+	Client *Client
+}
+
+// NewAPITokenService returns a new instance of APITokenService.
+func NewAPITokenService(client *Client) *APITokenService {
+	return &APITokenService{Client: client}
+}
+
+// FindAPITokens retrieves a list of the current user's tokens by filter.
+// Also returns a count of total matching tokens which may differ if "Limit"
+// is specified on the filter.
+func (s *APITokenService) FindAPITokens(ctx context.Context, filter wtf.APITokenFilter) ([]*wtf.APIToken, int, error) {
+	// Marshal filter into JSON format.
+	body, err := json.Marshal(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "GET", "/api-tokens", bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Issue request. Any non-200 status code is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, 0, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal result set of tokens & total token count.
+	var jsonResponse findAPITokensResponse
+	if err := json.NewDecoder(resp.Body).Decode(&jsonResponse); err != nil {
+		return nil, 0, err
+	}
+	return jsonResponse.APITokens, jsonResponse.N, nil
+}
+
+// CreateAPIToken creates a new token for the current user. The plaintext
+// token is set on token.Token.
+func (s *APITokenService) CreateAPIToken(ctx context.Context, token *wtf.APIToken) error {
+	// Marshal token data into JSON format.
+	body, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "POST", "/api-tokens", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	// Issue request. Treat non-201 status codes as errors.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusCreated {
+		return parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal returned token data.
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteAPIToken permanently revokes a token. Returns ENOTFOUND if the token
+// does not exist or belongs to another user.
+func (s *APITokenService) DeleteAPIToken(ctx context.Context, id int) error {
+	// Create a request with API key.
+	req, err := s.Client.newRequest(ctx, "DELETE", fmt.Sprintf("/api-tokens/%d", id), nil)
+	if err != nil {
+		return err
+	}
+
+	// Issue request. Any non-200 response is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// AuthenticateAPIToken is not implemented by the HTTP client. Tokens are
+// validated by the server when they are passed with each request.
+func (s *APITokenService) AuthenticateAPIToken(ctx context.Context, token string) (*wtf.APIToken, error) {
+	return nil, wtf.Errorf(wtf.ENOTIMPLEMENTED, "Not implemented.")
+}
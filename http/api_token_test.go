@@ -0,0 +1,133 @@
+package http_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benbjohnson/wtf"
+	wtfhttp "github.com/benbjohnson/wtf/http"
+)
+
+// Ensure API tokens are authenticated through the token service & are
+// restricted to the requests allowed by their scope.
+func TestAPITokenScope(t *testing.T) {
+	for _, tt := range []struct {
+		scope         string
+		allowSetValue bool
+		allowDelete   bool
+		allowSecrets  bool
+	}{
+		{scope: wtf.APITokenScopeRead},
+		{scope: wtf.APITokenScopeSetValue, allowSetValue: true},
+		{scope: wtf.APITokenScopeAdmin, allowSetValue: true, allowDelete: true, allowSecrets: true},
+	} {
+		t.Run(tt.scope, func(t *testing.T) {
+			s := MustOpenServer(t)
+			defer MustCloseServer(t, s)
+
+			user := &wtf.User{ID: 1, Name: "USER1"}
+			s.APITokenService.AuthenticateAPITokenFn = func(ctx context.Context, token string) (*wtf.APIToken, error) {
+				if token != "wtf_TOKEN" {
+					return nil, wtf.Errorf(wtf.ENOTFOUND, "API token not found.")
+				}
+				return &wtf.APIToken{ID: 1, UserID: user.ID, User: user, Scope: tt.scope}, nil
+			}
+			s.UserService.FindUserByIDFn = func(ctx context.Context, id int) (*wtf.User, error) {
+				return user, nil
+			}
+			s.DialService.FindDialsFn = func(ctx context.Context, filter wtf.DialFilter) ([]*wtf.Dial, int, error) {
+				return []*wtf.Dial{}, 0, nil
+			}
+			s.DialService.SetDialMembershipValueFn = func(ctx context.Context, dialID, value int, note string) error {
+				return nil
+			}
+			s.DialService.DeleteDialFn = func(ctx context.Context, id int) error {
+				return nil
+			}
+			s.IncomingWebhookService.FindIncomingWebhooksFn = func(ctx context.Context, filter wtf.IncomingWebhookFilter) ([]*wtf.IncomingWebhook, int, error) {
+				return []*wtf.IncomingWebhook{}, 0, nil
+			}
+			s.WebhookService.FindWebhooksFn = func(ctx context.Context, filter wtf.WebhookFilter) ([]*wtf.Webhook, int, error) {
+				return []*wtf.Webhook{}, 0, nil
+			}
+
+			ctx := wtf.NewContextWithUser(context.Background(), &wtf.User{APIKey: "wtf_TOKEN"})
+			client := wtfhttp.NewClient(s.URL())
+			dialService := wtfhttp.NewDialService(client)
+
+			// Reads are allowed by all scopes.
+			if _, _, err := dialService.FindDials(ctx, wtf.DialFilter{}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := dialService.SetDialMembershipValue(ctx, 1, 50, ""); tt.allowSetValue && err != nil {
+				t.Fatal(err)
+			} else if !tt.allowSetValue && wtf.ErrorCode(err) != wtf.EUNAUTHORIZED {
+				t.Fatalf("expected unauthorized error, got %v", err)
+			}
+
+			if err := dialService.DeleteDial(ctx, 1); tt.allowDelete && err != nil {
+				t.Fatal(err)
+			} else if !tt.allowDelete && wtf.ErrorCode(err) != wtf.EUNAUTHORIZED {
+				t.Fatalf("expected unauthorized error, got %v", err)
+			}
+
+			// The current user can be read by all scopes.
+			if other, err := wtfhttp.NewUserService(client).FindCurrentUser(ctx); err != nil {
+				t.Fatal(err)
+			} else if other.ID != user.ID {
+				t.Fatalf("ID=%v, want %v", other.ID, user.ID)
+			}
+
+			// Resources which contain credentials require the admin scope.
+			if _, _, err := wtfhttp.NewIncomingWebhookService(client).FindIncomingWebhooks(ctx, wtf.IncomingWebhookFilter{}); tt.allowSecrets && err != nil {
+				t.Fatal(err)
+			} else if !tt.allowSecrets && wtf.ErrorCode(err) != wtf.EUNAUTHORIZED {
+				t.Fatalf("expected unauthorized error, got %v", err)
+			}
+			if _, _, err := wtfhttp.NewWebhookService(client).FindWebhooks(ctx, wtf.WebhookFilter{}); tt.allowSecrets && err != nil {
+				t.Fatal(err)
+			} else if !tt.allowSecrets && wtf.ErrorCode(err) != wtf.EUNAUTHORIZED {
+				t.Fatalf("expected unauthorized error, got %v", err)
+			}
+		})
+	}
+
+	// Ensure keys without the token prefix, such as migrated legacy API keys,
+	// are authenticated through the token service.
+	t.Run("LegacyAPIKey", func(t *testing.T) {
+		s := MustOpenServer(t)
+		defer MustCloseServer(t, s)
+
+		user := &wtf.User{ID: 1, Name: "USER1"}
+		s.APITokenService.AuthenticateAPITokenFn = func(ctx context.Context, token string) (*wtf.APIToken, error) {
+			if token != "LEGACYKEY" {
+				return nil, wtf.Errorf(wtf.ENOTFOUND, "API token not found.")
+			}
+			return &wtf.APIToken{ID: 1, UserID: user.ID, User: user, Scope: wtf.APITokenScopeAdmin}, nil
+		}
+		s.DialService.DeleteDialFn = func(ctx context.Context, id int) error {
+			return nil
+		}
+
+		ctx := wtf.NewContextWithUser(context.Background(), &wtf.User{APIKey: "LEGACYKEY"})
+		if err := wtfhttp.NewDialService(wtfhttp.NewClient(s.URL())).DeleteDial(ctx, 1); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure an unknown token is rejected.
+	t.Run("ErrInvalid", func(t *testing.T) {
+		s := MustOpenServer(t)
+		defer MustCloseServer(t, s)
+
+		s.APITokenService.AuthenticateAPITokenFn = func(ctx context.Context, token string) (*wtf.APIToken, error) {
+			return nil, wtf.Errorf(wtf.ENOTFOUND, "API token not found.")
+		}
+
+		ctx := wtf.NewContextWithUser(context.Background(), &wtf.User{APIKey: "wtf_BAD"})
+		if _, _, err := wtfhttp.NewDialService(wtfhttp.NewClient(s.URL())).FindDials(ctx, wtf.DialFilter{}); wtf.ErrorCode(err) != wtf.EUNAUTHORIZED || wtf.ErrorMessage(err) != `Invalid API key.` {
+			t.Fatal(err)
+		}
+	})
+}
@@ -1,37 +1,219 @@
 package http
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/benbjohnson/wtf"
 	"github.com/benbjohnson/wtf/http/html"
 	"github.com/google/go-github/v32/github"
 	"github.com/gorilla/mux"
 	"golang.org/x/oauth2"
+	oauth2github "golang.org/x/oauth2/github"
 )
 
+// AuthProvider represents an external OAuth-based authentication provider,
+// such as GitHub or an OpenID Connect identity provider.
+type AuthProvider interface {
+	// Source returns the unique name of the provider. This is used in the
+	// login URLs and is stored as the Source on the Auth object.
+	Source() string
+
+	// DisplayName returns the name shown to users on the login page.
+	DisplayName() string
+
+	// AuthCodeURL returns the URL of the provider's consent page. The state
+	// is returned to the callback & is used to prevent CSRF attacks.
+	AuthCodeURL(ctx context.Context, state, redirectURL string) (string, error)
+
+	// Exchange converts an authorization code into an Auth object with an
+	// attached User. The user's email is only set if it has been verified by
+	// the provider as it is used to link accounts.
+	Exchange(ctx context.Context, state, code, redirectURL string) (*wtf.Auth, error)
+}
+
 // registerAuthRoutes is a helper function to register routes to a router.
 func (s *Server) registerAuthRoutes(r *mux.Router) {
 	r.HandleFunc("/login", s.handleLogin).Methods("GET")
 	r.HandleFunc("/logout", s.handleLogout).Methods("DELETE")
-	r.HandleFunc("/oauth/github", s.handleOAuthGitHub).Methods("GET")
-	r.HandleFunc("/oauth/github/callback", s.handleOAuthGitHubCallback).Methods("GET")
+	r.HandleFunc("/oauth/{source}", s.handleOAuth).Methods("GET")
+	r.HandleFunc("/oauth/{source}/callback", s.handleOAuthCallback).Methods("GET")
+	r.HandleFunc("/login/email", s.handleLoginEmail).Methods("POST")
+	r.HandleFunc("/login/email/callback", s.handleLoginEmailCallback).Methods("GET")
+}
+
+// LoginTokenName is the name used to sign login tokens sent by email.
+const LoginTokenName = "login_token"
+
+// registerAuthLinkRoutes is a helper function to register routes for managing
+// the current user's linked accounts. These routes require authentication.
+func (s *Server) registerAuthLinkRoutes(r *mux.Router) {
+	r.HandleFunc("/auths/{id}", s.handleAuthDelete).Methods("DELETE")
+}
+
+// authProviders returns a list of all registered authentication providers.
+// GitHub is included first if it has been configured.
+func (s *Server) authProviders() []AuthProvider {
+	var a []AuthProvider
+	if s.GitHubClientID != "" {
+		a = append(a, NewGitHubProvider(s.GitHubClientID, s.GitHubClientSecret))
+	}
+	return append(a, s.AuthProviders...)
+}
+
+// authProvider returns a registered authentication provider by source.
+// Returns nil if no provider exists.
+func (s *Server) authProvider(source string) AuthProvider {
+	for _, p := range s.authProviders() {
+		if p.Source() == source {
+			return p
+		}
+	}
+	return nil
+}
+
+// oauthRedirectURL returns the callback URL for a provider based on the
+// host that the request was made to.
+func (s *Server) oauthRedirectURL(r *http.Request, source string) string {
+	return fmt.Sprintf("%s://%s/oauth/%s/callback", s.Scheme(), r.Host, source)
 }
 
-// handleLogin handles the "GET /login" route. It simply renders an HTML login form.
+// handleLogin handles the "GET /login" route. It renders an HTML login form
+// with a button for each authentication provider.
 func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
-	var tmpl html.LoginTemplate
-	tmpl.Render(r.Context(), w)
+	s.loginTemplate().Render(r.Context(), w)
+}
+
+// loginTemplate returns the login page template with the available providers.
+func (s *Server) loginTemplate() *html.LoginTemplate {
+	tmpl := &html.LoginTemplate{EmailEnabled: s.Mailer != nil}
+	for _, p := range s.authProviders() {
+		tmpl.Providers = append(tmpl.Providers, html.LoginProvider{
+			Source:      p.Source(),
+			DisplayName: p.DisplayName(),
+		})
+	}
+	return tmpl
+}
+
+// handleLoginEmail handles the "POST /login/email" route. It creates a
+// single-use login token for the submitted email address & mails a signed
+// link containing the token to the address.
+func (s *Server) handleLoginEmail(w http.ResponseWriter, r *http.Request) {
+	if s.Mailer == nil {
+		Error(w, r, wtf.Errorf(wtf.ENOTFOUND, "Email login is not enabled."))
+		return
+	}
+
+	// Create a new token for the email address.
+	token := &wtf.LoginToken{Email: r.PostFormValue("email")}
+	if err := s.LoginTokenService.CreateLoginToken(r.Context(), token); err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Sign the token so that links are rejected if they have been altered.
+	signed, err := s.sc.Encode(LoginTokenName, token.Token)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	loginURL := s.baseURL(r) + "/login/email/callback?token=" + url.QueryEscape(signed)
+
+	// Send the login link to the user.
+	if err := s.Mailer.SendMail(r.Context(), &wtf.Mail{
+		To:      token.Email,
+		Subject: "Log in to WTF Dial",
+		Body: fmt.Sprintf("Use the link below to log in to WTF Dial. It expires in %d minutes & can only be used once.\n\n%s\n\nIf you did not request this email, you can safely ignore it.\n",
+			int(wtf.LoginTokenDuration/time.Minute), loginURL),
+	}); err != nil {
+		Error(w, r, fmt.Errorf("cannot send login email: %w", err))
+		return
+	}
+
+	// Render output to the client based on HTTP accept header.
+	switch r.Header.Get("Accept") {
+	case "application/json":
+		w.Header().Set("Content-type", "application/json")
+		w.Write([]byte(`{}`))
+
+	default:
+		tmpl := s.loginTemplate()
+		tmpl.EmailSent = token.Email
+		tmpl.Render(r.Context(), w)
+	}
+}
+
+// handleLoginEmailCallback handles the "GET /login/email/callback" route.
+// It redeems the token from a login link & logs in the user with the email
+// address that the link was sent to. The user is created if no user with the
+// email address exists.
+func (s *Server) handleLoginEmailCallback(w http.ResponseWriter, r *http.Request) {
+	if s.Mailer == nil {
+		Error(w, r, wtf.Errorf(wtf.ENOTFOUND, "Email login is not enabled."))
+		return
+	}
+
+	// Verify the signature on the token.
+	var token string
+	if err := s.sc.Decode(LoginTokenName, r.FormValue("token"), &token); err != nil {
+		Error(w, r, wtf.Errorf(wtf.EUNAUTHORIZED, "Invalid login link."))
+		return
+	}
+
+	// Mark the token as used so the link cannot be used again.
+	loginToken, err := s.LoginTokenService.RedeemLoginToken(r.Context(), token)
+	if wtf.ErrorCode(err) == wtf.ENOTFOUND {
+		Error(w, r, wtf.Errorf(wtf.EUNAUTHORIZED, "Invalid login link."))
+		return
+	} else if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Read session from request.
+	session, err := s.session(r)
+	if err != nil {
+		Error(w, r, fmt.Errorf("cannot read session: %s", err))
+		return
+	}
+
+	// Name new users after their email address. The email has been verified
+	// so it is used to link the user to any existing account.
+	name := loginToken.Email
+	if i := strings.LastIndex(name, "@"); i > 0 {
+		name = name[:i]
+	}
+	s.login(w, r, session, &wtf.Auth{
+		Source:   wtf.AuthSourceEmail,
+		SourceID: strings.ToLower(loginToken.Email),
+		User:     &wtf.User{Name: name, Email: loginToken.Email},
+	})
 }
 
-// handleLogout handles the "DELETE /logout" route. It clears the session
-// cookie and redirects the user to the home page.
+// handleLogout handles the "DELETE /logout" route. It revokes the current
+// session, clears the session cookie and redirects the user to the home page.
 func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	// Revoke the server-side session so the cookie cannot be reused. This
+	// route is not authenticated so the session's user is looked up first.
+	// The session may have already been revoked from another device.
+	session, _ := s.session(r)
+	if user := s.sessionUser(r.Context(), session); user != nil {
+		ctx := wtf.NewContextWithUser(r.Context(), user)
+		if err := s.SessionService.DeleteSession(ctx, session.SessionID); err != nil && wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			Error(w, r, err)
+			return
+		}
+	}
+
 	// Clear session cookie on HTTP response.
 	if err := s.setSession(w, Session{}); err != nil {
 		Error(w, r, err)
@@ -42,12 +224,22 @@ func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/", http.StatusFound)
 }
 
-// handleOAuthGitHub handles the "GET /oauth/github" route. It generates a
-// random state variable and redirects the user to the GitHub OAuth endpoint.
+// handleOAuth handles the "GET /oauth/:source" route. It generates a random
+// state variable and redirects the user to the provider's OAuth endpoint.
+//
+// If the "link" query parameter is set then the provider's account is linked
+// to the logged in user instead of logging in.
 //
 // After authentication, user will be redirected back to the callback page
 // where we can store the returned OAuth tokens.
-func (s *Server) handleOAuthGitHub(w http.ResponseWriter, r *http.Request) {
+func (s *Server) handleOAuth(w http.ResponseWriter, r *http.Request) {
+	source := mux.Vars(r)["source"]
+	provider := s.authProvider(source)
+	if provider == nil {
+		Error(w, r, wtf.Errorf(wtf.ENOTFOUND, "Auth provider not found."))
+		return
+	}
+
 	// Read session from request's cookies.
 	session, err := s.session(r)
 	if err != nil {
@@ -63,6 +255,21 @@ func (s *Server) handleOAuthGitHub(w http.ResponseWriter, r *http.Request) {
 	}
 	session.State = hex.EncodeToString(state)
 
+	// Linking requires a logged in user. This route is not authenticated by
+	// middleware so the user is read from the session directly.
+	session.Link = r.URL.Query().Get("link") == "true"
+	if session.Link && s.sessionUser(r.Context(), session) == nil {
+		Error(w, r, wtf.Errorf(wtf.EUNAUTHORIZED, "You must be logged in to link an account."))
+		return
+	}
+
+	// Determine the provider's consent page URL.
+	authCodeURL, err := provider.AuthCodeURL(r.Context(), session.State, s.oauthRedirectURL(r, source))
+	if err != nil {
+		Error(w, r, fmt.Errorf("cannot generate oauth url: %w", err))
+		return
+	}
+
 	// Store the state to the session in the response cookie.
 	if err := s.setSession(w, session); err != nil {
 		Error(w, r, err)
@@ -70,14 +277,21 @@ func (s *Server) handleOAuthGitHub(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Redirect to OAuth2 provider.
-	http.Redirect(w, r, s.OAuth2Config().AuthCodeURL(session.State), http.StatusFound)
+	http.Redirect(w, r, authCodeURL, http.StatusFound)
 }
 
-// handleOAuthGitHubCallback handles the "GET /oauth/github/callback" route.
+// handleOAuthCallback handles the "GET /oauth/:source/callback" route.
 // It validates the returned OAuth state that we generated previously, looks up
 // the current user's information, and creates an "Auth" object in the database.
-func (s *Server) handleOAuthGitHubCallback(w http.ResponseWriter, r *http.Request) {
-	// Read form variables passed in from GitHub.
+func (s *Server) handleOAuthCallback(w http.ResponseWriter, r *http.Request) {
+	source := mux.Vars(r)["source"]
+	provider := s.authProvider(source)
+	if provider == nil {
+		Error(w, r, wtf.Errorf(wtf.ENOTFOUND, "Auth provider not found."))
+		return
+	}
+
+	// Read form variables passed in from the provider.
 	state, code := r.FormValue("state"), r.FormValue("code")
 
 	// Read session from request.
@@ -93,32 +307,194 @@ func (s *Server) handleOAuthGitHubCallback(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// Exchange code for OAuth tokens.
-	tok, err := s.OAuth2Config().Exchange(r.Context(), code)
+	// The provider returns an error code if the user denied access.
+	if v := r.FormValue("error"); v != "" {
+		Error(w, r, wtf.Errorf(wtf.EUNAUTHORIZED, "Login failed: %s", v))
+		return
+	}
+
+	// Exchange code for OAuth tokens & the user's information.
+	auth, err := provider.Exchange(r.Context(), state, code, s.oauthRedirectURL(r, source))
 	if err != nil {
 		Error(w, r, fmt.Errorf("oauth exchange error: %s", err))
 		return
 	}
 
+	// Attach the account to the logged in user if linking.
+	if session.Link {
+		s.linkAuth(w, r, session, auth)
+		return
+	}
+
+	s.login(w, r, session, auth)
+}
+
+// login creates the auth & a new server-side session for its user and
+// redirects to the URL stored on login. This is the final step of logging in
+// with either an auth provider or an emailed login link.
+func (s *Server) login(w http.ResponseWriter, r *http.Request, session Session, auth *wtf.Auth) {
+	// Create the "Auth" object in the database. The AuthService will lookup
+	// the user by email if they already exist. Otherwise, a new user will be
+	// created and the user's ID will be set to auth.UserID.
+	if err := s.AuthService.CreateAuth(r.Context(), auth); err != nil {
+		Error(w, r, fmt.Errorf("cannot create auth: %s", err))
+		return
+	}
+
+	// Create a server-side session for the user which can later be revoked.
+	other := newSession(r)
+	other.AuthID = auth.ID
+	if err := s.SessionService.CreateSession(wtf.NewContextWithUser(r.Context(), auth.User), other); err != nil {
+		Error(w, r, fmt.Errorf("cannot create session: %s", err))
+		return
+	}
+
+	// Restore redirect URL stored on login.
+	redirectURL := session.RedirectURL
+
+	// Update browser session to store the user & session IDs and clear OAuth state.
+	session.UserID = auth.UserID
+	session.SessionID = other.ID
+	session.RedirectURL = ""
+	session.State = ""
+	if err := s.setSession(w, session); err != nil {
+		Error(w, r, fmt.Errorf("cannot set session cookie: %s", err))
+		return
+	}
+
+	// Redirect to stored URL or, if not available, to the home page.
+	if redirectURL == "" {
+		redirectURL = "/"
+	}
+	http.Redirect(w, r, redirectURL, http.StatusFound)
+}
+
+// linkAuth links auth to the user logged in to the session and redirects
+// back to the settings page. This is the final step of the OAuth callback
+// when linking an account.
+func (s *Server) linkAuth(w http.ResponseWriter, r *http.Request, session Session, auth *wtf.Auth) {
+	user := s.sessionUser(r.Context(), session)
+	if user == nil {
+		Error(w, r, wtf.Errorf(wtf.EUNAUTHORIZED, "You must be logged in to link an account."))
+		return
+	}
+
+	// Create the auth for the current user. Fails if the account is already
+	// linked to another user or the user already has an account from the provider.
+	auth.UserID, auth.User = user.ID, user
+	if err := s.AuthService.CreateAuth(wtf.NewContextWithUser(r.Context(), user), auth); err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Clear OAuth state from the browser session.
+	session.State = ""
+	session.Link = false
+	if err := s.setSession(w, session); err != nil {
+		Error(w, r, fmt.Errorf("cannot set session cookie: %s", err))
+		return
+	}
+
+	SetFlash(w, "Account successfully linked.")
+	http.Redirect(w, r, "/settings", http.StatusFound)
+}
+
+// handleAuthDelete handles the "DELETE /auths/:id" route. It unlinks one of
+// the current user's accounts. The last linked account cannot be removed as
+// the user would no longer be able to log in.
+func (s *Server) handleAuthDelete(w http.ResponseWriter, r *http.Request) {
+	// Parse auth ID from the URL.
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid ID format"))
+		return
+	}
+
+	// Ensure the user has another way to log in.
+	userID := wtf.UserIDFromContext(r.Context())
+	if _, n, err := s.AuthService.FindAuths(r.Context(), wtf.AuthFilter{UserID: &userID}); err != nil {
+		Error(w, r, err)
+		return
+	} else if n <= 1 {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "You cannot unlink your only linked account."))
+		return
+	}
+
+	// Delete the auth. Sessions created by logging in with it are revoked too.
+	if err := s.AuthService.DeleteAuth(r.Context(), id); err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Render output to the client based on HTTP accept header.
+	switch r.Header.Get("Accept") {
+	case "application/json":
+		w.Header().Set("Content-type", "application/json")
+		w.Write([]byte(`{}`))
+
+	default:
+		SetFlash(w, "Account successfully unlinked.")
+		http.Redirect(w, r, "/settings", http.StatusFound)
+	}
+}
+
+// GitHubProvider implements AuthProvider for GitHub OAuth applications.
+type GitHubProvider struct {
+	ClientID     string
+	ClientSecret string
+}
+
+// NewGitHubProvider returns a new instance of GitHubProvider.
+func NewGitHubProvider(clientID, clientSecret string) *GitHubProvider {
+	return &GitHubProvider{ClientID: clientID, ClientSecret: clientSecret}
+}
+
+// Source returns the GitHub auth source.
+func (p *GitHubProvider) Source() string { return wtf.AuthSourceGitHub }
+
+// DisplayName returns "GitHub".
+func (p *GitHubProvider) DisplayName() string { return "GitHub" }
+
+// OAuth2Config returns the GitHub OAuth2 configuration.
+func (p *GitHubProvider) OAuth2Config() *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     p.ClientID,
+		ClientSecret: p.ClientSecret,
+		Scopes:       []string{},
+		Endpoint:     oauth2github.Endpoint,
+	}
+}
+
+// AuthCodeURL returns the GitHub authorization URL. The redirect URL is
+// ignored as GitHub uses the callback URL registered with the application.
+func (p *GitHubProvider) AuthCodeURL(ctx context.Context, state, redirectURL string) (string, error) {
+	return p.OAuth2Config().AuthCodeURL(state), nil
+}
+
+// Exchange exchanges the code for OAuth tokens and fetches the GitHub user.
+func (p *GitHubProvider) Exchange(ctx context.Context, state, code, redirectURL string) (*wtf.Auth, error) {
+	// Exchange code for OAuth tokens.
+	tok, err := p.OAuth2Config().Exchange(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create a new GitHub API client.
-	client := github.NewClient(oauth2.NewClient(r.Context(), oauth2.StaticTokenSource(
+	client := github.NewClient(oauth2.NewClient(ctx, oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: tok.AccessToken},
 	)))
 
 	// Fetch user information for the currently authenticated user.
 	// Require that we at least receive a user ID from GitHub.
-	u, _, err := client.Users.Get(r.Context(), "")
+	u, _, err := client.Users.Get(ctx, "")
 	if err != nil {
-		Error(w, r, fmt.Errorf("cannot fetch github user: %s", err))
-		return
+		return nil, fmt.Errorf("cannot fetch github user: %s", err)
 	} else if u.ID == nil {
-		Error(w, r, fmt.Errorf("user ID not returned by GitHub, cannot authenticate user"))
-		return
+		return nil, fmt.Errorf("user ID not returned by GitHub, cannot authenticate user")
 	}
 
 	// Email is not necessarily available for all accounts. If it is, store it
-	// so we can link together multiple OAuth providers in the future
-	// (e.g. GitHub, Google, etc).
+	// so we can link together multiple OAuth providers (e.g. GitHub & OIDC).
 	var name string
 	if u.Name != nil {
 		name = *u.Name
@@ -144,30 +520,5 @@ func (s *Server) handleOAuthGitHubCallback(w http.ResponseWriter, r *http.Reques
 	if !tok.Expiry.IsZero() {
 		auth.Expiry = &tok.Expiry
 	}
-
-	// Create the "Auth" object in the database. The AuthService will lookup
-	// the user by email if they already exist. Otherwise, a new user will be
-	// created and the user's ID will be set to auth.UserID.
-	if err := s.AuthService.CreateAuth(r.Context(), auth); err != nil {
-		Error(w, r, fmt.Errorf("cannot create auth: %s", err))
-		return
-	}
-
-	// Restore redirect URL stored on login.
-	redirectURL := session.RedirectURL
-
-	// Update browser session to store the user's ID and clear OAuth state.
-	session.UserID = auth.UserID
-	session.RedirectURL = ""
-	session.State = ""
-	if err := s.setSession(w, session); err != nil {
-		Error(w, r, fmt.Errorf("cannot set session cookie: %s", err))
-		return
-	}
-
-	// Redirect to stored URL or, if not available, to the home page.
-	if redirectURL == "" {
-		redirectURL = "/"
-	}
-	http.Redirect(w, r, redirectURL, http.StatusFound)
+	return auth, nil
 }
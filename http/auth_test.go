@@ -1,11 +1,16 @@
 package http_test
 
 import (
+	"context"
 	"net/http"
 	"net/url"
+	"regexp"
+	"strings"
 	"testing"
 
+	"github.com/benbjohnson/wtf"
 	wtfhttp "github.com/benbjohnson/wtf/http"
+	"github.com/benbjohnson/wtf/mock"
 )
 
 // Ensure our OAuth route redirects to the correct GitHub URL and with the
@@ -53,3 +58,230 @@ func TestLogin_OAuth_GitHub(t *testing.T) {
 		t.Fatalf("Location.Query.state=%v, want %v", got, want)
 	}
 }
+
+// Ensure a session cookie is no longer accepted once its session has been
+// revoked on the server.
+func TestSession_Revoked(t *testing.T) {
+	s := MustOpenServer(t)
+	defer MustCloseServer(t, s)
+
+	s.SessionService.AuthenticateSessionFn = func(ctx context.Context, id int) (*wtf.Session, error) {
+		return nil, wtf.Errorf(wtf.ENOTFOUND, "Session not found.")
+	}
+
+	ctx := wtf.NewContextWithUser(context.Background(), &wtf.User{ID: 1})
+	req := s.MustNewRequest(t, ctx, "GET", "/dials", nil)
+	req.Header.Set("Accept", "application/json")
+	if resp, err := http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	} else if err := resp.Body.Close(); err != nil {
+		t.Fatal(err)
+	} else if got, want := resp.StatusCode, http.StatusUnauthorized; got != want {
+		t.Fatalf("StatusCode=%v, want %v", got, want)
+	}
+}
+
+// Ensure logging out revokes the current session & clears the cookie.
+func TestLogout(t *testing.T) {
+	s := MustOpenServer(t)
+	defer MustCloseServer(t, s)
+
+	user := &wtf.User{ID: 1, Name: "USER1"}
+	s.UserService.FindUserByIDFn = func(ctx context.Context, id int) (*wtf.User, error) {
+		return user, nil
+	}
+
+	var deletedID int
+	s.SessionService.DeleteSessionFn = func(ctx context.Context, id int) error {
+		if got, want := wtf.UserIDFromContext(ctx), user.ID; got != want {
+			t.Fatalf("UserID=%v, want %v", got, want)
+		}
+		deletedID = id
+		return nil
+	}
+
+	// Disable redirects so the cleared cookie can be inspected.
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	req := s.MustNewRequest(t, wtf.NewContextWithUser(context.Background(), user), "DELETE", "/logout", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := resp.Body.Close(); err != nil {
+		t.Fatal(err)
+	} else if got, want := resp.StatusCode, http.StatusFound; got != want {
+		t.Fatalf("StatusCode=%v, want %v", got, want)
+	} else if got, want := deletedID, user.ID; got != want {
+		t.Fatalf("DeleteSession(%v), want %v", got, want)
+	}
+
+	var session wtfhttp.Session
+	if err := s.UnmarshalSession(resp.Cookies()[0].Value, &session); err != nil {
+		t.Fatal(err)
+	} else if session.UserID != 0 || session.SessionID != 0 {
+		t.Fatalf("unexpected session: %#v", session)
+	}
+}
+
+// Ensure a user cannot unlink the only account they can log in with.
+func TestAuthDelete_ErrLastAuth(t *testing.T) {
+	s := MustOpenServer(t)
+	defer MustCloseServer(t, s)
+
+	user := &wtf.User{ID: 1, Name: "USER1"}
+	s.UserService.FindUserByIDFn = func(ctx context.Context, id int) (*wtf.User, error) {
+		return user, nil
+	}
+	s.AuthService.FindAuthsFn = func(ctx context.Context, filter wtf.AuthFilter) ([]*wtf.Auth, int, error) {
+		return []*wtf.Auth{{ID: 1, UserID: user.ID}}, 1, nil
+	}
+	s.AuthService.DeleteAuthFn = func(ctx context.Context, id int) error {
+		t.Fatal("unexpected delete")
+		return nil
+	}
+
+	req := s.MustNewRequest(t, wtf.NewContextWithUser(context.Background(), user), "DELETE", "/auths/1", nil)
+	req.Header.Set("Accept", "application/json")
+	if resp, err := http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	} else if err := resp.Body.Close(); err != nil {
+		t.Fatal(err)
+	} else if got, want := resp.StatusCode, http.StatusBadRequest; got != want {
+		t.Fatalf("StatusCode=%v, want %v", got, want)
+	}
+}
+
+// Ensure a user can log in with a link sent to their email address.
+func TestLogin_Email(t *testing.T) {
+	s := MustOpenServer(t)
+	defer MustCloseServer(t, s)
+
+	var mail *wtf.Mail
+	s.Mailer = &mock.Mailer{SendMailFn: func(ctx context.Context, msg *wtf.Mail) error {
+		mail = msg
+		return nil
+	}}
+	s.LoginTokenService.CreateLoginTokenFn = func(ctx context.Context, token *wtf.LoginToken) error {
+		token.ID, token.Token = 1, "TOKEN"
+		return nil
+	}
+	s.LoginTokenService.RedeemLoginTokenFn = func(ctx context.Context, token string) (*wtf.LoginToken, error) {
+		if token != "TOKEN" {
+			t.Fatalf("unexpected token: %q", token)
+		}
+		return &wtf.LoginToken{ID: 1, Email: "Jane@example.com"}, nil
+	}
+
+	var auth *wtf.Auth
+	s.AuthService.CreateAuthFn = func(ctx context.Context, other *wtf.Auth) error {
+		auth = other
+		auth.ID, auth.UserID, auth.User.ID = 1, 1, 1
+		return nil
+	}
+	s.SessionService.CreateSessionFn = func(ctx context.Context, session *wtf.Session) error {
+		session.ID = 2
+		return nil
+	}
+
+	// Request a login link.
+	resp, err := http.PostForm(s.URL()+"/login/email", url.Values{"email": {"Jane@example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	} else if err := resp.Body.Close(); err != nil {
+		t.Fatal(err)
+	} else if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Fatalf("StatusCode=%v, want %v", got, want)
+	} else if mail == nil {
+		t.Fatal("expected mail")
+	} else if got, want := mail.To, "Jane@example.com"; got != want {
+		t.Fatalf("To=%v, want %v", got, want)
+	}
+
+	// Extract the link from the message body. The token must be signed.
+	loginURL := regexp.MustCompile(`http://\S+`).FindString(mail.Body)
+	if !strings.HasPrefix(loginURL, s.URL()+"/login/email/callback?token=") {
+		t.Fatalf("unexpected login url: %q", loginURL)
+	} else if strings.Contains(loginURL, "token=TOKEN") {
+		t.Fatal("expected signed token")
+	}
+
+	// Follow the link without following the redirect.
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	if resp, err = client.Get(loginURL); err != nil {
+		t.Fatal(err)
+	} else if err := resp.Body.Close(); err != nil {
+		t.Fatal(err)
+	} else if got, want := resp.StatusCode, http.StatusFound; got != want {
+		t.Fatalf("StatusCode=%v, want %v", got, want)
+	}
+
+	// Ensure the auth was created for the email address.
+	if auth == nil {
+		t.Fatal("expected auth")
+	} else if got, want := auth.Source, wtf.AuthSourceEmail; got != want {
+		t.Fatalf("Source=%v, want %v", got, want)
+	} else if got, want := auth.SourceID, "jane@example.com"; got != want {
+		t.Fatalf("SourceID=%v, want %v", got, want)
+	} else if got, want := auth.User.Email, "Jane@example.com"; got != want {
+		t.Fatalf("Email=%v, want %v", got, want)
+	} else if got, want := auth.User.Name, "Jane"; got != want {
+		t.Fatalf("Name=%v, want %v", got, want)
+	}
+
+	// Ensure the browser session now refers to the user.
+	var session wtfhttp.Session
+	if err := s.UnmarshalSession(resp.Cookies()[0].Value, &session); err != nil {
+		t.Fatal(err)
+	} else if got, want := session.UserID, 1; got != want {
+		t.Fatalf("UserID=%v, want %v", got, want)
+	} else if got, want := session.SessionID, 2; got != want {
+		t.Fatalf("SessionID=%v, want %v", got, want)
+	}
+}
+
+// Ensure a login link is rejected if its token has not been signed by the server.
+func TestLogin_Email_ErrInvalidSignature(t *testing.T) {
+	s := MustOpenServer(t)
+	defer MustCloseServer(t, s)
+
+	s.Mailer = &mock.Mailer{}
+	s.LoginTokenService.RedeemLoginTokenFn = func(ctx context.Context, token string) (*wtf.LoginToken, error) {
+		t.Fatal("unexpected redeem")
+		return nil, nil
+	}
+
+	req := s.MustNewRequest(t, context.Background(), "GET", "/login/email/callback?token=TOKEN", nil)
+	req.Header.Set("Accept", "application/json")
+	if resp, err := http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	} else if err := resp.Body.Close(); err != nil {
+		t.Fatal(err)
+	} else if got, want := resp.StatusCode, http.StatusUnauthorized; got != want {
+		t.Fatalf("StatusCode=%v, want %v", got, want)
+	}
+}
+
+// Ensure email login is unavailable if no mailer is configured.
+func TestLogin_Email_ErrNotEnabled(t *testing.T) {
+	s := MustOpenServer(t)
+	defer MustCloseServer(t, s)
+
+	req := s.MustNewRequest(t, context.Background(), "POST", "/login/email", strings.NewReader("email=jane@example.com"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if resp, err := http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	} else if err := resp.Body.Close(); err != nil {
+		t.Fatal(err)
+	} else if got, want := resp.StatusCode, http.StatusNotFound; got != want {
+		t.Fatalf("StatusCode=%v, want %v", got, want)
+	}
+}
@@ -0,0 +1,191 @@
+package http_test
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+	wtfhttp "github.com/benbjohnson/wtf/http"
+	"github.com/benbjohnson/wtf/inmem"
+	"github.com/benbjohnson/wtf/wtftest"
+)
+
+// Ensure the HTTP client passes the shared service test suite when connected
+// to a server backed by the in-memory implementation.
+func TestClient(t *testing.T) {
+	wtftest.Run(t, func(tb testing.TB) *wtftest.Services {
+		db := inmem.NewDB()
+		db.EventService = inmem.NewEventService()
+
+		// Attach in-memory services to the server & run on a test listener.
+		s := wtfhttp.NewServer()
+		s.AlertService = inmem.NewAlertService(db)
+		s.AuthService = inmem.NewAuthService(db)
+		s.DialService = inmem.NewDialService(db)
+		s.DialMembershipService = inmem.NewDialMembershipService(db)
+		s.EventService = db.EventService
+		s.IncomingWebhookService = inmem.NewIncomingWebhookService(db)
+		s.LoginTokenService = inmem.NewLoginTokenService(db)
+		s.OrganizationService = inmem.NewOrganizationService(db)
+		s.SearchService = inmem.NewSearchService(db)
+		s.SessionService = inmem.NewSessionService(db)
+		s.UserService = inmem.NewUserService(db)
+		s.WebhookService = inmem.NewWebhookService(db)
+
+		// Users authenticate with a test API key so that API tokens are only
+		// created by the tests.
+		apiTokenService := inmem.NewAPITokenService(db)
+		s.APITokenService = &TestAPITokenService{APITokenService: apiTokenService, users: s.UserService}
+
+		ts := httptest.NewServer(s)
+		tb.Cleanup(ts.Close)
+
+		// Auths & users are only created via OAuth so those are accessed
+		// directly through the server's backing services.
+		client := wtfhttp.NewClient(ts.URL)
+		return &wtftest.Services{
+			AlertService:           &AlertService{AlertService: wtfhttp.NewAlertService(client), backend: s.AlertService},
+			APITokenService:        &APITokenService{APITokenService: wtfhttp.NewAPITokenService(client), backend: apiTokenService},
+			AuthService:            s.AuthService,
+			DialService:            wtfhttp.NewDialService(client),
+			DialMembershipService:  wtfhttp.NewDialMembershipService(client),
+			IncomingWebhookService: wtfhttp.NewIncomingWebhookService(client),
+			LoginTokenService:      s.LoginTokenService,
+			OrganizationService:    wtfhttp.NewOrganizationService(client),
+			SearchService:          wtfhttp.NewSearchService(client),
+			SessionService:         &SessionService{SessionService: wtfhttp.NewSessionService(client), backend: s.SessionService},
+			UserService:            &UserService{UserService: wtfhttp.NewUserService(client), backend: s.UserService},
+			WebhookService:         &WebhookService{WebhookService: wtfhttp.NewWebhookService(client), backend: s.WebhookService},
+			EventService:           db.EventService,
+			SetNow:                 func(fn func() time.Time) { db.Now = fn },
+			NewContextWithUser: func(ctx context.Context, user *wtf.User) context.Context {
+				other := *user
+				other.APIKey = fmt.Sprintf(testAPIKeyFormat, user.ID)
+				return wtf.NewContextWithUser(ctx, &other)
+			},
+		}
+	})
+}
+
+// Ensure the current user can be fetched using only their API token.
+func TestUserService_FindCurrentUser(t *testing.T) {
+	db := inmem.NewDB()
+	s := wtfhttp.NewServer()
+	s.APITokenService = inmem.NewAPITokenService(db)
+	s.UserService = inmem.NewUserService(db)
+
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	user := &wtf.User{Name: "jane", Email: "jane@gmail.com"}
+	if err := s.UserService.CreateUser(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	token := &wtf.APIToken{Name: "cli", Scope: wtf.APITokenScopeRead}
+	if err := s.APITokenService.CreateAPIToken(wtf.NewContextWithUser(context.Background(), user), token); err != nil {
+		t.Fatal(err)
+	}
+
+	// Authenticate with an API token only, as the CLI does.
+	ctx := wtf.NewContextWithUser(context.Background(), &wtf.User{APIKey: token.Token})
+	if other, err := wtfhttp.NewUserService(wtfhttp.NewClient(ts.URL)).FindCurrentUser(ctx); err != nil {
+		t.Fatal(err)
+	} else if got, want := other.ID, user.ID; got != want {
+		t.Fatalf("ID=%v, want %v", got, want)
+	}
+}
+
+// testAPIKeyFormat is the format of the API keys used by the shared test suite
+// to authenticate as a user.
+const testAPIKeyFormat = "TEST_USER_%d"
+
+// TestAPITokenService wraps the server's API token service but also accepts
+// test API keys which authenticate as the user with the ID in the key.
+type TestAPITokenService struct {
+	wtf.APITokenService
+	users wtf.UserService
+}
+
+func (s *TestAPITokenService) AuthenticateAPIToken(ctx context.Context, token string) (*wtf.APIToken, error) {
+	var userID int
+	if _, err := fmt.Sscanf(token, testAPIKeyFormat, &userID); err != nil {
+		return s.APITokenService.AuthenticateAPIToken(ctx, token)
+	}
+
+	user, err := s.users.FindUserByID(ctx, userID)
+	if wtf.ErrorCode(err) == wtf.ENOTFOUND {
+		return nil, wtf.Errorf(wtf.ENOTFOUND, "API token not found.")
+	} else if err != nil {
+		return nil, err
+	}
+	return &wtf.APIToken{UserID: user.ID, User: user, Scope: wtf.APITokenScopeAdmin}, nil
+}
+
+// UserService wraps the HTTP user service but creates users through the
+// server's backing service since users cannot be created over HTTP.
+type UserService struct {
+	*wtfhttp.UserService
+	backend wtf.UserService
+}
+
+func (s *UserService) CreateUser(ctx context.Context, user *wtf.User) error {
+	return s.backend.CreateUser(ctx, user)
+}
+
+// AlertService wraps the HTTP alert service but evaluates rules through the
+// server's backing service since evaluation is only run by the server.
+type AlertService struct {
+	*wtfhttp.AlertService
+	backend wtf.AlertService
+}
+
+func (s *AlertService) EvaluateAlertRules(ctx context.Context) error {
+	return s.backend.EvaluateAlertRules(ctx)
+}
+
+// WebhookService wraps the HTTP webhook service but accesses pending
+// deliveries through the server's backing service since deliveries are
+// only sent by the server.
+type WebhookService struct {
+	*wtfhttp.WebhookService
+	backend wtf.WebhookService
+}
+
+func (s *WebhookService) FindPendingWebhookDeliveries(ctx context.Context, limit int) ([]*wtf.WebhookDelivery, error) {
+	return s.backend.FindPendingWebhookDeliveries(ctx, limit)
+}
+
+func (s *WebhookService) RecordWebhookDeliveryAttempt(ctx context.Context, id int, result wtf.WebhookDeliveryResult) (*wtf.WebhookDelivery, error) {
+	return s.backend.RecordWebhookDeliveryAttempt(ctx, id, result)
+}
+
+// APITokenService wraps the HTTP API token service but authenticates tokens
+// through the server's backing service since tokens are only validated by
+// the server.
+type APITokenService struct {
+	*wtfhttp.APITokenService
+	backend wtf.APITokenService
+}
+
+func (s *APITokenService) AuthenticateAPIToken(ctx context.Context, token string) (*wtf.APIToken, error) {
+	return s.backend.AuthenticateAPIToken(ctx, token)
+}
+
+// SessionService wraps the HTTP session service but creates & authenticates
+// sessions through the server's backing service since those only occur on
+// the server during browser login & requests.
+type SessionService struct {
+	*wtfhttp.SessionService
+	backend wtf.SessionService
+}
+
+func (s *SessionService) CreateSession(ctx context.Context, session *wtf.Session) error {
+	return s.backend.CreateSession(ctx, session)
+}
+
+func (s *SessionService) AuthenticateSession(ctx context.Context, id int) (*wtf.Session, error) {
+	return s.backend.AuthenticateSession(ctx, id)
+}
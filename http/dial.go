@@ -6,7 +6,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/benbjohnson/wtf"
@@ -27,23 +29,43 @@ func (s *Server) registerDialRoutes(r *mux.Router) {
 	r.HandleFunc("/dials/new", s.handleDialNew).Methods("GET")
 	r.HandleFunc("/dials/new", s.handleDialCreate).Methods("POST")
 
+	// Report of average dial values over time.
+	r.HandleFunc("/dials/report", s.handleDialReport).Methods("GET")
+
+	// Report of average dial values by hour of day & day of week.
+	r.HandleFunc("/dials/heatmap", s.handleDialHeatmap).Methods("GET")
+
 	// View a single dial.
 	r.HandleFunc("/dials/{id}", s.handleDialView).Methods("GET")
 
+	// View historical values of a single dial.
+	r.HandleFunc("/dials/{id}/report", s.handleDialValueReport).Methods("GET")
+	r.HandleFunc("/dials/{id}/heatmap", s.handleDialHeatmap).Methods("GET")
+
 	// HTML form for updating an existing dial.
 	r.HandleFunc("/dials/{id}/edit", s.handleDialEdit).Methods("GET")
 	r.HandleFunc("/dials/{id}/edit", s.handleDialUpdate).Methods("PATCH")
 
+	// API endpoint for updating dials.
+	r.HandleFunc("/dials/{id}", s.handleDialUpdate).Methods("PATCH")
+
 	// Removing a dial.
 	r.HandleFunc("/dials/{id}", s.handleDialDelete).Methods("DELETE")
 
+	// Transferring a dial to another member.
+	r.HandleFunc("/dials/{id}/transfer", s.handleDialTransfer).Methods("POST")
+
+	// Regenerating the invite code of a dial.
+	r.HandleFunc("/dials/{id}/invite", s.handleDialInviteRotate).Methods("POST")
+
 	// Updating the value for the user's membership.
 	r.HandleFunc("/dials/{id}/membership", s.handleDialSetMembershipValue).Methods("PUT")
 }
 
 // handleDialIndex handles the "GET /dials" route. This route can optionally
 // accept filter arguments and outputs a list of all dials that the current
-// user is a member of.
+// user is a member of. JSON requests pass the filter in the body while other
+// requests use query parameters so filtered lists can be linked & exported.
 //
 // The endpoint works with HTML, JSON, & CSV formats.
 func (s *Server) handleDialIndex(w http.ResponseWriter, r *http.Request) {
@@ -56,6 +78,11 @@ func (s *Server) handleDialIndex(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	default:
+		var err error
+		if filter, err = parseDialFilter(r.URL.Query()); err != nil {
+			Error(w, r, err)
+			return
+		}
 		filter.Offset, _ = strconv.Atoi(r.URL.Query().Get("offset"))
 		filter.Limit = 20
 	}
@@ -99,12 +126,216 @@ func (s *Server) handleDialIndex(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// parseDialFilter parses the "tag", "userID", "name", "minValue", "maxValue"
+// & "sortBy" query parameters used to filter the dial list. Blank parameters
+// are ignored.
+func parseDialFilter(q url.Values) (filter wtf.DialFilter, err error) {
+	if v := q.Get("tag"); v != "" {
+		filter.Tag = &v
+	}
+	if v := q.Get("name"); v != "" {
+		filter.Name = &v
+	}
+	filter.SortBy = q.Get("sortBy")
+
+	if v := q.Get("userID"); v != "" {
+		userID, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, wtf.Errorf(wtf.EINVALID, "Invalid user ID format")
+		}
+		filter.UserID = &userID
+	}
+
+	if v := q.Get("minValue"); v != "" {
+		minValue, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, wtf.Errorf(wtf.EINVALID, "Invalid min value format")
+		}
+		filter.MinValue = &minValue
+	}
+
+	if v := q.Get("maxValue"); v != "" {
+		maxValue, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, wtf.Errorf(wtf.EINVALID, "Invalid max value format")
+		}
+		filter.MaxValue = &maxValue
+	}
+
+	return filter, nil
+}
+
 // findDialsResponse represents the output JSON struct for "GET /dials".
 type findDialsResponse struct {
 	Dials []*wtf.Dial `json:"dials"`
 	N     int         `json:"n"`
 }
 
+// handleDialReport handles the "GET /dials/report" route. It returns the
+// average value across all of the user's dials between the "start" & "end"
+// query parameters, slotted by "interval". Times are in RFC 3339 format and the
+// interval is a Go duration string (e.g. "5m"). Defaults to the last hour in
+// one minute intervals.
+func (s *Server) handleDialReport(w http.ResponseWriter, r *http.Request) {
+	// Force application/json output.
+	r.Header.Set("Accept", "application/json")
+
+	// Parse report time range & interval.
+	start, end, interval, err := parseReportRange(r.URL.Query(), wtf.LocationFromContext(r.Context()))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Generate report from the database.
+	report, err := s.DialService.AverageDialValueReport(r.Context(), start, end, interval)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Write report back as JSON response.
+	w.Header().Set("Content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		LogError(r, err)
+		return
+	}
+}
+
+// parseReportRange parses the "start", "end", & "interval" query parameters
+// used by report endpoints. Times are in RFC 3339 format and the interval is a
+// Go duration string. Defaults to the last hour in one minute intervals. The
+// default end time is aligned to the interval in loc.
+func parseReportRange(q url.Values, loc *time.Location) (start, end time.Time, interval time.Duration, err error) {
+	// Parse report interval, if specified.
+	interval = time.Minute
+	if v := q.Get("interval"); v != "" {
+		if interval, err = time.ParseDuration(v); err != nil {
+			return start, end, interval, wtf.Errorf(wtf.EINVALID, "Invalid interval format")
+		}
+	}
+
+	// Parse time range. Defaults to the hour prior to the current interval.
+	end = wtf.TruncateReportTime(time.Now(), interval, loc).Add(interval)
+	if v := q.Get("end"); v != "" {
+		if end, err = time.Parse(time.RFC3339, v); err != nil {
+			return start, end, interval, wtf.Errorf(wtf.EINVALID, "Invalid end time format")
+		}
+	}
+
+	start = end.Add(-1 * time.Hour)
+	if v := q.Get("start"); v != "" {
+		if start, err = time.Parse(time.RFC3339, v); err != nil {
+			return start, end, interval, wtf.Errorf(wtf.EINVALID, "Invalid start time format")
+		}
+	}
+
+	return start, end, interval, nil
+}
+
+// parseDialValueReportOptions parses the "stats" & "threshold" query
+// parameters used by the dial value report.
+func parseDialValueReportOptions(q url.Values) (opt wtf.DialValueReportOptions, err error) {
+	if v := q.Get("stats"); v != "" {
+		if opt.Stats, err = strconv.ParseBool(v); err != nil {
+			return opt, wtf.Errorf(wtf.EINVALID, "Invalid stats format")
+		}
+	}
+
+	if v := q.Get("threshold"); v != "" {
+		threshold, err := strconv.Atoi(v)
+		if err != nil {
+			return opt, wtf.Errorf(wtf.EINVALID, "Invalid threshold format")
+		}
+		opt.Threshold = &threshold
+	}
+
+	return opt, nil
+}
+
+// DefaultHeatmapRange is the time range used by the heatmap report if no
+// start time is specified.
+const DefaultHeatmapRange = 28 * 24 * time.Hour
+
+// handleDialHeatmap handles the "GET /dials/heatmap" & "GET /dials/:id/heatmap"
+// routes. It returns the average value of a single dial or of the dials
+// listed in the "dialID" query parameters for each hour of each day of the
+// week. If no dials are specified then all of the user's dials are included.
+// The time range is set by the "start" & "end" query parameters in RFC 3339
+// format and defaults to the last four weeks.
+//
+// The endpoint works with JSON & CSV formats.
+func (s *Server) handleDialHeatmap(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	// Parse dial IDs from the path or from the query parameters.
+	var dialIDs []int
+	if v, ok := mux.Vars(r)["id"]; ok {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid ID format"))
+			return
+		}
+		dialIDs = append(dialIDs, id)
+	}
+	for _, v := range q["dialID"] {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid dial ID format"))
+			return
+		}
+		dialIDs = append(dialIDs, id)
+	}
+
+	// Parse time range. Defaults to the last four weeks.
+	end := time.Now()
+	if v := q.Get("end"); v != "" {
+		var err error
+		if end, err = time.Parse(time.RFC3339, v); err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid end time format"))
+			return
+		}
+	}
+
+	start := end.Add(-DefaultHeatmapRange)
+	if v := q.Get("start"); v != "" {
+		var err error
+		if start, err = time.Parse(time.RFC3339, v); err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid start time format"))
+			return
+		}
+	}
+
+	// Generate report from the database.
+	report, err := s.DialService.DialHeatmapReport(r.Context(), dialIDs, start, end)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Render output based on HTTP accept header. Defaults to JSON.
+	switch r.Header.Get("Accept") {
+	case "text/csv":
+		w.Header().Set("Content-type", "text/csv")
+		enc := csv.NewDialHeatmapEncoder(w)
+		if err := enc.Encode(report); err != nil {
+			LogError(r, err)
+			return
+		}
+		if err := enc.Close(); err != nil {
+			LogError(r, err)
+			return
+		}
+
+	default:
+		w.Header().Set("Content-type", "application/json")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			LogError(r, err)
+			return
+		}
+	}
+}
+
 // handleDialView handles the "GET /dials/:id" route. It updates
 func (s *Server) handleDialView(w http.ResponseWriter, r *http.Request) {
 	// Parse ID from path.
@@ -138,18 +369,162 @@ func (s *Server) handleDialView(w http.ResponseWriter, r *http.Request) {
 		}
 
 	default:
-		tmpl := html.DialViewTemplate{
-			Dial:      dial,
-			InviteURL: fmt.Sprintf("%s/invite/%s", s.URL(), dial.InviteCode),
+		s.renderDialView(w, r, dial, html.DialViewTemplate{})
+	}
+}
+
+// renderDialView renders the dial view page for a dial with its memberships
+// attached. The history, alerts & incoming webhooks are fetched for the page.
+func (s *Server) renderDialView(w http.ResponseWriter, r *http.Request, dial *wtf.Dial, tmpl html.DialViewTemplate) {
+	// Determine the history range to display. Defaults to the last hour.
+	reportRange := r.URL.Query().Get("range")
+	rng, ok := dialViewReportRanges[reportRange]
+	if !ok {
+		reportRange, rng = "1h", dialViewReportRanges["1h"]
+	}
+	end := wtf.TruncateReportTime(time.Now(), rng.Interval, wtf.LocationFromContext(r.Context())).Add(rng.Interval)
+	start := end.Add(-rng.Duration)
+
+	// Generate the dial's history report over the selected range.
+	report, err := s.DialService.DialValueReport(r.Context(), []int{dial.ID}, start, end, rng.Interval, wtf.DialValueReportOptions{})
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Generate the dial's heatmap over the last four weeks.
+	heatmap, err := s.DialService.DialHeatmapReport(r.Context(), []int{dial.ID}, time.Now().Add(-DefaultHeatmapRange), time.Now())
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Fetch the dial's alert rules & most recent alerts.
+	rules, _, err := s.AlertService.FindAlertRules(r.Context(), wtf.AlertRuleFilter{DialID: &dial.ID})
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	alerts, _, err := s.AlertService.FindAlerts(r.Context(), wtf.AlertFilter{DialID: &dial.ID, Limit: 10})
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Fetch the current user's incoming webhooks for the dial.
+	incomingWebhooks, _, err := s.IncomingWebhookService.FindIncomingWebhooks(r.Context(), wtf.IncomingWebhookFilter{DialID: &dial.ID})
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Build the query used to download the same report as CSV.
+	q := make(url.Values)
+	q.Set("start", start.UTC().Format(time.RFC3339))
+	q.Set("end", end.UTC().Format(time.RFC3339))
+	q.Set("interval", rng.Interval.String())
+
+	tmpl.Dial = dial
+	tmpl.InviteURL = fmt.Sprintf("%s/invite/%s", s.URL(), dial.InviteCode)
+	tmpl.Report = report
+	tmpl.ReportRange = reportRange
+	tmpl.ReportQuery = q.Encode()
+	tmpl.Heatmap = heatmap
+	tmpl.AlertRules = rules
+	tmpl.Alerts = alerts
+	tmpl.IncomingWebhooks = incomingWebhooks
+	tmpl.IncomingWebhookURL = s.URL() + "/hooks"
+	tmpl.Render(r.Context(), w)
+}
+
+// dialViewReportRanges maps the history ranges selectable on the dial view
+// page to the total duration & the interval used to slot values.
+var dialViewReportRanges = map[string]struct {
+	Duration time.Duration
+	Interval time.Duration
+}{
+	"1h":  {time.Hour, time.Minute},
+	"24h": {24 * time.Hour, 15 * time.Minute},
+	"7d":  {7 * 24 * time.Hour, time.Hour},
+	"30d": {30 * 24 * time.Hour, 6 * time.Hour},
+}
+
+// handleDialValueReport handles the "GET /dials/:id/report" route. It returns
+// the historical values of a single dial between the "start" & "end" query
+// parameters, slotted by "interval". The time range & interval are parsed the
+// same as "GET /dials/report". Per-interval statistics are included if "stats"
+// is true & the summary includes the time above "threshold", if specified.
+//
+// The endpoint works with JSON & CSV formats.
+func (s *Server) handleDialValueReport(w http.ResponseWriter, r *http.Request) {
+	// Parse ID from path.
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid ID format"))
+		return
+	}
+
+	// Parse report time range & interval.
+	start, end, interval, err := parseReportRange(r.URL.Query(), wtf.LocationFromContext(r.Context()))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Parse statistics options.
+	opt, err := parseDialValueReportOptions(r.URL.Query())
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Generate report from the database.
+	report, err := s.DialService.DialValueReport(r.Context(), []int{id}, start, end, interval, opt)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Render output based on HTTP accept header. Defaults to JSON.
+	switch r.Header.Get("Accept") {
+	case "text/csv":
+		w.Header().Set("Content-type", "text/csv")
+		enc := csv.NewDialValueEncoder(w)
+		for _, series := range report.Series {
+			if err := enc.EncodeSeries(series); err != nil {
+				LogError(r, err)
+				return
+			}
+		}
+		if err := enc.Close(); err != nil {
+			LogError(r, err)
+			return
+		}
+
+	default:
+		w.Header().Set("Content-type", "application/json")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			LogError(r, err)
+			return
 		}
-		tmpl.Render(r.Context(), w)
 	}
 }
 
 // handleDialNew handles the "GET /dials/new" route.
 // It renders an HTML form for editing a new dial.
 func (s *Server) handleDialNew(w http.ResponseWriter, r *http.Request) {
-	tmpl := html.DialEditTemplate{Dial: &wtf.Dial{}}
+	// Fetch the user's organizations so the dial can be created within one.
+	orgs, _, err := s.OrganizationService.FindOrganizations(r.Context(), wtf.OrganizationFilter{})
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Preselect the organization if linked from the organization's page.
+	dial := &wtf.Dial{}
+	dial.OrganizationID, _ = strconv.Atoi(r.URL.Query().Get("organizationID"))
+
+	tmpl := html.DialEditTemplate{Dial: dial, Organizations: orgs}
 	tmpl.Render(r.Context(), w)
 }
 
@@ -166,6 +541,11 @@ func (s *Server) handleDialCreate(w http.ResponseWriter, r *http.Request) {
 		}
 	default:
 		dial.Name = r.PostFormValue("name")
+		dial.Aggregation = r.PostFormValue("aggregation")
+		dial.Percentile, _ = strconv.Atoi(r.PostFormValue("percentile"))
+		dial.Trim, _ = strconv.Atoi(r.PostFormValue("trim"))
+		dial.OrganizationID, _ = strconv.Atoi(r.PostFormValue("organizationID"))
+		dial.Tags = strings.Split(r.PostFormValue("tags"), ",")
 	}
 
 	// Create dial in the database.
@@ -194,7 +574,8 @@ func (s *Server) handleDialCreate(w http.ResponseWriter, r *http.Request) {
 			Error(w, r, err)
 			return
 		} else if err != nil {
-			tmpl := html.DialEditTemplate{Dial: &dial, Err: err}
+			orgs, _, _ := s.OrganizationService.FindOrganizations(r.Context(), wtf.OrganizationFilter{})
+			tmpl := html.DialEditTemplate{Dial: &dial, Err: err, Organizations: orgs}
 			tmpl.Render(r.Context(), w)
 			return
 		}
@@ -227,9 +608,10 @@ func (s *Server) handleDialEdit(w http.ResponseWriter, r *http.Request) {
 	tmpl.Render(r.Context(), w)
 }
 
-// handleDialUpdate handles the "PATCH /dials/:id/edit" route. This route
-// reads in the updated fields and issues an update in the database. On success,
-// it redirects to the dial's view page.
+// handleDialUpdate handles the "PATCH /dials/:id" and "PATCH /dials/:id/edit"
+// routes. This route reads in the updated fields and issues an update in the
+// database. On success, it redirects to the dial's view page or returns the
+// updated dial for JSON requests.
 func (s *Server) handleDialUpdate(w http.ResponseWriter, r *http.Request) {
 	// Parse dial ID from the path.
 	id, err := strconv.Atoi(mux.Vars(r)["id"])
@@ -238,26 +620,56 @@ func (s *Server) handleDialUpdate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse fields into an update object.
+	// Parse fields into an update object based on HTTP request's content type.
 	var upd wtf.DialUpdate
-	name := r.PostFormValue("name")
-	upd.Name = &name
+	switch r.Header.Get("Content-type") {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid JSON body"))
+			return
+		}
+	default:
+		name, aggregation := r.PostFormValue("name"), r.PostFormValue("aggregation")
+		percentile, _ := strconv.Atoi(r.PostFormValue("percentile"))
+		trim, _ := strconv.Atoi(r.PostFormValue("trim"))
+		upd.Name, upd.Aggregation = &name, &aggregation
+		tags := strings.Split(r.PostFormValue("tags"), ",")
+		upd.Percentile, upd.Trim = &percentile, &trim
+		upd.Tags = &tags
+	}
 
 	// Update the dial in the database.
 	dial, err := s.DialService.UpdateDial(r.Context(), id, upd)
-	if wtf.ErrorCode(err) == wtf.EINTERNAL {
-		Error(w, r, err)
-		return
-	} else if err != nil {
-		tmpl := html.DialEditTemplate{Dial: dial, Err: err}
-		tmpl.Render(r.Context(), w)
-		return
-	}
 
-	// Save a message to display to the user on the next page.
-	// Then redirect them to the dial's view page.
-	SetFlash(w, "Dial successfully updated.")
-	http.Redirect(w, r, fmt.Sprintf("/dials/%d", dial.ID), http.StatusFound)
+	// Write updated dial to response based on accept header.
+	switch r.Header.Get("Accept") {
+	case "application/json":
+		if err != nil {
+			Error(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-type", "application/json")
+		if err := json.NewEncoder(w).Encode(dial); err != nil {
+			LogError(r, err)
+			return
+		}
+
+	default:
+		if wtf.ErrorCode(err) == wtf.EINTERNAL {
+			Error(w, r, err)
+			return
+		} else if err != nil {
+			tmpl := html.DialEditTemplate{Dial: dial, Err: err}
+			tmpl.Render(r.Context(), w)
+			return
+		}
+
+		// Save a message to display to the user on the next page.
+		// Then redirect them to the dial's view page.
+		SetFlash(w, "Dial successfully updated.")
+		http.Redirect(w, r, fmt.Sprintf("/dials/%d", dial.ID), http.StatusFound)
+	}
 }
 
 // handleDialDelete handles the "DELETE /dials/:id" route. This route
@@ -289,6 +701,119 @@ func (s *Server) handleDialDelete(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleDialTransfer handles the "POST /dials/:id/transfer" route. This route
+// moves ownership of the dial to another member. On success, it redirects to
+// the dial's view page or returns the updated dial for JSON requests.
+func (s *Server) handleDialTransfer(w http.ResponseWriter, r *http.Request) {
+	// Parse dial ID from path.
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid ID format"))
+		return
+	}
+
+	// Parse the new owner's user ID based on HTTP request's content type.
+	var jsonRequest jsonTransferDialRequest
+	switch r.Header.Get("Content-type") {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&jsonRequest); err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid JSON body"))
+			return
+		}
+	default:
+		if jsonRequest.UserID, err = strconv.Atoi(r.PostFormValue("userID")); err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid user ID format"))
+			return
+		}
+	}
+
+	// Transfer the dial to the new owner.
+	dial, err := s.DialService.TransferDial(r.Context(), id, jsonRequest.UserID)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Render output to the client based on HTTP accept header.
+	switch r.Header.Get("Accept") {
+	case "application/json":
+		w.Header().Set("Content-type", "application/json")
+		if err := json.NewEncoder(w).Encode(dial); err != nil {
+			LogError(r, err)
+			return
+		}
+
+	default:
+		SetFlash(w, "Dial successfully transferred.")
+		http.Redirect(w, r, fmt.Sprintf("/dials/%d", dial.ID), http.StatusFound)
+	}
+}
+
+type jsonTransferDialRequest struct {
+	UserID int `json:"userID"`
+}
+
+// handleDialInviteRotate handles the "POST /dials/:id/invite" route. This
+// route replaces the dial's invite code with a new code & optional limits.
+// On success, it redirects to the dial's view page or returns the updated
+// dial for JSON requests.
+func (s *Server) handleDialInviteRotate(w http.ResponseWriter, r *http.Request) {
+	// Parse dial ID from path.
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid ID format"))
+		return
+	}
+
+	// Parse invite options based on HTTP request's content type. The HTML
+	// form specifies the expiration as a duration from the current time.
+	var opt wtf.DialInviteOptions
+	switch r.Header.Get("Content-type") {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&opt); err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid JSON body"))
+			return
+		}
+	default:
+		if v := r.PostFormValue("expiresIn"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid invite expiration format"))
+				return
+			}
+			expiresAt := time.Now().Add(d)
+			opt.ExpiresAt = &expiresAt
+		}
+		if v := r.PostFormValue("maxUses"); v != "" {
+			if opt.MaxUses, err = strconv.Atoi(v); err != nil {
+				Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid max uses format"))
+				return
+			}
+		}
+	}
+
+	// Replace the invite code.
+	dial, err := s.DialService.RotateDialInvite(r.Context(), id, opt)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Render output to the client based on HTTP accept header.
+	switch r.Header.Get("Accept") {
+	case "application/json":
+		w.Header().Set("Content-type", "application/json")
+		if err := json.NewEncoder(w).Encode(dial); err != nil {
+			LogError(r, err)
+			return
+		}
+
+	default:
+		SetFlash(w, "Invite link successfully regenerated.")
+		http.Redirect(w, r, fmt.Sprintf("/dials/%d", dial.ID), http.StatusFound)
+	}
+}
+
 // handleDialSetMembershipValue handles the "PUT /dials/:id/membership" route.
 func (s *Server) handleDialSetMembershipValue(w http.ResponseWriter, r *http.Request) {
 	var jsonRequest jsonSetDialMembershipValueRequest
@@ -305,7 +830,7 @@ func (s *Server) handleDialSetMembershipValue(w http.ResponseWriter, r *http.Req
 	}
 
 	// Update value for the user's membership on the dial.
-	if err := s.DialService.SetDialMembershipValue(r.Context(), id, jsonRequest.Value); err != nil {
+	if err := s.DialService.SetDialMembershipValue(r.Context(), id, jsonRequest.Value, jsonRequest.Note); err != nil {
 		Error(w, r, err)
 		return
 	}
@@ -316,7 +841,8 @@ func (s *Server) handleDialSetMembershipValue(w http.ResponseWriter, r *http.Req
 }
 
 type jsonSetDialMembershipValueRequest struct {
-	Value int `json:"value"`
+	Value int    `json:"value"`
+	Note  string `json:"note"`
 }
 
 // DialService implements the wtf.DialService over the HTTP protocol.
@@ -421,9 +947,37 @@ func (s *DialService) CreateDial(ctx context.Context, dial *wtf.Dial) error {
 	return nil
 }
 
-// UpdateDial is not implemented by the HTTP service.
+// UpdateDial updates an existing dial by ID. Only the dial owner & admins can
+// update a dial. Returns ENOTFOUND if dial does not exist. Returns EUNAUTHORIZED
+// if user is not the dial owner or an admin.
 func (s *DialService) UpdateDial(ctx context.Context, id int, upd wtf.DialUpdate) (*wtf.Dial, error) {
-	return nil, wtf.Errorf(wtf.ENOTIMPLEMENTED, "Not implemented.")
+	// Marshal update fields into JSON format.
+	body, err := json.Marshal(upd)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "PATCH", fmt.Sprintf("/dials/%d", id), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	// Issue request. Any non-200 status code is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal the updated dial data.
+	var dial wtf.Dial
+	if err := json.NewDecoder(resp.Body).Decode(&dial); err != nil {
+		return nil, err
+	}
+	return &dial, nil
 }
 
 // DeleteDial permanently removes a dial by ID. Only the dial owner may delete
@@ -448,14 +1002,83 @@ func (s *DialService) DeleteDial(ctx context.Context, id int) error {
 	return nil
 }
 
-// SetDialMembershipValue sets the value of the user's membership in a dial.
-// This works the same as calling UpdateDialMembership() although it doesn't
-// require that the user know their membership ID. Only the dial ID.
+// TransferDial transfers ownership of a dial to another member of the dial.
+// Only the dial owner may transfer a dial & the previous owner remains as an
+// admin. Returns ENOTFOUND if dial does not exist. Returns EUNAUTHORIZED if
+// user is not the dial owner. Returns EINVALID if the new owner is not a member.
+func (s *DialService) TransferDial(ctx context.Context, id, userID int) (*wtf.Dial, error) {
+	// Marshal new owner into JSON format.
+	body, err := json.Marshal(jsonTransferDialRequest{UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "POST", fmt.Sprintf("/dials/%d/transfer", id), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	// Issue request. Any non-200 status code is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal the transferred dial data.
+	var dial wtf.Dial
+	if err := json.NewDecoder(resp.Body).Decode(&dial); err != nil {
+		return nil, err
+	}
+	return &dial, nil
+}
+
+// RotateDialInvite generates a new invite code for the dial with optional
+// limits. The previous code stops working immediately. Only the dial owner &
+// admins can rotate the invite code. Returns ENOTFOUND if dial does not exist.
+// Returns EUNAUTHORIZED if user is not the dial owner or an admin.
+func (s *DialService) RotateDialInvite(ctx context.Context, id int, opt wtf.DialInviteOptions) (*wtf.Dial, error) {
+	// Marshal invite options into JSON format.
+	body, err := json.Marshal(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "POST", fmt.Sprintf("/dials/%d/invite", id), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	// Issue request. Any non-200 status code is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal the updated dial data.
+	var dial wtf.Dial
+	if err := json.NewDecoder(resp.Body).Decode(&dial); err != nil {
+		return nil, err
+	}
+	return &dial, nil
+}
+
+// SetDialMembershipValue sets the value of the user's membership in a dial
+// along with an optional note. This works the same as calling
+// UpdateDialMembership() although it doesn't require that the user know their
+// membership ID. Only the dial ID.
 //
 // Returns ENOTFOUND if the membership does not exist.
-func (s *DialService) SetDialMembershipValue(ctx context.Context, dialID, value int) error {
-	// Marshal value into JSON format.
-	body, err := json.Marshal(jsonSetDialMembershipValueRequest{Value: value})
+func (s *DialService) SetDialMembershipValue(ctx context.Context, dialID, value int, note string) error {
+	// Marshal value & note into JSON format.
+	body, err := json.Marshal(jsonSetDialMembershipValueRequest{Value: value, Note: note})
 	if err != nil {
 		return err
 	}
@@ -478,7 +1101,137 @@ func (s *DialService) SetDialMembershipValue(ctx context.Context, dialID, value
 	return nil
 }
 
-// AverageDialValueReport is not implemented by the HTTP service.
+// AverageDialValueReport returns a report of the average dial value across
+// all dials that the user is a member of. Average values are computed
+// between start & end time and are slotted into given intervals.
 func (s *DialService) AverageDialValueReport(ctx context.Context, start, end time.Time, interval time.Duration) (*wtf.DialValueReport, error) {
-	return nil, wtf.Errorf(wtf.ENOTIMPLEMENTED, "Not implemented.")
+	// Encode time range & interval as query parameters.
+	q := make(url.Values)
+	q.Set("start", start.Format(time.RFC3339))
+	q.Set("end", end.Format(time.RFC3339))
+	q.Set("interval", interval.String())
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "GET", "/dials/report?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Issue request. Any non-200 status code is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal the report records.
+	var report wtf.DialValueReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// DialValueReport returns a report with a separate series of historical
+// values for each of the given dials. Values are slotted into intervals
+// between start & end time. If no dial IDs are specified then every dial
+// that the user is a member of is included. Each series includes a summary
+// & each record optionally includes statistics about its interval.
+func (s *DialService) DialValueReport(ctx context.Context, dialIDs []int, start, end time.Time, interval time.Duration, opt wtf.DialValueReportOptions) (*wtf.DialValueReport, error) {
+	// Look up the user's dials if none are specified as the report is
+	// served separately for each dial.
+	if len(dialIDs) == 0 {
+		dials, _, err := s.FindDials(ctx, wtf.DialFilter{})
+		if err != nil {
+			return nil, err
+		}
+		for _, dial := range dials {
+			dialIDs = append(dialIDs, dial.ID)
+		}
+	}
+
+	// Fetch the report for each dial & combine the series.
+	report := &wtf.DialValueReport{Series: make([]*wtf.DialValueSeries, 0, len(dialIDs))}
+	for _, id := range dialIDs {
+		other, err := s.dialValueReport(ctx, id, start, end, interval, opt)
+		if err != nil {
+			return nil, err
+		}
+		report.Series = append(report.Series, other.Series...)
+	}
+	return report, nil
+}
+
+// dialValueReport returns the historical values of a single dial.
+func (s *DialService) dialValueReport(ctx context.Context, id int, start, end time.Time, interval time.Duration, opt wtf.DialValueReportOptions) (*wtf.DialValueReport, error) {
+	// Encode time range, interval & options as query parameters.
+	q := make(url.Values)
+	q.Set("start", start.Format(time.RFC3339))
+	q.Set("end", end.Format(time.RFC3339))
+	q.Set("interval", interval.String())
+	if opt.Stats {
+		q.Set("stats", "true")
+	}
+	if opt.Threshold != nil {
+		q.Set("threshold", strconv.Itoa(*opt.Threshold))
+	}
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "GET", fmt.Sprintf("/dials/%d/report?%s", id, q.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Issue request. Any non-200 status code is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal the report series.
+	var report wtf.DialValueReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// DialHeatmapReport returns the average value of the given dials for each
+// hour of each day of the week between start & end time. If no dial IDs are
+// specified then every dial that the user is a member of is included.
+func (s *DialService) DialHeatmapReport(ctx context.Context, dialIDs []int, start, end time.Time) (*wtf.DialHeatmapReport, error) {
+	// Encode dials & time range as query parameters.
+	q := make(url.Values)
+	for _, id := range dialIDs {
+		q.Add("dialID", strconv.Itoa(id))
+	}
+	q.Set("start", start.Format(time.RFC3339))
+	q.Set("end", end.Format(time.RFC3339))
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "GET", "/dials/heatmap?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Issue request. Any non-200 status code is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal the report.
+	var report wtf.DialHeatmapReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, err
+	}
+	return &report, nil
 }
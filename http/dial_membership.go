@@ -1,12 +1,17 @@
 package http
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/benbjohnson/wtf"
+	"github.com/benbjohnson/wtf/csv"
 	"github.com/benbjohnson/wtf/http/html"
 	"github.com/gorilla/mux"
 )
@@ -17,9 +22,21 @@ func (s *Server) registerDialMembershipRoutes(r *mux.Router) {
 	r.HandleFunc("/invite/{code}", s.handleDialMembershipNew).Methods("GET")
 	r.HandleFunc("/invite/{code}", s.handleDialMembershipCreate).Methods("POST")
 
+	// API endpoints for listing, viewing & creating memberships.
+	r.HandleFunc("/dial-memberships", s.handleDialMembershipIndex).Methods("GET")
+	r.HandleFunc("/dial-memberships", s.handleDialMembershipJoin).Methods("POST")
+
+	// Historical values of a membership or of all memberships on a dial.
+	r.HandleFunc("/dial-memberships/report", s.handleDialMembershipReport).Methods("GET")
+	r.HandleFunc("/dial-memberships/{id}", s.handleDialMembershipView).Methods("GET")
+
 	// Update membership WTF level.
 	r.HandleFunc("/dial-memberships/{id}", s.handleDialMembershipUpdate).Methods("PATCH")
 
+	// Update membership weight & role. Only available to the dial owner & admins.
+	r.HandleFunc("/dial-memberships/{id}/weight", s.handleDialMembershipSetWeight).Methods("PUT")
+	r.HandleFunc("/dial-memberships/{id}/role", s.handleDialMembershipSetRole).Methods("PUT")
+
 	// Remove membership.
 	r.HandleFunc("/dial-memberships/{id}", s.handleDialMembershipDelete).Methods("DELETE")
 }
@@ -58,6 +75,13 @@ func (s *Server) handleDialMembershipNew(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Reject expired or used up invite codes before asking the user to join.
+	// The service checks the invite again when the membership is created.
+	if err := dials[0].ValidateInvite(time.Now()); err != nil {
+		Error(w, r, err)
+		return
+	}
+
 	// Render HTML page asking user to confirm they want to join the dial.
 	tmpl := html.DialMembershipCreateTemplate{Dial: dials[0]}
 	tmpl.Render(r.Context(), w)
@@ -83,10 +107,11 @@ func (s *Server) handleDialMembershipCreate(w http.ResponseWriter, r *http.Reque
 	}
 
 	// Create a new membership between the current user and the dial associated
-	// with the invite code.
+	// with the invite code. The service rejects expired or used up codes.
 	membership := &wtf.DialMembership{
-		DialID: dials[0].ID,
-		UserID: userID,
+		DialID:     dials[0].ID,
+		UserID:     userID,
+		InviteCode: code,
 	}
 	if err := s.DialMembershipService.CreateDialMembership(r.Context(), membership); err != nil {
 		Error(w, r, err)
@@ -99,6 +124,175 @@ func (s *Server) handleDialMembershipCreate(w http.ResponseWriter, r *http.Reque
 	http.Redirect(w, r, fmt.Sprintf("/dials/%d", membership.DialID), http.StatusFound)
 }
 
+// handleDialMembershipIndex handles the "GET /dial-memberships" route. This
+// route accepts an optional JSON filter and returns all matching memberships
+// on dials that the current user is a member of.
+func (s *Server) handleDialMembershipIndex(w http.ResponseWriter, r *http.Request) {
+	// Force application/json output.
+	r.Header.Set("Accept", "application/json")
+
+	// Parse optional filter object.
+	var filter wtf.DialMembershipFilter
+	switch r.Header.Get("Content-type") {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&filter); err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid JSON body"))
+			return
+		}
+	default:
+		filter.Offset, _ = strconv.Atoi(r.URL.Query().Get("offset"))
+		filter.Limit = 20
+	}
+
+	// Fetch memberships from database.
+	memberships, n, err := s.DialMembershipService.FindDialMemberships(r.Context(), filter)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Write memberships & total count as JSON response.
+	w.Header().Set("Content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(findDialMembershipsResponse{
+		DialMemberships: memberships,
+		N:               n,
+	}); err != nil {
+		LogError(r, err)
+		return
+	}
+}
+
+// findDialMembershipsResponse represents the output JSON struct for "GET /dial-memberships".
+type findDialMembershipsResponse struct {
+	DialMemberships []*wtf.DialMembership `json:"dialMemberships"`
+	N               int                   `json:"n"`
+}
+
+// handleDialMembershipView handles the "GET /dial-memberships/:id" route.
+// It returns a single membership along with its associated dial & user.
+func (s *Server) handleDialMembershipView(w http.ResponseWriter, r *http.Request) {
+	// Parse membership ID from URL path.
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid ID format"))
+		return
+	}
+
+	// Force application/json output.
+	r.Header.Set("Accept", "application/json")
+
+	// Fetch membership from the database.
+	membership, err := s.DialMembershipService.FindDialMembershipByID(r.Context(), id)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Write membership as JSON response.
+	w.Header().Set("Content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(membership); err != nil {
+		LogError(r, err)
+		return
+	}
+}
+
+// handleDialMembershipReport handles the "GET /dial-memberships/report" route.
+// It returns the historical values of the membership specified by the "id"
+// query parameter or of every membership on the dial specified by "dialID".
+// The time range & interval are parsed the same as "GET /dials/report".
+//
+// The endpoint works with JSON & CSV formats.
+func (s *Server) handleDialMembershipReport(w http.ResponseWriter, r *http.Request) {
+	// Parse membership or dial ID to report on.
+	q := r.URL.Query()
+	var filter wtf.DialMembershipValueReportFilter
+	if v := q.Get("id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid ID format"))
+			return
+		}
+		filter.ID = &id
+	}
+	if v := q.Get("dialID"); v != "" {
+		dialID, err := strconv.Atoi(v)
+		if err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid dial ID format"))
+			return
+		}
+		filter.DialID = &dialID
+	}
+
+	// Parse report time range & interval.
+	start, end, interval, err := parseReportRange(q, wtf.LocationFromContext(r.Context()))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Generate report from the database.
+	report, err := s.DialMembershipService.DialMembershipValueReport(r.Context(), filter, start, end, interval)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Render output based on HTTP accept header. Defaults to JSON.
+	switch r.Header.Get("Accept") {
+	case "text/csv":
+		w.Header().Set("Content-type", "text/csv")
+		enc := csv.NewDialMembershipValueEncoder(w)
+		for _, series := range report.Series {
+			if err := enc.EncodeSeries(series); err != nil {
+				LogError(r, err)
+				return
+			}
+		}
+		if err := enc.Close(); err != nil {
+			LogError(r, err)
+			return
+		}
+
+	default:
+		w.Header().Set("Content-type", "application/json")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			LogError(r, err)
+			return
+		}
+	}
+}
+
+// handleDialMembershipJoin handles the "POST /dial-memberships" route. This is
+// the API equivalent of accepting an invitation. The request must include the
+// dial's invite code so that dials cannot be joined by guessing dial IDs.
+func (s *Server) handleDialMembershipJoin(w http.ResponseWriter, r *http.Request) {
+	// Force application/json output.
+	r.Header.Set("Accept", "application/json")
+
+	// Parse membership from JSON request body.
+	var membership wtf.DialMembership
+	if err := json.NewDecoder(r.Body).Decode(&membership); err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid JSON body"))
+		return
+	}
+
+	// Create a new membership for the current user. The service requires a
+	// valid invite code unless the dial belongs to one of the user's
+	// organizations.
+	if err := s.DialMembershipService.CreateDialMembership(r.Context(), &membership); err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Write new membership back as JSON response.
+	w.Header().Set("Content-type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(membership); err != nil {
+		LogError(r, err)
+		return
+	}
+}
+
 // handleDialMembershipUpdate handles the "PATCH /dial-memberships/:id" route.
 // This route is only called via JSON API on the dial view page.
 func (s *Server) handleDialMembershipUpdate(w http.ResponseWriter, r *http.Request) {
@@ -134,6 +328,84 @@ func (s *Server) handleDialMembershipUpdate(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+// handleDialMembershipSetWeight handles the "PUT /dial-memberships/:id/weight"
+// route. This route is only called via JSON API on the dial view page.
+func (s *Server) handleDialMembershipSetWeight(w http.ResponseWriter, r *http.Request) {
+	// Parse membership ID from URL path.
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid ID format"))
+		return
+	}
+
+	// Force application/json output.
+	r.Header.Set("Accept", "application/json")
+
+	// Parse weight from JSON request body.
+	var jsonRequest jsonSetDialMembershipWeightRequest
+	if err := json.NewDecoder(r.Body).Decode(&jsonRequest); err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid JSON body"))
+		return
+	}
+
+	// Update membership weight.
+	membership, err := s.DialMembershipService.SetDialMembershipWeight(r.Context(), id, jsonRequest.Weight)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Write new membership state back as JSON response.
+	w.Header().Set("Content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(membership); err != nil {
+		LogError(r, err)
+		return
+	}
+}
+
+type jsonSetDialMembershipWeightRequest struct {
+	Weight int `json:"weight"`
+}
+
+// handleDialMembershipSetRole handles the "PUT /dial-memberships/:id/role"
+// route. This route is only called via JSON API on the dial view page.
+func (s *Server) handleDialMembershipSetRole(w http.ResponseWriter, r *http.Request) {
+	// Parse membership ID from URL path.
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid ID format"))
+		return
+	}
+
+	// Force application/json output.
+	r.Header.Set("Accept", "application/json")
+
+	// Parse role from JSON request body.
+	var jsonRequest jsonSetDialMembershipRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&jsonRequest); err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid JSON body"))
+		return
+	}
+
+	// Update membership role.
+	membership, err := s.DialMembershipService.SetDialMembershipRole(r.Context(), id, jsonRequest.Role)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Write new membership state back as JSON response.
+	w.Header().Set("Content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(membership); err != nil {
+		LogError(r, err)
+		return
+	}
+}
+
+type jsonSetDialMembershipRoleRequest struct {
+	Role string `json:"role"`
+}
+
 // handleDialMembershipDelete handles the "DELETE /dial-memberships/:id" route.
 // This route deletes the given membership and redirects the user.
 func (s *Server) handleDialMembershipDelete(w http.ResponseWriter, r *http.Request) {
@@ -157,15 +429,286 @@ func (s *Server) handleDialMembershipDelete(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	// Let user know the membership has been deleted.
-	SetFlash(w, "Dial membership successfully deleted.")
+	// Render output to the client based on HTTP accept header.
+	switch r.Header.Get("Accept") {
+	case "application/json":
+		w.Header().Set("Content-type", "application/json")
+		w.Write([]byte(`{}`))
+
+	default:
+		// Let user know the membership has been deleted.
+		SetFlash(w, "Dial membership successfully deleted.")
+
+		// If user removed another member then redirect back to the dial's view
+		// page. However, if user removed their own membership then they won't be
+		// able to see the dial anymore so redirect them to the home page.
+		if membership.UserID != wtf.UserIDFromContext(r.Context()) {
+			http.Redirect(w, r, fmt.Sprintf("/dials/%d", membership.DialID), http.StatusFound)
+		} else {
+			http.Redirect(w, r, "/dials", http.StatusFound)
+		}
+	}
+}
+
+// DialMembershipService implements the wtf.DialMembershipService over the HTTP protocol.
+type DialMembershipService struct {
+	Client *Client
+}
+
+// NewDialMembershipService returns a new instance of DialMembershipService.
+func NewDialMembershipService(client *Client) *DialMembershipService {
+	return &DialMembershipService{Client: client}
+}
+
+// FindDialMembershipByID retrieves a membership by ID along with the associated
+// dial & user. Returns ENOTFOUND if membership does exist or user does not have
+// permission to view it.
+func (s *DialMembershipService) FindDialMembershipByID(ctx context.Context, id int) (*wtf.DialMembership, error) {
+	// Create request with API key attached.
+	req, err := s.Client.newRequest(ctx, "GET", fmt.Sprintf("/dial-memberships/%d", id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Issue request. If any other status besides 200, then treats as an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal the returned membership data.
+	var membership wtf.DialMembership
+	if err := json.NewDecoder(resp.Body).Decode(&membership); err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// FindDialMemberships retrieves a list of matching memberships based on filter.
+// Only returns memberships that belong to dials that the current user is a
+// member of. Also returns a count of total matching memberships which may
+// different if "Limit" is specified on the filter.
+func (s *DialMembershipService) FindDialMemberships(ctx context.Context, filter wtf.DialMembershipFilter) ([]*wtf.DialMembership, int, error) {
+	// Marshal filter into JSON format.
+	body, err := json.Marshal(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "GET", "/dial-memberships", bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Issue request. Any non-200 status code is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, 0, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal result set of memberships & total count.
+	var jsonResponse findDialMembershipsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&jsonResponse); err != nil {
+		return nil, 0, err
+	}
+	return jsonResponse.DialMemberships, jsonResponse.N, nil
+}
+
+// CreateDialMembership creates a new membership on a dial for the current user.
+// The membership's Dial must be set with the dial's invite code as users can
+// only join a dial by invitation. Returns EUNAUTHORIZED if there is no current
+// user logged in.
+func (s *DialMembershipService) CreateDialMembership(ctx context.Context, membership *wtf.DialMembership) error {
+	// Marshal membership data into JSON format.
+	body, err := json.Marshal(membership)
+	if err != nil {
+		return err
+	}
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "POST", "/dial-memberships", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	// Issue request. Treat non-201 status codes as errors.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusCreated {
+		return parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal returned membership data.
+	if err := json.NewDecoder(resp.Body).Decode(&membership); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateDialMembership updates the value of a membership. Only the owner of the
+// membership can update the value. Returns EUNAUTHORIZED if user is not the
+// owner. Returns ENOTFOUND if the membership does not exist.
+func (s *DialMembershipService) UpdateDialMembership(ctx context.Context, id int, upd wtf.DialMembershipUpdate) (*wtf.DialMembership, error) {
+	// Marshal update fields into JSON format.
+	body, err := json.Marshal(upd)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "PATCH", fmt.Sprintf("/dial-memberships/%d", id), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	// Issue request. Any non-200 status code is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal the updated membership data.
+	var membership wtf.DialMembership
+	if err := json.NewDecoder(resp.Body).Decode(&membership); err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// SetDialMembershipWeight sets the weight of a membership. Only the owner &
+// admins of the parent dial can set the weight. Returns EUNAUTHORIZED if user
+// cannot manage the dial. Returns ENOTFOUND if the membership does not exist.
+func (s *DialMembershipService) SetDialMembershipWeight(ctx context.Context, id, weight int) (*wtf.DialMembership, error) {
+	// Marshal weight into JSON format.
+	body, err := json.Marshal(jsonSetDialMembershipWeightRequest{Weight: weight})
+	if err != nil {
+		return nil, err
+	}
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "PUT", fmt.Sprintf("/dial-memberships/%d/weight", id), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	// Issue request. Any non-200 status code is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal the updated membership data.
+	var membership wtf.DialMembership
+	if err := json.NewDecoder(resp.Body).Decode(&membership); err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// SetDialMembershipRole sets the role of a membership. The owner role cannot
+// be assigned or removed. Returns EUNAUTHORIZED if user cannot change the role.
+// Returns ENOTFOUND if the membership does not exist.
+func (s *DialMembershipService) SetDialMembershipRole(ctx context.Context, id int, role string) (*wtf.DialMembership, error) {
+	// Marshal role into JSON format.
+	body, err := json.Marshal(jsonSetDialMembershipRoleRequest{Role: role})
+	if err != nil {
+		return nil, err
+	}
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "PUT", fmt.Sprintf("/dial-memberships/%d/role", id), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	// Issue request. Any non-200 status code is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal the updated membership data.
+	var membership wtf.DialMembership
+	if err := json.NewDecoder(resp.Body).Decode(&membership); err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// DeleteDialMembership permanently deletes a membership by ID. Only the
+// membership owner and the parent dial's owner & admins can delete a membership.
+func (s *DialMembershipService) DeleteDialMembership(ctx context.Context, id int) error {
+	// Create a request with API key.
+	req, err := s.Client.newRequest(ctx, "DELETE", fmt.Sprintf("/dial-memberships/%d", id), nil)
+	if err != nil {
+		return err
+	}
+
+	// Issue request. Any non-200 response is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// DialMembershipValueReport returns the historical values of a single
+// membership or of every membership on a dial. Values are slotted into
+// intervals between start & end time. The minimum interval size is one minute.
+func (s *DialMembershipService) DialMembershipValueReport(ctx context.Context, filter wtf.DialMembershipValueReportFilter, start, end time.Time, interval time.Duration) (*wtf.DialMembershipValueReport, error) {
+	// Encode filter, time range, & interval as query parameters.
+	q := make(url.Values)
+	if filter.ID != nil {
+		q.Set("id", strconv.Itoa(*filter.ID))
+	}
+	if filter.DialID != nil {
+		q.Set("dialID", strconv.Itoa(*filter.DialID))
+	}
+	q.Set("start", start.Format(time.RFC3339))
+	q.Set("end", end.Format(time.RFC3339))
+	q.Set("interval", interval.String())
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "GET", "/dial-memberships/report?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Issue request. Any non-200 status code is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
 
-	// If user is the owner then redirect back to the dial's view page. However,
-	// if user was just a member then they won't be able to see the dial anymore
-	// so redirect them to the home page.
-	if membership.Dial.UserID == wtf.UserIDFromContext(r.Context()) {
-		http.Redirect(w, r, fmt.Sprintf("/dials/%d", membership.DialID), http.StatusFound)
-	} else {
-		http.Redirect(w, r, "/dials", http.StatusFound)
+	// Unmarshal the report series.
+	var report wtf.DialMembershipValueReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, err
 	}
+	return &report, nil
 }
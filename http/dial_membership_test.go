@@ -0,0 +1,134 @@
+package http_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+)
+
+// Ensure the HTTP server can return a membership value report as CSV.
+func TestDialMembershipReport(t *testing.T) {
+	s := MustOpenServer(t)
+	defer MustCloseServer(t, s)
+
+	user0 := &wtf.User{ID: 1, Name: "USER1", APIKey: "APIKEY"}
+	ctx0 := wtf.NewContextWithUser(context.Background(), user0)
+
+	// Mock user look up by ID for loading session data & the report generation.
+	s.UserService.FindUserByIDFn = func(ctx context.Context, id int) (*wtf.User, error) {
+		return user0, nil
+	}
+	t0 := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	s.DialMembershipService.DialMembershipValueReportFn = func(ctx context.Context, filter wtf.DialMembershipValueReportFilter, start, end time.Time, interval time.Duration) (*wtf.DialMembershipValueReport, error) {
+		if filter.DialID == nil || *filter.DialID != 2 {
+			t.Fatalf("unexpected dial id: %#v", filter.DialID)
+		} else if got, want := interval, 5*time.Minute; got != want {
+			t.Fatalf("interval=%v, want %v", got, want)
+		}
+		return &wtf.DialMembershipValueReport{
+			Series: []*wtf.DialMembershipValueSeries{{
+				DialMembershipID: 3,
+				DialID:           2,
+				UserID:           1,
+				User:             user0,
+				Records: []*wtf.DialValueRecord{
+					{Value: 10, Timestamp: t0},
+					{Value: 20, Timestamp: t0.Add(5 * time.Minute)},
+				},
+			}},
+		}, nil
+	}
+
+	resp, err := http.DefaultClient.Do(s.MustNewRequest(t, ctx0, "GET", "/dial-memberships/report.csv?dialID=2&interval=5m", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Fatalf("StatusCode=%v, want %v", got, want)
+	} else if buf, err := ioutil.ReadAll(resp.Body); err != nil {
+		t.Fatal(err)
+	} else if got, want := string(buf), ""+
+		"dial_membership_id,dial_id,user_id,user,timestamp,value\n"+
+		"3,2,1,USER1,2000-01-01T00:00:00Z,10\n"+
+		"3,2,1,USER1,2000-01-01T00:05:00Z,20\n"; got != want {
+		t.Fatalf("body=%q, want %q", got, want)
+	}
+}
+
+// Ensure an expired invite code is rejected by the service when joining and
+// that its error is returned to the user.
+func TestDialMembershipCreate_ErrInviteExpired(t *testing.T) {
+	s := MustOpenServer(t)
+	defer MustCloseServer(t, s)
+
+	user0 := &wtf.User{ID: 1, Name: "USER1"}
+	ctx0 := wtf.NewContextWithUser(context.Background(), user0)
+	s.UserService.FindUserByIDFn = func(ctx context.Context, id int) (*wtf.User, error) {
+		return user0, nil
+	}
+
+	s.DialService.FindDialsFn = func(ctx context.Context, filter wtf.DialFilter) ([]*wtf.Dial, int, error) {
+		return []*wtf.Dial{{ID: 2, Name: "DIAL2", InviteCode: "INVITECODE"}}, 1, nil
+	}
+	s.DialMembershipService.CreateDialMembershipFn = func(ctx context.Context, membership *wtf.DialMembership) error {
+		if got, want := membership.InviteCode, "INVITECODE"; got != want {
+			t.Fatalf("InviteCode=%v, want %v", got, want)
+		}
+		return wtf.Errorf(wtf.EINVALID, "Invite code has expired.")
+	}
+
+	resp, err := http.DefaultClient.Do(s.MustNewRequest(t, ctx0, "POST", "/invite/INVITECODE", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if got, want := resp.StatusCode, http.StatusBadRequest; got != want {
+		t.Fatalf("StatusCode=%v, want %v", got, want)
+	} else if buf, err := ioutil.ReadAll(resp.Body); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(string(buf), "Invite code has expired.") {
+		t.Fatalf("unexpected body: %s", buf)
+	}
+}
+
+// Ensure the join page is not shown for an expired invite code.
+func TestDialMembershipNew_ErrInviteExpired(t *testing.T) {
+	s := MustOpenServer(t)
+	defer MustCloseServer(t, s)
+
+	user0 := &wtf.User{ID: 1, Name: "USER1"}
+	ctx0 := wtf.NewContextWithUser(context.Background(), user0)
+	s.UserService.FindUserByIDFn = func(ctx context.Context, id int) (*wtf.User, error) {
+		return user0, nil
+	}
+
+	expiresAt := time.Now().Add(-time.Hour)
+	s.DialService.FindDialsFn = func(ctx context.Context, filter wtf.DialFilter) ([]*wtf.Dial, int, error) {
+		return []*wtf.Dial{{ID: 2, Name: "DIAL2", InviteCode: "INVITECODE", InviteExpiresAt: &expiresAt}}, 1, nil
+	}
+	s.DialMembershipService.FindDialMembershipsFn = func(ctx context.Context, filter wtf.DialMembershipFilter) ([]*wtf.DialMembership, int, error) {
+		return nil, 0, nil
+	}
+
+	resp, err := http.DefaultClient.Do(s.MustNewRequest(t, ctx0, "GET", "/invite/INVITECODE", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if got, want := resp.StatusCode, http.StatusBadRequest; got != want {
+		t.Fatalf("StatusCode=%v, want %v", got, want)
+	} else if buf, err := ioutil.ReadAll(resp.Body); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(string(buf), "Invite code has expired.") {
+		t.Fatalf("unexpected body: %s", buf)
+	}
+}
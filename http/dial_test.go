@@ -2,6 +2,7 @@ package http_test
 
 import (
 	"context"
+	"io/ioutil"
 	"net/http"
 	"strings"
 	"testing"
@@ -70,12 +71,12 @@ func TestDialIndex(t *testing.T) {
 
 	// Ensure server can generate JSON output.
 	t.Run("JSON", func(t *testing.T) {
-		// Mock user look up by API key for API calls.
-		s.UserService.FindUsersFn = func(ctx context.Context, filter wtf.UserFilter) ([]*wtf.User, int, error) {
-			if filter.APIKey == nil || *filter.APIKey != "APIKEY" {
-				t.Fatalf("unexpected api key: %#v", filter.APIKey)
+		// Mock API token look up for API calls.
+		s.APITokenService.AuthenticateAPITokenFn = func(ctx context.Context, token string) (*wtf.APIToken, error) {
+			if token != "APIKEY" {
+				t.Fatalf("unexpected api key: %q", token)
 			}
-			return []*wtf.User{user0}, 1, nil
+			return &wtf.APIToken{ID: 1, UserID: user0.ID, User: user0, Scope: wtf.APITokenScopeAdmin}, nil
 		}
 
 		// Instantiate HTTP service and fetch dials.
@@ -90,4 +91,146 @@ func TestDialIndex(t *testing.T) {
 			t.Fatalf("n=%d, want %d", got, want)
 		}
 	})
+
+	// Ensure query parameters are passed as a filter & applied to the CSV export.
+	t.Run("Filter", func(t *testing.T) {
+		s.UserService.FindUserByIDFn = func(ctx context.Context, id int) (*wtf.User, error) {
+			return user0, nil
+		}
+
+		s.DialService.FindDialsFn = func(ctx context.Context, filter wtf.DialFilter) ([]*wtf.Dial, int, error) {
+			if filter.Tag == nil || *filter.Tag != "backend" {
+				t.Fatalf("unexpected tag: %#v", filter.Tag)
+			} else if filter.UserID == nil || *filter.UserID != 1 {
+				t.Fatalf("unexpected user id: %#v", filter.UserID)
+			} else if filter.MinValue == nil || *filter.MinValue != 10 {
+				t.Fatalf("unexpected min value: %#v", filter.MinValue)
+			} else if filter.MaxValue != nil || filter.Name != nil {
+				t.Fatalf("unexpected filter: %#v", filter)
+			} else if got, want := filter.SortBy, wtf.DialSortByValueDesc; got != want {
+				t.Fatalf("SortBy=%q, want %q", got, want)
+			}
+			return []*wtf.Dial{dial}, 1, nil
+		}
+
+		resp, err := http.DefaultClient.Do(s.MustNewRequest(t, ctx0, "GET", "/dials.csv?tag=backend&userID=1&minValue=10&maxValue=&sortBy=value_desc", nil))
+		if err != nil {
+			t.Fatal(err)
+		} else if got, want := resp.StatusCode, http.StatusOK; got != want {
+			t.Fatalf("StatusCode=%v, want %v", got, want)
+		}
+		defer resp.Body.Close()
+
+		if buf, err := ioutil.ReadAll(resp.Body); err != nil {
+			t.Fatal(err)
+		} else if got, want := string(buf), "id,name,value,created_by,created_at,updated_at,tags\n1,DIAL1,50,USER1,2000-01-01T00:00:00Z,2000-01-01T00:00:00Z,\n"; got != want {
+			t.Fatalf("body=%q, want %q", got, want)
+		}
+	})
+
+	// Ensure an invalid value range returns an error.
+	t.Run("ErrInvalidMinValue", func(t *testing.T) {
+		resp, err := http.DefaultClient.Do(s.MustNewRequest(t, ctx0, "GET", "/dials.json?minValue=abc", nil))
+		if err != nil {
+			t.Fatal(err)
+		} else if got, want := resp.StatusCode, http.StatusBadRequest; got != want {
+			t.Fatalf("StatusCode=%v, want %v", got, want)
+		}
+	})
+}
+
+// Ensure the HTTP server can return a single dial's value report as CSV.
+func TestDialValueReport(t *testing.T) {
+	s := MustOpenServer(t)
+	defer MustCloseServer(t, s)
+
+	user0 := &wtf.User{ID: 1, Name: "USER1", APIKey: "APIKEY"}
+	ctx0 := wtf.NewContextWithUser(context.Background(), user0)
+
+	// Mock user look up by ID for loading session data & the report generation.
+	s.UserService.FindUserByIDFn = func(ctx context.Context, id int) (*wtf.User, error) {
+		return user0, nil
+	}
+	t0 := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	s.DialService.DialValueReportFn = func(ctx context.Context, dialIDs []int, start, end time.Time, interval time.Duration, opt wtf.DialValueReportOptions) (*wtf.DialValueReport, error) {
+		if got, want := dialIDs, []int{2}; !cmp.Equal(got, want) {
+			t.Fatalf("dialIDs=%v, want %v", got, want)
+		} else if got, want := interval, time.Hour; got != want {
+			t.Fatalf("interval=%v, want %v", got, want)
+		} else if !opt.Stats {
+			t.Fatal("expected stats option")
+		}
+		return &wtf.DialValueReport{
+			Series: []*wtf.DialValueSeries{{
+				DialID: 2,
+				Name:   "DIAL2",
+				Records: []*wtf.DialValueRecord{
+					{Value: 10, Timestamp: t0, Stats: &wtf.DialValueStats{Min: 0, Max: 10, Median: 5, StdDev: 5, Count: 2}},
+					{Value: 20, Timestamp: t0.Add(time.Hour), Stats: &wtf.DialValueStats{Min: 20, Max: 20, Median: 20, Count: 1}},
+				},
+			}},
+		}, nil
+	}
+
+	resp, err := http.DefaultClient.Do(s.MustNewRequest(t, ctx0, "GET", "/dials/2/report.csv?interval=1h&stats=true", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Fatalf("StatusCode=%v, want %v", got, want)
+	} else if buf, err := ioutil.ReadAll(resp.Body); err != nil {
+		t.Fatal(err)
+	} else if got, want := string(buf), ""+
+		"dial_id,name,timestamp,value,min,max,median,stddev,count\n"+
+		"2,DIAL2,2000-01-01T00:00:00Z,10,0,10,5,5.00,2\n"+
+		"2,DIAL2,2000-01-01T01:00:00Z,20,20,20,20,0.00,1\n"; got != want {
+		t.Fatalf("body=%q, want %q", got, want)
+	}
+}
+
+// Ensure the HTTP server can return a dial heatmap report as CSV.
+func TestDialHeatmap(t *testing.T) {
+	s := MustOpenServer(t)
+	defer MustCloseServer(t, s)
+
+	user0 := &wtf.User{ID: 1, Name: "USER1", APIKey: "APIKEY"}
+	ctx0 := wtf.NewContextWithUser(context.Background(), user0)
+
+	// Mock user look up by ID for loading session data & the report generation.
+	s.UserService.FindUserByIDFn = func(ctx context.Context, id int) (*wtf.User, error) {
+		return user0, nil
+	}
+	s.DialService.DialHeatmapReportFn = func(ctx context.Context, dialIDs []int, start, end time.Time) (*wtf.DialHeatmapReport, error) {
+		if got, want := dialIDs, []int{2}; !cmp.Equal(got, want) {
+			t.Fatalf("dialIDs=%v, want %v", got, want)
+		} else if got, want := end.Sub(start), wtfhttp.DefaultHeatmapRange; got != want {
+			t.Fatalf("range=%v, want %v", got, want)
+		}
+
+		var report wtf.DialHeatmapReport
+		report.Values[time.Monday][9], report.Counts[time.Monday][9] = 62.5, 4
+		return &report, nil
+	}
+
+	resp, err := http.DefaultClient.Do(s.MustNewRequest(t, ctx0, "GET", "/dials/2/heatmap.csv", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Fatalf("StatusCode=%v, want %v", got, want)
+	} else if buf, err := ioutil.ReadAll(resp.Body); err != nil {
+		t.Fatal(err)
+	} else if lines := strings.Split(strings.TrimSpace(string(buf)), "\n"); len(lines) != 1+7*24 {
+		t.Fatalf("unexpected line count: %d", len(lines))
+	} else if got, want := lines[0], "day,hour,value,count"; got != want {
+		t.Fatalf("header=%q, want %q", got, want)
+	} else if got, want := lines[1], "Sunday,0,0.00,0"; got != want {
+		t.Fatalf("row=%q, want %q", got, want)
+	} else if got, want := lines[1+24+9], "Monday,9,62.50,4"; got != want {
+		t.Fatalf("row=%q, want %q", got, want)
+	}
 }
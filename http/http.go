@@ -47,6 +47,14 @@ func (c *Client) newRequest(ctx context.Context, method, url string, body io.Rea
 		req.Header.Set("Authorization", "Bearer "+user.APIKey)
 	}
 
+	// Pass along the timezone override, if set, so reports are aligned to it.
+	// Otherwise the server uses the user's timezone preference.
+	if loc := wtf.LocationOverrideFromContext(ctx); loc != nil {
+		q := req.URL.Query()
+		q.Set("tz", loc.String())
+		req.URL.RawQuery = q.Encode()
+	}
+
 	// Default to JSON format.
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-type", "application/json")
@@ -57,11 +65,18 @@ func (c *Client) newRequest(ctx context.Context, method, url string, body io.Rea
 // SessionCookieName is the name of the cookie used to store the session.
 const SessionCookieName = "session"
 
-// Session represents session data stored in a secure cookie.
+// Session represents session data stored in a secure cookie. The SessionID
+// refers to a server-side session which must still exist for the user to be
+// considered logged in.
 type Session struct {
 	UserID      int    `json:"userID"`
+	SessionID   int    `json:"sessionID"`
 	RedirectURL string `json:"redirectURL"`
 	State       string `json:"state"`
+
+	// If true, the OAuth flow in progress links a provider account to the
+	// logged in user instead of logging in.
+	Link bool `json:"link"`
 }
 
 // SetFlash sets the flash cookie for the next request to read.
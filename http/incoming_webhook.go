@@ -0,0 +1,346 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/benbjohnson/wtf"
+	"github.com/benbjohnson/wtf/http/html"
+	"github.com/gorilla/mux"
+)
+
+// registerIncomingWebhookRoutes is a helper function for registering routes
+// used to manage incoming webhooks. The route which invokes a webhook is
+// registered separately as it does not require authentication.
+func (s *Server) registerIncomingWebhookRoutes(r *mux.Router) {
+	// API endpoints for listing & creating the current user's incoming
+	// webhooks. Webhooks can also be created via the HTML form on the dial page.
+	r.HandleFunc("/incoming-webhooks", s.handleIncomingWebhookIndex).Methods("GET")
+	r.HandleFunc("/incoming-webhooks", s.handleIncomingWebhookCreate).Methods("POST")
+
+	// Revoke a single incoming webhook.
+	r.HandleFunc("/incoming-webhooks/{id}", s.handleIncomingWebhookDelete).Methods("DELETE")
+}
+
+// handleIncomingWebhookIndex handles the "GET /incoming-webhooks" route. This
+// route accepts an optional JSON filter and returns the current user's
+// matching incoming webhooks.
+func (s *Server) handleIncomingWebhookIndex(w http.ResponseWriter, r *http.Request) {
+	// Force application/json output.
+	r.Header.Set("Accept", "application/json")
+
+	// Parse optional filter object.
+	var filter wtf.IncomingWebhookFilter
+	switch r.Header.Get("Content-type") {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&filter); err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid JSON body"))
+			return
+		}
+	default:
+		filter.Offset, _ = strconv.Atoi(r.URL.Query().Get("offset"))
+		filter.Limit = 20
+	}
+
+	// Fetch incoming webhooks from database.
+	webhooks, n, err := s.IncomingWebhookService.FindIncomingWebhooks(r.Context(), filter)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Write webhooks & total count as JSON response.
+	w.Header().Set("Content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(findIncomingWebhooksResponse{
+		IncomingWebhooks: webhooks,
+		N:                n,
+	}); err != nil {
+		LogError(r, err)
+		return
+	}
+}
+
+// findIncomingWebhooksResponse represents the output JSON struct for
+// "GET /incoming-webhooks".
+type findIncomingWebhooksResponse struct {
+	IncomingWebhooks []*wtf.IncomingWebhook `json:"incomingWebhooks"`
+	N                int                    `json:"n"`
+}
+
+// handleIncomingWebhookCreate handles the "POST /incoming-webhooks" route. It
+// reads the webhook as JSON or from the HTML form on the dial page. As the
+// plaintext token is only available once, the HTML form renders the dial page
+// with the new webhook URL instead of redirecting.
+func (s *Server) handleIncomingWebhookCreate(w http.ResponseWriter, r *http.Request) {
+	var webhook wtf.IncomingWebhook
+	switch r.Header.Get("Content-type") {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&webhook); err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid JSON body"))
+			return
+		}
+	default:
+		if err := r.ParseForm(); err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid form body"))
+			return
+		}
+		webhook.DialID, _ = strconv.Atoi(r.PostForm.Get("dialID"))
+		webhook.Name = r.PostForm.Get("name")
+	}
+
+	// Create incoming webhook in the database.
+	if err := s.IncomingWebhookService.CreateIncomingWebhook(r.Context(), &webhook); err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Render output to the client based on HTTP accept header.
+	switch r.Header.Get("Accept") {
+	case "application/json":
+		w.Header().Set("Content-type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(webhook); err != nil {
+			LogError(r, err)
+			return
+		}
+
+	default:
+		// Fetch the dial & its memberships to render the dial page.
+		dial, err := s.DialService.FindDialByID(r.Context(), webhook.DialID)
+		if err != nil {
+			Error(w, r, err)
+			return
+		} else if dial.Memberships, _, err = s.DialMembershipService.FindDialMemberships(r.Context(), wtf.DialMembershipFilter{DialID: &dial.ID}); err != nil {
+			Error(w, r, err)
+			return
+		}
+		s.renderDialView(w, r, dial, html.DialViewTemplate{NewIncomingWebhook: &webhook})
+	}
+}
+
+// handleIncomingWebhookDelete handles the "DELETE /incoming-webhooks/:id"
+// route. This route revokes the webhook and redirects back to the dial page.
+func (s *Server) handleIncomingWebhookDelete(w http.ResponseWriter, r *http.Request) {
+	// Parse webhook ID from the URL.
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid ID format"))
+		return
+	}
+
+	// Look up webhook by ID so we know which dial to redirect back to.
+	webhooks, _, err := s.IncomingWebhookService.FindIncomingWebhooks(r.Context(), wtf.IncomingWebhookFilter{ID: &id})
+	if err != nil {
+		Error(w, r, err)
+		return
+	} else if len(webhooks) == 0 {
+		Error(w, r, wtf.Errorf(wtf.ENOTFOUND, "Incoming webhook not found."))
+		return
+	}
+
+	// Delete incoming webhook.
+	if err := s.IncomingWebhookService.DeleteIncomingWebhook(r.Context(), id); err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Render output to the client based on HTTP accept header.
+	switch r.Header.Get("Accept") {
+	case "application/json":
+		w.Header().Set("Content-type", "application/json")
+		w.Write([]byte(`{}`))
+
+	default:
+		SetFlash(w, "Incoming webhook successfully revoked.")
+		http.Redirect(w, r, fmt.Sprintf("/dials/%d", webhooks[0].DialID), http.StatusFound)
+	}
+}
+
+// handleIncomingWebhookInvoke handles the "POST /hooks/:token" route. This
+// route does not require a session or API key as the token authenticates the
+// request. The value can be sent as a JSON update or as a "value" form or
+// query parameter where a leading sign (e.g. "+10") denotes a relative change.
+func (s *Server) handleIncomingWebhookInvoke(w http.ResponseWriter, r *http.Request) {
+	// Force application/json output.
+	r.Header.Set("Accept", "application/json")
+
+	// Parse update from JSON body or form/query parameters.
+	var upd wtf.IncomingWebhookUpdate
+	switch r.Header.Get("Content-type") {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid JSON body"))
+			return
+		}
+	default:
+		var err error
+		if upd, err = wtf.ParseIncomingWebhookValue(r.FormValue("value")); err != nil {
+			Error(w, r, err)
+			return
+		}
+		if note := r.FormValue("note"); note != "" {
+			upd.Note = &note
+		}
+	}
+
+	// Update the membership associated with the token.
+	membership, err := s.IncomingWebhookService.InvokeIncomingWebhook(r.Context(), mux.Vars(r)["token"], upd)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Write the updated value as JSON response. Only the value is returned as
+	// the token holder is not necessarily a member of the dial.
+	w.Header().Set("Content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(jsonIncomingWebhookResult{
+		ID:    membership.ID,
+		Value: membership.Value,
+		Note:  membership.Note,
+	}); err != nil {
+		LogError(r, err)
+		return
+	}
+}
+
+// jsonIncomingWebhookResult represents the JSON response of an incoming
+// webhook invocation. It is a subset of the membership fields.
+type jsonIncomingWebhookResult struct {
+	ID    int    `json:"id"`
+	Value int    `json:"value"`
+	Note  string `json:"note,omitempty"`
+}
+
+// IncomingWebhookService implements the wtf.IncomingWebhookService over the
+// HTTP protocol.
+type IncomingWebhookService struct {
+	Client *Client
+}
+
+// NewIncomingWebhookService returns a new instance of IncomingWebhookService.
+func NewIncomingWebhookService(client *Client) *IncomingWebhookService {
+	return &IncomingWebhookService{Client: client}
+}
+
+// FindIncomingWebhooks retrieves a list of the current user's incoming
+// webhooks by filter. Also returns a count of total matching webhooks which
+// may differ if "Limit" is specified on the filter.
+func (s *IncomingWebhookService) FindIncomingWebhooks(ctx context.Context, filter wtf.IncomingWebhookFilter) ([]*wtf.IncomingWebhook, int, error) {
+	// Marshal filter into JSON format.
+	body, err := json.Marshal(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "GET", "/incoming-webhooks", bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Issue request. Any non-200 status code is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, 0, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal result set of webhooks & total webhook count.
+	var jsonResponse findIncomingWebhooksResponse
+	if err := json.NewDecoder(resp.Body).Decode(&jsonResponse); err != nil {
+		return nil, 0, err
+	}
+	return jsonResponse.IncomingWebhooks, jsonResponse.N, nil
+}
+
+// CreateIncomingWebhook creates a new incoming webhook for the current user's
+// membership on a dial. Returns ENOTFOUND if the user is not a member of the
+// dial. Returns EUNAUTHORIZED if the user is a viewer.
+func (s *IncomingWebhookService) CreateIncomingWebhook(ctx context.Context, webhook *wtf.IncomingWebhook) error {
+	// Marshal webhook data into JSON format.
+	body, err := json.Marshal(webhook)
+	if err != nil {
+		return err
+	}
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "POST", "/incoming-webhooks", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	// Issue request. Treat non-201 status codes as errors.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusCreated {
+		return parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal returned webhook data.
+	if err := json.NewDecoder(resp.Body).Decode(&webhook); err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteIncomingWebhook permanently revokes an incoming webhook. Returns
+// ENOTFOUND if the webhook does not exist or belongs to another user.
+func (s *IncomingWebhookService) DeleteIncomingWebhook(ctx context.Context, id int) error {
+	// Create a request with API key.
+	req, err := s.Client.newRequest(ctx, "DELETE", fmt.Sprintf("/incoming-webhooks/%d", id), nil)
+	if err != nil {
+		return err
+	}
+
+	// Issue request. Any non-200 response is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// InvokeIncomingWebhook sets the value of the webhook's membership. The token
+// is sent in the URL path so no user is required on the context.
+func (s *IncomingWebhookService) InvokeIncomingWebhook(ctx context.Context, token string, upd wtf.IncomingWebhookUpdate) (*wtf.DialMembership, error) {
+	// Marshal update into JSON format.
+	body, err := json.Marshal(upd)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create request. The token authenticates the request.
+	req, err := s.Client.newRequest(ctx, "POST", "/hooks/"+url.PathEscape(token), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	// Issue request. Any non-200 status code is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal the updated membership.
+	var membership wtf.DialMembership
+	if err := json.NewDecoder(resp.Body).Decode(&membership); err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
@@ -0,0 +1,55 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/benbjohnson/wtf"
+)
+
+// Ensure an incoming webhook can be invoked without a session using a
+// relative form value.
+func TestIncomingWebhookInvoke(t *testing.T) {
+	s := MustOpenServer(t)
+	defer MustCloseServer(t, s)
+
+	s.IncomingWebhookService.InvokeIncomingWebhookFn = func(ctx context.Context, token string, upd wtf.IncomingWebhookUpdate) (*wtf.DialMembership, error) {
+		if got, want := token, "TOKEN"; got != want {
+			t.Fatalf("token=%v, want %v", got, want)
+		} else if upd.Value != nil {
+			t.Fatalf("unexpected value: %v", *upd.Value)
+		} else if upd.Delta == nil || *upd.Delta != 10 {
+			t.Fatalf("unexpected delta: %v", upd.Delta)
+		} else if upd.Note == nil || *upd.Note != "deploy" {
+			t.Fatalf("unexpected note: %v", upd.Note)
+		}
+		return &wtf.DialMembership{
+			ID: 1, DialID: 2, UserID: 3, Value: 60, Note: "deploy",
+			Dial: &wtf.Dial{ID: 2, InviteCode: "INVITECODE"},
+			User: &wtf.User{ID: 3, Email: "jane@example.com"},
+		}, nil
+	}
+
+	// Relative values must be URL-encoded as "+" decodes to a space.
+	req := s.MustNewRequest(t, context.Background(), "POST", "/hooks/TOKEN", strings.NewReader("value=%2B10&note=deploy"))
+	req.Header.Set("Content-type", "application/x-www-form-urlencoded")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	// Ensure only the value is returned & not the dial or user.
+	var body map[string]interface{}
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Fatalf("StatusCode=%v, want %v", got, want)
+	} else if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	} else if got, want := body, map[string]interface{}{"id": float64(1), "value": float64(60), "note": "deploy"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("body=%#v, want %#v", got, want)
+	}
+}
@@ -0,0 +1,456 @@
+package http
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+	"golang.org/x/oauth2"
+)
+
+// OIDCClockSkew is the amount of clock drift allowed between the server and
+// the identity provider when checking ID token expiration.
+const OIDCClockSkew = 1 * time.Minute
+
+// OIDCTimeout is the maximum time allowed for a request to the identity provider.
+const OIDCTimeout = 10 * time.Second
+
+// OIDCKeyRefreshInterval is the minimum time between fetches of the provider's
+// signing keys. This prevents ID tokens with unknown key IDs from causing a
+// request to the identity provider every time they are verified.
+const OIDCKeyRefreshInterval = 1 * time.Minute
+
+// OIDCProvider implements AuthProvider for a generic OpenID Connect identity
+// provider. Endpoints & signing keys are discovered from the issuer's
+// "/.well-known/openid-configuration" document the first time they are needed.
+//
+// Only RS256-signed ID tokens are supported.
+type OIDCProvider struct {
+	// Unique name of the provider. Used in login URLs & stored as the auth source.
+	Name string
+
+	// Name shown on the login page. Defaults to Name if blank.
+	Title string
+
+	// Issuer URL of the identity provider. This must exactly match the
+	// "iss" claim of returned ID tokens.
+	IssuerURL string
+
+	// OAuth client credentials registered with the identity provider.
+	ClientID     string
+	ClientSecret string
+
+	// Scopes requested during login. Defaults to "openid", "email" & "profile".
+	Scopes []string
+
+	// Client used for requests to the identity provider.
+	// Defaults to a client which times out after OIDCTimeout.
+	HTTPClient *http.Client
+
+	// Returns the current time. Defaults to time.Now().
+	// Can be mocked for tests.
+	Now func() time.Time
+
+	mu              sync.Mutex
+	discovery       *oidcDiscovery
+	keys            map[string]*rsa.PublicKey
+	keysRefreshedAt time.Time
+}
+
+// NewOIDCProvider returns a new instance of OIDCProvider.
+func NewOIDCProvider(name, issuerURL, clientID, clientSecret string) *OIDCProvider {
+	return &OIDCProvider{
+		Name:         name,
+		IssuerURL:    issuerURL,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		HTTPClient:   &http.Client{Timeout: OIDCTimeout},
+		Now:          time.Now,
+	}
+}
+
+// Source returns the name of the provider.
+func (p *OIDCProvider) Source() string { return p.Name }
+
+// DisplayName returns the title of the provider, if set. Otherwise returns the name.
+func (p *OIDCProvider) DisplayName() string {
+	if p.Title != "" {
+		return p.Title
+	}
+	return p.Name
+}
+
+// AuthCodeURL returns the URL of the identity provider's authorization
+// endpoint. A nonce derived from the state is included so that the returned
+// ID token can be tied to this login attempt.
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, redirectURL string) (string, error) {
+	config, err := p.oauth2Config(ctx, redirectURL)
+	if err != nil {
+		return "", err
+	}
+	return config.AuthCodeURL(state, oauth2.SetAuthURLParam("nonce", oidcNonce(state))), nil
+}
+
+// Exchange exchanges the code for OAuth tokens and validates the returned ID
+// token. The user's email is only attached if the provider reports it as verified.
+func (p *OIDCProvider) Exchange(ctx context.Context, state, code, redirectURL string) (*wtf.Auth, error) {
+	config, err := p.oauth2Config(ctx, redirectURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Exchange code for OAuth tokens. The ID token is returned alongside them.
+	tok, err := config.Exchange(context.WithValue(ctx, oauth2.HTTPClient, p.HTTPClient), code)
+	if err != nil {
+		return nil, err
+	}
+	rawIDToken, _ := tok.Extra("id_token").(string)
+	if rawIDToken == "" {
+		return nil, fmt.Errorf("id token not returned by identity provider")
+	}
+
+	// Verify the ID token's signature & claims.
+	claims, err := p.verifyIDToken(ctx, rawIDToken, oidcNonce(state))
+	if err != nil {
+		return nil, err
+	}
+
+	// Only trust the email for linking accounts if it has been verified.
+	var email string
+	if claims.EmailVerified {
+		email = claims.Email
+	}
+
+	// Use the most human readable name available.
+	name := claims.Name
+	if name == "" {
+		name = claims.PreferredUsername
+	}
+	if name == "" {
+		name = claims.Email
+	}
+	if name == "" {
+		name = claims.Subject
+	}
+
+	// Create an authentication object with an associated user.
+	auth := &wtf.Auth{
+		Source:       p.Name,
+		SourceID:     claims.Subject,
+		AccessToken:  tok.AccessToken,
+		RefreshToken: tok.RefreshToken,
+		User: &wtf.User{
+			Name:  name,
+			Email: email,
+		},
+	}
+	if !tok.Expiry.IsZero() {
+		auth.Expiry = &tok.Expiry
+	}
+	return auth, nil
+}
+
+// oauth2Config returns the OAuth2 configuration using discovered endpoints.
+func (p *OIDCProvider) oauth2Config(ctx context.Context, redirectURL string) (*oauth2.Config, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := p.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+
+	return &oauth2.Config{
+		ClientID:     p.ClientID,
+		ClientSecret: p.ClientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  d.AuthorizationEndpoint,
+			TokenURL: d.TokenEndpoint,
+		},
+	}, nil
+}
+
+// oidcDiscovery represents the fields used from the provider's discovery document.
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// discover fetches & caches the provider's discovery document. The document
+// is fetched without holding the lock so a slow provider does not block other
+// requests. Concurrent fetches return the same document so either can be kept.
+func (p *OIDCProvider) discover(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	d := p.discovery
+	p.mu.Unlock()
+
+	if d != nil {
+		return d, nil
+	}
+
+	d = &oidcDiscovery{}
+	if err := getJSON(ctx, p.HTTPClient, strings.TrimSuffix(p.IssuerURL, "/")+"/.well-known/openid-configuration", d); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	} else if d.Issuer != p.IssuerURL {
+		return nil, fmt.Errorf("oidc discovery: issuer mismatch: %q", d.Issuer)
+	} else if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc discovery: missing endpoints")
+	}
+
+	p.mu.Lock()
+	p.discovery = d
+	p.mu.Unlock()
+
+	return d, nil
+}
+
+// publicKey returns the provider's signing key by key ID. The key set is
+// refetched if the key is not found as the provider may have rotated keys.
+// Refetches occur at most once every OIDCKeyRefreshInterval.
+func (p *OIDCProvider) publicKey(ctx context.Context, jwksURI, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	if key := p.findPublicKey(kid); key != nil {
+		p.mu.Unlock()
+		return key, nil
+	}
+
+	// Avoid refetching keys if they were recently fetched. This includes
+	// failed & in-progress fetches so an unavailable provider is not retried
+	// on every login.
+	now := p.Now()
+	if !p.keysRefreshedAt.IsZero() && now.Sub(p.keysRefreshedAt) < OIDCKeyRefreshInterval {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("oidc keys: signing key not found: %q", kid)
+	}
+	p.keysRefreshedAt = now
+	p.mu.Unlock()
+
+	// Fetch keys without holding the lock so a slow provider does not block
+	// other requests. The cached keys are swapped once fetched.
+	keys, err := p.fetchPublicKeys(ctx, jwksURI)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.keys = keys
+	if key := p.findPublicKey(kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("oidc keys: signing key not found: %q", kid)
+}
+
+// fetchPublicKeys fetches the provider's key set. Only RSA signing keys are
+// returned. Others are ignored.
+func (p *OIDCProvider) fetchPublicKeys(ctx context.Context, jwksURI string) (map[string]*rsa.PublicKey, error) {
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := getJSON(ctx, p.HTTPClient, jwksURI, &jwks); err != nil {
+		return nil, fmt.Errorf("oidc keys: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("oidc keys: invalid modulus: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("oidc keys: invalid exponent: %w", err)
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
+
+// findPublicKey returns a cached key by ID. If the token has no key ID then
+// the provider's only key is used. Must be called while holding the lock.
+func (p *OIDCProvider) findPublicKey(kid string) *rsa.PublicKey {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key
+		}
+	}
+	return p.keys[kid]
+}
+
+// oidcClaims represents the claims used from an ID token.
+type oidcClaims struct {
+	Issuer   string       `json:"iss"`
+	Subject  string       `json:"sub"`
+	Audience oidcAudience `json:"aud"`
+	Expiry   int64        `json:"exp"`
+	Nonce    string       `json:"nonce"`
+
+	Email             string   `json:"email"`
+	EmailVerified     oidcBool `json:"email_verified"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+}
+
+// verifyIDToken verifies the signature of a raw ID token and validates its
+// issuer, audience, expiration & nonce. Returns the token's claims.
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, rawIDToken, nonce string) (*oidcClaims, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(rawIDToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed id token")
+	}
+
+	// Decode header to determine the signing algorithm & key.
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed id token header: %w", err)
+	} else if header.Alg != "RS256" {
+		return nil, fmt.Errorf("unsupported id token algorithm: %q", header.Alg)
+	}
+
+	// Verify signature over the header & payload.
+	key, err := p.publicKey(ctx, d.JWKSURI, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed id token signature: %w", err)
+	}
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], sig); err != nil {
+		return nil, fmt.Errorf("invalid id token signature")
+	}
+
+	// Validate claims.
+	var claims oidcClaims
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed id token claims: %w", err)
+	} else if claims.Issuer != d.Issuer {
+		return nil, fmt.Errorf("id token issuer mismatch: %q", claims.Issuer)
+	} else if !claims.Audience.contains(p.ClientID) {
+		return nil, fmt.Errorf("id token audience mismatch")
+	} else if !p.Now().Before(time.Unix(claims.Expiry, 0).Add(OIDCClockSkew)) {
+		return nil, fmt.Errorf("id token expired")
+	} else if claims.Nonce != nonce {
+		return nil, fmt.Errorf("id token nonce mismatch")
+	} else if claims.Subject == "" {
+		return nil, fmt.Errorf("id token subject required")
+	}
+	return &claims, nil
+}
+
+// oidcAudience represents the "aud" claim which may be a string or a list.
+type oidcAudience []string
+
+// UnmarshalJSON decodes either a single audience string or a list of strings.
+func (a *oidcAudience) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*a = oidcAudience{s}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(a))
+}
+
+// contains returns true if v is one of the audiences.
+func (a oidcAudience) contains(v string) bool {
+	for _, s := range a {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// oidcBool represents a boolean claim. Some providers encode booleans as
+// strings so both forms are accepted.
+type oidcBool bool
+
+// UnmarshalJSON decodes a boolean or a "true"/"false" string.
+func (b *oidcBool) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case "true", `"true"`:
+		*b = true
+	case "false", `"false"`, "null":
+		*b = false
+	default:
+		return fmt.Errorf("invalid boolean: %s", data)
+	}
+	return nil
+}
+
+// oidcNonce returns the nonce used for a given OAuth state. The state is
+// already bound to the user's session cookie so it is hashed rather than
+// storing a separate value.
+func oidcNonce(state string) string {
+	hash := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(hash[:])
+}
+
+// decodeJWTSegment decodes a base64url encoded JSON segment of a JWT into v.
+func decodeJWTSegment(segment string, v interface{}) error {
+	buf, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(buf, v)
+}
+
+// getJSON issues a GET request to u and decodes the JSON response into v.
+func getJSON(ctx context.Context, client *http.Client, u string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,432 @@
+package http_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+	wtfhttp "github.com/benbjohnson/wtf/http"
+)
+
+// Ensure a user can log in through an OpenID Connect provider.
+func TestLogin_OAuth_OIDC(t *testing.T) {
+	idp := MustOpenOIDCServer(t)
+	s := MustOpenServer(t)
+	defer MustCloseServer(t, s)
+	s.AuthProviders = append(s.AuthProviders, wtfhttp.NewOIDCProvider("corp", idp.URL, "CLIENTID", "SECRET"))
+
+	var auth *wtf.Auth
+	s.AuthService.CreateAuthFn = func(ctx context.Context, other *wtf.Auth) error {
+		auth = other
+		auth.ID, auth.UserID, auth.User.ID = 1, 1, 1
+		return nil
+	}
+	s.SessionService.CreateSessionFn = func(ctx context.Context, session *wtf.Session) error {
+		session.ID = 2
+		return nil
+	}
+
+	// Disable redirects for testing OAuth.
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	// Ensure login redirects to the provider's authorization endpoint.
+	resp, err := client.Get(s.URL() + "/oauth/corp")
+	if err != nil {
+		t.Fatal(err)
+	} else if err := resp.Body.Close(); err != nil {
+		t.Fatal(err)
+	} else if got, want := resp.StatusCode, http.StatusFound; got != want {
+		t.Fatalf("StatusCode=%v, want %v", got, want)
+	}
+	cookie := resp.Cookies()[0]
+
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	} else if got, want := loc.Path, `/authorize`; got != want {
+		t.Fatalf("Location.Path=%v, want %v", got, want)
+	} else if got, want := loc.Query().Get("client_id"), "CLIENTID"; got != want {
+		t.Fatalf("Location.Query.client_id=%v, want %v", got, want)
+	} else if got, want := loc.Query().Get("redirect_uri"), s.URL()+"/oauth/corp/callback"; got != want {
+		t.Fatalf("Location.Query.redirect_uri=%v, want %v", got, want)
+	}
+	state := loc.Query().Get("state")
+	idp.Claims["nonce"] = loc.Query().Get("nonce")
+
+	// Return to the callback as the provider would.
+	req, err := http.NewRequest("GET", s.URL()+"/oauth/corp/callback?code=CODE&state="+url.QueryEscape(state), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.AddCookie(cookie)
+	if resp, err = client.Do(req); err != nil {
+		t.Fatal(err)
+	} else if err := resp.Body.Close(); err != nil {
+		t.Fatal(err)
+	} else if got, want := resp.StatusCode, http.StatusFound; got != want {
+		t.Fatalf("StatusCode=%v, want %v", got, want)
+	}
+
+	// Ensure the auth was created from the ID token claims.
+	if auth == nil {
+		t.Fatal("expected auth")
+	} else if got, want := auth.Source, "corp"; got != want {
+		t.Fatalf("Source=%v, want %v", got, want)
+	} else if got, want := auth.SourceID, "SUBJECT"; got != want {
+		t.Fatalf("SourceID=%v, want %v", got, want)
+	} else if got, want := auth.User.Email, "jane@example.com"; got != want {
+		t.Fatalf("Email=%v, want %v", got, want)
+	} else if got, want := auth.User.Name, "Jane"; got != want {
+		t.Fatalf("Name=%v, want %v", got, want)
+	}
+
+	// Ensure the browser session now refers to the user.
+	var session wtfhttp.Session
+	if err := s.UnmarshalSession(resp.Cookies()[0].Value, &session); err != nil {
+		t.Fatal(err)
+	} else if got, want := session.UserID, 1; got != want {
+		t.Fatalf("UserID=%v, want %v", got, want)
+	} else if got, want := session.SessionID, 2; got != want {
+		t.Fatalf("SessionID=%v, want %v", got, want)
+	}
+}
+
+// Ensure a logged in user can link an account from another provider.
+func TestLogin_OAuth_Link(t *testing.T) {
+	idp := MustOpenOIDCServer(t)
+	s := MustOpenServer(t)
+	defer MustCloseServer(t, s)
+	s.AuthProviders = append(s.AuthProviders, wtfhttp.NewOIDCProvider("corp", idp.URL, "CLIENTID", "SECRET"))
+
+	user := &wtf.User{ID: 1, Name: "USER1"}
+	s.UserService.FindUserByIDFn = func(ctx context.Context, id int) (*wtf.User, error) {
+		return user, nil
+	}
+
+	var auth *wtf.Auth
+	s.AuthService.CreateAuthFn = func(ctx context.Context, other *wtf.Auth) error {
+		if got, want := wtf.UserIDFromContext(ctx), user.ID; got != want {
+			t.Fatalf("UserID=%v, want %v", got, want)
+		}
+		auth = other
+		auth.ID = 2
+		return nil
+	}
+	s.SessionService.CreateSessionFn = func(ctx context.Context, session *wtf.Session) error {
+		t.Fatal("unexpected session")
+		return nil
+	}
+
+	// Disable redirects for testing OAuth.
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	// Begin linking as the logged in user.
+	resp, err := client.Do(s.MustNewRequest(t, wtf.NewContextWithUser(context.Background(), user), "GET", "/oauth/corp?link=true", nil))
+	if err != nil {
+		t.Fatal(err)
+	} else if err := resp.Body.Close(); err != nil {
+		t.Fatal(err)
+	} else if got, want := resp.StatusCode, http.StatusFound; got != want {
+		t.Fatalf("StatusCode=%v, want %v", got, want)
+	}
+	cookie := resp.Cookies()[0]
+
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp.Claims["nonce"] = loc.Query().Get("nonce")
+
+	// Return to the callback as the provider would.
+	req, err := http.NewRequest("GET", s.URL()+"/oauth/corp/callback?code=CODE&state="+url.QueryEscape(loc.Query().Get("state")), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.AddCookie(cookie)
+	if resp, err = client.Do(req); err != nil {
+		t.Fatal(err)
+	} else if err := resp.Body.Close(); err != nil {
+		t.Fatal(err)
+	} else if got, want := resp.StatusCode, http.StatusFound; got != want {
+		t.Fatalf("StatusCode=%v, want %v", got, want)
+	} else if got, want := resp.Header.Get("Location"), "/settings"; got != want {
+		t.Fatalf("Location=%v, want %v", got, want)
+	}
+
+	// Ensure the auth was attached to the current user.
+	if auth == nil {
+		t.Fatal("expected auth")
+	} else if got, want := auth.UserID, user.ID; got != want {
+		t.Fatalf("UserID=%v, want %v", got, want)
+	} else if got, want := auth.Source, "corp"; got != want {
+		t.Fatalf("Source=%v, want %v", got, want)
+	}
+}
+
+// Ensure linking an account requires the user to be logged in.
+func TestLogin_OAuth_Link_ErrUnauthorized(t *testing.T) {
+	idp := MustOpenOIDCServer(t)
+	s := MustOpenServer(t)
+	defer MustCloseServer(t, s)
+	s.AuthProviders = append(s.AuthProviders, wtfhttp.NewOIDCProvider("corp", idp.URL, "CLIENTID", "SECRET"))
+
+	req := s.MustNewRequest(t, context.Background(), "GET", "/oauth/corp?link=true", nil)
+	req.Header.Set("Accept", "application/json")
+	if resp, err := http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	} else if err := resp.Body.Close(); err != nil {
+		t.Fatal(err)
+	} else if got, want := resp.StatusCode, http.StatusUnauthorized; got != want {
+		t.Fatalf("StatusCode=%v, want %v", got, want)
+	}
+}
+
+// Ensure ID tokens are validated before the user is authenticated.
+func TestOIDCProvider_Exchange(t *testing.T) {
+	const state = "STATE"
+
+	// newProvider returns a provider for the IdP with a nonce expected for state.
+	newProvider := func(tb testing.TB, idp *OIDCServer) *wtfhttp.OIDCProvider {
+		p := wtfhttp.NewOIDCProvider("corp", idp.URL, "CLIENTID", "SECRET")
+		u, err := p.AuthCodeURL(context.Background(), state, "http://localhost/oauth/corp/callback")
+		if err != nil {
+			tb.Fatal(err)
+		}
+		loc, err := url.Parse(u)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		idp.Claims["nonce"] = loc.Query().Get("nonce")
+		return p
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		idp := MustOpenOIDCServer(t)
+		p := newProvider(t, idp)
+		if auth, err := p.Exchange(context.Background(), state, "CODE", ""); err != nil {
+			t.Fatal(err)
+		} else if got, want := auth.AccessToken, "ACCESSTOKEN"; got != want {
+			t.Fatalf("AccessToken=%v, want %v", got, want)
+		} else if got, want := auth.User.Email, "jane@example.com"; got != want {
+			t.Fatalf("Email=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure an unverified email is not used to link accounts.
+	t.Run("UnverifiedEmail", func(t *testing.T) {
+		idp := MustOpenOIDCServer(t)
+		p := newProvider(t, idp)
+		idp.Claims["email_verified"] = "false"
+		delete(idp.Claims, "name")
+		if auth, err := p.Exchange(context.Background(), state, "CODE", ""); err != nil {
+			t.Fatal(err)
+		} else if auth.User.Email != "" {
+			t.Fatalf("unexpected email: %q", auth.User.Email)
+		} else if got, want := auth.User.Name, "jane@example.com"; got != want {
+			t.Fatalf("Name=%v, want %v", got, want)
+		}
+	})
+
+	t.Run("ErrAudience", func(t *testing.T) {
+		idp := MustOpenOIDCServer(t)
+		p := newProvider(t, idp)
+		idp.Claims["aud"] = []string{"OTHER"}
+		if _, err := p.Exchange(context.Background(), state, "CODE", ""); err == nil || err.Error() != `id token audience mismatch` {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("ErrIssuer", func(t *testing.T) {
+		idp := MustOpenOIDCServer(t)
+		p := newProvider(t, idp)
+		idp.Claims["iss"] = "https://evil.example.com"
+		if _, err := p.Exchange(context.Background(), state, "CODE", ""); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+
+	t.Run("ErrExpired", func(t *testing.T) {
+		idp := MustOpenOIDCServer(t)
+		p := newProvider(t, idp)
+		idp.Claims["exp"] = time.Now().Add(-time.Hour).Unix()
+		if _, err := p.Exchange(context.Background(), state, "CODE", ""); err == nil || err.Error() != `id token expired` {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("ErrNonce", func(t *testing.T) {
+		idp := MustOpenOIDCServer(t)
+		p := newProvider(t, idp)
+		if _, err := p.Exchange(context.Background(), "OTHERSTATE", "CODE", ""); err == nil || err.Error() != `id token nonce mismatch` {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("ErrSignature", func(t *testing.T) {
+		idp := MustOpenOIDCServer(t)
+		p := newProvider(t, idp)
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		idp.SigningKey = key
+		if _, err := p.Exchange(context.Background(), state, "CODE", ""); err == nil || err.Error() != `invalid id token signature` {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure unknown key IDs do not refetch the key set on every verification.
+	t.Run("ErrUnknownKey", func(t *testing.T) {
+		idp := MustOpenOIDCServer(t)
+		p := newProvider(t, idp)
+		now := time.Now()
+		p.Now = func() time.Time { return now }
+
+		if _, err := p.Exchange(context.Background(), state, "CODE", ""); err != nil {
+			t.Fatal(err)
+		} else if got, want := atomic.LoadInt64(&idp.KeyRequestN), int64(1); got != want {
+			t.Fatalf("KeyRequestN=%v, want %v", got, want)
+		}
+
+		// Keys are not refetched within the refresh interval.
+		idp.SigningKeyID = "OTHER"
+		for i := 0; i < 2; i++ {
+			if _, err := p.Exchange(context.Background(), state, "CODE", ""); err == nil || err.Error() != `oidc keys: signing key not found: "OTHER"` {
+				t.Fatal(err)
+			}
+		}
+		if got, want := atomic.LoadInt64(&idp.KeyRequestN), int64(1); got != want {
+			t.Fatalf("KeyRequestN=%v, want %v", got, want)
+		}
+
+		// Keys are refetched once the interval has passed.
+		now = now.Add(wtfhttp.OIDCKeyRefreshInterval)
+		if _, err := p.Exchange(context.Background(), state, "CODE", ""); err == nil || err.Error() != `oidc keys: signing key not found: "OTHER"` {
+			t.Fatal(err)
+		} else if got, want := atomic.LoadInt64(&idp.KeyRequestN), int64(2); got != want {
+			t.Fatalf("KeyRequestN=%v, want %v", got, want)
+		}
+	})
+}
+
+// OIDCServer represents a fake OpenID Connect identity provider for testing.
+type OIDCServer struct {
+	*httptest.Server
+
+	// Key published by the JWKS endpoint.
+	Key *rsa.PrivateKey
+
+	// Key used to sign ID tokens. Defaults to Key.
+	SigningKey *rsa.PrivateKey
+
+	// Key ID set in the header of ID tokens. Defaults to the ID of Key.
+	SigningKeyID string
+
+	// Number of requests made to the JWKS endpoint. Accessed atomically.
+	KeyRequestN int64
+
+	// Claims returned in the ID token by the token endpoint.
+	Claims map[string]interface{}
+}
+
+// MustOpenOIDCServer returns a running fake identity provider which issues
+// ID tokens for a single user. Fatal on error.
+func MustOpenOIDCServer(tb testing.TB) *OIDCServer {
+	tb.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	s := &OIDCServer{Key: key, SigningKey: key, SigningKeyID: "KEY1"}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	tb.Cleanup(s.Close)
+
+	s.Claims = map[string]interface{}{
+		"iss":            s.URL,
+		"sub":            "SUBJECT",
+		"aud":            "CLIENTID",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"email":          "jane@example.com",
+		"email_verified": true,
+		"name":           "Jane",
+	}
+	return s
+}
+
+func (s *OIDCServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 s.URL,
+			"authorization_endpoint": s.URL + "/authorize",
+			"token_endpoint":         s.URL + "/token",
+			"jwks_uri":               s.URL + "/keys",
+		})
+
+	case "/keys":
+		atomic.AddInt64(&s.KeyRequestN, 1)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "KEY1",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(s.Key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.Key.E)).Bytes()),
+			}},
+		})
+
+	case "/token":
+		if r.PostFormValue("code") != "CODE" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "ACCESSTOKEN",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     s.signIDToken(),
+		})
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// signIDToken returns the claims encoded as an RS256-signed JWT.
+func (s *OIDCServer) signIDToken() string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": s.SigningKeyID, "typ": "JWT"})
+	claims, _ := json.Marshal(s.Claims)
+
+	payload := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(payload))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.SigningKey, crypto.SHA256, hash[:])
+	if err != nil {
+		panic(err)
+	}
+	return payload + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
@@ -0,0 +1,614 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/benbjohnson/wtf"
+	"github.com/benbjohnson/wtf/http/html"
+	"github.com/gorilla/mux"
+)
+
+// registerOrganizationRoutes is a helper function for registering organization routes.
+func (s *Server) registerOrganizationRoutes(r *mux.Router) {
+	// Listing of all organizations the user is a member of.
+	r.HandleFunc("/orgs", s.handleOrganizationIndex).Methods("GET")
+
+	// API endpoint & HTML form for creating organizations.
+	r.HandleFunc("/orgs", s.handleOrganizationCreate).Methods("POST")
+	r.HandleFunc("/orgs/new", s.handleOrganizationNew).Methods("GET")
+	r.HandleFunc("/orgs/new", s.handleOrganizationCreate).Methods("POST")
+
+	// Join an organization via its invite code.
+	r.HandleFunc("/orgs/invite/{code}", s.handleOrganizationMemberNew).Methods("GET")
+	r.HandleFunc("/orgs/invite/{code}", s.handleOrganizationMemberCreate).Methods("POST")
+
+	// Organization dashboard.
+	r.HandleFunc("/orgs/{id}", s.handleOrganizationView).Methods("GET")
+
+	// HTML form & API endpoint for updating an organization.
+	r.HandleFunc("/orgs/{id}/edit", s.handleOrganizationEdit).Methods("GET")
+	r.HandleFunc("/orgs/{id}/edit", s.handleOrganizationUpdate).Methods("PATCH")
+	r.HandleFunc("/orgs/{id}", s.handleOrganizationUpdate).Methods("PATCH")
+
+	// Removing an organization.
+	r.HandleFunc("/orgs/{id}", s.handleOrganizationDelete).Methods("DELETE")
+
+	// API endpoints for joining & leaving organizations.
+	r.HandleFunc("/org-members", s.handleOrganizationMemberJoin).Methods("POST")
+	r.HandleFunc("/org-members/{id}", s.handleOrganizationMemberDelete).Methods("DELETE")
+}
+
+// handleOrganizationIndex handles the "GET /orgs" route. This route can
+// optionally accept filter arguments and outputs a list of all organizations
+// that the current user is a member of.
+func (s *Server) handleOrganizationIndex(w http.ResponseWriter, r *http.Request) {
+	// Parse optional filter object.
+	var filter wtf.OrganizationFilter
+	switch r.Header.Get("Content-type") {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&filter); err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid JSON body"))
+			return
+		}
+	default:
+		filter.Offset, _ = strconv.Atoi(r.URL.Query().Get("offset"))
+		filter.Limit = 20
+	}
+
+	// Fetch organizations from database.
+	orgs, n, err := s.OrganizationService.FindOrganizations(r.Context(), filter)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Render output based on HTTP accept header.
+	switch r.Header.Get("Accept") {
+	case "application/json":
+		w.Header().Set("Content-type", "application/json")
+		if err := json.NewEncoder(w).Encode(findOrganizationsResponse{
+			Organizations: orgs,
+			N:             n,
+		}); err != nil {
+			LogError(r, err)
+			return
+		}
+
+	default:
+		tmpl := html.OrganizationIndexTemplate{Organizations: orgs, N: n, Filter: filter, URL: *r.URL}
+		tmpl.Render(r.Context(), w)
+	}
+}
+
+// findOrganizationsResponse represents the output JSON struct for "GET /orgs".
+type findOrganizationsResponse struct {
+	Organizations []*wtf.Organization `json:"organizations"`
+	N             int                 `json:"n"`
+}
+
+// handleOrganizationView handles the "GET /orgs/:id" route. The HTML page
+// shows a dashboard of all of the organization's dials along with their
+// average value. The JSON output only includes the organization & its members.
+func (s *Server) handleOrganizationView(w http.ResponseWriter, r *http.Request) {
+	// Parse ID from path.
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid ID format"))
+		return
+	}
+
+	// Fetch organization & its members from the database.
+	org, err := s.OrganizationService.FindOrganizationByID(r.Context(), id)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Format returned data based on HTTP accept header.
+	switch r.Header.Get("Accept") {
+	case "application/json":
+		w.Header().Set("Content-type", "application/json")
+		if err := json.NewEncoder(w).Encode(org); err != nil {
+			LogError(r, err)
+			return
+		}
+
+	default:
+		// Fetch every dial within the organization.
+		dials, _, err := s.DialService.FindDials(r.Context(), wtf.DialFilter{OrganizationID: &org.ID})
+		if err != nil {
+			Error(w, r, err)
+			return
+		}
+
+		tmpl := html.OrganizationViewTemplate{
+			Organization: org,
+			Dials:        dials,
+			InviteURL:    fmt.Sprintf("%s/orgs/invite/%s", s.URL(), org.InviteCode),
+		}
+		tmpl.Render(r.Context(), w)
+	}
+}
+
+// handleOrganizationNew handles the "GET /orgs/new" route.
+// It renders an HTML form for editing a new organization.
+func (s *Server) handleOrganizationNew(w http.ResponseWriter, r *http.Request) {
+	tmpl := html.OrganizationEditTemplate{Organization: &wtf.Organization{}}
+	tmpl.Render(r.Context(), w)
+}
+
+// handleOrganizationCreate handles the "POST /orgs" and "POST /orgs/new"
+// routes. It reads & writes data using with HTML or JSON.
+func (s *Server) handleOrganizationCreate(w http.ResponseWriter, r *http.Request) {
+	// Unmarshal data based on HTTP request's content type.
+	var org wtf.Organization
+	switch r.Header.Get("Content-type") {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&org); err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid JSON body"))
+			return
+		}
+	default:
+		org.Name = r.PostFormValue("name")
+	}
+
+	// Create organization in the database.
+	err := s.OrganizationService.CreateOrganization(r.Context(), &org)
+
+	// Write new organization content to response based on accept header.
+	switch r.Header.Get("Accept") {
+	case "application/json":
+		if err != nil {
+			Error(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(org); err != nil {
+			LogError(r, err)
+			return
+		}
+
+	default:
+		// Display validation errors on the edit page with the user's data.
+		if wtf.ErrorCode(err) == wtf.EINTERNAL {
+			Error(w, r, err)
+			return
+		} else if err != nil {
+			tmpl := html.OrganizationEditTemplate{Organization: &org, Err: err}
+			tmpl.Render(r.Context(), w)
+			return
+		}
+
+		SetFlash(w, "Organization successfully created.")
+		http.Redirect(w, r, fmt.Sprintf("/orgs/%d", org.ID), http.StatusFound)
+	}
+}
+
+// handleOrganizationEdit handles the "GET /orgs/:id/edit" route. This route
+// fetches the underlying organization and renders it in an HTML form.
+func (s *Server) handleOrganizationEdit(w http.ResponseWriter, r *http.Request) {
+	// Parse organization ID from the path.
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid ID format"))
+		return
+	}
+
+	// Fetch organization from the database.
+	org, err := s.OrganizationService.FindOrganizationByID(r.Context(), id)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	tmpl := html.OrganizationEditTemplate{Organization: org}
+	tmpl.Render(r.Context(), w)
+}
+
+// handleOrganizationUpdate handles the "PATCH /orgs/:id" and
+// "PATCH /orgs/:id/edit" routes. On success, it redirects to the
+// organization's dashboard or returns the updated organization for JSON requests.
+func (s *Server) handleOrganizationUpdate(w http.ResponseWriter, r *http.Request) {
+	// Parse organization ID from the path.
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid ID format"))
+		return
+	}
+
+	// Parse fields into an update object based on HTTP request's content type.
+	var upd wtf.OrganizationUpdate
+	switch r.Header.Get("Content-type") {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid JSON body"))
+			return
+		}
+	default:
+		name := r.PostFormValue("name")
+		upd.Name = &name
+	}
+
+	// Update the organization in the database.
+	org, err := s.OrganizationService.UpdateOrganization(r.Context(), id, upd)
+
+	// Write updated organization content to response based on accept header.
+	switch r.Header.Get("Accept") {
+	case "application/json":
+		if err != nil {
+			Error(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-type", "application/json")
+		if err := json.NewEncoder(w).Encode(org); err != nil {
+			LogError(r, err)
+			return
+		}
+
+	default:
+		// Display validation errors on the edit page with the user's data.
+		if wtf.ErrorCode(err) == wtf.EINVALID && org != nil {
+			tmpl := html.OrganizationEditTemplate{Organization: org, Err: err}
+			tmpl.Render(r.Context(), w)
+			return
+		} else if err != nil {
+			Error(w, r, err)
+			return
+		}
+
+		SetFlash(w, "Organization successfully updated.")
+		http.Redirect(w, r, fmt.Sprintf("/orgs/%d", org.ID), http.StatusFound)
+	}
+}
+
+// handleOrganizationDelete handles the "DELETE /orgs/:id" route. This route
+// permanently deletes the organization. Its dials are kept.
+func (s *Server) handleOrganizationDelete(w http.ResponseWriter, r *http.Request) {
+	// Parse organization ID from path.
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid ID format"))
+		return
+	}
+
+	// Delete the organization from the database.
+	if err := s.OrganizationService.DeleteOrganization(r.Context(), id); err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Render output to the client based on HTTP accept header.
+	switch r.Header.Get("Accept") {
+	case "application/json":
+		w.Header().Set("Content-type", "application/json")
+		w.Write([]byte(`{}`))
+
+	default:
+		SetFlash(w, "Organization successfully deleted.")
+		http.Redirect(w, r, "/orgs", http.StatusFound)
+	}
+}
+
+// handleOrganizationMemberNew handles the "GET /orgs/invite/:code" route.
+// This route asks the user to confirm that they want to join the organization.
+func (s *Server) handleOrganizationMemberNew(w http.ResponseWriter, r *http.Request) {
+	// Find organization by invite code.
+	// The invite code is unique so at most one organization will be returned.
+	code := mux.Vars(r)["code"]
+	orgs, _, err := s.OrganizationService.FindOrganizations(r.Context(), wtf.OrganizationFilter{InviteCode: &code})
+	if err != nil {
+		Error(w, r, err)
+		return
+	} else if len(orgs) == 0 {
+		Error(w, r, wtf.Errorf(wtf.ENOTFOUND, "Invalid invitation URL."))
+		return
+	}
+
+	// If the user is already a member then redirect to the dashboard.
+	if orgs[0].Role != "" {
+		SetFlash(w, "You are already a member of this organization.")
+		http.Redirect(w, r, fmt.Sprintf("/orgs/%d", orgs[0].ID), http.StatusFound)
+		return
+	}
+
+	tmpl := html.OrganizationMemberCreateTemplate{Organization: orgs[0]}
+	tmpl.Render(r.Context(), w)
+}
+
+// handleOrganizationMemberCreate handles the "POST /orgs/invite/:code" route.
+// This route adds the current user as a member of the organization.
+func (s *Server) handleOrganizationMemberCreate(w http.ResponseWriter, r *http.Request) {
+	// Look up organization by invite code.
+	code := mux.Vars(r)["code"]
+	orgs, _, err := s.OrganizationService.FindOrganizations(r.Context(), wtf.OrganizationFilter{InviteCode: &code})
+	if err != nil {
+		Error(w, r, err)
+		return
+	} else if len(orgs) == 0 {
+		Error(w, r, wtf.Errorf(wtf.ENOTFOUND, "Invalid invitation URL."))
+		return
+	}
+
+	// Join the organization. The service verifies the invite code.
+	member := &wtf.OrganizationMember{OrganizationID: orgs[0].ID, InviteCode: code}
+	if err := s.OrganizationService.CreateOrganizationMember(r.Context(), member); err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	SetFlash(w, fmt.Sprintf("You have now joined the %q organization.", orgs[0].Name))
+	http.Redirect(w, r, fmt.Sprintf("/orgs/%d", member.OrganizationID), http.StatusFound)
+}
+
+// handleOrganizationMemberJoin handles the "POST /org-members" route. This
+// route is only available via the JSON API.
+func (s *Server) handleOrganizationMemberJoin(w http.ResponseWriter, r *http.Request) {
+	// Force application/json output.
+	r.Header.Set("Accept", "application/json")
+
+	// Parse member from JSON request body.
+	var member wtf.OrganizationMember
+	if err := json.NewDecoder(r.Body).Decode(&member); err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid JSON body"))
+		return
+	}
+
+	// Create a new member for the current user. The invite code is verified
+	// by the service.
+	if err := s.OrganizationService.CreateOrganizationMember(r.Context(), &member); err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(member); err != nil {
+		LogError(r, err)
+		return
+	}
+}
+
+// handleOrganizationMemberDelete handles the "DELETE /org-members/:id" route.
+// Members can leave an organization & the owner can remove other members.
+func (s *Server) handleOrganizationMemberDelete(w http.ResponseWriter, r *http.Request) {
+	// Parse member ID from path.
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid ID format"))
+		return
+	}
+
+	// Remove the member from the organization.
+	if err := s.OrganizationService.DeleteOrganizationMember(r.Context(), id); err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Render output to the client based on HTTP accept header.
+	switch r.Header.Get("Accept") {
+	case "application/json":
+		w.Header().Set("Content-type", "application/json")
+		w.Write([]byte(`{}`))
+
+	default:
+		SetFlash(w, "Member successfully removed.")
+		http.Redirect(w, r, "/orgs", http.StatusFound)
+	}
+}
+
+// OrganizationService implements the wtf.OrganizationService over the HTTP protocol.
+type OrganizationService struct {
+	Client *Client
+}
+
+// NewOrganizationService returns a new instance of OrganizationService.
+func NewOrganizationService(client *Client) *OrganizationService {
+	return &OrganizationService{Client: client}
+}
+
+// FindOrganizationByID retrieves a single organization by ID along with its
+// members. Returns ENOTFOUND if the organization does not exist or the user
+// is not a member.
+func (s *OrganizationService) FindOrganizationByID(ctx context.Context, id int) (*wtf.Organization, error) {
+	// Create request with API key attached.
+	req, err := s.Client.newRequest(ctx, "GET", fmt.Sprintf("/orgs/%d", id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Issue request. If any other status besides 200, then treats as an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal the returned organization data.
+	var org wtf.Organization
+	if err := json.NewDecoder(resp.Body).Decode(&org); err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// FindOrganizations retrieves a list of organizations based on a filter.
+// Only returns organizations the user is a member of unless searching by
+// invite code. Also returns a count of total matching organizations which may
+// different from the number of returned organizations if "Limit" is set.
+func (s *OrganizationService) FindOrganizations(ctx context.Context, filter wtf.OrganizationFilter) ([]*wtf.Organization, int, error) {
+	// Marshal filter into JSON format.
+	body, err := json.Marshal(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "GET", "/orgs", bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Issue request. Any non-200 status code is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, 0, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal result set of organizations & total count.
+	var jsonResponse findOrganizationsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&jsonResponse); err != nil {
+		return nil, 0, err
+	}
+	return jsonResponse.Organizations, jsonResponse.N, nil
+}
+
+// CreateOrganization creates a new organization and assigns the current user
+// as the owner. The owner is automatically added as a member.
+func (s *OrganizationService) CreateOrganization(ctx context.Context, org *wtf.Organization) error {
+	// Marshal organization data into JSON format.
+	body, err := json.Marshal(org)
+	if err != nil {
+		return err
+	}
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "POST", "/orgs", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	// Issue request. Treat non-201 status codes as errors.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusCreated {
+		return parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal returned organization data.
+	if err := json.NewDecoder(resp.Body).Decode(&org); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateOrganization updates an existing organization by ID. Only the owner
+// can update an organization. Returns ENOTFOUND if the organization does not
+// exist. Returns EUNAUTHORIZED if the user is not the owner.
+func (s *OrganizationService) UpdateOrganization(ctx context.Context, id int, upd wtf.OrganizationUpdate) (*wtf.Organization, error) {
+	// Marshal update fields into JSON format.
+	body, err := json.Marshal(upd)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "PATCH", fmt.Sprintf("/orgs/%d", id), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	// Issue request. Any non-200 status code is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal the updated organization data.
+	var org wtf.Organization
+	if err := json.NewDecoder(resp.Body).Decode(&org); err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// DeleteOrganization permanently removes an organization by ID. Only the
+// owner may delete an organization. Returns ENOTFOUND if the organization
+// does not exist. Returns EUNAUTHORIZED if the user is not the owner.
+func (s *OrganizationService) DeleteOrganization(ctx context.Context, id int) error {
+	// Create a request with API key.
+	req, err := s.Client.newRequest(ctx, "DELETE", fmt.Sprintf("/orgs/%d", id), nil)
+	if err != nil {
+		return err
+	}
+
+	// Issue request. Any non-200 response is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// CreateOrganizationMember adds the current user to an organization. The
+// member's invite code must match the organization's invite code.
+func (s *OrganizationService) CreateOrganizationMember(ctx context.Context, member *wtf.OrganizationMember) error {
+	// Marshal member data into JSON format.
+	body, err := json.Marshal(member)
+	if err != nil {
+		return err
+	}
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "POST", "/org-members", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	// Issue request. Treat non-201 status codes as errors.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusCreated {
+		return parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal returned member data.
+	if err := json.NewDecoder(resp.Body).Decode(&member); err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteOrganizationMember removes a member from an organization by ID.
+// Members can leave an organization & the owner can remove other members.
+func (s *OrganizationService) DeleteOrganizationMember(ctx context.Context, id int) error {
+	// Create a request with API key.
+	req, err := s.Client.newRequest(ctx, "DELETE", fmt.Sprintf("/org-members/%d", id), nil)
+	if err != nil {
+		return err
+	}
+
+	// Issue request. Any non-200 response is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
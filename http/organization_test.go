@@ -0,0 +1,69 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/benbjohnson/wtf"
+)
+
+// Ensure the organization dashboard lists the organization's dials along with
+// their average value.
+func TestOrganizationView(t *testing.T) {
+	s := MustOpenServer(t)
+	defer MustCloseServer(t, s)
+
+	user0 := &wtf.User{ID: 1, Name: "USER1"}
+	ctx0 := wtf.NewContextWithUser(context.Background(), user0)
+	s.UserService.FindUserByIDFn = func(ctx context.Context, id int) (*wtf.User, error) {
+		return user0, nil
+	}
+
+	s.OrganizationService.FindOrganizationByIDFn = func(ctx context.Context, id int) (*wtf.Organization, error) {
+		if id != 2 {
+			t.Fatalf("unexpected id: %d", id)
+		}
+		return &wtf.Organization{
+			ID:         2,
+			UserID:     1,
+			Name:       "ACME",
+			InviteCode: "INVITECODE",
+			Role:       wtf.OrganizationRoleOwner,
+			Members: []*wtf.OrganizationMember{
+				{ID: 1, OrganizationID: 2, UserID: 1, User: user0, Role: wtf.OrganizationRoleOwner},
+			},
+		}, nil
+	}
+	s.DialService.FindDialsFn = func(ctx context.Context, filter wtf.DialFilter) ([]*wtf.Dial, int, error) {
+		if filter.OrganizationID == nil || *filter.OrganizationID != 2 {
+			t.Fatalf("unexpected organization filter: %#v", filter.OrganizationID)
+		}
+		return []*wtf.Dial{
+			{ID: 1, UserID: 1, User: user0, Name: "DIAL1", Value: 20, OrganizationID: 2, Role: wtf.DialMembershipRoleOwner},
+			{ID: 2, UserID: 1, User: user0, Name: "DIAL2", Value: 60, OrganizationID: 2},
+		}, 2, nil
+	}
+
+	resp, err := http.DefaultClient.Do(s.MustNewRequest(t, ctx0, "GET", "/orgs/2", nil))
+	if err != nil {
+		t.Fatal(err)
+	} else if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Fatalf("StatusCode=%v, want %v", got, want)
+	}
+	defer resp.Body.Close()
+
+	if doc, err := goquery.NewDocumentFromReader(resp.Body); err != nil {
+		t.Fatal(err)
+	} else if got, want := strings.TrimSpace(doc.Find(".organization-name").Text()), `ACME`; got != want {
+		t.Fatalf("name=%q, want %q", got, want)
+	} else if got, want := doc.Find(".table-dials tbody th.dial-name").Length(), 2; got != want {
+		t.Fatalf("dials=%v, want %v", got, want)
+	} else if got, want := strings.TrimSpace(doc.Find(".organization-value").Text()), `40%`; got != want {
+		t.Fatalf("value=%q, want %q", got, want)
+	} else if got, want := doc.Find(".table-dials button[data-dial-id]").AttrOr("data-dial-id", ""), `2`; got != want {
+		t.Fatalf("join dial=%q, want %q", got, want)
+	}
+}
@@ -0,0 +1,121 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/benbjohnson/wtf"
+	"github.com/benbjohnson/wtf/http/html"
+	"github.com/gorilla/mux"
+)
+
+// registerSearchRoutes is a helper function for registering search routes.
+func (s *Server) registerSearchRoutes(r *mux.Router) {
+	// HTML search page & API endpoint for searching dials.
+	r.HandleFunc("/search", s.handleSearch).Methods("GET")
+}
+
+// handleSearch handles the "GET /search" route. It returns the dials &
+// memberships matching the query. JSON requests pass the filter in the body
+// while HTML requests use the "q" query parameter.
+//
+// The endpoint works with HTML & JSON formats.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	// Parse filter object.
+	var filter wtf.SearchFilter
+	switch r.Header.Get("Content-type") {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&filter); err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid JSON body"))
+			return
+		}
+	default:
+		filter.Query = r.URL.Query().Get("q")
+		filter.Offset, _ = strconv.Atoi(r.URL.Query().Get("offset"))
+		filter.Limit = 20
+	}
+
+	switch r.Header.Get("Accept") {
+	case "application/json":
+		results, n, err := s.SearchService.Search(r.Context(), filter)
+		if err != nil {
+			Error(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-type", "application/json")
+		if err := json.NewEncoder(w).Encode(searchResponse{
+			Results: results,
+			N:       n,
+		}); err != nil {
+			LogError(r, err)
+			return
+		}
+
+	default:
+		// Display an empty search form if there are no search terms.
+		tmpl := html.SearchTemplate{Filter: filter, URL: *r.URL}
+		if len(wtf.SearchTerms(filter.Query)) > 0 {
+			var err error
+			if tmpl.Results, tmpl.N, err = s.SearchService.Search(r.Context(), filter); wtf.ErrorCode(err) == wtf.ENOTIMPLEMENTED {
+				tmpl.Err = err
+			} else if err != nil {
+				Error(w, r, err)
+				return
+			}
+		}
+		tmpl.Render(r.Context(), w)
+	}
+}
+
+// searchResponse represents the output JSON struct for "GET /search".
+type searchResponse struct {
+	Results []*wtf.SearchResult `json:"results"`
+	N       int                 `json:"n"`
+}
+
+// SearchService implements the wtf.SearchService over the HTTP protocol.
+type SearchService struct {
+	Client *Client
+}
+
+// NewSearchService returns a new instance of SearchService.
+func NewSearchService(client *Client) *SearchService {
+	return &SearchService{Client: client}
+}
+
+// Search returns dials & memberships matching every term in the filter's
+// query. Also returns a count of total matches which may differ from the
+// number of returned results if the "Limit" field is set.
+func (s *SearchService) Search(ctx context.Context, filter wtf.SearchFilter) ([]*wtf.SearchResult, int, error) {
+	// Marshal filter into JSON format.
+	body, err := json.Marshal(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "GET", "/search", bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Issue request. Any non-200 status code is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, 0, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal result set of matches & total count.
+	var jsonResponse searchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&jsonResponse); err != nil {
+		return nil, 0, err
+	}
+	return jsonResponse.Results, jsonResponse.N, nil
+}
@@ -0,0 +1,87 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/benbjohnson/wtf"
+)
+
+// Ensure the search page lists matching dials & memberships.
+func TestSearch(t *testing.T) {
+	s := MustOpenServer(t)
+	defer MustCloseServer(t, s)
+
+	user0 := &wtf.User{ID: 1, Name: "USER1"}
+	ctx0 := wtf.NewContextWithUser(context.Background(), user0)
+	s.UserService.FindUserByIDFn = func(ctx context.Context, id int) (*wtf.User, error) {
+		return user0, nil
+	}
+
+	s.SearchService.SearchFn = func(ctx context.Context, filter wtf.SearchFilter) ([]*wtf.SearchResult, int, error) {
+		if got, want := filter.Query, "fail over"; got != want {
+			t.Fatalf("Query=%q, want %q", got, want)
+		} else if got, want := filter.Offset, 20; got != want {
+			t.Fatalf("Offset=%v, want %v", got, want)
+		}
+		dial := &wtf.Dial{ID: 2, Name: "DIAL2"}
+		return []*wtf.SearchResult{
+			{DialID: 2, Dial: dial, Name: "DIAL2"},
+			{DialID: 2, Dial: dial, DialMembershipID: 3, Name: "USER3", Note: "NOTE"},
+		}, 22, nil
+	}
+
+	resp, err := http.DefaultClient.Do(s.MustNewRequest(t, ctx0, "GET", "/search?q=fail+over&offset=20", nil))
+	if err != nil {
+		t.Fatal(err)
+	} else if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Fatalf("StatusCode=%v, want %v", got, want)
+	}
+	defer resp.Body.Close()
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := doc.Find(".table-search-results tbody tr")
+	if got, want := rows.Length(), 2; got != want {
+		t.Fatalf("rows=%v, want %v", got, want)
+	} else if got, want := rows.Eq(0).Find(".search-result-dial a").AttrOr("href", ""), `/dials/2`; got != want {
+		t.Fatalf("href=%q, want %q", got, want)
+	} else if got := rows.Eq(1).Find(".search-result-match").Text(); !strings.Contains(got, "USER3") || !strings.Contains(got, "NOTE") {
+		t.Fatalf("unexpected match: %q", got)
+	}
+}
+
+// Ensure the search page shows a message if search is not available.
+func TestSearch_ErrNotImplemented(t *testing.T) {
+	s := MustOpenServer(t)
+	defer MustCloseServer(t, s)
+
+	user0 := &wtf.User{ID: 1, Name: "USER1"}
+	ctx0 := wtf.NewContextWithUser(context.Background(), user0)
+	s.UserService.FindUserByIDFn = func(ctx context.Context, id int) (*wtf.User, error) {
+		return user0, nil
+	}
+
+	s.SearchService.SearchFn = func(ctx context.Context, filter wtf.SearchFilter) ([]*wtf.SearchResult, int, error) {
+		return nil, 0, wtf.Errorf(wtf.ENOTIMPLEMENTED, "Search is not available.")
+	}
+
+	resp, err := http.DefaultClient.Do(s.MustNewRequest(t, ctx0, "GET", "/search?q=dial", nil))
+	if err != nil {
+		t.Fatal(err)
+	} else if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Fatalf("StatusCode=%v, want %v", got, want)
+	}
+	defer resp.Body.Close()
+
+	if doc, err := goquery.NewDocumentFromReader(resp.Body); err != nil {
+		t.Fatal(err)
+	} else if got := doc.Find(".text-danger").Text(); !strings.Contains(got, "Search is not available.") {
+		t.Fatalf("unexpected alert: %q", got)
+	}
+}
@@ -22,8 +22,6 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"golang.org/x/crypto/acme/autocert"
-	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/github"
 )
 
 // Generic HTTP metrics.
@@ -60,16 +58,32 @@ type Server struct {
 	HashKey  string
 	BlockKey string
 
-	// GitHub OAuth settings.
+	// GitHub OAuth settings. GitHub is only available for login if a
+	// client ID is specified.
 	GitHubClientID     string
 	GitHubClientSecret string
 
+	// Additional authentication providers, such as OpenID Connect providers.
+	AuthProviders []AuthProvider
+
+	// Mailer used to send login links. Email login is only available if a
+	// mailer is specified.
+	Mailer wtf.Mailer
+
 	// Servics used by the various HTTP routes.
-	AuthService           wtf.AuthService
-	DialService           wtf.DialService
-	DialMembershipService wtf.DialMembershipService
-	EventService          wtf.EventService
-	UserService           wtf.UserService
+	AlertService           wtf.AlertService
+	APITokenService        wtf.APITokenService
+	AuthService            wtf.AuthService
+	DialService            wtf.DialService
+	DialMembershipService  wtf.DialMembershipService
+	EventService           wtf.EventService
+	IncomingWebhookService wtf.IncomingWebhookService
+	LoginTokenService      wtf.LoginTokenService
+	OrganizationService    wtf.OrganizationService
+	SearchService          wtf.SearchService
+	SessionService         wtf.SessionService
+	UserService            wtf.UserService
+	WebhookService         wtf.WebhookService
 }
 
 // NewServer returns a new instance of Server.
@@ -103,11 +117,16 @@ func NewServer() *Server {
 	router := s.router.PathPrefix("/").Subrouter()
 	router.Use(s.authenticate)
 	router.Use(loadFlash)
+	router.Use(loadLocation)
 	router.Use(trackMetrics)
 
 	// Handle authentication check within handler function for home page.
 	router.HandleFunc("/", s.handleIndex).Methods("GET")
 
+	// Incoming webhooks authenticate using the token in the path instead of
+	// a session or API key so they are available to anonymous requests.
+	router.HandleFunc("/hooks/{token}", s.handleIncomingWebhookInvoke).Methods("POST")
+
 	// Register unauthenticated routes.
 	{
 		r := s.router.PathPrefix("/").Subrouter()
@@ -120,9 +139,19 @@ func NewServer() *Server {
 		r := router.PathPrefix("/").Subrouter()
 		r.Use(s.requireAuth)
 		r.HandleFunc("/settings", s.handleSettings).Methods("GET")
+		r.HandleFunc("/settings", s.handleSettingsUpdate).Methods("POST")
+		s.registerAPITokenRoutes(r)
+		s.registerAuthLinkRoutes(r)
 		s.registerDialRoutes(r)
 		s.registerDialMembershipRoutes(r)
+		s.registerAlertRoutes(r)
 		s.registerEventRoutes(r)
+		s.registerIncomingWebhookRoutes(r)
+		s.registerOrganizationRoutes(r)
+		s.registerSearchRoutes(r)
+		s.registerSessionRoutes(r)
+		s.registerUserRoutes(r)
+		s.registerWebhookRoutes(r)
 	}
 
 	return s
@@ -141,6 +170,16 @@ func (s *Server) Scheme() string {
 	return "http"
 }
 
+// baseURL returns the base URL used for links back to the server, such as in
+// emails. The configured domain is used, if available, so that links cannot
+// be pointed elsewhere by a forged Host header.
+func (s *Server) baseURL(r *http.Request) string {
+	if s.Domain != "" {
+		return s.URL()
+	}
+	return fmt.Sprintf("%s://%s", s.Scheme(), r.Host)
+}
+
 // Port returns the TCP port for the running server.
 // This is useful in tests where we allocate a random port by using ":0".
 func (s *Server) Port() int {
@@ -175,12 +214,27 @@ func (s *Server) Open() (err error) {
 	}
 
 	// Validate GitHub OAuth settings.
-	if s.GitHubClientID == "" {
-		return fmt.Errorf("github client id required")
-	} else if s.GitHubClientSecret == "" {
+	if s.GitHubClientID != "" && s.GitHubClientSecret == "" {
 		return fmt.Errorf("github client secret required")
 	}
 
+	// Ensure at least one way to log in exists & that provider names are unique.
+	providers := s.authProviders()
+	if len(providers) == 0 && s.Mailer == nil {
+		return fmt.Errorf("auth provider required")
+	}
+	sources := make(map[string]struct{})
+	for _, p := range providers {
+		if p.Source() == "" {
+			return fmt.Errorf("auth provider name required")
+		} else if p.Source() == wtf.AuthSourceEmail {
+			return fmt.Errorf("auth provider name reserved: %q", p.Source())
+		} else if _, ok := sources[p.Source()]; ok {
+			return fmt.Errorf("duplicate auth provider: %q", p.Source())
+		}
+		sources[p.Source()] = struct{}{}
+	}
+
 	// Open a listener on our bind address.
 	if s.Domain != "" {
 		s.ln = autocert.NewListener(s.Domain)
@@ -232,14 +286,10 @@ func (s *Server) Close() error {
 	return s.server.Shutdown(ctx)
 }
 
-// OAuth2Config returns the GitHub OAuth2 configuration.
-func (s *Server) OAuth2Config() *oauth2.Config {
-	return &oauth2.Config{
-		ClientID:     s.GitHubClientID,
-		ClientSecret: s.GitHubClientSecret,
-		Scopes:       []string{},
-		Endpoint:     github.Endpoint,
-	}
+// ServeHTTP handles an HTTP request. This allows the server to be used as an
+// http.Handler by an external listener, such as an httptest.Server.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.server.Handler.ServeHTTP(w, r)
 }
 
 func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
@@ -276,19 +326,28 @@ func (s *Server) authenticate(next http.Handler) http.Handler {
 		if v := r.Header.Get("Authorization"); strings.HasPrefix(v, "Bearer ") {
 			apiKey := strings.TrimPrefix(v, "Bearer ")
 
-			// Lookup user by API key. Display error if not found.
-			// Otherwise set
-			users, _, err := s.UserService.FindUsers(r.Context(), wtf.UserFilter{APIKey: &apiKey})
-			if err != nil {
+			// Lookup the API token. Legacy user API keys were migrated to
+			// API tokens so they are validated the same way. Display error
+			// if not found.
+			token, err := s.APITokenService.AuthenticateAPIToken(r.Context(), apiKey)
+			if wtf.ErrorCode(err) == wtf.ENOTFOUND {
+				Error(w, r, wtf.Errorf(wtf.EUNAUTHORIZED, "Invalid API key."))
+				return
+			} else if err != nil {
 				Error(w, r, err)
 				return
-			} else if len(users) == 0 {
-				Error(w, r, wtf.Errorf(wtf.EUNAUTHORIZED, "Invalid API key."))
+			}
+
+			// Restrict the request to those allowed by the token's scope.
+			if !apiTokenScopeAllows(token.Scope, r) {
+				Error(w, r, wtf.Errorf(wtf.EUNAUTHORIZED, "API token scope does not allow this request."))
 				return
 			}
 
-			// Update request context to include authenticated user.
-			r = r.WithContext(wtf.NewContextWithUser(r.Context(), users[0]))
+			// Update request context to include authenticated user & the
+			// token's scope.
+			ctx := wtf.NewContextWithUser(r.Context(), token.User)
+			r = r.WithContext(wtf.NewContextWithAPITokenScope(ctx, token.Scope))
 
 			// Delegate to next HTTP handler.
 			next.ServeHTTP(w, r)
@@ -298,19 +357,35 @@ func (s *Server) authenticate(next http.Handler) http.Handler {
 		// Read session from secure cookie.
 		session, _ := s.session(r)
 
-		// Read user, if available. Ignore if fetching assets.
-		if session.UserID != 0 {
-			if user, err := s.UserService.FindUserByID(r.Context(), session.UserID); err != nil {
-				log.Printf("cannot find session user: id=%d err=%s", session.UserID, err)
-			} else {
-				r = r.WithContext(wtf.NewContextWithUser(r.Context(), user))
-			}
+		// Read user from the server-side session, if available.
+		if user := s.sessionUser(r.Context(), session); user != nil {
+			r = r.WithContext(wtf.NewContextWithUser(r.Context(), user))
 		}
 
 		next.ServeHTTP(w, r)
 	})
 }
 
+// sessionUser returns the user for the server-side session referenced by the
+// cookie. Returns nil if there is no session or if it has been revoked or has
+// expired so those are treated as logged out.
+func (s *Server) sessionUser(ctx context.Context, session Session) *wtf.User {
+	if session.UserID == 0 || session.SessionID == 0 {
+		return nil
+	}
+
+	other, err := s.SessionService.AuthenticateSession(ctx, session.SessionID)
+	if err != nil {
+		if code := wtf.ErrorCode(err); code != wtf.ENOTFOUND && code != wtf.EUNAUTHORIZED {
+			log.Printf("cannot authenticate session: id=%d err=%s", session.SessionID, err)
+		}
+		return nil
+	} else if other.UserID != session.UserID {
+		return nil
+	}
+	return other.User
+}
+
 // requireNoAuth is middleware for requiring no authentication.
 // This is used if a user goes to log in but is already logged in.
 func (s *Server) requireNoAuth(next http.Handler) http.Handler {
@@ -336,6 +411,13 @@ func (s *Server) requireAuth(next http.Handler) http.Handler {
 			return
 		}
 
+		// API requests cannot follow a redirect to the login page so return
+		// an error instead.
+		if r.Header.Get("Accept") == "application/json" {
+			Error(w, r, wtf.Errorf(wtf.EUNAUTHORIZED, "You must be logged in."))
+			return
+		}
+
 		// Otherwise save the current URL (without scheme/host).
 		redirectURL := r.URL
 		redirectURL.Scheme, redirectURL.Host = "", ""
@@ -366,6 +448,24 @@ func loadFlash(next http.Handler) http.Handler {
 	})
 }
 
+// loadLocation is middleware for reading the "tz" query parameter. This allows
+// API callers to override the current user's timezone for a single request.
+func loadLocation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v := r.URL.Query().Get("tz"); v != "" {
+			if !wtf.IsValidTimezone(v) {
+				Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid timezone."))
+				return
+			}
+			loc, _ := time.LoadLocation(v)
+			r = r.WithContext(wtf.NewContextWithLocation(r.Context(), loc))
+		}
+
+		// Delegate to next HTTP handler.
+		next.ServeHTTP(w, r)
+	})
+}
+
 // trackMetrics is middleware for tracking the request count and timing per route.
 func trackMetrics(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -458,7 +558,7 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 
 	// Fetch historical average WTF values.
 	interval := time.Minute
-	end := time.Now().Truncate(interval).Add(interval)
+	end := wtf.TruncateReportTime(time.Now(), interval, wtf.LocationFromContext(r.Context())).Add(interval)
 	start := end.Add(-1 * time.Hour)
 	if tmpl.AverageDialValueReport, err = s.DialService.AverageDialValueReport(r.Context(), start, end, interval); err != nil {
 		Error(w, r, err)
@@ -471,7 +571,68 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 
 // handleSettings handles the "GET /settings" route.
 func (s *Server) handleSettings(w http.ResponseWriter, r *http.Request) {
-	var tmpl html.SettingsTemplate
+	tmpl := html.SettingsTemplate{Timezone: wtf.UserFromContext(r.Context()).Timezone}
+	s.renderSettings(w, r, tmpl)
+}
+
+// handleSettingsUpdate handles the "POST /settings" route. It updates the
+// current user's preferences from the settings form.
+func (s *Server) handleSettingsUpdate(w http.ResponseWriter, r *http.Request) {
+	timezone := r.PostFormValue("timezone")
+	upd := wtf.UserUpdate{Timezone: &timezone}
+
+	// The avatar preference is only shown if the user has linked accounts.
+	if _, ok := r.PostForm["avatarSource"]; ok {
+		avatarSource := r.PostForm.Get("avatarSource")
+		upd.AvatarSource = &avatarSource
+	}
+
+	// Update the current user in the database.
+	_, err := s.UserService.UpdateUser(r.Context(), wtf.UserIDFromContext(r.Context()), upd)
+	if wtf.ErrorCode(err) == wtf.EINTERNAL {
+		Error(w, r, err)
+		return
+	} else if err != nil {
+		tmpl := html.SettingsTemplate{Timezone: timezone, Err: err}
+		s.renderSettings(w, r, tmpl)
+		return
+	}
+
+	// Save a message to display to the user on the next page.
+	// Then redirect them back to the settings page.
+	SetFlash(w, "Settings successfully updated.")
+	http.Redirect(w, r, "/settings", http.StatusFound)
+}
+
+// renderSettings renders the settings page with the current user's API
+// tokens, active sessions & the providers available for linking attached.
+func (s *Server) renderSettings(w http.ResponseWriter, r *http.Request, tmpl html.SettingsTemplate) {
+	tokens, _, err := s.APITokenService.FindAPITokens(r.Context(), wtf.APITokenFilter{})
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	tmpl.APITokens = tokens
+
+	sessions, _, err := s.SessionService.FindSessions(r.Context(), wtf.SessionFilter{})
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	tmpl.Sessions = sessions
+
+	// List providers so linked accounts can be named & new ones can be linked.
+	for _, p := range s.authProviders() {
+		tmpl.Providers = append(tmpl.Providers, html.LoginProvider{
+			Source:      p.Source(),
+			DisplayName: p.DisplayName(),
+		})
+	}
+
+	// Mark the session for this browser so it can be highlighted.
+	session, _ := s.session(r)
+	tmpl.CurrentSessionID = session.SessionID
+
 	tmpl.Render(r.Context(), w)
 }
 
@@ -25,11 +25,19 @@ type Server struct {
 	*wtfhttp.Server
 
 	// Mock services.
-	AuthService           mock.AuthService
-	DialService           mock.DialService
-	DialMembershipService mock.DialMembershipService
-	EventService          mock.EventService
-	UserService           mock.UserService
+	AlertService           mock.AlertService
+	APITokenService        mock.APITokenService
+	AuthService            mock.AuthService
+	DialService            mock.DialService
+	DialMembershipService  mock.DialMembershipService
+	EventService           mock.EventService
+	IncomingWebhookService mock.IncomingWebhookService
+	LoginTokenService      mock.LoginTokenService
+	OrganizationService    mock.OrganizationService
+	SearchService          mock.SearchService
+	SessionService         mock.SessionService
+	UserService            mock.UserService
+	WebhookService         mock.WebhookService
 }
 
 // MustOpenServer is a test helper function for starting a new test HTTP server.
@@ -45,11 +53,30 @@ func MustOpenServer(tb testing.TB) *Server {
 	s.GitHubClientSecret = TestGitHubClientSecret
 
 	// Assign mocks to actual server's services.
+	s.Server.AlertService = &s.AlertService
+	s.Server.APITokenService = &s.APITokenService
 	s.Server.AuthService = &s.AuthService
 	s.Server.DialService = &s.DialService
 	s.Server.DialMembershipService = &s.DialMembershipService
 	s.Server.EventService = &s.EventService
+	s.Server.IncomingWebhookService = &s.IncomingWebhookService
+	s.Server.LoginTokenService = &s.LoginTokenService
+	s.Server.OrganizationService = &s.OrganizationService
+	s.Server.SearchService = &s.SearchService
+	s.Server.SessionService = &s.SessionService
 	s.Server.UserService = &s.UserService
+	s.Server.WebhookService = &s.WebhookService
+
+	// Sessions created by MustNewRequest() use the user's ID as the session
+	// ID so, by default, resolve a session's user through the user service.
+	// This allows tests to only mock FindUserByID().
+	s.SessionService.AuthenticateSessionFn = func(ctx context.Context, id int) (*wtf.Session, error) {
+		user, err := s.UserService.FindUserByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return &wtf.Session{ID: id, UserID: user.ID, User: user}, nil
+	}
 
 	// Begin running test server.
 	if err := s.Open(); err != nil {
@@ -62,6 +89,12 @@ func MustOpenServer(tb testing.TB) *Server {
 // Fail on error.
 func MustCloseServer(tb testing.TB, s *Server) {
 	tb.Helper()
+
+	// The HTTP client services use the default client. Close its idle
+	// connections first as the server waits on connections which have not
+	// yet sent a request, such as those dialed but unused by the transport.
+	http.DefaultClient.CloseIdleConnections()
+
 	if err := s.Close(); err != nil {
 		tb.Fatal(err)
 	}
@@ -80,7 +113,7 @@ func (s *Server) MustNewRequest(tb testing.TB, ctx context.Context, method, url
 
 	// Generate session cookie for user, if logged in.
 	if user := wtf.UserFromContext(ctx); user != nil {
-		data, err := s.MarshalSession(wtfhttp.Session{UserID: user.ID})
+		data, err := s.MarshalSession(wtfhttp.Session{UserID: user.ID, SessionID: user.ID})
 		if err != nil {
 			tb.Fatal(err)
 		}
@@ -0,0 +1,281 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/benbjohnson/wtf"
+	"github.com/gorilla/mux"
+)
+
+// registerSessionRoutes is a helper function for registering session routes.
+func (s *Server) registerSessionRoutes(r *mux.Router) {
+	// API endpoint for listing the current user's active sessions.
+	r.HandleFunc("/sessions", s.handleSessionIndex).Methods("GET")
+
+	// Revoke all of the current user's sessions ("sign out everywhere").
+	r.HandleFunc("/sessions", s.handleSessionDeleteAll).Methods("DELETE")
+
+	// Revoke a single session.
+	r.HandleFunc("/sessions/{id}", s.handleSessionDelete).Methods("DELETE")
+}
+
+// handleSessionIndex handles the "GET /sessions" route. This route accepts an
+// optional JSON filter and returns the current user's active sessions.
+func (s *Server) handleSessionIndex(w http.ResponseWriter, r *http.Request) {
+	// Force application/json output.
+	r.Header.Set("Accept", "application/json")
+
+	// Parse optional filter object.
+	var filter wtf.SessionFilter
+	switch r.Header.Get("Content-type") {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&filter); err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid JSON body"))
+			return
+		}
+	default:
+		filter.Offset, _ = strconv.Atoi(r.URL.Query().Get("offset"))
+		filter.Limit = 20
+	}
+
+	// Fetch sessions from database.
+	sessions, n, err := s.SessionService.FindSessions(r.Context(), filter)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Write sessions & total count as JSON response.
+	w.Header().Set("Content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(findSessionsResponse{
+		Sessions: sessions,
+		N:        n,
+	}); err != nil {
+		LogError(r, err)
+		return
+	}
+}
+
+// findSessionsResponse represents the output JSON struct for "GET /sessions".
+type findSessionsResponse struct {
+	Sessions []*wtf.Session `json:"sessions"`
+	N        int            `json:"n"`
+}
+
+// handleSessionDelete handles the "DELETE /sessions/:id" route. This route
+// revokes the session and redirects back to the settings page. Revoking the
+// current session logs the user out.
+func (s *Server) handleSessionDelete(w http.ResponseWriter, r *http.Request) {
+	// Parse session ID from the URL.
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid ID format"))
+		return
+	}
+
+	// Delete session.
+	if err := s.SessionService.DeleteSession(r.Context(), id); err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Render output to the client based on HTTP accept header.
+	switch r.Header.Get("Accept") {
+	case "application/json":
+		w.Header().Set("Content-type", "application/json")
+		w.Write([]byte(`{}`))
+
+	default:
+		if session, _ := s.session(r); session.SessionID == id {
+			if err := s.setSession(w, Session{}); err != nil {
+				Error(w, r, err)
+				return
+			}
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+
+		SetFlash(w, "Session successfully revoked.")
+		http.Redirect(w, r, "/settings", http.StatusFound)
+	}
+}
+
+// handleSessionDeleteAll handles the "DELETE /sessions" route. This route
+// revokes all of the current user's sessions, including the current one, and
+// redirects the user to the home page.
+func (s *Server) handleSessionDeleteAll(w http.ResponseWriter, r *http.Request) {
+	// Delete all sessions.
+	if err := s.SessionService.DeleteAllSessions(r.Context()); err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Render output to the client based on HTTP accept header.
+	switch r.Header.Get("Accept") {
+	case "application/json":
+		w.Header().Set("Content-type", "application/json")
+		w.Write([]byte(`{}`))
+
+	default:
+		// Clear session cookie as the current session has been revoked too.
+		if err := s.setSession(w, Session{}); err != nil {
+			Error(w, r, err)
+			return
+		}
+		http.Redirect(w, r, "/", http.StatusFound)
+	}
+}
+
+// newSession returns a session populated with details about the client
+// making the request.
+func newSession(r *http.Request) *wtf.Session {
+	ipAddress := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ipAddress = host
+	}
+
+	return &wtf.Session{
+		Device:    describeUserAgent(r.UserAgent()),
+		IPAddress: ipAddress,
+		UserAgent: r.UserAgent(),
+	}
+}
+
+// describeUserAgent returns a short, human readable description of the
+// browser & operating system in a user agent string (e.g. "Firefox on Linux").
+// This is only a rough guess used for display on the settings page.
+func describeUserAgent(ua string) string {
+	var browser string
+	switch {
+	case strings.Contains(ua, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "Firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "Chrome/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "Safari/"):
+		browser = "Safari"
+	default:
+		browser = "Unknown browser"
+	}
+
+	var os string
+	switch {
+	case strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPad"):
+		os = "iOS"
+	case strings.Contains(ua, "Android"):
+		os = "Android"
+	case strings.Contains(ua, "Windows"):
+		os = "Windows"
+	case strings.Contains(ua, "Mac OS X"):
+		os = "macOS"
+	case strings.Contains(ua, "Linux"):
+		os = "Linux"
+	default:
+		return browser
+	}
+	return browser + " on " + os
+}
+
+// SessionService implements the wtf.SessionService over the HTTP protocol.
+type SessionService struct {
+	Client *Client
+}
+
+// NewSessionService returns a new instance of SessionService.
+func NewSessionService(client *Client) *SessionService {
+	return &SessionService{Client: client}
+}
+
+// FindSessions retrieves a list of the current user's active sessions by
+// filter. Also returns a count of total matching sessions which may differ if
+// "Limit" is specified on the filter.
+func (s *SessionService) FindSessions(ctx context.Context, filter wtf.SessionFilter) ([]*wtf.Session, int, error) {
+	// Marshal filter into JSON format.
+	body, err := json.Marshal(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "GET", "/sessions", bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Issue request. Any non-200 status code is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, 0, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal result set of sessions & total session count.
+	var jsonResponse findSessionsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&jsonResponse); err != nil {
+		return nil, 0, err
+	}
+	return jsonResponse.Sessions, jsonResponse.N, nil
+}
+
+// CreateSession is not implemented by the HTTP client. Sessions are created
+// by the server when a user logs in through the browser.
+func (s *SessionService) CreateSession(ctx context.Context, session *wtf.Session) error {
+	return wtf.Errorf(wtf.ENOTIMPLEMENTED, "Not implemented.")
+}
+
+// AuthenticateSession is not implemented by the HTTP client. Sessions are
+// validated by the server when the session cookie is passed with each request.
+func (s *SessionService) AuthenticateSession(ctx context.Context, id int) (*wtf.Session, error) {
+	return nil, wtf.Errorf(wtf.ENOTIMPLEMENTED, "Not implemented.")
+}
+
+// DeleteSession revokes one of the current user's sessions. Returns ENOTFOUND
+// if the session does not exist or belongs to another user.
+func (s *SessionService) DeleteSession(ctx context.Context, id int) error {
+	// Create a request with API key.
+	req, err := s.Client.newRequest(ctx, "DELETE", fmt.Sprintf("/sessions/%d", id), nil)
+	if err != nil {
+		return err
+	}
+
+	// Issue request. Any non-200 response is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// DeleteAllSessions revokes all of the current user's sessions.
+func (s *SessionService) DeleteAllSessions(ctx context.Context) error {
+	// Create a request with API key.
+	req, err := s.Client.newRequest(ctx, "DELETE", "/sessions", nil)
+	if err != nil {
+		return err
+	}
+
+	// Issue request. Any non-200 response is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
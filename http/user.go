@@ -0,0 +1,254 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/benbjohnson/wtf"
+	"github.com/gorilla/mux"
+)
+
+// registerUserRoutes is a helper function for registering user routes.
+// These routes are only available via the JSON API and are restricted to the
+// currently logged in user.
+func (s *Server) registerUserRoutes(r *mux.Router) {
+	// Fetch the currently logged in user.
+	r.HandleFunc("/users/me", s.handleUserMe).Methods("GET")
+
+	// View, update & delete a user.
+	r.HandleFunc("/users/{id}", s.handleUserView).Methods("GET")
+	r.HandleFunc("/users/{id}", s.handleUserUpdate).Methods("PATCH")
+	r.HandleFunc("/users/{id}", s.handleUserDelete).Methods("DELETE")
+}
+
+// handleUserMe handles the "GET /users/me" route. It returns the currently
+// logged in user. This allows API clients that only know their API key to
+// look up their own user details.
+func (s *Server) handleUserMe(w http.ResponseWriter, r *http.Request) {
+	// Force application/json output.
+	r.Header.Set("Accept", "application/json")
+
+	// Fetch the current user from the database so associations are attached.
+	user, err := s.UserService.FindUserByID(r.Context(), wtf.UserIDFromContext(r.Context()))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Write user back as JSON response.
+	w.Header().Set("Content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(user); err != nil {
+		LogError(r, err)
+		return
+	}
+}
+
+// handleUserView handles the "GET /users/:id" route. Users can only view
+// themselves so any other user ID returns a not found error.
+func (s *Server) handleUserView(w http.ResponseWriter, r *http.Request) {
+	// Parse user ID from URL path.
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid ID format"))
+		return
+	}
+
+	// Force application/json output.
+	r.Header.Set("Accept", "application/json")
+
+	// Do not reveal other users' details.
+	if id != wtf.UserIDFromContext(r.Context()) {
+		Error(w, r, wtf.Errorf(wtf.ENOTFOUND, "User not found."))
+		return
+	}
+
+	// Fetch user from the database.
+	user, err := s.UserService.FindUserByID(r.Context(), id)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Write user back as JSON response.
+	w.Header().Set("Content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(user); err != nil {
+		LogError(r, err)
+		return
+	}
+}
+
+// handleUserUpdate handles the "PATCH /users/:id" route. Only the current
+// user can be updated.
+func (s *Server) handleUserUpdate(w http.ResponseWriter, r *http.Request) {
+	// Parse user ID from URL path.
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid ID format"))
+		return
+	}
+
+	// Force application/json output.
+	r.Header.Set("Accept", "application/json")
+
+	// Parse update object from JSON request body.
+	var upd wtf.UserUpdate
+	if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid JSON body"))
+		return
+	}
+
+	// Update user in the database.
+	user, err := s.UserService.UpdateUser(r.Context(), id, upd)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Write new user state back as JSON response.
+	w.Header().Set("Content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(user); err != nil {
+		LogError(r, err)
+		return
+	}
+}
+
+// handleUserDelete handles the "DELETE /users/:id" route. This permanently
+// deletes the current user along with all of their owned dials.
+func (s *Server) handleUserDelete(w http.ResponseWriter, r *http.Request) {
+	// Parse user ID from URL path.
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid ID format"))
+		return
+	}
+
+	// Force application/json output.
+	r.Header.Set("Accept", "application/json")
+
+	// Delete user from the database.
+	if err := s.UserService.DeleteUser(r.Context(), id); err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Write response to indicate success.
+	w.Header().Set("Content-type", "application/json")
+	w.Write([]byte(`{}`))
+}
+
+// UserService implements the wtf.UserService over the HTTP protocol.
+//
+// Users can only view & manage themselves over HTTP. Users are created via
+// OAuth so CreateUser() and FindUsers() are not implemented.
+type UserService struct {
+	Client *Client
+}
+
+// NewUserService returns a new instance of UserService.
+func NewUserService(client *Client) *UserService {
+	return &UserService{Client: client}
+}
+
+// FindCurrentUser retrieves the user associated with the API key on the context.
+func (s *UserService) FindCurrentUser(ctx context.Context) (*wtf.User, error) {
+	return s.findUser(ctx, "/users/me")
+}
+
+// FindUserByID retrieves a user by ID along with their associated auth objects.
+// Returns ENOTFOUND if user does not exist or is not the current user.
+func (s *UserService) FindUserByID(ctx context.Context, id int) (*wtf.User, error) {
+	return s.findUser(ctx, fmt.Sprintf("/users/%d", id))
+}
+
+// findUser retrieves a single user from the given URL path.
+func (s *UserService) findUser(ctx context.Context, path string) (*wtf.User, error) {
+	// Create request with API key attached.
+	req, err := s.Client.newRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Issue request. If any other status besides 200, then treats as an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal the returned user data.
+	var user wtf.User
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindUsers is not implemented by the HTTP service.
+func (s *UserService) FindUsers(ctx context.Context, filter wtf.UserFilter) ([]*wtf.User, int, error) {
+	return nil, 0, wtf.Errorf(wtf.ENOTIMPLEMENTED, "Not implemented.")
+}
+
+// CreateUser is not implemented by the HTTP service.
+func (s *UserService) CreateUser(ctx context.Context, user *wtf.User) error {
+	return wtf.Errorf(wtf.ENOTIMPLEMENTED, "Not implemented.")
+}
+
+// UpdateUser updates a user object. Returns EUNAUTHORIZED if current user is
+// not the user that is being updated. Returns ENOTFOUND if user does not exist.
+func (s *UserService) UpdateUser(ctx context.Context, id int, upd wtf.UserUpdate) (*wtf.User, error) {
+	// Marshal update fields into JSON format.
+	body, err := json.Marshal(upd)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "PATCH", fmt.Sprintf("/users/%d", id), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	// Issue request. Any non-200 status code is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal the updated user data.
+	var user wtf.User
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// DeleteUser permanently deletes a user and all owned dials. Returns
+// EUNAUTHORIZED if current user is not the user being deleted. Returns
+// ENOTFOUND if user does not exist.
+func (s *UserService) DeleteUser(ctx context.Context, id int) error {
+	// Create a request with API key.
+	req, err := s.Client.newRequest(ctx, "DELETE", fmt.Sprintf("/users/%d", id), nil)
+	if err != nil {
+		return err
+	}
+
+	// Issue request. Any non-200 response is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
@@ -0,0 +1,629 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+	"github.com/benbjohnson/wtf/http/html"
+	"github.com/gorilla/mux"
+)
+
+// registerWebhookRoutes is a helper function for registering webhook routes.
+func (s *Server) registerWebhookRoutes(r *mux.Router) {
+	// HTML page for managing a dial's webhooks & viewing recent deliveries.
+	r.HandleFunc("/dials/{id}/webhooks", s.handleDialWebhooks).Methods("GET")
+
+	// API endpoints for listing & creating webhooks. Webhooks can also be
+	// created via the HTML form on the dial webhooks page.
+	r.HandleFunc("/webhooks", s.handleWebhookIndex).Methods("GET")
+	r.HandleFunc("/webhooks", s.handleWebhookCreate).Methods("POST")
+
+	// View & remove a single webhook.
+	r.HandleFunc("/webhooks/{id}", s.handleWebhookView).Methods("GET")
+	r.HandleFunc("/webhooks/{id}", s.handleWebhookDelete).Methods("DELETE")
+
+	// List recent deliveries & queue a delivery to be sent again.
+	r.HandleFunc("/webhook-deliveries", s.handleWebhookDeliveryIndex).Methods("GET")
+	r.HandleFunc("/webhook-deliveries/{id}/redeliver", s.handleWebhookDeliveryRedeliver).Methods("POST")
+}
+
+// handleDialWebhooks handles the "GET /dials/:id/webhooks" route. It lists
+// the webhooks on the dial along with the most recent deliveries.
+func (s *Server) handleDialWebhooks(w http.ResponseWriter, r *http.Request) {
+	// Parse dial ID from the path.
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid ID format"))
+		return
+	}
+
+	// Fetch dial & ensure the user can manage it.
+	dial, err := s.DialService.FindDialByID(r.Context(), id)
+	if err != nil {
+		Error(w, r, err)
+		return
+	} else if !wtf.CanEditDial(r.Context(), dial) {
+		Error(w, r, wtf.Errorf(wtf.EUNAUTHORIZED, "You must be the owner or an admin to manage webhooks."))
+		return
+	}
+
+	// Fetch the dial's webhooks & most recent deliveries.
+	webhooks, _, err := s.WebhookService.FindWebhooks(r.Context(), wtf.WebhookFilter{DialID: &dial.ID})
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	deliveries, _, err := s.WebhookService.FindWebhookDeliveries(r.Context(), wtf.WebhookDeliveryFilter{DialID: &dial.ID, Limit: 20})
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	tmpl := html.WebhookIndexTemplate{
+		Dial:       dial,
+		Webhooks:   webhooks,
+		Deliveries: deliveries,
+	}
+	tmpl.Render(r.Context(), w)
+}
+
+// handleWebhookIndex handles the "GET /webhooks" route. This route accepts
+// an optional JSON filter and returns all matching webhooks on dials that
+// the current user owns or administers.
+func (s *Server) handleWebhookIndex(w http.ResponseWriter, r *http.Request) {
+	// Force application/json output.
+	r.Header.Set("Accept", "application/json")
+
+	// Parse optional filter object.
+	var filter wtf.WebhookFilter
+	switch r.Header.Get("Content-type") {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&filter); err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid JSON body"))
+			return
+		}
+	default:
+		filter.Offset, _ = strconv.Atoi(r.URL.Query().Get("offset"))
+		filter.Limit = 20
+	}
+
+	// Fetch webhooks from database.
+	webhooks, n, err := s.WebhookService.FindWebhooks(r.Context(), filter)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Write webhooks & total count as JSON response.
+	w.Header().Set("Content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(findWebhooksResponse{
+		Webhooks: webhooks,
+		N:        n,
+	}); err != nil {
+		LogError(r, err)
+		return
+	}
+}
+
+// findWebhooksResponse represents the output JSON struct for "GET /webhooks".
+type findWebhooksResponse struct {
+	Webhooks []*wtf.Webhook `json:"webhooks"`
+	N        int            `json:"n"`
+}
+
+// handleWebhookView handles the "GET /webhooks/:id" route.
+func (s *Server) handleWebhookView(w http.ResponseWriter, r *http.Request) {
+	// Parse webhook ID from URL path.
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid ID format"))
+		return
+	}
+
+	// Force application/json output.
+	r.Header.Set("Accept", "application/json")
+
+	// Fetch webhook from the database.
+	webhook, err := s.WebhookService.FindWebhookByID(r.Context(), id)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Write webhook as JSON response.
+	w.Header().Set("Content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(webhook); err != nil {
+		LogError(r, err)
+		return
+	}
+}
+
+// handleWebhookCreate handles the "POST /webhooks" route. It reads the
+// webhook as JSON or from the HTML form on the dial webhooks page.
+func (s *Server) handleWebhookCreate(w http.ResponseWriter, r *http.Request) {
+	var webhook wtf.Webhook
+	switch r.Header.Get("Content-type") {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&webhook); err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid JSON body"))
+			return
+		}
+	default:
+		if err := r.ParseForm(); err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid form body"))
+			return
+		}
+		webhook.DialID, _ = strconv.Atoi(r.PostForm.Get("dialID"))
+		webhook.URL = r.PostForm.Get("url")
+		webhook.Secret = r.PostForm.Get("secret")
+		webhook.EventTypes = r.PostForm["eventTypes"]
+	}
+
+	// Create webhook in the database.
+	if err := s.WebhookService.CreateWebhook(r.Context(), &webhook); err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Render output to the client based on HTTP accept header.
+	switch r.Header.Get("Accept") {
+	case "application/json":
+		w.Header().Set("Content-type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(webhook); err != nil {
+			LogError(r, err)
+			return
+		}
+
+	default:
+		SetFlash(w, "Webhook successfully created.")
+		http.Redirect(w, r, fmt.Sprintf("/dials/%d/webhooks", webhook.DialID), http.StatusFound)
+	}
+}
+
+// handleWebhookDelete handles the "DELETE /webhooks/:id" route. This route
+// deletes the webhook & its deliveries and redirects back to the dial's
+// webhooks page.
+func (s *Server) handleWebhookDelete(w http.ResponseWriter, r *http.Request) {
+	// Parse webhook ID from the URL.
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid ID format"))
+		return
+	}
+
+	// Look up webhook by ID so we know which dial to redirect back to.
+	webhook, err := s.WebhookService.FindWebhookByID(r.Context(), id)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Delete webhook.
+	if err := s.WebhookService.DeleteWebhook(r.Context(), id); err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Render output to the client based on HTTP accept header.
+	switch r.Header.Get("Accept") {
+	case "application/json":
+		w.Header().Set("Content-type", "application/json")
+		w.Write([]byte(`{}`))
+
+	default:
+		SetFlash(w, "Webhook successfully deleted.")
+		http.Redirect(w, r, fmt.Sprintf("/dials/%d/webhooks", webhook.DialID), http.StatusFound)
+	}
+}
+
+// handleWebhookDeliveryIndex handles the "GET /webhook-deliveries" route.
+// This route accepts an optional JSON filter and returns matching
+// deliveries, most recent first.
+func (s *Server) handleWebhookDeliveryIndex(w http.ResponseWriter, r *http.Request) {
+	// Force application/json output.
+	r.Header.Set("Accept", "application/json")
+
+	// Parse optional filter object.
+	var filter wtf.WebhookDeliveryFilter
+	switch r.Header.Get("Content-type") {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&filter); err != nil {
+			Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid JSON body"))
+			return
+		}
+	default:
+		filter.Offset, _ = strconv.Atoi(r.URL.Query().Get("offset"))
+		filter.Limit = 20
+	}
+
+	// Fetch deliveries from database.
+	deliveries, n, err := s.WebhookService.FindWebhookDeliveries(r.Context(), filter)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Write deliveries & total count as JSON response.
+	w.Header().Set("Content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(findWebhookDeliveriesResponse{
+		WebhookDeliveries: deliveries,
+		N:                 n,
+	}); err != nil {
+		LogError(r, err)
+		return
+	}
+}
+
+// findWebhookDeliveriesResponse represents the output JSON struct for
+// "GET /webhook-deliveries".
+type findWebhookDeliveriesResponse struct {
+	WebhookDeliveries []*wtf.WebhookDelivery `json:"webhookDeliveries"`
+	N                 int                    `json:"n"`
+}
+
+// handleWebhookDeliveryRedeliver handles the "POST /webhook-deliveries/:id/redeliver"
+// route. It queues a new delivery of the same event & returns the new
+// delivery or redirects back to the dial's webhooks page.
+func (s *Server) handleWebhookDeliveryRedeliver(w http.ResponseWriter, r *http.Request) {
+	// Parse delivery ID from the URL.
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		Error(w, r, wtf.Errorf(wtf.EINVALID, "Invalid ID format"))
+		return
+	}
+
+	// Queue the delivery again.
+	delivery, err := s.WebhookService.RedeliverWebhookDelivery(r.Context(), id)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	// Render output to the client based on HTTP accept header.
+	switch r.Header.Get("Accept") {
+	case "application/json":
+		w.Header().Set("Content-type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(delivery); err != nil {
+			LogError(r, err)
+			return
+		}
+
+	default:
+		SetFlash(w, "Webhook delivery successfully queued.")
+		http.Redirect(w, r, fmt.Sprintf("/dials/%d/webhooks", delivery.DialID), http.StatusFound)
+	}
+}
+
+// Webhook dispatcher settings.
+const (
+	// Number of pending deliveries sent by a single call to DeliverPending().
+	DefaultWebhookDeliveryBatchSize = 100
+
+	// Time allowed for a receiver to respond to a delivery.
+	WebhookDeliveryTimeout = 10 * time.Second
+)
+
+// WebhookDispatcher sends pending webhook deliveries to their receivers.
+//
+// Each delivery is sent as an HTTP POST of the JSON-encoded event. The body
+// is signed with the webhook secret & the signature is sent in the
+// "X-WTF-Signature" header so receivers can verify the sender.
+type WebhookDispatcher struct {
+	// Service used to fetch pending deliveries & record attempts.
+	WebhookService wtf.WebhookService
+
+	// HTTP client used to send deliveries. The default client only connects
+	// to public addresses & does not follow redirects.
+	HTTPClient *http.Client
+
+	// Maximum number of deliveries sent per call to DeliverPending().
+	BatchSize int
+}
+
+// NewWebhookDispatcher returns a new instance of WebhookDispatcher.
+func NewWebhookDispatcher(webhookService wtf.WebhookService) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		WebhookService: webhookService,
+		HTTPClient:     newWebhookHTTPClient(),
+		BatchSize:      DefaultWebhookDeliveryBatchSize,
+	}
+}
+
+// errWebhookAddressNotAllowed is returned when a delivery connects to an
+// address that is not public.
+var errWebhookAddressNotAllowed = errors.New("webhook address not allowed")
+
+// newWebhookHTTPClient returns an HTTP client for sending deliveries.
+//
+// Webhook URLs are provided by users so the client checks the resolved IP
+// address of every connection to ensure it is public. This prevents webhooks
+// from reaching the server's own network, even if a host name resolves to a
+// private address. Redirects are not followed for the same reason.
+func newWebhookHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: WebhookDeliveryTimeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			} else if ip := net.ParseIP(host); ip == nil || !wtf.IsPublicIP(ip) {
+				return errWebhookAddressNotAllowed
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: WebhookDeliveryTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: WebhookDeliveryTimeout,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// DeliverPending sends each pending delivery which is due & records the
+// result of each attempt. Failed deliveries are retried by a later call.
+func (d *WebhookDispatcher) DeliverPending(ctx context.Context) error {
+	deliveries, err := d.WebhookService.FindPendingWebhookDeliveries(ctx, d.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		result := d.deliver(ctx, delivery)
+		if _, err := d.WebhookService.RecordWebhookDeliveryAttempt(ctx, delivery.ID, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliver sends a single delivery to its webhook & returns the result.
+func (d *WebhookDispatcher) deliver(ctx context.Context, delivery *wtf.WebhookDelivery) wtf.WebhookDeliveryResult {
+	req, err := http.NewRequestWithContext(ctx, "POST", delivery.Webhook.URL, bytes.NewReader(delivery.Body))
+	if err != nil {
+		return wtf.WebhookDeliveryResult{Error: "Invalid webhook URL."}
+	}
+	req.Header.Set("Content-type", "application/json")
+	req.Header.Set("X-WTF-Event", delivery.EventType)
+	req.Header.Set("X-WTF-Delivery", strconv.Itoa(delivery.ID))
+	req.Header.Set("X-WTF-Signature", wtf.SignWebhookBody(delivery.Webhook.Secret, delivery.Body))
+
+	// Errors are shown to the dial's owners so the underlying network error is
+	// only logged. Otherwise, it could be used to probe hosts & ports.
+	resp, err := d.HTTPClient.Do(req)
+	if err != nil {
+		log.Printf("webhook delivery failed: id=%d err=%s", delivery.ID, err)
+		return wtf.WebhookDeliveryResult{Error: webhookDeliveryErrorMessage(err)}
+	}
+	defer resp.Body.Close()
+
+	// Drain the body so the connection can be reused.
+	io.Copy(ioutil.Discard, resp.Body)
+
+	return wtf.WebhookDeliveryResult{StatusCode: resp.StatusCode}
+}
+
+// webhookDeliveryErrorMessage returns a user-facing message for a failed
+// delivery request.
+func webhookDeliveryErrorMessage(err error) string {
+	var netErr net.Error
+	if errors.Is(err, errWebhookAddressNotAllowed) {
+		return "Webhook URL must not point to a local or private address."
+	} else if errors.As(err, &netErr) && netErr.Timeout() {
+		return "Webhook receiver did not respond in time."
+	}
+	return "Unable to connect to webhook receiver."
+}
+
+// WebhookService implements the wtf.WebhookService over the HTTP protocol.
+type WebhookService struct {
+	Client *Client
+}
+
+// NewWebhookService returns a new instance of WebhookService.
+func NewWebhookService(client *Client) *WebhookService {
+	return &WebhookService{Client: client}
+}
+
+// FindWebhookByID retrieves a single webhook by ID along with the associated
+// dial. Returns ENOTFOUND if webhook does not exist or user is not the owner
+// or an admin of the webhook's dial.
+func (s *WebhookService) FindWebhookByID(ctx context.Context, id int) (*wtf.Webhook, error) {
+	// Create request with API key attached.
+	req, err := s.Client.newRequest(ctx, "GET", fmt.Sprintf("/webhooks/%d", id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Issue request. If any other status besides 200, then treats as an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal the returned webhook data.
+	var webhook wtf.Webhook
+	if err := json.NewDecoder(resp.Body).Decode(&webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// FindWebhooks retrieves a list of webhooks by filter. Only returns webhooks
+// on dials that the current user owns or administers. Also returns a count of
+// total matching webhooks which may differ if "Limit" is specified on the filter.
+func (s *WebhookService) FindWebhooks(ctx context.Context, filter wtf.WebhookFilter) ([]*wtf.Webhook, int, error) {
+	// Marshal filter into JSON format.
+	body, err := json.Marshal(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "GET", "/webhooks", bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Issue request. Any non-200 status code is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, 0, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal result set of webhooks & total webhook count.
+	var jsonResponse findWebhooksResponse
+	if err := json.NewDecoder(resp.Body).Decode(&jsonResponse); err != nil {
+		return nil, 0, err
+	}
+	return jsonResponse.Webhooks, jsonResponse.N, nil
+}
+
+// CreateWebhook creates a new webhook on a dial. Returns EUNAUTHORIZED if the
+// user is not the owner or an admin of the dial.
+func (s *WebhookService) CreateWebhook(ctx context.Context, webhook *wtf.Webhook) error {
+	// Marshal webhook data into JSON format.
+	body, err := json.Marshal(webhook)
+	if err != nil {
+		return err
+	}
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "POST", "/webhooks", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	// Issue request. Treat non-201 status codes as errors.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusCreated {
+		return parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal returned webhook data.
+	if err := json.NewDecoder(resp.Body).Decode(&webhook); err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteWebhook permanently removes a webhook & its deliveries. Returns
+// EUNAUTHORIZED if the user is not the owner or an admin of the dial.
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id int) error {
+	// Create a request with API key.
+	req, err := s.Client.newRequest(ctx, "DELETE", fmt.Sprintf("/webhooks/%d", id), nil)
+	if err != nil {
+		return err
+	}
+
+	// Issue request. Any non-200 response is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// FindWebhookDeliveries retrieves a list of deliveries by filter, most recent
+// first. Only returns deliveries for webhooks the current user can see.
+func (s *WebhookService) FindWebhookDeliveries(ctx context.Context, filter wtf.WebhookDeliveryFilter) ([]*wtf.WebhookDelivery, int, error) {
+	// Marshal filter into JSON format.
+	body, err := json.Marshal(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Create request with API key.
+	req, err := s.Client.newRequest(ctx, "GET", "/webhook-deliveries", bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Issue request. Any non-200 status code is considered an error.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, 0, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal result set of deliveries & total delivery count.
+	var jsonResponse findWebhookDeliveriesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&jsonResponse); err != nil {
+		return nil, 0, err
+	}
+	return jsonResponse.WebhookDeliveries, jsonResponse.N, nil
+}
+
+// RedeliverWebhookDelivery queues a new delivery of the same event as an
+// existing delivery. Returns the new delivery.
+func (s *WebhookService) RedeliverWebhookDelivery(ctx context.Context, id int) (*wtf.WebhookDelivery, error) {
+	// Create a request with API key.
+	req, err := s.Client.newRequest(ctx, "POST", fmt.Sprintf("/webhook-deliveries/%d/redeliver", id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Issue request. Treat non-201 status codes as errors.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusCreated {
+		return nil, parseResponseError(resp)
+	}
+	defer resp.Body.Close()
+
+	// Unmarshal the new delivery.
+	var delivery wtf.WebhookDelivery
+	if err := json.NewDecoder(resp.Body).Decode(&delivery); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// FindPendingWebhookDeliveries is not implemented by the HTTP client.
+// Deliveries are sent by the background worker running on the server.
+func (s *WebhookService) FindPendingWebhookDeliveries(ctx context.Context, limit int) ([]*wtf.WebhookDelivery, error) {
+	return nil, wtf.Errorf(wtf.ENOTIMPLEMENTED, "Not implemented.")
+}
+
+// RecordWebhookDeliveryAttempt is not implemented by the HTTP client.
+// Deliveries are sent by the background worker running on the server.
+func (s *WebhookService) RecordWebhookDeliveryAttempt(ctx context.Context, id int, result wtf.WebhookDeliveryResult) (*wtf.WebhookDelivery, error) {
+	return nil, wtf.Errorf(wtf.ENOTIMPLEMENTED, "Not implemented.")
+}
@@ -0,0 +1,182 @@
+package http_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/benbjohnson/wtf"
+	wtfhttp "github.com/benbjohnson/wtf/http"
+	"github.com/benbjohnson/wtf/inmem"
+	"github.com/benbjohnson/wtf/mock"
+)
+
+// Ensure the dispatcher sends signed deliveries & records the results.
+func TestWebhookDispatcher_DeliverPending(t *testing.T) {
+	// Ensure a delivery is sent with a valid signature & marked as succeeded.
+	t.Run("OK", func(t *testing.T) {
+		var received int
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Fatal(err)
+			} else if got, want := r.Header.Get("X-WTF-Signature"), wtf.SignWebhookBody("SECRET", body); got != want {
+				t.Errorf("X-WTF-Signature=%q, want %q", got, want)
+			} else if got, want := r.Header.Get("X-WTF-Event"), wtf.EventTypeDialValueChanged; got != want {
+				t.Errorf("X-WTF-Event=%q, want %q", got, want)
+			} else if got, want := r.Header.Get("Content-type"), "application/json"; got != want {
+				t.Errorf("Content-type=%q, want %q", got, want)
+			}
+			received++
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer receiver.Close()
+
+		ctx, webhookService, dial := MustOpenWebhookDial(t, "http://receiver.test/hook")
+		dispatcher := wtfhttp.NewWebhookDispatcher(webhookService)
+		dispatcher.HTTPClient = ReceiverHTTPClient(dispatcher.HTTPClient, receiver)
+		if err := dispatcher.DeliverPending(ctx); err != nil {
+			t.Fatal(err)
+		} else if got, want := received, 1; got != want {
+			t.Fatalf("received=%v, want %v", got, want)
+		}
+
+		if deliveries, _, err := webhookService.FindWebhookDeliveries(ctx, wtf.WebhookDeliveryFilter{DialID: &dial.ID}); err != nil {
+			t.Fatal(err)
+		} else if got, want := deliveries[0].Status, wtf.WebhookDeliveryStatusSucceeded; got != want {
+			t.Fatalf("Status=%v, want %v", got, want)
+		} else if got, want := deliveries[0].StatusCode, http.StatusNoContent; got != want {
+			t.Fatalf("StatusCode=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure an error response schedules the delivery to be retried.
+	t.Run("Retry", func(t *testing.T) {
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer receiver.Close()
+
+		ctx, webhookService, dial := MustOpenWebhookDial(t, "http://receiver.test/hook")
+		dispatcher := wtfhttp.NewWebhookDispatcher(webhookService)
+		dispatcher.HTTPClient = ReceiverHTTPClient(dispatcher.HTTPClient, receiver)
+		if err := dispatcher.DeliverPending(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		if deliveries, _, err := webhookService.FindWebhookDeliveries(ctx, wtf.WebhookDeliveryFilter{DialID: &dial.ID}); err != nil {
+			t.Fatal(err)
+		} else if got, want := deliveries[0].Status, wtf.WebhookDeliveryStatusPending; got != want {
+			t.Fatalf("Status=%v, want %v", got, want)
+		} else if got, want := deliveries[0].Attempts, 1; got != want {
+			t.Fatalf("Attempts=%v, want %v", got, want)
+		} else if got, want := deliveries[0].StatusCode, http.StatusInternalServerError; got != want {
+			t.Fatalf("StatusCode=%v, want %v", got, want)
+		}
+
+		// The delivery should not be attempted again until after the delay.
+		if deliveries, err := webhookService.FindPendingWebhookDeliveries(ctx, 0); err != nil {
+			t.Fatal(err)
+		} else if len(deliveries) != 0 {
+			t.Fatalf("unexpected pending deliveries: %d", len(deliveries))
+		}
+	})
+
+	// Ensure redirects are not followed & the redirect is recorded as a failure.
+	t.Run("NoRedirect", func(t *testing.T) {
+		var received int
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received++
+			http.Redirect(w, r, "http://169.254.169.254/latest/meta-data", http.StatusFound)
+		}))
+		defer receiver.Close()
+
+		ctx, webhookService, dial := MustOpenWebhookDial(t, "http://receiver.test/hook")
+		dispatcher := wtfhttp.NewWebhookDispatcher(webhookService)
+		dispatcher.HTTPClient = ReceiverHTTPClient(dispatcher.HTTPClient, receiver)
+		if err := dispatcher.DeliverPending(ctx); err != nil {
+			t.Fatal(err)
+		} else if got, want := received, 1; got != want {
+			t.Fatalf("received=%v, want %v", got, want)
+		}
+
+		if deliveries, _, err := webhookService.FindWebhookDeliveries(ctx, wtf.WebhookDeliveryFilter{DialID: &dial.ID}); err != nil {
+			t.Fatal(err)
+		} else if got, want := deliveries[0].StatusCode, http.StatusFound; got != want {
+			t.Fatalf("StatusCode=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure deliveries are not sent to private addresses & the network error
+	// is not shown to the user.
+	t.Run("ErrPrivateAddress", func(t *testing.T) {
+		var received int
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received++
+		}))
+		defer receiver.Close()
+
+		// Bypass validation as host names may resolve to private addresses.
+		var result wtf.WebhookDeliveryResult
+		webhookService := &mock.WebhookService{
+			FindPendingWebhookDeliveriesFn: func(ctx context.Context, limit int) ([]*wtf.WebhookDelivery, error) {
+				return []*wtf.WebhookDelivery{{ID: 1, Webhook: &wtf.Webhook{URL: receiver.URL, Secret: "SECRET"}}}, nil
+			},
+			RecordWebhookDeliveryAttemptFn: func(ctx context.Context, id int, v wtf.WebhookDeliveryResult) (*wtf.WebhookDelivery, error) {
+				result = v
+				return &wtf.WebhookDelivery{ID: id}, nil
+			},
+		}
+
+		if err := wtfhttp.NewWebhookDispatcher(webhookService).DeliverPending(context.Background()); err != nil {
+			t.Fatal(err)
+		} else if got, want := received, 0; got != want {
+			t.Fatalf("received=%v, want %v", got, want)
+		} else if got, want := result.Error, "Webhook URL must not point to a local or private address."; got != want {
+			t.Fatalf("Error=%q, want %q", got, want)
+		}
+	})
+}
+
+// ReceiverHTTPClient returns a copy of client which connects to receiver for
+// every request. This allows tests to use a public host name in webhook URLs
+// while keeping the client's redirect policy.
+func ReceiverHTTPClient(client *http.Client, receiver *httptest.Server) *http.Client {
+	other := *client
+	other.Transport = &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, receiver.Listener.Addr().String())
+		},
+	}
+	return &other
+}
+
+// MustOpenWebhookDial returns an in-memory webhook service with a dial that
+// has a single webhook pointing to url & one pending delivery.
+func MustOpenWebhookDial(tb testing.TB, url string) (context.Context, wtf.WebhookService, *wtf.Dial) {
+	tb.Helper()
+
+	db := inmem.NewDB()
+	db.EventService = inmem.NewEventService()
+	webhookService := inmem.NewWebhookService(db)
+
+	user := &wtf.User{Name: "jane"}
+	if err := inmem.NewUserService(db).CreateUser(context.Background(), user); err != nil {
+		tb.Fatal(err)
+	}
+	ctx := wtf.NewContextWithUser(context.Background(), user)
+
+	dialService := inmem.NewDialService(db)
+	dial := &wtf.Dial{Name: "DIAL"}
+	if err := dialService.CreateDial(ctx, dial); err != nil {
+		tb.Fatal(err)
+	} else if err := webhookService.CreateWebhook(ctx, &wtf.Webhook{DialID: dial.ID, URL: url, Secret: "SECRET", EventTypes: []string{wtf.EventTypeDialValueChanged}}); err != nil {
+		tb.Fatal(err)
+	} else if err := dialService.SetDialMembershipValue(ctx, dial.ID, 50, ""); err != nil {
+		tb.Fatal(err)
+	}
+	return ctx, webhookService, dial
+}
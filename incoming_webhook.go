@@ -0,0 +1,149 @@
+package wtf
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Incoming webhook constants.
+const (
+	MaxIncomingWebhookNameLen = 100
+
+	// Number of characters of the token stored in plaintext so that users can
+	// identify a webhook after creation.
+	IncomingWebhookDisplayPrefixLen = 8
+)
+
+// IncomingWebhook represents a token which allows automated tools, such as
+// CI or paging systems, to set the value of a single dial membership without
+// the user's API key. Tokens are created & revoked by the membership's user.
+//
+// Only a hash of the token is stored. The plaintext token is only available
+// on the object returned from CreateIncomingWebhook().
+type IncomingWebhook struct {
+	ID int `json:"id"`
+
+	// Membership whose value is set by the webhook. The dial & user are
+	// copied from the membership when the webhook is created.
+	DialMembershipID int `json:"dialMembershipID"`
+	DialID           int `json:"dialID"`
+	UserID           int `json:"userID"`
+
+	// Label used to identify the webhook, such as the name of the tool.
+	Name string `json:"name"`
+
+	// Secret token used in the webhook URL. Only set when the webhook is created.
+	Token string `json:"token,omitempty"`
+
+	// First few characters of the token. Used to identify the webhook in lists.
+	Prefix string `json:"prefix"`
+
+	// Time the webhook was last used to set a value. Nil if never used.
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+
+	// Timestamp for webhook creation.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Validate returns an error if the webhook contains invalid fields.
+// This only performs basic validation.
+func (w *IncomingWebhook) Validate() error {
+	if w.DialID == 0 {
+		return Errorf(EINVALID, "Dial required for incoming webhook.")
+	} else if w.Name == "" {
+		return Errorf(EINVALID, "Incoming webhook name required.")
+	} else if len(w.Name) > MaxIncomingWebhookNameLen {
+		return Errorf(EINVALID, "Incoming webhook name too long.")
+	}
+	return nil
+}
+
+// HashIncomingWebhookToken returns the hex-encoded SHA-256 hash of a
+// plaintext webhook token. Tokens are stored & looked up by this hash.
+func HashIncomingWebhookToken(token string) string {
+	return HashAPIToken(token)
+}
+
+// IncomingWebhookService represents a service for managing incoming webhooks.
+type IncomingWebhookService interface {
+	// Retrieves a list of the current user's incoming webhooks by filter.
+	// Plaintext tokens are never returned. Also returns the total count of matching webhooks which may differ
+	// if filter.Limit is specified.
+	FindIncomingWebhooks(ctx context.Context, filter IncomingWebhookFilter) ([]*IncomingWebhook, int, error)
+
+	// Creates a new incoming webhook for the current user's membership on the
+	// dial specified by webhook.DialID. Returns ENOTFOUND if the user is not
+	// a member of the dial. Returns EUNAUTHORIZED if the user is a viewer.
+	// The plaintext token is set on webhook.Token & cannot be retrieved again.
+	CreateIncomingWebhook(ctx context.Context, webhook *IncomingWebhook) error
+
+	// Permanently revokes an incoming webhook. Returns ENOTFOUND if the
+	// webhook does not exist or does not belong to the current user.
+	DeleteIncomingWebhook(ctx context.Context, id int) error
+
+	// Sets the value of the webhook's membership on behalf of its user. This
+	// does not require a user on the context as the token is the credential.
+	// The returned membership does not include its dial or user.
+	// Returns ENOTFOUND if the token does not exist.
+	InvokeIncomingWebhook(ctx context.Context, token string, upd IncomingWebhookUpdate) (*DialMembership, error)
+}
+
+// IncomingWebhookFilter represents a filter used by FindIncomingWebhooks().
+type IncomingWebhookFilter struct {
+	ID     *int `json:"id"`
+	DialID *int `json:"dialID"`
+
+	// Restricts results to a subset of the total range.
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+}
+
+// IncomingWebhookUpdate represents a change to a membership value made via an
+// incoming webhook. Either the absolute value or a relative delta is set.
+type IncomingWebhookUpdate struct {
+	Value *int    `json:"value"`
+	Delta *int    `json:"delta"`
+	Note  *string `json:"note"`
+}
+
+// Validate returns an error if the update does not set exactly one of the
+// value or delta.
+func (upd *IncomingWebhookUpdate) Validate() error {
+	if (upd.Value == nil) == (upd.Delta == nil) {
+		return Errorf(EINVALID, "Either a value or a delta is required.")
+	}
+	return nil
+}
+
+// Apply returns the new membership value after applying the update to the
+// current value. Relative changes are clamped to the range of valid values.
+func (upd *IncomingWebhookUpdate) Apply(value int) int {
+	if upd.Value != nil {
+		return *upd.Value
+	}
+
+	value += *upd.Delta
+	if value < 0 {
+		return 0
+	} else if value > 100 {
+		return 100
+	}
+	return value
+}
+
+// ParseIncomingWebhookValue parses a value passed to an incoming webhook as a
+// string. Values prefixed with a sign (e.g. "+10" or "-10") are relative to
+// the current value. Otherwise the value is absolute.
+func ParseIncomingWebhookValue(s string) (IncomingWebhookUpdate, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return IncomingWebhookUpdate{}, Errorf(EINVALID, "Invalid incoming webhook value.")
+	}
+
+	if strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-") {
+		return IncomingWebhookUpdate{Delta: &v}, nil
+	}
+	return IncomingWebhookUpdate{Value: &v}, nil
+}
@@ -0,0 +1,373 @@
+package inmem
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/benbjohnson/wtf"
+)
+
+// Ensure service implements interface.
+var _ wtf.AlertService = (*AlertService)(nil)
+
+// AlertService represents a service for managing dial alert rules in memory.
+type AlertService struct {
+	db *DB
+}
+
+// NewAlertService returns a new instance of AlertService.
+func NewAlertService(db *DB) *AlertService {
+	return &AlertService{db: db}
+}
+
+// FindAlertRuleByID retrieves a single alert rule by ID along with the
+// associated dial. Returns ENOTFOUND if rule does not exist or user is not a
+// member of the rule's dial.
+func (s *AlertService) FindAlertRuleByID(ctx context.Context, id int) (*wtf.AlertRule, error) {
+	tx, err := s.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rule, err := findAlertRuleByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	} else if err := attachAlertRuleAssociations(ctx, tx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// FindAlertRules retrieves a list of alert rules by filter. Only returns rules
+// on dials that the current user is a member of.
+//
+// Also returns a count of total matching rules which may differ if "Limit"
+// is specified on the filter.
+func (s *AlertService) FindAlertRules(ctx context.Context, filter wtf.AlertRuleFilter) ([]*wtf.AlertRule, int, error) {
+	tx, err := s.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	rules, n, err := findAlertRules(ctx, tx, filter)
+	if err != nil {
+		return rules, n, err
+	}
+
+	// Attach dial to each returned rule.
+	for _, rule := range rules {
+		if err := attachAlertRuleAssociations(ctx, tx, rule); err != nil {
+			return rules, n, err
+		}
+	}
+	return rules, n, nil
+}
+
+// CreateAlertRule creates a new alert rule on a dial. Returns EUNAUTHORIZED if
+// the user is not the owner or an admin of the dial.
+func (s *AlertService) CreateAlertRule(ctx context.Context, rule *wtf.AlertRule) error {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := createAlertRule(ctx, tx, rule); err != nil {
+		return err
+	} else if err := attachAlertRuleAssociations(ctx, tx, rule); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteAlertRule permanently removes an alert rule & its alert history.
+// Returns EUNAUTHORIZED if the user is not the owner or an admin of the dial.
+func (s *AlertService) DeleteAlertRule(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteAlertRule(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// FindAlerts retrieves a list of alerts by filter, most recent first. Only
+// returns alerts on dials that the current user is a member of.
+func (s *AlertService) FindAlerts(ctx context.Context, filter wtf.AlertFilter) ([]*wtf.Alert, int, error) {
+	tx, err := s.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+	return findAlerts(ctx, tx, filter)
+}
+
+// EvaluateAlertRules evaluates every alert rule against the current time.
+// This is called periodically by a background worker so that duration-based
+// rules can fire while the dial value stays the same.
+func (s *AlertService) EvaluateAlertRules(ctx context.Context) error {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Find every dial which has at least one rule.
+	m := make(map[int]struct{})
+	for _, rule := range tx.alertRules {
+		m[rule.DialID] = struct{}{}
+	}
+	dialIDs := make([]int, 0, len(m))
+	for dialID := range m {
+		dialIDs = append(dialIDs, dialID)
+	}
+	sort.Ints(dialIDs)
+
+	for _, dialID := range dialIDs {
+		evaluateDialAlertRules(ctx, tx, dialID)
+	}
+	return tx.Commit()
+}
+
+// findAlertRuleByID returns an alert rule by ID. Returns ENOTFOUND if the rule
+// does not exist or the user is not a member of the rule's dial.
+func findAlertRuleByID(ctx context.Context, tx *Tx, id int) (*wtf.AlertRule, error) {
+	rules, _, err := findAlertRules(ctx, tx, wtf.AlertRuleFilter{ID: &id})
+	if err != nil {
+		return nil, err
+	} else if len(rules) == 0 {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "Alert rule not found."}
+	}
+	return rules[0], nil
+}
+
+// findAlertRules returns a list of alert rules on dials that the current user
+// is a member of. Also returns a count of total matching rules.
+func findAlertRules(ctx context.Context, tx *Tx, filter wtf.AlertRuleFilter) (_ []*wtf.AlertRule, n int, err error) {
+	userID := wtf.UserIDFromContext(ctx)
+
+	rules := make([]*wtf.AlertRule, 0)
+	for _, rule := range tx.alertRules {
+		if v := filter.ID; v != nil && rule.ID != *v {
+			continue
+		} else if v := filter.DialID; v != nil && rule.DialID != *v {
+			continue
+		} else if !isDialMember(tx, rule.DialID, userID) {
+			continue
+		}
+
+		// Return a copy without associations so the stored record is unchanged.
+		other := *rule
+		other.Dial = nil
+		rules = append(rules, &other)
+	}
+
+	// Sort by ID & restrict to the requested range.
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	n = len(rules)
+	start, end := applyLimitOffset(n, filter.Limit, filter.Offset)
+	return rules[start:end], n, nil
+}
+
+// findAlertRulesByDialID returns all alert rules on a dial, sorted by ID.
+// This does not check permissions as it is used internally for rule evaluation.
+func findAlertRulesByDialID(tx *Tx, dialID int) []*wtf.AlertRule {
+	rules := make([]*wtf.AlertRule, 0)
+	for _, rule := range tx.alertRules {
+		if rule.DialID == dialID {
+			rules = append(rules, rule)
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules
+}
+
+// createAlertRule creates a new alert rule on a dial. Returns EUNAUTHORIZED
+// if the current user cannot edit the dial.
+func createAlertRule(ctx context.Context, tx *Tx, rule *wtf.AlertRule) error {
+	// New rules start in the "ok" state & fire on the next evaluation.
+	rule.State = wtf.AlertRuleStateOK
+
+	// Set timestamps to current time.
+	rule.CreatedAt = tx.now
+	rule.UpdatedAt = rule.CreatedAt
+
+	// Perform basic field validation.
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+
+	// Verify the user is a member of the dial & can manage it.
+	if dial, err := findDialByID(ctx, tx, rule.DialID); err != nil {
+		return err
+	} else if !wtf.CanEditDial(ctx, dial) {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You must be the owner or an admin to create alert rules.")
+	}
+
+	// Assign the next ID & store a copy without associations.
+	tx.seq.alertRule++
+	rule.ID = tx.seq.alertRule
+
+	other := *rule
+	other.Dial = nil
+	tx.alertRules[rule.ID] = &other
+
+	return nil
+}
+
+// deleteAlertRule permanently removes an alert rule & its alert history.
+func deleteAlertRule(ctx context.Context, tx *Tx, id int) error {
+	// Verify object exists & the current user can manage the dial.
+	rule, err := findAlertRuleByID(ctx, tx, id)
+	if err != nil {
+		return err
+	} else if err := attachAlertRuleAssociations(ctx, tx, rule); err != nil {
+		return err
+	} else if !wtf.CanEditDial(ctx, rule.Dial) {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You must be the owner or an admin to delete alert rules.")
+	}
+
+	removeAlertRule(tx, id)
+	return nil
+}
+
+// removeAlertRule removes an alert rule along with its alert history.
+func removeAlertRule(tx *Tx, id int) {
+	for _, alert := range tx.alerts {
+		if alert.AlertRuleID == id {
+			delete(tx.alerts, alert.ID)
+		}
+	}
+	delete(tx.alertRules, id)
+}
+
+// findAlerts returns a list of alerts on dials the current user is a member
+// of, most recent first. Also returns a count of total matching alerts.
+func findAlerts(ctx context.Context, tx *Tx, filter wtf.AlertFilter) (_ []*wtf.Alert, n int, err error) {
+	userID := wtf.UserIDFromContext(ctx)
+
+	alerts := make([]*wtf.Alert, 0)
+	for _, alert := range tx.alerts {
+		if v := filter.AlertRuleID; v != nil && alert.AlertRuleID != *v {
+			continue
+		} else if v := filter.DialID; v != nil && alert.DialID != *v {
+			continue
+		} else if !isDialMember(tx, alert.DialID, userID) {
+			continue
+		}
+
+		other := *alert
+		alerts = append(alerts, &other)
+	}
+
+	// Sort by most recent first & restrict to the requested range.
+	sort.Slice(alerts, func(i, j int) bool {
+		if !alerts[i].FiredAt.Equal(alerts[j].FiredAt) {
+			return alerts[i].FiredAt.After(alerts[j].FiredAt)
+		}
+		return alerts[i].ID > alerts[j].ID
+	})
+	n = len(alerts)
+	start, end := applyLimitOffset(n, filter.Limit, filter.Offset)
+	return alerts[start:end], n, nil
+}
+
+// evaluateDialAlertRules evaluates each alert rule on a dial against the dial
+// value history. Rules which start to match are fired & rules which stop
+// matching are resolved. Dial members are notified of each change in state.
+func evaluateDialAlertRules(ctx context.Context, tx *Tx, dialID int) {
+	for _, rule := range findAlertRulesByDialID(tx, dialID) {
+		records := findDialValueRecordsSince(tx, dialID, tx.now.Add(-rule.Duration))
+
+		// Skip if the rule state does not change.
+		firing := rule.Evaluate(records, tx.now)
+		if firing == (rule.State == wtf.AlertRuleStateFiring) {
+			continue
+		}
+
+		// Use the current dial value for the alert.
+		var value int
+		if len(records) > 0 {
+			value = records[len(records)-1].Value
+		}
+
+		if firing {
+			fireAlertRule(ctx, tx, rule, value)
+		} else {
+			resolveAlertRule(ctx, tx, rule, value)
+		}
+	}
+}
+
+// fireAlertRule records a new alert for the rule & notifies dial members.
+func fireAlertRule(ctx context.Context, tx *Tx, rule *wtf.AlertRule, value int) {
+	setAlertRuleState(tx, rule, wtf.AlertRuleStateFiring)
+
+	tx.seq.alert++
+	alert := &wtf.Alert{
+		ID:          tx.seq.alert,
+		AlertRuleID: rule.ID,
+		DialID:      rule.DialID,
+		Value:       value,
+		FiredAt:     tx.now,
+	}
+	tx.alerts[alert.ID] = alert
+
+	publishDialEvent(ctx, tx, rule.DialID, wtf.Event{
+		Type: wtf.EventTypeAlertFired,
+		Payload: &wtf.AlertPayload{
+			ID:          alert.ID,
+			AlertRuleID: rule.ID,
+			DialID:      rule.DialID,
+			Value:       value,
+		},
+	})
+}
+
+// resolveAlertRule marks the rule's open alerts as resolved & notifies dial members.
+func resolveAlertRule(ctx context.Context, tx *Tx, rule *wtf.AlertRule, value int) {
+	setAlertRuleState(tx, rule, wtf.AlertRuleStateOK)
+
+	for _, alert := range tx.alerts {
+		if alert.AlertRuleID != rule.ID || alert.ResolvedAt != nil {
+			continue
+		}
+
+		resolvedAt := tx.now
+		other := *alert
+		other.ResolvedAt = &resolvedAt
+		tx.alerts[alert.ID] = &other
+
+		publishDialEvent(ctx, tx, rule.DialID, wtf.Event{
+			Type: wtf.EventTypeAlertResolved,
+			Payload: &wtf.AlertPayload{
+				ID:          alert.ID,
+				AlertRuleID: rule.ID,
+				DialID:      rule.DialID,
+				Value:       value,
+			},
+		})
+	}
+}
+
+// setAlertRuleState updates the state of the stored rule.
+func setAlertRuleState(tx *Tx, rule *wtf.AlertRule, state string) {
+	other := *rule
+	other.State, other.UpdatedAt = state, tx.now
+	tx.alertRules[rule.ID] = &other
+}
+
+// attachAlertRuleAssociations attaches the dial to the alert rule.
+func attachAlertRuleAssociations(ctx context.Context, tx *Tx, rule *wtf.AlertRule) (err error) {
+	if rule.Dial, err = findDialByID(ctx, tx, rule.DialID); err != nil {
+		return fmt.Errorf("attach alert rule dial: %w", err)
+	}
+	return nil
+}
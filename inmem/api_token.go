@@ -0,0 +1,199 @@
+package inmem
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/benbjohnson/wtf"
+)
+
+// Ensure service implements interface.
+var _ wtf.APITokenService = (*APITokenService)(nil)
+
+// APITokenService represents a service for managing API tokens in memory.
+type APITokenService struct {
+	db *DB
+}
+
+// NewAPITokenService returns a new instance of APITokenService.
+func NewAPITokenService(db *DB) *APITokenService {
+	return &APITokenService{db: db}
+}
+
+// apiToken represents a stored token along with the hash of its plaintext
+// value. The plaintext token is never stored.
+type apiToken struct {
+	wtf.APIToken
+	hash string
+}
+
+// FindAPITokens retrieves a list of the current user's tokens by filter.
+// Also returns a count of total matching tokens which may differ if "Limit"
+// is specified on the filter.
+func (s *APITokenService) FindAPITokens(ctx context.Context, filter wtf.APITokenFilter) ([]*wtf.APIToken, int, error) {
+	tx, err := s.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+	return findAPITokens(ctx, tx, filter)
+}
+
+// CreateAPIToken creates a new token for the current user. The plaintext
+// token is set on token.Token & only its hash is stored.
+func (s *APITokenService) CreateAPIToken(ctx context.Context, token *wtf.APIToken) error {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := createAPIToken(ctx, tx, token); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteAPIToken permanently revokes a token. Returns ENOTFOUND if the token
+// does not exist or belongs to another user.
+func (s *APITokenService) DeleteAPIToken(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Verify the token exists & belongs to the current user.
+	if tokens, _, err := findAPITokens(ctx, tx, wtf.APITokenFilter{ID: &id}); err != nil {
+		return err
+	} else if len(tokens) == 0 {
+		return &wtf.Error{Code: wtf.ENOTFOUND, Message: "API token not found."}
+	}
+
+	delete(tx.apiTokens, id)
+	return tx.Commit()
+}
+
+// AuthenticateAPIToken looks up a token by its plaintext value, records its
+// use & attaches its user. Returns ENOTFOUND if the token does not exist or
+// EUNAUTHORIZED if the token has expired.
+func (s *APITokenService) AuthenticateAPIToken(ctx context.Context, token string) (*wtf.APIToken, error) {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	apiToken, err := authenticateAPIToken(ctx, tx, token)
+	if err != nil {
+		return nil, err
+	}
+	return apiToken, tx.Commit()
+}
+
+// findAPITokens returns a list of the current user's tokens. Also returns a
+// count of total matching tokens.
+func findAPITokens(ctx context.Context, tx *Tx, filter wtf.APITokenFilter) (_ []*wtf.APIToken, n int, err error) {
+	userID := wtf.UserIDFromContext(ctx)
+
+	tokens := make([]*wtf.APIToken, 0)
+	for _, token := range tx.apiTokens {
+		if token.UserID != userID {
+			continue
+		} else if v := filter.ID; v != nil && token.ID != *v {
+			continue
+		}
+
+		other := token.APIToken
+		tokens = append(tokens, &other)
+	}
+
+	// Sort by ID & restrict to the requested range.
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].ID < tokens[j].ID })
+	n = len(tokens)
+	start, end := applyLimitOffset(n, filter.Limit, filter.Offset)
+	return tokens[start:end], n, nil
+}
+
+// createAPIToken generates a new token for the current user & stores its hash.
+func createAPIToken(ctx context.Context, tx *Tx, token *wtf.APIToken) error {
+	userID := wtf.UserIDFromContext(ctx)
+	if userID == 0 {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You must be logged in to create an API token.")
+	}
+
+	// Perform basic field validation.
+	if err := token.Validate(); err != nil {
+		return err
+	} else if token.IsExpired(tx.now) {
+		return wtf.Errorf(wtf.EINVALID, "API token expiration must be in the future.")
+	}
+
+	// Generate a random token. Only the prefix is kept in plaintext.
+	code, err := generateInviteCode()
+	if err != nil {
+		return err
+	}
+	token.Token = wtf.APITokenPrefix + code
+	token.Prefix = token.Token[:wtf.APITokenDisplayPrefixLen]
+	token.UserID = userID
+	token.User = nil
+	token.LastUsedAt = nil
+	token.CreatedAt = tx.now
+
+	// Assign the next ID & store a copy without the plaintext token.
+	tx.seq.apiToken++
+	token.ID = tx.seq.apiToken
+
+	other := &apiToken{APIToken: *token, hash: wtf.HashAPIToken(token.Token)}
+	other.Token = ""
+	tx.apiTokens[token.ID] = other
+
+	return nil
+}
+
+// removeAPITokens removes all tokens belonging to a user.
+func removeAPITokens(tx *Tx, userID int) {
+	for _, token := range tx.apiTokens {
+		if token.UserID == userID {
+			delete(tx.apiTokens, token.ID)
+		}
+	}
+}
+
+// authenticateAPIToken looks up a token by the hash of its plaintext value
+// and records the time it was used.
+func authenticateAPIToken(ctx context.Context, tx *Tx, token string) (*wtf.APIToken, error) {
+	hash := wtf.HashAPIToken(token)
+
+	// Look up token by hash.
+	var stored *apiToken
+	for _, v := range tx.apiTokens {
+		if v.hash == hash {
+			stored = v
+			break
+		}
+	}
+	if stored == nil {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "API token not found."}
+	} else if stored.IsExpired(tx.now) {
+		return nil, wtf.Errorf(wtf.EUNAUTHORIZED, "API token expired.")
+	}
+
+	// Record when the token was last used.
+	lastUsedAt := tx.now
+	other := *stored
+	other.LastUsedAt = &lastUsedAt
+	tx.apiTokens[other.ID] = &other
+
+	// Return a copy with the token's user attached.
+	apiToken := other.APIToken
+	user, err := findUserByID(ctx, tx, apiToken.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("find api token user: %w", err)
+	}
+	apiToken.User = user
+
+	return &apiToken, nil
+}
@@ -0,0 +1,293 @@
+package inmem
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+)
+
+// Ensure service implements interface.
+var _ wtf.AuthService = (*AuthService)(nil)
+
+// AuthService represents a service for managing OAuth authentication in memory.
+type AuthService struct {
+	db *DB
+}
+
+// NewAuthService returns a new instance of AuthService attached to DB.
+func NewAuthService(db *DB) *AuthService {
+	return &AuthService{db: db}
+}
+
+// FindAuthByID retrieves an authentication object by ID along with the associated user.
+// Returns ENOTFOUND if ID does not exist.
+func (s *AuthService) FindAuthByID(ctx context.Context, id int) (*wtf.Auth, error) {
+	tx, err := s.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Look up auth by ID and read associated user object.
+	auth, err := findAuthByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	} else if err := attachAuthAssociations(ctx, tx, auth); err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+// FindAuths retrieves authentication objects based on a filter.
+//
+// Also returns the total number of objects that match the filter. This may
+// differ from the returned object count if the Limit field is set.
+func (s *AuthService) FindAuths(ctx context.Context, filter wtf.AuthFilter) ([]*wtf.Auth, int, error) {
+	tx, err := s.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	// Fetch the individual authentication objects.
+	auths, n, err := findAuths(ctx, tx, filter)
+	if err != nil {
+		return auths, n, err
+	}
+
+	// Iterate over returned objects and attach user objects.
+	for _, auth := range auths {
+		if err := attachAuthAssociations(ctx, tx, auth); err != nil {
+			return auths, n, err
+		}
+	}
+	return auths, n, nil
+}
+
+// CreateAuth Creates a new authentication object If a User is attached to auth,
+// then the auth object is linked to an existing user. Otherwise a new user
+// object is created.
+//
+// On success, the auth.ID is set to the new authentication ID.
+func (s *AuthService) CreateAuth(ctx context.Context, auth *wtf.Auth) error {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Auths can only be linked to an existing user by that user.
+	if auth.UserID != 0 && auth.UserID != wtf.UserIDFromContext(ctx) {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You are not allowed to link an auth to this user.")
+	}
+
+	// Check to see if the auth already exists for the given source.
+	if other, err := findAuthBySourceID(ctx, tx, auth.Source, auth.SourceID); err == nil {
+		// An account that is already linked cannot be moved to another user.
+		if auth.UserID != 0 && other.UserID != auth.UserID {
+			return wtf.Errorf(wtf.ECONFLICT, "This account is already linked to another user.")
+		}
+
+		// If an auth already exists for the source user, update with the new tokens.
+		if other, err = updateAuth(ctx, tx, other.ID, auth.AccessToken, auth.RefreshToken, auth.Expiry); err != nil {
+			return fmt.Errorf("cannot update auth: id=%d err=%w", other.ID, err)
+		} else if err := attachAuthAssociations(ctx, tx, other); err != nil {
+			return err
+		}
+
+		// Copy found auth back to the caller's arg & return.
+		*auth = *other
+		return tx.Commit()
+	} else if wtf.ErrorCode(err) != wtf.ENOTFOUND {
+		return fmt.Errorf("canot find auth by source user: %w", err)
+	}
+
+	// Check if auth has a new user object passed in. It is considered "new" if
+	// the caller doesn't know the ID for the user.
+	if auth.UserID == 0 && auth.User != nil {
+		// Look up the user by email address. If no user can be found then
+		// create a new user with the auth.User object passed in.
+		if user, err := findUserByEmail(ctx, tx, auth.User.Email); err == nil { // user exists
+			auth.User = user
+		} else if wtf.ErrorCode(err) == wtf.ENOTFOUND { // user does not exist
+			if err := createUser(ctx, tx, auth.User); err != nil {
+				return fmt.Errorf("cannot create user: %w", err)
+			}
+		} else {
+			return fmt.Errorf("cannot find user by email: %w", err)
+		}
+
+		// Assign the created/found user ID back to the auth object.
+		auth.UserID = auth.User.ID
+	} else if auth.UserID != 0 {
+		// When linking to an existing user, only one auth per source is allowed.
+		if _, n, err := findAuths(ctx, tx, wtf.AuthFilter{UserID: &auth.UserID, Source: &auth.Source}); err != nil {
+			return err
+		} else if n != 0 {
+			return wtf.Errorf(wtf.ECONFLICT, "An account from this provider is already linked.")
+		}
+	}
+
+	// Create new auth object & attach associated user.
+	if err := createAuth(ctx, tx, auth); err != nil {
+		return err
+	} else if err := attachAuthAssociations(ctx, tx, auth); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteAuth permanently deletes an authentication object from the system by ID.
+// The parent user object is not removed.
+func (s *AuthService) DeleteAuth(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteAuth(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// findAuthByID is a helper function to return an auth object by ID.
+// Returns ENOTFOUND if auth doesn't exist.
+func findAuthByID(ctx context.Context, tx *Tx, id int) (*wtf.Auth, error) {
+	auths, _, err := findAuths(ctx, tx, wtf.AuthFilter{ID: &id})
+	if err != nil {
+		return nil, err
+	} else if len(auths) == 0 {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "Auth not found."}
+	}
+	return auths[0], nil
+}
+
+// findAuthBySourceID is a helper function to return an auth object by source ID.
+// Returns ENOTFOUND if auth doesn't exist.
+func findAuthBySourceID(ctx context.Context, tx *Tx, source, sourceID string) (*wtf.Auth, error) {
+	auths, _, err := findAuths(ctx, tx, wtf.AuthFilter{Source: &source, SourceID: &sourceID})
+	if err != nil {
+		return nil, err
+	} else if len(auths) == 0 {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "Auth not found."}
+	}
+	return auths[0], nil
+}
+
+// findAuths returns a list of auth objects that match a filter. Also returns
+// a total count of matches which may differ from results if filter.Limit is set.
+func findAuths(ctx context.Context, tx *Tx, filter wtf.AuthFilter) (_ []*wtf.Auth, n int, err error) {
+	auths := make([]*wtf.Auth, 0)
+	for _, auth := range tx.auths {
+		if v := filter.ID; v != nil && auth.ID != *v {
+			continue
+		} else if v := filter.UserID; v != nil && auth.UserID != *v {
+			continue
+		} else if v := filter.Source; v != nil && auth.Source != *v {
+			continue
+		} else if v := filter.SourceID; v != nil && auth.SourceID != *v {
+			continue
+		}
+
+		// Return a copy without associations so the stored record is unchanged.
+		other := *auth
+		other.User = nil
+		auths = append(auths, &other)
+	}
+
+	// Sort by ID & restrict to the requested range.
+	sort.Slice(auths, func(i, j int) bool { return auths[i].ID < auths[j].ID })
+	n = len(auths)
+	start, end := applyLimitOffset(n, filter.Limit, filter.Offset)
+	return auths[start:end], n, nil
+}
+
+// createAuth creates a new auth object. On success, the ID is set to the
+// new ID & timestamp fields are set to the current time.
+func createAuth(ctx context.Context, tx *Tx, auth *wtf.Auth) error {
+	// Set timestamp fields to current time.
+	auth.CreatedAt = tx.now
+	auth.UpdatedAt = auth.CreatedAt
+
+	// Ensure auth object passes basic validation.
+	if err := auth.Validate(); err != nil {
+		return err
+	}
+
+	// Ensure the user exists & that only one auth exists per source per user.
+	if _, ok := tx.users[auth.UserID]; !ok {
+		return &wtf.Error{Code: wtf.ENOTFOUND, Message: "User not found."}
+	}
+	for _, other := range tx.auths {
+		if other.Source == auth.Source && (other.UserID == auth.UserID || other.SourceID == auth.SourceID) {
+			return wtf.Errorf(wtf.ECONFLICT, "Auth already exists for source.")
+		}
+	}
+
+	// Assign the next ID and store a copy without associations.
+	tx.seq.auth++
+	auth.ID = tx.seq.auth
+
+	other := *auth
+	other.User = nil
+	tx.auths[auth.ID] = &other
+
+	return nil
+}
+
+// updateAuth updates tokens & expiry on exist auth object.
+// Returns new state of the auth object.
+func updateAuth(ctx context.Context, tx *Tx, id int, accessToken, refreshToken string, expiry *time.Time) (*wtf.Auth, error) {
+	// Fetch current object state.
+	auth, err := findAuthByID(ctx, tx, id)
+	if err != nil {
+		return auth, err
+	}
+
+	// Update fields & last updated date.
+	auth.AccessToken = accessToken
+	auth.RefreshToken = refreshToken
+	auth.Expiry = expiry
+	auth.UpdatedAt = tx.now
+
+	// Perform basic field validation.
+	if err := auth.Validate(); err != nil {
+		return auth, err
+	}
+
+	// Replace stored record with a copy of the new state.
+	other := *auth
+	tx.auths[id] = &other
+
+	return auth, nil
+}
+
+// deleteAuth permanently removes an auth object by ID.
+func deleteAuth(ctx context.Context, tx *Tx, id int) error {
+	// Verify object exists & that the user is the owner of the auth.
+	if auth, err := findAuthByID(ctx, tx, id); err != nil {
+		return err
+	} else if auth.UserID != wtf.UserIDFromContext(ctx) {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You are not allowed to delete this auth.")
+	}
+
+	// Sessions created by logging in with the auth are no longer valid.
+	removeSessions(tx, func(session *wtf.Session) bool { return session.AuthID == id })
+
+	delete(tx.auths, id)
+	return nil
+}
+
+// attachAuthAssociations is a helper function to fetch & attach the associated user
+// to the auth object.
+func attachAuthAssociations(ctx context.Context, tx *Tx, auth *wtf.Auth) (err error) {
+	if auth.User, err = findUserByID(ctx, tx, auth.UserID); err != nil {
+		return fmt.Errorf("attach auth user: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,1004 @@
+package inmem
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+)
+
+// Ensure service implements interface.
+var _ wtf.DialService = (*DialService)(nil)
+
+// DialService represents a service for managing dials in memory.
+type DialService struct {
+	db *DB
+}
+
+// NewDialService returns a new instance of DialService.
+func NewDialService(db *DB) *DialService {
+	return &DialService{db: db}
+}
+
+// FindDialByID retrieves a single dial by ID along with the owner user.
+// Only the dial owner & members can see a dial. Returns ENOTFOUND if dial does
+// not exist or user does not have permission to view it.
+func (s *DialService) FindDialByID(ctx context.Context, id int) (*wtf.Dial, error) {
+	tx, err := s.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Fetch dial object and attach owner user.
+	dial, err := findDialByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	} else if err := attachDialAssociations(ctx, tx, dial); err != nil {
+		return nil, err
+	}
+	return dial, nil
+}
+
+// FindDials retrieves a list of dials based on a filter. Only returns dials
+// that the user owns or is a member of.
+//
+// Also returns a count of total matching dials which may different from the
+// number of returned dials if the  "Limit" field is set.
+func (s *DialService) FindDials(ctx context.Context, filter wtf.DialFilter) ([]*wtf.Dial, int, error) {
+	tx, err := s.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	// Fetch list of matching dial objects.
+	dials, n, err := findDials(ctx, tx, filter)
+	if err != nil {
+		return dials, n, err
+	}
+
+	// Iterate over dials and attach associated owner user.
+	for _, dial := range dials {
+		if err := attachDialAssociations(ctx, tx, dial); err != nil {
+			return dials, n, err
+		}
+	}
+	return dials, n, nil
+}
+
+// CreateDial creates a new dial and assigns the current user as the owner.
+// The owner will automatically be added as a member of the new dial.
+func (s *DialService) CreateDial(ctx context.Context, dial *wtf.Dial) error {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Create dial and attach associated owner user.
+	if err := createDial(ctx, tx, dial); err != nil {
+		return err
+	} else if err := attachDialAssociations(ctx, tx, dial); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateDial updates an existing dial by ID. Only the dial owner can update a dial.
+// Returns the new dial state even if there was an error during update.
+//
+// Returns ENOTFOUND if dial does not exist. Returns EUNAUTHORIZED if user
+// is not the dial owner.
+func (s *DialService) UpdateDial(ctx context.Context, id int, upd wtf.DialUpdate) (*wtf.Dial, error) {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Update the dial object and attach associated user to returned dial.
+	dial, err := updateDial(ctx, tx, id, upd)
+	if err != nil {
+		return dial, err
+	} else if err := attachDialAssociations(ctx, tx, dial); err != nil {
+		return dial, err
+	}
+	return dial, tx.Commit()
+}
+
+// DeleteDial permanently removes a dial by ID. Only the dial owner may delete
+// a dial. Returns ENOTFOUND if dial does not exist. Returns EUNAUTHORIZED if
+// user is not the dial owner.
+func (s *DialService) DeleteDial(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteDial(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// TransferDial transfers ownership of a dial to another member of the dial.
+// Only the dial owner may transfer a dial & the previous owner remains as an
+// admin. Returns ENOTFOUND if dial does not exist. Returns EUNAUTHORIZED if
+// user is not the dial owner. Returns EINVALID if the new owner is not a member.
+func (s *DialService) TransferDial(ctx context.Context, id, userID int) (*wtf.Dial, error) {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Transfer the dial and attach the new owner user to the returned dial.
+	dial, err := transferDial(ctx, tx, id, userID)
+	if err != nil {
+		return dial, err
+	} else if err := attachDialAssociations(ctx, tx, dial); err != nil {
+		return dial, err
+	}
+	return dial, tx.Commit()
+}
+
+// RotateDialInvite generates a new invite code for the dial with optional
+// limits. The previous code stops working immediately. Only the dial owner &
+// admins can rotate the invite code. Returns ENOTFOUND if dial does not exist.
+// Returns EUNAUTHORIZED if user is not the dial owner or an admin.
+func (s *DialService) RotateDialInvite(ctx context.Context, id int, opt wtf.DialInviteOptions) (*wtf.Dial, error) {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Replace the invite code and attach associated user to returned dial.
+	dial, err := rotateDialInvite(ctx, tx, id, opt)
+	if err != nil {
+		return dial, err
+	} else if err := attachDialAssociations(ctx, tx, dial); err != nil {
+		return dial, err
+	}
+	return dial, tx.Commit()
+}
+
+// Sets the value of the user's membership in a dial along with an optional
+// note. This works the same as calling UpdateDialMembership() although it
+// doesn't require that the user know their membership ID. Only the dial ID.
+//
+// Returns ENOTFOUND if the membership does not exist.
+func (s *DialService) SetDialMembershipValue(ctx context.Context, dialID, value int, note string) error {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Fetch current user.
+	userID := wtf.UserIDFromContext(ctx)
+
+	// Find user's membership.
+	memberships, _, err := findDialMemberships(ctx, tx, wtf.DialMembershipFilter{
+		DialID: &dialID,
+		UserID: &userID,
+	})
+	if err != nil {
+		return err
+	} else if len(memberships) == 0 {
+		return wtf.Errorf(wtf.ENOTFOUND, "User is not a member of this dial.")
+	}
+
+	// Update value on membership.
+	if _, err := updateDialMembership(ctx, tx, memberships[0].ID, wtf.DialMembershipUpdate{Value: &value, Note: &note}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DialValues returns a list of all stored historical values for a dial.
+// This is only used for testing.
+func (s *DialService) DialValues(ctx context.Context, id int) ([]int, error) {
+	tx, err := s.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var a []int
+	for _, v := range tx.dialValues[id] {
+		a = append(a, v.Value)
+	}
+	return a, nil
+}
+
+// AverageDialValueReport returns a report of the average dial value across
+// all dials that the user is a member of. Average values are computed
+// between start & end time and are slotted into given intervals. The
+// minimum interval size is one minute.
+func (s *DialService) AverageDialValueReport(ctx context.Context, start, end time.Time, interval time.Duration) (*wtf.DialValueReport, error) {
+	tx, err := s.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Ensure start/end line up with the interval unit in the user's timezone.
+	loc := wtf.LocationFromContext(ctx)
+	start = wtf.TruncateReportTime(start, interval, loc).UTC()
+	end = wtf.TruncateReportTime(end, interval, loc).UTC()
+
+	// Compute the start of each slot between start & end.
+	slots := wtf.ReportSlotTimes(start, end, interval, loc)
+	report := &wtf.DialValueReport{
+		Records: make([]*wtf.DialValueRecord, len(slots)),
+	}
+
+	// Fetch all dials which user is a member or owner.
+	dials, _, err := findDials(ctx, tx, wtf.DialFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("find dials: %w", err)
+	}
+
+	// Iterate over each dial and compute value at each slot.
+	valuesSlice := make([][]int, len(dials))
+	for i, dial := range dials {
+		valuesSlice[i] = findValueSlotsBetween(tx.dialValues[dial.ID], slots, end)
+	}
+
+	// Compute average for each slot.
+	for i := range slots {
+		var avg int
+		if len(dials) != 0 {
+			var sum int
+			for j := range dials {
+				sum += valuesSlice[j][i]
+			}
+			avg = sum / len(valuesSlice)
+		}
+
+		// Append record for avg value at a given time.
+		report.Records[i] = &wtf.DialValueRecord{
+			Timestamp: slots[i],
+			Value:     avg,
+		}
+	}
+
+	return report, nil
+}
+
+// DialValueReport returns a report with a separate series of historical
+// values for each of the given dials. Values are slotted into intervals
+// between start & end time. If no dial IDs are specified then every dial
+// that the user is a member of is included. Each series includes a summary
+// & each record optionally includes statistics about its interval.
+func (s *DialService) DialValueReport(ctx context.Context, dialIDs []int, start, end time.Time, interval time.Duration, opt wtf.DialValueReportOptions) (*wtf.DialValueReport, error) {
+	if err := wtf.ValidateReportRange(start, end, interval); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Ensure start/end line up with the interval unit in the user's timezone.
+	loc := wtf.LocationFromContext(ctx)
+	start = wtf.TruncateReportTime(start, interval, loc).UTC()
+	end = wtf.TruncateReportTime(end, interval, loc).UTC()
+
+	// Fetch requested dials or all dials which user is a member of.
+	dials, err := findReportDials(ctx, tx, dialIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build a series for each dial from the values within each slot. The
+	// value of a slot is the last value within it.
+	report := &wtf.DialValueReport{
+		Series: make([]*wtf.DialValueSeries, len(dials)),
+	}
+	slots := wtf.ReportSlotTimes(start, end, interval, loc)
+	for i, dial := range dials {
+		samples := findValueSamplesBetween(tx.dialValues[dial.ID], slots, end)
+
+		series := &wtf.DialValueSeries{
+			DialID:  dial.ID,
+			Name:    dial.Name,
+			Records: make([]*wtf.DialValueRecord, len(samples)),
+		}
+		for j, a := range samples {
+			series.Records[j] = &wtf.DialValueRecord{
+				Timestamp: slots[j],
+				Value:     a[len(a)-1],
+			}
+			if opt.Stats {
+				series.Records[j].Stats = wtf.NewDialValueStats(a)
+			}
+		}
+		series.Summary = wtf.NewDialValueSummary(series.Records, interval, opt.Threshold)
+		report.Series[i] = series
+	}
+
+	return report, nil
+}
+
+// dialValue represents a historical value of a dial at a point in time.
+type dialValue struct {
+	Timestamp time.Time
+	Value     int
+}
+
+// DialHeatmapReport returns the average value of the given dials for each
+// hour of each day of the week between start & end time. Hours & days are
+// based on the user's timezone. If no dial IDs are specified then every dial
+// that the user is a member of is included.
+func (s *DialService) DialHeatmapReport(ctx context.Context, dialIDs []int, start, end time.Time) (*wtf.DialHeatmapReport, error) {
+	if err := wtf.ValidateReportRange(start, end, time.Hour); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Align start/end to hours in the user's timezone. Hours in the future
+	// are excluded as their values are not yet known.
+	loc := wtf.LocationFromContext(ctx)
+	start = wtf.TruncateReportTime(start, time.Hour, loc).UTC()
+	end = wtf.TruncateReportTime(end, time.Hour, loc).UTC()
+	if now := wtf.TruncateReportTime(tx.now, time.Hour, loc).Add(time.Hour).UTC(); end.After(now) {
+		end = now
+	}
+
+	// Fetch requested dials or all dials which user is a member of.
+	dials, err := findReportDials(ctx, tx, dialIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add the value of each dial at every hour to the matching cell.
+	report := &wtf.DialHeatmapReport{}
+	if !end.After(start) {
+		return report, nil
+	}
+	slots := wtf.ReportSlotTimes(start, end, time.Hour, loc)
+	for _, dial := range dials {
+		values := findValueSlotsBetween(tx.dialValues[dial.ID], slots, end)
+		for i, value := range values {
+			report.Add(slots[i].In(loc), value)
+		}
+	}
+
+	return report, nil
+}
+
+// findReportDials returns the dials for the given IDs. Each dial must be
+// visible to the user. If no IDs are specified then all dials which the user
+// is a member of are returned.
+func findReportDials(ctx context.Context, tx *Tx, ids []int) ([]*wtf.Dial, error) {
+	if len(ids) == 0 {
+		dials, _, err := findDials(ctx, tx, wtf.DialFilter{})
+		if err != nil {
+			return nil, fmt.Errorf("find dials: %w", err)
+		}
+		return dials, nil
+	}
+
+	dials := make([]*wtf.Dial, 0, len(ids))
+	for _, id := range ids {
+		dial, err := findDialByID(ctx, tx, id)
+		if err != nil {
+			return nil, err
+		}
+		dials = append(dials, dial)
+	}
+	return dials, nil
+}
+
+// findDialByID is a helper function to retrieve a dial by ID.
+// Returns ENOTFOUND if dial doesn't exist.
+func findDialByID(ctx context.Context, tx *Tx, id int) (*wtf.Dial, error) {
+	dials, _, err := findDials(ctx, tx, wtf.DialFilter{ID: &id})
+	if err != nil {
+		return nil, err
+	} else if len(dials) == 0 {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "Dial not found."}
+	}
+	return dials[0], nil
+}
+
+// checkDialExists returns nil if a dial does not exist. Otherwise returns ENOTFOUND.
+// This is used to avoid permissions checks when inserting related objects.
+func checkDialExists(ctx context.Context, tx *Tx, id int) error {
+	if _, ok := tx.dials[id]; !ok {
+		return &wtf.Error{Code: wtf.ENOTFOUND, Message: "Dial not found."}
+	}
+	return nil
+}
+
+// findDials retrieves a list of matching dials. Also returns a total matching
+// count which may different from the number of results if filter.Limit is set.
+func findDials(ctx context.Context, tx *Tx, filter wtf.DialFilter) (_ []*wtf.Dial, n int, err error) {
+	userID := wtf.UserIDFromContext(ctx)
+
+	dials := make([]*wtf.Dial, 0)
+	for _, dial := range tx.dials {
+		if v := filter.ID; v != nil && dial.ID != *v {
+			continue
+		}
+		if v := filter.OrganizationID; v != nil && dial.OrganizationID != *v {
+			continue
+		}
+		if v := filter.UserID; v != nil && dial.UserID != *v {
+			continue
+		}
+		if v := filter.Tag; v != nil && !dial.HasTag(wtf.NormalizeDialTag(*v)) {
+			continue
+		}
+		if v := filter.Name; v != nil && !strings.Contains(strings.ToLower(dial.Name), strings.ToLower(*v)) {
+			continue
+		}
+		if v := filter.MinValue; v != nil && dial.Value < *v {
+			continue
+		}
+		if v := filter.MaxValue; v != nil && dial.Value > *v {
+			continue
+		}
+
+		// Limit to dials user is a member of unless searching by invite code.
+		// Dials within the user's organizations are also visible when looking
+		// up a single dial or listing an organization's dials.
+		if v := filter.InviteCode; v != nil {
+			if dial.InviteCode != *v {
+				continue
+			}
+		} else if filter.ID != nil || filter.OrganizationID != nil {
+			if !isDialMember(tx, dial.ID, userID) && !isOrganizationMember(tx, dial.OrganizationID, userID) {
+				continue
+			}
+		} else if !isDialMember(tx, dial.ID, userID) {
+			continue
+		}
+
+		// Return a copy without associations so the stored record is unchanged.
+		// The current user's role is computed from their membership.
+		other := *dial
+		other.User, other.Organization, other.Memberships = nil, nil, nil
+		other.Tags = copyDialTags(dial.Tags)
+		other.Role = dialMembershipRole(tx, dial.ID, userID)
+
+		// Omit the invite code if the current API token cannot read it.
+		if !wtf.CanViewInviteCodes(ctx) {
+			other.InviteCode = ""
+		}
+
+		dials = append(dials, &other)
+	}
+
+	// Determine sorting. Sort by ID first so ties are stable.
+	sort.Slice(dials, func(i, j int) bool { return dials[i].ID < dials[j].ID })
+	switch filter.SortBy {
+	case wtf.DialSortByNameAsc:
+		sort.SliceStable(dials, func(i, j int) bool {
+			return strings.ToLower(dials[i].Name) < strings.ToLower(dials[j].Name)
+		})
+	case wtf.DialSortByValueAsc:
+		sort.SliceStable(dials, func(i, j int) bool { return dials[i].Value < dials[j].Value })
+	case wtf.DialSortByValueDesc:
+		sort.SliceStable(dials, func(i, j int) bool { return dials[i].Value > dials[j].Value })
+	case wtf.DialSortByUpdatedAtDesc:
+		sort.SliceStable(dials, func(i, j int) bool { return dials[i].UpdatedAt.After(dials[j].UpdatedAt) })
+	}
+
+	// Restrict to the requested range.
+	n = len(dials)
+	start, end := applyLimitOffset(n, filter.Limit, filter.Offset)
+	return dials[start:end], n, nil
+}
+
+// copyDialTags returns a copy of tags so the stored record cannot be changed
+// through a returned dial. Returns nil if there are no tags.
+func copyDialTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	return append([]string(nil), tags...)
+}
+
+// isDialMember returns true if the user has a membership on the given dial.
+func isDialMember(tx *Tx, dialID, userID int) bool {
+	return dialMembershipRole(tx, dialID, userID) != ""
+}
+
+// dialMembershipRole returns the role of the user's membership on the given
+// dial. Returns a blank string if the user is not a member.
+func dialMembershipRole(tx *Tx, dialID, userID int) string {
+	for _, membership := range tx.memberships {
+		if membership.DialID == dialID && membership.UserID == userID {
+			return membership.Role
+		}
+	}
+	return ""
+}
+
+// createDial creates a new dial.
+func createDial(ctx context.Context, tx *Tx, dial *wtf.Dial) error {
+	// Assign dial to the current user.
+	// Return an error if the user is not currently logged in.
+	userID := wtf.UserIDFromContext(ctx)
+	if userID == 0 {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You must be logged in to create a dial.")
+	}
+	dial.UserID = wtf.UserIDFromContext(ctx)
+
+	// Generate a random invite code. New invite codes have no limits until
+	// the code is rotated.
+	inviteCode, err := generateInviteCode()
+	if err != nil {
+		return err
+	}
+	dial.InviteCode = inviteCode
+	dial.InviteExpiresAt, dial.InviteMaxUses, dial.InviteUseCount = nil, 0, 0
+
+	// Default to averaging member values.
+	if dial.Aggregation == "" {
+		dial.Aggregation = wtf.DialAggregationMean
+	}
+	dial.Tags = wtf.NormalizeDialTags(dial.Tags)
+
+	// Set timestamps to current time.
+	dial.CreatedAt = tx.now
+	dial.UpdatedAt = dial.CreatedAt
+
+	// Perform basic field validation & ensure user exists.
+	if err := dial.Validate(); err != nil {
+		return err
+	} else if _, err := findUserByID(ctx, tx, dial.UserID); err != nil {
+		return err
+	}
+
+	// Ensure the user is a member of the dial's organization, if any.
+	if dial.OrganizationID != 0 && !isOrganizationMember(tx, dial.OrganizationID, dial.UserID) {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You must be a member of the organization to create a dial in it.")
+	}
+
+	// Assign the next ID. The value always starts at zero as it is computed
+	// from the memberships.
+	tx.seq.dial++
+	dial.ID = tx.seq.dial
+	dial.Value = 0
+
+	other := *dial
+	other.User, other.Organization, other.Memberships, other.Role = nil, nil, nil, ""
+	other.Tags = copyDialTags(dial.Tags)
+	tx.dials[dial.ID] = &other
+
+	// Record initial value to history.
+	insertDialValue(tx, dial.ID, dial.Value, dial.CreatedAt)
+
+	// Create self membership automatically.
+	if err := createDialMembership(ctx, tx, &wtf.DialMembership{
+		DialID: dial.ID,
+		UserID: dial.UserID,
+		Role:   wtf.DialMembershipRoleOwner,
+	}); err != nil {
+		return fmt.Errorf("create self-membership: %w", err)
+	}
+	dial.Role = wtf.DialMembershipRoleOwner
+
+	return nil
+}
+
+// generateInviteCode returns a new random invite code.
+func generateInviteCode() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// updateDial updates a dial by ID. Returns the new state of the dial after update.
+func updateDial(ctx context.Context, tx *Tx, id int, upd wtf.DialUpdate) (*wtf.Dial, error) {
+	// Fetch current object state. Return an error if current user is not owner or admin.
+	dial, err := findDialByID(ctx, tx, id)
+	if err != nil {
+		return dial, err
+	} else if !wtf.CanEditDial(ctx, dial) {
+		return dial, wtf.Errorf(wtf.EUNAUTHORIZED, "You must be the owner or an admin to edit a dial.")
+	}
+
+	// Update fields, if set.
+	if v := upd.Name; v != nil {
+		dial.Name = *v
+	}
+	if v := upd.Aggregation; v != nil {
+		dial.Aggregation = *v
+	}
+	if v := upd.Percentile; v != nil {
+		dial.Percentile = *v
+	}
+	if v := upd.Trim; v != nil {
+		dial.Trim = *v
+	}
+	if v := upd.Tags; v != nil {
+		dial.Tags = wtf.NormalizeDialTags(*v)
+	}
+	dial.UpdatedAt = tx.now
+
+	// Perform basic field validation.
+	if err := dial.Validate(); err != nil {
+		return dial, err
+	}
+
+	// Replace stored record with a copy of the new state.
+	other := *dial
+	other.Tags = copyDialTags(dial.Tags)
+	other.Role = ""
+	tx.dials[id] = &other
+
+	// Recompute the dial value in case the aggregation settings changed.
+	if err := refreshDialValue(ctx, tx, id); err != nil {
+		return dial, fmt.Errorf("refresh dial value: %w", err)
+	}
+	dial.Value = tx.dials[id].Value
+
+	return dial, nil
+}
+
+// deleteDial permanently deletes a dial by ID. Returns EUNAUTHORIZED if user
+// does not own the dial.
+func deleteDial(ctx context.Context, tx *Tx, id int) error {
+	// Verify object exists & the current user is the owner.
+	if dial, err := findDialByID(ctx, tx, id); err != nil {
+		return err
+	} else if !wtf.CanDeleteDial(ctx, dial) {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "Only the owner can delete a dial.")
+	}
+
+	removeDial(tx, id)
+	return nil
+}
+
+// transferDial moves ownership of a dial to another member. The previous owner
+// remains on the dial as an admin. Returns the new state of the dial.
+func transferDial(ctx context.Context, tx *Tx, id, userID int) (*wtf.Dial, error) {
+	// Fetch current object state. Return an error if current user is not owner.
+	dial, err := findDialByID(ctx, tx, id)
+	if err != nil {
+		return dial, err
+	} else if !wtf.CanTransferDial(ctx, dial) {
+		return dial, wtf.Errorf(wtf.EUNAUTHORIZED, "Only the owner can transfer a dial.")
+	}
+
+	// Exit if the dial is already owned by the user.
+	if dial.UserID == userID {
+		return dial, nil
+	}
+
+	// Ensure the new owner is already a member of the dial.
+	if !isDialMember(tx, id, userID) {
+		return dial, wtf.Errorf(wtf.EINVALID, "New owner must be a member of the dial.")
+	}
+
+	// Reassign the dial. The current user is now an admin of the dial.
+	prevUserID := dial.UserID
+	dial.UserID = userID
+	dial.Role = wtf.DialMembershipRoleAdmin
+	dial.UpdatedAt = tx.now
+
+	// Replace stored record with a copy of the new state.
+	other := *dial
+	other.Role = ""
+	tx.dials[id] = &other
+
+	// Swap the roles of the previous & new owner memberships.
+	for _, membership := range tx.memberships {
+		if membership.DialID != id {
+			continue
+		}
+
+		switch membership.UserID {
+		case userID:
+			m := *membership
+			m.Role = wtf.DialMembershipRoleOwner
+			tx.memberships[m.ID] = &m
+		case prevUserID:
+			m := *membership
+			m.Role = wtf.DialMembershipRoleAdmin
+			tx.memberships[m.ID] = &m
+		}
+	}
+
+	// Recompute the dial value in case the new owner was previously a viewer.
+	if err := refreshDialValue(ctx, tx, id); err != nil {
+		return dial, fmt.Errorf("refresh dial value: %w", err)
+	}
+	dial.Value = tx.dials[id].Value
+
+	return dial, nil
+}
+
+// rotateDialInvite replaces the invite code & limits of a dial. The use count
+// is reset for the new code. Returns the new state of the dial.
+func rotateDialInvite(ctx context.Context, tx *Tx, id int, opt wtf.DialInviteOptions) (*wtf.Dial, error) {
+	// Fetch current object state. Return an error if current user is not owner or admin.
+	dial, err := findDialByID(ctx, tx, id)
+	if err != nil {
+		return dial, err
+	} else if !wtf.CanEditDial(ctx, dial) {
+		return dial, wtf.Errorf(wtf.EUNAUTHORIZED, "You must be the owner or an admin to rotate the invite code.")
+	}
+
+	// Ensure the new code does not expire immediately.
+	if opt.ExpiresAt != nil && !opt.ExpiresAt.After(tx.now) {
+		return dial, wtf.Errorf(wtf.EINVALID, "Invite expiration must be in the future.")
+	}
+
+	// Generate a new code & apply limits. Expiration is stored with the same
+	// precision as other timestamps.
+	if dial.InviteCode, err = generateInviteCode(); err != nil {
+		return dial, err
+	}
+	dial.InviteExpiresAt, dial.InviteMaxUses, dial.InviteUseCount = nil, opt.MaxUses, 0
+	if opt.ExpiresAt != nil {
+		t := opt.ExpiresAt.UTC().Truncate(time.Second)
+		dial.InviteExpiresAt = &t
+	}
+	dial.UpdatedAt = tx.now
+
+	// Perform basic field validation.
+	if err := dial.Validate(); err != nil {
+		return dial, err
+	}
+
+	// Replace stored record with a copy of the new state.
+	other := *dial
+	other.Role = ""
+	tx.dials[id] = &other
+
+	return dial, nil
+}
+
+// useDialInvite verifies that code is the current, usable invite code for a
+// dial & increments its use count. Returns EINVALID if the code is incorrect,
+// expired, or has reached its maximum number of uses.
+func useDialInvite(ctx context.Context, tx *Tx, id int, code string) error {
+	// Read the stored dial directly as the user is not yet a member.
+	dial, ok := tx.dials[id]
+	if !ok {
+		return &wtf.Error{Code: wtf.ENOTFOUND, Message: "Dial not found."}
+	}
+
+	// Ensure the code matches & is still usable.
+	if dial.InviteCode != code {
+		return wtf.Errorf(wtf.EINVALID, "Invalid invite code.")
+	} else if err := dial.ValidateInvite(tx.now); err != nil {
+		return err
+	}
+
+	// Count this use of the invite code.
+	other := *dial
+	other.InviteUseCount++
+	tx.dials[id] = &other
+
+	return nil
+}
+
+// removeDial removes a dial along with its memberships, alert rules &
+// historical values.
+func removeDial(tx *Tx, id int) {
+	for _, membership := range tx.memberships {
+		if membership.DialID == id {
+			delete(tx.memberships, membership.ID)
+			delete(tx.membershipValues, membership.ID)
+			removeIncomingWebhooks(tx, membership.ID)
+		}
+	}
+	for _, rule := range tx.alertRules {
+		if rule.DialID == id {
+			removeAlertRule(tx, rule.ID)
+		}
+	}
+	for _, webhook := range tx.webhooks {
+		if webhook.DialID == id {
+			removeWebhook(tx, webhook.ID)
+		}
+	}
+	delete(tx.dialValues, id)
+	delete(tx.dials, id)
+}
+
+// refreshDialValue recomputes the WTF level of a dial by ID and saves it in dial.Value.
+func refreshDialValue(ctx context.Context, tx *Tx, id int) error {
+	// Fetch current dial value.
+	dial, ok := tx.dials[id]
+	if !ok {
+		return nil // no dial, skip
+	}
+	oldValue := dial.Value
+
+	// Compute aggregate value from dial memberships. Viewers are excluded as
+	// they do not contribute a value.
+	var values, weights []int
+	for _, membership := range tx.memberships {
+		if membership.DialID == id && membership.Role != wtf.DialMembershipRoleViewer {
+			values, weights = append(values, membership.Value), append(weights, membership.Weight)
+		}
+	}
+	newValue := dial.AggregateValue(values, weights)
+
+	// Exit if the value will not change.
+	if oldValue == newValue {
+		return nil
+	}
+
+	// Update value on dial.
+	other := *dial
+	other.Value = newValue
+	other.UpdatedAt = tx.now
+	tx.dials[id] = &other
+
+	// Record historical value.
+	insertDialValue(tx, id, newValue, tx.now)
+
+	// Publish event to notify other members that the value has changed.
+	publishDialEvent(ctx, tx, id, wtf.Event{
+		Type: wtf.EventTypeDialValueChanged,
+		Payload: &wtf.DialValueChangedPayload{
+			ID:    id,
+			Value: newValue,
+		},
+	})
+
+	// Fire or resolve any alert rules affected by the change.
+	evaluateDialAlertRules(ctx, tx, id)
+
+	return nil
+}
+
+// insertDialValue records a dial value at specific point in time.
+func insertDialValue(tx *Tx, id int, value int, timestamp time.Time) {
+	// Reduce our precision to only one update per minute.
+	timestamp = timestamp.Truncate(1 * time.Minute)
+
+	// Copy values so that the original slice is not modified.
+	values := make([]dialValue, len(tx.dialValues[id]), len(tx.dialValues[id])+1)
+	copy(values, tx.dialValues[id])
+
+	// Update an existing record for the dial at the given timestamp.
+	i := sort.Search(len(values), func(i int) bool { return !values[i].Timestamp.Before(timestamp) })
+	if i < len(values) && values[i].Timestamp.Equal(timestamp) {
+		values[i].Value = value
+		tx.dialValues[id] = values
+		return
+	}
+
+	// Otherwise insert a new record in timestamp order.
+	values = append(values, dialValue{})
+	copy(values[i+1:], values[i:])
+	values[i] = dialValue{Timestamp: timestamp, Value: value}
+	tx.dialValues[id] = values
+}
+
+// findValueSlotsBetween returns the value of a dial or membership within each
+// slot up to end. Slots are the start times from wtf.ReportSlotTimes(). The
+// value of each slot is the last value within the slot. The history must be
+// sorted by timestamp.
+func findValueSlotsBetween(history []dialValue, slots []time.Time, end time.Time) []int {
+	samples := findValueSamplesBetween(history, slots, end)
+
+	values := make([]int, len(samples))
+	for i := range samples {
+		values[i] = samples[i][len(samples[i])-1]
+	}
+	return values
+}
+
+// findValueSamplesBetween returns all values of a dial or membership within
+// each slot up to end. Each slot begins with the value
+// carried over from the previous slot followed by each value changed to within
+// the slot. The carried value is omitted if the value changed exactly at the
+// start of the slot.
+func findValueSamplesBetween(history []dialValue, slots []time.Time, end time.Time) [][]int {
+	samples := make([][]int, len(slots))
+	if len(samples) == 0 {
+		return samples
+	}
+	start := slots[0]
+
+	// Determine initial value at start of report time range.
+	var lastValue int
+	for _, v := range history {
+		if v.Timestamp.After(start) {
+			break
+		}
+		lastValue = v.Value
+	}
+
+	// Append all values between start & end to slots. Track which slots
+	// changed exactly at their start so no value is carried over.
+	changedAtStart := make([]bool, len(samples))
+	for _, v := range history {
+		if v.Timestamp.Before(start) || !v.Timestamp.Before(end) {
+			continue
+		}
+
+		i := wtf.ReportSlotIndex(slots, v.Timestamp)
+		if i < 0 || i >= len(samples) {
+			continue
+		} else if len(samples[i]) == 0 && v.Timestamp.Equal(slots[i]) {
+			changedAtStart[i] = true
+		}
+		samples[i] = append(samples[i], v.Value)
+	}
+
+	// Iterate over slots to prepend the value carried from the previous slot.
+	for i := range samples {
+		if !changedAtStart[i] {
+			samples[i] = append([]int{lastValue}, samples[i]...)
+		}
+		lastValue = samples[i][len(samples[i])-1]
+	}
+
+	return samples
+}
+
+// findDialValueRecordsSince returns the value history of a dial starting with
+// the last value recorded at or before start followed by every value recorded
+// after it. Records keep their original timestamps.
+func findDialValueRecordsSince(tx *Tx, id int, start time.Time) []*wtf.DialValueRecord {
+	history := tx.dialValues[id]
+
+	// Find the index of the last value at or before start.
+	i := sort.Search(len(history), func(i int) bool { return history[i].Timestamp.After(start) })
+	if i > 0 {
+		i--
+	}
+
+	records := make([]*wtf.DialValueRecord, 0, len(history)-i)
+	for _, v := range history[i:] {
+		records = append(records, &wtf.DialValueRecord{Value: v.Value, Timestamp: v.Timestamp})
+	}
+	return records
+}
+
+// publishDialEvent publishes event to the dial members.
+func publishDialEvent(ctx context.Context, tx *Tx, id int, event wtf.Event) {
+	for _, membership := range tx.memberships {
+		if membership.DialID == id {
+			tx.db.EventService.PublishEvent(membership.UserID, event)
+		}
+	}
+
+	// Queue the event for delivery to the dial's webhooks.
+	insertWebhookDeliveries(tx, id, event)
+}
+
+// attachDialAssociations is a helper function to look up and attach the owner user to the dial.
+func attachDialAssociations(ctx context.Context, tx *Tx, dial *wtf.Dial) (err error) {
+	if dial.User, err = findUserByID(ctx, tx, dial.UserID); err != nil {
+		return fmt.Errorf("attach dial user: %w", err)
+	}
+
+	// Attach the organization even if the user is not one of its members so
+	// that its name can be displayed with the dial.
+	if dial.OrganizationID != 0 {
+		if dial.Organization, err = lookupOrganization(tx, dial.OrganizationID); err != nil {
+			return fmt.Errorf("attach dial organization: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,606 @@
+package inmem
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+)
+
+// Ensure service implements interface.
+var _ wtf.DialMembershipService = (*DialMembershipService)(nil)
+
+// DialMembershipService represents a service for managing dial memberships in memory.
+type DialMembershipService struct {
+	db *DB
+}
+
+// NewDialMembershipService returns a new instance of DialMembershipService.
+func NewDialMembershipService(db *DB) *DialMembershipService {
+	return &DialMembershipService{db: db}
+}
+
+// FindDialMembershipByID retrieves a membership by ID along with the associated
+// dial & user. Returns ENOTFOUND if membership does exist or user does not have
+// permission to view it.
+func (s *DialMembershipService) FindDialMembershipByID(ctx context.Context, id int) (*wtf.DialMembership, error) {
+	tx, err := s.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Fetch membership object by ID and attach associated user & dial.
+	membership, err := findDialMembershipByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	} else if err := attachDialMembershipAssociations(ctx, tx, membership); err != nil {
+		return nil, err
+	}
+	return membership, nil
+}
+
+// FindDialMemberships retrieves a list of matching memberships based on filter.
+// Only returns memberships that belong to dials that the current user is a member of.
+//
+// Also returns a count of total matching memberships which may different if
+// "Limit" is specified on the filter.
+func (s *DialMembershipService) FindDialMemberships(ctx context.Context, filter wtf.DialMembershipFilter) ([]*wtf.DialMembership, int, error) {
+	tx, err := s.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	// Fetch a list of matching membership objects.
+	memberships, n, err := findDialMemberships(ctx, tx, filter)
+	if err != nil {
+		return memberships, n, err
+	}
+
+	// Attach dial & user to each returned membership.
+	for _, membership := range memberships {
+		if err := attachDialMembershipAssociations(ctx, tx, membership); err != nil {
+			return memberships, n, err
+		}
+	}
+	return memberships, n, nil
+}
+
+// CreateDialMembership creates a new membership on a dial for the current user.
+// Returns EUNAUTHORIZED if there is no current user logged in.
+func (s *DialMembershipService) CreateDialMembership(ctx context.Context, membership *wtf.DialMembership) error {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Ensure user is logged in & assign membership to current user.
+	userID := wtf.UserIDFromContext(ctx)
+	if userID == 0 {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You must be logged in to join a dial.")
+	}
+	membership.UserID = wtf.UserIDFromContext(ctx)
+
+	// New members always join with the member role.
+	membership.Role = wtf.DialMembershipRoleMember
+
+	// Read the invite code from the membership or from the attached dial.
+	if membership.InviteCode == "" && membership.Dial != nil {
+		membership.InviteCode = membership.Dial.InviteCode
+	}
+
+	// Dials can only be joined with an invite code unless they belong to one
+	// of the user's organizations. The invite code is verified & counted when
+	// the membership is created. Missing dial IDs are left for the membership
+	// validation to report.
+	if membership.DialID != 0 && membership.InviteCode == "" {
+		if err := checkDialOrganizationMember(tx, membership.DialID, userID); err != nil {
+			return err
+		}
+	}
+
+	// Create new membership and attach associated user & dial to returned data.
+	if err := createDialMembership(ctx, tx, membership); err != nil {
+		return err
+	} else if err := attachDialMembershipAssociations(ctx, tx, membership); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateDialMembership updates the value of a membership. Only the owner of
+// the membership can update the value. Returns EUNAUTHORIZED if user is not the
+// owner. Returns ENOTFOUND if the membership does not exist.
+func (s *DialMembershipService) UpdateDialMembership(ctx context.Context, id int, upd wtf.DialMembershipUpdate) (*wtf.DialMembership, error) {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Update a membership and attach associated user & dial to returned data.
+	membership, err := updateDialMembership(ctx, tx, id, upd)
+	if err != nil {
+		return membership, err
+	} else if err := attachDialMembershipAssociations(ctx, tx, membership); err != nil {
+		return membership, err
+	}
+	return membership, tx.Commit()
+}
+
+// SetDialMembershipWeight sets the weight of a membership. Only the owner &
+// admins of the parent dial can set the weight. Returns EUNAUTHORIZED if user
+// cannot manage the dial. Returns ENOTFOUND if the membership does not exist.
+func (s *DialMembershipService) SetDialMembershipWeight(ctx context.Context, id, weight int) (*wtf.DialMembership, error) {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Update the weight and attach associated user & dial to returned data.
+	membership, err := setDialMembershipWeight(ctx, tx, id, weight)
+	if err != nil {
+		return membership, err
+	} else if err := attachDialMembershipAssociations(ctx, tx, membership); err != nil {
+		return membership, err
+	}
+	return membership, tx.Commit()
+}
+
+// SetDialMembershipRole sets the role of a membership. The owner role cannot
+// be assigned or removed. Returns EUNAUTHORIZED if user cannot change the role.
+// Returns ENOTFOUND if the membership does not exist.
+func (s *DialMembershipService) SetDialMembershipRole(ctx context.Context, id int, role string) (*wtf.DialMembership, error) {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Update the role and attach associated user & dial to returned data.
+	membership, err := setDialMembershipRole(ctx, tx, id, role)
+	if err != nil {
+		return membership, err
+	} else if err := attachDialMembershipAssociations(ctx, tx, membership); err != nil {
+		return membership, err
+	}
+	return membership, tx.Commit()
+}
+
+// DeleteDialMembership permanently deletes a membership by ID. Only the
+// membership owner and the parent dial's owner & admins can delete a membership.
+func (s *DialMembershipService) DeleteDialMembership(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteDialMembership(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DialMembershipValueReport returns the historical values of a single
+// membership or of every membership on a dial. Values are slotted into
+// intervals between start & end time.
+func (s *DialMembershipService) DialMembershipValueReport(ctx context.Context, filter wtf.DialMembershipValueReportFilter, start, end time.Time, interval time.Duration) (*wtf.DialMembershipValueReport, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	} else if err := wtf.ValidateReportRange(start, end, interval); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Ensure start/end line up with the interval unit in the user's timezone.
+	loc := wtf.LocationFromContext(ctx)
+	start = wtf.TruncateReportTime(start, interval, loc).UTC()
+	end = wtf.TruncateReportTime(end, interval, loc).UTC()
+
+	// Verify the dial is visible to the user, if filtering by dial.
+	if filter.DialID != nil {
+		if _, err := findDialByID(ctx, tx, *filter.DialID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Fetch matching memberships. These are restricted to dials that the
+	// current user is a member of.
+	memberships, _, err := findDialMemberships(ctx, tx, wtf.DialMembershipFilter{
+		ID:     filter.ID,
+		DialID: filter.DialID,
+	})
+	if err != nil {
+		return nil, err
+	} else if filter.ID != nil && len(memberships) == 0 {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "Dial membership not found."}
+	}
+
+	// Build a series for each membership from its value at each slot.
+	report := &wtf.DialMembershipValueReport{
+		Series: make([]*wtf.DialMembershipValueSeries, len(memberships)),
+	}
+	slots := wtf.ReportSlotTimes(start, end, interval, loc)
+	for i, membership := range memberships {
+		user, err := findUserByID(ctx, tx, membership.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("find user: %w", err)
+		}
+
+		values := findValueSlotsBetween(findDialMembershipValueHistory(tx, membership.ID), slots, end)
+
+		series := &wtf.DialMembershipValueSeries{
+			DialMembershipID: membership.ID,
+			DialID:           membership.DialID,
+			UserID:           membership.UserID,
+			User:             user,
+			Records:          make([]*wtf.DialValueRecord, len(values)),
+		}
+		for j, value := range values {
+			series.Records[j] = &wtf.DialValueRecord{
+				Timestamp: slots[j],
+				Value:     value,
+			}
+		}
+		report.Series[i] = series
+	}
+
+	return report, nil
+}
+
+// findDialMembershipByID returns a membership object by ID.
+// Returns ENOTFOUND if membership does not exist.
+func findDialMembershipByID(ctx context.Context, tx *Tx, id int) (*wtf.DialMembership, error) {
+	memberships, _, err := findDialMemberships(ctx, tx, wtf.DialMembershipFilter{ID: &id})
+	if err != nil {
+		return nil, err
+	} else if len(memberships) == 0 {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "Dial membership not found."}
+	}
+	return memberships[0], nil
+}
+
+// findDialMemberships returns a list of memberships matching a filter. Also
+// returns a count of total matching memberships which may differ if filter.Limit is set.
+func findDialMemberships(ctx context.Context, tx *Tx, filter wtf.DialMembershipFilter) (_ []*wtf.DialMembership, n int, err error) {
+	userID := wtf.UserIDFromContext(ctx)
+
+	memberships := make([]*wtf.DialMembership, 0)
+	for _, membership := range tx.memberships {
+		if v := filter.ID; v != nil && membership.ID != *v {
+			continue
+		} else if v := filter.DialID; v != nil && membership.DialID != *v {
+			continue
+		} else if v := filter.UserID; v != nil && membership.UserID != *v {
+			continue
+		}
+
+		// Limit to user's dials or memberships of dials they belong to.
+		dial := tx.dials[membership.DialID]
+		if dial == nil || tx.users[membership.UserID] == nil {
+			continue
+		} else if dial.UserID != userID && !isDialMember(tx, dial.ID, userID) {
+			continue
+		}
+
+		// Return a copy without associations so the stored record is unchanged.
+		other := *membership
+		other.Dial, other.User = nil, nil
+		memberships = append(memberships, &other)
+	}
+
+	// Determine sorting. Sort by ID first so ties are stable.
+	sort.Slice(memberships, func(i, j int) bool { return memberships[i].ID < memberships[j].ID })
+	switch filter.SortBy {
+	case wtf.DialMembershipSortByUpdatedAtDesc:
+		sort.SliceStable(memberships, func(i, j int) bool {
+			return memberships[i].UpdatedAt.After(memberships[j].UpdatedAt)
+		})
+	default:
+		// Sort current user's membership first and then order by user name.
+		sort.SliceStable(memberships, func(i, j int) bool {
+			a, b := memberships[i], memberships[j]
+			if (a.UserID == userID) != (b.UserID == userID) {
+				return a.UserID == userID
+			}
+			return tx.users[a.UserID].Name < tx.users[b.UserID].Name
+		})
+	}
+
+	// Restrict to the requested range.
+	n = len(memberships)
+	start, end := applyLimitOffset(n, filter.Limit, filter.Offset)
+	return memberships[start:end], n, nil
+}
+
+// checkDialOrganizationMember returns ENOTFOUND unless the dial belongs to an
+// organization which the user is a member of. This is used to allow joining
+// organization dials without an invite code.
+func checkDialOrganizationMember(tx *Tx, dialID, userID int) error {
+	if dial, ok := tx.dials[dialID]; !ok || !isOrganizationMember(tx, dial.OrganizationID, userID) {
+		return &wtf.Error{Code: wtf.ENOTFOUND, Message: "Dial not found."}
+	}
+	return nil
+}
+
+// createDialMembership creates a new membership. Assigns the new ID
+// to membership.ID and updates the timestamps.
+func createDialMembership(ctx context.Context, tx *Tx, membership *wtf.DialMembership) error {
+	// New memberships always start with the default weight. Only the dial
+	// owner can change it afterward.
+	membership.Weight = wtf.DefaultDialMembershipWeight
+
+	// Update timestamps to current time.
+	membership.CreatedAt = tx.now
+	membership.UpdatedAt = membership.CreatedAt
+
+	// Perform basic field validation.
+	if err := membership.Validate(); err != nil {
+		return err
+	}
+
+	// Lookup dial & user to ensure they exist. The dial check avoids
+	// permission checks since the user is not yet a member.
+	if err := checkDialExists(ctx, tx, membership.DialID); err != nil {
+		return err
+	} else if _, err := findUserByID(ctx, tx, membership.UserID); err != nil {
+		return err
+	}
+
+	// Verify & count the invite code, if one was used to join.
+	if membership.InviteCode != "" {
+		if err := useDialInvite(ctx, tx, membership.DialID, membership.InviteCode); err != nil {
+			return err
+		}
+	}
+
+	// Only allow a single membership per user on a dial.
+	if isDialMember(tx, membership.DialID, membership.UserID) {
+		return wtf.Errorf(wtf.ECONFLICT, "Dial membership already exists.")
+	}
+
+	// Assign the next ID and store a copy without associations.
+	tx.seq.membership++
+	membership.ID = tx.seq.membership
+
+	other := *membership
+	other.Dial, other.User = nil, nil
+	tx.memberships[membership.ID] = &other
+
+	// Record initial value to history.
+	insertDialMembershipValue(tx, membership.ID, membership.Value, membership.Note, membership.CreatedAt)
+
+	// Ensure computed parent dial value is up to date.
+	if err := refreshDialValue(ctx, tx, membership.DialID); err != nil {
+		return fmt.Errorf("refresh dial value: %w", err)
+	}
+
+	return nil
+}
+
+// updateDialMembership updates the value of a membership.
+// Returns EUNAUTHORIZED if user is not the membership owner or is a viewer.
+func updateDialMembership(ctx context.Context, tx *Tx, id int, upd wtf.DialMembershipUpdate) (*wtf.DialMembership, error) {
+	// Fetch current object state. Return error if current user is not owner.
+	membership, err := findDialMembershipByID(ctx, tx, id)
+	if err != nil {
+		return membership, err
+	} else if membership.UserID != wtf.UserIDFromContext(ctx) {
+		return membership, wtf.Errorf(wtf.EUNAUTHORIZED, "You do not have permission to update the dial membership.")
+	} else if !wtf.CanEditDialMembership(ctx, membership) {
+		return membership, wtf.Errorf(wtf.EUNAUTHORIZED, "Viewers cannot set a value on a dial.")
+	}
+
+	// Save state of membership to compare later in the function.
+	prev := *membership
+
+	// Update fields. A note only describes a single change so it is cleared
+	// when the value changes without a new note.
+	if v := upd.Value; v != nil {
+		membership.Value = *v
+	}
+	if v := upd.Note; v != nil {
+		membership.Note = *v
+	} else if membership.Value != prev.Value {
+		membership.Note = ""
+	}
+
+	// Exit if membership did not change.
+	if prev.Value == membership.Value && prev.Note == membership.Note {
+		return membership, nil
+	}
+
+	// Set last updated date to current time.
+	membership.UpdatedAt = tx.now
+
+	// Perform basic field validation.
+	if err := membership.Validate(); err != nil {
+		return membership, err
+	}
+
+	// Replace stored record with a copy of the new state.
+	other := *membership
+	tx.memberships[id] = &other
+
+	// Record the change & its note to the membership's history.
+	insertDialMembershipValue(tx, id, membership.Value, membership.Note, membership.UpdatedAt)
+
+	// Ensure computed dial value is up to date.
+	if err := refreshDialValue(ctx, tx, membership.DialID); err != nil {
+		return membership, fmt.Errorf("refresh dial value: %w", err)
+	}
+
+	// Publish event to all dial members.
+	publishDialEvent(ctx, tx, membership.DialID, wtf.Event{
+		Type: wtf.EventTypeDialMembershipValueChanged,
+		Payload: &wtf.DialMembershipValueChangedPayload{
+			ID:    id,
+			Value: membership.Value,
+			Note:  membership.Note,
+		},
+	})
+
+	return membership, nil
+}
+
+// setDialMembershipWeight updates the weight of a membership.
+// Returns EUNAUTHORIZED if user is not the owner or an admin of the parent dial.
+func setDialMembershipWeight(ctx context.Context, tx *Tx, id, weight int) (*wtf.DialMembership, error) {
+	// Fetch current object state along with the parent dial to verify the
+	// current user's role.
+	membership, err := findDialMembershipByID(ctx, tx, id)
+	if err != nil {
+		return membership, err
+	} else if err := attachDialMembershipAssociations(ctx, tx, membership); err != nil {
+		return membership, err
+	} else if !wtf.CanEditDialMembershipWeight(ctx, membership) {
+		return membership, wtf.Errorf(wtf.EUNAUTHORIZED, "Only the dial owner or an admin can set the membership weight.")
+	}
+
+	// Exit if weight did not change.
+	if membership.Weight == weight {
+		return membership, nil
+	}
+	membership.Weight = weight
+
+	// Perform basic field validation.
+	if err := membership.Validate(); err != nil {
+		return membership, err
+	}
+
+	// Replace stored record with a copy of the new state. The last updated
+	// date is left unchanged as it tracks changes to the member's WTF level.
+	other := *membership
+	other.Dial, other.User = nil, nil
+	tx.memberships[id] = &other
+
+	// Ensure computed dial value is up to date.
+	if err := refreshDialValue(ctx, tx, membership.DialID); err != nil {
+		return membership, fmt.Errorf("refresh dial value: %w", err)
+	}
+	return membership, nil
+}
+
+// setDialMembershipRole updates the role of a membership.
+// Returns EUNAUTHORIZED if user cannot change the membership's role.
+func setDialMembershipRole(ctx context.Context, tx *Tx, id int, role string) (*wtf.DialMembership, error) {
+	// Fetch current object state along with the parent dial to verify the
+	// current user's role.
+	membership, err := findDialMembershipByID(ctx, tx, id)
+	if err != nil {
+		return membership, err
+	} else if err := attachDialMembershipAssociations(ctx, tx, membership); err != nil {
+		return membership, err
+	}
+
+	// The owner role is tied to the dial itself so it cannot be moved by role.
+	if membership.Role == wtf.DialMembershipRoleOwner || role == wtf.DialMembershipRoleOwner {
+		return membership, wtf.Errorf(wtf.ECONFLICT, "Dial ownership cannot be changed by setting a role.")
+	} else if !wtf.CanEditDialMembershipRole(ctx, membership, role) {
+		return membership, wtf.Errorf(wtf.EUNAUTHORIZED, "You do not have permission to change the membership role.")
+	}
+
+	// Exit if role did not change.
+	if membership.Role == role {
+		return membership, nil
+	}
+	membership.Role = role
+
+	// Perform basic field validation.
+	if err := membership.Validate(); err != nil {
+		return membership, err
+	}
+
+	// Replace stored record with a copy of the new state.
+	other := *membership
+	other.Dial, other.User = nil, nil
+	tx.memberships[id] = &other
+
+	// Ensure computed dial value is up to date as viewers are not counted.
+	if err := refreshDialValue(ctx, tx, membership.DialID); err != nil {
+		return membership, fmt.Errorf("refresh dial value: %w", err)
+	}
+	return membership, nil
+}
+
+// dialMembershipValue represents a historical value of a membership along with
+// the note given for the change.
+type dialMembershipValue struct {
+	Timestamp time.Time
+	Value     int
+	Note      string
+}
+
+// insertDialMembershipValue records a membership value & note at a specific
+// point in time. Unlike dial values, every change is kept so no notes are lost.
+func insertDialMembershipValue(tx *Tx, id, value int, note string, timestamp time.Time) {
+	// Copy values so that the original slice is not modified.
+	values := make([]dialMembershipValue, len(tx.membershipValues[id]), len(tx.membershipValues[id])+1)
+	copy(values, tx.membershipValues[id])
+	tx.membershipValues[id] = append(values, dialMembershipValue{Timestamp: timestamp, Value: value, Note: note})
+}
+
+// findDialMembershipValueHistory returns the value history of a membership
+// without notes so it can be used to compute value slots.
+func findDialMembershipValueHistory(tx *Tx, id int) []dialValue {
+	history := make([]dialValue, len(tx.membershipValues[id]))
+	for i, v := range tx.membershipValues[id] {
+		history[i] = dialValue{Timestamp: v.Timestamp, Value: v.Value}
+	}
+	return history
+}
+
+// deleteDialMembership permanently deletes a membership and updates the dial value.
+func deleteDialMembership(ctx context.Context, tx *Tx, id int) error {
+	// Fetch user ID of currently logged in user.
+	userID := wtf.UserIDFromContext(ctx)
+
+	// Verify object exists and fetch associations.
+	membership, err := findDialMembershipByID(ctx, tx, id)
+	if err != nil {
+		return err
+	} else if err := attachDialMembershipAssociations(ctx, tx, membership); err != nil {
+		return err
+	}
+
+	// Do not allow dial owner to delete their own membership.
+	if membership.Role == wtf.DialMembershipRoleOwner && membership.UserID == userID {
+		return wtf.Errorf(wtf.ECONFLICT, "Dial owner may not delete their own membership.")
+	}
+
+	// Verify user owns membership or manages the parent dial.
+	if !wtf.CanDeleteDialMembership(ctx, membership) {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You do not have permission to delete the dial membership.")
+	}
+
+	delete(tx.memberships, id)
+	delete(tx.membershipValues, id)
+	removeIncomingWebhooks(tx, id)
+
+	// Ensure computed dial value is up to date.
+	if err := refreshDialValue(ctx, tx, membership.DialID); err != nil {
+		return fmt.Errorf("refresh dial value: %w", err)
+	}
+	return nil
+}
+
+// attachDialMembershipAssociations attaches the parent dial & user to the membership.
+func attachDialMembershipAssociations(ctx context.Context, tx *Tx, membership *wtf.DialMembership) (err error) {
+	if membership.Dial, err = findDialByID(ctx, tx, membership.DialID); err != nil {
+		return fmt.Errorf("attach membership dial: %w", err)
+	} else if membership.User, err = findUserByID(ctx, tx, membership.UserID); err != nil {
+		return fmt.Errorf("attach membership user: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package inmem_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+	"github.com/benbjohnson/wtf/inmem"
+	"github.com/benbjohnson/wtf/wtftest"
+)
+
+func TestDialService_DialValues(t *testing.T) {
+	// Ensure historical values are stored with a resolution of 1 minute.
+	t.Run("Rollup", func(t *testing.T) {
+		db, s := MustOpenServices(t)
+
+		db.Now = func() time.Time {
+			return time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		}
+
+		ctx := context.Background()
+		_, ctx0 := wtftest.MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		dial0 := wtftest.MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL0"})
+		membership0 := wtftest.MustFindDialMembershipByID(t, ctx0, s, 1)
+
+		// Update value after one minute.
+		db.Now = func() time.Time {
+			return time.Date(2000, time.January, 1, 0, 1, 0, 0, time.UTC)
+		}
+		wtftest.MustSetDialMembershipValue(t, ctx0, s, membership0.ID, 50)
+		wtftest.MustSetDialMembershipValue(t, ctx0, s, membership0.ID, 60)
+
+		// Update value after 1m30s.
+		db.Now = func() time.Time {
+			return time.Date(2000, time.January, 1, 0, 1, 30, 0, time.UTC)
+		}
+		wtftest.MustSetDialMembershipValue(t, ctx0, s, membership0.ID, 10)
+
+		// Update value after 5 minutes.
+		db.Now = func() time.Time {
+			return time.Date(2000, time.January, 1, 0, 5, 0, 0, time.UTC)
+		}
+		wtftest.MustSetDialMembershipValue(t, ctx0, s, membership0.ID, 100)
+
+		// Ensure only 3 values are stored.
+		if values, err := inmem.NewDialService(db).DialValues(ctx, dial0.ID); err != nil {
+			t.Fatal(err)
+		} else if got, want := values, []int{0, 10, 100}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("DialValues()=%#v, want %#v", got, want)
+		}
+	})
+}
@@ -0,0 +1,220 @@
+package inmem
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/benbjohnson/wtf"
+)
+
+// Ensure service implements interface.
+var _ wtf.IncomingWebhookService = (*IncomingWebhookService)(nil)
+
+// IncomingWebhookService represents a service for managing incoming webhooks
+// in memory.
+type IncomingWebhookService struct {
+	db *DB
+}
+
+// NewIncomingWebhookService returns a new instance of IncomingWebhookService.
+func NewIncomingWebhookService(db *DB) *IncomingWebhookService {
+	return &IncomingWebhookService{db: db}
+}
+
+// incomingWebhook represents a stored webhook along with the hash of its
+// token. The plaintext token is never stored.
+type incomingWebhook struct {
+	wtf.IncomingWebhook
+	hash string
+}
+
+// FindIncomingWebhooks retrieves a list of the current user's incoming
+// webhooks by filter. Also returns a count of total matching webhooks which
+// may differ if "Limit" is specified on the filter.
+func (s *IncomingWebhookService) FindIncomingWebhooks(ctx context.Context, filter wtf.IncomingWebhookFilter) ([]*wtf.IncomingWebhook, int, error) {
+	tx, err := s.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+	return findIncomingWebhooks(ctx, tx, filter)
+}
+
+// CreateIncomingWebhook creates a new incoming webhook for the current user's
+// membership on a dial. Returns ENOTFOUND if the user is not a member of the
+// dial. Returns EUNAUTHORIZED if the user is a viewer.
+func (s *IncomingWebhookService) CreateIncomingWebhook(ctx context.Context, webhook *wtf.IncomingWebhook) error {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := createIncomingWebhook(ctx, tx, webhook); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteIncomingWebhook permanently revokes an incoming webhook. Returns
+// ENOTFOUND if the webhook does not exist or belongs to another user.
+func (s *IncomingWebhookService) DeleteIncomingWebhook(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Verify the webhook exists & belongs to the current user.
+	if webhooks, _, err := findIncomingWebhooks(ctx, tx, wtf.IncomingWebhookFilter{ID: &id}); err != nil {
+		return err
+	} else if len(webhooks) == 0 {
+		return &wtf.Error{Code: wtf.ENOTFOUND, Message: "Incoming webhook not found."}
+	}
+
+	delete(tx.incomingWebhooks, id)
+	return tx.Commit()
+}
+
+// InvokeIncomingWebhook sets the value of the webhook's membership on behalf
+// of its user. Returns ENOTFOUND if the token does not exist.
+func (s *IncomingWebhookService) InvokeIncomingWebhook(ctx context.Context, token string, upd wtf.IncomingWebhookUpdate) (*wtf.DialMembership, error) {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	membership, err := invokeIncomingWebhook(ctx, tx, token, upd)
+	if err != nil {
+		return nil, err
+	}
+	return membership, tx.Commit()
+}
+
+// findIncomingWebhooks returns a list of the current user's incoming webhooks.
+// Also returns a count of total matching webhooks.
+func findIncomingWebhooks(ctx context.Context, tx *Tx, filter wtf.IncomingWebhookFilter) (_ []*wtf.IncomingWebhook, n int, err error) {
+	userID := wtf.UserIDFromContext(ctx)
+
+	webhooks := make([]*wtf.IncomingWebhook, 0)
+	for _, webhook := range tx.incomingWebhooks {
+		if webhook.UserID != userID {
+			continue
+		} else if v := filter.ID; v != nil && webhook.ID != *v {
+			continue
+		} else if v := filter.DialID; v != nil && webhook.DialID != *v {
+			continue
+		}
+
+		other := webhook.IncomingWebhook
+		webhooks = append(webhooks, &other)
+	}
+
+	// Sort by ID & restrict to the requested range.
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+	n = len(webhooks)
+	start, end := applyLimitOffset(n, filter.Limit, filter.Offset)
+	return webhooks[start:end], n, nil
+}
+
+// createIncomingWebhook creates a new incoming webhook on the current user's
+// membership of the dial.
+func createIncomingWebhook(ctx context.Context, tx *Tx, webhook *wtf.IncomingWebhook) error {
+	// Perform basic field validation.
+	if err := webhook.Validate(); err != nil {
+		return err
+	}
+
+	// Find the user's membership on the dial. Viewers cannot set a value so
+	// they cannot create a webhook to set one either.
+	userID := wtf.UserIDFromContext(ctx)
+	memberships, _, err := findDialMemberships(ctx, tx, wtf.DialMembershipFilter{DialID: &webhook.DialID, UserID: &userID})
+	if err != nil {
+		return err
+	} else if len(memberships) == 0 {
+		return wtf.Errorf(wtf.ENOTFOUND, "User is not a member of this dial.")
+	} else if !wtf.CanEditDialMembership(ctx, memberships[0]) {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "Viewers cannot create incoming webhooks.")
+	}
+	webhook.DialMembershipID = memberships[0].ID
+	webhook.UserID = userID
+
+	// Generate a random token to identify the webhook.
+	token, err := generateInviteCode()
+	if err != nil {
+		return err
+	}
+	webhook.Token = token
+	webhook.Prefix = token[:wtf.IncomingWebhookDisplayPrefixLen]
+	webhook.LastUsedAt = nil
+	webhook.CreatedAt = tx.now
+
+	// Assign the next ID & store a copy without the plaintext token.
+	tx.seq.incomingWebhook++
+	webhook.ID = tx.seq.incomingWebhook
+
+	other := &incomingWebhook{IncomingWebhook: *webhook, hash: wtf.HashIncomingWebhookToken(token)}
+	other.Token = ""
+	tx.incomingWebhooks[webhook.ID] = other
+
+	return nil
+}
+
+// removeIncomingWebhooks removes all incoming webhooks for a membership.
+func removeIncomingWebhooks(tx *Tx, membershipID int) {
+	for _, webhook := range tx.incomingWebhooks {
+		if webhook.DialMembershipID == membershipID {
+			delete(tx.incomingWebhooks, webhook.ID)
+		}
+	}
+}
+
+// invokeIncomingWebhook updates the value of the membership associated with
+// token. The update is performed as the webhook's user so the same
+// permissions apply as when the user sets the value themselves. The dial &
+// user are not attached as the caller only holds the token.
+func invokeIncomingWebhook(ctx context.Context, tx *Tx, token string, upd wtf.IncomingWebhookUpdate) (*wtf.DialMembership, error) {
+	if err := upd.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Look up webhook by the hash of its token.
+	hash := wtf.HashIncomingWebhookToken(token)
+	var webhook *incomingWebhook
+	for _, v := range tx.incomingWebhooks {
+		if v.hash == hash {
+			webhook = v
+			break
+		}
+	}
+	if webhook == nil {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "Incoming webhook not found."}
+	}
+
+	// Act on behalf of the webhook's user.
+	user, err := findUserByID(ctx, tx, webhook.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("find incoming webhook user: %w", err)
+	}
+	ctx = wtf.NewContextWithUser(ctx, user)
+
+	// Compute the new value from the current value & update the membership.
+	membership, err := findDialMembershipByID(ctx, tx, webhook.DialMembershipID)
+	if err != nil {
+		return nil, err
+	}
+	value := upd.Apply(membership.Value)
+	if membership, err = updateDialMembership(ctx, tx, membership.ID, wtf.DialMembershipUpdate{Value: &value, Note: upd.Note}); err != nil {
+		return nil, err
+	}
+
+	// Record when the webhook was last used.
+	lastUsedAt := tx.now
+	other := *webhook
+	other.LastUsedAt = &lastUsedAt
+	tx.incomingWebhooks[webhook.ID] = &other
+
+	return membership, nil
+}
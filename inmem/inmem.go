@@ -0,0 +1,279 @@
+package inmem
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+)
+
+// DB represents an in-memory data store. It mirrors the behavior of the SQLite
+// implementation but requires no external dependencies so it is useful for
+// unit tests and throwaway demo servers.
+//
+// All writes occur against a copy of the data which is swapped in on commit.
+// This gives us the same all-or-nothing semantics as a SQL transaction without
+// needing to write undo logic for every operation.
+type DB struct {
+	mu   sync.RWMutex
+	data *data
+
+	// Destination for events to be published.
+	EventService wtf.EventService
+
+	// Returns the current time. Defaults to time.Now().
+	// Can be mocked for tests.
+	Now func() time.Time
+}
+
+// NewDB returns a new, empty instance of DB.
+func NewDB() *DB {
+	return &DB{
+		data: newData(),
+		Now:  time.Now,
+
+		EventService: wtf.NopEventService(),
+	}
+}
+
+// BeginTx starts a transaction and returns a wrapper Tx type. Read-only
+// transactions share a read lock while writable transactions hold an
+// exclusive lock until the transaction is committed or rolled back.
+//
+// Similar to the SQLite implementation, the transaction provides a fixed
+// timestamp at the start of the transaction.
+func (db *DB) BeginTx(ctx context.Context, writable bool) (*Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	tx := &Tx{
+		db:       db,
+		writable: writable,
+		now:      db.Now().UTC().Truncate(time.Second),
+	}
+
+	// Writable transactions operate on a copy of the data so that the changes
+	// can be discarded if the transaction is rolled back.
+	if writable {
+		db.mu.Lock()
+		tx.data = db.data.clone()
+	} else {
+		db.mu.RLock()
+		tx.data = db.data
+	}
+	return tx, nil
+}
+
+// Tx represents a transaction against the in-memory data store.
+type Tx struct {
+	*data
+	db       *DB
+	writable bool
+	done     bool
+	now      time.Time
+}
+
+// Commit saves the transaction's changes to the database & releases the lock.
+func (tx *Tx) Commit() error {
+	if tx.done {
+		return nil
+	}
+	tx.done = true
+
+	if tx.writable {
+		tx.db.data = tx.data
+		tx.db.mu.Unlock()
+	} else {
+		tx.db.mu.RUnlock()
+	}
+	return nil
+}
+
+// Rollback discards the transaction's changes & releases the lock. This is a
+// no-op if the transaction has already been committed.
+func (tx *Tx) Rollback() error {
+	if tx.done {
+		return nil
+	}
+	tx.done = true
+
+	if tx.writable {
+		tx.db.mu.Unlock()
+	} else {
+		tx.db.mu.RUnlock()
+	}
+	return nil
+}
+
+// data holds the actual records for the database. Records are never modified
+// in place. Instead, updated records are copied & reassigned so that a shallow
+// clone of the maps is enough to isolate a transaction.
+type data struct {
+	users       map[int]*wtf.User
+	auths       map[int]*wtf.Auth
+	dials       map[int]*wtf.Dial
+	memberships map[int]*wtf.DialMembership
+
+	// Historical dial values by dial ID. Sorted by timestamp.
+	dialValues map[int][]dialValue
+
+	// Historical membership values by membership ID. Sorted by timestamp.
+	membershipValues map[int][]dialMembershipValue
+
+	// Alert rules & their history of alerts.
+	alertRules map[int]*wtf.AlertRule
+	alerts     map[int]*wtf.Alert
+
+	// Webhooks & their queue of deliveries.
+	webhooks          map[int]*wtf.Webhook
+	webhookDeliveries map[int]*wtf.WebhookDelivery
+
+	// Tokens for setting membership values from automated tools.
+	incomingWebhooks map[int]*incomingWebhook
+
+	// Named API tokens by ID. Tokens are looked up by the hash of their value.
+	apiTokens map[int]*apiToken
+
+	// Browser sessions by ID.
+	sessions map[int]*wtf.Session
+
+	// Emailed login tokens. Tokens are looked up by the hash of their value.
+	loginTokens map[int]*loginToken
+
+	// Organizations & their members.
+	organizations       map[int]*wtf.Organization
+	organizationMembers map[int]*wtf.OrganizationMember
+
+	// Autoincrement sequences for each record type.
+	seq struct {
+		user       int
+		auth       int
+		dial       int
+		membership int
+		alertRule  int
+		alert      int
+
+		webhook         int
+		webhookDelivery int
+		incomingWebhook int
+		apiToken        int
+		session         int
+		loginToken      int
+
+		organization       int
+		organizationMember int
+	}
+}
+
+// newData returns a new, empty instance of data.
+func newData() *data {
+	return &data{
+		users:       make(map[int]*wtf.User),
+		auths:       make(map[int]*wtf.Auth),
+		dials:       make(map[int]*wtf.Dial),
+		memberships: make(map[int]*wtf.DialMembership),
+		dialValues:  make(map[int][]dialValue),
+		alertRules:  make(map[int]*wtf.AlertRule),
+		alerts:      make(map[int]*wtf.Alert),
+		webhooks:    make(map[int]*wtf.Webhook),
+
+		membershipValues:  make(map[int][]dialMembershipValue),
+		webhookDeliveries: make(map[int]*wtf.WebhookDelivery),
+		incomingWebhooks:  make(map[int]*incomingWebhook),
+		apiTokens:         make(map[int]*apiToken),
+		sessions:          make(map[int]*wtf.Session),
+		loginTokens:       make(map[int]*loginToken),
+
+		organizations:       make(map[int]*wtf.Organization),
+		organizationMembers: make(map[int]*wtf.OrganizationMember),
+	}
+}
+
+// clone returns a shallow copy of the data.
+func (d *data) clone() *data {
+	other := &data{
+		users:       make(map[int]*wtf.User, len(d.users)),
+		auths:       make(map[int]*wtf.Auth, len(d.auths)),
+		dials:       make(map[int]*wtf.Dial, len(d.dials)),
+		memberships: make(map[int]*wtf.DialMembership, len(d.memberships)),
+		dialValues:  make(map[int][]dialValue, len(d.dialValues)),
+		alertRules:  make(map[int]*wtf.AlertRule, len(d.alertRules)),
+		alerts:      make(map[int]*wtf.Alert, len(d.alerts)),
+		webhooks:    make(map[int]*wtf.Webhook, len(d.webhooks)),
+		seq:         d.seq,
+
+		membershipValues:  make(map[int][]dialMembershipValue, len(d.membershipValues)),
+		webhookDeliveries: make(map[int]*wtf.WebhookDelivery, len(d.webhookDeliveries)),
+		incomingWebhooks:  make(map[int]*incomingWebhook, len(d.incomingWebhooks)),
+		apiTokens:         make(map[int]*apiToken, len(d.apiTokens)),
+		sessions:          make(map[int]*wtf.Session, len(d.sessions)),
+		loginTokens:       make(map[int]*loginToken, len(d.loginTokens)),
+
+		organizations:       make(map[int]*wtf.Organization, len(d.organizations)),
+		organizationMembers: make(map[int]*wtf.OrganizationMember, len(d.organizationMembers)),
+	}
+	for k, v := range d.users {
+		other.users[k] = v
+	}
+	for k, v := range d.auths {
+		other.auths[k] = v
+	}
+	for k, v := range d.dials {
+		other.dials[k] = v
+	}
+	for k, v := range d.memberships {
+		other.memberships[k] = v
+	}
+	for k, v := range d.dialValues {
+		other.dialValues[k] = v
+	}
+	for k, v := range d.membershipValues {
+		other.membershipValues[k] = v
+	}
+	for k, v := range d.alertRules {
+		other.alertRules[k] = v
+	}
+	for k, v := range d.alerts {
+		other.alerts[k] = v
+	}
+	for k, v := range d.webhooks {
+		other.webhooks[k] = v
+	}
+	for k, v := range d.webhookDeliveries {
+		other.webhookDeliveries[k] = v
+	}
+	for k, v := range d.incomingWebhooks {
+		other.incomingWebhooks[k] = v
+	}
+	for k, v := range d.apiTokens {
+		other.apiTokens[k] = v
+	}
+	for k, v := range d.sessions {
+		other.sessions[k] = v
+	}
+	for k, v := range d.loginTokens {
+		other.loginTokens[k] = v
+	}
+	for k, v := range d.organizations {
+		other.organizations[k] = v
+	}
+	for k, v := range d.organizationMembers {
+		other.organizationMembers[k] = v
+	}
+	return other
+}
+
+// applyLimitOffset returns the subset of n items after applying limit & offset.
+// Returns the start & end indices to slice the results with.
+func applyLimitOffset(n, limit, offset int) (start, end int) {
+	if offset > n {
+		offset = n
+	}
+	start, end = offset, n
+	if limit > 0 && start+limit < end {
+		end = start + limit
+	}
+	return start, end
+}
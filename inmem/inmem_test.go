@@ -0,0 +1,42 @@
+package inmem_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/wtf/inmem"
+	"github.com/benbjohnson/wtf/wtftest"
+)
+
+// Ensure the in-memory implementation passes the shared service test suite.
+func TestServices(t *testing.T) {
+	wtftest.Run(t, func(tb testing.TB) *wtftest.Services {
+		_, s := MustOpenServices(tb)
+		return s
+	})
+}
+
+// MustOpenServices returns a new, empty DB and the services attached to it.
+func MustOpenServices(tb testing.TB) (*inmem.DB, *wtftest.Services) {
+	tb.Helper()
+
+	db := inmem.NewDB()
+	db.EventService = inmem.NewEventService()
+
+	return db, &wtftest.Services{
+		AlertService:           inmem.NewAlertService(db),
+		APITokenService:        inmem.NewAPITokenService(db),
+		AuthService:            inmem.NewAuthService(db),
+		DialService:            inmem.NewDialService(db),
+		DialMembershipService:  inmem.NewDialMembershipService(db),
+		IncomingWebhookService: inmem.NewIncomingWebhookService(db),
+		LoginTokenService:      inmem.NewLoginTokenService(db),
+		OrganizationService:    inmem.NewOrganizationService(db),
+		SearchService:          inmem.NewSearchService(db),
+		SessionService:         inmem.NewSessionService(db),
+		UserService:            inmem.NewUserService(db),
+		WebhookService:         inmem.NewWebhookService(db),
+		EventService:           db.EventService,
+		SetNow:                 func(fn func() time.Time) { db.Now = fn },
+	}
+}
@@ -0,0 +1,122 @@
+package inmem
+
+import (
+	"context"
+	"strings"
+
+	"github.com/benbjohnson/wtf"
+)
+
+// Ensure service implements interface.
+var _ wtf.LoginTokenService = (*LoginTokenService)(nil)
+
+// LoginTokenService represents a service for managing email login tokens in memory.
+type LoginTokenService struct {
+	db *DB
+}
+
+// NewLoginTokenService returns a new instance of LoginTokenService.
+func NewLoginTokenService(db *DB) *LoginTokenService {
+	return &LoginTokenService{db: db}
+}
+
+// loginToken represents a stored token along with the hash of its plaintext
+// value. The plaintext token is never stored.
+type loginToken struct {
+	wtf.LoginToken
+	hash string
+}
+
+// CreateLoginToken creates a new single-use token for token.Email. The
+// plaintext token is set on token.Token & only its hash is stored.
+func (s *LoginTokenService) CreateLoginToken(ctx context.Context, token *wtf.LoginToken) error {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := createLoginToken(ctx, tx, token); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RedeemLoginToken looks up a token by its plaintext value & marks it as used.
+// Returns ENOTFOUND if the token does not exist or EUNAUTHORIZED if the token
+// has expired or has already been used.
+func (s *LoginTokenService) RedeemLoginToken(ctx context.Context, token string) (*wtf.LoginToken, error) {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	loginToken, err := redeemLoginToken(ctx, tx, token)
+	if err != nil {
+		return nil, err
+	}
+	return loginToken, tx.Commit()
+}
+
+// createLoginToken generates a new token for an email address & stores its hash.
+func createLoginToken(ctx context.Context, tx *Tx, token *wtf.LoginToken) error {
+	token.Email = strings.TrimSpace(token.Email)
+
+	// Perform basic field validation.
+	if err := token.Validate(); err != nil {
+		return err
+	}
+
+	// Generate a random token.
+	code, err := generateInviteCode()
+	if err != nil {
+		return err
+	}
+	token.Token = code
+	token.ExpiresAt = tx.now.Add(wtf.LoginTokenDuration)
+	token.UsedAt = nil
+	token.CreatedAt = tx.now
+
+	// Assign the next ID & store a copy without the plaintext token.
+	tx.seq.loginToken++
+	token.ID = tx.seq.loginToken
+
+	other := &loginToken{LoginToken: *token, hash: wtf.HashLoginToken(token.Token)}
+	other.Token = ""
+	tx.loginTokens[token.ID] = other
+
+	return nil
+}
+
+// redeemLoginToken looks up a token by the hash of its plaintext value and
+// records the time it was used so it cannot be used again.
+func redeemLoginToken(ctx context.Context, tx *Tx, token string) (*wtf.LoginToken, error) {
+	hash := wtf.HashLoginToken(token)
+
+	// Look up token by hash.
+	var stored *loginToken
+	for _, v := range tx.loginTokens {
+		if v.hash == hash {
+			stored = v
+			break
+		}
+	}
+	if stored == nil {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "Login token not found."}
+	} else if stored.UsedAt != nil {
+		return nil, wtf.Errorf(wtf.EUNAUTHORIZED, "Login token already used.")
+	} else if stored.IsExpired(tx.now) {
+		return nil, wtf.Errorf(wtf.EUNAUTHORIZED, "Login token expired.")
+	}
+
+	// Mark the token as used.
+	usedAt := tx.now
+	other := *stored
+	other.UsedAt = &usedAt
+	tx.loginTokens[other.ID] = &other
+
+	// Return a copy of the token.
+	loginToken := other.LoginToken
+	return &loginToken, nil
+}
@@ -0,0 +1,433 @@
+package inmem
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/benbjohnson/wtf"
+)
+
+// Ensure service implements interface.
+var _ wtf.OrganizationService = (*OrganizationService)(nil)
+
+// OrganizationService represents a service for managing organizations in memory.
+type OrganizationService struct {
+	db *DB
+}
+
+// NewOrganizationService returns a new instance of OrganizationService.
+func NewOrganizationService(db *DB) *OrganizationService {
+	return &OrganizationService{db: db}
+}
+
+// FindOrganizationByID retrieves a single organization by ID along with its
+// members. Returns ENOTFOUND if the organization does not exist or the user
+// is not a member.
+func (s *OrganizationService) FindOrganizationByID(ctx context.Context, id int) (*wtf.Organization, error) {
+	tx, err := s.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	org, err := findOrganizationByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	} else if err := attachOrganizationAssociations(ctx, tx, org); err != nil {
+		return nil, err
+	} else if org.Members, err = findOrganizationMembers(ctx, tx, org.ID); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// FindOrganizations retrieves a list of organizations based on a filter. Only
+// returns organizations the user is a member of unless searching by invite code.
+func (s *OrganizationService) FindOrganizations(ctx context.Context, filter wtf.OrganizationFilter) ([]*wtf.Organization, int, error) {
+	tx, err := s.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	orgs, n, err := findOrganizations(ctx, tx, filter)
+	if err != nil {
+		return orgs, n, err
+	}
+	for _, org := range orgs {
+		if err := attachOrganizationAssociations(ctx, tx, org); err != nil {
+			return orgs, n, err
+		}
+	}
+	return orgs, n, nil
+}
+
+// CreateOrganization creates a new organization and assigns the current user
+// as the owner. The owner is automatically added as a member.
+func (s *OrganizationService) CreateOrganization(ctx context.Context, org *wtf.Organization) error {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := createOrganization(ctx, tx, org); err != nil {
+		return err
+	} else if err := attachOrganizationAssociations(ctx, tx, org); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateOrganization updates an existing organization by ID. Only the owner
+// can update an organization.
+func (s *OrganizationService) UpdateOrganization(ctx context.Context, id int, upd wtf.OrganizationUpdate) (*wtf.Organization, error) {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	org, err := updateOrganization(ctx, tx, id, upd)
+	if err != nil {
+		return org, err
+	} else if err := attachOrganizationAssociations(ctx, tx, org); err != nil {
+		return org, err
+	}
+	return org, tx.Commit()
+}
+
+// DeleteOrganization permanently removes an organization by ID. Dials within
+// the organization are kept but are no longer associated with it.
+func (s *OrganizationService) DeleteOrganization(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteOrganization(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateOrganizationMember adds the current user to an organization. The
+// member's invite code must match the organization's invite code.
+func (s *OrganizationService) CreateOrganizationMember(ctx context.Context, member *wtf.OrganizationMember) error {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Ensure user is logged in & assign membership to current user.
+	userID := wtf.UserIDFromContext(ctx)
+	if userID == 0 {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You must be logged in to join an organization.")
+	}
+	member.UserID = userID
+	member.Role = wtf.OrganizationRoleMember
+
+	// Verify the invite code against the organization.
+	if org, ok := tx.organizations[member.OrganizationID]; !ok {
+		return &wtf.Error{Code: wtf.ENOTFOUND, Message: "Organization not found."}
+	} else if member.InviteCode == "" || member.InviteCode != org.InviteCode {
+		return wtf.Errorf(wtf.EINVALID, "Invalid invite code.")
+	}
+
+	if err := createOrganizationMember(ctx, tx, member); err != nil {
+		return err
+	} else if member.User, err = findUserByID(ctx, tx, member.UserID); err != nil {
+		return err
+	} else if member.Organization, err = findOrganizationByID(ctx, tx, member.OrganizationID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteOrganizationMember removes a member from an organization by ID.
+// Members can leave an organization & the owner can remove other members.
+func (s *OrganizationService) DeleteOrganizationMember(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteOrganizationMember(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// findOrganizationByID is a helper function to return an organization by ID.
+// Returns ENOTFOUND if the organization does not exist or the user is not a member.
+func findOrganizationByID(ctx context.Context, tx *Tx, id int) (*wtf.Organization, error) {
+	orgs, _, err := findOrganizations(ctx, tx, wtf.OrganizationFilter{ID: &id})
+	if err != nil {
+		return nil, err
+	} else if len(orgs) == 0 {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "Organization not found."}
+	}
+	return orgs[0], nil
+}
+
+// lookupOrganization returns an organization by ID without checking that the
+// current user is a member. The invite code is not returned.
+func lookupOrganization(tx *Tx, id int) (*wtf.Organization, error) {
+	org, ok := tx.organizations[id]
+	if !ok {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "Organization not found."}
+	}
+	other := *org
+	other.InviteCode = ""
+	return &other, nil
+}
+
+// findOrganizations retrieves a list of matching organizations. Also returns a
+// total matching count which may different from the number of results if
+// filter.Limit is set.
+func findOrganizations(ctx context.Context, tx *Tx, filter wtf.OrganizationFilter) (_ []*wtf.Organization, n int, err error) {
+	userID := wtf.UserIDFromContext(ctx)
+
+	orgs := make([]*wtf.Organization, 0)
+	for _, org := range tx.organizations {
+		if v := filter.ID; v != nil && org.ID != *v {
+			continue
+		}
+
+		// Limit to organizations user is a member of unless searching by invite code.
+		role := organizationMemberRole(tx, org.ID, userID)
+		if v := filter.InviteCode; v != nil {
+			if org.InviteCode != *v {
+				continue
+			}
+		} else if role == "" {
+			continue
+		}
+
+		other := *org
+		other.Role = role
+
+		// Omit the invite code if the current API token cannot read it.
+		if !wtf.CanViewInviteCodes(ctx) {
+			other.InviteCode = ""
+		}
+
+		orgs = append(orgs, &other)
+	}
+
+	// Sort by name & restrict to the requested range.
+	sort.Slice(orgs, func(i, j int) bool {
+		if orgs[i].Name != orgs[j].Name {
+			return orgs[i].Name < orgs[j].Name
+		}
+		return orgs[i].ID < orgs[j].ID
+	})
+	n = len(orgs)
+	start, end := applyLimitOffset(n, filter.Limit, filter.Offset)
+	return orgs[start:end], n, nil
+}
+
+// isOrganizationMember returns true if the user is a member of the organization.
+func isOrganizationMember(tx *Tx, organizationID, userID int) bool {
+	return organizationID != 0 && organizationMemberRole(tx, organizationID, userID) != ""
+}
+
+// organizationMemberRole returns the role of the user within the organization.
+// Returns a blank string if the user is not a member.
+func organizationMemberRole(tx *Tx, organizationID, userID int) string {
+	for _, member := range tx.organizationMembers {
+		if member.OrganizationID == organizationID && member.UserID == userID {
+			return member.Role
+		}
+	}
+	return ""
+}
+
+// createOrganization creates a new organization & adds the owner as a member.
+func createOrganization(ctx context.Context, tx *Tx, org *wtf.Organization) error {
+	// Assign organization to the current user.
+	// Return an error if the user is not currently logged in.
+	userID := wtf.UserIDFromContext(ctx)
+	if userID == 0 {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You must be logged in to create an organization.")
+	}
+	org.UserID = userID
+
+	// Generate a random invite code.
+	inviteCode, err := generateInviteCode()
+	if err != nil {
+		return err
+	}
+	org.InviteCode = inviteCode
+
+	// Set timestamps to current time.
+	org.CreatedAt = tx.now
+	org.UpdatedAt = org.CreatedAt
+
+	// Perform basic field validation.
+	if err := org.Validate(); err != nil {
+		return err
+	}
+
+	tx.seq.organization++
+	org.ID = tx.seq.organization
+
+	other := *org
+	other.User, other.Members, other.Role = nil, nil, ""
+	tx.organizations[org.ID] = &other
+
+	// Add the owner as the first member of the organization.
+	if err := createOrganizationMember(ctx, tx, &wtf.OrganizationMember{
+		OrganizationID: org.ID,
+		UserID:         org.UserID,
+		Role:           wtf.OrganizationRoleOwner,
+	}); err != nil {
+		return fmt.Errorf("create owner membership: %w", err)
+	}
+	org.Role = wtf.OrganizationRoleOwner
+
+	return nil
+}
+
+// updateOrganization updates an organization by ID. Returns the new state of
+// the organization after update.
+func updateOrganization(ctx context.Context, tx *Tx, id int, upd wtf.OrganizationUpdate) (*wtf.Organization, error) {
+	// Fetch current object state. Return an error if user is not the owner.
+	org, err := findOrganizationByID(ctx, tx, id)
+	if err != nil {
+		return org, err
+	} else if !wtf.CanEditOrganization(ctx, org) {
+		return org, wtf.Errorf(wtf.EUNAUTHORIZED, "Only the owner can edit an organization.")
+	}
+
+	// Update fields, if set.
+	if v := upd.Name; v != nil {
+		org.Name = *v
+	}
+	org.UpdatedAt = tx.now
+
+	// Perform basic field validation.
+	if err := org.Validate(); err != nil {
+		return org, err
+	}
+
+	other := *org
+	other.Role = ""
+	tx.organizations[id] = &other
+
+	return org, nil
+}
+
+// deleteOrganization permanently removes an organization by ID.
+func deleteOrganization(ctx context.Context, tx *Tx, id int) error {
+	// Verify object exists & the current user is the owner.
+	if org, err := findOrganizationByID(ctx, tx, id); err != nil {
+		return err
+	} else if !wtf.CanEditOrganization(ctx, org) {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "Only the owner can delete an organization.")
+	}
+
+	removeOrganization(tx, id)
+	return nil
+}
+
+// removeOrganization removes an organization & its members. Dials within the
+// organization are kept but their organization is cleared.
+func removeOrganization(tx *Tx, id int) {
+	for _, member := range tx.organizationMembers {
+		if member.OrganizationID == id {
+			delete(tx.organizationMembers, member.ID)
+		}
+	}
+	for _, dial := range tx.dials {
+		if dial.OrganizationID == id {
+			other := *dial
+			other.OrganizationID = 0
+			tx.dials[dial.ID] = &other
+		}
+	}
+	delete(tx.organizations, id)
+}
+
+// findOrganizationMembers returns all members of an organization with their
+// users attached. The owner is listed first & then members by name.
+func findOrganizationMembers(ctx context.Context, tx *Tx, organizationID int) (_ []*wtf.OrganizationMember, err error) {
+	members := make([]*wtf.OrganizationMember, 0)
+	for _, member := range tx.organizationMembers {
+		if member.OrganizationID != organizationID {
+			continue
+		}
+
+		other := *member
+		if other.User, err = findUserByID(ctx, tx, member.UserID); err != nil {
+			return nil, fmt.Errorf("attach member user: %w", err)
+		}
+		members = append(members, &other)
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		if iOwner, jOwner := members[i].Role == wtf.OrganizationRoleOwner, members[j].Role == wtf.OrganizationRoleOwner; iOwner != jOwner {
+			return iOwner
+		}
+		return members[i].User.Name < members[j].User.Name
+	})
+	return members, nil
+}
+
+// createOrganizationMember inserts a new organization member. Returns
+// ECONFLICT if the user is already a member.
+func createOrganizationMember(ctx context.Context, tx *Tx, member *wtf.OrganizationMember) error {
+	if organizationMemberRole(tx, member.OrganizationID, member.UserID) != "" {
+		return wtf.Errorf(wtf.ECONFLICT, "You are already a member of this organization.")
+	}
+
+	member.CreatedAt = tx.now
+
+	tx.seq.organizationMember++
+	member.ID = tx.seq.organizationMember
+
+	other := *member
+	other.User, other.Organization, other.InviteCode = nil, nil, ""
+	tx.organizationMembers[member.ID] = &other
+
+	return nil
+}
+
+// deleteOrganizationMember permanently removes a member by ID. Returns
+// EUNAUTHORIZED if the current user cannot remove the member.
+func deleteOrganizationMember(ctx context.Context, tx *Tx, id int) error {
+	// Verify object exists & is visible to the current user.
+	stored, ok := tx.organizationMembers[id]
+	if !ok {
+		return &wtf.Error{Code: wtf.ENOTFOUND, Message: "Organization member not found."}
+	}
+	member := *stored
+
+	org, err := findOrganizationByID(ctx, tx, member.OrganizationID)
+	if wtf.ErrorCode(err) == wtf.ENOTFOUND {
+		return &wtf.Error{Code: wtf.ENOTFOUND, Message: "Organization member not found."}
+	} else if err != nil {
+		return err
+	}
+	member.Organization = org
+
+	if !wtf.CanDeleteOrganizationMember(ctx, &member) {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You do not have permission to remove this member.")
+	}
+
+	delete(tx.organizationMembers, id)
+	return nil
+}
+
+// attachOrganizationAssociations is a helper function to look up and attach
+// the owner user to the organization.
+func attachOrganizationAssociations(ctx context.Context, tx *Tx, org *wtf.Organization) (err error) {
+	if org.User, err = findUserByID(ctx, tx, org.UserID); err != nil {
+		return fmt.Errorf("attach organization user: %w", err)
+	}
+	return nil
+}
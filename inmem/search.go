@@ -0,0 +1,113 @@
+package inmem
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/benbjohnson/wtf"
+)
+
+// Ensure service implements interface.
+var _ wtf.SearchService = (*SearchService)(nil)
+
+// SearchService represents a service for full-text search across dials in
+// memory. Dials & memberships are scanned on each search instead of being
+// indexed.
+type SearchService struct {
+	db *DB
+}
+
+// NewSearchService returns a new instance of SearchService.
+func NewSearchService(db *DB) *SearchService {
+	return &SearchService{db: db}
+}
+
+// Search returns dials & memberships matching every term in the filter's
+// query. Results are ordered by dial & then by membership.
+func (s *SearchService) Search(ctx context.Context, filter wtf.SearchFilter) ([]*wtf.SearchResult, int, error) {
+	tx, err := s.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	results, n, err := search(ctx, tx, filter)
+	if err != nil {
+		return results, n, err
+	}
+
+	// Attach the dial to each result.
+	for _, result := range results {
+		if result.Dial, err = findDialByID(ctx, tx, result.DialID); err != nil {
+			return results, n, err
+		}
+	}
+	return results, n, nil
+}
+
+// search returns a list of dials & memberships matching the filter query.
+func search(ctx context.Context, tx *Tx, filter wtf.SearchFilter) (_ []*wtf.SearchResult, n int, err error) {
+	terms := wtf.SearchTerms(filter.Query)
+	if len(terms) == 0 {
+		return nil, 0, wtf.Errorf(wtf.EINVALID, "Search query required.")
+	}
+
+	// Limit dials to the same set returned by findDials() when looking up a
+	// single dial. Memberships are only visible to members of their dial.
+	userID := wtf.UserIDFromContext(ctx)
+	results := make([]*wtf.SearchResult, 0)
+	for _, dial := range tx.dials {
+		if !isDialMember(tx, dial.ID, userID) && !isOrganizationMember(tx, dial.OrganizationID, userID) {
+			continue
+		} else if matchSearchTerms(terms, dial.Name) {
+			results = append(results, &wtf.SearchResult{DialID: dial.ID, Name: dial.Name})
+		}
+	}
+
+	for _, membership := range tx.memberships {
+		user := tx.users[membership.UserID]
+		if user == nil || !isDialMember(tx, membership.DialID, userID) {
+			continue
+		} else if matchSearchTerms(terms, user.Name, membership.Note) {
+			results = append(results, &wtf.SearchResult{
+				DialID:           membership.DialID,
+				DialMembershipID: membership.ID,
+				Name:             user.Name,
+				Note:             membership.Note,
+			})
+		}
+	}
+
+	// Sort by dial & then membership. Dial names sort before memberships.
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].DialID != results[j].DialID {
+			return results[i].DialID < results[j].DialID
+		}
+		return results[i].DialMembershipID < results[j].DialMembershipID
+	})
+
+	// Restrict to the requested range.
+	n = len(results)
+	start, end := applyLimitOffset(n, filter.Limit, filter.Offset)
+	return results[start:end], n, nil
+}
+
+// matchSearchTerms returns true if every term is the prefix of a word in one
+// of the given fields.
+func matchSearchTerms(terms []string, fields ...string) bool {
+	words := wtf.SearchTerms(strings.Join(fields, " "))
+	for _, term := range terms {
+		var found bool
+		for _, word := range words {
+			if strings.HasPrefix(word, term) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
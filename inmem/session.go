@@ -0,0 +1,204 @@
+package inmem
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/benbjohnson/wtf"
+)
+
+// Ensure service implements interface.
+var _ wtf.SessionService = (*SessionService)(nil)
+
+// SessionService represents a service for managing sessions in memory.
+type SessionService struct {
+	db *DB
+}
+
+// NewSessionService returns a new instance of SessionService.
+func NewSessionService(db *DB) *SessionService {
+	return &SessionService{db: db}
+}
+
+// FindSessions retrieves a list of the current user's unexpired sessions by
+// filter. Also returns a count of total matching sessions which may differ if
+// "Limit" is specified on the filter.
+func (s *SessionService) FindSessions(ctx context.Context, filter wtf.SessionFilter) ([]*wtf.Session, int, error) {
+	tx, err := s.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+	return findSessions(ctx, tx, filter)
+}
+
+// CreateSession creates a new session for the current user.
+func (s *SessionService) CreateSession(ctx context.Context, session *wtf.Session) error {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := createSession(ctx, tx, session); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// AuthenticateSession looks up a session by ID, records its use & attaches
+// its user. Returns ENOTFOUND if the session does not exist or EUNAUTHORIZED
+// if the session has expired.
+func (s *SessionService) AuthenticateSession(ctx context.Context, id int) (*wtf.Session, error) {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	session, err := authenticateSession(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	return session, tx.Commit()
+}
+
+// DeleteSession revokes one of the current user's sessions. Returns ENOTFOUND
+// if the session does not exist or belongs to another user.
+func (s *SessionService) DeleteSession(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Verify the session exists & belongs to the current user.
+	if session := tx.sessions[id]; session == nil || session.UserID != wtf.UserIDFromContext(ctx) {
+		return &wtf.Error{Code: wtf.ENOTFOUND, Message: "Session not found."}
+	}
+
+	delete(tx.sessions, id)
+	return tx.Commit()
+}
+
+// DeleteAllSessions revokes all of the current user's sessions.
+func (s *SessionService) DeleteAllSessions(ctx context.Context) error {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	removeSessions(tx, func(session *wtf.Session) bool {
+		return session.UserID == wtf.UserIDFromContext(ctx)
+	})
+	return tx.Commit()
+}
+
+// findSessions returns a list of the current user's unexpired sessions. Also
+// returns a count of total matching sessions.
+func findSessions(ctx context.Context, tx *Tx, filter wtf.SessionFilter) (_ []*wtf.Session, n int, err error) {
+	userID := wtf.UserIDFromContext(ctx)
+
+	sessions := make([]*wtf.Session, 0)
+	for _, session := range tx.sessions {
+		if session.UserID != userID || session.IsExpired(tx.now) {
+			continue
+		} else if v := filter.ID; v != nil && session.ID != *v {
+			continue
+		}
+
+		other := *session
+		sessions = append(sessions, &other)
+	}
+
+	// Sort by most recently seen & restrict to the requested range.
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].LastSeenAt.Equal(sessions[j].LastSeenAt) {
+			return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+		}
+		return sessions[i].ID > sessions[j].ID
+	})
+	n = len(sessions)
+	start, end := applyLimitOffset(n, filter.Limit, filter.Offset)
+	return sessions[start:end], n, nil
+}
+
+// createSession creates a new session for the current user.
+func createSession(ctx context.Context, tx *Tx, session *wtf.Session) error {
+	userID := wtf.UserIDFromContext(ctx)
+	if userID == 0 {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You must be logged in to create a session.")
+	}
+
+	// Ensure the auth used to log in, if any, belongs to the user.
+	if session.AuthID != 0 {
+		if auth, err := findAuthByID(ctx, tx, session.AuthID); err != nil {
+			return err
+		} else if auth.UserID != userID {
+			return wtf.Errorf(wtf.EUNAUTHORIZED, "You are not allowed to create a session for this auth.")
+		}
+	}
+
+	// Remove expired sessions for all users so they do not accumulate.
+	removeSessions(tx, func(session *wtf.Session) bool {
+		return session.IsExpired(tx.now)
+	})
+
+	session.UserID = userID
+	session.User = nil
+	session.ExpiresAt = tx.now.Add(wtf.SessionDuration)
+	session.LastSeenAt = tx.now
+	session.CreatedAt = tx.now
+
+	// Assign the next ID & store a copy.
+	tx.seq.session++
+	session.ID = tx.seq.session
+
+	other := *session
+	tx.sessions[session.ID] = &other
+
+	return nil
+}
+
+// removeSessions removes all sessions which match fn. This mirrors the
+// ON DELETE CASCADE behavior of the SQLite implementation for users & auths.
+func removeSessions(tx *Tx, fn func(*wtf.Session) bool) {
+	for _, session := range tx.sessions {
+		if fn(session) {
+			delete(tx.sessions, session.ID)
+		}
+	}
+}
+
+// authenticateSession looks up a session by ID & updates the time it was last
+// seen if it has not been updated recently.
+func authenticateSession(ctx context.Context, tx *Tx, id int) (*wtf.Session, error) {
+	session := tx.sessions[id]
+	if session == nil {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "Session not found."}
+	} else if session.IsExpired(tx.now) {
+		return nil, wtf.Errorf(wtf.EUNAUTHORIZED, "Session expired.")
+	}
+
+	// Record when the session was last seen.
+	if tx.now.Sub(session.LastSeenAt) >= wtf.SessionLastSeenInterval {
+		updated := *session
+		updated.LastSeenAt = tx.now
+		tx.sessions[updated.ID] = &updated
+		session = &updated
+	}
+	other := *session
+
+	// Attach the session's user along with their auths to the returned copy.
+	user, err := findUserByID(ctx, tx, other.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("find session user: %w", err)
+	} else if err := attachUserAuths(ctx, tx, user); err != nil {
+		return nil, err
+	}
+	other.User = user
+
+	return &other, nil
+}
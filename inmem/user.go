@@ -0,0 +1,299 @@
+package inmem
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/benbjohnson/wtf"
+)
+
+// Ensure service implements interface.
+var _ wtf.UserService = (*UserService)(nil)
+
+// UserService represents a service for managing users in memory.
+type UserService struct {
+	db *DB
+}
+
+// NewUserService returns a new instance of UserService.
+func NewUserService(db *DB) *UserService {
+	return &UserService{db: db}
+}
+
+// FindUserByID retrieves a user by ID along with their associated auth objects.
+// Returns ENOTFOUND if user does not exist.
+func (s *UserService) FindUserByID(ctx context.Context, id int) (*wtf.User, error) {
+	tx, err := s.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Fetch user and their associated OAuth objects.
+	user, err := findUserByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	} else if err := attachUserAuths(ctx, tx, user); err != nil {
+		return user, err
+	}
+	return user, nil
+}
+
+// FindUsers retrieves a list of users by filter. Also returns total count of
+// matching users which may differ from returned results if filter.Limit is specified.
+func (s *UserService) FindUsers(ctx context.Context, filter wtf.UserFilter) ([]*wtf.User, int, error) {
+	tx, err := s.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+	return findUsers(ctx, tx, filter)
+}
+
+// CreateUser creates a new user. This is only used for testing since users are
+// typically created during the OAuth creation process in AuthService.CreateAuth().
+func (s *UserService) CreateUser(ctx context.Context, user *wtf.User) error {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Create a new user object and attach associated OAuth objects.
+	if err := createUser(ctx, tx, user); err != nil {
+		return err
+	} else if err := attachUserAuths(ctx, tx, user); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateUser updates a user object. Returns EUNAUTHORIZED if current user is
+// not the user that is being updated. Returns ENOTFOUND if user does not exist.
+func (s *UserService) UpdateUser(ctx context.Context, id int, upd wtf.UserUpdate) (*wtf.User, error) {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Update user & attach associated OAuth objects.
+	user, err := updateUser(ctx, tx, id, upd)
+	if err != nil {
+		return user, err
+	} else if err := attachUserAuths(ctx, tx, user); err != nil {
+		return user, err
+	} else if err := tx.Commit(); err != nil {
+		return user, err
+	}
+	return user, nil
+}
+
+// DeleteUser permanently deletes a user and all owned dials.
+// Returns EUNAUTHORIZED if current user is not the user being deleted.
+// Returns ENOTFOUND if user does not exist.
+func (s *UserService) DeleteUser(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteUser(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// findUserByID is a helper function to fetch a user by ID.
+// Returns ENOTFOUND if user does not exist.
+func findUserByID(ctx context.Context, tx *Tx, id int) (*wtf.User, error) {
+	a, _, err := findUsers(ctx, tx, wtf.UserFilter{ID: &id})
+	if err != nil {
+		return nil, err
+	} else if len(a) == 0 {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "User not found."}
+	}
+	return a[0], nil
+}
+
+// findUserByEmail is a helper function to fetch a user by email.
+// Returns ENOTFOUND if user does not exist.
+func findUserByEmail(ctx context.Context, tx *Tx, email string) (*wtf.User, error) {
+	a, _, err := findUsers(ctx, tx, wtf.UserFilter{Email: &email})
+	if err != nil {
+		return nil, err
+	} else if len(a) == 0 {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "User not found."}
+	}
+	return a[0], nil
+}
+
+// findUsers returns a list of users matching a filter. Also returns a count of
+// total matching users which may differ if filter.Limit is set.
+func findUsers(ctx context.Context, tx *Tx, filter wtf.UserFilter) (_ []*wtf.User, n int, err error) {
+	// Iterate over all users and only keep the ones that match every filter field.
+	users := make([]*wtf.User, 0)
+	for _, user := range tx.users {
+		if v := filter.ID; v != nil && user.ID != *v {
+			continue
+		} else if v := filter.Email; v != nil && (user.Email == "" || user.Email != *v) {
+			continue
+		}
+
+		// Return a copy so the caller cannot modify the stored record.
+		other := *user
+		users = append(users, &other)
+	}
+
+	// Sort by ID & restrict to the requested range.
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	n = len(users)
+	start, end := applyLimitOffset(n, filter.Limit, filter.Offset)
+	return users[start:end], n, nil
+}
+
+// createUser creates a new user. Sets the new ID to user.ID and sets the
+// timestamps to the current time.
+func createUser(ctx context.Context, tx *Tx, user *wtf.User) error {
+	// Set timestamps to the current time.
+	user.CreatedAt = tx.now
+	user.UpdatedAt = user.CreatedAt
+
+	// Perform basic field validation.
+	if err := user.Validate(); err != nil {
+		return err
+	}
+
+	// Email is unique if it is set.
+	if err := checkUserEmailAvailable(tx, 0, user.Email); err != nil {
+		return err
+	}
+
+	// Assign the next ID and store a copy of the user without associations.
+	tx.seq.user++
+	user.ID = tx.seq.user
+
+	other := *user
+	other.Auths = nil
+	tx.users[user.ID] = &other
+
+	return nil
+}
+
+// updateUser updates fields on a user object. Returns EUNAUTHORIZED if current
+// user is not the user being updated.
+func updateUser(ctx context.Context, tx *Tx, id int, upd wtf.UserUpdate) (*wtf.User, error) {
+	// Fetch current object state.
+	user, err := findUserByID(ctx, tx, id)
+	if err != nil {
+		return user, err
+	} else if user.ID != wtf.UserIDFromContext(ctx) {
+		return nil, wtf.Errorf(wtf.EUNAUTHORIZED, "You are not allowed to update this user.")
+	}
+
+	// Update fields.
+	if v := upd.Name; v != nil {
+		user.Name = *v
+	}
+	if v := upd.Timezone; v != nil {
+		user.Timezone = *v
+	}
+	if v := upd.AvatarSource; v != nil {
+		user.AvatarSource = *v
+	}
+
+	// Set last updated date to current time.
+	user.UpdatedAt = tx.now
+
+	// Perform basic field validation.
+	if err := user.Validate(); err != nil {
+		return user, err
+	}
+
+	// Ensure the preferred avatar is from one of the user's linked accounts.
+	if upd.AvatarSource != nil && user.AvatarSource != "" {
+		if _, n, err := findAuths(ctx, tx, wtf.AuthFilter{UserID: &user.ID, Source: &user.AvatarSource}); err != nil {
+			return user, err
+		} else if n == 0 {
+			return user, wtf.Errorf(wtf.EINVALID, "Avatar must be from a linked account.")
+		}
+	}
+
+	// Replace stored record with a copy of the new state.
+	other := *user
+	tx.users[id] = &other
+
+	return user, nil
+}
+
+// deleteUser permanently removes a user by ID. Returns EUNAUTHORIZED if current
+// user is not the one being deleted.
+//
+// Owned dials, memberships, & auths are removed as well. This mirrors the
+// ON DELETE CASCADE behavior of the SQLite implementation.
+func deleteUser(ctx context.Context, tx *Tx, id int) error {
+	// Verify object exists.
+	if user, err := findUserByID(ctx, tx, id); err != nil {
+		return err
+	} else if user.ID != wtf.UserIDFromContext(ctx) {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You are not allowed to delete this user.")
+	}
+
+	// Remove all dependent records.
+	for _, auth := range tx.auths {
+		if auth.UserID == id {
+			delete(tx.auths, auth.ID)
+		}
+	}
+	for _, dial := range tx.dials {
+		if dial.UserID == id {
+			removeDial(tx, dial.ID)
+		}
+	}
+	for _, membership := range tx.memberships {
+		if membership.UserID == id {
+			delete(tx.memberships, membership.ID)
+			delete(tx.membershipValues, membership.ID)
+			removeIncomingWebhooks(tx, membership.ID)
+		}
+	}
+	for _, org := range tx.organizations {
+		if org.UserID == id {
+			removeOrganization(tx, org.ID)
+		}
+	}
+	for _, member := range tx.organizationMembers {
+		if member.UserID == id {
+			delete(tx.organizationMembers, member.ID)
+		}
+	}
+	removeAPITokens(tx, id)
+	removeSessions(tx, func(session *wtf.Session) bool { return session.UserID == id })
+
+	delete(tx.users, id)
+	return nil
+}
+
+// checkUserEmailAvailable returns ECONFLICT if email is used by a different user.
+// Blank emails are not checked since they are stored as NULL in SQLite.
+func checkUserEmailAvailable(tx *Tx, id int, email string) error {
+	if email == "" {
+		return nil
+	}
+	for _, user := range tx.users {
+		if user.ID != id && user.Email == email {
+			return wtf.Errorf(wtf.ECONFLICT, "User email already exists.")
+		}
+	}
+	return nil
+}
+
+// attachUserAuths attaches OAuth objects associated with the user.
+func attachUserAuths(ctx context.Context, tx *Tx, user *wtf.User) (err error) {
+	if user.Auths, _, err = findAuths(ctx, tx, wtf.AuthFilter{UserID: &user.ID}); err != nil {
+		return fmt.Errorf("attach user auths: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,409 @@
+package inmem
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/benbjohnson/wtf"
+)
+
+// Ensure service implements interface.
+var _ wtf.WebhookService = (*WebhookService)(nil)
+
+// WebhookService represents a service for managing webhooks in memory.
+type WebhookService struct {
+	db *DB
+}
+
+// NewWebhookService returns a new instance of WebhookService.
+func NewWebhookService(db *DB) *WebhookService {
+	return &WebhookService{db: db}
+}
+
+// FindWebhookByID retrieves a single webhook by ID along with the associated
+// dial. Returns ENOTFOUND if webhook does not exist or user is not the owner
+// or an admin of the webhook's dial.
+func (s *WebhookService) FindWebhookByID(ctx context.Context, id int) (*wtf.Webhook, error) {
+	tx, err := s.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	webhook, err := findWebhookByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	} else if err := attachWebhookAssociations(ctx, tx, webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// FindWebhooks retrieves a list of webhooks by filter. Only returns webhooks
+// on dials that the current user owns or administers.
+//
+// Also returns a count of total matching webhooks which may differ if "Limit"
+// is specified on the filter.
+func (s *WebhookService) FindWebhooks(ctx context.Context, filter wtf.WebhookFilter) ([]*wtf.Webhook, int, error) {
+	tx, err := s.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	webhooks, n, err := findWebhooks(ctx, tx, filter)
+	if err != nil {
+		return webhooks, n, err
+	}
+
+	// Attach dial to each returned webhook.
+	for _, webhook := range webhooks {
+		if err := attachWebhookAssociations(ctx, tx, webhook); err != nil {
+			return webhooks, n, err
+		}
+	}
+	return webhooks, n, nil
+}
+
+// CreateWebhook creates a new webhook on a dial. Returns EUNAUTHORIZED if the
+// user is not the owner or an admin of the dial.
+func (s *WebhookService) CreateWebhook(ctx context.Context, webhook *wtf.Webhook) error {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := createWebhook(ctx, tx, webhook); err != nil {
+		return err
+	} else if err := attachWebhookAssociations(ctx, tx, webhook); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteWebhook permanently removes a webhook & its deliveries. Returns
+// EUNAUTHORIZED if the user is not the owner or an admin of the dial.
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteWebhook(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// FindWebhookDeliveries retrieves a list of deliveries by filter, most recent
+// first. Only returns deliveries for webhooks the current user can see.
+func (s *WebhookService) FindWebhookDeliveries(ctx context.Context, filter wtf.WebhookDeliveryFilter) ([]*wtf.WebhookDelivery, int, error) {
+	tx, err := s.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+	return findWebhookDeliveries(ctx, tx, filter)
+}
+
+// RedeliverWebhookDelivery queues a new delivery of the same event as an
+// existing delivery. Returns ENOTFOUND if the delivery does not exist or the
+// user cannot see its webhook.
+func (s *WebhookService) RedeliverWebhookDelivery(ctx context.Context, id int) (*wtf.WebhookDelivery, error) {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	deliveries, _, err := findWebhookDeliveries(ctx, tx, wtf.WebhookDeliveryFilter{ID: &id})
+	if err != nil {
+		return nil, err
+	} else if len(deliveries) == 0 {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "Webhook delivery not found."}
+	}
+
+	delivery := insertWebhookDelivery(tx, &wtf.WebhookDelivery{
+		WebhookID: deliveries[0].WebhookID,
+		DialID:    deliveries[0].DialID,
+		EventType: deliveries[0].EventType,
+		Body:      deliveries[0].Body,
+	})
+	return delivery, tx.Commit()
+}
+
+// FindPendingWebhookDeliveries retrieves pending deliveries which are due to
+// be attempted along with their webhook. This is used by the delivery worker
+// & does not check permissions.
+func (s *WebhookService) FindPendingWebhookDeliveries(ctx context.Context, limit int) ([]*wtf.WebhookDelivery, error) {
+	tx, err := s.db.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	deliveries := make([]*wtf.WebhookDelivery, 0)
+	for _, delivery := range tx.webhookDeliveries {
+		if delivery.Status != wtf.WebhookDeliveryStatusPending {
+			continue
+		} else if delivery.NextAttemptAt == nil || delivery.NextAttemptAt.After(tx.now) {
+			continue
+		}
+
+		// Attach webhook so the worker knows where to send the delivery.
+		webhook, ok := tx.webhooks[delivery.WebhookID]
+		if !ok {
+			return nil, fmt.Errorf("webhook not found: id=%d", delivery.WebhookID)
+		}
+		other, webhookCopy := *delivery, *webhook
+		other.Webhook = &webhookCopy
+		deliveries = append(deliveries, &other)
+	}
+
+	// Sort by due time so the oldest deliveries are attempted first.
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].NextAttemptAt.Equal(*deliveries[j].NextAttemptAt) {
+			return deliveries[i].NextAttemptAt.Before(*deliveries[j].NextAttemptAt)
+		}
+		return deliveries[i].ID < deliveries[j].ID
+	})
+	if limit > 0 && len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+// RecordWebhookDeliveryAttempt records the result of a delivery attempt &
+// schedules a retry, if needed. This is used by the delivery worker & does
+// not check permissions.
+func (s *WebhookService) RecordWebhookDeliveryAttempt(ctx context.Context, id int, result wtf.WebhookDeliveryResult) (*wtf.WebhookDelivery, error) {
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	delivery, ok := tx.webhookDeliveries[id]
+	if !ok {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "Webhook delivery not found."}
+	} else if delivery.Status != wtf.WebhookDeliveryStatusPending {
+		return nil, wtf.Errorf(wtf.ECONFLICT, "Webhook delivery is not pending.")
+	}
+
+	other := *delivery
+	other.RecordAttempt(result, tx.now)
+	tx.webhookDeliveries[id] = &other
+
+	ret := other
+	return &ret, tx.Commit()
+}
+
+// findWebhookByID returns a webhook by ID. Returns ENOTFOUND if the webhook
+// does not exist or the user cannot manage the webhook's dial.
+func findWebhookByID(ctx context.Context, tx *Tx, id int) (*wtf.Webhook, error) {
+	webhooks, _, err := findWebhooks(ctx, tx, wtf.WebhookFilter{ID: &id})
+	if err != nil {
+		return nil, err
+	} else if len(webhooks) == 0 {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "Webhook not found."}
+	}
+	return webhooks[0], nil
+}
+
+// findWebhooks returns a list of webhooks on dials that the current user owns
+// or administers. Also returns a count of total matching webhooks.
+func findWebhooks(ctx context.Context, tx *Tx, filter wtf.WebhookFilter) (_ []*wtf.Webhook, n int, err error) {
+	userID := wtf.UserIDFromContext(ctx)
+
+	webhooks := make([]*wtf.Webhook, 0)
+	for _, webhook := range tx.webhooks {
+		if v := filter.ID; v != nil && webhook.ID != *v {
+			continue
+		} else if v := filter.DialID; v != nil && webhook.DialID != *v {
+			continue
+		} else if !canManageWebhooks(tx, webhook.DialID, userID) {
+			continue
+		}
+
+		// Return a copy without associations so the stored record is unchanged.
+		other := *webhook
+		other.Dial = nil
+		webhooks = append(webhooks, &other)
+	}
+
+	// Sort by ID & restrict to the requested range.
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+	n = len(webhooks)
+	start, end := applyLimitOffset(n, filter.Limit, filter.Offset)
+	return webhooks[start:end], n, nil
+}
+
+// canManageWebhooks returns true if the user owns or administers the dial.
+// Webhooks are limited to these users as they include secrets.
+func canManageWebhooks(tx *Tx, dialID, userID int) bool {
+	switch dialMembershipRole(tx, dialID, userID) {
+	case wtf.DialMembershipRoleOwner, wtf.DialMembershipRoleAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
+// createWebhook creates a new webhook on a dial. Returns EUNAUTHORIZED if the
+// current user cannot edit the dial.
+func createWebhook(ctx context.Context, tx *Tx, webhook *wtf.Webhook) error {
+	// Generate a secret if one is not provided.
+	if webhook.Secret == "" {
+		secret, err := generateInviteCode()
+		if err != nil {
+			return err
+		}
+		webhook.Secret = secret
+	}
+
+	// Set timestamps to current time.
+	webhook.CreatedAt = tx.now
+	webhook.UpdatedAt = webhook.CreatedAt
+
+	// Perform basic field validation.
+	if err := webhook.Validate(); err != nil {
+		return err
+	}
+
+	// Verify the user is a member of the dial & can manage it.
+	if dial, err := findDialByID(ctx, tx, webhook.DialID); err != nil {
+		return err
+	} else if !wtf.CanEditDial(ctx, dial) {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You must be the owner or an admin to create webhooks.")
+	}
+
+	// Assign the next ID & store a copy without associations.
+	tx.seq.webhook++
+	webhook.ID = tx.seq.webhook
+
+	other := *webhook
+	other.Dial = nil
+	tx.webhooks[webhook.ID] = &other
+
+	return nil
+}
+
+// deleteWebhook permanently removes a webhook & its deliveries.
+func deleteWebhook(ctx context.Context, tx *Tx, id int) error {
+	// Verify object exists & the current user can manage the dial.
+	webhook, err := findWebhookByID(ctx, tx, id)
+	if err != nil {
+		return err
+	} else if err := attachWebhookAssociations(ctx, tx, webhook); err != nil {
+		return err
+	} else if !wtf.CanEditDial(ctx, webhook.Dial) {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You must be the owner or an admin to delete webhooks.")
+	}
+
+	removeWebhook(tx, id)
+	return nil
+}
+
+// removeWebhook removes a webhook along with its deliveries.
+func removeWebhook(tx *Tx, id int) {
+	for _, delivery := range tx.webhookDeliveries {
+		if delivery.WebhookID == id {
+			delete(tx.webhookDeliveries, delivery.ID)
+		}
+	}
+	delete(tx.webhooks, id)
+}
+
+// findWebhookDeliveries returns a list of deliveries for webhooks that the
+// current user can see, most recent first. Also returns a total count.
+func findWebhookDeliveries(ctx context.Context, tx *Tx, filter wtf.WebhookDeliveryFilter) (_ []*wtf.WebhookDelivery, n int, err error) {
+	userID := wtf.UserIDFromContext(ctx)
+
+	deliveries := make([]*wtf.WebhookDelivery, 0)
+	for _, delivery := range tx.webhookDeliveries {
+		if v := filter.ID; v != nil && delivery.ID != *v {
+			continue
+		} else if v := filter.WebhookID; v != nil && delivery.WebhookID != *v {
+			continue
+		} else if v := filter.DialID; v != nil && delivery.DialID != *v {
+			continue
+		} else if v := filter.Status; v != nil && delivery.Status != *v {
+			continue
+		} else if !canManageWebhooks(tx, delivery.DialID, userID) {
+			continue
+		}
+
+		other := *delivery
+		deliveries = append(deliveries, &other)
+	}
+
+	// Sort by most recent first & restrict to the requested range.
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].CreatedAt.Equal(deliveries[j].CreatedAt) {
+			return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+		}
+		return deliveries[i].ID > deliveries[j].ID
+	})
+	n = len(deliveries)
+	start, end := applyLimitOffset(n, filter.Limit, filter.Offset)
+	return deliveries[start:end], n, nil
+}
+
+// insertWebhookDeliveries queues a delivery of event to each webhook on the
+// dial which is subscribed to the event type.
+func insertWebhookDeliveries(tx *Tx, dialID int, event wtf.Event) {
+	// Sort webhooks so deliveries are queued in a consistent order.
+	webhooks := make([]*wtf.Webhook, 0)
+	for _, webhook := range tx.webhooks {
+		if webhook.DialID == dialID && webhook.Subscribed(event.Type) {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+
+	// Event payloads are plain structs so encoding should never fail.
+	body, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	for _, webhook := range webhooks {
+		insertWebhookDelivery(tx, &wtf.WebhookDelivery{
+			WebhookID: webhook.ID,
+			DialID:    dialID,
+			EventType: event.Type,
+			Body:      body,
+		})
+	}
+}
+
+// insertWebhookDelivery stores a new pending delivery which is due immediately.
+func insertWebhookDelivery(tx *Tx, delivery *wtf.WebhookDelivery) *wtf.WebhookDelivery {
+	nextAttemptAt := tx.now
+
+	tx.seq.webhookDelivery++
+	delivery.ID = tx.seq.webhookDelivery
+	delivery.Status = wtf.WebhookDeliveryStatusPending
+	delivery.NextAttemptAt = &nextAttemptAt
+	delivery.CreatedAt = tx.now
+	delivery.UpdatedAt = delivery.CreatedAt
+
+	other := *delivery
+	tx.webhookDeliveries[delivery.ID] = &other
+	return delivery
+}
+
+// attachWebhookAssociations attaches the dial to the webhook.
+func attachWebhookAssociations(ctx context.Context, tx *Tx, webhook *wtf.Webhook) (err error) {
+	if webhook.Dial, err = findDialByID(ctx, tx, webhook.DialID); err != nil {
+		return fmt.Errorf("attach webhook dial: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package wtf
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// Login token constants.
+const (
+	// Length of time that an emailed login link can be used.
+	LoginTokenDuration = 15 * time.Minute
+
+	MaxEmailLen = 254
+)
+
+// LoginToken represents a single-use token which is emailed to a user so they
+// can log in without an OAuth provider. Redeeming the token proves that the
+// user controls the email address.
+//
+// Only a hash of the token is stored. The plaintext token is only available
+// on the object returned from CreateLoginToken().
+type LoginToken struct {
+	ID int `json:"id"`
+
+	// Email address that the token was sent to.
+	Email string `json:"email"`
+
+	// Plaintext token. Only set when the token is created.
+	Token string `json:"-"`
+
+	// Time after which the token can no longer be used.
+	ExpiresAt time.Time `json:"expiresAt"`
+
+	// Time the token was redeemed. Nil if it has not been used.
+	UsedAt *time.Time `json:"usedAt"`
+
+	// Timestamp for token creation.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Validate returns an error if the token contains invalid fields.
+// This only performs basic validation.
+func (t *LoginToken) Validate() error {
+	if t.Email == "" {
+		return Errorf(EINVALID, "Email required.")
+	} else if len(t.Email) > MaxEmailLen {
+		return Errorf(EINVALID, "Email too long.")
+	} else if !IsValidEmail(t.Email) {
+		return Errorf(EINVALID, "Invalid email address.")
+	}
+	return nil
+}
+
+// IsExpired returns true if the token expires at or before now.
+func (t *LoginToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// IsValidEmail returns true if s looks like an email address. This is only
+// a basic check as the address is verified by sending mail to it.
+func IsValidEmail(s string) bool {
+	at := strings.LastIndex(s, "@")
+	return at > 0 && at < len(s)-1 && !strings.ContainsAny(s, " \t\r\n<>,")
+}
+
+// HashLoginToken returns the hex-encoded SHA-256 hash of a plaintext token.
+// Tokens are stored & looked up by this hash.
+func HashLoginToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+// LoginTokenService represents a service for managing email login tokens.
+type LoginTokenService interface {
+	// Creates a new token for token.Email. The plaintext token is set on
+	// token.Token & cannot be retrieved again. Does not require a user on the
+	// context. The expiration & timestamps are set by the service.
+	CreateLoginToken(ctx context.Context, token *LoginToken) error
+
+	// Looks up a token by its plaintext value & marks it as used so that it
+	// cannot be redeemed again. Does not require a user on the context.
+	// Returns ENOTFOUND if the token does not exist. Returns EUNAUTHORIZED if
+	// it has expired or has already been used.
+	RedeemLoginToken(ctx context.Context, token string) (*LoginToken, error)
+}
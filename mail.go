@@ -0,0 +1,18 @@
+package wtf
+
+import (
+	"context"
+)
+
+// Mail represents a plain text email message.
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer represents a service for sending email. The sender address is
+// configured on the implementation.
+type Mailer interface {
+	SendMail(ctx context.Context, msg *Mail) error
+}
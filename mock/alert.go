@@ -0,0 +1,42 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/benbjohnson/wtf"
+)
+
+var _ wtf.AlertService = (*AlertService)(nil)
+
+type AlertService struct {
+	FindAlertRuleByIDFn  func(ctx context.Context, id int) (*wtf.AlertRule, error)
+	FindAlertRulesFn     func(ctx context.Context, filter wtf.AlertRuleFilter) ([]*wtf.AlertRule, int, error)
+	CreateAlertRuleFn    func(ctx context.Context, rule *wtf.AlertRule) error
+	DeleteAlertRuleFn    func(ctx context.Context, id int) error
+	FindAlertsFn         func(ctx context.Context, filter wtf.AlertFilter) ([]*wtf.Alert, int, error)
+	EvaluateAlertRulesFn func(ctx context.Context) error
+}
+
+func (s *AlertService) FindAlertRuleByID(ctx context.Context, id int) (*wtf.AlertRule, error) {
+	return s.FindAlertRuleByIDFn(ctx, id)
+}
+
+func (s *AlertService) FindAlertRules(ctx context.Context, filter wtf.AlertRuleFilter) ([]*wtf.AlertRule, int, error) {
+	return s.FindAlertRulesFn(ctx, filter)
+}
+
+func (s *AlertService) CreateAlertRule(ctx context.Context, rule *wtf.AlertRule) error {
+	return s.CreateAlertRuleFn(ctx, rule)
+}
+
+func (s *AlertService) DeleteAlertRule(ctx context.Context, id int) error {
+	return s.DeleteAlertRuleFn(ctx, id)
+}
+
+func (s *AlertService) FindAlerts(ctx context.Context, filter wtf.AlertFilter) ([]*wtf.Alert, int, error) {
+	return s.FindAlertsFn(ctx, filter)
+}
+
+func (s *AlertService) EvaluateAlertRules(ctx context.Context) error {
+	return s.EvaluateAlertRulesFn(ctx)
+}
@@ -0,0 +1,32 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/benbjohnson/wtf"
+)
+
+var _ wtf.APITokenService = (*APITokenService)(nil)
+
+type APITokenService struct {
+	FindAPITokensFn        func(ctx context.Context, filter wtf.APITokenFilter) ([]*wtf.APIToken, int, error)
+	CreateAPITokenFn       func(ctx context.Context, token *wtf.APIToken) error
+	DeleteAPITokenFn       func(ctx context.Context, id int) error
+	AuthenticateAPITokenFn func(ctx context.Context, token string) (*wtf.APIToken, error)
+}
+
+func (s *APITokenService) FindAPITokens(ctx context.Context, filter wtf.APITokenFilter) ([]*wtf.APIToken, int, error) {
+	return s.FindAPITokensFn(ctx, filter)
+}
+
+func (s *APITokenService) CreateAPIToken(ctx context.Context, token *wtf.APIToken) error {
+	return s.CreateAPITokenFn(ctx, token)
+}
+
+func (s *APITokenService) DeleteAPIToken(ctx context.Context, id int) error {
+	return s.DeleteAPITokenFn(ctx, id)
+}
+
+func (s *APITokenService) AuthenticateAPIToken(ctx context.Context, token string) (*wtf.APIToken, error) {
+	return s.AuthenticateAPITokenFn(ctx, token)
+}
@@ -16,8 +16,12 @@ type DialService struct {
 	CreateDialFn             func(ctx context.Context, dial *wtf.Dial) error
 	UpdateDialFn             func(ctx context.Context, id int, upd wtf.DialUpdate) (*wtf.Dial, error)
 	DeleteDialFn             func(ctx context.Context, id int) error
-	SetDialMembershipValueFn func(ctx context.Context, dialID, value int) error
+	TransferDialFn           func(ctx context.Context, id, userID int) (*wtf.Dial, error)
+	RotateDialInviteFn       func(ctx context.Context, id int, opt wtf.DialInviteOptions) (*wtf.Dial, error)
+	SetDialMembershipValueFn func(ctx context.Context, dialID, value int, note string) error
 	AverageDialValueReportFn func(ctx context.Context, start, end time.Time, interval time.Duration) (*wtf.DialValueReport, error)
+	DialValueReportFn        func(ctx context.Context, dialIDs []int, start, end time.Time, interval time.Duration, opt wtf.DialValueReportOptions) (*wtf.DialValueReport, error)
+	DialHeatmapReportFn      func(ctx context.Context, dialIDs []int, start, end time.Time) (*wtf.DialHeatmapReport, error)
 }
 
 func (s *DialService) FindDialByID(ctx context.Context, id int) (*wtf.Dial, error) {
@@ -40,10 +44,26 @@ func (s *DialService) DeleteDial(ctx context.Context, id int) error {
 	return s.DeleteDialFn(ctx, id)
 }
 
-func (s *DialService) SetDialMembershipValue(ctx context.Context, dialID, value int) error {
-	return s.SetDialMembershipValueFn(ctx, dialID, value)
+func (s *DialService) TransferDial(ctx context.Context, id, userID int) (*wtf.Dial, error) {
+	return s.TransferDialFn(ctx, id, userID)
+}
+
+func (s *DialService) RotateDialInvite(ctx context.Context, id int, opt wtf.DialInviteOptions) (*wtf.Dial, error) {
+	return s.RotateDialInviteFn(ctx, id, opt)
+}
+
+func (s *DialService) SetDialMembershipValue(ctx context.Context, dialID, value int, note string) error {
+	return s.SetDialMembershipValueFn(ctx, dialID, value, note)
 }
 
 func (s *DialService) AverageDialValueReport(ctx context.Context, start, end time.Time, interval time.Duration) (*wtf.DialValueReport, error) {
 	return s.AverageDialValueReportFn(ctx, start, end, interval)
 }
+
+func (s *DialService) DialValueReport(ctx context.Context, dialIDs []int, start, end time.Time, interval time.Duration, opt wtf.DialValueReportOptions) (*wtf.DialValueReport, error) {
+	return s.DialValueReportFn(ctx, dialIDs, start, end, interval, opt)
+}
+
+func (s *DialService) DialHeatmapReport(ctx context.Context, dialIDs []int, start, end time.Time) (*wtf.DialHeatmapReport, error) {
+	return s.DialHeatmapReportFn(ctx, dialIDs, start, end)
+}
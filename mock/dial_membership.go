@@ -2,6 +2,7 @@ package mock
 
 import (
 	"context"
+	"time"
 
 	"github.com/benbjohnson/wtf"
 )
@@ -9,11 +10,14 @@ import (
 var _ wtf.DialMembershipService = (*DialMembershipService)(nil)
 
 type DialMembershipService struct {
-	FindDialMembershipByIDFn func(ctx context.Context, id int) (*wtf.DialMembership, error)
-	FindDialMembershipsFn    func(ctx context.Context, filter wtf.DialMembershipFilter) ([]*wtf.DialMembership, int, error)
-	CreateDialMembershipFn   func(ctx context.Context, membership *wtf.DialMembership) error
-	UpdateDialMembershipFn   func(ctx context.Context, id int, upd wtf.DialMembershipUpdate) (*wtf.DialMembership, error)
-	DeleteDialMembershipFn   func(ctx context.Context, id int) error
+	FindDialMembershipByIDFn    func(ctx context.Context, id int) (*wtf.DialMembership, error)
+	FindDialMembershipsFn       func(ctx context.Context, filter wtf.DialMembershipFilter) ([]*wtf.DialMembership, int, error)
+	CreateDialMembershipFn      func(ctx context.Context, membership *wtf.DialMembership) error
+	UpdateDialMembershipFn      func(ctx context.Context, id int, upd wtf.DialMembershipUpdate) (*wtf.DialMembership, error)
+	SetDialMembershipWeightFn   func(ctx context.Context, id, weight int) (*wtf.DialMembership, error)
+	SetDialMembershipRoleFn     func(ctx context.Context, id int, role string) (*wtf.DialMembership, error)
+	DeleteDialMembershipFn      func(ctx context.Context, id int) error
+	DialMembershipValueReportFn func(ctx context.Context, filter wtf.DialMembershipValueReportFilter, start, end time.Time, interval time.Duration) (*wtf.DialMembershipValueReport, error)
 }
 
 func (s *DialMembershipService) FindDialMembershipByID(ctx context.Context, id int) (*wtf.DialMembership, error) {
@@ -32,6 +36,18 @@ func (s *DialMembershipService) UpdateDialMembership(ctx context.Context, id int
 	return s.UpdateDialMembershipFn(ctx, id, upd)
 }
 
+func (s *DialMembershipService) SetDialMembershipWeight(ctx context.Context, id, weight int) (*wtf.DialMembership, error) {
+	return s.SetDialMembershipWeightFn(ctx, id, weight)
+}
+
+func (s *DialMembershipService) SetDialMembershipRole(ctx context.Context, id int, role string) (*wtf.DialMembership, error) {
+	return s.SetDialMembershipRoleFn(ctx, id, role)
+}
+
 func (s *DialMembershipService) DeleteDialMembership(ctx context.Context, id int) error {
 	return s.DeleteDialMembershipFn(ctx, id)
 }
+
+func (s *DialMembershipService) DialMembershipValueReport(ctx context.Context, filter wtf.DialMembershipValueReportFilter, start, end time.Time, interval time.Duration) (*wtf.DialMembershipValueReport, error) {
+	return s.DialMembershipValueReportFn(ctx, filter, start, end, interval)
+}
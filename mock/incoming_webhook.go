@@ -0,0 +1,32 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/benbjohnson/wtf"
+)
+
+var _ wtf.IncomingWebhookService = (*IncomingWebhookService)(nil)
+
+type IncomingWebhookService struct {
+	FindIncomingWebhooksFn  func(ctx context.Context, filter wtf.IncomingWebhookFilter) ([]*wtf.IncomingWebhook, int, error)
+	CreateIncomingWebhookFn func(ctx context.Context, webhook *wtf.IncomingWebhook) error
+	DeleteIncomingWebhookFn func(ctx context.Context, id int) error
+	InvokeIncomingWebhookFn func(ctx context.Context, token string, upd wtf.IncomingWebhookUpdate) (*wtf.DialMembership, error)
+}
+
+func (s *IncomingWebhookService) FindIncomingWebhooks(ctx context.Context, filter wtf.IncomingWebhookFilter) ([]*wtf.IncomingWebhook, int, error) {
+	return s.FindIncomingWebhooksFn(ctx, filter)
+}
+
+func (s *IncomingWebhookService) CreateIncomingWebhook(ctx context.Context, webhook *wtf.IncomingWebhook) error {
+	return s.CreateIncomingWebhookFn(ctx, webhook)
+}
+
+func (s *IncomingWebhookService) DeleteIncomingWebhook(ctx context.Context, id int) error {
+	return s.DeleteIncomingWebhookFn(ctx, id)
+}
+
+func (s *IncomingWebhookService) InvokeIncomingWebhook(ctx context.Context, token string, upd wtf.IncomingWebhookUpdate) (*wtf.DialMembership, error) {
+	return s.InvokeIncomingWebhookFn(ctx, token, upd)
+}
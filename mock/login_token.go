@@ -0,0 +1,22 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/benbjohnson/wtf"
+)
+
+var _ wtf.LoginTokenService = (*LoginTokenService)(nil)
+
+type LoginTokenService struct {
+	CreateLoginTokenFn func(ctx context.Context, token *wtf.LoginToken) error
+	RedeemLoginTokenFn func(ctx context.Context, token string) (*wtf.LoginToken, error)
+}
+
+func (s *LoginTokenService) CreateLoginToken(ctx context.Context, token *wtf.LoginToken) error {
+	return s.CreateLoginTokenFn(ctx, token)
+}
+
+func (s *LoginTokenService) RedeemLoginToken(ctx context.Context, token string) (*wtf.LoginToken, error) {
+	return s.RedeemLoginTokenFn(ctx, token)
+}
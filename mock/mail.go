@@ -0,0 +1,17 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/benbjohnson/wtf"
+)
+
+var _ wtf.Mailer = (*Mailer)(nil)
+
+type Mailer struct {
+	SendMailFn func(ctx context.Context, msg *wtf.Mail) error
+}
+
+func (s *Mailer) SendMail(ctx context.Context, msg *wtf.Mail) error {
+	return s.SendMailFn(ctx, msg)
+}
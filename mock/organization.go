@@ -0,0 +1,47 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/benbjohnson/wtf"
+)
+
+var _ wtf.OrganizationService = (*OrganizationService)(nil)
+
+type OrganizationService struct {
+	FindOrganizationByIDFn     func(ctx context.Context, id int) (*wtf.Organization, error)
+	FindOrganizationsFn        func(ctx context.Context, filter wtf.OrganizationFilter) ([]*wtf.Organization, int, error)
+	CreateOrganizationFn       func(ctx context.Context, org *wtf.Organization) error
+	UpdateOrganizationFn       func(ctx context.Context, id int, upd wtf.OrganizationUpdate) (*wtf.Organization, error)
+	DeleteOrganizationFn       func(ctx context.Context, id int) error
+	CreateOrganizationMemberFn func(ctx context.Context, member *wtf.OrganizationMember) error
+	DeleteOrganizationMemberFn func(ctx context.Context, id int) error
+}
+
+func (s *OrganizationService) FindOrganizationByID(ctx context.Context, id int) (*wtf.Organization, error) {
+	return s.FindOrganizationByIDFn(ctx, id)
+}
+
+func (s *OrganizationService) FindOrganizations(ctx context.Context, filter wtf.OrganizationFilter) ([]*wtf.Organization, int, error) {
+	return s.FindOrganizationsFn(ctx, filter)
+}
+
+func (s *OrganizationService) CreateOrganization(ctx context.Context, org *wtf.Organization) error {
+	return s.CreateOrganizationFn(ctx, org)
+}
+
+func (s *OrganizationService) UpdateOrganization(ctx context.Context, id int, upd wtf.OrganizationUpdate) (*wtf.Organization, error) {
+	return s.UpdateOrganizationFn(ctx, id, upd)
+}
+
+func (s *OrganizationService) DeleteOrganization(ctx context.Context, id int) error {
+	return s.DeleteOrganizationFn(ctx, id)
+}
+
+func (s *OrganizationService) CreateOrganizationMember(ctx context.Context, member *wtf.OrganizationMember) error {
+	return s.CreateOrganizationMemberFn(ctx, member)
+}
+
+func (s *OrganizationService) DeleteOrganizationMember(ctx context.Context, id int) error {
+	return s.DeleteOrganizationMemberFn(ctx, id)
+}
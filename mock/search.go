@@ -0,0 +1,17 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/benbjohnson/wtf"
+)
+
+var _ wtf.SearchService = (*SearchService)(nil)
+
+type SearchService struct {
+	SearchFn func(ctx context.Context, filter wtf.SearchFilter) ([]*wtf.SearchResult, int, error)
+}
+
+func (s *SearchService) Search(ctx context.Context, filter wtf.SearchFilter) ([]*wtf.SearchResult, int, error) {
+	return s.SearchFn(ctx, filter)
+}
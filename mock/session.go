@@ -0,0 +1,37 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/benbjohnson/wtf"
+)
+
+var _ wtf.SessionService = (*SessionService)(nil)
+
+type SessionService struct {
+	FindSessionsFn        func(ctx context.Context, filter wtf.SessionFilter) ([]*wtf.Session, int, error)
+	CreateSessionFn       func(ctx context.Context, session *wtf.Session) error
+	AuthenticateSessionFn func(ctx context.Context, id int) (*wtf.Session, error)
+	DeleteSessionFn       func(ctx context.Context, id int) error
+	DeleteAllSessionsFn   func(ctx context.Context) error
+}
+
+func (s *SessionService) FindSessions(ctx context.Context, filter wtf.SessionFilter) ([]*wtf.Session, int, error) {
+	return s.FindSessionsFn(ctx, filter)
+}
+
+func (s *SessionService) CreateSession(ctx context.Context, session *wtf.Session) error {
+	return s.CreateSessionFn(ctx, session)
+}
+
+func (s *SessionService) AuthenticateSession(ctx context.Context, id int) (*wtf.Session, error) {
+	return s.AuthenticateSessionFn(ctx, id)
+}
+
+func (s *SessionService) DeleteSession(ctx context.Context, id int) error {
+	return s.DeleteSessionFn(ctx, id)
+}
+
+func (s *SessionService) DeleteAllSessions(ctx context.Context) error {
+	return s.DeleteAllSessionsFn(ctx)
+}
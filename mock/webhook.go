@@ -0,0 +1,52 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/benbjohnson/wtf"
+)
+
+var _ wtf.WebhookService = (*WebhookService)(nil)
+
+type WebhookService struct {
+	FindWebhookByIDFn              func(ctx context.Context, id int) (*wtf.Webhook, error)
+	FindWebhooksFn                 func(ctx context.Context, filter wtf.WebhookFilter) ([]*wtf.Webhook, int, error)
+	CreateWebhookFn                func(ctx context.Context, webhook *wtf.Webhook) error
+	DeleteWebhookFn                func(ctx context.Context, id int) error
+	FindWebhookDeliveriesFn        func(ctx context.Context, filter wtf.WebhookDeliveryFilter) ([]*wtf.WebhookDelivery, int, error)
+	RedeliverWebhookDeliveryFn     func(ctx context.Context, id int) (*wtf.WebhookDelivery, error)
+	FindPendingWebhookDeliveriesFn func(ctx context.Context, limit int) ([]*wtf.WebhookDelivery, error)
+	RecordWebhookDeliveryAttemptFn func(ctx context.Context, id int, result wtf.WebhookDeliveryResult) (*wtf.WebhookDelivery, error)
+}
+
+func (s *WebhookService) FindWebhookByID(ctx context.Context, id int) (*wtf.Webhook, error) {
+	return s.FindWebhookByIDFn(ctx, id)
+}
+
+func (s *WebhookService) FindWebhooks(ctx context.Context, filter wtf.WebhookFilter) ([]*wtf.Webhook, int, error) {
+	return s.FindWebhooksFn(ctx, filter)
+}
+
+func (s *WebhookService) CreateWebhook(ctx context.Context, webhook *wtf.Webhook) error {
+	return s.CreateWebhookFn(ctx, webhook)
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id int) error {
+	return s.DeleteWebhookFn(ctx, id)
+}
+
+func (s *WebhookService) FindWebhookDeliveries(ctx context.Context, filter wtf.WebhookDeliveryFilter) ([]*wtf.WebhookDelivery, int, error) {
+	return s.FindWebhookDeliveriesFn(ctx, filter)
+}
+
+func (s *WebhookService) RedeliverWebhookDelivery(ctx context.Context, id int) (*wtf.WebhookDelivery, error) {
+	return s.RedeliverWebhookDeliveryFn(ctx, id)
+}
+
+func (s *WebhookService) FindPendingWebhookDeliveries(ctx context.Context, limit int) ([]*wtf.WebhookDelivery, error) {
+	return s.FindPendingWebhookDeliveriesFn(ctx, limit)
+}
+
+func (s *WebhookService) RecordWebhookDeliveryAttempt(ctx context.Context, id int, result wtf.WebhookDeliveryResult) (*wtf.WebhookDelivery, error) {
+	return s.RecordWebhookDeliveryAttemptFn(ctx, id, result)
+}
@@ -0,0 +1,174 @@
+package wtf
+
+import (
+	"context"
+	"time"
+	"unicode/utf8"
+)
+
+// Organization constants.
+const (
+	MaxOrganizationNameLen = 100
+)
+
+// Organization member roles. The owner is the user who created the
+// organization & there is only one per organization. Members can see, join &
+// create dials within the organization.
+const (
+	OrganizationRoleOwner  = "owner"
+	OrganizationRoleMember = "member"
+)
+
+// Organization represents a group of users, such as a team, which share a set
+// of dials. Every member of an organization can see the organization's dials
+// & join them without an invite link.
+//
+// An organization is created by a user who becomes its owner. Only the owner
+// can edit or delete the organization. Users become members by accepting the
+// organization's invite link.
+type Organization struct {
+	ID int `json:"id"`
+
+	// Owner of the organization.
+	UserID int   `json:"userID"`
+	User   *User `json:"user"`
+
+	// Human-readable name of the organization.
+	Name string `json:"name"`
+
+	// Code used to invite other users to the organization.
+	InviteCode string `json:"inviteCode,omitempty"`
+
+	// Role of the current user within the organization. This is a computed
+	// field and is blank if the current user is not a member.
+	Role string `json:"role,omitempty"`
+
+	// Timestamps for organization creation & last update.
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// List of members of the organization.
+	// This is only set when returning a single organization.
+	Members []*OrganizationMember `json:"members,omitempty"`
+}
+
+// MemberByUserID returns the member of the organization for a given user.
+// Returns nil if the user is not a member or if members are unset.
+func (o *Organization) MemberByUserID(userID int) *OrganizationMember {
+	for _, m := range o.Members {
+		if m.UserID == userID {
+			return m
+		}
+	}
+	return nil
+}
+
+// Validate returns an error if the organization has invalid fields. Only
+// performs basic validation.
+func (o *Organization) Validate() error {
+	if o.Name == "" {
+		return Errorf(EINVALID, "Organization name required.")
+	} else if utf8.RuneCountInString(o.Name) > MaxOrganizationNameLen {
+		return Errorf(EINVALID, "Organization name too long.")
+	} else if o.UserID == 0 {
+		return Errorf(EINVALID, "Organization creator required.")
+	}
+	return nil
+}
+
+// CanEditOrganization returns true if the current user can edit or delete the
+// organization. Only the owner can edit the organization.
+func CanEditOrganization(ctx context.Context, org *Organization) bool {
+	return org.UserID == UserIDFromContext(ctx)
+}
+
+// OrganizationMember represents a user's membership in an organization.
+type OrganizationMember struct {
+	ID int `json:"id"`
+
+	// Parent organization.
+	OrganizationID int           `json:"organizationID"`
+	Organization   *Organization `json:"organization,omitempty"`
+
+	// User that is a member of the organization.
+	UserID int   `json:"userID"`
+	User   *User `json:"user"`
+
+	// Role of the member within the organization.
+	Role string `json:"role"`
+
+	// Invite code used to join the organization. This is verified against
+	// the organization on creation & is not stored.
+	InviteCode string `json:"inviteCode,omitempty"`
+
+	// Timestamp for when the user joined.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CanDeleteOrganizationMember returns true if the current user can remove the
+// member from the organization. Members can leave & the owner can remove any
+// other member. The owner cannot leave their own organization.
+func CanDeleteOrganizationMember(ctx context.Context, member *OrganizationMember) bool {
+	userID := UserIDFromContext(ctx)
+	if member.Role == OrganizationRoleOwner {
+		return false
+	} else if member.UserID == userID {
+		return true
+	}
+	return member.Organization != nil && member.Organization.UserID == userID
+}
+
+// OrganizationService represents a service for managing organizations.
+type OrganizationService interface {
+	// Retrieves a single organization by ID along with its members. Only
+	// members can see an organization. Returns ENOTFOUND if the organization
+	// does not exist or the user is not a member.
+	FindOrganizationByID(ctx context.Context, id int) (*Organization, error)
+
+	// Retrieves a list of organizations based on a filter. Only returns
+	// organizations the user is a member of unless searching by invite code.
+	// Also returns a count of total matching organizations which may differ
+	// from the number of returned organizations if "Limit" is set.
+	FindOrganizations(ctx context.Context, filter OrganizationFilter) ([]*Organization, int, error)
+
+	// Creates a new organization and assigns the current user as the owner.
+	// The owner is automatically added as a member.
+	CreateOrganization(ctx context.Context, org *Organization) error
+
+	// Updates an existing organization by ID. Only the owner can update an
+	// organization. Returns ENOTFOUND if the organization does not exist.
+	// Returns EUNAUTHORIZED if the user is not the owner.
+	UpdateOrganization(ctx context.Context, id int, upd OrganizationUpdate) (*Organization, error)
+
+	// Permanently removes an organization by ID. Dials within the organization
+	// are not removed. Only the owner can delete an organization. Returns
+	// ENOTFOUND if the organization does not exist. Returns EUNAUTHORIZED if
+	// the user is not the owner.
+	DeleteOrganization(ctx context.Context, id int) error
+
+	// Adds the current user to an organization. The member's invite code must
+	// match the organization's invite code. Returns EINVALID if the invite
+	// code is invalid & ECONFLICT if the user is already a member.
+	CreateOrganizationMember(ctx context.Context, member *OrganizationMember) error
+
+	// Removes a member from an organization by ID. Members can leave & the
+	// owner can remove other members. Returns ENOTFOUND if the member does not
+	// exist. Returns EUNAUTHORIZED if the user cannot remove the member.
+	DeleteOrganizationMember(ctx context.Context, id int) error
+}
+
+// OrganizationFilter represents a filter used by FindOrganizations().
+type OrganizationFilter struct {
+	// Filtering fields.
+	ID         *int    `json:"id"`
+	InviteCode *string `json:"inviteCode"`
+
+	// Restrict to subset of range.
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+}
+
+// OrganizationUpdate represents a set of fields to update on an organization.
+type OrganizationUpdate struct {
+	Name *string `json:"name"`
+}
@@ -0,0 +1,59 @@
+package wtf
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// SearchResult represents a single match from a full-text search. A result
+// matches either the name of a dial or the member name & note of one of the
+// dial's memberships.
+type SearchResult struct {
+	// Dial that contains the match. The dial is always attached.
+	DialID int   `json:"dialID"`
+	Dial   *Dial `json:"dial,omitempty"`
+
+	// Membership that contains the match. Zero if the dial name matched.
+	DialMembershipID int `json:"dialMembershipID,omitempty"`
+
+	// Matched dial name or member name along with the membership note.
+	Name string `json:"name"`
+	Note string `json:"note,omitempty"`
+}
+
+// IsDialMembership returns true if the result matched a dial membership
+// instead of the dial itself.
+func (r *SearchResult) IsDialMembership() bool {
+	return r.DialMembershipID != 0
+}
+
+// SearchService represents a service for full-text search across dials.
+type SearchService interface {
+	// Returns dials & memberships matching every term in the filter's query.
+	// Terms match the start of any word in a dial name, member name or
+	// membership note. Dials are only returned if the user can see them and
+	// memberships are only returned to members of their dial. Also returns
+	// a count of total matches which may differ from the number of returned
+	// results if the "Limit" field is set.
+	//
+	// Returns EINVALID if the query contains no terms.
+	Search(ctx context.Context, filter SearchFilter) ([]*SearchResult, int, error)
+}
+
+// SearchFilter represents a filter used by Search().
+type SearchFilter struct {
+	Query string `json:"query"`
+
+	// Restrict to subset of range.
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+}
+
+// SearchTerms returns the lowercase words in a search query. Punctuation &
+// whitespace separate words and are otherwise ignored.
+func SearchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
@@ -0,0 +1,81 @@
+package wtf
+
+import (
+	"context"
+	"time"
+)
+
+// Session constants.
+const (
+	// Length of time that a session is valid after the user logs in.
+	SessionDuration = 30 * 24 * time.Hour
+
+	// Minimum time between updates to a session's LastSeenAt. This avoids a
+	// database write on every request.
+	SessionLastSeenInterval = time.Minute
+)
+
+// Session represents a logged in browser session for a user. The session ID
+// is stored in the user's cookie & is checked on every request so that
+// sessions can be revoked from the server.
+type Session struct {
+	ID int `json:"id"`
+
+	// User that is logged in.
+	UserID int   `json:"userID"`
+	User   *User `json:"user,omitempty"`
+
+	// Auth used to log in, if any. The session is removed if the auth is deleted.
+	AuthID int `json:"authID,omitempty"`
+
+	// Details about the client which created the session. The device is a
+	// short, human readable description derived from the user agent.
+	Device    string `json:"device"`
+	IPAddress string `json:"ipAddress"`
+	UserAgent string `json:"userAgent"`
+
+	// Time after which the session is no longer valid.
+	ExpiresAt time.Time `json:"expiresAt"`
+
+	// Timestamps for when the session was last used & created.
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// IsExpired returns true if the session expires at or before now.
+func (s *Session) IsExpired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
+
+// SessionService represents a service for managing sessions.
+type SessionService interface {
+	// Retrieves a list of the current user's unexpired sessions by filter,
+	// most recently seen first. Also returns the total count of matching
+	// sessions which may differ if filter.Limit is specified.
+	FindSessions(ctx context.Context, filter SessionFilter) ([]*Session, int, error)
+
+	// Creates a new session for the current user. The expiration & timestamps
+	// are set by the service.
+	CreateSession(ctx context.Context, session *Session) error
+
+	// Looks up a session by ID, records its use & attaches its user. Does not
+	// require a user on the context. Returns ENOTFOUND if the session does not
+	// exist or has been revoked. Returns EUNAUTHORIZED if it has expired.
+	AuthenticateSession(ctx context.Context, id int) (*Session, error)
+
+	// Revokes a single session. Returns ENOTFOUND if the session does not
+	// exist or does not belong to the current user.
+	DeleteSession(ctx context.Context, id int) error
+
+	// Revokes all of the current user's sessions.
+	DeleteAllSessions(ctx context.Context) error
+}
+
+// SessionFilter represents a filter used by FindSessions().
+type SessionFilter struct {
+	ID *int `json:"id"`
+
+	// Restricts results to a subset of the total range.
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+}
@@ -0,0 +1,153 @@
+// Package smtp implements wtf.Mailer by delivering mail to an SMTP server.
+package smtp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+)
+
+// Ensure types implement interface.
+var _ wtf.Mailer = (*Mailer)(nil)
+var _ wtf.Mailer = (*LogMailer)(nil)
+
+// Mailer represents a client for sending mail through an SMTP server.
+type Mailer struct {
+	// Address of the SMTP server, in "host:port" format.
+	Addr string
+
+	// Address that mail is sent from.
+	From string
+
+	// Credentials used for PLAIN authentication. Authentication is skipped if
+	// no username is set. The server must support TLS unless it is localhost.
+	Username string
+	Password string
+}
+
+// NewMailer returns a new instance of Mailer.
+func NewMailer(addr, from string) *Mailer {
+	return &Mailer{Addr: addr, From: from}
+}
+
+// SendMail delivers msg to the SMTP server. The connection is upgraded with
+// STARTTLS if the server supports it.
+func (m *Mailer) SendMail(ctx context.Context, msg *wtf.Mail) error {
+	if m.From == "" {
+		return fmt.Errorf("smtp: from address required")
+	}
+
+	data, err := encode(m.From, msg)
+	if err != nil {
+		return err
+	}
+
+	host, _, err := net.SplitHostPort(m.Addr)
+	if err != nil {
+		return fmt.Errorf("smtp: invalid address: %w", err)
+	}
+
+	// Connect to the server. The context deadline, if any, applies to the
+	// entire conversation.
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", m.Addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	// Encrypt the connection, if available, before sending credentials.
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if m.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.Username, m.Password, host)); err != nil {
+			return err
+		}
+	}
+
+	// Send the envelope & message.
+	if err := c.Mail(m.From); err != nil {
+		return err
+	} else if err := c.Rcpt(msg.To); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	} else if _, err := w.Write(data); err != nil {
+		return err
+	} else if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// LogMailer implements wtf.Mailer by writing messages to a writer instead of
+// delivering them. This is useful during development when no SMTP server is
+// available as login links can be copied from the server's output.
+type LogMailer struct {
+	Writer io.Writer
+}
+
+// NewLogMailer returns a new instance of LogMailer that writes to STDOUT.
+func NewLogMailer() *LogMailer {
+	return &LogMailer{Writer: os.Stdout}
+}
+
+// SendMail writes msg to the writer.
+func (m *LogMailer) SendMail(ctx context.Context, msg *wtf.Mail) error {
+	_, err := fmt.Fprintf(m.Writer, "mail: to=%s subject=%q\n%s\n", msg.To, msg.Subject, msg.Body)
+	return err
+}
+
+// encode returns msg as a plain text RFC 5322 message.
+func encode(from string, msg *wtf.Mail) ([]byte, error) {
+	// Disallow line breaks in headers so they cannot be used to inject
+	// additional headers or recipients.
+	for _, v := range []string{from, msg.To, msg.Subject} {
+		if strings.ContainsAny(v, "\r\n") {
+			return nil, fmt.Errorf("smtp: invalid header value: %q", v)
+		}
+	}
+	if msg.To == "" {
+		return nil, fmt.Errorf("smtp: recipient required")
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("\r\n")
+
+	// Normalize line endings in the body to CRLF.
+	body := strings.ReplaceAll(msg.Body, "\r\n", "\n")
+	buf.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	buf.WriteString("\r\n")
+
+	return buf.Bytes(), nil
+}
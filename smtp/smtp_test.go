@@ -0,0 +1,168 @@
+package smtp_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/benbjohnson/wtf"
+	"github.com/benbjohnson/wtf/smtp"
+)
+
+func TestMailer_SendMail(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		s := MustOpenServer(t)
+		m := smtp.NewMailer(s.Addr(), "wtf@example.com")
+		if err := m.SendMail(context.Background(), &wtf.Mail{
+			To:      "jane@example.com",
+			Subject: "Log in to WTF Dial",
+			Body:    "line one\nline two",
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		msg := s.Message()
+		if got, want := msg.From, "wtf@example.com"; got != want {
+			t.Fatalf("From=%v, want %v", got, want)
+		} else if got, want := msg.To, "jane@example.com"; got != want {
+			t.Fatalf("To=%v, want %v", got, want)
+		} else if !strings.Contains(msg.Data, "Subject: Log in to WTF Dial\r\n") {
+			t.Fatalf("unexpected data: %s", msg.Data)
+		} else if !strings.HasSuffix(msg.Data, "\r\n\r\nline one\r\nline two\r\n") {
+			t.Fatalf("unexpected data: %s", msg.Data)
+		}
+	})
+
+	// Ensure credentials are sent if a username is specified.
+	t.Run("Auth", func(t *testing.T) {
+		s := MustOpenServer(t)
+		m := smtp.NewMailer(s.Addr(), "wtf@example.com")
+		m.Username, m.Password = "USER", "PASS"
+		if err := m.SendMail(context.Background(), &wtf.Mail{To: "jane@example.com", Subject: "X", Body: "Y"}); err != nil {
+			t.Fatal(err)
+		} else if got, want := s.Message().Auth, "\x00USER\x00PASS"; got != want {
+			t.Fatalf("Auth=%q, want %q", got, want)
+		}
+	})
+
+	// Ensure line breaks cannot be used to inject headers.
+	t.Run("ErrHeaderInjection", func(t *testing.T) {
+		m := smtp.NewMailer("127.0.0.1:0", "wtf@example.com")
+		if err := m.SendMail(context.Background(), &wtf.Mail{To: "jane@example.com", Subject: "X\r\nBcc: evil@example.com"}); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestLogMailer_SendMail(t *testing.T) {
+	var buf bytes.Buffer
+	m := &smtp.LogMailer{Writer: &buf}
+	if err := m.SendMail(context.Background(), &wtf.Mail{To: "jane@example.com", Subject: "X", Body: "http://localhost/login"}); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(buf.String(), "http://localhost/login") {
+		t.Fatalf("unexpected output: %s", buf.String())
+	}
+}
+
+// Server represents a minimal SMTP server for testing. It accepts a single
+// message & records it.
+type Server struct {
+	ln  net.Listener
+	wg  sync.WaitGroup
+	msg Message
+}
+
+// Message represents a message received by the test server.
+type Message struct {
+	Auth string
+	From string
+	To   string
+	Data string
+}
+
+// MustOpenServer returns a running test SMTP server. Fatal on error.
+func MustOpenServer(tb testing.TB) *Server {
+	tb.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	s := &Server{ln: ln}
+	tb.Cleanup(func() { ln.Close() })
+
+	s.wg.Add(1)
+	go func() { defer s.wg.Done(); s.serve() }()
+	return s
+}
+
+// Addr returns the address the server is listening on.
+func (s *Server) Addr() string { return s.ln.Addr().String() }
+
+// Message waits for the connection to finish & returns the received message.
+func (s *Server) Message() Message {
+	s.wg.Wait()
+	return s.msg
+}
+
+func (s *Server) serve() {
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch cmd {
+		case "EHLO", "HELO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			fields := strings.Fields(line)
+			buf, _ := base64.StdEncoding.DecodeString(fields[len(fields)-1])
+			s.msg.Auth = string(buf)
+			reply("235 OK")
+		case "MAIL":
+			s.msg.From = strings.Trim(line[strings.Index(line, ":")+1:], "<> ")
+			reply("250 OK")
+		case "RCPT":
+			s.msg.To = strings.Trim(line[strings.Index(line, ":")+1:], "<> ")
+			reply("250 OK")
+		case "DATA":
+			reply("354 Go ahead")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				} else if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			s.msg.Data = data.String()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Not implemented")
+		}
+	}
+}
@@ -0,0 +1,513 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+)
+
+// AlertService represents a service for managing dial alert rules in SQLite.
+type AlertService struct {
+	db *DB
+}
+
+// NewAlertService returns a new instance of AlertService.
+func NewAlertService(db *DB) *AlertService {
+	return &AlertService{db: db}
+}
+
+// FindAlertRuleByID retrieves a single alert rule by ID along with the
+// associated dial. Returns ENOTFOUND if rule does not exist or user is not a
+// member of the rule's dial.
+func (s *AlertService) FindAlertRuleByID(ctx context.Context, id int) (*wtf.AlertRule, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rule, err := findAlertRuleByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	} else if err := attachAlertRuleAssociations(ctx, tx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// FindAlertRules retrieves a list of alert rules by filter. Only returns rules
+// on dials that the current user is a member of.
+//
+// Also returns a count of total matching rules which may differ if "Limit"
+// is specified on the filter.
+func (s *AlertService) FindAlertRules(ctx context.Context, filter wtf.AlertRuleFilter) ([]*wtf.AlertRule, int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	rules, n, err := findAlertRules(ctx, tx, filter)
+	if err != nil {
+		return rules, n, err
+	}
+
+	// Attach dial to each returned rule.
+	for _, rule := range rules {
+		if err := attachAlertRuleAssociations(ctx, tx, rule); err != nil {
+			return rules, n, err
+		}
+	}
+	return rules, n, nil
+}
+
+// CreateAlertRule creates a new alert rule on a dial. Returns EUNAUTHORIZED if
+// the user is not the owner or an admin of the dial.
+func (s *AlertService) CreateAlertRule(ctx context.Context, rule *wtf.AlertRule) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := createAlertRule(ctx, tx, rule); err != nil {
+		return err
+	} else if err := attachAlertRuleAssociations(ctx, tx, rule); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteAlertRule permanently removes an alert rule & its alert history.
+// Returns EUNAUTHORIZED if the user is not the owner or an admin of the dial.
+func (s *AlertService) DeleteAlertRule(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteAlertRule(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// FindAlerts retrieves a list of alerts by filter, most recent first. Only
+// returns alerts on dials that the current user is a member of.
+func (s *AlertService) FindAlerts(ctx context.Context, filter wtf.AlertFilter) ([]*wtf.Alert, int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+	return findAlerts(ctx, tx, filter)
+}
+
+// EvaluateAlertRules evaluates every alert rule against the current time.
+// This is called periodically by a background worker so that duration-based
+// rules can fire while the dial value stays the same.
+func (s *AlertService) EvaluateAlertRules(ctx context.Context) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Find every dial which has at least one rule.
+	rows, err := tx.QueryContext(ctx, `SELECT DISTINCT dial_id FROM alert_rules ORDER BY dial_id`)
+	if err != nil {
+		return FormatError(err)
+	}
+	defer rows.Close()
+
+	var dialIDs []int
+	for rows.Next() {
+		var dialID int
+		if err := rows.Scan(&dialID); err != nil {
+			return err
+		}
+		dialIDs = append(dialIDs, dialID)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	} else if err := rows.Close(); err != nil {
+		return err
+	}
+
+	for _, dialID := range dialIDs {
+		if err := evaluateDialAlertRules(ctx, tx, dialID); err != nil {
+			return fmt.Errorf("evaluate dial alert rules: dial=%d err=%w", dialID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// findAlertRuleByID returns an alert rule by ID. Returns ENOTFOUND if the rule
+// does not exist or the user is not a member of the rule's dial.
+func findAlertRuleByID(ctx context.Context, tx *Tx, id int) (*wtf.AlertRule, error) {
+	rules, _, err := findAlertRules(ctx, tx, wtf.AlertRuleFilter{ID: &id})
+	if err != nil {
+		return nil, err
+	} else if len(rules) == 0 {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "Alert rule not found."}
+	}
+	return rules[0], nil
+}
+
+// findAlertRules returns a list of alert rules on dials that the current user
+// is a member of. Also returns a count of total matching rules.
+func findAlertRules(ctx context.Context, tx *Tx, filter wtf.AlertRuleFilter) (_ []*wtf.AlertRule, n int, err error) {
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := filter.ID; v != nil {
+		where, args = append(where, "id = ?"), append(args, *v)
+	}
+	if v := filter.DialID; v != nil {
+		where, args = append(where, "dial_id = ?"), append(args, *v)
+	}
+
+	// Limit to rules on dials the user is a member of.
+	where = append(where, `dial_id IN (SELECT dial_id FROM dial_memberships WHERE user_id = ?)`)
+	args = append(args, wtf.UserIDFromContext(ctx))
+
+	return queryAlertRules(ctx, tx, where, args, filter.Limit, filter.Offset)
+}
+
+// findAlertRulesByDialID returns all alert rules on a dial. This does not
+// check permissions as it is used internally for rule evaluation.
+func findAlertRulesByDialID(ctx context.Context, tx *Tx, dialID int) ([]*wtf.AlertRule, error) {
+	rules, _, err := queryAlertRules(ctx, tx, []string{"dial_id = ?"}, []interface{}{dialID}, 0, 0)
+	return rules, err
+}
+
+// queryAlertRules executes a query against the alert_rules table using the
+// given WHERE clause segments which are AND-ed together.
+func queryAlertRules(ctx context.Context, tx *Tx, where []string, args []interface{}, limit, offset int) (_ []*wtf.AlertRule, n int, err error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+		    id,
+		    dial_id,
+		    condition,
+		    threshold,
+		    duration,
+		    state,
+		    created_at,
+		    updated_at,
+		    COUNT(*) OVER()
+		FROM alert_rules
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY id ASC
+		`+FormatLimitOffset(limit, offset),
+		args...,
+	)
+	if err != nil {
+		return nil, n, FormatError(err)
+	}
+	defer rows.Close()
+
+	rules := make([]*wtf.AlertRule, 0)
+	for rows.Next() {
+		var rule wtf.AlertRule
+		if err := rows.Scan(
+			&rule.ID,
+			&rule.DialID,
+			&rule.Condition,
+			&rule.Threshold,
+			&rule.Duration,
+			&rule.State,
+			(*NullTime)(&rule.CreatedAt),
+			(*NullTime)(&rule.UpdatedAt),
+			&n,
+		); err != nil {
+			return nil, 0, err
+		}
+		rules = append(rules, &rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return rules, n, nil
+}
+
+// createAlertRule creates a new alert rule on a dial. Returns EUNAUTHORIZED
+// if the current user cannot edit the dial.
+func createAlertRule(ctx context.Context, tx *Tx, rule *wtf.AlertRule) error {
+	// New rules start in the "ok" state & fire on the next evaluation.
+	rule.State = wtf.AlertRuleStateOK
+
+	// Set timestamps to current time.
+	rule.CreatedAt = tx.now
+	rule.UpdatedAt = rule.CreatedAt
+
+	// Perform basic field validation.
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+
+	// Verify the user is a member of the dial & can manage it.
+	if dial, err := findDialByID(ctx, tx, rule.DialID); err != nil {
+		return err
+	} else if !wtf.CanEditDial(ctx, dial) {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You must be the owner or an admin to create alert rules.")
+	}
+
+	// Insert row into database.
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO alert_rules (
+			dial_id,
+			condition,
+			threshold,
+			duration,
+			state,
+			created_at,
+			updated_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`,
+		rule.DialID,
+		rule.Condition,
+		rule.Threshold,
+		rule.Duration,
+		rule.State,
+		(*NullTime)(&rule.CreatedAt),
+		(*NullTime)(&rule.UpdatedAt),
+	)
+	if err != nil {
+		return FormatError(err)
+	}
+
+	// Read back new rule ID into caller argument.
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	rule.ID = int(id)
+
+	return nil
+}
+
+// deleteAlertRule permanently removes an alert rule. Alert history is removed
+// by the foreign key cascade.
+func deleteAlertRule(ctx context.Context, tx *Tx, id int) error {
+	// Verify object exists & the current user can manage the dial.
+	rule, err := findAlertRuleByID(ctx, tx, id)
+	if err != nil {
+		return err
+	} else if err := attachAlertRuleAssociations(ctx, tx, rule); err != nil {
+		return err
+	} else if !wtf.CanEditDial(ctx, rule.Dial) {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You must be the owner or an admin to delete alert rules.")
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM alert_rules WHERE id = ?`, id); err != nil {
+		return FormatError(err)
+	}
+	return nil
+}
+
+// findAlerts returns a list of alerts on dials the current user is a member
+// of, most recent first. Also returns a count of total matching alerts.
+func findAlerts(ctx context.Context, tx *Tx, filter wtf.AlertFilter) (_ []*wtf.Alert, n int, err error) {
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := filter.AlertRuleID; v != nil {
+		where, args = append(where, "alert_rule_id = ?"), append(args, *v)
+	}
+	if v := filter.DialID; v != nil {
+		where, args = append(where, "dial_id = ?"), append(args, *v)
+	}
+
+	// Limit to alerts on dials the user is a member of.
+	where = append(where, `dial_id IN (SELECT dial_id FROM dial_memberships WHERE user_id = ?)`)
+	args = append(args, wtf.UserIDFromContext(ctx))
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+		    id,
+		    alert_rule_id,
+		    dial_id,
+		    value,
+		    fired_at,
+		    resolved_at,
+		    COUNT(*) OVER()
+		FROM alerts
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY fired_at DESC, id DESC
+		`+FormatLimitOffset(filter.Limit, filter.Offset),
+		args...,
+	)
+	if err != nil {
+		return nil, n, FormatError(err)
+	}
+	defer rows.Close()
+
+	alerts := make([]*wtf.Alert, 0)
+	for rows.Next() {
+		var alert wtf.Alert
+		var resolvedAt NullTime
+		if err := rows.Scan(
+			&alert.ID,
+			&alert.AlertRuleID,
+			&alert.DialID,
+			&alert.Value,
+			(*NullTime)(&alert.FiredAt),
+			&resolvedAt,
+			&n,
+		); err != nil {
+			return nil, 0, err
+		}
+
+		if t := time.Time(resolvedAt); !t.IsZero() {
+			alert.ResolvedAt = &t
+		}
+		alerts = append(alerts, &alert)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return alerts, n, nil
+}
+
+// evaluateDialAlertRules evaluates each alert rule on a dial against the dial
+// value history. Rules which start to match are fired & rules which stop
+// matching are resolved. Dial members are notified of each change in state.
+func evaluateDialAlertRules(ctx context.Context, tx *Tx, dialID int) error {
+	rules, err := findAlertRulesByDialID(ctx, tx, dialID)
+	if err != nil {
+		return err
+	} else if len(rules) == 0 {
+		return nil
+	}
+
+	for _, rule := range rules {
+		records, err := findDialValueRecordsSince(ctx, tx, dialID, tx.now.Add(-rule.Duration))
+		if err != nil {
+			return err
+		}
+
+		// Skip if the rule state does not change.
+		firing := rule.Evaluate(records, tx.now)
+		if firing == (rule.State == wtf.AlertRuleStateFiring) {
+			continue
+		}
+
+		// Use the current dial value for the alert.
+		var value int
+		if len(records) > 0 {
+			value = records[len(records)-1].Value
+		}
+
+		if firing {
+			if err := fireAlertRule(ctx, tx, rule, value); err != nil {
+				return fmt.Errorf("fire alert rule: %w", err)
+			}
+		} else {
+			if err := resolveAlertRule(ctx, tx, rule, value); err != nil {
+				return fmt.Errorf("resolve alert rule: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// fireAlertRule records a new alert for the rule & notifies dial members.
+func fireAlertRule(ctx context.Context, tx *Tx, rule *wtf.AlertRule, value int) error {
+	if err := setAlertRuleState(ctx, tx, rule, wtf.AlertRuleStateFiring); err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO alerts (alert_rule_id, dial_id, value, fired_at)
+		VALUES (?, ?, ?, ?)
+	`,
+		rule.ID, rule.DialID, value, (*NullTime)(&tx.now),
+	)
+	if err != nil {
+		return FormatError(err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	return publishDialEvent(ctx, tx, rule.DialID, wtf.Event{
+		Type: wtf.EventTypeAlertFired,
+		Payload: &wtf.AlertPayload{
+			ID:          int(id),
+			AlertRuleID: rule.ID,
+			DialID:      rule.DialID,
+			Value:       value,
+		},
+	})
+}
+
+// resolveAlertRule marks the rule's open alert as resolved & notifies dial members.
+func resolveAlertRule(ctx context.Context, tx *Tx, rule *wtf.AlertRule, value int) error {
+	if err := setAlertRuleState(ctx, tx, rule, wtf.AlertRuleStateOK); err != nil {
+		return err
+	}
+
+	// Look up the open alert so it can be included in the event.
+	var id int
+	if err := tx.QueryRowContext(ctx, `
+		SELECT id
+		FROM alerts
+		WHERE alert_rule_id = ?
+		  AND resolved_at IS NULL
+		ORDER BY id DESC
+		LIMIT 1
+	`,
+		rule.ID,
+	).Scan(&id); err != nil {
+		return FormatError(err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE alerts
+		SET resolved_at = ?
+		WHERE id = ?
+	`,
+		(*NullTime)(&tx.now), id,
+	); err != nil {
+		return FormatError(err)
+	}
+
+	return publishDialEvent(ctx, tx, rule.DialID, wtf.Event{
+		Type: wtf.EventTypeAlertResolved,
+		Payload: &wtf.AlertPayload{
+			ID:          id,
+			AlertRuleID: rule.ID,
+			DialID:      rule.DialID,
+			Value:       value,
+		},
+	})
+}
+
+// setAlertRuleState updates the state of the rule.
+func setAlertRuleState(ctx context.Context, tx *Tx, rule *wtf.AlertRule, state string) error {
+	rule.State, rule.UpdatedAt = state, tx.now
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE alert_rules
+		SET state = ?,
+		    updated_at = ?
+		WHERE id = ?
+	`,
+		rule.State,
+		(*NullTime)(&rule.UpdatedAt),
+		rule.ID,
+	); err != nil {
+		return FormatError(err)
+	}
+	return nil
+}
+
+// attachAlertRuleAssociations attaches the dial to the alert rule.
+func attachAlertRuleAssociations(ctx context.Context, tx *Tx, rule *wtf.AlertRule) (err error) {
+	if rule.Dial, err = findDialByID(ctx, tx, rule.DialID); err != nil {
+		return fmt.Errorf("attach alert rule dial: %w", err)
+	}
+	return nil
+}
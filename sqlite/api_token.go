@@ -0,0 +1,254 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+)
+
+// Ensure service implements interface.
+var _ wtf.APITokenService = (*APITokenService)(nil)
+
+// APITokenService represents a service for managing API tokens in SQLite.
+type APITokenService struct {
+	db *DB
+}
+
+// NewAPITokenService returns a new instance of APITokenService.
+func NewAPITokenService(db *DB) *APITokenService {
+	return &APITokenService{db: db}
+}
+
+// FindAPITokens retrieves a list of the current user's tokens by filter.
+// Also returns a count of total matching tokens which may differ if "Limit"
+// is specified on the filter.
+func (s *APITokenService) FindAPITokens(ctx context.Context, filter wtf.APITokenFilter) ([]*wtf.APIToken, int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+	return findAPITokens(ctx, tx, filter)
+}
+
+// CreateAPIToken creates a new token for the current user. The plaintext
+// token is set on token.Token & only its hash is stored.
+func (s *APITokenService) CreateAPIToken(ctx context.Context, token *wtf.APIToken) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := createAPIToken(ctx, tx, token); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteAPIToken permanently revokes a token. Returns ENOTFOUND if the token
+// does not exist or belongs to another user.
+func (s *APITokenService) DeleteAPIToken(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteAPIToken(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// AuthenticateAPIToken looks up a token by its plaintext value, records its
+// use & attaches its user. Returns ENOTFOUND if the token does not exist or
+// EUNAUTHORIZED if the token has expired.
+func (s *APITokenService) AuthenticateAPIToken(ctx context.Context, token string) (*wtf.APIToken, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	apiToken, err := authenticateAPIToken(ctx, tx, token)
+	if err != nil {
+		return nil, err
+	}
+	return apiToken, tx.Commit()
+}
+
+// findAPITokens returns a list of the current user's tokens. Also returns a
+// count of total matching tokens.
+func findAPITokens(ctx context.Context, tx *Tx, filter wtf.APITokenFilter) (_ []*wtf.APIToken, n int, err error) {
+	where, args := []string{"user_id = ?"}, []interface{}{wtf.UserIDFromContext(ctx)}
+	if v := filter.ID; v != nil {
+		where, args = append(where, "id = ?"), append(args, *v)
+	}
+	return queryAPITokens(ctx, tx, where, args, filter.Limit, filter.Offset)
+}
+
+// queryAPITokens executes a query against the api_tokens table using the
+// given WHERE clause segments which are AND-ed together.
+func queryAPITokens(ctx context.Context, tx *Tx, where []string, args []interface{}, limit, offset int) (_ []*wtf.APIToken, n int, err error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+		    id,
+		    user_id,
+		    name,
+		    scope,
+		    prefix,
+		    expires_at,
+		    last_used_at,
+		    created_at,
+		    COUNT(*) OVER()
+		FROM api_tokens
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY id ASC
+		`+FormatLimitOffset(limit, offset),
+		args...,
+	)
+	if err != nil {
+		return nil, n, FormatError(err)
+	}
+	defer rows.Close()
+
+	tokens := make([]*wtf.APIToken, 0)
+	for rows.Next() {
+		var token wtf.APIToken
+		var expiresAt, lastUsedAt NullTime
+		if err := rows.Scan(
+			&token.ID,
+			&token.UserID,
+			&token.Name,
+			&token.Scope,
+			&token.Prefix,
+			&expiresAt,
+			&lastUsedAt,
+			(*NullTime)(&token.CreatedAt),
+			&n,
+		); err != nil {
+			return nil, 0, err
+		}
+
+		if t := time.Time(expiresAt); !t.IsZero() {
+			token.ExpiresAt = &t
+		}
+		if t := time.Time(lastUsedAt); !t.IsZero() {
+			token.LastUsedAt = &t
+		}
+		tokens = append(tokens, &token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return tokens, n, nil
+}
+
+// createAPIToken generates a new token for the current user & stores its hash.
+func createAPIToken(ctx context.Context, tx *Tx, token *wtf.APIToken) error {
+	userID := wtf.UserIDFromContext(ctx)
+	if userID == 0 {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You must be logged in to create an API token.")
+	}
+
+	// Perform basic field validation.
+	if err := token.Validate(); err != nil {
+		return err
+	} else if token.IsExpired(tx.now) {
+		return wtf.Errorf(wtf.EINVALID, "API token expiration must be in the future.")
+	}
+
+	// Generate a random token. Only the prefix is kept in plaintext.
+	code, err := generateInviteCode()
+	if err != nil {
+		return err
+	}
+	token.Token = wtf.APITokenPrefix + code
+	token.Prefix = token.Token[:wtf.APITokenDisplayPrefixLen]
+	token.UserID = userID
+	token.LastUsedAt = nil
+	token.CreatedAt = tx.now
+
+	// Insert row into database.
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO api_tokens (
+			user_id,
+			name,
+			scope,
+			prefix,
+			token_hash,
+			expires_at,
+			created_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`,
+		token.UserID,
+		token.Name,
+		token.Scope,
+		token.Prefix,
+		wtf.HashAPIToken(token.Token),
+		(*NullTime)(token.ExpiresAt),
+		(*NullTime)(&token.CreatedAt),
+	)
+	if err != nil {
+		return FormatError(err)
+	}
+
+	// Read back new token ID into caller argument.
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	token.ID = int(id)
+
+	return nil
+}
+
+// deleteAPIToken permanently removes one of the current user's tokens.
+func deleteAPIToken(ctx context.Context, tx *Tx, id int) error {
+	// Verify the token exists & belongs to the current user.
+	if tokens, _, err := findAPITokens(ctx, tx, wtf.APITokenFilter{ID: &id}); err != nil {
+		return err
+	} else if len(tokens) == 0 {
+		return &wtf.Error{Code: wtf.ENOTFOUND, Message: "API token not found."}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM api_tokens WHERE id = ?`, id); err != nil {
+		return FormatError(err)
+	}
+	return nil
+}
+
+// authenticateAPIToken looks up a token by the hash of its plaintext value
+// and records the time it was used.
+func authenticateAPIToken(ctx context.Context, tx *Tx, token string) (*wtf.APIToken, error) {
+	tokens, _, err := queryAPITokens(ctx, tx, []string{"token_hash = ?"}, []interface{}{wtf.HashAPIToken(token)}, 0, 0)
+	if err != nil {
+		return nil, err
+	} else if len(tokens) == 0 {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "API token not found."}
+	}
+	apiToken := tokens[0]
+
+	if apiToken.IsExpired(tx.now) {
+		return nil, wtf.Errorf(wtf.EUNAUTHORIZED, "API token expired.")
+	}
+
+	// Attach the token's user.
+	if apiToken.User, err = findUserByID(ctx, tx, apiToken.UserID); err != nil {
+		return nil, fmt.Errorf("find api token user: %w", err)
+	}
+
+	// Record when the token was last used.
+	lastUsedAt := tx.now
+	if _, err := tx.ExecContext(ctx, `UPDATE api_tokens SET last_used_at = ? WHERE id = ?`, (*NullTime)(&lastUsedAt), apiToken.ID); err != nil {
+		return nil, FormatError(err)
+	}
+	apiToken.LastUsedAt = &lastUsedAt
+
+	return apiToken, nil
+}
@@ -80,8 +80,18 @@ func (s *AuthService) CreateAuth(ctx context.Context, auth *wtf.Auth) error {
 	}
 	defer tx.Rollback()
 
+	// Auths can only be linked to an existing user by that user.
+	if auth.UserID != 0 && auth.UserID != wtf.UserIDFromContext(ctx) {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You are not allowed to link an auth to this user.")
+	}
+
 	// Check to see if the auth already exists for the given source.
 	if other, err := findAuthBySourceID(ctx, tx, auth.Source, auth.SourceID); err == nil {
+		// An account that is already linked cannot be moved to another user.
+		if auth.UserID != 0 && other.UserID != auth.UserID {
+			return wtf.Errorf(wtf.ECONFLICT, "This account is already linked to another user.")
+		}
+
 		// If an auth already exists for the source user, update with the new tokens.
 		if other, err = updateAuth(ctx, tx, other.ID, auth.AccessToken, auth.RefreshToken, auth.Expiry); err != nil {
 			return fmt.Errorf("cannot update auth: id=%d err=%w", other.ID, err)
@@ -113,6 +123,13 @@ func (s *AuthService) CreateAuth(ctx context.Context, auth *wtf.Auth) error {
 
 		// Assign the created/found user ID back to the auth object.
 		auth.UserID = auth.User.ID
+	} else if auth.UserID != 0 {
+		// When linking to an existing user, only one auth per source is allowed.
+		if _, n, err := findAuths(ctx, tx, wtf.AuthFilter{UserID: &auth.UserID, Source: &auth.Source}); err != nil {
+			return err
+		} else if n != 0 {
+			return wtf.Errorf(wtf.ECONFLICT, "An account from this provider is already linked.")
+		}
 	}
 
 	// Create new auth object & attach associated user.
@@ -45,7 +45,7 @@ func (s *DialService) FindDialByID(ctx context.Context, id int) (*wtf.Dial, erro
 }
 
 // FindDials retrieves a list of dials based on a filter. Only returns dials
-// that the user owns or is a member of.
+// that the user owns or is a member of unless filtering by organization.
 //
 // Also returns a count of total matching dials which may different from the
 // number of returned dials if the  "Limit" field is set.
@@ -128,12 +128,54 @@ func (s *DialService) DeleteDial(ctx context.Context, id int) error {
 	return tx.Commit()
 }
 
-// Sets the value of the user's membership in a dial. This works the same
-// as calling UpdateDialMembership() although it doesn't require that the
-// user know their membership ID. Only the dial ID.
+// TransferDial transfers ownership of a dial to another member of the dial.
+// Only the dial owner may transfer a dial & the previous owner remains as an
+// admin. Returns ENOTFOUND if dial does not exist. Returns EUNAUTHORIZED if
+// user is not the dial owner. Returns EINVALID if the new owner is not a member.
+func (s *DialService) TransferDial(ctx context.Context, id, userID int) (*wtf.Dial, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Transfer the dial and attach the new owner user to the returned dial.
+	dial, err := transferDial(ctx, tx, id, userID)
+	if err != nil {
+		return dial, err
+	} else if err := attachDialAssociations(ctx, tx, dial); err != nil {
+		return dial, err
+	}
+	return dial, tx.Commit()
+}
+
+// RotateDialInvite generates a new invite code for the dial with optional
+// limits. The previous code stops working immediately. Only the dial owner &
+// admins can rotate the invite code. Returns ENOTFOUND if dial does not exist.
+// Returns EUNAUTHORIZED if user is not the dial owner or an admin.
+func (s *DialService) RotateDialInvite(ctx context.Context, id int, opt wtf.DialInviteOptions) (*wtf.Dial, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Replace the invite code and attach associated user to returned dial.
+	dial, err := rotateDialInvite(ctx, tx, id, opt)
+	if err != nil {
+		return dial, err
+	} else if err := attachDialAssociations(ctx, tx, dial); err != nil {
+		return dial, err
+	}
+	return dial, tx.Commit()
+}
+
+// Sets the value of the user's membership in a dial along with an optional
+// note. This works the same as calling UpdateDialMembership() although it
+// doesn't require that the user know their membership ID. Only the dial ID.
 //
 // Returns ENOTFOUND if the membership does not exist.
-func (s *DialService) SetDialMembershipValue(ctx context.Context, dialID, value int) error {
+func (s *DialService) SetDialMembershipValue(ctx context.Context, dialID, value int, note string) error {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
@@ -155,7 +197,7 @@ func (s *DialService) SetDialMembershipValue(ctx context.Context, dialID, value
 	}
 
 	// Update value on membership.
-	if _, err := updateDialMembership(ctx, tx, memberships[0].ID, wtf.DialMembershipUpdate{Value: &value}); err != nil {
+	if _, err := updateDialMembership(ctx, tx, memberships[0].ID, wtf.DialMembershipUpdate{Value: &value, Note: &note}); err != nil {
 		return err
 	}
 	return tx.Commit()
@@ -207,14 +249,15 @@ func (s *DialService) AverageDialValueReport(ctx context.Context, start, end tim
 	}
 	defer tx.Rollback()
 
-	// Ensure start/end line up with the interval unit.
-	start = start.Truncate(interval).UTC()
-	end = end.Truncate(interval).UTC()
+	// Ensure start/end line up with the interval unit in the user's timezone.
+	loc := wtf.LocationFromContext(ctx)
+	start = wtf.TruncateReportTime(start, interval, loc).UTC()
+	end = wtf.TruncateReportTime(end, interval, loc).UTC()
 
-	// Compute the number of slots between start & end.
-	slotN := int(end.Sub(start) / interval)
+	// Compute the start of each slot between start & end.
+	slots := wtf.ReportSlotTimes(start, end, interval, loc)
 	report := &wtf.DialValueReport{
-		Records: make([]*wtf.DialValueRecord, slotN),
+		Records: make([]*wtf.DialValueRecord, len(slots)),
 	}
 
 	// Fetch all dials which user is a member or owner.
@@ -226,7 +269,7 @@ func (s *DialService) AverageDialValueReport(ctx context.Context, start, end tim
 	// Iterate over each dial and compute value at each slot.
 	valuesSlice := make([][]int, len(dials))
 	for i, dial := range dials {
-		values, err := findDialValueSlotsBetween(ctx, tx, dial.ID, start, end, interval)
+		values, err := findValueSlotsBetween(ctx, tx, "dial_values", "dial_id", dial.ID, slots, end)
 		if err != nil {
 			return nil, fmt.Errorf("dial values between: id=%d err=%w", dial.ID, err)
 		}
@@ -234,7 +277,7 @@ func (s *DialService) AverageDialValueReport(ctx context.Context, start, end tim
 	}
 
 	// Compute average for each slot.
-	for i := 0; i < slotN; i++ {
+	for i := range slots {
 		var avg int
 		if len(dials) != 0 {
 			var sum int
@@ -246,7 +289,7 @@ func (s *DialService) AverageDialValueReport(ctx context.Context, start, end tim
 
 		// Append record for avg value at a given time.
 		report.Records[i] = &wtf.DialValueRecord{
-			Timestamp: start.Add(time.Duration(i) * interval),
+			Timestamp: slots[i],
 			Value:     avg,
 		}
 	}
@@ -254,6 +297,138 @@ func (s *DialService) AverageDialValueReport(ctx context.Context, start, end tim
 	return report, nil
 }
 
+// DialValueReport returns a report with a separate series of historical
+// values for each of the given dials. Values are slotted into intervals
+// between start & end time. If no dial IDs are specified then every dial
+// that the user is a member of is included. Each series includes a summary
+// & each record optionally includes statistics about its interval.
+func (s *DialService) DialValueReport(ctx context.Context, dialIDs []int, start, end time.Time, interval time.Duration, opt wtf.DialValueReportOptions) (*wtf.DialValueReport, error) {
+	if err := wtf.ValidateReportRange(start, end, interval); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Ensure start/end line up with the interval unit in the user's timezone.
+	loc := wtf.LocationFromContext(ctx)
+	start = wtf.TruncateReportTime(start, interval, loc).UTC()
+	end = wtf.TruncateReportTime(end, interval, loc).UTC()
+
+	// Fetch requested dials or all dials which user is a member of.
+	dials, err := findReportDials(ctx, tx, dialIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build a series for each dial from the values within each slot. The
+	// value of a slot is the last value within it.
+	report := &wtf.DialValueReport{
+		Series: make([]*wtf.DialValueSeries, len(dials)),
+	}
+	slots := wtf.ReportSlotTimes(start, end, interval, loc)
+	for i, dial := range dials {
+		samples, err := findValueSamplesBetween(ctx, tx, "dial_values", "dial_id", dial.ID, slots, end)
+		if err != nil {
+			return nil, fmt.Errorf("dial values between: id=%d err=%w", dial.ID, err)
+		}
+
+		series := &wtf.DialValueSeries{
+			DialID:  dial.ID,
+			Name:    dial.Name,
+			Records: make([]*wtf.DialValueRecord, len(samples)),
+		}
+		for j, a := range samples {
+			series.Records[j] = &wtf.DialValueRecord{
+				Timestamp: slots[j],
+				Value:     a[len(a)-1],
+			}
+			if opt.Stats {
+				series.Records[j].Stats = wtf.NewDialValueStats(a)
+			}
+		}
+		series.Summary = wtf.NewDialValueSummary(series.Records, interval, opt.Threshold)
+		report.Series[i] = series
+	}
+
+	return report, nil
+}
+
+// DialHeatmapReport returns the average value of the given dials for each
+// hour of each day of the week between start & end time. Hours & days are
+// based on the user's timezone. If no dial IDs are specified then every dial
+// that the user is a member of is included.
+func (s *DialService) DialHeatmapReport(ctx context.Context, dialIDs []int, start, end time.Time) (*wtf.DialHeatmapReport, error) {
+	if err := wtf.ValidateReportRange(start, end, time.Hour); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Align start/end to hours in the user's timezone. Hours in the future
+	// are excluded as their values are not yet known.
+	loc := wtf.LocationFromContext(ctx)
+	start = wtf.TruncateReportTime(start, time.Hour, loc).UTC()
+	end = wtf.TruncateReportTime(end, time.Hour, loc).UTC()
+	if now := wtf.TruncateReportTime(tx.now, time.Hour, loc).Add(time.Hour).UTC(); end.After(now) {
+		end = now
+	}
+
+	// Fetch requested dials or all dials which user is a member of.
+	dials, err := findReportDials(ctx, tx, dialIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add the value of each dial at every hour to the matching cell.
+	report := &wtf.DialHeatmapReport{}
+	if !end.After(start) {
+		return report, nil
+	}
+	slots := wtf.ReportSlotTimes(start, end, time.Hour, loc)
+	for _, dial := range dials {
+		values, err := findValueSlotsBetween(ctx, tx, "dial_values", "dial_id", dial.ID, slots, end)
+		if err != nil {
+			return nil, fmt.Errorf("dial values between: id=%d err=%w", dial.ID, err)
+		}
+		for i, value := range values {
+			report.Add(slots[i].In(loc), value)
+		}
+	}
+
+	return report, nil
+}
+
+// findReportDials returns the dials for the given IDs. Each dial must be
+// visible to the user. If no IDs are specified then all dials which the user
+// is a member of are returned.
+func findReportDials(ctx context.Context, tx *Tx, ids []int) ([]*wtf.Dial, error) {
+	if len(ids) == 0 {
+		dials, _, err := findDials(ctx, tx, wtf.DialFilter{})
+		if err != nil {
+			return nil, fmt.Errorf("find dials: %w", err)
+		}
+		return dials, nil
+	}
+
+	dials := make([]*wtf.Dial, 0, len(ids))
+	for _, id := range ids {
+		dial, err := findDialByID(ctx, tx, id)
+		if err != nil {
+			return nil, err
+		}
+		dials = append(dials, dial)
+	}
+	return dials, nil
+}
+
 // findDialByID is a helper function to retrieve a dial by ID.
 // Returns ENOTFOUND if dial doesn't exist.
 func findDialByID(ctx context.Context, tx *Tx, id int) (*wtf.Dial, error) {
@@ -286,36 +461,89 @@ func checkDialExists(ctx context.Context, tx *Tx, id int) error {
 func findDials(ctx context.Context, tx *Tx, filter wtf.DialFilter) (_ []*wtf.Dial, n int, err error) {
 	// Build WHERE clause. Each part of the WHERE clause is AND-ed together.
 	// Values are appended to an arg list to avoid SQL injection.
-	where, args := []string{"1 = 1"}, []interface{}{}
+	//
+	// The first arg is used to look up the current user's role in the dial.
+	userID := wtf.UserIDFromContext(ctx)
+	where, args := []string{"1 = 1"}, []interface{}{userID}
 	if v := filter.ID; v != nil {
 		where, args = append(where, "id = ?"), append(args, *v)
 	}
 
+	if v := filter.OrganizationID; v != nil {
+		where, args = append(where, "organization_id = ?"), append(args, *v)
+	}
+	if v := filter.UserID; v != nil {
+		where, args = append(where, "user_id = ?"), append(args, *v)
+	}
+	if v := filter.Tag; v != nil {
+		where, args = append(where, "id IN (SELECT dt.dial_id FROM dial_tags dt WHERE dt.tag = ?)"), append(args, wtf.NormalizeDialTag(*v))
+	}
+	if v := filter.Name; v != nil {
+		where, args = append(where, "INSTR(LOWER(name), LOWER(?)) > 0"), append(args, *v)
+	}
+	if v := filter.MinValue; v != nil {
+		where, args = append(where, "value >= ?"), append(args, *v)
+	}
+	if v := filter.MaxValue; v != nil {
+		where, args = append(where, "value <= ?"), append(args, *v)
+	}
+
 	// Limit to dials user is a member of unless searching by invite code.
+	// Dials within the user's organizations are also visible when looking
+	// up a single dial or listing an organization's dials.
 	if v := filter.InviteCode; v != nil {
 		where, args = append(where, "invite_code = ?"), append(args, *v)
+	} else if filter.ID != nil || filter.OrganizationID != nil {
+		where = append(where, `(
+			id IN (SELECT dial_id FROM dial_memberships dm WHERE dm.user_id = ?) OR
+			organization_id IN (SELECT organization_id FROM organization_members om WHERE om.user_id = ?)
+		)`)
+		args = append(args, userID, userID)
 	} else {
-		userID := wtf.UserIDFromContext(ctx)
 		where = append(where, `(
 			id IN (SELECT dial_id FROM dial_memberships dm WHERE dm.user_id = ?)
 		)`)
 		args = append(args, userID)
 	}
 
+	// Determine sorting. Ties are always broken by ID.
+	var sortBy string
+	switch filter.SortBy {
+	case wtf.DialSortByNameAsc:
+		sortBy = "name COLLATE NOCASE ASC"
+	case wtf.DialSortByValueAsc:
+		sortBy = "value ASC"
+	case wtf.DialSortByValueDesc:
+		sortBy = "value DESC"
+	case wtf.DialSortByUpdatedAtDesc:
+		sortBy = "updated_at DESC"
+	default:
+		sortBy = "id ASC"
+	}
+
 	// Execue query with limiting WHERE clause and LIMIT/OFFSET injected.
 	rows, err := tx.QueryContext(ctx, `
 		SELECT 
 		    id,
 		    user_id,
 		    name,
+		    IFNULL((SELECT GROUP_CONCAT(dt.tag) FROM dial_tags dt WHERE dt.dial_id = dials.id), ''),
+		    organization_id,
 		    value,
+		    aggregation,
+		    aggregation_percentile,
+		    aggregation_trim,
 		    invite_code,
+		    invite_expires_at,
+		    invite_max_uses,
+		    invite_use_count,
+		    IFNULL((SELECT dm.role FROM dial_memberships dm WHERE dm.dial_id = dials.id AND dm.user_id = ?), ''),
 		    created_at,
 		    updated_at,
 		    COUNT(*) OVER()
 		FROM dials
 		WHERE `+strings.Join(where, " AND ")+`
-		ORDER BY id ASC
+		ORDER BY `+sortBy+`, id ASC
 		`+FormatLimitOffset(filter.Limit, filter.Offset),
 		args...,
 	)
@@ -328,18 +556,46 @@ func findDials(ctx context.Context, tx *Tx, filter wtf.DialFilter) (_ []*wtf.Dia
 	dials := make([]*wtf.Dial, 0)
 	for rows.Next() {
 		var dial wtf.Dial
+		var tags string
+		var organizationID sql.NullInt64
+		var inviteExpiresAt NullTime
 		if err := rows.Scan(
 			&dial.ID,
 			&dial.UserID,
 			&dial.Name,
+			&tags,
+			&organizationID,
 			&dial.Value,
+			&dial.Aggregation,
+			&dial.Percentile,
+			&dial.Trim,
 			&dial.InviteCode,
+			&inviteExpiresAt,
+			&dial.InviteMaxUses,
+			&dial.InviteUseCount,
+			&dial.Role,
 			(*NullTime)(&dial.CreatedAt),
 			(*NullTime)(&dial.UpdatedAt),
 			&n,
 		); err != nil {
 			return nil, 0, err
 		}
+
+		if t := time.Time(inviteExpiresAt); !t.IsZero() {
+			dial.InviteExpiresAt = &t
+		}
+		dial.OrganizationID = int(organizationID.Int64)
+
+		// Omit the invite code if the current API token cannot read it.
+		if !wtf.CanViewInviteCodes(ctx) {
+			dial.InviteCode = ""
+		}
+
+		// Tags can be split on commas as they cannot contain a comma.
+		if tags != "" {
+			dial.Tags = wtf.NormalizeDialTags(strings.Split(tags, ","))
+		}
+
 		dials = append(dials, &dial)
 	}
 	if err := rows.Err(); err != nil {
@@ -359,12 +615,20 @@ func createDial(ctx context.Context, tx *Tx, dial *wtf.Dial) error {
 	}
 	dial.UserID = wtf.UserIDFromContext(ctx)
 
-	// Generate a random invite code.
-	inviteCode := make([]byte, 16)
-	if _, err := io.ReadFull(rand.Reader, inviteCode); err != nil {
+	// Generate a random invite code. New invite codes have no limits until
+	// the code is rotated.
+	inviteCode, err := generateInviteCode()
+	if err != nil {
 		return err
 	}
-	dial.InviteCode = hex.EncodeToString(inviteCode)
+	dial.InviteCode = inviteCode
+	dial.InviteExpiresAt, dial.InviteMaxUses, dial.InviteUseCount = nil, 0, 0
+
+	// Default to averaging member values.
+	if dial.Aggregation == "" {
+		dial.Aggregation = wtf.DialAggregationMean
+	}
+	dial.Tags = wtf.NormalizeDialTags(dial.Tags)
 
 	// Set timestamps to current time.
 	dial.CreatedAt = tx.now
@@ -377,19 +641,38 @@ func createDial(ctx context.Context, tx *Tx, dial *wtf.Dial) error {
 		return err
 	}
 
+	// Ensure the user is a member of the dial's organization, if any.
+	var organizationID interface{}
+	if dial.OrganizationID != 0 {
+		if _, err := findOrganizationByID(ctx, tx, dial.OrganizationID); wtf.ErrorCode(err) == wtf.ENOTFOUND {
+			return wtf.Errorf(wtf.EUNAUTHORIZED, "You must be a member of the organization to create a dial in it.")
+		} else if err != nil {
+			return err
+		}
+		organizationID = dial.OrganizationID
+	}
+
 	// Insert row into database.
 	result, err := tx.ExecContext(ctx, `
 		INSERT INTO dials (
 			user_id,
 			name,
+			organization_id,
+			aggregation,
+			aggregation_percentile,
+			aggregation_trim,
 			invite_code,
 			created_at,
 			updated_at
 		)
-		VALUES (?, ?, ?, ?, ?)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
 		dial.UserID,
 		dial.Name,
+		organizationID,
+		dial.Aggregation,
+		dial.Percentile,
+		dial.Trim,
 		dial.InviteCode,
 		(*NullTime)(&dial.CreatedAt),
 		(*NullTime)(&dial.UpdatedAt),
@@ -405,6 +688,16 @@ func createDial(ctx context.Context, tx *Tx, dial *wtf.Dial) error {
 	}
 	dial.ID = int(id)
 
+	// Attach tags to the new dial.
+	if err := replaceDialTags(ctx, tx, dial.ID, dial.Tags); err != nil {
+		return fmt.Errorf("replace dial tags: %w", err)
+	}
+
+	// Add the dial name to the search index.
+	if err := reindexDial(ctx, tx, dial.ID); err != nil {
+		return fmt.Errorf("reindex dial: %w", err)
+	}
+
 	// Record initial value to history table.
 	if err := insertDialValue(ctx, tx, dial.ID, dial.Value, dial.CreatedAt); err != nil {
 		return fmt.Errorf("insert initial value: %w", err)
@@ -414,27 +707,50 @@ func createDial(ctx context.Context, tx *Tx, dial *wtf.Dial) error {
 	if err := createDialMembership(ctx, tx, &wtf.DialMembership{
 		DialID: dial.ID,
 		UserID: dial.UserID,
+		Role:   wtf.DialMembershipRoleOwner,
 	}); err != nil {
 		return fmt.Errorf("create self-membership: %w", err)
 	}
+	dial.Role = wtf.DialMembershipRoleOwner
 
 	return nil
 }
 
+// generateInviteCode returns a new random invite code.
+func generateInviteCode() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
 // updateDial updates a dial by ID. Returns the new state of the dial after update.
 func updateDial(ctx context.Context, tx *Tx, id int, upd wtf.DialUpdate) (*wtf.Dial, error) {
-	// Fetch current object state. Return an error if current user is not owner.
+	// Fetch current object state. Return an error if current user is not owner or admin.
 	dial, err := findDialByID(ctx, tx, id)
 	if err != nil {
 		return dial, err
 	} else if !wtf.CanEditDial(ctx, dial) {
-		return dial, wtf.Errorf(wtf.EUNAUTHORIZED, "You must be the owner can edit a dial.")
+		return dial, wtf.Errorf(wtf.EUNAUTHORIZED, "You must be the owner or an admin to edit a dial.")
 	}
 
 	// Update fields, if set.
 	if v := upd.Name; v != nil {
 		dial.Name = *v
 	}
+	if v := upd.Aggregation; v != nil {
+		dial.Aggregation = *v
+	}
+	if v := upd.Percentile; v != nil {
+		dial.Percentile = *v
+	}
+	if v := upd.Trim; v != nil {
+		dial.Trim = *v
+	}
+	if v := upd.Tags; v != nil {
+		dial.Tags = wtf.NormalizeDialTags(*v)
+	}
 	dial.UpdatedAt = tx.now
 
 	// Perform basic field validation.
@@ -446,59 +762,279 @@ func updateDial(ctx context.Context, tx *Tx, id int, upd wtf.DialUpdate) (*wtf.D
 	if _, err := tx.ExecContext(ctx, `
 		UPDATE dials
 		SET name = ?,
+		    aggregation = ?,
+		    aggregation_percentile = ?,
+		    aggregation_trim = ?,
 		    updated_at = ?
 		WHERE id = ?
 	`,
 		dial.Name,
+		dial.Aggregation,
+		dial.Percentile,
+		dial.Trim,
 		(*NullTime)(&dial.UpdatedAt),
 		id,
 	); err != nil {
 		return dial, FormatError(err)
 	}
 
+	// Replace the dial's tags, if changed.
+	if upd.Tags != nil {
+		if err := replaceDialTags(ctx, tx, id, dial.Tags); err != nil {
+			return dial, fmt.Errorf("replace dial tags: %w", err)
+		}
+	}
+
+	// Update the dial name in the search index.
+	if err := reindexDial(ctx, tx, id); err != nil {
+		return dial, fmt.Errorf("reindex dial: %w", err)
+	}
+
+	// Recompute the dial value in case the aggregation settings changed.
+	if err := refreshDialValue(ctx, tx, id); err != nil {
+		return dial, fmt.Errorf("refresh dial value: %w", err)
+	} else if err := tx.QueryRowContext(ctx, `SELECT value FROM dials WHERE id = ?`, id).Scan(&dial.Value); err != nil {
+		return dial, FormatError(err)
+	}
+
 	return dial, nil
 }
 
+// replaceDialTags removes all existing tags from a dial & inserts the given tags.
+func replaceDialTags(ctx context.Context, tx *Tx, id int, tags []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM dial_tags WHERE dial_id = ?`, id); err != nil {
+		return FormatError(err)
+	}
+
+	for _, tag := range tags {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO dial_tags (dial_id, tag) VALUES (?, ?)`, id, tag); err != nil {
+			return FormatError(err)
+		}
+	}
+	return nil
+}
+
 // deleteDial permanently deletes a dial by ID. Returns EUNAUTHORIZED if user
 // does not own the dial.
 func deleteDial(ctx context.Context, tx *Tx, id int) error {
 	// Verify object exists & the current user is the owner.
 	if dial, err := findDialByID(ctx, tx, id); err != nil {
 		return err
-	} else if !wtf.CanEditDial(ctx, dial) {
+	} else if !wtf.CanDeleteDial(ctx, dial) {
 		return wtf.Errorf(wtf.EUNAUTHORIZED, "Only the owner can delete a dial.")
 	}
 
-	// Remove row from database.
+	// Remove row from database & remove the dial & its memberships from
+	// the search index.
 	if _, err := tx.ExecContext(ctx, `DELETE FROM dials WHERE id = ?`, id); err != nil {
 		return FormatError(err)
+	} else if err := unindexDial(ctx, tx, id); err != nil {
+		return fmt.Errorf("unindex dial: %w", err)
 	}
 	return nil
 }
 
-// refreshDialValue recomputes the WTF level of a dial by ID and saves it in dials.value.
-func refreshDialValue(ctx context.Context, tx *Tx, id int) error {
-	// Fetch current dial value.
-	var oldValue int
-	if err := tx.QueryRowContext(ctx, `SELECT value FROM dials WHERE id = ? `, id).Scan(&oldValue); err == sql.ErrNoRows {
-		return nil // no dial, skip
+// transferDial moves ownership of a dial to another member. The previous owner
+// remains on the dial as an admin. Returns the new state of the dial.
+func transferDial(ctx context.Context, tx *Tx, id, userID int) (*wtf.Dial, error) {
+	// Fetch current object state. Return an error if current user is not owner.
+	dial, err := findDialByID(ctx, tx, id)
+	if err != nil {
+		return dial, err
+	} else if !wtf.CanTransferDial(ctx, dial) {
+		return dial, wtf.Errorf(wtf.EUNAUTHORIZED, "Only the owner can transfer a dial.")
+	}
+
+	// Exit if the dial is already owned by the user.
+	if dial.UserID == userID {
+		return dial, nil
+	}
+
+	// Ensure the new owner is already a member of the dial.
+	if memberships, _, err := findDialMemberships(ctx, tx, wtf.DialMembershipFilter{
+		DialID: &id,
+		UserID: &userID,
+	}); err != nil {
+		return dial, err
+	} else if len(memberships) == 0 {
+		return dial, wtf.Errorf(wtf.EINVALID, "New owner must be a member of the dial.")
+	}
+
+	// Reassign the dial. The current user is now an admin of the dial.
+	prevUserID := dial.UserID
+	dial.UserID = userID
+	dial.Role = wtf.DialMembershipRoleAdmin
+	dial.UpdatedAt = tx.now
+
+	// Execute update query on the dial.
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE dials
+		SET user_id = ?,
+		    updated_at = ?
+		WHERE id = ?
+	`,
+		dial.UserID,
+		(*NullTime)(&dial.UpdatedAt),
+		id,
+	); err != nil {
+		return dial, FormatError(err)
+	}
+
+	// Swap the roles of the previous & new owner memberships.
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE dial_memberships
+		SET role = CASE user_id WHEN ? THEN ? ELSE ? END
+		WHERE dial_id = ?
+		  AND user_id IN (?, ?)
+	`,
+		userID, wtf.DialMembershipRoleOwner, wtf.DialMembershipRoleAdmin,
+		id,
+		userID, prevUserID,
+	); err != nil {
+		return dial, FormatError(err)
+	}
+
+	// Recompute the dial value in case the new owner was previously a viewer.
+	if err := refreshDialValue(ctx, tx, id); err != nil {
+		return dial, fmt.Errorf("refresh dial value: %w", err)
+	} else if err := tx.QueryRowContext(ctx, `SELECT value FROM dials WHERE id = ?`, id).Scan(&dial.Value); err != nil {
+		return dial, FormatError(err)
+	}
+
+	return dial, nil
+}
+
+// rotateDialInvite replaces the invite code & limits of a dial. The use count
+// is reset for the new code. Returns the new state of the dial.
+func rotateDialInvite(ctx context.Context, tx *Tx, id int, opt wtf.DialInviteOptions) (*wtf.Dial, error) {
+	// Fetch current object state. Return an error if current user is not owner or admin.
+	dial, err := findDialByID(ctx, tx, id)
+	if err != nil {
+		return dial, err
+	} else if !wtf.CanEditDial(ctx, dial) {
+		return dial, wtf.Errorf(wtf.EUNAUTHORIZED, "You must be the owner or an admin to rotate the invite code.")
+	}
+
+	// Ensure the new code does not expire immediately.
+	if opt.ExpiresAt != nil && !opt.ExpiresAt.After(tx.now) {
+		return dial, wtf.Errorf(wtf.EINVALID, "Invite expiration must be in the future.")
+	}
+
+	// Generate a new code & apply limits. Expiration is stored with the same
+	// precision as other timestamps.
+	if dial.InviteCode, err = generateInviteCode(); err != nil {
+		return dial, err
+	}
+	dial.InviteExpiresAt, dial.InviteMaxUses, dial.InviteUseCount = nil, opt.MaxUses, 0
+	if opt.ExpiresAt != nil {
+		t := opt.ExpiresAt.UTC().Truncate(time.Second)
+		dial.InviteExpiresAt = &t
+	}
+	dial.UpdatedAt = tx.now
+
+	// Perform basic field validation.
+	if err := dial.Validate(); err != nil {
+		return dial, err
+	}
+
+	// Execute update query.
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE dials
+		SET invite_code = ?,
+		    invite_expires_at = ?,
+		    invite_max_uses = ?,
+		    invite_use_count = 0,
+		    updated_at = ?
+		WHERE id = ?
+	`,
+		dial.InviteCode,
+		(*NullTime)(dial.InviteExpiresAt),
+		dial.InviteMaxUses,
+		(*NullTime)(&dial.UpdatedAt),
+		id,
+	); err != nil {
+		return dial, FormatError(err)
+	}
+
+	return dial, nil
+}
+
+// useDialInvite verifies that code is the current, usable invite code for a
+// dial & increments its use count. Returns EINVALID if the code is incorrect,
+// expired, or has reached its maximum number of uses.
+func useDialInvite(ctx context.Context, tx *Tx, id int, code string) error {
+	// Fetch the invite settings directly as the user is not yet a member.
+	var dial wtf.Dial
+	var inviteExpiresAt NullTime
+	if err := tx.QueryRowContext(ctx, `
+		SELECT invite_code, invite_expires_at, invite_max_uses, invite_use_count
+		FROM dials
+		WHERE id = ?
+	`,
+		id,
+	).Scan(
+		&dial.InviteCode,
+		&inviteExpiresAt,
+		&dial.InviteMaxUses,
+		&dial.InviteUseCount,
+	); err == sql.ErrNoRows {
+		return &wtf.Error{Code: wtf.ENOTFOUND, Message: "Dial not found."}
 	} else if err != nil {
 		return FormatError(err)
 	}
 
-	// Compute average value from dial memberships.
-	var newValue int
+	if t := time.Time(inviteExpiresAt); !t.IsZero() {
+		dial.InviteExpiresAt = &t
+	}
+
+	// Ensure the code matches & is still usable.
+	if dial.InviteCode != code {
+		return wtf.Errorf(wtf.EINVALID, "Invalid invite code.")
+	} else if err := dial.ValidateInvite(tx.now); err != nil {
+		return err
+	}
+
+	// Count this use of the invite code.
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE dials
+		SET invite_use_count = invite_use_count + 1
+		WHERE id = ?
+	`,
+		id,
+	); err != nil {
+		return FormatError(err)
+	}
+	return nil
+}
+
+// refreshDialValue recomputes the WTF level of a dial by ID and saves it in dials.value.
+func refreshDialValue(ctx context.Context, tx *Tx, id int) error {
+	// Fetch current dial value & aggregation settings.
+	var dial wtf.Dial
 	if err := tx.QueryRowContext(ctx, `
-		SELECT CAST(ROUND(IFNULL(AVG(value), 0)) AS INTEGER)
-		FROM dial_memberships
-		WHERE dial_id = ?
+		SELECT value, aggregation, aggregation_percentile, aggregation_trim
+		FROM dials
+		WHERE id = ?
 	`,
 		id,
 	).Scan(
-		&newValue,
-	); err != nil && err != sql.ErrNoRows {
+		&dial.Value,
+		&dial.Aggregation,
+		&dial.Percentile,
+		&dial.Trim,
+	); err == sql.ErrNoRows {
+		return nil // no dial, skip
+	} else if err != nil {
 		return FormatError(err)
 	}
+	oldValue := dial.Value
+
+	// Compute aggregate value from dial memberships.
+	values, weights, err := findDialMembershipValues(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	newValue := dial.AggregateValue(values, weights)
 
 	// Exit if the value will not change.
 	if oldValue == newValue {
@@ -535,9 +1071,44 @@ func refreshDialValue(ctx context.Context, tx *Tx, id int) error {
 		return fmt.Errorf("publish dial event: %w", err)
 	}
 
+	// Fire or resolve any alert rules affected by the change.
+	if err := evaluateDialAlertRules(ctx, tx, id); err != nil {
+		return fmt.Errorf("evaluate dial alert rules: %w", err)
+	}
+
 	return nil
 }
 
+// findDialMembershipValues returns the current value & weight of each
+// membership of a dial. Viewers are excluded as they do not contribute a value.
+func findDialMembershipValues(ctx context.Context, tx *Tx, id int) (values, weights []int, err error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT value, weight
+		FROM dial_memberships
+		WHERE dial_id = ?
+		  AND role <> ?
+	`,
+		id,
+		wtf.DialMembershipRoleViewer,
+	)
+	if err != nil {
+		return nil, nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value, weight int
+		if err := rows.Scan(&value, &weight); err != nil {
+			return nil, nil, err
+		}
+		values, weights = append(values, value), append(weights, weight)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return values, weights, nil
+}
+
 // insertDialValue records a dial value at specific point in time.
 func insertDialValue(ctx context.Context, tx *Tx, id int, value int, timestamp time.Time) error {
 	// Reduce our precision to only one update per minute.
@@ -556,32 +1127,48 @@ func insertDialValue(ctx context.Context, tx *Tx, id int, value int, timestamp t
 	return nil
 }
 
-// findDialValueSlotsBetween returns the value of a dial at given intervals in a time range.
+// findValueSlotsBetween returns the value of a dial or membership within each
+// slot up to end. Slots are the start times from wtf.ReportSlotTimes(). The
+// value of each slot is the last value within the slot. The table & column
+// identify the value history and its key.
+func findValueSlotsBetween(ctx context.Context, tx *Tx, table, column string, id int, slots []time.Time, end time.Time) ([]int, error) {
+	samples, err := findValueSamplesBetween(ctx, tx, table, column, id, slots, end)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]int, len(samples))
+	for i := range samples {
+		values[i] = samples[i][len(samples[i])-1]
+	}
+	return values, nil
+}
+
+// findValueSamplesBetween returns all values of a dial or membership within
+// each slot up to end. Each slot begins with the value
+// carried over from the previous slot followed by each value changed to within
+// the slot. The carried value is omitted if the value changed exactly at the
+// start of the slot. Values recorded at the same time are ordered by insertion.
 //
 // This function is implemented naively so that we build a set of slots, insert
-// values when they've changed, and then we backfill the empty slots with the
-// previous value.
+// values when they've changed, and then we backfill the carried values.
 //
 // There's probably a fancier way to do this in SQL but this was pretty easy.
-func findDialValueSlotsBetween(ctx context.Context, tx *Tx, id int, start, end time.Time, interval time.Duration) ([]int, error) {
-	values := make([]int, end.Sub(start)/interval)
-	if len(values) == 0 {
-		return values, nil
-	}
-
-	// Mark slots empty. We'll fill them in later.
-	for i := range values {
-		values[i] = -1
+func findValueSamplesBetween(ctx context.Context, tx *Tx, table, column string, id int, slots []time.Time, end time.Time) ([][]int, error) {
+	samples := make([][]int, len(slots))
+	if len(samples) == 0 {
+		return samples, nil
 	}
+	start := slots[0]
 
 	// Determine initial value at start of report time range.
 	var value int
 	if err := tx.QueryRowContext(ctx, `
 		SELECT value
-		FROM dial_values
-		WHERE dial_id = ?
+		FROM `+table+`
+		WHERE `+column+` = ?
 		  AND "timestamp" <= ?
-		ORDER BY "timestamp" DESC
+		ORDER BY "timestamp" DESC, rowid DESC
 		LIMIT 1
 		`,
 		id,
@@ -591,16 +1178,16 @@ func findDialValueSlotsBetween(ctx context.Context, tx *Tx, id int, start, end t
 	); err != nil && err != sql.ErrNoRows {
 		return nil, err
 	}
-	values[0] = value
+	lastValue := value
 
 	// Find all values between start & end.
 	rows, err := tx.QueryContext(ctx, `
 		SELECT value, "timestamp"
-		FROM dial_values
-		WHERE dial_id = ?
+		FROM `+table+`
+		WHERE `+column+` = ?
 		  AND "timestamp" >= ?
 		  AND "timestamp" < ?
-		ORDER BY "timestamp" ASC
+		ORDER BY "timestamp" ASC, rowid ASC
 	`,
 		id,
 		(*NullTime)(&start),
@@ -611,15 +1198,22 @@ func findDialValueSlotsBetween(ctx context.Context, tx *Tx, id int, start, end t
 	}
 	defer rows.Close()
 
-	// Iterate over rows and assign values to slots.
+	// Iterate over rows and append values to slots. Track which slots
+	// changed exactly at their start so no value is carried over.
+	changedAtStart := make([]bool, len(samples))
 	for rows.Next() {
 		var timestamp time.Time
 		if err := rows.Scan(&value, (*NullTime)(&timestamp)); err != nil {
 			return nil, err
 		}
 
-		i := int(timestamp.Sub(start) / interval)
-		values[i] = value
+		i := wtf.ReportSlotIndex(slots, timestamp)
+		if i < 0 || i >= len(samples) {
+			continue
+		} else if len(samples[i]) == 0 && timestamp.Equal(slots[i]) {
+			changedAtStart[i] = true
+		}
+		samples[i] = append(samples[i], value)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -627,17 +1221,54 @@ func findDialValueSlotsBetween(ctx context.Context, tx *Tx, id int, start, end t
 		return nil, err
 	}
 
-	// Iterate over values to fill empty slots.
-	var lastValue int
-	for i, v := range values {
-		if v != -1 {
-			lastValue = v
-			continue
+	// Iterate over slots to prepend the value carried from the previous slot.
+	for i := range samples {
+		if !changedAtStart[i] {
+			samples[i] = append([]int{lastValue}, samples[i]...)
 		}
-		values[i] = lastValue
+		lastValue = samples[i][len(samples[i])-1]
 	}
 
-	return values, nil
+	return samples, nil
+}
+
+// findDialValueRecordsSince returns the value history of a dial starting with
+// the last value recorded at or before start followed by every value recorded
+// after it. Records keep their original timestamps.
+func findDialValueRecordsSince(ctx context.Context, tx *Tx, id int, start time.Time) ([]*wtf.DialValueRecord, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT value, "timestamp"
+		FROM dial_values
+		WHERE dial_id = ?
+		  AND "timestamp" >= COALESCE((
+		    SELECT MAX("timestamp")
+		    FROM dial_values
+		    WHERE dial_id = ?
+		      AND "timestamp" <= ?
+		  ), '')
+		ORDER BY "timestamp" ASC
+	`,
+		id,
+		id,
+		(*NullTime)(&start),
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	records := make([]*wtf.DialValueRecord, 0)
+	for rows.Next() {
+		var record wtf.DialValueRecord
+		if err := rows.Scan(&record.Value, (*NullTime)(&record.Timestamp)); err != nil {
+			return nil, err
+		}
+		records = append(records, &record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
 }
 
 // publishDialEvent publishes event to the dial members.
@@ -661,6 +1292,11 @@ func publishDialEvent(ctx context.Context, tx *Tx, id int, event wtf.Event) erro
 	if err := rows.Err(); err != nil {
 		return err
 	}
+
+	// Queue the event for delivery to the dial's webhooks.
+	if err := insertWebhookDeliveries(ctx, tx, id, event); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -669,5 +1305,13 @@ func attachDialAssociations(ctx context.Context, tx *Tx, dial *wtf.Dial) (err er
 	if dial.User, err = findUserByID(ctx, tx, dial.UserID); err != nil {
 		return fmt.Errorf("attach dial user: %w", err)
 	}
+
+	// Attach the organization even if the user is not one of its members so
+	// that its name can be displayed with the dial.
+	if dial.OrganizationID != 0 {
+		if dial.Organization, err = lookupOrganization(ctx, tx, dial.OrganizationID); err != nil {
+			return fmt.Errorf("attach dial organization: %w", err)
+		}
+	}
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/benbjohnson/wtf"
 )
@@ -82,6 +83,24 @@ func (s *DialMembershipService) CreateDialMembership(ctx context.Context, member
 	}
 	membership.UserID = wtf.UserIDFromContext(ctx)
 
+	// New members always join with the member role.
+	membership.Role = wtf.DialMembershipRoleMember
+
+	// Read the invite code from the membership or from the attached dial.
+	if membership.InviteCode == "" && membership.Dial != nil {
+		membership.InviteCode = membership.Dial.InviteCode
+	}
+
+	// Dials can only be joined with an invite code unless they belong to one
+	// of the user's organizations. The invite code is verified & counted when
+	// the membership is created. Missing dial IDs are left for the membership
+	// validation to report.
+	if membership.DialID != 0 && membership.InviteCode == "" {
+		if err := checkDialOrganizationMember(ctx, tx, membership.DialID, userID); err != nil {
+			return err
+		}
+	}
+
 	// Create new membership and attach associated user & dial to returned data.
 	if err := createDialMembership(ctx, tx, membership); err != nil {
 		return err
@@ -111,8 +130,48 @@ func (s *DialMembershipService) UpdateDialMembership(ctx context.Context, id int
 	return membership, tx.Commit()
 }
 
+// SetDialMembershipWeight sets the weight of a membership. Only the owner &
+// admins of the parent dial can set the weight. Returns EUNAUTHORIZED if user
+// cannot manage the dial. Returns ENOTFOUND if the membership does not exist.
+func (s *DialMembershipService) SetDialMembershipWeight(ctx context.Context, id, weight int) (*wtf.DialMembership, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Update the weight and attach associated user & dial to returned data.
+	membership, err := setDialMembershipWeight(ctx, tx, id, weight)
+	if err != nil {
+		return membership, err
+	} else if err := attachDialMembershipAssociations(ctx, tx, membership); err != nil {
+		return membership, err
+	}
+	return membership, tx.Commit()
+}
+
+// SetDialMembershipRole sets the role of a membership. The owner role cannot
+// be assigned or removed. Returns EUNAUTHORIZED if user cannot change the role.
+// Returns ENOTFOUND if the membership does not exist.
+func (s *DialMembershipService) SetDialMembershipRole(ctx context.Context, id int, role string) (*wtf.DialMembership, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Update the role and attach associated user & dial to returned data.
+	membership, err := setDialMembershipRole(ctx, tx, id, role)
+	if err != nil {
+		return membership, err
+	} else if err := attachDialMembershipAssociations(ctx, tx, membership); err != nil {
+		return membership, err
+	}
+	return membership, tx.Commit()
+}
+
 // DeleteDialMembership permanently deletes a membership by ID. Only the
-// membership owner and the parent dial's owner can delete a membership.
+// membership owner and the parent dial's owner & admins can delete a membership.
 func (s *DialMembershipService) DeleteDialMembership(ctx context.Context, id int) error {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -126,6 +185,81 @@ func (s *DialMembershipService) DeleteDialMembership(ctx context.Context, id int
 	return tx.Commit()
 }
 
+// DialMembershipValueReport returns the historical values of a single
+// membership or of every membership on a dial. Values are slotted into
+// intervals between start & end time.
+func (s *DialMembershipService) DialMembershipValueReport(ctx context.Context, filter wtf.DialMembershipValueReportFilter, start, end time.Time, interval time.Duration) (*wtf.DialMembershipValueReport, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	} else if err := wtf.ValidateReportRange(start, end, interval); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Ensure start/end line up with the interval unit in the user's timezone.
+	loc := wtf.LocationFromContext(ctx)
+	start = wtf.TruncateReportTime(start, interval, loc).UTC()
+	end = wtf.TruncateReportTime(end, interval, loc).UTC()
+
+	// Verify the dial is visible to the user, if filtering by dial.
+	if filter.DialID != nil {
+		if _, err := findDialByID(ctx, tx, *filter.DialID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Fetch matching memberships. These are restricted to dials that the
+	// current user is a member of.
+	memberships, _, err := findDialMemberships(ctx, tx, wtf.DialMembershipFilter{
+		ID:     filter.ID,
+		DialID: filter.DialID,
+	})
+	if err != nil {
+		return nil, err
+	} else if filter.ID != nil && len(memberships) == 0 {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "Dial membership not found."}
+	}
+
+	// Build a series for each membership from its value at each slot.
+	report := &wtf.DialMembershipValueReport{
+		Series: make([]*wtf.DialMembershipValueSeries, len(memberships)),
+	}
+	slots := wtf.ReportSlotTimes(start, end, interval, loc)
+	for i, membership := range memberships {
+		user, err := findUserByID(ctx, tx, membership.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("find user: %w", err)
+		}
+
+		values, err := findValueSlotsBetween(ctx, tx, "dial_membership_values", "dial_membership_id", membership.ID, slots, end)
+		if err != nil {
+			return nil, fmt.Errorf("membership values between: id=%d err=%w", membership.ID, err)
+		}
+
+		series := &wtf.DialMembershipValueSeries{
+			DialMembershipID: membership.ID,
+			DialID:           membership.DialID,
+			UserID:           membership.UserID,
+			User:             user,
+			Records:          make([]*wtf.DialValueRecord, len(values)),
+		}
+		for j, value := range values {
+			series.Records[j] = &wtf.DialValueRecord{
+				Timestamp: slots[j],
+				Value:     value,
+			}
+		}
+		report.Series[i] = series
+	}
+
+	return report, nil
+}
+
 // findDialMembershipByID returns a membership object by ID.
 // Returns ENOTFOUND if membership does not exist.
 func findDialMembershipByID(ctx context.Context, tx *Tx, id int) (*wtf.DialMembership, error) {
@@ -178,6 +312,10 @@ func findDialMemberships(ctx context.Context, tx *Tx, filter wtf.DialMembershipF
 		    dm.dial_id,
 		    dm.user_id,
 		    dm.value,
+		    dm.weight,
+		    dm.role,
+		    dm.invite_code,
+		    dm.note,
 		    dm.created_at,
 		    dm.updated_at,
 		    d.user_id AS dial_user_id,
@@ -205,6 +343,10 @@ func findDialMemberships(ctx context.Context, tx *Tx, filter wtf.DialMembershipF
 			&membership.DialID,
 			&membership.UserID,
 			&membership.Value,
+			&membership.Weight,
+			&membership.Role,
+			&membership.InviteCode,
+			&membership.Note,
 			(*NullTime)(&membership.CreatedAt),
 			(*NullTime)(&membership.UpdatedAt),
 			&dialUserID,
@@ -222,9 +364,31 @@ func findDialMemberships(ctx context.Context, tx *Tx, filter wtf.DialMembershipF
 	return memberships, n, nil
 }
 
+// checkDialOrganizationMember returns ENOTFOUND unless the dial belongs to an
+// organization which the user is a member of. This is used to allow joining
+// organization dials without an invite code.
+func checkDialOrganizationMember(ctx context.Context, tx *Tx, dialID, userID int) error {
+	var n int
+	if err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(1)
+		FROM dials d
+		INNER JOIN organization_members om ON om.organization_id = d.organization_id
+		WHERE d.id = ? AND om.user_id = ?
+	`, dialID, userID).Scan(&n); err != nil {
+		return FormatError(err)
+	} else if n == 0 {
+		return &wtf.Error{Code: wtf.ENOTFOUND, Message: "Dial not found."}
+	}
+	return nil
+}
+
 // createDialMembership creates a new membership. Assigns the new database ID
 // to membership.ID and updates the timestamps.
 func createDialMembership(ctx context.Context, tx *Tx, membership *wtf.DialMembership) error {
+	// New memberships always start with the default weight. Only the dial
+	// owner can change it afterward.
+	membership.Weight = wtf.DefaultDialMembershipWeight
+
 	// Update timestamps to current time.
 	membership.CreatedAt = tx.now
 	membership.UpdatedAt = membership.CreatedAt
@@ -246,20 +410,35 @@ func createDialMembership(ctx context.Context, tx *Tx, membership *wtf.DialMembe
 		return err
 	}
 
+	// Verify & count the invite code, if one was used to join.
+	if membership.InviteCode != "" {
+		if err := useDialInvite(ctx, tx, membership.DialID, membership.InviteCode); err != nil {
+			return err
+		}
+	}
+
 	// Execute query to insert membership.
 	result, err := tx.ExecContext(ctx, `
 		INSERT INTO dial_memberships (
 			dial_id,
 			user_id,
 			value,
+			weight,
+			role,
+			invite_code,
+			note,
 			created_at,
 			updated_at
 		)
-		VALUES (?, ?, ?, ?, ?)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
 		membership.DialID,
 		membership.UserID,
 		membership.Value,
+		membership.Weight,
+		membership.Role,
+		membership.InviteCode,
+		membership.Note,
 		(*NullTime)(&membership.CreatedAt),
 		(*NullTime)(&membership.UpdatedAt),
 	)
@@ -274,6 +453,16 @@ func createDialMembership(ctx context.Context, tx *Tx, membership *wtf.DialMembe
 	}
 	membership.ID = int(id)
 
+	// Record initial value to history.
+	if err := insertDialMembershipValue(ctx, tx, membership.ID, membership.Value, membership.Note, membership.CreatedAt); err != nil {
+		return fmt.Errorf("insert dial membership value: %w", err)
+	}
+
+	// Add the member name & note to the search index.
+	if err := reindexDialMembership(ctx, tx, membership.ID); err != nil {
+		return fmt.Errorf("reindex dial membership: %w", err)
+	}
+
 	// Ensure computed parent dial value is up to date.
 	if err := refreshDialValue(ctx, tx, membership.DialID); err != nil {
 		return fmt.Errorf("refresh dial value: %w", err)
@@ -283,7 +472,7 @@ func createDialMembership(ctx context.Context, tx *Tx, membership *wtf.DialMembe
 }
 
 // updateDialMembership updates the value of a membership.
-// Returns EUNAUTHORIZED if user is not the membership owner.
+// Returns EUNAUTHORIZED if user is not the membership owner or is a viewer.
 func updateDialMembership(ctx context.Context, tx *Tx, id int, upd wtf.DialMembershipUpdate) (*wtf.DialMembership, error) {
 	// Fetch current object state. Return error if current user is not owner.
 	membership, err := findDialMembershipByID(ctx, tx, id)
@@ -291,18 +480,26 @@ func updateDialMembership(ctx context.Context, tx *Tx, id int, upd wtf.DialMembe
 		return membership, err
 	} else if membership.UserID != wtf.UserIDFromContext(ctx) {
 		return membership, wtf.Errorf(wtf.EUNAUTHORIZED, "You do not have permission to update the dial membership.")
+	} else if !wtf.CanEditDialMembership(ctx, membership) {
+		return membership, wtf.Errorf(wtf.EUNAUTHORIZED, "Viewers cannot set a value on a dial.")
 	}
 
 	// Save state of membership to compare later in the function.
 	prev := *membership
 
-	// Update fields.
+	// Update fields. A note only describes a single change so it is cleared
+	// when the value changes without a new note.
 	if v := upd.Value; v != nil {
 		membership.Value = *v
 	}
+	if v := upd.Note; v != nil {
+		membership.Note = *v
+	} else if membership.Value != prev.Value {
+		membership.Note = ""
+	}
 
 	// Exit if membership did not change.
-	if prev.Value == membership.Value {
+	if prev.Value == membership.Value && prev.Note == membership.Note {
 		return membership, nil
 	}
 
@@ -318,16 +515,28 @@ func updateDialMembership(ctx context.Context, tx *Tx, id int, upd wtf.DialMembe
 	if _, err := tx.ExecContext(ctx, `
 		UPDATE dial_memberships
 		SET value = ?,
+		    note = ?,
 		    updated_at = ?
 		WHERE id = ?
 	`,
 		membership.Value,
+		membership.Note,
 		(*NullTime)(&membership.UpdatedAt),
 		id,
 	); err != nil {
 		return membership, FormatError(err)
 	}
 
+	// Record the change & its note to the membership's history.
+	if err := insertDialMembershipValue(ctx, tx, id, membership.Value, membership.Note, membership.UpdatedAt); err != nil {
+		return membership, fmt.Errorf("insert dial membership value: %w", err)
+	}
+
+	// Update the note in the search index.
+	if err := reindexDialMembership(ctx, tx, id); err != nil {
+		return membership, fmt.Errorf("reindex dial membership: %w", err)
+	}
+
 	// Ensure computed dial value is up to date.
 	if err := refreshDialValue(ctx, tx, membership.DialID); err != nil {
 		return membership, fmt.Errorf("refresh dial value: %w", err)
@@ -339,6 +548,7 @@ func updateDialMembership(ctx context.Context, tx *Tx, id int, upd wtf.DialMembe
 		Payload: &wtf.DialMembershipValueChangedPayload{
 			ID:    id,
 			Value: membership.Value,
+			Note:  membership.Note,
 		},
 	}); err != nil {
 		return membership, fmt.Errorf("publish dial event: %w", err)
@@ -347,6 +557,114 @@ func updateDialMembership(ctx context.Context, tx *Tx, id int, upd wtf.DialMembe
 	return membership, nil
 }
 
+// insertDialMembershipValue records a membership value & note at a specific
+// point in time. Unlike dial values, every change is kept so no notes are lost.
+func insertDialMembershipValue(ctx context.Context, tx *Tx, id, value int, note string, timestamp time.Time) error {
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO dial_membership_values (dial_membership_id, "timestamp", value, note)
+		VALUES (?, ?, ?, ?)
+	`,
+		id, (*NullTime)(&timestamp), value, note,
+	); err != nil {
+		return FormatError(err)
+	}
+	return nil
+}
+
+// setDialMembershipWeight updates the weight of a membership.
+// Returns EUNAUTHORIZED if user is not the owner or an admin of the parent dial.
+func setDialMembershipWeight(ctx context.Context, tx *Tx, id, weight int) (*wtf.DialMembership, error) {
+	// Fetch current object state along with the parent dial to verify the
+	// current user's role.
+	membership, err := findDialMembershipByID(ctx, tx, id)
+	if err != nil {
+		return membership, err
+	} else if err := attachDialMembershipAssociations(ctx, tx, membership); err != nil {
+		return membership, err
+	} else if !wtf.CanEditDialMembershipWeight(ctx, membership) {
+		return membership, wtf.Errorf(wtf.EUNAUTHORIZED, "Only the dial owner or an admin can set the membership weight.")
+	}
+
+	// Exit if weight did not change.
+	if membership.Weight == weight {
+		return membership, nil
+	}
+	membership.Weight = weight
+
+	// Perform basic field validation.
+	if err := membership.Validate(); err != nil {
+		return membership, err
+	}
+
+	// Execute query to update membership weight. The last updated date is
+	// left unchanged as it tracks changes to the member's WTF level.
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE dial_memberships
+		SET weight = ?
+		WHERE id = ?
+	`,
+		membership.Weight,
+		id,
+	); err != nil {
+		return membership, FormatError(err)
+	}
+
+	// Ensure computed dial value is up to date.
+	if err := refreshDialValue(ctx, tx, membership.DialID); err != nil {
+		return membership, fmt.Errorf("refresh dial value: %w", err)
+	}
+	return membership, nil
+}
+
+// setDialMembershipRole updates the role of a membership.
+// Returns EUNAUTHORIZED if user cannot change the membership's role.
+func setDialMembershipRole(ctx context.Context, tx *Tx, id int, role string) (*wtf.DialMembership, error) {
+	// Fetch current object state along with the parent dial to verify the
+	// current user's role.
+	membership, err := findDialMembershipByID(ctx, tx, id)
+	if err != nil {
+		return membership, err
+	} else if err := attachDialMembershipAssociations(ctx, tx, membership); err != nil {
+		return membership, err
+	}
+
+	// The owner role is tied to the dial itself so it cannot be moved by role.
+	if membership.Role == wtf.DialMembershipRoleOwner || role == wtf.DialMembershipRoleOwner {
+		return membership, wtf.Errorf(wtf.ECONFLICT, "Dial ownership cannot be changed by setting a role.")
+	} else if !wtf.CanEditDialMembershipRole(ctx, membership, role) {
+		return membership, wtf.Errorf(wtf.EUNAUTHORIZED, "You do not have permission to change the membership role.")
+	}
+
+	// Exit if role did not change.
+	if membership.Role == role {
+		return membership, nil
+	}
+	membership.Role = role
+
+	// Perform basic field validation.
+	if err := membership.Validate(); err != nil {
+		return membership, err
+	}
+
+	// Execute query to update membership role.
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE dial_memberships
+		SET role = ?
+		WHERE id = ?
+	`,
+		membership.Role,
+		id,
+	); err != nil {
+		return membership, FormatError(err)
+	}
+
+	// Ensure computed dial value is up to date as viewers are not counted.
+	if err := refreshDialValue(ctx, tx, membership.DialID); err != nil {
+		return membership, fmt.Errorf("refresh dial value: %w", err)
+	}
+	return membership, nil
+}
+
 // deleteDialMembership permanently deletes a membership and updates the dial value.
 func deleteDialMembership(ctx context.Context, tx *Tx, id int) error {
 	// Fetch user ID of currently logged in user.
@@ -360,19 +678,21 @@ func deleteDialMembership(ctx context.Context, tx *Tx, id int) error {
 		return err
 	}
 
-	// Verify user owns membership or parent dial.
-	if membership.UserID != userID && membership.Dial.UserID != userID {
-		return wtf.Errorf(wtf.EUNAUTHORIZED, "You do not have permission to delete the dial membership.")
-	}
-
 	// Do not allow dial owner to delete their own membership.
-	if membership.UserID == membership.Dial.UserID {
+	if membership.Role == wtf.DialMembershipRoleOwner && membership.UserID == userID {
 		return wtf.Errorf(wtf.ECONFLICT, "Dial owner may not delete their own membership.")
 	}
 
-	// Remove row from database.
+	// Verify user owns membership or manages the parent dial.
+	if !wtf.CanDeleteDialMembership(ctx, membership) {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You do not have permission to delete the dial membership.")
+	}
+
+	// Remove row from database & from the search index.
 	if _, err := tx.ExecContext(ctx, `DELETE FROM dial_memberships WHERE id = ?`, id); err != nil {
 		return FormatError(err)
+	} else if err := unindexDialMembership(ctx, tx, id); err != nil {
+		return fmt.Errorf("unindex dial membership: %w", err)
 	}
 
 	// Ensure computed dial value is up to date.
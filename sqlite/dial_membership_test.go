@@ -23,8 +23,9 @@ func TestDialMembershipService_CreateDialMembership(t *testing.T) {
 
 		s := sqlite.NewDialMembershipService(db)
 		membership := &wtf.DialMembership{
-			DialID: dial.ID,
-			Value:  50,
+			DialID:     dial.ID,
+			InviteCode: dial.InviteCode,
+			Value:      50,
 		}
 
 		// Create new membership. One membership should already exist (1) since it
@@ -92,8 +93,9 @@ func TestDialMembershipService_UpdateDialMembership(t *testing.T) {
 
 		dial := MustCreateDial(t, ctx0, db, &wtf.Dial{Name: "DIAL"})
 		membership := MustCreateDialMembership(t, ctx1, db, &wtf.DialMembership{
-			DialID: dial.ID,
-			Value:  50,
+			DialID:     dial.ID,
+			InviteCode: dial.InviteCode,
+			Value:      50,
 		})
 
 		// Update membership value.
@@ -170,8 +172,9 @@ func TestDialMembershipService_UpdateDialMembership(t *testing.T) {
 		_, ctx1 := MustCreateUser(t, ctx, db, &wtf.User{Name: "jim", Email: "jim@gmail.com"})
 		dial := MustCreateDial(t, ctx0, db, &wtf.Dial{Name: "DIAL"})
 		membership := MustCreateDialMembership(t, ctx1, db, &wtf.DialMembership{
-			DialID: dial.ID,
-			Value:  50,
+			DialID:     dial.ID,
+			InviteCode: dial.InviteCode,
+			Value:      50,
 		})
 
 		newValue := 25
@@ -216,11 +219,11 @@ func TestDialMembershipService_FindDialMemberships(t *testing.T) {
 		// Dials will automatically create memberships for the owner.
 		dial0 := MustCreateDial(t, ctx0, db, &wtf.Dial{Name: "DIAL0"})
 		membership0 := MustFindDialMembershipByID(t, ctx0, db, 1)
-		membership1 := MustCreateDialMembership(t, ctx1, db, &wtf.DialMembership{DialID: dial0.ID, Value: 10})
-		membership2 := MustCreateDialMembership(t, ctx2, db, &wtf.DialMembership{DialID: dial0.ID, Value: 20})
+		membership1 := MustCreateDialMembership(t, ctx1, db, &wtf.DialMembership{DialID: dial0.ID, InviteCode: dial0.InviteCode, Value: 10})
+		membership2 := MustCreateDialMembership(t, ctx2, db, &wtf.DialMembership{DialID: dial0.ID, InviteCode: dial0.InviteCode, Value: 20})
 
 		dial1 := MustCreateDial(t, ctx1, db, &wtf.Dial{Name: "DIAL1"})
-		MustCreateDialMembership(t, ctx0, db, &wtf.DialMembership{DialID: dial1.ID, Value: 30})
+		MustCreateDialMembership(t, ctx0, db, &wtf.DialMembership{DialID: dial1.ID, InviteCode: dial1.InviteCode, Value: 30})
 
 		a, n, err := s.FindDialMemberships(ctx2, wtf.DialMembershipFilter{})
 		if err != nil {
@@ -286,7 +289,7 @@ func TestDialMembershipService_FindDialMemberships(t *testing.T) {
 		_, ctx0 := MustCreateUser(t, ctx, db, &wtf.User{Name: "jane"})
 		user1, ctx1 := MustCreateUser(t, ctx, db, &wtf.User{Name: "jill"})
 		dial0 := MustCreateDial(t, ctx0, db, &wtf.Dial{Name: "DIAL0"})
-		membership0 := MustCreateDialMembership(t, ctx1, db, &wtf.DialMembership{DialID: dial0.ID, Value: 10})
+		membership0 := MustCreateDialMembership(t, ctx1, db, &wtf.DialMembership{DialID: dial0.ID, InviteCode: dial0.InviteCode, Value: 10})
 
 		a, n, err := s.FindDialMemberships(ctx0, wtf.DialMembershipFilter{UserID: &user1.ID})
 		if err != nil {
@@ -312,7 +315,7 @@ func TestDialMembershipService_DeleteDialMembership(t *testing.T) {
 		_, ctx0 := MustCreateUser(t, ctx, db, &wtf.User{Name: "jane"})
 		_, ctx1 := MustCreateUser(t, ctx, db, &wtf.User{Name: "jim"})
 		dial := MustCreateDial(t, ctx0, db, &wtf.Dial{Name: "DIAL"})
-		membership := MustCreateDialMembership(t, ctx1, db, &wtf.DialMembership{DialID: dial.ID, Value: 50})
+		membership := MustCreateDialMembership(t, ctx1, db, &wtf.DialMembership{DialID: dial.ID, InviteCode: dial.InviteCode, Value: 50})
 
 		if err := s.DeleteDialMembership(ctx1, membership.ID); err != nil {
 			t.Fatal(err)
@@ -341,7 +344,7 @@ func TestDialMembershipService_DeleteDialMembership(t *testing.T) {
 		_, ctx0 := MustCreateUser(t, ctx, db, &wtf.User{Name: "jane"})
 		_, ctx1 := MustCreateUser(t, ctx, db, &wtf.User{Name: "jim"})
 		dial := MustCreateDial(t, ctx0, db, &wtf.Dial{Name: "DIAL"})
-		membership := MustCreateDialMembership(t, ctx1, db, &wtf.DialMembership{DialID: dial.ID, Value: 50})
+		membership := MustCreateDialMembership(t, ctx1, db, &wtf.DialMembership{DialID: dial.ID, InviteCode: dial.InviteCode, Value: 50})
 
 		if err := s.DeleteDialMembership(ctx0, membership.ID); err != nil {
 			t.Fatal(err)
@@ -388,8 +391,8 @@ func TestDialMembershipService_DeleteDialMembership(t *testing.T) {
 		_, ctx1 := MustCreateUser(t, ctx, db, &wtf.User{Name: "jim"})
 		_, ctx2 := MustCreateUser(t, ctx, db, &wtf.User{Name: "bob"})
 		dial := MustCreateDial(t, ctx0, db, &wtf.Dial{Name: "DIAL"})
-		membership0 := MustCreateDialMembership(t, ctx1, db, &wtf.DialMembership{DialID: dial.ID, Value: 50})
-		MustCreateDialMembership(t, ctx2, db, &wtf.DialMembership{DialID: dial.ID, Value: 50})
+		membership0 := MustCreateDialMembership(t, ctx1, db, &wtf.DialMembership{DialID: dial.ID, InviteCode: dial.InviteCode, Value: 50})
+		MustCreateDialMembership(t, ctx2, db, &wtf.DialMembership{DialID: dial.ID, InviteCode: dial.InviteCode, Value: 50})
 
 		if err := s.DeleteDialMembership(ctx2, membership0.ID); err == nil {
 			t.Fatal("expected error")
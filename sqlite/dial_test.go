@@ -161,7 +161,7 @@ func TestDialService_FindDials(t *testing.T) {
 
 		dial0 := MustCreateDial(t, ctx0, db, &wtf.Dial{Name: "dial0"})
 		MustCreateDial(t, ctx0, db, &wtf.Dial{Name: "dial1"})
-		MustCreateDialMembership(t, ctx1, db, &wtf.DialMembership{DialID: dial0.ID, UserID: user1.ID})
+		MustCreateDialMembership(t, ctx1, db, &wtf.DialMembership{DialID: dial0.ID, InviteCode: dial0.InviteCode, UserID: user1.ID})
 
 		s := sqlite.NewDialService(db)
 		if a, n, err := s.FindDials(ctx1, wtf.DialFilter{}); err != nil {
@@ -234,7 +234,7 @@ func TestDialService_AverageDialValueReport(t *testing.T) {
 
 		dial0 := MustCreateDial(t, ctx0, db, &wtf.Dial{Name: "DIAL0"})
 		membership0 := MustFindDialMembershipByID(t, ctx0, db, 1)
-		MustCreateDialMembership(t, ctx1, db, &wtf.DialMembership{DialID: dial0.ID})
+		MustCreateDialMembership(t, ctx1, db, &wtf.DialMembership{DialID: dial0.ID, InviteCode: dial0.InviteCode})
 
 		// Update value after one hour (avg 25).
 		db.Now = func() time.Time {
@@ -0,0 +1,273 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+)
+
+// IncomingWebhookService represents a service for managing incoming webhooks
+// in SQLite.
+type IncomingWebhookService struct {
+	db *DB
+}
+
+// NewIncomingWebhookService returns a new instance of IncomingWebhookService.
+func NewIncomingWebhookService(db *DB) *IncomingWebhookService {
+	return &IncomingWebhookService{db: db}
+}
+
+// FindIncomingWebhooks retrieves a list of the current user's incoming
+// webhooks by filter. Also returns a count of total matching webhooks which
+// may differ if "Limit" is specified on the filter.
+func (s *IncomingWebhookService) FindIncomingWebhooks(ctx context.Context, filter wtf.IncomingWebhookFilter) ([]*wtf.IncomingWebhook, int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+	return findIncomingWebhooks(ctx, tx, filter)
+}
+
+// CreateIncomingWebhook creates a new incoming webhook for the current user's
+// membership on a dial. Returns ENOTFOUND if the user is not a member of the
+// dial. Returns EUNAUTHORIZED if the user is a viewer.
+func (s *IncomingWebhookService) CreateIncomingWebhook(ctx context.Context, webhook *wtf.IncomingWebhook) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := createIncomingWebhook(ctx, tx, webhook); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteIncomingWebhook permanently revokes an incoming webhook. Returns
+// ENOTFOUND if the webhook does not exist or belongs to another user.
+func (s *IncomingWebhookService) DeleteIncomingWebhook(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteIncomingWebhook(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// InvokeIncomingWebhook sets the value of the webhook's membership on behalf
+// of its user. Returns ENOTFOUND if the token does not exist.
+func (s *IncomingWebhookService) InvokeIncomingWebhook(ctx context.Context, token string, upd wtf.IncomingWebhookUpdate) (*wtf.DialMembership, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	membership, err := invokeIncomingWebhook(ctx, tx, token, upd)
+	if err != nil {
+		return nil, err
+	}
+	return membership, tx.Commit()
+}
+
+// findIncomingWebhooks returns a list of the current user's incoming webhooks.
+// Also returns a count of total matching webhooks.
+func findIncomingWebhooks(ctx context.Context, tx *Tx, filter wtf.IncomingWebhookFilter) (_ []*wtf.IncomingWebhook, n int, err error) {
+	where, args := []string{"user_id = ?"}, []interface{}{wtf.UserIDFromContext(ctx)}
+	if v := filter.ID; v != nil {
+		where, args = append(where, "id = ?"), append(args, *v)
+	}
+	if v := filter.DialID; v != nil {
+		where, args = append(where, "dial_id = ?"), append(args, *v)
+	}
+	return queryIncomingWebhooks(ctx, tx, where, args, filter.Limit, filter.Offset)
+}
+
+// queryIncomingWebhooks executes a query against the incoming_webhooks table
+// using the given WHERE clause segments which are AND-ed together.
+func queryIncomingWebhooks(ctx context.Context, tx *Tx, where []string, args []interface{}, limit, offset int) (_ []*wtf.IncomingWebhook, n int, err error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+		    id,
+		    dial_membership_id,
+		    dial_id,
+		    user_id,
+		    name,
+		    prefix,
+		    last_used_at,
+		    created_at,
+		    COUNT(*) OVER()
+		FROM incoming_webhooks
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY id ASC
+		`+FormatLimitOffset(limit, offset),
+		args...,
+	)
+	if err != nil {
+		return nil, n, FormatError(err)
+	}
+	defer rows.Close()
+
+	webhooks := make([]*wtf.IncomingWebhook, 0)
+	for rows.Next() {
+		var webhook wtf.IncomingWebhook
+		var lastUsedAt NullTime
+		if err := rows.Scan(
+			&webhook.ID,
+			&webhook.DialMembershipID,
+			&webhook.DialID,
+			&webhook.UserID,
+			&webhook.Name,
+			&webhook.Prefix,
+			&lastUsedAt,
+			(*NullTime)(&webhook.CreatedAt),
+			&n,
+		); err != nil {
+			return nil, 0, err
+		}
+
+		if t := time.Time(lastUsedAt); !t.IsZero() {
+			webhook.LastUsedAt = &t
+		}
+		webhooks = append(webhooks, &webhook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return webhooks, n, nil
+}
+
+// createIncomingWebhook creates a new incoming webhook on the current user's
+// membership of the dial.
+func createIncomingWebhook(ctx context.Context, tx *Tx, webhook *wtf.IncomingWebhook) error {
+	// Perform basic field validation.
+	if err := webhook.Validate(); err != nil {
+		return err
+	}
+
+	// Find the user's membership on the dial. Viewers cannot set a value so
+	// they cannot create a webhook to set one either.
+	userID := wtf.UserIDFromContext(ctx)
+	memberships, _, err := findDialMemberships(ctx, tx, wtf.DialMembershipFilter{DialID: &webhook.DialID, UserID: &userID})
+	if err != nil {
+		return err
+	} else if len(memberships) == 0 {
+		return wtf.Errorf(wtf.ENOTFOUND, "User is not a member of this dial.")
+	} else if !wtf.CanEditDialMembership(ctx, memberships[0]) {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "Viewers cannot create incoming webhooks.")
+	}
+	webhook.DialMembershipID = memberships[0].ID
+	webhook.UserID = userID
+
+	// Generate a random token to identify the webhook. Only the prefix is
+	// kept in plaintext.
+	token, err := generateInviteCode()
+	if err != nil {
+		return err
+	}
+	webhook.Token = token
+	webhook.Prefix = token[:wtf.IncomingWebhookDisplayPrefixLen]
+	webhook.LastUsedAt = nil
+	webhook.CreatedAt = tx.now
+
+	// Insert row into database.
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO incoming_webhooks (
+			dial_membership_id,
+			dial_id,
+			user_id,
+			name,
+			prefix,
+			token_hash,
+			created_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`,
+		webhook.DialMembershipID,
+		webhook.DialID,
+		webhook.UserID,
+		webhook.Name,
+		webhook.Prefix,
+		wtf.HashIncomingWebhookToken(webhook.Token),
+		(*NullTime)(&webhook.CreatedAt),
+	)
+	if err != nil {
+		return FormatError(err)
+	}
+
+	// Read back new webhook ID into caller argument.
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	webhook.ID = int(id)
+
+	return nil
+}
+
+// deleteIncomingWebhook permanently removes one of the current user's
+// incoming webhooks.
+func deleteIncomingWebhook(ctx context.Context, tx *Tx, id int) error {
+	// Verify the webhook exists & belongs to the current user.
+	if webhooks, _, err := findIncomingWebhooks(ctx, tx, wtf.IncomingWebhookFilter{ID: &id}); err != nil {
+		return err
+	} else if len(webhooks) == 0 {
+		return &wtf.Error{Code: wtf.ENOTFOUND, Message: "Incoming webhook not found."}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM incoming_webhooks WHERE id = ?`, id); err != nil {
+		return FormatError(err)
+	}
+	return nil
+}
+
+// invokeIncomingWebhook updates the value of the membership associated with
+// token. The update is performed as the webhook's user so the same
+// permissions apply as when the user sets the value themselves. The dial &
+// user are not attached as the caller only holds the token.
+func invokeIncomingWebhook(ctx context.Context, tx *Tx, token string, upd wtf.IncomingWebhookUpdate) (*wtf.DialMembership, error) {
+	if err := upd.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Look up webhook by the hash of its token.
+	webhooks, _, err := queryIncomingWebhooks(ctx, tx, []string{"token_hash = ?"}, []interface{}{wtf.HashIncomingWebhookToken(token)}, 0, 0)
+	if err != nil {
+		return nil, err
+	} else if len(webhooks) == 0 {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "Incoming webhook not found."}
+	}
+	webhook := webhooks[0]
+
+	// Act on behalf of the webhook's user.
+	user, err := findUserByID(ctx, tx, webhook.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("find incoming webhook user: %w", err)
+	}
+	ctx = wtf.NewContextWithUser(ctx, user)
+
+	// Compute the new value from the current value & update the membership.
+	membership, err := findDialMembershipByID(ctx, tx, webhook.DialMembershipID)
+	if err != nil {
+		return nil, err
+	}
+	value := upd.Apply(membership.Value)
+	if membership, err = updateDialMembership(ctx, tx, membership.ID, wtf.DialMembershipUpdate{Value: &value, Note: upd.Note}); err != nil {
+		return nil, err
+	}
+
+	// Record when the webhook was last used.
+	if _, err := tx.ExecContext(ctx, `UPDATE incoming_webhooks SET last_used_at = ? WHERE id = ?`, (*NullTime)(&tx.now), webhook.ID); err != nil {
+		return nil, FormatError(err)
+	}
+	return membership, nil
+}
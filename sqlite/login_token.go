@@ -0,0 +1,175 @@
+package sqlite
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+)
+
+// Ensure service implements interface.
+var _ wtf.LoginTokenService = (*LoginTokenService)(nil)
+
+// LoginTokenService represents a service for managing email login tokens in SQLite.
+type LoginTokenService struct {
+	db *DB
+}
+
+// NewLoginTokenService returns a new instance of LoginTokenService.
+func NewLoginTokenService(db *DB) *LoginTokenService {
+	return &LoginTokenService{db: db}
+}
+
+// CreateLoginToken creates a new single-use token for token.Email. The
+// plaintext token is set on token.Token & only its hash is stored.
+func (s *LoginTokenService) CreateLoginToken(ctx context.Context, token *wtf.LoginToken) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := createLoginToken(ctx, tx, token); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RedeemLoginToken looks up a token by its plaintext value & marks it as used.
+// Returns ENOTFOUND if the token does not exist or EUNAUTHORIZED if the token
+// has expired or has already been used.
+func (s *LoginTokenService) RedeemLoginToken(ctx context.Context, token string) (*wtf.LoginToken, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	loginToken, err := redeemLoginToken(ctx, tx, token)
+	if err != nil {
+		return nil, err
+	}
+	return loginToken, tx.Commit()
+}
+
+// queryLoginTokens executes a query against the login_tokens table using the
+// given WHERE clause segments which are AND-ed together.
+func queryLoginTokens(ctx context.Context, tx *Tx, where []string, args []interface{}) ([]*wtf.LoginToken, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+		    id,
+		    email,
+		    expires_at,
+		    used_at,
+		    created_at
+		FROM login_tokens
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY id ASC
+	`,
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	tokens := make([]*wtf.LoginToken, 0)
+	for rows.Next() {
+		var token wtf.LoginToken
+		var usedAt NullTime
+		if err := rows.Scan(
+			&token.ID,
+			&token.Email,
+			(*NullTime)(&token.ExpiresAt),
+			&usedAt,
+			(*NullTime)(&token.CreatedAt),
+		); err != nil {
+			return nil, err
+		}
+
+		if t := time.Time(usedAt); !t.IsZero() {
+			token.UsedAt = &t
+		}
+		tokens = append(tokens, &token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// createLoginToken generates a new token for an email address & stores its hash.
+func createLoginToken(ctx context.Context, tx *Tx, token *wtf.LoginToken) error {
+	token.Email = strings.TrimSpace(token.Email)
+
+	// Perform basic field validation.
+	if err := token.Validate(); err != nil {
+		return err
+	}
+
+	// Generate a random token.
+	code, err := generateInviteCode()
+	if err != nil {
+		return err
+	}
+	token.Token = code
+	token.ExpiresAt = tx.now.Add(wtf.LoginTokenDuration)
+	token.UsedAt = nil
+	token.CreatedAt = tx.now
+
+	// Insert row into database.
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO login_tokens (
+			email,
+			token_hash,
+			expires_at,
+			created_at
+		)
+		VALUES (?, ?, ?, ?)
+	`,
+		token.Email,
+		wtf.HashLoginToken(token.Token),
+		(*NullTime)(&token.ExpiresAt),
+		(*NullTime)(&token.CreatedAt),
+	)
+	if err != nil {
+		return FormatError(err)
+	}
+
+	// Read back new token ID into caller argument.
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	token.ID = int(id)
+
+	return nil
+}
+
+// redeemLoginToken looks up a token by the hash of its plaintext value and
+// records the time it was used so it cannot be used again.
+func redeemLoginToken(ctx context.Context, tx *Tx, token string) (*wtf.LoginToken, error) {
+	tokens, err := queryLoginTokens(ctx, tx, []string{"token_hash = ?"}, []interface{}{wtf.HashLoginToken(token)})
+	if err != nil {
+		return nil, err
+	} else if len(tokens) == 0 {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "Login token not found."}
+	}
+	loginToken := tokens[0]
+
+	if loginToken.UsedAt != nil {
+		return nil, wtf.Errorf(wtf.EUNAUTHORIZED, "Login token already used.")
+	} else if loginToken.IsExpired(tx.now) {
+		return nil, wtf.Errorf(wtf.EUNAUTHORIZED, "Login token expired.")
+	}
+
+	// Mark the token as used.
+	usedAt := tx.now
+	if _, err := tx.ExecContext(ctx, `UPDATE login_tokens SET used_at = ? WHERE id = ?`, (*NullTime)(&usedAt), loginToken.ID); err != nil {
+		return nil, FormatError(err)
+	}
+	loginToken.UsedAt = &usedAt
+
+	return loginToken, nil
+}
@@ -0,0 +1,3 @@
+ALTER TABLE dials ADD COLUMN aggregation TEXT NOT NULL DEFAULT 'mean';
+ALTER TABLE dials ADD COLUMN aggregation_percentile INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE dials ADD COLUMN aggregation_trim INTEGER NOT NULL DEFAULT 0;
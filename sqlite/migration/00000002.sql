@@ -0,0 +1 @@
+ALTER TABLE dial_memberships ADD COLUMN weight INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,5 @@
+ALTER TABLE dial_memberships ADD COLUMN role TEXT NOT NULL DEFAULT 'member';
+
+UPDATE dial_memberships
+SET role = 'owner'
+WHERE user_id = (SELECT d.user_id FROM dials d WHERE d.id = dial_memberships.dial_id);
@@ -0,0 +1,5 @@
+ALTER TABLE dials ADD COLUMN invite_expires_at TEXT;
+ALTER TABLE dials ADD COLUMN invite_max_uses INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE dials ADD COLUMN invite_use_count INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE dial_memberships ADD COLUMN invite_code TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,11 @@
+ALTER TABLE dial_memberships ADD COLUMN note TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE dial_membership_values (
+	id                 INTEGER PRIMARY KEY AUTOINCREMENT,
+	dial_membership_id INTEGER NOT NULL REFERENCES dial_memberships (id) ON DELETE CASCADE,
+	"timestamp"        TEXT NOT NULL,
+	value              INTEGER NOT NULL,
+	note               TEXT NOT NULL
+);
+
+CREATE INDEX dial_membership_values_dial_membership_id_idx ON dial_membership_values (dial_membership_id, "timestamp");
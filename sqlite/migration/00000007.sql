@@ -0,0 +1,24 @@
+CREATE TABLE alert_rules (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	dial_id    INTEGER NOT NULL REFERENCES dials (id) ON DELETE CASCADE,
+	condition  TEXT NOT NULL,
+	threshold  INTEGER NOT NULL,
+	duration   INTEGER NOT NULL, -- nanoseconds
+	state      TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL
+);
+
+CREATE INDEX alert_rules_dial_id_idx ON alert_rules (dial_id);
+
+CREATE TABLE alerts (
+	id            INTEGER PRIMARY KEY AUTOINCREMENT,
+	alert_rule_id INTEGER NOT NULL REFERENCES alert_rules (id) ON DELETE CASCADE,
+	dial_id       INTEGER NOT NULL REFERENCES dials (id) ON DELETE CASCADE,
+	value         INTEGER NOT NULL,
+	fired_at      TEXT NOT NULL,
+	resolved_at   TEXT
+);
+
+CREATE INDEX alerts_alert_rule_id_idx ON alerts (alert_rule_id);
+CREATE INDEX alerts_dial_id_idx ON alerts (dial_id);
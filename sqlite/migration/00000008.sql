@@ -0,0 +1,29 @@
+CREATE TABLE webhooks (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	dial_id     INTEGER NOT NULL REFERENCES dials (id) ON DELETE CASCADE,
+	url         TEXT NOT NULL,
+	secret      TEXT NOT NULL,
+	event_types TEXT NOT NULL, -- comma-separated, blank for all
+	created_at  TEXT NOT NULL,
+	updated_at  TEXT NOT NULL
+);
+
+CREATE INDEX webhooks_dial_id_idx ON webhooks (dial_id);
+
+CREATE TABLE webhook_deliveries (
+	id              INTEGER PRIMARY KEY AUTOINCREMENT,
+	webhook_id      INTEGER NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+	dial_id         INTEGER NOT NULL REFERENCES dials (id) ON DELETE CASCADE,
+	event_type      TEXT NOT NULL,
+	body            TEXT NOT NULL,
+	status          TEXT NOT NULL,
+	attempts        INTEGER NOT NULL DEFAULT 0,
+	status_code     INTEGER NOT NULL DEFAULT 0,
+	error           TEXT NOT NULL DEFAULT '',
+	next_attempt_at TEXT,
+	created_at      TEXT NOT NULL,
+	updated_at      TEXT NOT NULL
+);
+
+CREATE INDEX webhook_deliveries_webhook_id_idx ON webhook_deliveries (webhook_id);
+CREATE INDEX webhook_deliveries_pending_idx ON webhook_deliveries (status, next_attempt_at);
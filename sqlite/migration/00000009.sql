@@ -0,0 +1,14 @@
+CREATE TABLE incoming_webhooks (
+	id                 INTEGER PRIMARY KEY AUTOINCREMENT,
+	dial_membership_id INTEGER NOT NULL REFERENCES dial_memberships (id) ON DELETE CASCADE,
+	dial_id            INTEGER NOT NULL REFERENCES dials (id) ON DELETE CASCADE,
+	user_id            INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	name               TEXT NOT NULL,
+	prefix             TEXT NOT NULL,
+	token_hash         TEXT UNIQUE NOT NULL,
+	last_used_at       TEXT,
+	created_at         TEXT NOT NULL
+);
+
+CREATE INDEX incoming_webhooks_dial_membership_id_idx ON incoming_webhooks (dial_membership_id);
+CREATE INDEX incoming_webhooks_user_id_idx ON incoming_webhooks (user_id);
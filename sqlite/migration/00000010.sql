@@ -0,0 +1,13 @@
+CREATE TABLE api_tokens (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id      INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	name         TEXT NOT NULL,
+	scope        TEXT NOT NULL,
+	prefix       TEXT NOT NULL,
+	token_hash   TEXT UNIQUE NOT NULL,
+	expires_at   TEXT,
+	last_used_at TEXT,
+	created_at   TEXT NOT NULL
+);
+
+CREATE INDEX api_tokens_user_id_idx ON api_tokens (user_id);
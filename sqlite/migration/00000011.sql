@@ -0,0 +1,15 @@
+CREATE TABLE sessions (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id      INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	auth_id      INTEGER REFERENCES auths (id) ON DELETE CASCADE,
+	device       TEXT NOT NULL,
+	ip_address   TEXT NOT NULL,
+	user_agent   TEXT NOT NULL,
+	expires_at   TEXT NOT NULL,
+	last_seen_at TEXT NOT NULL,
+	created_at   TEXT NOT NULL
+);
+
+CREATE INDEX sessions_user_id_idx ON sessions (user_id);
+CREATE INDEX sessions_auth_id_idx ON sessions (auth_id);
+CREATE INDEX sessions_expires_at_idx ON sessions (expires_at);
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN avatar_source TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,8 @@
+CREATE TABLE login_tokens (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	email      TEXT NOT NULL,
+	token_hash TEXT UNIQUE NOT NULL,
+	expires_at TEXT NOT NULL,
+	used_at    TEXT,
+	created_at TEXT NOT NULL
+);
@@ -0,0 +1,26 @@
+CREATE TABLE organizations (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id     INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	name        TEXT NOT NULL,
+	invite_code TEXT UNIQUE NOT NULL,
+	created_at  TEXT NOT NULL,
+	updated_at  TEXT NOT NULL
+);
+
+CREATE INDEX organizations_user_id_idx ON organizations (user_id);
+
+CREATE TABLE organization_members (
+	id              INTEGER PRIMARY KEY AUTOINCREMENT,
+	organization_id INTEGER NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+	user_id         INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	role            TEXT NOT NULL,
+	created_at      TEXT NOT NULL,
+
+	UNIQUE(organization_id, user_id)
+);
+
+CREATE INDEX organization_members_user_id_idx ON organization_members (user_id);
+
+ALTER TABLE dials ADD COLUMN organization_id INTEGER REFERENCES organizations (id) ON DELETE SET NULL;
+
+CREATE INDEX dials_organization_id_idx ON dials (organization_id);
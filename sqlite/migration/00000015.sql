@@ -0,0 +1,8 @@
+CREATE TABLE dial_tags (
+	dial_id INTEGER NOT NULL REFERENCES dials (id) ON DELETE CASCADE,
+	tag     TEXT NOT NULL,
+
+	PRIMARY KEY (dial_id, tag)
+);
+
+CREATE INDEX dial_tags_tag_idx ON dial_tags (tag);
@@ -0,0 +1,568 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/benbjohnson/wtf"
+)
+
+// Ensure service implements interface.
+var _ wtf.OrganizationService = (*OrganizationService)(nil)
+
+// OrganizationService represents a service for managing organizations.
+type OrganizationService struct {
+	db *DB
+}
+
+// NewOrganizationService returns a new instance of OrganizationService.
+func NewOrganizationService(db *DB) *OrganizationService {
+	return &OrganizationService{db: db}
+}
+
+// FindOrganizationByID retrieves a single organization by ID along with its
+// members. Returns ENOTFOUND if the organization does not exist or the user
+// is not a member.
+func (s *OrganizationService) FindOrganizationByID(ctx context.Context, id int) (*wtf.Organization, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Fetch organization and attach owner & members.
+	org, err := findOrganizationByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	} else if err := attachOrganizationAssociations(ctx, tx, org); err != nil {
+		return nil, err
+	} else if org.Members, err = findOrganizationMembers(ctx, tx, org.ID); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// FindOrganizations retrieves a list of organizations based on a filter. Only
+// returns organizations the user is a member of unless searching by invite code.
+//
+// Also returns a count of total matching organizations which may different
+// from the number of returned organizations if the "Limit" field is set.
+func (s *OrganizationService) FindOrganizations(ctx context.Context, filter wtf.OrganizationFilter) ([]*wtf.Organization, int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	// Fetch list of matching organizations.
+	orgs, n, err := findOrganizations(ctx, tx, filter)
+	if err != nil {
+		return orgs, n, err
+	}
+
+	// Attach owner to each organization.
+	for _, org := range orgs {
+		if err := attachOrganizationAssociations(ctx, tx, org); err != nil {
+			return orgs, n, err
+		}
+	}
+	return orgs, n, nil
+}
+
+// CreateOrganization creates a new organization and assigns the current user
+// as the owner. The owner is automatically added as a member.
+func (s *OrganizationService) CreateOrganization(ctx context.Context, org *wtf.Organization) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Create organization and attach associated owner user.
+	if err := createOrganization(ctx, tx, org); err != nil {
+		return err
+	} else if err := attachOrganizationAssociations(ctx, tx, org); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateOrganization updates an existing organization by ID. Only the owner
+// can update an organization. Returns ENOTFOUND if the organization does not
+// exist. Returns EUNAUTHORIZED if the user is not the owner.
+func (s *OrganizationService) UpdateOrganization(ctx context.Context, id int, upd wtf.OrganizationUpdate) (*wtf.Organization, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Update the organization and attach associated owner user.
+	org, err := updateOrganization(ctx, tx, id, upd)
+	if err != nil {
+		return org, err
+	} else if err := attachOrganizationAssociations(ctx, tx, org); err != nil {
+		return org, err
+	}
+	return org, tx.Commit()
+}
+
+// DeleteOrganization permanently removes an organization by ID. Dials within
+// the organization are kept but are no longer associated with it. Only the
+// owner may delete an organization.
+func (s *OrganizationService) DeleteOrganization(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteOrganization(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateOrganizationMember adds the current user to an organization. The
+// member's invite code must match the organization's invite code.
+func (s *OrganizationService) CreateOrganizationMember(ctx context.Context, member *wtf.OrganizationMember) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Ensure user is logged in & assign membership to current user.
+	userID := wtf.UserIDFromContext(ctx)
+	if userID == 0 {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You must be logged in to join an organization.")
+	}
+	member.UserID = userID
+	member.Role = wtf.OrganizationRoleMember
+
+	// Verify the invite code against the organization. This avoids the
+	// visibility check as the user is not yet a member.
+	var inviteCode string
+	if err := tx.QueryRowContext(ctx, `SELECT invite_code FROM organizations WHERE id = ?`, member.OrganizationID).Scan(&inviteCode); err == sql.ErrNoRows {
+		return &wtf.Error{Code: wtf.ENOTFOUND, Message: "Organization not found."}
+	} else if err != nil {
+		return FormatError(err)
+	} else if member.InviteCode == "" || member.InviteCode != inviteCode {
+		return wtf.Errorf(wtf.EINVALID, "Invalid invite code.")
+	}
+
+	if err := createOrganizationMember(ctx, tx, member); err != nil {
+		return err
+	} else if member.User, err = findUserByID(ctx, tx, member.UserID); err != nil {
+		return err
+	} else if member.Organization, err = findOrganizationByID(ctx, tx, member.OrganizationID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteOrganizationMember removes a member from an organization by ID.
+// Members can leave an organization & the owner can remove other members.
+func (s *OrganizationService) DeleteOrganizationMember(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteOrganizationMember(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// findOrganizationByID is a helper function to return an organization by ID.
+// Returns ENOTFOUND if the organization does not exist or the user is not a member.
+func findOrganizationByID(ctx context.Context, tx *Tx, id int) (*wtf.Organization, error) {
+	orgs, _, err := findOrganizations(ctx, tx, wtf.OrganizationFilter{ID: &id})
+	if err != nil {
+		return nil, err
+	} else if len(orgs) == 0 {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "Organization not found."}
+	}
+	return orgs[0], nil
+}
+
+// lookupOrganization returns an organization by ID without checking that the
+// current user is a member. The invite code is not returned. This is used to
+// display the organization of a dial to users outside the organization.
+func lookupOrganization(ctx context.Context, tx *Tx, id int) (*wtf.Organization, error) {
+	var org wtf.Organization
+	if err := tx.QueryRowContext(ctx, `
+		SELECT id, user_id, name, created_at, updated_at
+		FROM organizations
+		WHERE id = ?
+	`, id).Scan(
+		&org.ID,
+		&org.UserID,
+		&org.Name,
+		(*NullTime)(&org.CreatedAt),
+		(*NullTime)(&org.UpdatedAt),
+	); err == sql.ErrNoRows {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "Organization not found."}
+	} else if err != nil {
+		return nil, FormatError(err)
+	}
+	return &org, nil
+}
+
+// findOrganizations returns a list of organizations matching a filter. Also
+// returns a count of total matching organizations which may differ if
+// filter.Limit is set.
+func findOrganizations(ctx context.Context, tx *Tx, filter wtf.OrganizationFilter) (_ []*wtf.Organization, n int, err error) {
+	userID := wtf.UserIDFromContext(ctx)
+
+	// Build WHERE clause. Each segment of the clause is AND-ed together.
+	// Values are appended to args so we can avoid SQL injection.
+	where, args := []string{"1 = 1"}, []interface{}{userID}
+	if v := filter.ID; v != nil {
+		where, args = append(where, "o.id = ?"), append(args, *v)
+	}
+
+	// Limit to organizations user is a member of unless searching by invite code.
+	if v := filter.InviteCode; v != nil {
+		where, args = append(where, "o.invite_code = ?"), append(args, *v)
+	} else {
+		where = append(where, "om.id IS NOT NULL")
+	}
+
+	// Execute query with limiting WHERE clause and LIMIT/OFFSET injected.
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+		    o.id,
+		    o.user_id,
+		    o.name,
+		    o.invite_code,
+		    om.role,
+		    o.created_at,
+		    o.updated_at,
+		    COUNT(*) OVER()
+		FROM organizations o
+		LEFT JOIN organization_members om ON om.organization_id = o.id AND om.user_id = ?
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY o.name ASC, o.id ASC
+		`+FormatLimitOffset(filter.Limit, filter.Offset)+`
+	`,
+		args...,
+	)
+	if err != nil {
+		return nil, n, FormatError(err)
+	}
+	defer rows.Close()
+
+	// Iterate over rows and deserialize into Organization objects.
+	orgs := make([]*wtf.Organization, 0)
+	for rows.Next() {
+		var org wtf.Organization
+		var role sql.NullString
+		if err := rows.Scan(
+			&org.ID,
+			&org.UserID,
+			&org.Name,
+			&org.InviteCode,
+			&role,
+			(*NullTime)(&org.CreatedAt),
+			(*NullTime)(&org.UpdatedAt),
+			&n,
+		); err != nil {
+			return nil, 0, err
+		}
+		org.Role = role.String
+
+		// Omit the invite code if the current API token cannot read it.
+		if !wtf.CanViewInviteCodes(ctx) {
+			org.InviteCode = ""
+		}
+
+		orgs = append(orgs, &org)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return orgs, n, nil
+}
+
+// createOrganization creates a new organization & adds the owner as a member.
+func createOrganization(ctx context.Context, tx *Tx, org *wtf.Organization) error {
+	// Assign organization to the current user.
+	// Return an error if the user is not currently logged in.
+	userID := wtf.UserIDFromContext(ctx)
+	if userID == 0 {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You must be logged in to create an organization.")
+	}
+	org.UserID = userID
+
+	// Generate a random invite code.
+	inviteCode, err := generateInviteCode()
+	if err != nil {
+		return err
+	}
+	org.InviteCode = inviteCode
+
+	// Set timestamps to current time.
+	org.CreatedAt = tx.now
+	org.UpdatedAt = org.CreatedAt
+
+	// Perform basic field validation.
+	if err := org.Validate(); err != nil {
+		return err
+	}
+
+	// Insert row into database.
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO organizations (
+			user_id,
+			name,
+			invite_code,
+			created_at,
+			updated_at
+		)
+		VALUES (?, ?, ?, ?, ?)
+	`,
+		org.UserID,
+		org.Name,
+		org.InviteCode,
+		(*NullTime)(&org.CreatedAt),
+		(*NullTime)(&org.UpdatedAt),
+	)
+	if err != nil {
+		return FormatError(err)
+	}
+
+	// Read back new organization ID into caller argument.
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	org.ID = int(id)
+	org.Role = wtf.OrganizationRoleOwner
+
+	// Add the owner as the first member of the organization.
+	if err := createOrganizationMember(ctx, tx, &wtf.OrganizationMember{
+		OrganizationID: org.ID,
+		UserID:         org.UserID,
+		Role:           wtf.OrganizationRoleOwner,
+	}); err != nil {
+		return fmt.Errorf("create owner membership: %w", err)
+	}
+
+	return nil
+}
+
+// updateOrganization updates an organization by ID. Returns the new state of
+// the organization after update.
+func updateOrganization(ctx context.Context, tx *Tx, id int, upd wtf.OrganizationUpdate) (*wtf.Organization, error) {
+	// Fetch current object state. Return an error if user is not the owner.
+	org, err := findOrganizationByID(ctx, tx, id)
+	if err != nil {
+		return org, err
+	} else if !wtf.CanEditOrganization(ctx, org) {
+		return org, wtf.Errorf(wtf.EUNAUTHORIZED, "Only the owner can edit an organization.")
+	}
+
+	// Update fields, if set.
+	if v := upd.Name; v != nil {
+		org.Name = *v
+	}
+
+	// Set last updated date to current time.
+	org.UpdatedAt = tx.now
+
+	// Perform basic field validation.
+	if err := org.Validate(); err != nil {
+		return org, err
+	}
+
+	// Execute update query.
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE organizations
+		SET name = ?,
+		    updated_at = ?
+		WHERE id = ?
+	`,
+		org.Name,
+		(*NullTime)(&org.UpdatedAt),
+		id,
+	); err != nil {
+		return org, FormatError(err)
+	}
+
+	return org, nil
+}
+
+// deleteOrganization permanently removes an organization by ID. Dials within
+// the organization have their organization cleared by the foreign key.
+func deleteOrganization(ctx context.Context, tx *Tx, id int) error {
+	// Verify object exists & the current user is the owner.
+	if org, err := findOrganizationByID(ctx, tx, id); err != nil {
+		return err
+	} else if !wtf.CanEditOrganization(ctx, org) {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "Only the owner can delete an organization.")
+	}
+
+	// Remove row from database.
+	if _, err := tx.ExecContext(ctx, `DELETE FROM organizations WHERE id = ?`, id); err != nil {
+		return FormatError(err)
+	}
+	return nil
+}
+
+// findOrganizationMembers returns all members of an organization with their
+// users attached. The owner is listed first & then members by name.
+func findOrganizationMembers(ctx context.Context, tx *Tx, organizationID int) (_ []*wtf.OrganizationMember, err error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+		    om.id,
+		    om.organization_id,
+		    om.user_id,
+		    om.role,
+		    om.created_at
+		FROM organization_members om
+		INNER JOIN users u ON om.user_id = u.id
+		WHERE om.organization_id = ?
+		ORDER BY CASE om.role WHEN ? THEN 0 ELSE 1 END ASC, u.name ASC
+	`,
+		organizationID,
+		wtf.OrganizationRoleOwner,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	members := make([]*wtf.OrganizationMember, 0)
+	for rows.Next() {
+		var member wtf.OrganizationMember
+		if err := rows.Scan(
+			&member.ID,
+			&member.OrganizationID,
+			&member.UserID,
+			&member.Role,
+			(*NullTime)(&member.CreatedAt),
+		); err != nil {
+			return nil, err
+		}
+		members = append(members, &member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	// Attach users after the rows are closed.
+	for _, member := range members {
+		if member.User, err = findUserByID(ctx, tx, member.UserID); err != nil {
+			return nil, fmt.Errorf("attach member user: %w", err)
+		}
+	}
+	return members, nil
+}
+
+// findOrganizationMemberByID returns a member by ID. Returns ENOTFOUND if the
+// member does not exist or the current user is not in the same organization.
+func findOrganizationMemberByID(ctx context.Context, tx *Tx, id int) (*wtf.OrganizationMember, error) {
+	var member wtf.OrganizationMember
+	if err := tx.QueryRowContext(ctx, `
+		SELECT id, organization_id, user_id, role, created_at
+		FROM organization_members
+		WHERE id = ?
+	`, id).Scan(
+		&member.ID,
+		&member.OrganizationID,
+		&member.UserID,
+		&member.Role,
+		(*NullTime)(&member.CreatedAt),
+	); err == sql.ErrNoRows {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "Organization member not found."}
+	} else if err != nil {
+		return nil, FormatError(err)
+	}
+
+	// Only members of the organization can see its members.
+	org, err := findOrganizationByID(ctx, tx, member.OrganizationID)
+	if wtf.ErrorCode(err) == wtf.ENOTFOUND {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "Organization member not found."}
+	} else if err != nil {
+		return nil, err
+	}
+	member.Organization = org
+
+	return &member, nil
+}
+
+// createOrganizationMember inserts a new organization member. Returns
+// ECONFLICT if the user is already a member.
+func createOrganizationMember(ctx context.Context, tx *Tx, member *wtf.OrganizationMember) error {
+	member.CreatedAt = tx.now
+
+	// Ensure the user has not already joined.
+	var n int
+	if err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(1)
+		FROM organization_members
+		WHERE organization_id = ? AND user_id = ?
+	`, member.OrganizationID, member.UserID).Scan(&n); err != nil {
+		return FormatError(err)
+	} else if n != 0 {
+		return wtf.Errorf(wtf.ECONFLICT, "You are already a member of this organization.")
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO organization_members (
+			organization_id,
+			user_id,
+			role,
+			created_at
+		)
+		VALUES (?, ?, ?, ?)
+	`,
+		member.OrganizationID,
+		member.UserID,
+		member.Role,
+		(*NullTime)(&member.CreatedAt),
+	)
+	if err != nil {
+		return FormatError(err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	member.ID = int(id)
+
+	return nil
+}
+
+// deleteOrganizationMember permanently removes a member by ID. Returns
+// EUNAUTHORIZED if the current user cannot remove the member.
+func deleteOrganizationMember(ctx context.Context, tx *Tx, id int) error {
+	// Verify object exists & the current user can remove it.
+	if member, err := findOrganizationMemberByID(ctx, tx, id); err != nil {
+		return err
+	} else if !wtf.CanDeleteOrganizationMember(ctx, member) {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You do not have permission to remove this member.")
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM organization_members WHERE id = ?`, id); err != nil {
+		return FormatError(err)
+	}
+	return nil
+}
+
+// attachOrganizationAssociations is a helper function to look up and attach
+// the owner user to the organization.
+func attachOrganizationAssociations(ctx context.Context, tx *Tx, org *wtf.Organization) (err error) {
+	if org.User, err = findUserByID(ctx, tx, org.UserID); err != nil {
+		return fmt.Errorf("attach organization user: %w", err)
+	}
+	return nil
+}
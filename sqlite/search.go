@@ -0,0 +1,264 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/benbjohnson/wtf"
+)
+
+// Ensure service implements interface.
+var _ wtf.SearchService = (*SearchService)(nil)
+
+// SearchService represents a service for full-text search across dials.
+type SearchService struct {
+	db *DB
+}
+
+// NewSearchService returns a new instance of SearchService.
+func NewSearchService(db *DB) *SearchService {
+	return &SearchService{db: db}
+}
+
+// Search returns dials & memberships matching every term in the filter's
+// query. Results are ordered by relevance. Returns ENOTIMPLEMENTED if SQLite
+// was built without FTS5 support.
+func (s *SearchService) Search(ctx context.Context, filter wtf.SearchFilter) ([]*wtf.SearchResult, int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	// Fetch list of matches.
+	results, n, err := search(ctx, tx, filter)
+	if err != nil {
+		return results, n, err
+	}
+
+	// Attach the dial to each result.
+	// This should be batched up if using a remote database server.
+	for _, result := range results {
+		if result.Dial, err = findDialByID(ctx, tx, result.DialID); err != nil {
+			return results, n, err
+		}
+	}
+	return results, n, nil
+}
+
+// search returns a list of search index entries matching the filter query.
+func search(ctx context.Context, tx *Tx, filter wtf.SearchFilter) (_ []*wtf.SearchResult, n int, err error) {
+	if !tx.db.searchEnabled {
+		return nil, 0, wtf.Errorf(wtf.ENOTIMPLEMENTED, "Search is not available. SQLite must be built with FTS5 support.")
+	}
+
+	// Convert each term into a quoted prefix query. All terms must match.
+	terms := wtf.SearchTerms(filter.Query)
+	if len(terms) == 0 {
+		return nil, 0, wtf.Errorf(wtf.EINVALID, "Search query required.")
+	}
+	for i, term := range terms {
+		terms[i] = `"` + term + `"*`
+	}
+
+	// Limit dials to the same set returned by findDials() when looking up a
+	// single dial. Memberships are only visible to members of their dial.
+	userID := wtf.UserIDFromContext(ctx)
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+		    dial_id,
+		    dial_membership_id,
+		    name,
+		    note,
+		    COUNT(*) OVER()
+		FROM search_index
+		WHERE search_index MATCH ?
+		AND (
+			dial_id IN (SELECT dm.dial_id FROM dial_memberships dm WHERE dm.user_id = ?) OR (
+				dial_membership_id = 0 AND
+				dial_id IN (
+					SELECT d.id FROM dials d
+					WHERE d.organization_id IN (SELECT om.organization_id FROM organization_members om WHERE om.user_id = ?)
+				)
+			)
+		)
+		ORDER BY rank, dial_id ASC, dial_membership_id ASC
+		`+FormatLimitOffset(filter.Limit, filter.Offset),
+		strings.Join(terms, " "), userID, userID,
+	)
+	if err != nil {
+		return nil, n, FormatError(err)
+	}
+	defer rows.Close()
+
+	// Iterate over rows and deserialize into SearchResult objects.
+	results := make([]*wtf.SearchResult, 0)
+	for rows.Next() {
+		var result wtf.SearchResult
+		if err := rows.Scan(
+			&result.DialID,
+			&result.DialMembershipID,
+			&result.Name,
+			&result.Note,
+			&n,
+		); err != nil {
+			return nil, 0, err
+		}
+		results = append(results, &result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return results, n, nil
+}
+
+// initSearchIndex creates the full-text search index if it does not exist &
+// rebuilds it from the existing dials & memberships if it is out of date. The
+// index is created outside of the migrations as it requires SQLite to be built
+// with FTS5 support (e.g. with the "sqlite_fts5" build tag). Search is
+// disabled if FTS5 is not available.
+//
+// Rows written while FTS5 was not available are not indexed so the index is
+// rebuilt when its entry count does not match the dials & memberships.
+func (db *DB) initSearchIndex() error {
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// FTS5 registers this function so it is only available if FTS5 is enabled.
+	if _, err := tx.Exec(`SELECT fts5_source_id()`); err != nil {
+		return nil
+	}
+
+	// Create the index. Dials are indexed with a zero membership ID.
+	if _, err := tx.Exec(`
+		CREATE VIRTUAL TABLE IF NOT EXISTS search_index USING fts5(
+			dial_id UNINDEXED,
+			dial_membership_id UNINDEXED,
+			name,
+			note
+		)
+	`); err != nil {
+		return err
+	}
+
+	// Each dial & membership has a single entry so only rebuild the index if
+	// the counts differ. This avoids rewriting the index on every open.
+	var indexed, total int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM search_index`).Scan(&indexed); err != nil {
+		return err
+	} else if err := tx.QueryRow(`SELECT (SELECT COUNT(*) FROM dials) + (SELECT COUNT(*) FROM dial_memberships)`).Scan(&total); err != nil {
+		return err
+	} else if indexed != total {
+		if err := rebuildSearchIndex(tx); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	db.searchEnabled = true
+	return nil
+}
+
+// rebuildSearchIndex replaces all entries in the search index with the
+// existing dials & memberships.
+func rebuildSearchIndex(tx *sql.Tx) error {
+	if _, err := tx.Exec(`DELETE FROM search_index`); err != nil {
+		return err
+	} else if _, err := tx.Exec(`
+		INSERT INTO search_index (dial_id, dial_membership_id, name, note)
+		SELECT id, 0, name, '' FROM dials
+	`); err != nil {
+		return err
+	} else if _, err := tx.Exec(`
+		INSERT INTO search_index (dial_id, dial_membership_id, name, note)
+		SELECT dm.dial_id, dm.id, u.name, dm.note
+		FROM dial_memberships dm
+		INNER JOIN users u ON dm.user_id = u.id
+	`); err != nil {
+		return err
+	}
+	return nil
+}
+
+// execSearchIndex executes a statement that updates the search index. This is
+// a no-op if search is not available.
+func execSearchIndex(ctx context.Context, tx *Tx, query string, args ...interface{}) error {
+	if !tx.db.searchEnabled {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return FormatError(err)
+	}
+	return nil
+}
+
+// reindexDial replaces the search index entry for a dial's name.
+func reindexDial(ctx context.Context, tx *Tx, id int) error {
+	if err := execSearchIndex(ctx, tx, `DELETE FROM search_index WHERE dial_id = ? AND dial_membership_id = 0`, id); err != nil {
+		return err
+	}
+	return execSearchIndex(ctx, tx, `
+		INSERT INTO search_index (dial_id, dial_membership_id, name, note)
+		SELECT id, 0, name, '' FROM dials WHERE id = ?
+	`, id)
+}
+
+// unindexDial removes a dial & all of its memberships from the search index.
+func unindexDial(ctx context.Context, tx *Tx, id int) error {
+	return execSearchIndex(ctx, tx, `DELETE FROM search_index WHERE dial_id = ?`, id)
+}
+
+// reindexDialMembership replaces the search index entry for a membership's
+// member name & note.
+func reindexDialMembership(ctx context.Context, tx *Tx, id int) error {
+	if err := unindexDialMembership(ctx, tx, id); err != nil {
+		return err
+	}
+	return execSearchIndex(ctx, tx, `
+		INSERT INTO search_index (dial_id, dial_membership_id, name, note)
+		SELECT dm.dial_id, dm.id, u.name, dm.note
+		FROM dial_memberships dm
+		INNER JOIN users u ON dm.user_id = u.id
+		WHERE dm.id = ?
+	`, id)
+}
+
+// unindexDialMembership removes a membership from the search index.
+func unindexDialMembership(ctx context.Context, tx *Tx, id int) error {
+	return execSearchIndex(ctx, tx, `DELETE FROM search_index WHERE dial_membership_id = ?`, id)
+}
+
+// reindexUser updates the member name for all of a user's memberships.
+func reindexUser(ctx context.Context, tx *Tx, id int) error {
+	if err := execSearchIndex(ctx, tx, `
+		DELETE FROM search_index
+		WHERE dial_membership_id IN (SELECT id FROM dial_memberships WHERE user_id = ?)
+	`, id); err != nil {
+		return err
+	}
+	return execSearchIndex(ctx, tx, `
+		INSERT INTO search_index (dial_id, dial_membership_id, name, note)
+		SELECT dm.dial_id, dm.id, u.name, dm.note
+		FROM dial_memberships dm
+		INNER JOIN users u ON dm.user_id = u.id
+		WHERE dm.user_id = ?
+	`, id)
+}
+
+// unindexUser removes a user's memberships & owned dials from the search
+// index. This must be called before the user is deleted as the rows are
+// removed from the database by cascading deletes.
+func unindexUser(ctx context.Context, tx *Tx, id int) error {
+	return execSearchIndex(ctx, tx, `
+		DELETE FROM search_index
+		WHERE dial_membership_id IN (SELECT id FROM dial_memberships WHERE user_id = ?)
+		OR dial_id IN (SELECT id FROM dials WHERE user_id = ?)
+	`, id, id)
+}
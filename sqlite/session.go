@@ -0,0 +1,273 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/benbjohnson/wtf"
+)
+
+// Ensure service implements interface.
+var _ wtf.SessionService = (*SessionService)(nil)
+
+// SessionService represents a service for managing sessions in SQLite.
+type SessionService struct {
+	db *DB
+}
+
+// NewSessionService returns a new instance of SessionService.
+func NewSessionService(db *DB) *SessionService {
+	return &SessionService{db: db}
+}
+
+// FindSessions retrieves a list of the current user's unexpired sessions by
+// filter. Also returns a count of total matching sessions which may differ if
+// "Limit" is specified on the filter.
+func (s *SessionService) FindSessions(ctx context.Context, filter wtf.SessionFilter) ([]*wtf.Session, int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+	return findSessions(ctx, tx, filter)
+}
+
+// CreateSession creates a new session for the current user.
+func (s *SessionService) CreateSession(ctx context.Context, session *wtf.Session) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := createSession(ctx, tx, session); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// AuthenticateSession looks up a session by ID, records its use & attaches
+// its user. Returns ENOTFOUND if the session does not exist or EUNAUTHORIZED
+// if the session has expired.
+func (s *SessionService) AuthenticateSession(ctx context.Context, id int) (*wtf.Session, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	session, err := authenticateSession(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	return session, tx.Commit()
+}
+
+// DeleteSession revokes one of the current user's sessions. Returns ENOTFOUND
+// if the session does not exist or belongs to another user.
+func (s *SessionService) DeleteSession(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteSession(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteAllSessions revokes all of the current user's sessions.
+func (s *SessionService) DeleteAllSessions(ctx context.Context) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = ?`, wtf.UserIDFromContext(ctx)); err != nil {
+		return FormatError(err)
+	}
+	return tx.Commit()
+}
+
+// findSessions returns a list of the current user's unexpired sessions. Also
+// returns a count of total matching sessions.
+func findSessions(ctx context.Context, tx *Tx, filter wtf.SessionFilter) (_ []*wtf.Session, n int, err error) {
+	where := []string{"user_id = ?", "expires_at > ?"}
+	args := []interface{}{wtf.UserIDFromContext(ctx), (*NullTime)(&tx.now)}
+	if v := filter.ID; v != nil {
+		where, args = append(where, "id = ?"), append(args, *v)
+	}
+	return querySessions(ctx, tx, where, args, filter.Limit, filter.Offset)
+}
+
+// querySessions executes a query against the sessions table using the given
+// WHERE clause segments which are AND-ed together.
+func querySessions(ctx context.Context, tx *Tx, where []string, args []interface{}, limit, offset int) (_ []*wtf.Session, n int, err error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+		    id,
+		    user_id,
+		    auth_id,
+		    device,
+		    ip_address,
+		    user_agent,
+		    expires_at,
+		    last_seen_at,
+		    created_at,
+		    COUNT(*) OVER()
+		FROM sessions
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY last_seen_at DESC, id DESC
+		`+FormatLimitOffset(limit, offset),
+		args...,
+	)
+	if err != nil {
+		return nil, n, FormatError(err)
+	}
+	defer rows.Close()
+
+	sessions := make([]*wtf.Session, 0)
+	for rows.Next() {
+		var session wtf.Session
+		var authID sql.NullInt64
+		if err := rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&authID,
+			&session.Device,
+			&session.IPAddress,
+			&session.UserAgent,
+			(*NullTime)(&session.ExpiresAt),
+			(*NullTime)(&session.LastSeenAt),
+			(*NullTime)(&session.CreatedAt),
+			&n,
+		); err != nil {
+			return nil, 0, err
+		}
+		session.AuthID = int(authID.Int64)
+		sessions = append(sessions, &session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return sessions, n, nil
+}
+
+// createSession creates a new session for the current user.
+func createSession(ctx context.Context, tx *Tx, session *wtf.Session) error {
+	userID := wtf.UserIDFromContext(ctx)
+	if userID == 0 {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You must be logged in to create a session.")
+	}
+
+	// Ensure the auth used to log in, if any, belongs to the user.
+	var authID interface{}
+	if session.AuthID != 0 {
+		if auth, err := findAuthByID(ctx, tx, session.AuthID); err != nil {
+			return err
+		} else if auth.UserID != userID {
+			return wtf.Errorf(wtf.EUNAUTHORIZED, "You are not allowed to create a session for this auth.")
+		}
+		authID = session.AuthID
+	}
+
+	// Remove expired sessions for all users so the table does not grow
+	// without limit. Expired sessions can never be used again.
+	if _, err := tx.ExecContext(ctx, `DELETE FROM sessions WHERE expires_at <= ?`, (*NullTime)(&tx.now)); err != nil {
+		return FormatError(err)
+	}
+
+	session.UserID = userID
+	session.ExpiresAt = tx.now.Add(wtf.SessionDuration)
+	session.LastSeenAt = tx.now
+	session.CreatedAt = tx.now
+
+	// Insert row into database.
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO sessions (
+			user_id,
+			auth_id,
+			device,
+			ip_address,
+			user_agent,
+			expires_at,
+			last_seen_at,
+			created_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		session.UserID,
+		authID,
+		session.Device,
+		session.IPAddress,
+		session.UserAgent,
+		(*NullTime)(&session.ExpiresAt),
+		(*NullTime)(&session.LastSeenAt),
+		(*NullTime)(&session.CreatedAt),
+	)
+	if err != nil {
+		return FormatError(err)
+	}
+
+	// Read back new session ID into caller argument.
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	session.ID = int(id)
+
+	return nil
+}
+
+// authenticateSession looks up a session by ID & updates the time it was last
+// seen if it has not been updated recently.
+func authenticateSession(ctx context.Context, tx *Tx, id int) (*wtf.Session, error) {
+	sessions, _, err := querySessions(ctx, tx, []string{"id = ?"}, []interface{}{id}, 0, 0)
+	if err != nil {
+		return nil, err
+	} else if len(sessions) == 0 {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "Session not found."}
+	}
+	session := sessions[0]
+
+	if session.IsExpired(tx.now) {
+		return nil, wtf.Errorf(wtf.EUNAUTHORIZED, "Session expired.")
+	}
+
+	// Attach the session's user along with their auths.
+	if session.User, err = findUserByID(ctx, tx, session.UserID); err != nil {
+		return nil, fmt.Errorf("find session user: %w", err)
+	} else if err := attachUserAuths(ctx, tx, session.User); err != nil {
+		return nil, err
+	}
+
+	// Record when the session was last seen.
+	if tx.now.Sub(session.LastSeenAt) >= wtf.SessionLastSeenInterval {
+		session.LastSeenAt = tx.now
+		if _, err := tx.ExecContext(ctx, `UPDATE sessions SET last_seen_at = ? WHERE id = ?`, (*NullTime)(&session.LastSeenAt), session.ID); err != nil {
+			return nil, FormatError(err)
+		}
+	}
+
+	return session, nil
+}
+
+// deleteSession permanently removes one of the current user's sessions.
+func deleteSession(ctx context.Context, tx *Tx, id int) error {
+	// Verify the session exists & belongs to the current user.
+	if sessions, _, err := querySessions(ctx, tx, []string{"id = ?", "user_id = ?"}, []interface{}{id, wtf.UserIDFromContext(ctx)}, 0, 0); err != nil {
+		return err
+	} else if len(sessions) == 0 {
+		return &wtf.Error{Code: wtf.ENOTFOUND, Message: "Session not found."}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM sessions WHERE id = ?`, id); err != nil {
+		return FormatError(err)
+	}
+	return nil
+}
@@ -55,6 +55,9 @@ type DB struct {
 	// Returns the current time. Defaults to time.Now().
 	// Can be mocked for tests.
 	Now func() time.Time
+
+	// True if SQLite supports FTS5 & the search index is available.
+	searchEnabled bool
 }
 
 // NewDB returns a new instance of DB associated with the given datasource name.
@@ -106,6 +109,10 @@ func (db *DB) Open() (err error) {
 		return fmt.Errorf("migrate: %w", err)
 	}
 
+	if err := db.initSearchIndex(); err != nil {
+		return fmt.Errorf("init search index: %w", err)
+	}
+
 	// Monitor stats in background goroutine.
 	go db.monitor()
 
@@ -140,6 +147,10 @@ func (db *DB) migrate() error {
 			return fmt.Errorf("migration error: name=%q err=%w", name, err)
 		}
 	}
+
+	if err := db.migrateLegacyAPIKeys(); err != nil {
+		return fmt.Errorf("migration error: name=%q err=%w", legacyAPIKeysMigration, err)
+	}
 	return nil
 }
 
@@ -175,6 +186,79 @@ func (db *DB) migrateFile(name string) error {
 	return tx.Commit()
 }
 
+// legacyAPIKeysMigration is the name saved to the "migrations" table once
+// legacy user API keys have been moved to API tokens.
+const legacyAPIKeysMigration = "legacy-api-keys"
+
+// migrateLegacyAPIKeys moves each user's legacy API key to an admin API token
+// so existing clients continue to work but the key can be revoked like any
+// other token. Only a hash of the key is kept so the plaintext key on the user
+// is replaced with a random value. This is not a migration file since SQLite
+// has no built-in function to hash the key.
+func (db *DB) migrateLegacyAPIKeys() error {
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Ensure migration has not already been run.
+	var n int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM migrations WHERE name = ?`, legacyAPIKeysMigration).Scan(&n); err != nil {
+		return err
+	} else if n != 0 {
+		return nil // already run migration, skip
+	}
+
+	// Read all keys before writing as the rows share the transaction.
+	type legacyAPIKey struct {
+		userID int
+		key    string
+	}
+	var keys []legacyAPIKey
+	rows, err := tx.Query(`SELECT id, api_key FROM users ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var k legacyAPIKey
+		if err := rows.Scan(&k.userID, &k.key); err != nil {
+			return err
+		}
+		keys = append(keys, k)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	} else if err := rows.Close(); err != nil {
+		return err
+	}
+
+	now := db.Now().UTC().Truncate(time.Second)
+	for _, k := range keys {
+		prefix := k.key
+		if len(prefix) > wtf.APITokenDisplayPrefixLen {
+			prefix = prefix[:wtf.APITokenDisplayPrefixLen]
+		}
+
+		if _, err := tx.Exec(`
+			INSERT INTO api_tokens (user_id, name, scope, prefix, token_hash, created_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, k.userID, "Legacy API key", wtf.APITokenScopeAdmin, prefix, wtf.HashAPIToken(k.key), (*NullTime)(&now)); err != nil {
+			return err
+		} else if _, err := tx.Exec(`UPDATE users SET api_key = lower(hex(randomblob(32))) WHERE id = ?`, k.userID); err != nil {
+			return err
+		}
+	}
+
+	// Insert record into migrations to prevent re-running migration.
+	if _, err := tx.Exec(`INSERT INTO migrations (name) VALUES (?)`, legacyAPIKeysMigration); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 // Close closes the database connection.
 func (db *DB) Close() error {
 	// Cancel background context.
@@ -1,12 +1,18 @@
 package sqlite_test
 
 import (
+	"context"
+	"database/sql"
 	"flag"
 	"io/ioutil"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/benbjohnson/wtf"
+	"github.com/benbjohnson/wtf/inmem"
 	"github.com/benbjohnson/wtf/sqlite"
+	"github.com/benbjohnson/wtf/wtftest"
 )
 
 var dump = flag.Bool("dump", false, "save work data")
@@ -17,6 +23,148 @@ func TestDB(t *testing.T) {
 	MustCloseDB(t, db)
 }
 
+// Ensure the search index is rebuilt on open if it is missing entries so that
+// rows written while search was not available can be found.
+func TestDB_SearchIndex(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "db")
+
+	db := sqlite.NewDB(dsn)
+	if err := db.Open(); err != nil {
+		t.Fatal(err)
+	}
+	s := &wtftest.Services{
+		DialService:   sqlite.NewDialService(db),
+		SearchService: sqlite.NewSearchService(db),
+		UserService:   sqlite.NewUserService(db),
+	}
+	_, ctx0 := wtftest.MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+	wtftest.MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "Infrastructure"})
+	if _, n := wtftest.MustSearch(t, ctx0, s, "infra"); n != 1 {
+		t.Fatalf("n=%d, want 1", n)
+	}
+
+	// execIndex executes a statement directly against the search index.
+	execIndex := func(query string) {
+		t.Helper()
+		conn, err := sql.Open("sqlite3", dsn)
+		if err != nil {
+			t.Fatal(err)
+		} else if _, err := conn.Exec(query); err != nil {
+			t.Fatal(err)
+		} else if err := conn.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// reopen closes & reopens the database.
+	reopen := func() {
+		t.Helper()
+		MustCloseDB(t, db)
+		db = sqlite.NewDB(dsn)
+		if err := db.Open(); err != nil {
+			t.Fatal(err)
+		}
+		s.SearchService = sqlite.NewSearchService(db)
+	}
+	defer func() { MustCloseDB(t, db) }()
+
+	// Change an entry without changing the entry count. The index should not
+	// be rebuilt on open as it is not missing entries.
+	execIndex(`UPDATE search_index SET name = 'Other'`)
+	reopen()
+	if _, n := wtftest.MustSearch(t, ctx0, s, "infra"); n != 0 {
+		t.Fatalf("n=%d, want 0", n)
+	}
+
+	// Clear the index as if the rows were written while FTS5 was unavailable.
+	// Reopen the database & ensure the dial is indexed again.
+	execIndex(`DELETE FROM search_index`)
+	reopen()
+	if _, n := wtftest.MustSearch(t, ctx0, s, "infra"); n != 1 {
+		t.Fatalf("n=%d, want 1", n)
+	}
+}
+
+// Ensure legacy user API keys are moved to admin API tokens on open so that
+// existing clients can continue to authenticate.
+func TestDB_MigrateLegacyAPIKeys(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "db")
+
+	db := sqlite.NewDB(dsn)
+	if err := db.Open(); err != nil {
+		t.Fatal(err)
+	}
+	user, _ := MustCreateUser(t, context.Background(), db, &wtf.User{Name: "jane"})
+	MustCloseDB(t, db)
+
+	// Restore a plaintext key as if the user was created before API tokens.
+	conn, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err := conn.Exec(`UPDATE users SET api_key = 'LEGACYKEY' WHERE id = ?`, user.ID); err != nil {
+		t.Fatal(err)
+	} else if _, err := conn.Exec(`DELETE FROM migrations WHERE name = 'legacy-api-keys'`); err != nil {
+		t.Fatal(err)
+	} else if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopen the database & ensure the key authenticates with full access.
+	db = sqlite.NewDB(dsn)
+	if err := db.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer MustCloseDB(t, db)
+
+	s := sqlite.NewAPITokenService(db)
+	if token, err := s.AuthenticateAPIToken(context.Background(), "LEGACYKEY"); err != nil {
+		t.Fatal(err)
+	} else if got, want := token.UserID, user.ID; got != want {
+		t.Fatalf("UserID=%v, want %v", got, want)
+	} else if got, want := token.Scope, wtf.APITokenScopeAdmin; got != want {
+		t.Fatalf("Scope=%v, want %v", got, want)
+	}
+
+	// Ensure the plaintext key is no longer stored.
+	var n int
+	conn, err = sql.Open("sqlite3", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := conn.QueryRow(`SELECT COUNT(*) FROM users WHERE api_key = 'LEGACYKEY'`).Scan(&n); err != nil {
+		t.Fatal(err)
+	} else if n != 0 {
+		t.Fatal("expected plaintext key to be removed")
+	}
+}
+
+// Ensure the SQLite implementation passes the shared service test suite.
+func TestServices(t *testing.T) {
+	wtftest.Run(t, func(tb testing.TB) *wtftest.Services {
+		db := MustOpenDB(tb)
+		tb.Cleanup(func() { MustCloseDB(tb, db) })
+		db.EventService = inmem.NewEventService()
+
+		return &wtftest.Services{
+			AlertService:           sqlite.NewAlertService(db),
+			APITokenService:        sqlite.NewAPITokenService(db),
+			AuthService:            sqlite.NewAuthService(db),
+			DialService:            sqlite.NewDialService(db),
+			DialMembershipService:  sqlite.NewDialMembershipService(db),
+			IncomingWebhookService: sqlite.NewIncomingWebhookService(db),
+			LoginTokenService:      sqlite.NewLoginTokenService(db),
+			OrganizationService:    sqlite.NewOrganizationService(db),
+			SearchService:          sqlite.NewSearchService(db),
+			SessionService:         sqlite.NewSessionService(db),
+			UserService:            sqlite.NewUserService(db),
+			WebhookService:         sqlite.NewWebhookService(db),
+			EventService:           db.EventService,
+			SetNow:                 func(fn func() time.Time) { db.Now = fn },
+		}
+	})
+}
+
 // MustOpenDB returns a new, open DB. Fatal on error.
 func MustOpenDB(tb testing.TB) *sqlite.DB {
 	tb.Helper()
@@ -2,11 +2,8 @@ package sqlite
 
 import (
 	"context"
-	"crypto/rand"
 	"database/sql"
-	"encoding/hex"
 	"fmt"
-	"io"
 	"strings"
 
 	"github.com/benbjohnson/wtf"
@@ -145,9 +142,6 @@ func findUsers(ctx context.Context, tx *Tx, filter wtf.UserFilter) (_ []*wtf.Use
 	if v := filter.Email; v != nil {
 		where, args = append(where, "email = ?"), append(args, *v)
 	}
-	if v := filter.APIKey; v != nil {
-		where, args = append(where, "api_key = ?"), append(args, *v)
-	}
 
 	// Execute query to fetch user rows.
 	rows, err := tx.QueryContext(ctx, `
@@ -155,7 +149,8 @@ func findUsers(ctx context.Context, tx *Tx, filter wtf.UserFilter) (_ []*wtf.Use
 		    id,
 		    name,
 		    email,
-		    api_key,
+		    timezone,
+		    avatar_source,
 		    created_at,
 		    updated_at,
 		    COUNT(*) OVER()
@@ -179,7 +174,8 @@ func findUsers(ctx context.Context, tx *Tx, filter wtf.UserFilter) (_ []*wtf.Use
 			&user.ID,
 			&user.Name,
 			&email,
-			&user.APIKey,
+			&user.Timezone,
+			&user.AvatarSource,
 			(*NullTime)(&user.CreatedAt),
 			(*NullTime)(&user.UpdatedAt),
 			&n,
@@ -219,27 +215,23 @@ func createUser(ctx context.Context, tx *Tx, user *wtf.User) error {
 		email = &user.Email
 	}
 
-	// Generate random API key.
-	apiKey := make([]byte, 32)
-	if _, err := io.ReadFull(rand.Reader, apiKey); err != nil {
-		return err
-	}
-	user.APIKey = hex.EncodeToString(apiKey)
-
-	// Execute insertion query.
+	// Execute insertion query. The api_key column is no longer used since
+	// API keys are issued as API tokens. It is filled with a random value
+	// to satisfy its UNIQUE constraint.
 	result, err := tx.ExecContext(ctx, `
 		INSERT INTO users (
 			name,
 			email,
 			api_key,
+			timezone,
 			created_at,
 			updated_at
 		)
-		VALUES (?, ?, ?, ?, ?)
+		VALUES (?, ?, lower(hex(randomblob(32))), ?, ?, ?)
 	`,
 		user.Name,
 		email,
-		user.APIKey,
+		user.Timezone,
 		(*NullTime)(&user.CreatedAt),
 		(*NullTime)(&user.UpdatedAt),
 	)
@@ -271,8 +263,11 @@ func updateUser(ctx context.Context, tx *Tx, id int, upd wtf.UserUpdate) (*wtf.U
 	if v := upd.Name; v != nil {
 		user.Name = *v
 	}
-	if v := upd.Email; v != nil {
-		user.Email = *v
+	if v := upd.Timezone; v != nil {
+		user.Timezone = *v
+	}
+	if v := upd.AvatarSource; v != nil {
+		user.AvatarSource = *v
 	}
 
 	// Set last updated date to current time.
@@ -283,6 +278,15 @@ func updateUser(ctx context.Context, tx *Tx, id int, upd wtf.UserUpdate) (*wtf.U
 		return user, err
 	}
 
+	// Ensure the preferred avatar is from one of the user's linked accounts.
+	if upd.AvatarSource != nil && user.AvatarSource != "" {
+		if _, n, err := findAuths(ctx, tx, wtf.AuthFilter{UserID: &user.ID, Source: &user.AvatarSource}); err != nil {
+			return user, err
+		} else if n == 0 {
+			return user, wtf.Errorf(wtf.EINVALID, "Avatar must be from a linked account.")
+		}
+	}
+
 	// Email is nullable and has a UNIQUE constraint so ensure we store blank
 	// fields as NULLs.
 	var email *string
@@ -295,17 +299,28 @@ func updateUser(ctx context.Context, tx *Tx, id int, upd wtf.UserUpdate) (*wtf.U
 		UPDATE users
 		SET name = ?,
 		    email = ?,
+		    timezone = ?,
+		    avatar_source = ?,
 		    updated_at = ?
 		WHERE id = ?
 	`,
 		user.Name,
 		email,
+		user.Timezone,
+		user.AvatarSource,
 		(*NullTime)(&user.UpdatedAt),
 		id,
 	); err != nil {
 		return user, FormatError(err)
 	}
 
+	// Update the user's name on their memberships in the search index.
+	if upd.Name != nil {
+		if err := reindexUser(ctx, tx, id); err != nil {
+			return user, fmt.Errorf("reindex user: %w", err)
+		}
+	}
+
 	return user, nil
 }
 
@@ -319,6 +334,12 @@ func deleteUser(ctx context.Context, tx *Tx, id int) error {
 		return wtf.Errorf(wtf.EUNAUTHORIZED, "You are not allowed to delete this user.")
 	}
 
+	// Remove the user's memberships & dials from the search index before
+	// they are removed by cascading deletes.
+	if err := unindexUser(ctx, tx, id); err != nil {
+		return fmt.Errorf("unindex user: %w", err)
+	}
+
 	// Remove row from database.
 	if _, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, id); err != nil {
 		return FormatError(err)
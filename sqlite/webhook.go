@@ -0,0 +1,574 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+)
+
+// WebhookService represents a service for managing webhooks in SQLite.
+type WebhookService struct {
+	db *DB
+}
+
+// NewWebhookService returns a new instance of WebhookService.
+func NewWebhookService(db *DB) *WebhookService {
+	return &WebhookService{db: db}
+}
+
+// FindWebhookByID retrieves a single webhook by ID along with the associated
+// dial. Returns ENOTFOUND if webhook does not exist or user is not the owner
+// or an admin of the webhook's dial.
+func (s *WebhookService) FindWebhookByID(ctx context.Context, id int) (*wtf.Webhook, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	webhook, err := findWebhookByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	} else if err := attachWebhookAssociations(ctx, tx, webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// FindWebhooks retrieves a list of webhooks by filter. Only returns webhooks
+// on dials that the current user owns or administers.
+//
+// Also returns a count of total matching webhooks which may differ if "Limit"
+// is specified on the filter.
+func (s *WebhookService) FindWebhooks(ctx context.Context, filter wtf.WebhookFilter) ([]*wtf.Webhook, int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	webhooks, n, err := findWebhooks(ctx, tx, filter)
+	if err != nil {
+		return webhooks, n, err
+	}
+
+	// Attach dial to each returned webhook.
+	for _, webhook := range webhooks {
+		if err := attachWebhookAssociations(ctx, tx, webhook); err != nil {
+			return webhooks, n, err
+		}
+	}
+	return webhooks, n, nil
+}
+
+// CreateWebhook creates a new webhook on a dial. Returns EUNAUTHORIZED if the
+// user is not the owner or an admin of the dial.
+func (s *WebhookService) CreateWebhook(ctx context.Context, webhook *wtf.Webhook) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := createWebhook(ctx, tx, webhook); err != nil {
+		return err
+	} else if err := attachWebhookAssociations(ctx, tx, webhook); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteWebhook permanently removes a webhook & its deliveries. Returns
+// EUNAUTHORIZED if the user is not the owner or an admin of the dial.
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteWebhook(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// FindWebhookDeliveries retrieves a list of deliveries by filter, most recent
+// first. Only returns deliveries for webhooks the current user can see.
+func (s *WebhookService) FindWebhookDeliveries(ctx context.Context, filter wtf.WebhookDeliveryFilter) ([]*wtf.WebhookDelivery, int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+	return findWebhookDeliveries(ctx, tx, filter)
+}
+
+// RedeliverWebhookDelivery queues a new delivery of the same event as an
+// existing delivery. Returns ENOTFOUND if the delivery does not exist or the
+// user cannot see its webhook.
+func (s *WebhookService) RedeliverWebhookDelivery(ctx context.Context, id int) (*wtf.WebhookDelivery, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	delivery, err := redeliverWebhookDelivery(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	return delivery, tx.Commit()
+}
+
+// FindPendingWebhookDeliveries retrieves pending deliveries which are due to
+// be attempted along with their webhook. This is used by the delivery worker
+// & does not check permissions.
+func (s *WebhookService) FindPendingWebhookDeliveries(ctx context.Context, limit int) ([]*wtf.WebhookDelivery, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	deliveries, _, err := queryWebhookDeliveries(ctx, tx,
+		[]string{"status = ?", "next_attempt_at <= ?"},
+		[]interface{}{wtf.WebhookDeliveryStatusPending, (*NullTime)(&tx.now)},
+		"next_attempt_at ASC, id ASC", limit, 0,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach webhook so the worker knows where to send the delivery.
+	for _, delivery := range deliveries {
+		webhooks, _, err := queryWebhooks(ctx, tx, []string{"id = ?"}, []interface{}{delivery.WebhookID}, 0, 0)
+		if err != nil {
+			return nil, err
+		} else if len(webhooks) == 0 {
+			return nil, fmt.Errorf("webhook not found: id=%d", delivery.WebhookID)
+		}
+		delivery.Webhook = webhooks[0]
+	}
+	return deliveries, nil
+}
+
+// RecordWebhookDeliveryAttempt records the result of a delivery attempt &
+// schedules a retry, if needed. This is used by the delivery worker & does
+// not check permissions.
+func (s *WebhookService) RecordWebhookDeliveryAttempt(ctx context.Context, id int, result wtf.WebhookDeliveryResult) (*wtf.WebhookDelivery, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	delivery, err := recordWebhookDeliveryAttempt(ctx, tx, id, result)
+	if err != nil {
+		return nil, err
+	}
+	return delivery, tx.Commit()
+}
+
+// findWebhookByID returns a webhook by ID. Returns ENOTFOUND if the webhook
+// does not exist or the user cannot manage the webhook's dial.
+func findWebhookByID(ctx context.Context, tx *Tx, id int) (*wtf.Webhook, error) {
+	webhooks, _, err := findWebhooks(ctx, tx, wtf.WebhookFilter{ID: &id})
+	if err != nil {
+		return nil, err
+	} else if len(webhooks) == 0 {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "Webhook not found."}
+	}
+	return webhooks[0], nil
+}
+
+// findWebhooks returns a list of webhooks on dials that the current user owns
+// or administers. Also returns a count of total matching webhooks.
+func findWebhooks(ctx context.Context, tx *Tx, filter wtf.WebhookFilter) (_ []*wtf.Webhook, n int, err error) {
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := filter.ID; v != nil {
+		where, args = append(where, "id = ?"), append(args, *v)
+	}
+	if v := filter.DialID; v != nil {
+		where, args = append(where, "dial_id = ?"), append(args, *v)
+	}
+
+	// Limit to webhooks on dials the user can manage as they include secrets.
+	where = append(where, webhookDialPermissionClause)
+	args = append(args, wtf.UserIDFromContext(ctx), wtf.DialMembershipRoleOwner, wtf.DialMembershipRoleAdmin)
+
+	return queryWebhooks(ctx, tx, where, args, filter.Limit, filter.Offset)
+}
+
+// webhookDialPermissionClause restricts results to dials that the user owns
+// or administers. The args are the user ID & the owner & admin roles.
+const webhookDialPermissionClause = `dial_id IN (SELECT dial_id FROM dial_memberships WHERE user_id = ? AND role IN (?, ?))`
+
+// queryWebhooks executes a query against the webhooks table using the given
+// WHERE clause segments which are AND-ed together.
+func queryWebhooks(ctx context.Context, tx *Tx, where []string, args []interface{}, limit, offset int) (_ []*wtf.Webhook, n int, err error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+		    id,
+		    dial_id,
+		    url,
+		    secret,
+		    event_types,
+		    created_at,
+		    updated_at,
+		    COUNT(*) OVER()
+		FROM webhooks
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY id ASC
+		`+FormatLimitOffset(limit, offset),
+		args...,
+	)
+	if err != nil {
+		return nil, n, FormatError(err)
+	}
+	defer rows.Close()
+
+	webhooks := make([]*wtf.Webhook, 0)
+	for rows.Next() {
+		var webhook wtf.Webhook
+		var eventTypes string
+		if err := rows.Scan(
+			&webhook.ID,
+			&webhook.DialID,
+			&webhook.URL,
+			&webhook.Secret,
+			&eventTypes,
+			(*NullTime)(&webhook.CreatedAt),
+			(*NullTime)(&webhook.UpdatedAt),
+			&n,
+		); err != nil {
+			return nil, 0, err
+		}
+
+		if eventTypes != "" {
+			webhook.EventTypes = strings.Split(eventTypes, ",")
+		}
+		webhooks = append(webhooks, &webhook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return webhooks, n, nil
+}
+
+// createWebhook creates a new webhook on a dial. Returns EUNAUTHORIZED if the
+// current user cannot edit the dial.
+func createWebhook(ctx context.Context, tx *Tx, webhook *wtf.Webhook) error {
+	// Generate a secret if one is not provided.
+	if webhook.Secret == "" {
+		secret, err := generateInviteCode()
+		if err != nil {
+			return err
+		}
+		webhook.Secret = secret
+	}
+
+	// Set timestamps to current time.
+	webhook.CreatedAt = tx.now
+	webhook.UpdatedAt = webhook.CreatedAt
+
+	// Perform basic field validation.
+	if err := webhook.Validate(); err != nil {
+		return err
+	}
+
+	// Verify the user is a member of the dial & can manage it.
+	if dial, err := findDialByID(ctx, tx, webhook.DialID); err != nil {
+		return err
+	} else if !wtf.CanEditDial(ctx, dial) {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You must be the owner or an admin to create webhooks.")
+	}
+
+	// Insert row into database.
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO webhooks (
+			dial_id,
+			url,
+			secret,
+			event_types,
+			created_at,
+			updated_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`,
+		webhook.DialID,
+		webhook.URL,
+		webhook.Secret,
+		strings.Join(webhook.EventTypes, ","),
+		(*NullTime)(&webhook.CreatedAt),
+		(*NullTime)(&webhook.UpdatedAt),
+	)
+	if err != nil {
+		return FormatError(err)
+	}
+
+	// Read back new webhook ID into caller argument.
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	webhook.ID = int(id)
+
+	return nil
+}
+
+// deleteWebhook permanently removes a webhook. Deliveries are removed by the
+// foreign key cascade.
+func deleteWebhook(ctx context.Context, tx *Tx, id int) error {
+	// Verify object exists & the current user can manage the dial.
+	webhook, err := findWebhookByID(ctx, tx, id)
+	if err != nil {
+		return err
+	} else if err := attachWebhookAssociations(ctx, tx, webhook); err != nil {
+		return err
+	} else if !wtf.CanEditDial(ctx, webhook.Dial) {
+		return wtf.Errorf(wtf.EUNAUTHORIZED, "You must be the owner or an admin to delete webhooks.")
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM webhooks WHERE id = ?`, id); err != nil {
+		return FormatError(err)
+	}
+	return nil
+}
+
+// findWebhookDeliveries returns a list of deliveries for webhooks that the
+// current user can see, most recent first. Also returns a total count.
+func findWebhookDeliveries(ctx context.Context, tx *Tx, filter wtf.WebhookDeliveryFilter) (_ []*wtf.WebhookDelivery, n int, err error) {
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := filter.ID; v != nil {
+		where, args = append(where, "id = ?"), append(args, *v)
+	}
+	if v := filter.WebhookID; v != nil {
+		where, args = append(where, "webhook_id = ?"), append(args, *v)
+	}
+	if v := filter.DialID; v != nil {
+		where, args = append(where, "dial_id = ?"), append(args, *v)
+	}
+	if v := filter.Status; v != nil {
+		where, args = append(where, "status = ?"), append(args, *v)
+	}
+
+	// Limit to deliveries on dials the user can manage.
+	where = append(where, webhookDialPermissionClause)
+	args = append(args, wtf.UserIDFromContext(ctx), wtf.DialMembershipRoleOwner, wtf.DialMembershipRoleAdmin)
+
+	return queryWebhookDeliveries(ctx, tx, where, args, "created_at DESC, id DESC", filter.Limit, filter.Offset)
+}
+
+// queryWebhookDeliveries executes a query against the webhook_deliveries
+// table using the given WHERE clause segments which are AND-ed together.
+func queryWebhookDeliveries(ctx context.Context, tx *Tx, where []string, args []interface{}, sortBy string, limit, offset int) (_ []*wtf.WebhookDelivery, n int, err error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+		    id,
+		    webhook_id,
+		    dial_id,
+		    event_type,
+		    body,
+		    status,
+		    attempts,
+		    status_code,
+		    error,
+		    next_attempt_at,
+		    created_at,
+		    updated_at,
+		    COUNT(*) OVER()
+		FROM webhook_deliveries
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY `+sortBy+`
+		`+FormatLimitOffset(limit, offset),
+		args...,
+	)
+	if err != nil {
+		return nil, n, FormatError(err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]*wtf.WebhookDelivery, 0)
+	for rows.Next() {
+		var delivery wtf.WebhookDelivery
+		var body string
+		var nextAttemptAt NullTime
+		if err := rows.Scan(
+			&delivery.ID,
+			&delivery.WebhookID,
+			&delivery.DialID,
+			&delivery.EventType,
+			&body,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.StatusCode,
+			&delivery.Error,
+			&nextAttemptAt,
+			(*NullTime)(&delivery.CreatedAt),
+			(*NullTime)(&delivery.UpdatedAt),
+			&n,
+		); err != nil {
+			return nil, 0, err
+		}
+
+		delivery.Body = json.RawMessage(body)
+		if t := time.Time(nextAttemptAt); !t.IsZero() {
+			delivery.NextAttemptAt = &t
+		}
+		deliveries = append(deliveries, &delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return deliveries, n, nil
+}
+
+// insertWebhookDeliveries queues a delivery of event to each webhook on the
+// dial which is subscribed to the event type.
+func insertWebhookDeliveries(ctx context.Context, tx *Tx, dialID int, event wtf.Event) error {
+	webhooks, _, err := queryWebhooks(ctx, tx, []string{"dial_id = ?"}, []interface{}{dialID}, 0, 0)
+	if err != nil {
+		return err
+	} else if len(webhooks) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.Subscribed(event.Type) {
+			continue
+		}
+
+		delivery := &wtf.WebhookDelivery{
+			WebhookID: webhook.ID,
+			DialID:    dialID,
+			EventType: event.Type,
+			Body:      body,
+		}
+		if err := insertWebhookDelivery(ctx, tx, delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertWebhookDelivery inserts a new pending delivery which is due immediately.
+func insertWebhookDelivery(ctx context.Context, tx *Tx, delivery *wtf.WebhookDelivery) error {
+	nextAttemptAt := tx.now
+	delivery.Status = wtf.WebhookDeliveryStatusPending
+	delivery.Attempts, delivery.StatusCode, delivery.Error = 0, 0, ""
+	delivery.NextAttemptAt = &nextAttemptAt
+	delivery.CreatedAt = tx.now
+	delivery.UpdatedAt = delivery.CreatedAt
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (
+			webhook_id,
+			dial_id,
+			event_type,
+			body,
+			status,
+			next_attempt_at,
+			created_at,
+			updated_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		delivery.WebhookID,
+		delivery.DialID,
+		delivery.EventType,
+		string(delivery.Body),
+		delivery.Status,
+		(*NullTime)(delivery.NextAttemptAt),
+		(*NullTime)(&delivery.CreatedAt),
+		(*NullTime)(&delivery.UpdatedAt),
+	)
+	if err != nil {
+		return FormatError(err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	delivery.ID = int(id)
+
+	return nil
+}
+
+// redeliverWebhookDelivery queues a copy of an existing delivery.
+func redeliverWebhookDelivery(ctx context.Context, tx *Tx, id int) (*wtf.WebhookDelivery, error) {
+	deliveries, _, err := findWebhookDeliveries(ctx, tx, wtf.WebhookDeliveryFilter{ID: &id})
+	if err != nil {
+		return nil, err
+	} else if len(deliveries) == 0 {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "Webhook delivery not found."}
+	}
+
+	delivery := &wtf.WebhookDelivery{
+		WebhookID: deliveries[0].WebhookID,
+		DialID:    deliveries[0].DialID,
+		EventType: deliveries[0].EventType,
+		Body:      deliveries[0].Body,
+	}
+	if err := insertWebhookDelivery(ctx, tx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// recordWebhookDeliveryAttempt updates a delivery with the result of an attempt.
+func recordWebhookDeliveryAttempt(ctx context.Context, tx *Tx, id int, result wtf.WebhookDeliveryResult) (*wtf.WebhookDelivery, error) {
+	deliveries, _, err := queryWebhookDeliveries(ctx, tx, []string{"id = ?"}, []interface{}{id}, "id ASC", 0, 0)
+	if err != nil {
+		return nil, err
+	} else if len(deliveries) == 0 {
+		return nil, &wtf.Error{Code: wtf.ENOTFOUND, Message: "Webhook delivery not found."}
+	}
+	delivery := deliveries[0]
+
+	// Only pending deliveries can be attempted.
+	if delivery.Status != wtf.WebhookDeliveryStatusPending {
+		return nil, wtf.Errorf(wtf.ECONFLICT, "Webhook delivery is not pending.")
+	}
+	delivery.RecordAttempt(result, tx.now)
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = ?,
+		    attempts = ?,
+		    status_code = ?,
+		    error = ?,
+		    next_attempt_at = ?,
+		    updated_at = ?
+		WHERE id = ?
+	`,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.StatusCode,
+		delivery.Error,
+		(*NullTime)(delivery.NextAttemptAt),
+		(*NullTime)(&delivery.UpdatedAt),
+		id,
+	); err != nil {
+		return nil, FormatError(err)
+	}
+	return delivery, nil
+}
+
+// attachWebhookAssociations attaches the dial to the webhook.
+func attachWebhookAssociations(ctx context.Context, tx *Tx, webhook *wtf.Webhook) (err error) {
+	if webhook.Dial, err = findDialByID(ctx, tx, webhook.DialID); err != nil {
+		return fmt.Errorf("attach webhook dial: %w", err)
+	}
+	return nil
+}
@@ -14,15 +14,25 @@ type User struct {
 	Name  string `json:"name"`
 	Email string `json:"email"`
 
-	// Randomly generated API key for use with the CLI.
+	// API token used by clients, such as the CLI, to authenticate with the
+	// HTTP API. This is only set on the client & is not stored with the user.
 	APIKey string `json:"-"`
 
+	// Preferred timezone as an IANA name (e.g. "America/Los_Angeles"). Used
+	// to align daily & weekly report intervals and to display timestamps.
+	// Defaults to UTC if blank.
+	Timezone string `json:"timezone"`
+
+	// Source of the linked auth whose avatar is preferred (e.g. "github").
+	// If blank or unavailable, the first available avatar is used.
+	AvatarSource string `json:"avatarSource"`
+
 	// Timestamps for user creation & last update.
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 
 	// List of associated OAuth authentication objects.
-	// Currently only GitHub is supported so there should only be a maximum of one.
+	// A user can have at most one auth per source.
 	Auths []*Auth `json:"auths"`
 }
 
@@ -31,14 +41,56 @@ type User struct {
 func (u *User) Validate() error {
 	if u.Name == "" {
 		return Errorf(EINVALID, "User name required.")
+	} else if !IsValidTimezone(u.Timezone) {
+		return Errorf(EINVALID, "Invalid timezone.")
+	}
+	return nil
+}
+
+// Location returns the location for the user's timezone.
+// Returns UTC if the timezone is blank or invalid.
+func (u *User) Location() *time.Location {
+	if u.Timezone == "" {
+		return time.UTC
+	} else if loc, err := time.LoadLocation(u.Timezone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// IsValidTimezone returns true if s is blank or a known IANA timezone name.
+func IsValidTimezone(s string) bool {
+	if s == "" {
+		return true
+	} else if s == "Local" {
+		return false // depends on the server so it is not a valid preference
+	}
+	_, err := time.LoadLocation(s)
+	return err == nil
+}
+
+// AuthBySource returns the user's auth for the given source.
+// Returns nil if the user has not linked an account from the source.
+func (u *User) AuthBySource(source string) *Auth {
+	for _, auth := range u.Auths {
+		if auth.Source == source {
+			return auth
+		}
 	}
 	return nil
 }
 
 // AvatarURL returns a URL to the avatar image for the user.
-// This loops over all auth providers to find the first available avatar.
-// Currently only GitHub is supported. Returns blank string if no avatar URL available.
+// The avatar from the user's preferred source is used, if available.
+// Otherwise this loops over all auth providers to find the first available
+// avatar. Returns blank string if no avatar URL available.
 func (u *User) AvatarURL(size int) string {
+	if auth := u.AuthBySource(u.AvatarSource); auth != nil {
+		if s := auth.AvatarURL(size); s != "" {
+			return s
+		}
+	}
+
 	for _, auth := range u.Auths {
 		if s := auth.AvatarURL(size); s != "" {
 			return s
@@ -74,9 +126,8 @@ type UserService interface {
 // UserFilter represents a filter passed to FindUsers().
 type UserFilter struct {
 	// Filtering fields.
-	ID     *int    `json:"id"`
-	Email  *string `json:"email"`
-	APIKey *string `json:"apiKey"`
+	ID    *int    `json:"id"`
+	Email *string `json:"email"`
 
 	// Restrict to subset of results.
 	Offset int `json:"offset"`
@@ -84,7 +135,13 @@ type UserFilter struct {
 }
 
 // UserUpdate represents a set of fields to be updated via UpdateUser().
+//
+// The email cannot be updated as it is used to link new logins to existing
+// users. It is only set from a login which has verified the address.
 type UserUpdate struct {
-	Name  *string `json:"name"`
-	Email *string `json:"email"`
+	Name     *string `json:"name"`
+	Timezone *string `json:"timezone"`
+
+	// Must be blank or the source of one of the user's auths.
+	AvatarSource *string `json:"avatarSource"`
 }
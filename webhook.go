@@ -0,0 +1,311 @@
+package wtf
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Webhook constants.
+const (
+	MaxWebhookURLLen = 2048
+
+	// Number of attempts made before a delivery is marked as failed. Retries
+	// wait twice as long as the previous retry, starting at the base delay.
+	MaxWebhookDeliveryAttempts = 6
+	WebhookRetryBaseDelay      = 30 * time.Second
+)
+
+// WebhookEventTypes is the list of event types that can be sent to webhooks.
+var WebhookEventTypes = []string{
+	EventTypeDialValueChanged,
+	EventTypeDialMembershipValueChanged,
+	EventTypeAlertFired,
+	EventTypeAlertResolved,
+}
+
+// IsValidWebhookEventType returns true if s is an event type that can be sent to webhooks.
+func IsValidWebhookEventType(s string) bool {
+	for _, typ := range WebhookEventTypes {
+		if s == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook represents a subscription to the events of a dial. Each event is
+// delivered to the URL as an HTTP POST of the JSON-encoded Event & signed
+// using the secret. Only the dial owner & admins can manage webhooks.
+type Webhook struct {
+	ID int `json:"id"`
+
+	// Dial whose events are delivered to the webhook.
+	DialID int   `json:"dialID"`
+	Dial   *Dial `json:"dial"`
+
+	// Destination URL for deliveries. Must be an HTTP or HTTPS URL.
+	URL string `json:"url"`
+
+	// Key used to sign the body of each delivery. A random secret is
+	// generated if one is not specified on creation.
+	Secret string `json:"secret"`
+
+	// Types of events to deliver. All event types are delivered if empty.
+	EventTypes []string `json:"eventTypes"`
+
+	// Timestamps for webhook creation & last update.
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Validate returns an error if the webhook contains invalid fields.
+// This only performs basic validation.
+func (w *Webhook) Validate() error {
+	if w.DialID == 0 {
+		return Errorf(EINVALID, "Dial required for webhook.")
+	} else if w.URL == "" {
+		return Errorf(EINVALID, "Webhook URL required.")
+	} else if len(w.URL) > MaxWebhookURLLen {
+		return Errorf(EINVALID, "Webhook URL too long.")
+	} else if u, err := url.Parse(w.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Errorf(EINVALID, "Webhook URL must be an HTTP or HTTPS URL.")
+	} else if !isPublicWebhookHost(u.Hostname()) {
+		return Errorf(EINVALID, "Webhook URL must not point to a local or private address.")
+	} else if w.Secret == "" {
+		return Errorf(EINVALID, "Webhook secret required.")
+	}
+
+	for _, typ := range w.EventTypes {
+		if !IsValidWebhookEventType(typ) {
+			return Errorf(EINVALID, "Invalid webhook event type.")
+		}
+	}
+	return nil
+}
+
+// isPublicWebhookHost returns false if host is a local name or an IP address
+// which is not public. Other host names are checked when a delivery is sent
+// since they may resolve to a different address by then.
+func isPublicWebhookHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	} else if ip := net.ParseIP(host); ip != nil {
+		return IsPublicIP(ip)
+	}
+	return true
+}
+
+// nonPublicIPNets are address ranges which are globally unicast but are not
+// reachable on the public internet.
+var nonPublicIPNets = mustParseCIDRs(
+	"0.0.0.0/8",      // "this" network
+	"10.0.0.0/8",     // private
+	"100.64.0.0/10",  // carrier-grade NAT
+	"172.16.0.0/12",  // private
+	"192.0.0.0/24",   // IETF protocol assignments
+	"192.168.0.0/16", // private
+	"198.18.0.0/15",  // benchmarking
+	"240.0.0.0/4",    // reserved
+	"fc00::/7",       // unique local
+)
+
+// IsPublicIP returns true if ip is a publicly routable unicast address.
+// Loopback, link-local, multicast, private & reserved addresses are not public.
+// Webhooks are only delivered to public addresses so that they cannot be used
+// to reach services on the server's own network.
+func IsPublicIP(ip net.IP) bool {
+	if !ip.IsGlobalUnicast() {
+		return false
+	}
+	for _, ipnet := range nonPublicIPNets {
+		if ipnet.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// mustParseCIDRs parses a list of CIDR blocks. Panics on error.
+func mustParseCIDRs(a ...string) []*net.IPNet {
+	ipnets := make([]*net.IPNet, len(a))
+	for i, s := range a {
+		_, ipnet, err := net.ParseCIDR(s)
+		if err != nil {
+			panic(err)
+		}
+		ipnets[i] = ipnet
+	}
+	return ipnets
+}
+
+// Subscribed returns true if events of the given type should be delivered.
+func (w *Webhook) Subscribed(eventType string) bool {
+	if len(w.EventTypes) == 0 {
+		return true
+	}
+	for _, typ := range w.EventTypes {
+		if typ == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// SignWebhookBody returns the signature of a delivery body. The signature is
+// the hex-encoded HMAC-SHA256 of the body, prefixed with "sha256=".
+func SignWebhookBody(secret string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write(body)
+	return "sha256=" + hex.EncodeToString(h.Sum(nil))
+}
+
+// Webhook delivery statuses.
+const (
+	WebhookDeliveryStatusPending   = "pending"
+	WebhookDeliveryStatusSucceeded = "succeeded"
+	WebhookDeliveryStatusFailed    = "failed"
+)
+
+// WebhookDelivery represents a single event to be sent to a webhook. Failed
+// attempts are retried with backoff until the delivery succeeds or reaches
+// the maximum number of attempts.
+type WebhookDelivery struct {
+	ID int `json:"id"`
+
+	// Webhook the event is sent to & the dial it belongs to. The webhook is
+	// only attached for pending deliveries returned to the delivery worker.
+	WebhookID int      `json:"webhookID"`
+	Webhook   *Webhook `json:"webhook,omitempty"`
+	DialID    int      `json:"dialID"`
+
+	// Type of event & the JSON-encoded Event sent as the request body.
+	EventType string          `json:"eventType"`
+	Body      json.RawMessage `json:"body"`
+
+	// Current status & number of attempts made so far.
+	Status   string `json:"status"`
+	Attempts int    `json:"attempts"`
+
+	// Response status code & error message from the most recent attempt.
+	StatusCode int    `json:"statusCode"`
+	Error      string `json:"error,omitempty"`
+
+	// Time of the next attempt. Only set while the delivery is pending.
+	NextAttemptAt *time.Time `json:"nextAttemptAt"`
+
+	// Timestamps for delivery creation & last update.
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// RecordAttempt updates the delivery with the result of an attempt made at
+// the given time. Successful attempts complete the delivery. Otherwise the
+// next attempt is scheduled or the delivery is failed once the maximum number
+// of attempts has been reached.
+func (d *WebhookDelivery) RecordAttempt(result WebhookDeliveryResult, now time.Time) {
+	d.Attempts++
+	d.StatusCode, d.Error = result.StatusCode, result.Error
+	d.UpdatedAt = now
+
+	if result.OK() {
+		d.Status, d.NextAttemptAt = WebhookDeliveryStatusSucceeded, nil
+		return
+	} else if d.Attempts >= MaxWebhookDeliveryAttempts {
+		d.Status, d.NextAttemptAt = WebhookDeliveryStatusFailed, nil
+		return
+	}
+
+	t := now.Add(WebhookRetryDelay(d.Attempts))
+	d.NextAttemptAt = &t
+}
+
+// WebhookRetryDelay returns the time to wait before retrying a delivery after
+// the given number of failed attempts.
+func WebhookRetryDelay(attempts int) time.Duration {
+	if attempts < 1 {
+		return 0
+	}
+	return WebhookRetryBaseDelay << (attempts - 1)
+}
+
+// WebhookDeliveryResult represents the outcome of a single delivery attempt.
+type WebhookDeliveryResult struct {
+	// HTTP status code returned by the receiver. Zero if no response was received.
+	StatusCode int `json:"statusCode"`
+
+	// Error message if the request could not be made.
+	Error string `json:"error,omitempty"`
+}
+
+// OK returns true if the receiver accepted the delivery with a 2xx response.
+func (r *WebhookDeliveryResult) OK() bool {
+	return r.Error == "" && r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// WebhookService represents a service for managing webhooks & their deliveries.
+type WebhookService interface {
+	// Retrieves a single webhook by ID along with the associated dial.
+	// Returns ENOTFOUND if webhook does not exist or user is not the owner or
+	// an admin of the webhook's dial.
+	FindWebhookByID(ctx context.Context, id int) (*Webhook, error)
+
+	// Retrieves a list of webhooks by filter. Only returns webhooks on dials
+	// that the current user owns or administers. Also returns the total count
+	// of matching webhooks which may differ if filter.Limit is specified.
+	FindWebhooks(ctx context.Context, filter WebhookFilter) ([]*Webhook, int, error)
+
+	// Creates a new webhook on a dial. Returns EUNAUTHORIZED if the user is
+	// not the owner or an admin of the dial.
+	CreateWebhook(ctx context.Context, webhook *Webhook) error
+
+	// Permanently removes a webhook & its deliveries. Returns EUNAUTHORIZED if
+	// the user is not the owner or an admin of the dial.
+	DeleteWebhook(ctx context.Context, id int) error
+
+	// Retrieves a list of deliveries by filter, most recent first. Only
+	// returns deliveries for webhooks the current user can see.
+	FindWebhookDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]*WebhookDelivery, int, error)
+
+	// Queues a new delivery of the same event as an existing delivery.
+	// Returns the new delivery.
+	RedeliverWebhookDelivery(ctx context.Context, id int) (*WebhookDelivery, error)
+
+	// Retrieves pending deliveries which are due to be attempted along with
+	// their webhook. This is intended for the delivery worker & ignores the
+	// current user.
+	FindPendingWebhookDeliveries(ctx context.Context, limit int) ([]*WebhookDelivery, error)
+
+	// Records the result of a delivery attempt & schedules a retry, if needed.
+	// This is intended for the delivery worker & ignores the current user.
+	RecordWebhookDeliveryAttempt(ctx context.Context, id int, result WebhookDeliveryResult) (*WebhookDelivery, error)
+}
+
+// WebhookFilter represents a filter used by FindWebhooks().
+type WebhookFilter struct {
+	ID     *int `json:"id"`
+	DialID *int `json:"dialID"`
+
+	// Restricts results to a subset of the total range.
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+}
+
+// WebhookDeliveryFilter represents a filter used by FindWebhookDeliveries().
+type WebhookDeliveryFilter struct {
+	ID        *int    `json:"id"`
+	WebhookID *int    `json:"webhookID"`
+	DialID    *int    `json:"dialID"`
+	Status    *string `json:"status"`
+
+	// Restricts results to a subset of the total range.
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+}
@@ -0,0 +1,384 @@
+package wtftest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+)
+
+func testAlertService(t *testing.T, open OpenFunc) {
+	t.Run("CreateAlertRule", func(t *testing.T) { testAlertService_CreateAlertRule(t, open) })
+	t.Run("FindAlertRules", func(t *testing.T) { testAlertService_FindAlertRules(t, open) })
+	t.Run("DeleteAlertRule", func(t *testing.T) { testAlertService_DeleteAlertRule(t, open) })
+	t.Run("EvaluateAlertRules", func(t *testing.T) { testAlertService_EvaluateAlertRules(t, open) })
+	t.Run("Events", func(t *testing.T) { testAlertService_Events(t, open) })
+}
+
+func testAlertService_CreateAlertRule(t *testing.T, open OpenFunc) {
+	// Ensure the dial owner can create an alert rule.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		rule := &wtf.AlertRule{DialID: dial.ID, Condition: wtf.AlertConditionAbove, Threshold: 75, Duration: 10 * time.Minute}
+		if err := s.AlertService.CreateAlertRule(ctx0, rule); err != nil {
+			t.Fatal(err)
+		} else if got, want := rule.ID, 1; got != want {
+			t.Fatalf("ID=%v, want %v", got, want)
+		} else if got, want := rule.State, wtf.AlertRuleStateOK; got != want {
+			t.Fatalf("State=%v, want %v", got, want)
+		} else if rule.Dial == nil || rule.Dial.ID != dial.ID {
+			t.Fatalf("unexpected dial: %#v", rule.Dial)
+		} else if rule.CreatedAt.IsZero() {
+			t.Fatal("expected created at")
+		}
+
+		// Fetch rule & compare.
+		if other, err := s.AlertService.FindAlertRuleByID(ctx0, rule.ID); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(rule, other) {
+			t.Fatalf("mismatch: %#v != %#v", rule, other)
+		}
+	})
+
+	// Ensure an invalid condition returns an error.
+	t.Run("ErrInvalidCondition", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		if err := s.AlertService.CreateAlertRule(ctx0, &wtf.AlertRule{DialID: dial.ID, Condition: "sideways"}); wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Invalid alert condition.` {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure the threshold must be a valid dial value.
+	t.Run("ErrInvalidThreshold", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		if err := s.AlertService.CreateAlertRule(ctx0, &wtf.AlertRule{DialID: dial.ID, Condition: wtf.AlertConditionAbove, Threshold: 101}); wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Alert threshold must be between 0 & 100.` {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure rising alerts require a window to measure the change within.
+	t.Run("ErrRiseDurationRequired", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		if err := s.AlertService.CreateAlertRule(ctx0, &wtf.AlertRule{DialID: dial.ID, Condition: wtf.AlertConditionRise, Threshold: 30}); wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Rising alerts require a threshold & duration.` {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure regular members cannot create alert rules.
+	t.Run("ErrUnauthorized", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial, InviteCode: dial.InviteCode})
+
+		if err := s.AlertService.CreateAlertRule(ctx1, &wtf.AlertRule{DialID: dial.ID, Condition: wtf.AlertConditionAbove, Threshold: 75}); wtf.ErrorCode(err) != wtf.EUNAUTHORIZED {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure non-members cannot see the dial to create a rule.
+	t.Run("ErrDialNotFound", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		if err := s.AlertService.CreateAlertRule(ctx1, &wtf.AlertRule{DialID: dial.ID, Condition: wtf.AlertConditionAbove, Threshold: 75}); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatal(err)
+		}
+	})
+}
+
+func testAlertService_FindAlertRules(t *testing.T, open OpenFunc) {
+	// Ensure members can see rules on their dials only.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		dial0 := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL0"})
+		dial1 := MustCreateDial(t, ctx1, s, &wtf.Dial{Name: "DIAL1"})
+		MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial0.ID, Dial: dial0, InviteCode: dial0.InviteCode})
+
+		MustCreateAlertRule(t, ctx0, s, &wtf.AlertRule{DialID: dial0.ID, Condition: wtf.AlertConditionAbove, Threshold: 75})
+		MustCreateAlertRule(t, ctx0, s, &wtf.AlertRule{DialID: dial0.ID, Condition: wtf.AlertConditionBelow, Threshold: 10})
+		MustCreateAlertRule(t, ctx1, s, &wtf.AlertRule{DialID: dial1.ID, Condition: wtf.AlertConditionAbove, Threshold: 50})
+
+		// The member can see both dials' rules but the owner only sees their own.
+		if rules, n, err := s.AlertService.FindAlertRules(ctx1, wtf.AlertRuleFilter{}); err != nil {
+			t.Fatal(err)
+		} else if got, want := n, 3; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		} else if got, want := len(rules), 3; got != want {
+			t.Fatalf("len=%v, want %v", got, want)
+		}
+
+		if rules, n, err := s.AlertService.FindAlertRules(ctx0, wtf.AlertRuleFilter{}); err != nil {
+			t.Fatal(err)
+		} else if got, want := n, 2; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		} else if got, want := rules[1].Condition, wtf.AlertConditionBelow; got != want {
+			t.Fatalf("Condition=%v, want %v", got, want)
+		}
+
+		// Filter by dial.
+		if rules, _, err := s.AlertService.FindAlertRules(ctx1, wtf.AlertRuleFilter{DialID: &dial1.ID}); err != nil {
+			t.Fatal(err)
+		} else if got, want := len(rules), 1; got != want {
+			t.Fatalf("len=%v, want %v", got, want)
+		} else if got, want := rules[0].Threshold, 50; got != want {
+			t.Fatalf("Threshold=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure non-members cannot fetch a rule by ID.
+	t.Run("ErrNotFound", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		rule := MustCreateAlertRule(t, ctx0, s, &wtf.AlertRule{DialID: dial.ID, Condition: wtf.AlertConditionAbove, Threshold: 75})
+
+		if _, err := s.AlertService.FindAlertRuleByID(ctx1, rule.ID); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatal(err)
+		}
+	})
+}
+
+func testAlertService_DeleteAlertRule(t *testing.T, open OpenFunc) {
+	// Ensure the dial owner can delete a rule.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		rule := MustCreateAlertRule(t, ctx0, s, &wtf.AlertRule{DialID: dial.ID, Condition: wtf.AlertConditionAbove, Threshold: 75})
+
+		if err := s.AlertService.DeleteAlertRule(ctx0, rule.ID); err != nil {
+			t.Fatal(err)
+		} else if _, err := s.AlertService.FindAlertRuleByID(ctx0, rule.ID); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure regular members cannot delete a rule.
+	t.Run("ErrUnauthorized", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial, InviteCode: dial.InviteCode})
+		rule := MustCreateAlertRule(t, ctx0, s, &wtf.AlertRule{DialID: dial.ID, Condition: wtf.AlertConditionAbove, Threshold: 75})
+
+		if err := s.AlertService.DeleteAlertRule(ctx1, rule.ID); wtf.ErrorCode(err) != wtf.EUNAUTHORIZED {
+			t.Fatal(err)
+		}
+	})
+}
+
+func testAlertService_EvaluateAlertRules(t *testing.T, open OpenFunc) {
+	// Ensure a rule fires once the value has been past the threshold for the
+	// full duration & resolves when the value drops.
+	t.Run("Above", func(t *testing.T) {
+		s := open(t)
+		t0 := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		setNow(t, s, t0)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		rule := MustCreateAlertRule(t, ctx0, s, &wtf.AlertRule{DialID: dial.ID, Condition: wtf.AlertConditionAbove, Threshold: 75, Duration: 10 * time.Minute})
+
+		// Raise the value. The rule should not fire until the duration passes.
+		if err := s.DialService.SetDialMembershipValue(ctx0, dial.ID, 80, ""); err != nil {
+			t.Fatal(err)
+		}
+		setNow(t, s, t0.Add(5*time.Minute))
+		MustEvaluateAlertRules(t, ctx0, s)
+		if other := MustFindAlertRuleByID(t, ctx0, s, rule.ID); other.State != wtf.AlertRuleStateOK {
+			t.Fatalf("State=%v, want %v", other.State, wtf.AlertRuleStateOK)
+		}
+
+		// Fire once the value has stayed above the threshold.
+		setNow(t, s, t0.Add(10*time.Minute))
+		MustEvaluateAlertRules(t, ctx0, s)
+		if other := MustFindAlertRuleByID(t, ctx0, s, rule.ID); other.State != wtf.AlertRuleStateFiring {
+			t.Fatalf("State=%v, want %v", other.State, wtf.AlertRuleStateFiring)
+		}
+
+		// Evaluating again should not record another alert.
+		setNow(t, s, t0.Add(11*time.Minute))
+		MustEvaluateAlertRules(t, ctx0, s)
+
+		// Lowering the value should resolve the alert immediately.
+		setNow(t, s, t0.Add(12*time.Minute))
+		if err := s.DialService.SetDialMembershipValue(ctx0, dial.ID, 50, ""); err != nil {
+			t.Fatal(err)
+		} else if other := MustFindAlertRuleByID(t, ctx0, s, rule.ID); other.State != wtf.AlertRuleStateOK {
+			t.Fatalf("State=%v, want %v", other.State, wtf.AlertRuleStateOK)
+		}
+
+		resolvedAt := t0.Add(12 * time.Minute)
+		if alerts, n, err := s.AlertService.FindAlerts(ctx0, wtf.AlertFilter{AlertRuleID: &rule.ID}); err != nil {
+			t.Fatal(err)
+		} else if got, want := n, 1; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		} else if got, want := alerts[0], (&wtf.Alert{ID: alerts[0].ID, AlertRuleID: rule.ID, DialID: dial.ID, Value: 80, FiredAt: t0.Add(10 * time.Minute), ResolvedAt: &resolvedAt}); !reflect.DeepEqual(got, want) {
+			t.Fatalf("alert=%#v, want %#v", got, want)
+		}
+	})
+
+	// Ensure a rising rule fires as soon as the value changes enough.
+	t.Run("Rise", func(t *testing.T) {
+		s := open(t)
+		t0 := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		setNow(t, s, t0)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		rule := MustCreateAlertRule(t, ctx0, s, &wtf.AlertRule{DialID: dial.ID, Condition: wtf.AlertConditionRise, Threshold: 30, Duration: 5 * time.Minute})
+
+		if err := s.DialService.SetDialMembershipValue(ctx0, dial.ID, 20, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		// A slow rise should not fire.
+		setNow(t, s, t0.Add(10*time.Minute))
+		if err := s.DialService.SetDialMembershipValue(ctx0, dial.ID, 45, ""); err != nil {
+			t.Fatal(err)
+		} else if other := MustFindAlertRuleByID(t, ctx0, s, rule.ID); other.State != wtf.AlertRuleStateOK {
+			t.Fatalf("State=%v, want %v", other.State, wtf.AlertRuleStateOK)
+		}
+
+		// A quick rise should fire.
+		setNow(t, s, t0.Add(12*time.Minute))
+		if err := s.DialService.SetDialMembershipValue(ctx0, dial.ID, 80, ""); err != nil {
+			t.Fatal(err)
+		} else if other := MustFindAlertRuleByID(t, ctx0, s, rule.ID); other.State != wtf.AlertRuleStateFiring {
+			t.Fatalf("State=%v, want %v", other.State, wtf.AlertRuleStateFiring)
+		}
+
+		// Resolve once the rise falls outside the window.
+		setNow(t, s, t0.Add(20*time.Minute))
+		MustEvaluateAlertRules(t, ctx0, s)
+		if other := MustFindAlertRuleByID(t, ctx0, s, rule.ID); other.State != wtf.AlertRuleStateOK {
+			t.Fatalf("State=%v, want %v", other.State, wtf.AlertRuleStateOK)
+		}
+	})
+
+	// Ensure alerts are only visible to dial members.
+	t.Run("FindAlerts", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		MustCreateAlertRule(t, ctx0, s, &wtf.AlertRule{DialID: dial.ID, Condition: wtf.AlertConditionAbove, Threshold: 50})
+
+		if err := s.DialService.SetDialMembershipValue(ctx0, dial.ID, 60, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, n, err := s.AlertService.FindAlerts(ctx0, wtf.AlertFilter{DialID: &dial.ID}); err != nil {
+			t.Fatal(err)
+		} else if got, want := n, 1; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		}
+		if _, n, err := s.AlertService.FindAlerts(ctx1, wtf.AlertFilter{}); err != nil {
+			t.Fatal(err)
+		} else if got, want := n, 0; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		}
+	})
+}
+
+func testAlertService_Events(t *testing.T, open OpenFunc) {
+	// Ensure dial members are notified when a rule fires & resolves.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial, InviteCode: dial.InviteCode})
+		rule := MustCreateAlertRule(t, ctx0, s, &wtf.AlertRule{DialID: dial.ID, Condition: wtf.AlertConditionAbove, Threshold: 40})
+
+		sub := MustSubscribe(t, ctx1, s)
+		if err := s.DialService.SetDialMembershipValue(ctx0, dial.ID, 100, ""); err != nil {
+			t.Fatal(err)
+		}
+		alerts, _, err := s.AlertService.FindAlerts(ctx0, wtf.AlertFilter{})
+		if err != nil {
+			t.Fatal(err)
+		} else if len(alerts) != 1 {
+			t.Fatalf("len=%v, want 1", len(alerts))
+		}
+		mustReceiveEvent(t, sub, wtf.Event{Type: wtf.EventTypeAlertFired, Payload: &wtf.AlertPayload{ID: alerts[0].ID, AlertRuleID: rule.ID, DialID: dial.ID, Value: 50}})
+
+		if err := s.DialService.SetDialMembershipValue(ctx0, dial.ID, 0, ""); err != nil {
+			t.Fatal(err)
+		}
+		mustReceiveEvent(t, sub, wtf.Event{Type: wtf.EventTypeAlertResolved, Payload: &wtf.AlertPayload{ID: alerts[0].ID, AlertRuleID: rule.ID, DialID: dial.ID, Value: 0}})
+	})
+}
+
+// mustReceiveEvent reads pending events from sub until an event of the same
+// type as want is found & compares it. Fatal if no matching event is pending.
+func mustReceiveEvent(tb testing.TB, sub wtf.Subscription, want wtf.Event) {
+	tb.Helper()
+	for {
+		select {
+		case got := <-sub.C():
+			if got.Type != want.Type {
+				continue
+			} else if !reflect.DeepEqual(got, want) {
+				tb.Fatalf("event=%#v, want %#v", got, want)
+			}
+			return
+		default:
+			tb.Fatalf("expected %s event", want.Type)
+		}
+	}
+}
+
+// MustCreateAlertRule creates an alert rule. Fatal on error.
+func MustCreateAlertRule(tb testing.TB, ctx context.Context, s *Services, rule *wtf.AlertRule) *wtf.AlertRule {
+	tb.Helper()
+	if err := s.AlertService.CreateAlertRule(ctx, rule); err != nil {
+		tb.Fatal(err)
+	}
+	return rule
+}
+
+// MustFindAlertRuleByID finds an alert rule by ID. Fatal on error.
+func MustFindAlertRuleByID(tb testing.TB, ctx context.Context, s *Services, id int) *wtf.AlertRule {
+	tb.Helper()
+	rule, err := s.AlertService.FindAlertRuleByID(ctx, id)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return rule
+}
+
+// MustEvaluateAlertRules evaluates all alert rules. Fatal on error.
+func MustEvaluateAlertRules(tb testing.TB, ctx context.Context, s *Services) {
+	tb.Helper()
+	if err := s.AlertService.EvaluateAlertRules(ctx); err != nil {
+		tb.Fatal(err)
+	}
+}
@@ -0,0 +1,230 @@
+package wtftest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+)
+
+func testAPITokenService(t *testing.T, open OpenFunc) {
+	t.Run("CreateAPIToken", func(t *testing.T) { testAPITokenService_CreateAPIToken(t, open) })
+	t.Run("FindAPITokens", func(t *testing.T) { testAPITokenService_FindAPITokens(t, open) })
+	t.Run("DeleteAPIToken", func(t *testing.T) { testAPITokenService_DeleteAPIToken(t, open) })
+	t.Run("AuthenticateAPIToken", func(t *testing.T) { testAPITokenService_AuthenticateAPIToken(t, open) })
+	t.Run("Scope", func(t *testing.T) { testAPITokenService_Scope(t, open) })
+}
+
+func testAPITokenService_CreateAPIToken(t *testing.T, open OpenFunc) {
+	// Ensure a token can be created & its plaintext value is only returned once.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		user0, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+
+		token := &wtf.APIToken{Name: "laptop", Scope: wtf.APITokenScopeRead}
+		if err := s.APITokenService.CreateAPIToken(ctx0, token); err != nil {
+			t.Fatal(err)
+		} else if got, want := token.ID, 1; got != want {
+			t.Fatalf("ID=%v, want %v", got, want)
+		} else if got, want := token.UserID, user0.ID; got != want {
+			t.Fatalf("UserID=%v, want %v", got, want)
+		} else if !strings.HasPrefix(token.Token, wtf.APITokenPrefix) {
+			t.Fatalf("unexpected token: %q", token.Token)
+		} else if got, want := token.Prefix, token.Token[:wtf.APITokenDisplayPrefixLen]; got != want {
+			t.Fatalf("Prefix=%v, want %v", got, want)
+		} else if token.CreatedAt.IsZero() {
+			t.Fatal("expected created at")
+		}
+
+		// Ensure the plaintext token is not returned when listing.
+		if a, _, err := s.APITokenService.FindAPITokens(ctx0, wtf.APITokenFilter{}); err != nil {
+			t.Fatal(err)
+		} else if got, want := len(a), 1; got != want {
+			t.Fatalf("len=%v, want %v", got, want)
+		} else if a[0].Token != "" {
+			t.Fatalf("unexpected token: %q", a[0].Token)
+		} else if got, want := a[0].Prefix, token.Prefix; got != want {
+			t.Fatalf("Prefix=%v, want %v", got, want)
+		} else if got, want := a[0].Scope, wtf.APITokenScopeRead; got != want {
+			t.Fatalf("Scope=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure a name is required.
+	t.Run("ErrNameRequired", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		if err := s.APITokenService.CreateAPIToken(ctx0, &wtf.APIToken{Scope: wtf.APITokenScopeRead}); wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `API token name required.` {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure an unknown scope is rejected.
+	t.Run("ErrInvalidScope", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		if err := s.APITokenService.CreateAPIToken(ctx0, &wtf.APIToken{Name: "laptop", Scope: "root"}); wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Invalid API token scope.` {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure a token cannot be created already expired.
+	t.Run("ErrExpiresInPast", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		expiresAt := time.Now().Add(-time.Hour)
+		if err := s.APITokenService.CreateAPIToken(ctx0, &wtf.APIToken{Name: "laptop", Scope: wtf.APITokenScopeRead, ExpiresAt: &expiresAt}); wtf.ErrorCode(err) != wtf.EINVALID {
+			t.Fatal(err)
+		}
+	})
+}
+
+func testAPITokenService_FindAPITokens(t *testing.T, open OpenFunc) {
+	// Ensure only the current user's tokens are returned.
+	t.Run("OwnOnly", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+
+		MustCreateAPIToken(t, ctx0, s, &wtf.APIToken{Name: "A", Scope: wtf.APITokenScopeRead})
+		MustCreateAPIToken(t, ctx1, s, &wtf.APIToken{Name: "B", Scope: wtf.APITokenScopeSetValue})
+		MustCreateAPIToken(t, ctx1, s, &wtf.APIToken{Name: "C", Scope: wtf.APITokenScopeAdmin})
+
+		if a, n, err := s.APITokenService.FindAPITokens(ctx1, wtf.APITokenFilter{Limit: 1}); err != nil {
+			t.Fatal(err)
+		} else if got, want := len(a), 1; got != want {
+			t.Fatalf("len=%v, want %v", got, want)
+		} else if got, want := a[0].Name, "B"; got != want {
+			t.Fatalf("Name=%v, want %v", got, want)
+		} else if got, want := n, 2; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		}
+	})
+}
+
+func testAPITokenService_DeleteAPIToken(t *testing.T, open OpenFunc) {
+	// Ensure a revoked token can no longer be used.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		token := MustCreateAPIToken(t, ctx0, s, &wtf.APIToken{Name: "laptop", Scope: wtf.APITokenScopeAdmin})
+
+		if err := s.APITokenService.DeleteAPIToken(ctx0, token.ID); err != nil {
+			t.Fatal(err)
+		} else if _, err := s.APITokenService.AuthenticateAPIToken(context.Background(), token.Token); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure a user cannot revoke another user's token.
+	t.Run("ErrNotFound", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+		token := MustCreateAPIToken(t, ctx0, s, &wtf.APIToken{Name: "laptop", Scope: wtf.APITokenScopeAdmin})
+
+		if err := s.APITokenService.DeleteAPIToken(ctx1, token.ID); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatal(err)
+		}
+	})
+}
+
+func testAPITokenService_AuthenticateAPIToken(t *testing.T, open OpenFunc) {
+	// Ensure a token returns its user & records when it was used.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		user0, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		token := MustCreateAPIToken(t, ctx0, s, &wtf.APIToken{Name: "laptop", Scope: wtf.APITokenScopeSetValue})
+
+		if other, err := s.APITokenService.AuthenticateAPIToken(context.Background(), token.Token); err != nil {
+			t.Fatal(err)
+		} else if got, want := other.ID, token.ID; got != want {
+			t.Fatalf("ID=%v, want %v", got, want)
+		} else if got, want := other.Scope, wtf.APITokenScopeSetValue; got != want {
+			t.Fatalf("Scope=%v, want %v", got, want)
+		} else if other.User == nil || other.User.ID != user0.ID {
+			t.Fatalf("unexpected user: %#v", other.User)
+		} else if other.Token != "" {
+			t.Fatalf("unexpected token: %q", other.Token)
+		}
+
+		if a, _, err := s.APITokenService.FindAPITokens(ctx0, wtf.APITokenFilter{ID: &token.ID}); err != nil {
+			t.Fatal(err)
+		} else if a[0].LastUsedAt == nil {
+			t.Fatal("expected last used at")
+		}
+	})
+
+	// Ensure an unknown token returns an error.
+	t.Run("ErrNotFound", func(t *testing.T) {
+		s := open(t)
+		if _, err := s.APITokenService.AuthenticateAPIToken(context.Background(), wtf.APITokenPrefix+"NO_SUCH_TOKEN"); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure a token cannot be used after it expires.
+	t.Run("ErrExpired", func(t *testing.T) {
+		s := open(t)
+		t0 := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		setNow(t, s, t0)
+
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		expiresAt := t0.Add(time.Hour)
+		token := MustCreateAPIToken(t, ctx0, s, &wtf.APIToken{Name: "laptop", Scope: wtf.APITokenScopeRead, ExpiresAt: &expiresAt})
+
+		setNow(t, s, t0.Add(30*time.Minute))
+		if _, err := s.APITokenService.AuthenticateAPIToken(context.Background(), token.Token); err != nil {
+			t.Fatal(err)
+		}
+
+		setNow(t, s, t0.Add(time.Hour))
+		if _, err := s.APITokenService.AuthenticateAPIToken(context.Background(), token.Token); wtf.ErrorCode(err) != wtf.EUNAUTHORIZED || wtf.ErrorMessage(err) != `API token expired.` {
+			t.Fatal(err)
+		}
+	})
+}
+
+func testAPITokenService_Scope(t *testing.T, open OpenFunc) {
+	// Ensure invite codes are only returned to requests made with an admin token.
+	t.Run("InviteCodes", func(t *testing.T) {
+		s := open(t)
+		user0, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		org := MustCreateOrganization(t, ctx0, s, &wtf.Organization{Name: "Acme"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL", OrganizationID: org.ID})
+
+		for _, scope := range []string{wtf.APITokenScopeRead, wtf.APITokenScopeSetValue, wtf.APITokenScopeAdmin} {
+			token := MustCreateAPIToken(t, ctx0, s, &wtf.APIToken{Name: scope, Scope: scope})
+			ctx := wtf.NewContextWithUser(context.Background(), &wtf.User{ID: user0.ID, APIKey: token.Token})
+			ctx = wtf.NewContextWithAPITokenScope(ctx, scope)
+
+			wantDialCode, wantOrgCode := "", ""
+			if scope == wtf.APITokenScopeAdmin {
+				wantDialCode, wantOrgCode = dial.InviteCode, org.InviteCode
+			}
+
+			if other, err := s.DialService.FindDialByID(ctx, dial.ID); err != nil {
+				t.Fatal(err)
+			} else if got, want := other.InviteCode, wantDialCode; got != want {
+				t.Fatalf("scope=%s Dial.InviteCode=%q, want %q", scope, got, want)
+			}
+
+			if other, err := s.OrganizationService.FindOrganizationByID(ctx, org.ID); err != nil {
+				t.Fatal(err)
+			} else if got, want := other.InviteCode, wantOrgCode; got != want {
+				t.Fatalf("scope=%s Organization.InviteCode=%q, want %q", scope, got, want)
+			}
+		}
+	})
+}
+
+// MustCreateAPIToken creates an API token. Fatal on error.
+func MustCreateAPIToken(tb testing.TB, ctx context.Context, s *Services, token *wtf.APIToken) *wtf.APIToken {
+	tb.Helper()
+	if err := s.APITokenService.CreateAPIToken(ctx, token); err != nil {
+		tb.Fatal(err)
+	}
+	return token
+}
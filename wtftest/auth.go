@@ -0,0 +1,343 @@
+package wtftest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+)
+
+func testAuthService(t *testing.T, open OpenFunc) {
+	t.Run("CreateAuth", func(t *testing.T) { testAuthService_CreateAuth(t, open) })
+	t.Run("DeleteAuth", func(t *testing.T) { testAuthService_DeleteAuth(t, open) })
+	t.Run("FindAuth", func(t *testing.T) { testAuthService_FindAuth(t, open) })
+	t.Run("FindAuths", func(t *testing.T) { testAuthService_FindAuths(t, open) })
+}
+
+func testAuthService_CreateAuth(t *testing.T, open OpenFunc) {
+	// Ensure we can create a new auth object and associated user.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		expiry := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		auth := &wtf.Auth{
+			Source:       wtf.AuthSourceGitHub,
+			SourceID:     "SOURCEID",
+			AccessToken:  "ACCESS",
+			RefreshToken: "REFRESH",
+			Expiry:       &expiry,
+			User: &wtf.User{
+				Name:  "jill",
+				Email: "jill@gmail.com",
+			},
+		}
+
+		// Create new auth object & ensure ID and timestamps are returned.
+		if err := s.AuthService.CreateAuth(context.Background(), auth); err != nil {
+			t.Fatal(err)
+		} else if got, want := auth.ID, 1; got != want {
+			t.Fatalf("ID=%v, want %v", got, want)
+		} else if auth.CreatedAt.IsZero() {
+			t.Fatal("expected created at")
+		} else if auth.UpdatedAt.IsZero() {
+			t.Fatal("expected updated at")
+		}
+
+		// Fetch auth & compare.
+		if other, err := s.AuthService.FindAuthByID(context.Background(), 1); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(auth, other) {
+			t.Fatalf("mismatch: %#v != %#v", auth, other)
+		}
+
+		// Fetching user should return auths.
+		if user, err := s.UserService.FindUserByID(newContextWithUser(context.Background(), s, auth.User), 1); err != nil {
+			t.Fatal(err)
+		} else if len(user.Auths) != 1 {
+			t.Fatal("expected auths")
+		} else if auth := user.Auths[0]; auth.ID != 1 {
+			t.Fatalf("unexpected auth: %#v", auth)
+		}
+	})
+
+	// Ensure that creating an auth for an existing source user updates the tokens.
+	t.Run("Existing", func(t *testing.T) {
+		s := open(t)
+		auth0, _ := MustCreateAuth(t, context.Background(), s, &wtf.Auth{
+			Source:      wtf.AuthSourceGitHub,
+			SourceID:    "X",
+			AccessToken: "ACCESS0",
+			User:        &wtf.User{Name: "X"},
+		})
+
+		auth1 := &wtf.Auth{
+			Source:      wtf.AuthSourceGitHub,
+			SourceID:    "X",
+			AccessToken: "ACCESS1",
+			User:        &wtf.User{Name: "X"},
+		}
+		if err := s.AuthService.CreateAuth(context.Background(), auth1); err != nil {
+			t.Fatal(err)
+		} else if got, want := auth1.ID, auth0.ID; got != want {
+			t.Fatalf("ID=%v, want %v", got, want)
+		} else if got, want := auth1.AccessToken, "ACCESS1"; got != want {
+			t.Fatalf("AccessToken=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure that an auth is linked to an existing user with the same email.
+	t.Run("ExistingEmail", func(t *testing.T) {
+		s := open(t)
+		user0, _ := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "X", Email: "x@y.com"})
+		auth, _ := MustCreateAuth(t, context.Background(), s, &wtf.Auth{
+			Source:      wtf.AuthSourceGitHub,
+			SourceID:    "X",
+			AccessToken: "ACCESS",
+			User:        &wtf.User{Name: "Y", Email: "x@y.com"},
+		})
+		if got, want := auth.UserID, user0.ID; got != want {
+			t.Fatalf("UserID=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure that an email auth does not require an access token & is linked
+	// to an existing user with the same email.
+	t.Run("Email", func(t *testing.T) {
+		s := open(t)
+		user0, _ := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "X", Email: "x@y.com"})
+		auth, _ := MustCreateAuth(t, context.Background(), s, &wtf.Auth{
+			Source:   wtf.AuthSourceEmail,
+			SourceID: "x@y.com",
+			User:     &wtf.User{Name: "x", Email: "x@y.com"},
+		})
+		if got, want := auth.UserID, user0.ID; got != want {
+			t.Fatalf("UserID=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure that an account from another provider can be linked to the current user.
+	t.Run("Link", func(t *testing.T) {
+		s := open(t)
+		auth0, ctx0 := MustCreateAuth(t, context.Background(), s, &wtf.Auth{
+			Source:      wtf.AuthSourceGitHub,
+			SourceID:    "X",
+			AccessToken: "ACCESS",
+			User:        &wtf.User{Name: "X", Email: "x@y.com"},
+		})
+
+		auth1 := &wtf.Auth{UserID: auth0.UserID, Source: "corp", SourceID: "Y", AccessToken: "ACCESS"}
+		if err := s.AuthService.CreateAuth(ctx0, auth1); err != nil {
+			t.Fatal(err)
+		} else if got, want := auth1.UserID, auth0.UserID; got != want {
+			t.Fatalf("UserID=%v, want %v", got, want)
+		}
+
+		if user, err := s.UserService.FindUserByID(ctx0, auth0.UserID); err != nil {
+			t.Fatal(err)
+		} else if got, want := len(user.Auths), 2; got != want {
+			t.Fatalf("len(Auths)=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure that an account already linked to another user cannot be linked.
+	t.Run("ErrLinkedToOtherUser", func(t *testing.T) {
+		s := open(t)
+		MustCreateAuth(t, context.Background(), s, &wtf.Auth{
+			Source:      "corp",
+			SourceID:    "X",
+			AccessToken: "ACCESS",
+			User:        &wtf.User{Name: "X"},
+		})
+		user1, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "Y"})
+
+		if err := s.AuthService.CreateAuth(ctx1, &wtf.Auth{UserID: user1.ID, Source: "corp", SourceID: "X", AccessToken: "ACCESS"}); wtf.ErrorCode(err) != wtf.ECONFLICT {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure that a user can only link one account per provider.
+	t.Run("ErrSourceAlreadyLinked", func(t *testing.T) {
+		s := open(t)
+		auth0, ctx0 := MustCreateAuth(t, context.Background(), s, &wtf.Auth{
+			Source:      wtf.AuthSourceGitHub,
+			SourceID:    "X",
+			AccessToken: "ACCESS",
+			User:        &wtf.User{Name: "X"},
+		})
+
+		if err := s.AuthService.CreateAuth(ctx0, &wtf.Auth{UserID: auth0.UserID, Source: wtf.AuthSourceGitHub, SourceID: "Y", AccessToken: "ACCESS"}); wtf.ErrorCode(err) != wtf.ECONFLICT {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure that an account cannot be linked to a different user.
+	t.Run("ErrLinkUnauthorized", func(t *testing.T) {
+		s := open(t)
+		user0, _ := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "X"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "Y"})
+
+		if err := s.AuthService.CreateAuth(ctx1, &wtf.Auth{UserID: user0.ID, Source: "corp", SourceID: "X", AccessToken: "ACCESS"}); wtf.ErrorCode(err) != wtf.EUNAUTHORIZED {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure that a blank source field returns an error.
+	t.Run("ErrSourceRequired", func(t *testing.T) {
+		s := open(t)
+		if err := s.AuthService.CreateAuth(context.Background(), &wtf.Auth{
+			User: &wtf.User{Name: "NAME"},
+		}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Source required.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure that a blank source ID field returns an error.
+	t.Run("ErrSourceIDRequired", func(t *testing.T) {
+		s := open(t)
+		if err := s.AuthService.CreateAuth(context.Background(), &wtf.Auth{
+			Source: wtf.AuthSourceGitHub,
+			User:   &wtf.User{Name: "NAME"},
+		}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Source ID required.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure that a blank access token field returns an error.
+	t.Run("ErrAccessTokenRequired", func(t *testing.T) {
+		s := open(t)
+		if err := s.AuthService.CreateAuth(context.Background(), &wtf.Auth{
+			Source:   wtf.AuthSourceGitHub,
+			SourceID: "X",
+			User:     &wtf.User{Name: "NAME"},
+		}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Access token required.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure that a user object is required when creating an auth.
+	t.Run("ErrUserRequired", func(t *testing.T) {
+		s := open(t)
+		if err := s.AuthService.CreateAuth(context.Background(), &wtf.Auth{}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `User required.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+}
+
+func testAuthService_DeleteAuth(t *testing.T, open OpenFunc) {
+	// Ensure an auth object can be deleted by its owner.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		auth0, ctx0 := MustCreateAuth(t, context.Background(), s, &wtf.Auth{
+			Source:      wtf.AuthSourceGitHub,
+			SourceID:    "X",
+			AccessToken: "X", User: &wtf.User{Name: "X"},
+		})
+
+		// Delete auth & ensure it is actually gone.
+		if err := s.AuthService.DeleteAuth(ctx0, auth0.ID); err != nil {
+			t.Fatal(err)
+		} else if _, err := s.AuthService.FindAuthByID(ctx0, auth0.ID); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure an error is returned if deleting a non-existent auth object.
+	t.Run("ErrNotFound", func(t *testing.T) {
+		s := open(t)
+		if err := s.AuthService.DeleteAuth(context.Background(), 1); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure deleting a auth is restricted only to the owner.
+	t.Run("ErrUnauthorized", func(t *testing.T) {
+		s := open(t)
+		auth0, _ := MustCreateAuth(t, context.Background(), s, &wtf.Auth{
+			Source:      wtf.AuthSourceGitHub,
+			SourceID:    "X",
+			AccessToken: "X", User: &wtf.User{Name: "X"},
+		})
+		_, ctx1 := MustCreateAuth(t, context.Background(), s, &wtf.Auth{
+			Source:      wtf.AuthSourceGitHub,
+			SourceID:    "Y",
+			AccessToken: "Y", User: &wtf.User{Name: "Y"},
+		})
+
+		if err := s.AuthService.DeleteAuth(ctx1, auth0.ID); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EUNAUTHORIZED || wtf.ErrorMessage(err) != `You are not allowed to delete this auth.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+}
+
+func testAuthService_FindAuth(t *testing.T, open OpenFunc) {
+	// Ensure we receive an error if fetching a non-existent auth.
+	t.Run("ErrNotFound", func(t *testing.T) {
+		s := open(t)
+		if _, err := s.AuthService.FindAuthByID(context.Background(), 1); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+}
+
+func testAuthService_FindAuths(t *testing.T, open OpenFunc) {
+	// Ensure we can fetch all auths for a single user.
+	t.Run("User", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+
+		// Create two auths with the same user email which will group them.
+		// The third auth is for a separate user.
+		MustCreateAuth(t, ctx, s, &wtf.Auth{
+			Source:      "SRCA",
+			SourceID:    "X1",
+			AccessToken: "ACCESSX1",
+			User:        &wtf.User{Name: "X", Email: "x@y.com"},
+		})
+		MustCreateAuth(t, ctx, s, &wtf.Auth{
+			Source:      "SRCB",
+			SourceID:    "X2",
+			AccessToken: "ACCESSX2",
+			User:        &wtf.User{Name: "X", Email: "x@y.com"},
+		})
+		MustCreateAuth(t, ctx, s, &wtf.Auth{
+			Source:      wtf.AuthSourceGitHub,
+			SourceID:    "Y",
+			AccessToken: "ACCESSY",
+			User:        &wtf.User{Name: "Y"},
+		})
+
+		// Fetch auths and compare results.
+		userID := 1
+		if a, n, err := s.AuthService.FindAuths(ctx, wtf.AuthFilter{UserID: &userID}); err != nil {
+			t.Fatal(err)
+		} else if got, want := len(a), 2; got != want {
+			t.Fatalf("len=%v, want %v", got, want)
+		} else if got, want := a[0].SourceID, "X1"; got != want {
+			t.Fatalf("[]=%v, want %v", got, want)
+		} else if got, want := a[1].SourceID, "X2"; got != want {
+			t.Fatalf("[]=%v, want %v", got, want)
+		} else if got, want := n, 2; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		}
+
+		// Ensure the total count is returned when the results are paginated.
+		if a, n, err := s.AuthService.FindAuths(ctx, wtf.AuthFilter{UserID: &userID, Limit: 1}); err != nil {
+			t.Fatal(err)
+		} else if got, want := len(a), 1; got != want {
+			t.Fatalf("len=%v, want %v", got, want)
+		} else if got, want := n, 2; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,1254 @@
+package wtftest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+)
+
+func testDialService(t *testing.T, open OpenFunc) {
+	t.Run("CreateDial", func(t *testing.T) { testDialService_CreateDial(t, open) })
+	t.Run("UpdateDial", func(t *testing.T) { testDialService_UpdateDial(t, open) })
+	t.Run("FindDial", func(t *testing.T) { testDialService_FindDial(t, open) })
+	t.Run("FindDials", func(t *testing.T) { testDialService_FindDials(t, open) })
+	t.Run("DeleteDial", func(t *testing.T) { testDialService_DeleteDial(t, open) })
+	t.Run("TransferDial", func(t *testing.T) { testDialService_TransferDial(t, open) })
+	t.Run("RotateDialInvite", func(t *testing.T) { testDialService_RotateDialInvite(t, open) })
+	t.Run("SetDialMembershipValue", func(t *testing.T) { testDialService_SetDialMembershipValue(t, open) })
+	t.Run("AverageDialValueReport", func(t *testing.T) { testDialService_AverageDialValueReport(t, open) })
+	t.Run("DialValueReport", func(t *testing.T) { testDialService_DialValueReport(t, open) })
+	t.Run("DialHeatmapReport", func(t *testing.T) { testDialService_DialHeatmapReport(t, open) })
+}
+
+func testDialService_CreateDial(t *testing.T, open OpenFunc) {
+	// Ensure a dial can be created by a user & a membership for the user is automatically created.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane", Email: "jane@gmail.com"})
+
+		// Create new dial. Ensure the current user is the owner & an invite code is generated.
+		dial := &wtf.Dial{Name: "mydial"}
+		if err := s.DialService.CreateDial(ctx0, dial); err != nil {
+			t.Fatal(err)
+		} else if got, want := dial.ID, 1; got != want {
+			t.Fatalf("ID=%v, want %v", got, want)
+		} else if got, want := dial.UserID, 1; got != want {
+			t.Fatalf("UserID=%v, want %v", got, want)
+		} else if dial.InviteCode == "" {
+			t.Fatal("expected invite code generation")
+		} else if dial.CreatedAt.IsZero() {
+			t.Fatal("expected created at")
+		} else if dial.UpdatedAt.IsZero() {
+			t.Fatal("expected updated at")
+		} else if dial.User == nil {
+			t.Fatal("expected user")
+		} else if got, want := dial.Aggregation, wtf.DialAggregationMean; got != want {
+			t.Fatalf("Aggregation=%v, want %v", got, want)
+		} else if got, want := dial.Role, wtf.DialMembershipRoleOwner; got != want {
+			t.Fatalf("Role=%v, want %v", got, want)
+		}
+
+		// Fetch dial & compare. Memberships may optionally be attached so they are ignored.
+		if other, err := s.DialService.FindDialByID(ctx0, 1); err != nil {
+			t.Fatal(err)
+		} else if other.Memberships = nil; !reflect.DeepEqual(dial, other) {
+			t.Fatalf("mismatch: %#v != %#v", dial, other)
+		}
+
+		// Ensure membership for owner automatically created.
+		if _, n, err := s.DialMembershipService.FindDialMemberships(ctx0, wtf.DialMembershipFilter{DialID: &dial.ID}); err != nil {
+			t.Fatal(err)
+		} else if n != 1 {
+			t.Fatal("expected owner membership auto-creation")
+		}
+	})
+
+	// Ensure that creating a nameless dial returns an error.
+	t.Run("ErrNameRequired", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane", Email: "jane@gmail.com"})
+
+		if err := s.DialService.CreateDial(ctx0, &wtf.Dial{}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != "Dial name required." {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure that creating a dial with a long name returns an error.
+	t.Run("ErrNameTooLong", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane", Email: "jane@gmail.com"})
+
+		if err := s.DialService.CreateDial(ctx0, &wtf.Dial{Name: strings.Repeat("X", wtf.MaxDialNameLen+1)}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != "Dial name too long." {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure an error is returned if the aggregation mode is unknown.
+	t.Run("ErrInvalidAggregation", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+
+		if err := s.DialService.CreateDial(ctx0, &wtf.Dial{Name: "mydial", Aggregation: "mode"}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != "Invalid dial aggregation." {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure user is logged in when creating a dial.
+	t.Run("ErrUserRequired", func(t *testing.T) {
+		s := open(t)
+		if err := s.DialService.CreateDial(context.Background(), &wtf.Dial{Name: "mydial"}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EUNAUTHORIZED {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure a dial can be created within an organization the user belongs to.
+	t.Run("Organization", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		org := MustCreateOrganization(t, ctx0, s, &wtf.Organization{Name: "Acme"})
+
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL", OrganizationID: org.ID})
+		if other := MustFindDialByID(t, ctx0, s, dial.ID); other.OrganizationID != org.ID {
+			t.Fatalf("OrganizationID=%v, want %v", other.OrganizationID, org.ID)
+		} else if other.Organization == nil || other.Organization.Name != "Acme" {
+			t.Fatalf("unexpected organization: %#v", other.Organization)
+		}
+	})
+
+	// Ensure a dial cannot be created in an organization the user is not in.
+	t.Run("ErrOrganizationUnauthorized", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+		org := MustCreateOrganization(t, ctx0, s, &wtf.Organization{Name: "Acme"})
+
+		if err := s.DialService.CreateDial(ctx1, &wtf.Dial{Name: "DIAL", OrganizationID: org.ID}); wtf.ErrorCode(err) != wtf.EUNAUTHORIZED {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure tags are normalized when a dial is created.
+	t.Run("Tags", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "mydial", Tags: []string{" Oncall", "backend", "", "ONCALL"}})
+		if got, want := dial.Tags, []string{"backend", "oncall"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("Tags=%v, want %v", got, want)
+		} else if other := MustFindDialByID(t, ctx0, s, dial.ID); !reflect.DeepEqual(other.Tags, dial.Tags) {
+			t.Fatalf("Tags=%v, want %v", other.Tags, dial.Tags)
+		}
+	})
+
+	// Ensure an error is returned if a tag contains invalid characters.
+	t.Run("ErrInvalidTag", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+
+		if err := s.DialService.CreateDial(ctx0, &wtf.Dial{Name: "mydial", Tags: []string{"on call"}}); wtf.ErrorCode(err) != wtf.EINVALID {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure an error is returned if a dial has too many tags.
+	t.Run("ErrTooManyTags", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+
+		var tags []string
+		for i := 0; i <= wtf.MaxDialTags; i++ {
+			tags = append(tags, fmt.Sprintf("tag%d", i))
+		}
+		if err := s.DialService.CreateDial(ctx0, &wtf.Dial{Name: "mydial", Tags: tags}); wtf.ErrorCode(err) != wtf.EINVALID {
+			t.Fatal(err)
+		}
+	})
+}
+
+func testDialService_UpdateDial(t *testing.T, open OpenFunc) {
+	// Ensure a dial name can be updated.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane", Email: "jane@gmail.com"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "NAME"})
+
+		// Update dial.
+		newName := "mydial2"
+		uu, err := s.DialService.UpdateDial(ctx0, dial.ID, wtf.DialUpdate{Name: &newName})
+		skipIfNotImplemented(t, err)
+		if err != nil {
+			t.Fatal(err)
+		} else if got, want := uu.Name, "mydial2"; got != want {
+			t.Fatalf("Name=%v, want %v", got, want)
+		}
+
+		// Fetch dial & compare.
+		if other, err := s.DialService.FindDialByID(ctx0, 1); err != nil {
+			t.Fatal(err)
+		} else if other.Memberships = nil; !reflect.DeepEqual(uu, other) {
+			t.Fatalf("mismatch: %#v != %#v", uu, other)
+		}
+	})
+
+	// Ensure changing the aggregation mode recomputes the dial value.
+	t.Run("Aggregation", func(t *testing.T) {
+		for _, tt := range []struct {
+			name string
+			upd  wtf.DialUpdate
+			want int
+		}{
+			{name: "Mean", upd: wtf.DialUpdate{Aggregation: stringPtr(wtf.DialAggregationMean)}, want: 34},
+			{name: "Median", upd: wtf.DialUpdate{Aggregation: stringPtr(wtf.DialAggregationMedian)}, want: 20},
+			{name: "Max", upd: wtf.DialUpdate{Aggregation: stringPtr(wtf.DialAggregationMax)}, want: 100},
+			{name: "Percentile", upd: wtf.DialUpdate{Aggregation: stringPtr(wtf.DialAggregationPercentile), Percentile: intPtr(80)}, want: 40},
+			{name: "TrimmedMean", upd: wtf.DialUpdate{Aggregation: stringPtr(wtf.DialAggregationTrimmedMean), Trim: intPtr(20)}, want: 23},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				s := open(t)
+				ctx := context.Background()
+				_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+				dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "NAME"})
+
+				// Add members with values 10, 20, 40 & 100. Owner remains at 0.
+				for i, value := range []int{10, 20, 40, 100} {
+					_, ctx := MustCreateUser(t, ctx, s, &wtf.User{Name: fmt.Sprintf("USER%d", i)})
+					MustCreateDialMembership(t, ctx, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial, Value: value})
+				}
+
+				uu, err := s.DialService.UpdateDial(ctx0, dial.ID, tt.upd)
+				skipIfNotImplemented(t, err)
+				if err != nil {
+					t.Fatal(err)
+				} else if got := uu.Value; got != tt.want {
+					t.Fatalf("Value=%v, want %v", got, tt.want)
+				} else if other := MustFindDialByID(t, ctx0, s, dial.ID); other.Value != tt.want {
+					t.Fatalf("Value=%v, want %v", other.Value, tt.want)
+				}
+			})
+		}
+	})
+
+	// Ensure members are notified when an aggregation change affects the value.
+	t.Run("AggregationEvent", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "NAME"})
+		MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial, Value: 80})
+
+		sub := MustSubscribe(t, ctx1, s)
+		_, err := s.DialService.UpdateDial(ctx0, dial.ID, wtf.DialUpdate{Aggregation: stringPtr(wtf.DialAggregationMax)})
+		skipIfNotImplemented(t, err)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := wtf.Event{Type: wtf.EventTypeDialValueChanged, Payload: &wtf.DialValueChangedPayload{ID: dial.ID, Value: 80}}
+		select {
+		case got := <-sub.C():
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("event=%#v, want %#v", got, want)
+			}
+		default:
+			t.Fatal("expected event")
+		}
+	})
+
+	// Ensure aggregation settings are validated.
+	t.Run("ErrInvalidPercentile", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "NAME"})
+
+		_, err := s.DialService.UpdateDial(ctx0, dial.ID, wtf.DialUpdate{Percentile: intPtr(101)})
+		skipIfNotImplemented(t, err)
+		if err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != "Dial percentile must be between 0 & 100." {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure only the dial owner can update a dial.
+	t.Run("ErrUnauthorized", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		user1, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "NAME"})
+		MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial, UserID: user1.ID})
+
+		newName := "mydial2"
+		_, err := s.DialService.UpdateDial(ctx1, dial.ID, wtf.DialUpdate{Name: &newName})
+		skipIfNotImplemented(t, err)
+		if err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EUNAUTHORIZED || wtf.ErrorMessage(err) != "You must be the owner or an admin to edit a dial." {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure an error is returned if the dial does not exist.
+	t.Run("ErrNotFound", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+
+		newName := "mydial2"
+		_, err := s.DialService.UpdateDial(ctx0, 1, wtf.DialUpdate{Name: &newName})
+		skipIfNotImplemented(t, err)
+		if wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure a dial's tags can be replaced & cleared.
+	t.Run("Tags", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "mydial", Tags: []string{"backend"}})
+
+		tags := []string{"Release", "oncall"}
+		if other, err := s.DialService.UpdateDial(ctx0, dial.ID, wtf.DialUpdate{Tags: &tags}); err != nil {
+			t.Fatal(err)
+		} else if got, want := other.Tags, []string{"oncall", "release"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("Tags=%v, want %v", got, want)
+		}
+
+		// Ensure tags are unchanged if the update does not include them.
+		name := "mydial2"
+		if _, err := s.DialService.UpdateDial(ctx0, dial.ID, wtf.DialUpdate{Name: &name}); err != nil {
+			t.Fatal(err)
+		} else if got, want := MustFindDialByID(t, ctx0, s, dial.ID).Tags, []string{"oncall", "release"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("Tags=%v, want %v", got, want)
+		}
+
+		tags = []string{}
+		if _, err := s.DialService.UpdateDial(ctx0, dial.ID, wtf.DialUpdate{Tags: &tags}); err != nil {
+			t.Fatal(err)
+		} else if got := MustFindDialByID(t, ctx0, s, dial.ID).Tags; len(got) != 0 {
+			t.Fatalf("Tags=%v, want none", got)
+		}
+	})
+}
+
+func testDialService_FindDial(t *testing.T, open OpenFunc) {
+	// Ensure an error is returned if the dial does not exist.
+	t.Run("ErrNotFound", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		if _, err := s.DialService.FindDialByID(ctx0, 1); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure a dial cannot be seen by a user who is not a member.
+	t.Run("ErrNotMember", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "NAME"})
+
+		if _, err := s.DialService.FindDialByID(ctx1, dial.ID); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+}
+
+func testDialService_FindDials(t *testing.T, open OpenFunc) {
+	// Ensure all dials that are owned by user can be fetched.
+	t.Run("Owned", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john", Email: "john@gmail.com"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane", Email: "jane@gmail.com"})
+
+		MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "dial0"})
+		MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "dial1"})
+		MustCreateDial(t, ctx1, s, &wtf.Dial{Name: "dial2"})
+
+		if a, n, err := s.DialService.FindDials(ctx0, wtf.DialFilter{}); err != nil {
+			t.Fatal(err)
+		} else if got, want := len(a), 2; got != want {
+			t.Fatalf("len=%v, want %v", got, want)
+		} else if got, want := a[0].Name, "dial0"; got != want {
+			t.Fatalf("[0]=%v, want %v", got, want)
+		} else if got, want := a[1].Name, "dial1"; got != want {
+			t.Fatalf("[1]=%v, want %v", got, want)
+		} else if got, want := n, 2; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure all dials that user is a member of can be fetched.
+	t.Run("MemberOf", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john", Email: "john@gmail.com"})
+		user1, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane", Email: "jane@gmail.com"})
+
+		dial0 := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "dial0"})
+		MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "dial1"})
+		MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial0.ID, Dial: dial0, UserID: user1.ID})
+
+		if a, n, err := s.DialService.FindDials(ctx1, wtf.DialFilter{}); err != nil {
+			t.Fatal(err)
+		} else if got, want := len(a), 1; got != want {
+			t.Fatalf("len=%v, want %v", got, want)
+		} else if got, want := a[0].Name, "dial0"; got != want {
+			t.Fatalf("[0]=%v, want %v", got, want)
+		} else if got, want := n, 1; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure dial can be found by invite code even if user is not a member.
+	t.Run("InviteCode", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john", Email: "john@gmail.com"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane", Email: "jane@gmail.com"})
+
+		dial0 := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "dial0"})
+		MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "dial1"})
+
+		if a, n, err := s.DialService.FindDials(ctx1, wtf.DialFilter{InviteCode: &dial0.InviteCode}); err != nil {
+			t.Fatal(err)
+		} else if got, want := len(a), 1; got != want {
+			t.Fatalf("len=%v, want %v", got, want)
+		} else if got, want := a[0].Name, "dial0"; got != want {
+			t.Fatalf("[0]=%v, want %v", got, want)
+		} else if got, want := n, 1; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure the total count is returned when the results are paginated.
+	t.Run("LimitOffset", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+
+		MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "dial0"})
+		MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "dial1"})
+		MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "dial2"})
+
+		if a, n, err := s.DialService.FindDials(ctx0, wtf.DialFilter{Offset: 1, Limit: 1}); err != nil {
+			t.Fatal(err)
+		} else if got, want := len(a), 1; got != want {
+			t.Fatalf("len=%v, want %v", got, want)
+		} else if got, want := a[0].Name, "dial1"; got != want {
+			t.Fatalf("[0]=%v, want %v", got, want)
+		} else if got, want := n, 3; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure organization members can see & join the organization's dials
+	// without an invite link.
+	t.Run("Organization", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		user1, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+		_, ctx2 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jim"})
+		org := MustCreateOrganization(t, ctx0, s, &wtf.Organization{Name: "Acme"})
+		MustCreateOrganizationMember(t, ctx1, s, &wtf.OrganizationMember{OrganizationID: org.ID, InviteCode: org.InviteCode})
+
+		dial0 := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "dial0", OrganizationID: org.ID})
+		MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "dial1"})
+		MustCreateDial(t, ctx1, s, &wtf.Dial{Name: "dial2", OrganizationID: org.ID})
+
+		// Ensure filtering by organization only returns the organization's dials.
+		if a, n, err := s.DialService.FindDials(ctx1, wtf.DialFilter{OrganizationID: &org.ID}); err != nil {
+			t.Fatal(err)
+		} else if got, want := n, 2; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		} else if got, want := a[0].Name, "dial0"; got != want {
+			t.Fatalf("[0]=%v, want %v", got, want)
+		} else if got, want := a[0].Role, ""; got != want {
+			t.Fatalf("Role=%v, want %v", got, want)
+		} else if got, want := a[1].Name, "dial2"; got != want {
+			t.Fatalf("[1]=%v, want %v", got, want)
+		}
+
+		// Ensure a member can join an organization dial without an invite code.
+		if dial := MustFindDialByID(t, ctx1, s, dial0.ID); dial.OrganizationID != org.ID {
+			t.Fatalf("OrganizationID=%v, want %v", dial.OrganizationID, org.ID)
+		}
+		membership := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial0.ID})
+		if got, want := membership.UserID, user1.ID; got != want {
+			t.Fatalf("UserID=%v, want %v", got, want)
+		}
+
+		// Ensure users outside the organization cannot see its dials.
+		if _, n, err := s.DialService.FindDials(ctx2, wtf.DialFilter{OrganizationID: &org.ID}); err != nil {
+			t.Fatal(err)
+		} else if n != 0 {
+			t.Fatalf("n=%v, want 0", n)
+		} else if _, err := s.DialService.FindDialByID(ctx2, dial0.ID); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure dials can be filtered by tag, owner, name & value range.
+	t.Run("Filters", func(t *testing.T) {
+		s := open(t)
+		user0, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+
+		dial0 := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "API Latency", Tags: []string{"backend", "oncall"}})
+		dial1 := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "Release Train", Tags: []string{"release"}})
+		dial2 := MustCreateDial(t, ctx1, s, &wtf.Dial{Name: "Database", Tags: []string{"backend"}})
+		MustCreateDialMembership(t, ctx0, s, &wtf.DialMembership{DialID: dial2.ID, InviteCode: dial2.InviteCode})
+
+		for _, tt := range []struct {
+			dialID int
+			value  int
+		}{{dial0.ID, 30}, {dial1.ID, 70}} {
+			if err := s.DialService.SetDialMembershipValue(ctx0, tt.dialID, tt.value, ""); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		tag, name, minValue, maxValue := "Backend", "latency", 20, 60
+		for _, tt := range []struct {
+			name   string
+			filter wtf.DialFilter
+			want   []string
+		}{
+			{"Tag", wtf.DialFilter{Tag: &tag}, []string{"API Latency", "Database"}},
+			{"UserID", wtf.DialFilter{UserID: &user0.ID}, []string{"API Latency", "Release Train"}},
+			{"Name", wtf.DialFilter{Name: &name}, []string{"API Latency"}},
+			{"MinValue", wtf.DialFilter{MinValue: &minValue}, []string{"API Latency", "Release Train"}},
+			{"MaxValue", wtf.DialFilter{MaxValue: &maxValue}, []string{"API Latency", "Database"}},
+			{"ValueRange", wtf.DialFilter{MinValue: &minValue, MaxValue: &maxValue}, []string{"API Latency"}},
+			{"Combined", wtf.DialFilter{Tag: &tag, UserID: &user0.ID}, []string{"API Latency"}},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				a, n, err := s.DialService.FindDials(ctx0, tt.filter)
+				if err != nil {
+					t.Fatal(err)
+				} else if got, want := n, len(tt.want); got != want {
+					t.Fatalf("n=%v, want %v", got, want)
+				}
+
+				var names []string
+				for _, dial := range a {
+					names = append(names, dial.Name)
+				}
+				if !reflect.DeepEqual(names, tt.want) {
+					t.Fatalf("names=%v, want %v", names, tt.want)
+				}
+			})
+		}
+	})
+
+	// Ensure dials can be sorted by name & value.
+	t.Run("SortBy", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+
+		for _, tt := range []struct {
+			name  string
+			value int
+		}{{"beta", 50}, {"Alpha", 10}, {"gamma", 90}} {
+			dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: tt.name})
+			if err := s.DialService.SetDialMembershipValue(ctx0, dial.ID, tt.value, ""); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for _, tt := range []struct {
+			sortBy string
+			want   []string
+		}{
+			{"", []string{"beta", "Alpha", "gamma"}},
+			{wtf.DialSortByNameAsc, []string{"Alpha", "beta", "gamma"}},
+			{wtf.DialSortByValueAsc, []string{"Alpha", "beta", "gamma"}},
+			{wtf.DialSortByValueDesc, []string{"gamma", "beta", "Alpha"}},
+		} {
+			a, _, err := s.DialService.FindDials(ctx0, wtf.DialFilter{SortBy: tt.sortBy})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var names []string
+			for _, dial := range a {
+				names = append(names, dial.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Fatalf("SortBy=%q: names=%v, want %v", tt.sortBy, names, tt.want)
+			}
+		}
+	})
+}
+
+func testDialService_DeleteDial(t *testing.T, open OpenFunc) {
+	// Ensure a dial can be deleted by the owner.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane", Email: "jane@gmail.com"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "NAME"})
+
+		if err := s.DialService.DeleteDial(ctx0, dial.ID); err != nil {
+			t.Fatal(err)
+		} else if _, err := s.DialService.FindDialByID(ctx0, dial.ID); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure only the dial owner can delete a dial.
+	t.Run("ErrUnauthorized", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		user1, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "NAME"})
+		MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial, UserID: user1.ID})
+
+		if err := s.DialService.DeleteDial(ctx1, dial.ID); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EUNAUTHORIZED || wtf.ErrorMessage(err) != "Only the owner can delete a dial." {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure an admin can edit but not delete a dial.
+	t.Run("ErrAdmin", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "NAME"})
+		membership := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial})
+		MustSetDialMembershipRole(t, ctx0, s, membership.ID, wtf.DialMembershipRoleAdmin)
+
+		if err := s.DialService.DeleteDial(ctx1, dial.ID); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EUNAUTHORIZED || wtf.ErrorMessage(err) != "Only the owner can delete a dial." {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure an error is returned if the dial does not exist.
+	t.Run("ErrNotFound", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		if err := s.DialService.DeleteDial(ctx0, 1); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+}
+
+func testDialService_TransferDial(t *testing.T, open OpenFunc) {
+	// Ensure a dial can be transferred to a member & the dial survives the
+	// deletion of the previous owner.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		user0, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		user1, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "NAME"})
+		membership := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial})
+
+		if other, err := s.DialService.TransferDial(ctx0, dial.ID, user1.ID); err != nil {
+			t.Fatal(err)
+		} else if got, want := other.UserID, user1.ID; got != want {
+			t.Fatalf("UserID=%v, want %v", got, want)
+		} else if got, want := other.Role, wtf.DialMembershipRoleAdmin; got != want {
+			t.Fatalf("Role=%v, want %v", got, want)
+		}
+
+		// Ensure the membership roles have been swapped.
+		if other := MustFindDialMembershipByID(t, ctx1, s, membership.ID); other.Role != wtf.DialMembershipRoleOwner {
+			t.Fatalf("Role=%v, want %v", other.Role, wtf.DialMembershipRoleOwner)
+		} else if other := MustFindDialMembershipByID(t, ctx0, s, 1); other.Role != wtf.DialMembershipRoleAdmin {
+			t.Fatalf("Role=%v, want %v", other.Role, wtf.DialMembershipRoleAdmin)
+		}
+
+		// Previous owner can no longer delete the dial.
+		if err := s.DialService.DeleteDial(ctx0, dial.ID); wtf.ErrorCode(err) != wtf.EUNAUTHORIZED {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		// Deleting the previous owner should leave the dial in place.
+		if err := s.UserService.DeleteUser(ctx0, user0.ID); err != nil {
+			t.Fatal(err)
+		} else if other := MustFindDialByID(t, ctx1, s, dial.ID); other.UserID != user1.ID {
+			t.Fatalf("UserID=%v, want %v", other.UserID, user1.ID)
+		}
+	})
+
+	// Ensure a dial can only be transferred to an existing member.
+	t.Run("ErrNotMember", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		user1, _ := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "NAME"})
+
+		if _, err := s.DialService.TransferDial(ctx0, dial.ID, user1.ID); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != "New owner must be a member of the dial." {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure only the dial owner can transfer a dial, even if they are an admin.
+	t.Run("ErrUnauthorized", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		user1, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "NAME"})
+		membership := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial})
+		MustSetDialMembershipRole(t, ctx0, s, membership.ID, wtf.DialMembershipRoleAdmin)
+
+		if _, err := s.DialService.TransferDial(ctx1, dial.ID, user1.ID); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EUNAUTHORIZED || wtf.ErrorMessage(err) != "Only the owner can transfer a dial." {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure an error is returned if the dial does not exist.
+	t.Run("ErrNotFound", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		if _, err := s.DialService.TransferDial(ctx0, 1, 1); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+}
+
+func testDialService_RotateDialInvite(t *testing.T, open OpenFunc) {
+	// Ensure the invite code can be replaced with limits & the previous code
+	// no longer finds the dial.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		now := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		setNow(t, s, now)
+
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "NAME"})
+		MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial, InviteCode: dial.InviteCode})
+
+		expiresAt := now.Add(time.Hour)
+		other, err := s.DialService.RotateDialInvite(ctx0, dial.ID, wtf.DialInviteOptions{ExpiresAt: &expiresAt, MaxUses: 2})
+		if err != nil {
+			t.Fatal(err)
+		} else if other.InviteCode == "" || other.InviteCode == dial.InviteCode {
+			t.Fatalf("unexpected invite code: %q", other.InviteCode)
+		} else if other.InviteExpiresAt == nil || !other.InviteExpiresAt.Equal(expiresAt) {
+			t.Fatalf("InviteExpiresAt=%v, want %v", other.InviteExpiresAt, expiresAt)
+		} else if got, want := other.InviteMaxUses, 2; got != want {
+			t.Fatalf("InviteMaxUses=%v, want %v", got, want)
+		} else if got, want := other.InviteUseCount, 0; got != want {
+			t.Fatalf("InviteUseCount=%v, want %v", got, want)
+		}
+
+		// Ensure the new state is persisted.
+		if other := MustFindDialByID(t, ctx0, s, dial.ID); other.InviteMaxUses != 2 || other.InviteUseCount != 0 {
+			t.Fatalf("unexpected invite limits: %d/%d", other.InviteUseCount, other.InviteMaxUses)
+		}
+
+		// Ensure the previous code no longer finds the dial.
+		_, ctx2 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jill"})
+		if a, _, err := s.DialService.FindDials(ctx2, wtf.DialFilter{InviteCode: &dial.InviteCode}); err != nil {
+			t.Fatal(err)
+		} else if len(a) != 0 {
+			t.Fatalf("len=%v, want 0", len(a))
+		}
+	})
+
+	// Ensure an admin can rotate the invite code.
+	t.Run("Admin", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "NAME"})
+		membership := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial})
+		MustSetDialMembershipRole(t, ctx0, s, membership.ID, wtf.DialMembershipRoleAdmin)
+
+		if other, err := s.DialService.RotateDialInvite(ctx1, dial.ID, wtf.DialInviteOptions{}); err != nil {
+			t.Fatal(err)
+		} else if other.InviteCode == dial.InviteCode {
+			t.Fatal("expected new invite code")
+		}
+	})
+
+	// Ensure the expiration must be in the future.
+	t.Run("ErrInvalidExpiry", func(t *testing.T) {
+		s := open(t)
+		now := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		setNow(t, s, now)
+
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "NAME"})
+
+		if _, err := s.DialService.RotateDialInvite(ctx0, dial.ID, wtf.DialInviteOptions{ExpiresAt: &now}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != "Invite expiration must be in the future." {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure max uses cannot be negative.
+	t.Run("ErrInvalidMaxUses", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "NAME"})
+
+		if _, err := s.DialService.RotateDialInvite(ctx0, dial.ID, wtf.DialInviteOptions{MaxUses: -1}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != "Invite max uses cannot be negative." {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure a regular member cannot rotate the invite code.
+	t.Run("ErrUnauthorized", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "NAME"})
+		MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial})
+
+		if _, err := s.DialService.RotateDialInvite(ctx1, dial.ID, wtf.DialInviteOptions{}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EUNAUTHORIZED || wtf.ErrorMessage(err) != "You must be the owner or an admin to rotate the invite code." {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure an error is returned if the dial does not exist.
+	t.Run("ErrNotFound", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		if _, err := s.DialService.RotateDialInvite(ctx0, 1, wtf.DialInviteOptions{}); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+}
+
+func testDialService_SetDialMembershipValue(t *testing.T, open OpenFunc) {
+	// Ensure a user can set their value on a dial by dial ID.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "NAME"})
+
+		if err := s.DialService.SetDialMembershipValue(ctx0, dial.ID, 80, "prod DB migration failing"); err != nil {
+			t.Fatal(err)
+		} else if got, want := MustFindDialByID(t, ctx0, s, dial.ID).Value, 80; got != want {
+			t.Fatalf("Value=%v, want %v", got, want)
+		} else if got, want := MustFindDialMembershipByID(t, ctx0, s, 1).Note, "prod DB migration failing"; got != want {
+			t.Fatalf("Note=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure an error is returned if the user is not a member of the dial.
+	t.Run("ErrNotMember", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "NAME"})
+
+		if err := s.DialService.SetDialMembershipValue(ctx1, dial.ID, 80, ""); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+}
+
+func testDialService_AverageDialValueReport(t *testing.T, open OpenFunc) {
+	// Ensure we can compute the average dial value across time for one dial.
+	t.Run("SingleDial", func(t *testing.T) {
+		s := open(t)
+		setNow(t, s, time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC))
+
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "joe"})
+
+		dial0 := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL0"})
+		membership0 := MustFindDialMembershipByID(t, ctx0, s, 1)
+		MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial0.ID, Dial: dial0})
+
+		// Update value after one hour (avg 25).
+		setNow(t, s, time.Date(2000, time.January, 1, 1, 0, 0, 0, time.UTC))
+		MustSetDialMembershipValue(t, ctx0, s, membership0.ID, 50)
+
+		// Update value after 3 hours (avg 50).
+		setNow(t, s, time.Date(2000, time.January, 1, 3, 0, 0, 0, time.UTC))
+		MustSetDialMembershipValue(t, ctx0, s, membership0.ID, 100)
+
+		// Generate hourly report.
+		start := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		end := time.Date(2000, time.January, 1, 5, 0, 0, 0, time.UTC)
+		report, err := s.DialService.AverageDialValueReport(ctx0, start, end, time.Hour)
+		skipIfNotImplemented(t, err)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := report.Records, []*wtf.DialValueRecord{
+			{Timestamp: time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC), Value: 0},
+			{Timestamp: time.Date(2000, time.January, 1, 1, 0, 0, 0, time.UTC), Value: 25},
+			{Timestamp: time.Date(2000, time.January, 1, 2, 0, 0, 0, time.UTC), Value: 25},
+			{Timestamp: time.Date(2000, time.January, 1, 3, 0, 0, 0, time.UTC), Value: 50},
+			{Timestamp: time.Date(2000, time.January, 1, 4, 0, 0, 0, time.UTC), Value: 50},
+		}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("Records=%#v, want %#v", got, want)
+		}
+	})
+
+	// Ensure an empty report is returned if the user has no dials.
+	t.Run("NoDials", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+
+		start := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		end := time.Date(2000, time.January, 1, 2, 0, 0, 0, time.UTC)
+		report, err := s.DialService.AverageDialValueReport(ctx0, start, end, time.Hour)
+		skipIfNotImplemented(t, err)
+		if err != nil {
+			t.Fatal(err)
+		} else if got, want := len(report.Records), 2; got != want {
+			t.Fatalf("len=%v, want %v", got, want)
+		} else if got, want := report.Records[1].Value, 0; got != want {
+			t.Fatalf("Value=%v, want %v", got, want)
+		}
+	})
+}
+
+func testDialService_DialValueReport(t *testing.T, open OpenFunc) {
+	// Ensure we can generate a separate series of values for each dial.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		setNow(t, s, time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC))
+
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		dial0 := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL0"})
+		dial1 := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL1"})
+
+		// Update first dial after one day & second dial after two days.
+		setNow(t, s, time.Date(2000, time.January, 2, 0, 0, 0, 0, time.UTC))
+		MustSetDialMembershipValue(t, ctx0, s, dial0.ID, 40)
+		setNow(t, s, time.Date(2000, time.January, 3, 0, 0, 0, 0, time.UTC))
+		MustSetDialMembershipValue(t, ctx0, s, dial1.ID, 80)
+
+		// Generate daily report for both dials.
+		start := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		end := time.Date(2000, time.January, 4, 0, 0, 0, 0, time.UTC)
+		report, err := s.DialService.DialValueReport(ctx0, []int{dial0.ID, dial1.ID}, start, end, 24*time.Hour, wtf.DialValueReportOptions{})
+		skipIfNotImplemented(t, err)
+		if err != nil {
+			t.Fatal(err)
+		} else if got, want := len(report.Series), 2; got != want {
+			t.Fatalf("len(Series)=%v, want %v", got, want)
+		}
+
+		if series := report.Series[0]; series.DialID != dial0.ID || series.Name != "DIAL0" {
+			t.Fatalf("unexpected series: %#v", series)
+		} else if got, want := series.Records, []*wtf.DialValueRecord{
+			{Timestamp: time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC), Value: 0},
+			{Timestamp: time.Date(2000, time.January, 2, 0, 0, 0, 0, time.UTC), Value: 40},
+			{Timestamp: time.Date(2000, time.January, 3, 0, 0, 0, 0, time.UTC), Value: 40},
+		}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("Records=%#v, want %#v", got, want)
+		}
+
+		if series := report.Series[1]; series.DialID != dial1.ID || series.Name != "DIAL1" {
+			t.Fatalf("unexpected series: %#v", series)
+		} else if got, want := series.Records, []*wtf.DialValueRecord{
+			{Timestamp: time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC), Value: 0},
+			{Timestamp: time.Date(2000, time.January, 2, 0, 0, 0, 0, time.UTC), Value: 0},
+			{Timestamp: time.Date(2000, time.January, 3, 0, 0, 0, 0, time.UTC), Value: 80},
+		}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("Records=%#v, want %#v", got, want)
+		}
+	})
+
+	// Ensure per-interval statistics & a range summary can be generated.
+	t.Run("Stats", func(t *testing.T) {
+		s := open(t)
+		setNow(t, s, time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC))
+
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		dial0 := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL0"})
+		membership0 := MustFindDialMembershipByID(t, ctx0, s, 1)
+
+		// Change the value twice within the first hour.
+		setNow(t, s, time.Date(2000, time.January, 1, 0, 10, 0, 0, time.UTC))
+		MustSetDialMembershipValue(t, ctx0, s, membership0.ID, 40)
+		setNow(t, s, time.Date(2000, time.January, 1, 0, 20, 0, 0, time.UTC))
+		MustSetDialMembershipValue(t, ctx0, s, membership0.ID, 60)
+
+		// Generate hourly report with statistics.
+		start := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		end := time.Date(2000, time.January, 1, 2, 0, 0, 0, time.UTC)
+		report, err := s.DialService.DialValueReport(ctx0, []int{dial0.ID}, start, end, time.Hour, wtf.DialValueReportOptions{
+			Stats:     true,
+			Threshold: intPtr(50),
+		})
+		skipIfNotImplemented(t, err)
+		if err != nil {
+			t.Fatal(err)
+		} else if got, want := len(report.Series), 1; got != want {
+			t.Fatalf("len(Series)=%v, want %v", got, want)
+		}
+		series := report.Series[0]
+
+		// The first interval includes the initial value & both changes.
+		if stats := series.Records[0].Stats; stats == nil {
+			t.Fatal("expected stats")
+		} else if stats.Min != 0 || stats.Max != 60 || stats.Median != 40 || stats.Count != 3 {
+			t.Fatalf("unexpected stats: %#v", stats)
+		} else if got, want := math.Round(stats.StdDev*100)/100, 24.94; got != want {
+			t.Fatalf("StdDev=%v, want %v", got, want)
+		}
+
+		// The second interval only includes the carried over value.
+		if got, want := series.Records[1].Stats, (&wtf.DialValueStats{Min: 60, Max: 60, Median: 60, Count: 1}); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stats=%#v, want %#v", got, want)
+		}
+
+		// Both intervals end above the threshold.
+		if got, want := series.Summary, (&wtf.DialValueSummary{
+			Mean:               60,
+			PeakValue:          60,
+			PeakTime:           start,
+			Threshold:          intPtr(50),
+			TimeAboveThreshold: 2 * time.Hour,
+		}); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Summary=%#v, want %#v", got, want)
+		}
+	})
+
+	// Ensure daily intervals are aligned to midnight in the user's timezone
+	// and that the timezone can be overridden by the context.
+	t.Run("Timezone", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane", Timezone: "America/Los_Angeles"})
+		dial0 := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL0"})
+
+		start := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		end := time.Date(2000, time.January, 3, 0, 0, 0, 0, time.UTC)
+		report, err := s.DialService.DialValueReport(ctx0, []int{dial0.ID}, start, end, 24*time.Hour, wtf.DialValueReportOptions{})
+		skipIfNotImplemented(t, err)
+		if err != nil {
+			t.Fatal(err)
+		} else if got, want := len(report.Series[0].Records), 2; got != want {
+			t.Fatalf("len(Records)=%v, want %v", got, want)
+		} else if got, want := report.Series[0].Records[0].Timestamp, time.Date(1999, time.December, 31, 8, 0, 0, 0, time.UTC); !got.Equal(want) {
+			t.Fatalf("Timestamp=%v, want %v", got, want)
+		}
+
+		// Override the user's timezone with UTC.
+		ctx := wtf.NewContextWithLocation(ctx0, time.UTC)
+		if report, err := s.DialService.DialValueReport(ctx, []int{dial0.ID}, start, end, 24*time.Hour, wtf.DialValueReportOptions{}); err != nil {
+			t.Fatal(err)
+		} else if got, want := report.Series[0].Records[0].Timestamp, start; !got.Equal(want) {
+			t.Fatalf("Timestamp=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure daily intervals stay aligned to local midnight when the range
+	// crosses a daylight saving time change.
+	t.Run("DaylightSavingTime", func(t *testing.T) {
+		s := open(t)
+		setNow(t, s, time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC))
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane", Timezone: "America/Los_Angeles"})
+		dial0 := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL0"})
+
+		// Update the dial on the last day, after the clocks have changed.
+		setNow(t, s, time.Date(2026, time.March, 14, 10, 0, 0, 0, time.UTC))
+		MustSetDialMembershipValue(t, ctx0, s, dial0.ID, 50)
+
+		start := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+		end := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+		report, err := s.DialService.DialValueReport(ctx0, []int{dial0.ID}, start, end, 24*time.Hour, wtf.DialValueReportOptions{})
+		skipIfNotImplemented(t, err)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		records := report.Series[0].Records
+		if got, want := len(records), 14; got != want {
+			t.Fatalf("len(Records)=%v, want %v", got, want)
+		} else if got, want := records[7].Timestamp, time.Date(2026, time.March, 8, 8, 0, 0, 0, time.UTC); !got.Equal(want) {
+			t.Fatalf("Timestamp=%v, want %v", got, want)
+		} else if got, want := records[8].Timestamp, time.Date(2026, time.March, 9, 7, 0, 0, 0, time.UTC); !got.Equal(want) {
+			t.Fatalf("Timestamp=%v, want %v", got, want)
+		} else if got, want := records[12].Value, 0; got != want {
+			t.Fatalf("Value=%v, want %v", got, want)
+		} else if got, want := records[13].Value, 50; got != want {
+			t.Fatalf("Value=%v, want %v", got, want)
+		}
+
+		// Ensure membership reports use the same slots.
+		if report, err := s.DialMembershipService.DialMembershipValueReport(ctx0, wtf.DialMembershipValueReportFilter{DialID: &dial0.ID}, start, end, 24*time.Hour); err != nil {
+			t.Fatal(err)
+		} else if got, want := len(report.Series[0].Records), 14; got != want {
+			t.Fatalf("len(Records)=%v, want %v", got, want)
+		} else if got, want := report.Series[0].Records[13].Value, 50; got != want {
+			t.Fatalf("Value=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure every dial the user belongs to is reported if none are specified.
+	t.Run("AllDials", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL0"})
+		MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL1"})
+
+		start := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		end := time.Date(2000, time.January, 1, 2, 0, 0, 0, time.UTC)
+		report, err := s.DialService.DialValueReport(ctx0, nil, start, end, time.Hour, wtf.DialValueReportOptions{})
+		skipIfNotImplemented(t, err)
+		if err != nil {
+			t.Fatal(err)
+		} else if got, want := len(report.Series), 2; got != want {
+			t.Fatalf("len(Series)=%v, want %v", got, want)
+		} else if got, want := len(report.Series[0].Records), 2; got != want {
+			t.Fatalf("len(Records)=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure an error is returned if the interval is too small.
+	t.Run("ErrInterval", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial0 := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL0"})
+
+		start := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		end := time.Date(2000, time.January, 1, 2, 0, 0, 0, time.UTC)
+		if _, err := s.DialService.DialValueReport(ctx0, []int{dial0.ID}, start, end, time.Second, wtf.DialValueReportOptions{}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Report interval must be at least one minute.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure an error is returned if the range contains too many intervals.
+	t.Run("ErrTooManyIntervals", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial0 := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL0"})
+
+		start := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		end := time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC)
+		if _, err := s.DialService.DialValueReport(ctx0, []int{dial0.ID}, start, end, time.Minute, wtf.DialValueReportOptions{}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Report range contains too many intervals.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure an error is returned if the user is not a member of the dial.
+	t.Run("ErrNotFound", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "joe"})
+		dial0 := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL0"})
+
+		start := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		end := time.Date(2000, time.January, 1, 2, 0, 0, 0, time.UTC)
+		if _, err := s.DialService.DialValueReport(ctx1, []int{dial0.ID}, start, end, time.Hour, wtf.DialValueReportOptions{}); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+}
+
+func testDialService_DialHeatmapReport(t *testing.T, open OpenFunc) {
+	// Ensure hourly values are averaged by day & hour in the user's timezone.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		setNow(t, s, time.Date(2000, time.January, 3, 16, 0, 0, 0, time.UTC))
+
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane", Timezone: "America/Los_Angeles"})
+		dial0 := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL0"})
+		membership0 := MustFindDialMembershipByID(t, ctx0, s, 1)
+
+		// Raise the value for a single hour on Monday morning (Pacific time).
+		setNow(t, s, time.Date(2000, time.January, 3, 17, 0, 0, 0, time.UTC))
+		MustSetDialMembershipValue(t, ctx0, s, membership0.ID, 60)
+		setNow(t, s, time.Date(2000, time.January, 3, 18, 0, 0, 0, time.UTC))
+		MustSetDialMembershipValue(t, ctx0, s, membership0.ID, 0)
+
+		// Generate report. Hours after the current time are excluded.
+		start := time.Date(2000, time.January, 3, 16, 0, 0, 0, time.UTC)
+		end := time.Date(2000, time.January, 4, 0, 0, 0, 0, time.UTC)
+		report, err := s.DialService.DialHeatmapReport(ctx0, []int{dial0.ID}, start, end)
+		skipIfNotImplemented(t, err)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var want wtf.DialHeatmapReport
+		want.Values[time.Monday][9] = 60
+		want.Counts[time.Monday][8] = 1
+		want.Counts[time.Monday][9] = 1
+		want.Counts[time.Monday][10] = 1
+		if !reflect.DeepEqual(report, &want) {
+			t.Fatalf("unexpected report: %#v", report)
+		}
+	})
+
+	// Ensure an error is returned if the range contains too many hours.
+	t.Run("ErrTooManyIntervals", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial0 := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL0"})
+
+		start := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		end := time.Date(2002, time.January, 1, 0, 0, 0, 0, time.UTC)
+		if _, err := s.DialService.DialHeatmapReport(ctx0, []int{dial0.ID}, start, end); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Report range contains too many intervals.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure an error is returned if the user is not a member of the dial.
+	t.Run("ErrNotFound", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "joe"})
+		dial0 := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL0"})
+
+		start := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		end := time.Date(2000, time.January, 2, 0, 0, 0, 0, time.UTC)
+		if _, err := s.DialService.DialHeatmapReport(ctx1, []int{dial0.ID}, start, end); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+}
@@ -0,0 +1,931 @@
+package wtftest
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+)
+
+func testDialMembershipService(t *testing.T, open OpenFunc) {
+	t.Run("CreateDialMembership", func(t *testing.T) { testDialMembershipService_CreateDialMembership(t, open) })
+	t.Run("UpdateDialMembership", func(t *testing.T) { testDialMembershipService_UpdateDialMembership(t, open) })
+	t.Run("SetDialMembershipWeight", func(t *testing.T) { testDialMembershipService_SetDialMembershipWeight(t, open) })
+	t.Run("SetDialMembershipRole", func(t *testing.T) { testDialMembershipService_SetDialMembershipRole(t, open) })
+	t.Run("FindDialMemberships", func(t *testing.T) { testDialMembershipService_FindDialMemberships(t, open) })
+	t.Run("DeleteDialMembership", func(t *testing.T) { testDialMembershipService_DeleteDialMembership(t, open) })
+	t.Run("Events", func(t *testing.T) { testDialMembershipService_Events(t, open) })
+	t.Run("DialMembershipValueReport", func(t *testing.T) { testDialMembershipService_DialMembershipValueReport(t, open) })
+}
+
+func testDialMembershipService_CreateDialMembership(t *testing.T, open OpenFunc) {
+	// Ensure we can create a dial membership.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane", Email: "jane@gmail.com"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jim", Email: "jim@gmail.com"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		// Create new membership. One membership should already exist (1) since it
+		// is automatically created for the dial owner.
+		membership := &wtf.DialMembership{DialID: dial.ID, Dial: dial, Value: 50}
+		if err := s.DialMembershipService.CreateDialMembership(ctx1, membership); err != nil {
+			t.Fatal(err)
+		} else if got, want := membership.ID, 2; got != want {
+			t.Fatalf("ID=%v, want %v", got, want)
+		} else if got, want := membership.Dial.Value, 25; got != want {
+			t.Fatalf("Dial.Value=%v, want %v", got, want)
+		} else if got, want := membership.Weight, wtf.DefaultDialMembershipWeight; got != want {
+			t.Fatalf("Weight=%v, want %v", got, want)
+		} else if got, want := membership.Role, wtf.DialMembershipRoleMember; got != want {
+			t.Fatalf("Role=%v, want %v", got, want)
+		}
+
+		// Fetch membership & compare.
+		if other, err := s.DialMembershipService.FindDialMembershipByID(ctx1, membership.ID); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(membership, other) {
+			t.Fatalf("mismatch: %#v != %#v", membership, other)
+		}
+	})
+
+	// Ensure the invite code is recorded on the membership & counted on the dial.
+	t.Run("InviteCode", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jim"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		membership := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial, InviteCode: dial.InviteCode})
+		if other := MustFindDialMembershipByID(t, ctx1, s, membership.ID); other.InviteCode != dial.InviteCode {
+			t.Fatalf("InviteCode=%v, want %v", other.InviteCode, dial.InviteCode)
+		} else if other := MustFindDialByID(t, ctx0, s, dial.ID); other.InviteUseCount != 1 {
+			t.Fatalf("InviteUseCount=%v, want %v", other.InviteUseCount, 1)
+		}
+	})
+
+	// Ensure an error is returned if the invite code does not match the dial.
+	t.Run("ErrInvalidInviteCode", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jim"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		if err := s.DialMembershipService.CreateDialMembership(ctx1, &wtf.DialMembership{DialID: dial.ID, InviteCode: "XXX"}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Invalid invite code.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure an error is returned if the invite code has expired.
+	t.Run("ErrInviteExpired", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		now := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		setNow(t, s, now)
+
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jim"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		expiresAt := now.Add(time.Hour)
+		dial, err := s.DialService.RotateDialInvite(ctx0, dial.ID, wtf.DialInviteOptions{ExpiresAt: &expiresAt})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		setNow(t, s, expiresAt)
+		if err := s.DialMembershipService.CreateDialMembership(ctx1, &wtf.DialMembership{DialID: dial.ID, InviteCode: dial.InviteCode}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Invite code has expired.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure an error is returned if the invite code has been used up.
+	t.Run("ErrInviteExhausted", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jim"})
+		_, ctx2 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jill"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		dial, err := s.DialService.RotateDialInvite(ctx0, dial.ID, wtf.DialInviteOptions{MaxUses: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, InviteCode: dial.InviteCode})
+
+		if err := s.DialMembershipService.CreateDialMembership(ctx2, &wtf.DialMembership{DialID: dial.ID, InviteCode: dial.InviteCode}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Invite code has reached its maximum number of uses.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure a dial cannot be joined without an invite code unless it belongs
+	// to one of the user's organizations.
+	t.Run("ErrInviteCodeRequired", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jim"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		if err := s.DialMembershipService.CreateDialMembership(ctx1, &wtf.DialMembership{DialID: dial.ID}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.ENOTFOUND || wtf.ErrorMessage(err) != `Dial not found.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure organization members can join the organization's dials without
+	// an invite code but other users cannot.
+	t.Run("Organization", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jim"})
+		_, ctx2 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jill"})
+		org := MustCreateOrganization(t, ctx0, s, &wtf.Organization{Name: "Acme"})
+		MustCreateOrganizationMember(t, ctx1, s, &wtf.OrganizationMember{OrganizationID: org.ID, InviteCode: org.InviteCode})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL", OrganizationID: org.ID})
+
+		MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID})
+
+		if err := s.DialMembershipService.CreateDialMembership(ctx2, &wtf.DialMembership{DialID: dial.ID}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.ENOTFOUND || wtf.ErrorMessage(err) != `Dial not found.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure an error is returned if we do not have an associated dial.
+	t.Run("ErrDialRequired", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+
+		if err := s.DialMembershipService.CreateDialMembership(ctx0, &wtf.DialMembership{}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Dial required for membership.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure an error is returned if the dial does not exist.
+	t.Run("ErrDialNotFound", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+
+		if err := s.DialMembershipService.CreateDialMembership(ctx0, &wtf.DialMembership{DialID: 100}); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure an error is returned if the user is already a member of the dial.
+	t.Run("ErrConflict", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jim"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial})
+
+		if err := s.DialMembershipService.CreateDialMembership(ctx1, &wtf.DialMembership{DialID: dial.ID, Dial: dial}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.ECONFLICT || wtf.ErrorMessage(err) != `Dial membership already exists.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure an error is returned if user is not currently logged in.
+	t.Run("ErrUserRequired", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		if err := s.DialMembershipService.CreateDialMembership(ctx, &wtf.DialMembership{DialID: dial.ID, Dial: dial}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EUNAUTHORIZED {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+}
+
+func testDialMembershipService_UpdateDialMembership(t *testing.T, open OpenFunc) {
+	// Ensure a membership value can be updated by owner.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane", Email: "jane@gmail.com"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jim", Email: "jim@gmail.com"})
+
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		membership := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{
+			DialID: dial.ID,
+			Dial:   dial,
+			Value:  50,
+		})
+
+		// Update membership value.
+		// New aggregate dial value is the rounded average of the owner
+		// membership (0) and the new value (25).
+		newValue := 25
+		var err error
+		if membership, err = s.DialMembershipService.UpdateDialMembership(ctx1, membership.ID, wtf.DialMembershipUpdate{Value: &newValue}); err != nil {
+			t.Fatal(err)
+		} else if got, want := membership.Value, 25; got != want {
+			t.Fatalf("Value=%v, want %v", got, want)
+		} else if got, want := membership.Dial.Value, 13; got != want {
+			t.Fatalf("Dial.Value=%v, want %v", got, want)
+		}
+
+		// Fetch membership & compare.
+		if other, err := s.DialMembershipService.FindDialMembershipByID(ctx1, membership.ID); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(membership, other) {
+			t.Fatalf("mismatch: %#v != %#v", membership, other)
+		}
+	})
+
+	// Ensure a note can be attached to a change & is cleared by the next
+	// change if no new note is given.
+	t.Run("Note", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		value, note := 90, "prod DB migration failing"
+		if membership, err := s.DialMembershipService.UpdateDialMembership(ctx0, 1, wtf.DialMembershipUpdate{Value: &value, Note: &note}); err != nil {
+			t.Fatal(err)
+		} else if got, want := membership.Note, note; got != want {
+			t.Fatalf("Note=%v, want %v", got, want)
+		} else if got, want := MustFindDialMembershipByID(t, ctx0, s, 1).Note, note; got != want {
+			t.Fatalf("Note=%v, want %v", got, want)
+		}
+
+		// Updating only the note with the same value should still apply.
+		note = "rolled back"
+		if membership, err := s.DialMembershipService.UpdateDialMembership(ctx0, 1, wtf.DialMembershipUpdate{Value: &value, Note: &note}); err != nil {
+			t.Fatal(err)
+		} else if got, want := membership.Note, note; got != want {
+			t.Fatalf("Note=%v, want %v", got, want)
+		}
+
+		// Changing the value without a note clears the previous note.
+		value = 20
+		if membership, err := s.DialMembershipService.UpdateDialMembership(ctx0, 1, wtf.DialMembershipUpdate{Value: &value}); err != nil {
+			t.Fatal(err)
+		} else if membership.Note != "" {
+			t.Fatalf("unexpected note: %q", membership.Note)
+		}
+	})
+
+	// Ensure an error is returned if the note is too long.
+	t.Run("ErrNoteTooLong", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		value, note := 50, strings.Repeat("X", wtf.MaxDialMembershipNoteLen+1)
+		if _, err := s.DialMembershipService.UpdateDialMembership(ctx0, 1, wtf.DialMembershipUpdate{Value: &value, Note: &note}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Dial membership note too long.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure an error is returned if another user tries to update a membership.
+	t.Run("ErrUnauthorized", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane", Email: "jane@gmail.com"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jim", Email: "jim@gmail.com"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		membership := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{
+			DialID: dial.ID,
+			Dial:   dial,
+			Value:  50,
+		})
+
+		newValue := 25
+		if _, err := s.DialMembershipService.UpdateDialMembership(ctx0, membership.ID, wtf.DialMembershipUpdate{Value: &newValue}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EUNAUTHORIZED || wtf.ErrorMessage(err) != `You do not have permission to update the dial membership.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure membership value is between 0 & 100.
+	t.Run("ErrValueOutOfRange", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane", Email: "jane@gmail.com"})
+		MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		newValue := -1
+		if _, err := s.DialMembershipService.UpdateDialMembership(ctx0, 1, wtf.DialMembershipUpdate{Value: &newValue}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Dial value must be between 0 & 100.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+}
+
+func testDialMembershipService_SetDialMembershipWeight(t *testing.T, open OpenFunc) {
+	// Ensure the dial owner can set a membership weight & the dial value is
+	// recomputed using the weighted values.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jim"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		membership := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial, Value: 60})
+
+		// Member counts double so the dial value is (0 + 60*2) / 3.
+		other, err := s.DialMembershipService.SetDialMembershipWeight(ctx0, membership.ID, 2)
+		if err != nil {
+			t.Fatal(err)
+		} else if got, want := other.Weight, 2; got != want {
+			t.Fatalf("Weight=%v, want %v", got, want)
+		} else if got, want := other.Dial.Value, 40; got != want {
+			t.Fatalf("Dial.Value=%v, want %v", got, want)
+		}
+
+		// Ensure weight is persisted.
+		if other := MustFindDialMembershipByID(t, ctx1, s, membership.ID); other.Weight != 2 {
+			t.Fatalf("Weight=%v, want %v", other.Weight, 2)
+		}
+	})
+
+	// Ensure a membership with a zero weight does not count toward the dial value.
+	t.Run("Zero", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jim"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		MustSetDialMembershipValue(t, ctx0, s, 1, 30)
+		membership := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial, Value: 90})
+
+		if _, err := s.DialMembershipService.SetDialMembershipWeight(ctx0, membership.ID, 0); err != nil {
+			t.Fatal(err)
+		} else if other := MustFindDialByID(t, ctx0, s, dial.ID); other.Value != 30 {
+			t.Fatalf("Value=%v, want %v", other.Value, 30)
+		}
+	})
+
+	// Ensure a member cannot change their own weight.
+	t.Run("ErrUnauthorized", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jim"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		membership := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial})
+
+		if _, err := s.DialMembershipService.SetDialMembershipWeight(ctx1, membership.ID, 2); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EUNAUTHORIZED || wtf.ErrorMessage(err) != `Only the dial owner or an admin can set the membership weight.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure weight is within the allowed range.
+	t.Run("ErrWeightOutOfRange", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		if _, err := s.DialMembershipService.SetDialMembershipWeight(ctx0, 1, wtf.MaxDialMembershipWeight+1); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Dial membership weight must be between 0 & 10.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure an error is returned if the membership does not exist.
+	t.Run("ErrNotFound", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+
+		if _, err := s.DialMembershipService.SetDialMembershipWeight(ctx0, 1, 2); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+}
+
+func testDialMembershipService_SetDialMembershipRole(t *testing.T, open OpenFunc) {
+	// Ensure the dial owner can promote a member to admin & the admin can
+	// then edit the dial.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jim"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		membership := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial})
+
+		if other, err := s.DialMembershipService.SetDialMembershipRole(ctx0, membership.ID, wtf.DialMembershipRoleAdmin); err != nil {
+			t.Fatal(err)
+		} else if got, want := other.Role, wtf.DialMembershipRoleAdmin; got != want {
+			t.Fatalf("Role=%v, want %v", got, want)
+		}
+
+		// Ensure the role is reported on the dial for the admin.
+		if other := MustFindDialByID(t, ctx1, s, dial.ID); other.Role != wtf.DialMembershipRoleAdmin {
+			t.Fatalf("Dial.Role=%v, want %v", other.Role, wtf.DialMembershipRoleAdmin)
+		}
+
+		// Admin can now rename the dial.
+		newName := "NEWNAME"
+		if other, err := s.DialService.UpdateDial(ctx1, dial.ID, wtf.DialUpdate{Name: &newName}); err != nil {
+			t.Fatal(err)
+		} else if got, want := other.Name, "NEWNAME"; got != want {
+			t.Fatalf("Name=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure viewers do not count toward the dial value & cannot set a value.
+	t.Run("Viewer", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jim"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		MustSetDialMembershipValue(t, ctx0, s, 1, 30)
+		membership := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial, Value: 90})
+
+		if other, err := s.DialMembershipService.SetDialMembershipRole(ctx0, membership.ID, wtf.DialMembershipRoleViewer); err != nil {
+			t.Fatal(err)
+		} else if got, want := other.Dial.Value, 30; got != want {
+			t.Fatalf("Dial.Value=%v, want %v", got, want)
+		}
+
+		newValue := 50
+		if _, err := s.DialMembershipService.UpdateDialMembership(ctx1, membership.ID, wtf.DialMembershipUpdate{Value: &newValue}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EUNAUTHORIZED || wtf.ErrorMessage(err) != `Viewers cannot set a value on a dial.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure an admin can manage members but not other admins.
+	t.Run("Admin", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jim"})
+		_, ctx2 := MustCreateUser(t, ctx, s, &wtf.User{Name: "bob"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		membership1 := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial})
+		membership2 := MustCreateDialMembership(t, ctx2, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial})
+		MustSetDialMembershipRole(t, ctx0, s, membership1.ID, wtf.DialMembershipRoleAdmin)
+
+		// Admin can demote a member to viewer & set their weight.
+		if _, err := s.DialMembershipService.SetDialMembershipRole(ctx1, membership2.ID, wtf.DialMembershipRoleViewer); err != nil {
+			t.Fatal(err)
+		} else if _, err := s.DialMembershipService.SetDialMembershipWeight(ctx1, membership2.ID, 2); err != nil {
+			t.Fatal(err)
+		}
+
+		// Admin cannot promote others to admin.
+		if _, err := s.DialMembershipService.SetDialMembershipRole(ctx1, membership2.ID, wtf.DialMembershipRoleAdmin); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EUNAUTHORIZED || wtf.ErrorMessage(err) != `You do not have permission to change the membership role.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		// Admin can remove a member from the dial.
+		if err := s.DialMembershipService.DeleteDialMembership(ctx1, membership2.ID); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure the owner's role cannot be changed & the owner role cannot be assigned.
+	t.Run("ErrOwner", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jim"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		membership := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial})
+
+		if _, err := s.DialMembershipService.SetDialMembershipRole(ctx0, 1, wtf.DialMembershipRoleMember); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.ECONFLICT || wtf.ErrorMessage(err) != `Dial ownership cannot be changed by setting a role.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if _, err := s.DialMembershipService.SetDialMembershipRole(ctx0, membership.ID, wtf.DialMembershipRoleOwner); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.ECONFLICT || wtf.ErrorMessage(err) != `Dial ownership cannot be changed by setting a role.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure a member cannot change roles.
+	t.Run("ErrUnauthorized", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jim"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		membership := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial})
+
+		if _, err := s.DialMembershipService.SetDialMembershipRole(ctx1, membership.ID, wtf.DialMembershipRoleAdmin); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EUNAUTHORIZED || wtf.ErrorMessage(err) != `You do not have permission to change the membership role.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure the role must be a known value.
+	t.Run("ErrInvalidRole", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jim"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		membership := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial})
+
+		if _, err := s.DialMembershipService.SetDialMembershipRole(ctx0, membership.ID, "superuser"); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Invalid dial membership role.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure an error is returned if the membership does not exist.
+	t.Run("ErrNotFound", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+
+		if _, err := s.DialMembershipService.SetDialMembershipRole(ctx0, 1, wtf.DialMembershipRoleViewer); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+}
+
+func testDialMembershipService_FindDialMemberships(t *testing.T, open OpenFunc) {
+	// Ensure dial member can see all memberships in dial.
+	t.Run("RestrictToDialMember", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		_, ctx2 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jill"})
+
+		// Dials will automatically create memberships for the owner.
+		dial0 := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL0"})
+		membership0 := MustFindDialMembershipByID(t, ctx0, s, 1)
+		membership1 := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial0.ID, Dial: dial0, Value: 10})
+		membership2 := MustCreateDialMembership(t, ctx2, s, &wtf.DialMembership{DialID: dial0.ID, Dial: dial0, Value: 20})
+
+		dial1 := MustCreateDial(t, ctx1, s, &wtf.Dial{Name: "DIAL1"})
+		MustCreateDialMembership(t, ctx0, s, &wtf.DialMembership{DialID: dial1.ID, Dial: dial1, Value: 30})
+
+		a, n, err := s.DialMembershipService.FindDialMemberships(ctx2, wtf.DialMembershipFilter{})
+		if err != nil {
+			t.Fatal(err)
+		} else if got, want := len(a), 3; got != want {
+			t.Fatalf("len=%v, want %v", got, want)
+		} else if got, want := n, 3; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		}
+
+		// Self membership should appear first, then the remaining memberships
+		// should appear sorted by user name.
+		for i, want := range []*wtf.DialMembership{membership2, membership0, membership1} {
+			if got := a[i]; got.ID != want.ID {
+				t.Fatalf("[%d].ID=%v, want %v", i, got.ID, want.ID)
+			} else if got.Value != want.Value {
+				t.Fatalf("[%d].Value=%v, want %v", i, got.Value, want.Value)
+			}
+		}
+	})
+
+	// Ensure memberships can be filtered by dial.
+	t.Run("DialID", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+
+		// These dials will automatically create memberships for the owner (1,2).
+		dial0 := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL0"})
+		MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL1"})
+
+		a, n, err := s.DialMembershipService.FindDialMemberships(ctx0, wtf.DialMembershipFilter{DialID: &dial0.ID})
+		if err != nil {
+			t.Fatal(err)
+		} else if got, want := len(a), 1; got != want {
+			t.Fatalf("len=%v, want %v", got, want)
+		} else if got, want := n, 1; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		} else if got, want := a[0].ID, 1; got != want {
+			t.Fatalf("[].ID=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure memberships can be filtered by user.
+	t.Run("UserID", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		user1, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jill"})
+		dial0 := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL0"})
+		membership0 := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial0.ID, Dial: dial0, Value: 10})
+
+		a, n, err := s.DialMembershipService.FindDialMemberships(ctx0, wtf.DialMembershipFilter{UserID: &user1.ID})
+		if err != nil {
+			t.Fatal(err)
+		} else if got, want := len(a), 1; got != want {
+			t.Fatalf("len=%v, want %v", got, want)
+		} else if got, want := n, 1; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		} else if got, want := a[0].ID, membership0.ID; got != want {
+			t.Fatalf("[].ID=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure the total count is returned when the results are paginated.
+	t.Run("LimitOffset", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jill"})
+		_, ctx2 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL0"})
+		membership1 := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial})
+		MustCreateDialMembership(t, ctx2, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial})
+
+		a, n, err := s.DialMembershipService.FindDialMemberships(ctx0, wtf.DialMembershipFilter{Offset: 1, Limit: 1})
+		if err != nil {
+			t.Fatal(err)
+		} else if got, want := len(a), 1; got != want {
+			t.Fatalf("len=%v, want %v", got, want)
+		} else if got, want := n, 3; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		} else if got, want := a[0].ID, membership1.ID; got != want {
+			t.Fatalf("[].ID=%v, want %v", got, want)
+		}
+	})
+}
+
+func testDialMembershipService_DeleteDialMembership(t *testing.T, open OpenFunc) {
+	// Ensure a membership owner can delete their membership.
+	t.Run("ByMembershipOwner", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jim"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		membership := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial, Value: 50})
+
+		if err := s.DialMembershipService.DeleteDialMembership(ctx1, membership.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		// Ensure membership has been deleted.
+		if _, err := s.DialMembershipService.FindDialMembershipByID(ctx1, membership.ID); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.ENOTFOUND || wtf.ErrorMessage(err) != `Dial membership not found.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		// Ensure dial aggregate value is updated.
+		if other := MustFindDialByID(t, ctx0, s, dial.ID); other.Value != 0 {
+			t.Fatalf("unexpected dial value: %d", other.Value)
+		}
+	})
+
+	// Ensure a dial owner can delete another user's membership.
+	t.Run("ByDialOwner", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jim"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		membership := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial, Value: 50})
+
+		if err := s.DialMembershipService.DeleteDialMembership(ctx0, membership.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		// Ensure membership has been deleted.
+		if _, err := s.DialMembershipService.FindDialMembershipByID(ctx0, membership.ID); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.ENOTFOUND || wtf.ErrorMessage(err) != `Dial membership not found.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure owner's membership cannot be deleted.
+	t.Run("ErrCannotDeleteOwnerMembership", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		if err := s.DialMembershipService.DeleteDialMembership(ctx0, 1); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.ECONFLICT || wtf.ErrorMessage(err) != `Dial owner may not delete their own membership.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		// Ensure dial still exists.
+		MustFindDialByID(t, ctx0, s, dial.ID)
+	})
+
+	// Ensure a non-owner (of dial or membership) cannot delete a membership.
+	t.Run("ErrUnauthorized", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jim"})
+		_, ctx2 := MustCreateUser(t, ctx, s, &wtf.User{Name: "bob"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		membership0 := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial, Value: 50})
+		MustCreateDialMembership(t, ctx2, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial, Value: 50})
+
+		if err := s.DialMembershipService.DeleteDialMembership(ctx2, membership0.ID); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EUNAUTHORIZED || wtf.ErrorMessage(err) != `You do not have permission to delete the dial membership.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+}
+
+func testDialMembershipService_Events(t *testing.T, open OpenFunc) {
+	// Ensure dial members are notified when a membership value changes.
+	t.Run("ValueChanged", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jim"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		membership := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial})
+
+		// Subscribe as the dial owner & update the other member's value.
+		sub := MustSubscribe(t, ctx0, s)
+		MustSetDialMembershipValue(t, ctx1, s, membership.ID, 50)
+
+		// Ensure the dial value change is published before the membership change.
+		for _, want := range []wtf.Event{
+			{Type: wtf.EventTypeDialValueChanged, Payload: &wtf.DialValueChangedPayload{ID: dial.ID, Value: 25}},
+			{Type: wtf.EventTypeDialMembershipValueChanged, Payload: &wtf.DialMembershipValueChangedPayload{ID: membership.ID, Value: 50}},
+		} {
+			select {
+			case got := <-sub.C():
+				if !reflect.DeepEqual(got, want) {
+					t.Fatalf("event=%#v, want %#v", got, want)
+				}
+			default:
+				t.Fatalf("expected event: %s", want.Type)
+			}
+		}
+	})
+
+	// Ensure the note for a change is included in the event.
+	t.Run("ValueChangedNote", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		sub := MustSubscribe(t, ctx0, s)
+		if err := s.DialService.SetDialMembershipValue(ctx0, dial.ID, 90, "prod DB migration failing"); err != nil {
+			t.Fatal(err)
+		}
+
+		// Skip the dial value change & verify the membership change.
+		<-sub.C()
+		select {
+		case got := <-sub.C():
+			if want := (wtf.Event{
+				Type:    wtf.EventTypeDialMembershipValueChanged,
+				Payload: &wtf.DialMembershipValueChangedPayload{ID: 1, Value: 90, Note: "prod DB migration failing"},
+			}); !reflect.DeepEqual(got, want) {
+				t.Fatalf("event=%#v, want %#v", got, want)
+			}
+		default:
+			t.Fatal("expected event")
+		}
+	})
+
+	// Ensure users who are not members do not receive events.
+	t.Run("NonMember", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jim"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		sub := MustSubscribe(t, ctx1, s)
+		if err := s.DialService.SetDialMembershipValue(ctx0, dial.ID, 50, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case event := <-sub.C():
+			t.Fatalf("unexpected event: %#v", event)
+		default:
+		}
+	})
+}
+
+func testDialMembershipService_DialMembershipValueReport(t *testing.T, open OpenFunc) {
+	// Ensure membership values are slotted into intervals for a single
+	// membership & for every membership on a dial.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		t0 := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		setNow(t, s, t0)
+
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jim"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		membership := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial})
+
+		// Update values over several minutes. Only the last change within a
+		// minute is used for the slot.
+		setNow(t, s, t0.Add(1*time.Minute))
+		MustSetDialMembershipValue(t, ctx1, s, membership.ID, 50)
+		setNow(t, s, t0.Add(3*time.Minute))
+		MustSetDialMembershipValue(t, ctx1, s, membership.ID, 70)
+		setNow(t, s, t0.Add(3*time.Minute+30*time.Second))
+		MustSetDialMembershipValue(t, ctx1, s, membership.ID, 80)
+
+		// Report on the single membership.
+		report, err := s.DialMembershipService.DialMembershipValueReport(ctx1, wtf.DialMembershipValueReportFilter{ID: &membership.ID}, t0, t0.Add(5*time.Minute), time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		} else if got, want := len(report.Series), 1; got != want {
+			t.Fatalf("len(Series)=%v, want %v", got, want)
+		} else if series := report.Series[0]; series.DialMembershipID != membership.ID || series.User.Name != "jim" {
+			t.Fatalf("unexpected series: %#v", series)
+		} else if got, want := report.Series[0].Records, []*wtf.DialValueRecord{
+			{Value: 0, Timestamp: t0},
+			{Value: 50, Timestamp: t0.Add(1 * time.Minute)},
+			{Value: 50, Timestamp: t0.Add(2 * time.Minute)},
+			{Value: 80, Timestamp: t0.Add(3 * time.Minute)},
+			{Value: 80, Timestamp: t0.Add(4 * time.Minute)},
+		}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("Records=%#v, want %#v", got, want)
+		}
+
+		// Report on every membership of the dial.
+		if report, err := s.DialMembershipService.DialMembershipValueReport(ctx0, wtf.DialMembershipValueReportFilter{DialID: &dial.ID}, t0, t0.Add(5*time.Minute), time.Minute); err != nil {
+			t.Fatal(err)
+		} else if got, want := len(report.Series), 2; got != want {
+			t.Fatalf("len(Series)=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure a membership or dial is required.
+	t.Run("ErrFilterRequired", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		if _, err := s.DialMembershipService.DialMembershipValueReport(ctx0, wtf.DialMembershipValueReportFilter{}, time.Now().Add(-time.Hour), time.Now(), time.Minute); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Dial membership or dial required for report.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure the interval is at least one minute.
+	t.Run("ErrInterval", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		if _, err := s.DialMembershipService.DialMembershipValueReport(ctx0, wtf.DialMembershipValueReportFilter{DialID: &dial.ID}, time.Now().Add(-time.Hour), time.Now(), time.Second); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Report interval must be at least one minute.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure non-members cannot report on a dial or its memberships.
+	t.Run("ErrNotFound", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jim"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		start, end := time.Now().Add(-time.Hour), time.Now()
+		if _, err := s.DialMembershipService.DialMembershipValueReport(ctx1, wtf.DialMembershipValueReportFilter{ID: intPtr(1)}, start, end, time.Minute); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatalf("unexpected error: %#v", err)
+		} else if _, err := s.DialMembershipService.DialMembershipValueReport(ctx1, wtf.DialMembershipValueReportFilter{DialID: &dial.ID}, start, end, time.Minute); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+}
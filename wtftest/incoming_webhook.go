@@ -0,0 +1,253 @@
+package wtftest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benbjohnson/wtf"
+)
+
+func testIncomingWebhookService(t *testing.T, open OpenFunc) {
+	t.Run("CreateIncomingWebhook", func(t *testing.T) { testIncomingWebhookService_CreateIncomingWebhook(t, open) })
+	t.Run("FindIncomingWebhooks", func(t *testing.T) { testIncomingWebhookService_FindIncomingWebhooks(t, open) })
+	t.Run("DeleteIncomingWebhook", func(t *testing.T) { testIncomingWebhookService_DeleteIncomingWebhook(t, open) })
+	t.Run("InvokeIncomingWebhook", func(t *testing.T) { testIncomingWebhookService_InvokeIncomingWebhook(t, open) })
+}
+
+func testIncomingWebhookService_CreateIncomingWebhook(t *testing.T, open OpenFunc) {
+	// Ensure a member can create a webhook for their own membership.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		user1, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		membership := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial, InviteCode: dial.InviteCode})
+
+		webhook := &wtf.IncomingWebhook{DialID: dial.ID, Name: "CI"}
+		if err := s.IncomingWebhookService.CreateIncomingWebhook(ctx1, webhook); err != nil {
+			t.Fatal(err)
+		} else if got, want := webhook.ID, 1; got != want {
+			t.Fatalf("ID=%v, want %v", got, want)
+		} else if got, want := webhook.DialMembershipID, membership.ID; got != want {
+			t.Fatalf("DialMembershipID=%v, want %v", got, want)
+		} else if got, want := webhook.UserID, user1.ID; got != want {
+			t.Fatalf("UserID=%v, want %v", got, want)
+		} else if webhook.Token == "" {
+			t.Fatal("expected token")
+		} else if got, want := webhook.Prefix, webhook.Token[:wtf.IncomingWebhookDisplayPrefixLen]; got != want {
+			t.Fatalf("Prefix=%v, want %v", got, want)
+		} else if webhook.LastUsedAt != nil {
+			t.Fatalf("unexpected last used at: %v", webhook.LastUsedAt)
+		} else if webhook.CreatedAt.IsZero() {
+			t.Fatal("expected created at")
+		}
+	})
+
+	// Ensure a name is required.
+	t.Run("ErrNameRequired", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		if err := s.IncomingWebhookService.CreateIncomingWebhook(ctx0, &wtf.IncomingWebhook{DialID: dial.ID}); wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Incoming webhook name required.` {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure a user who is not a member of the dial cannot create a webhook.
+	t.Run("ErrNotMember", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		if err := s.IncomingWebhookService.CreateIncomingWebhook(ctx1, &wtf.IncomingWebhook{DialID: dial.ID, Name: "CI"}); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure a viewer cannot create a webhook since they cannot set a value.
+	t.Run("ErrViewer", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		membership := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial, InviteCode: dial.InviteCode})
+		MustSetDialMembershipRole(t, ctx0, s, membership.ID, wtf.DialMembershipRoleViewer)
+
+		if err := s.IncomingWebhookService.CreateIncomingWebhook(ctx1, &wtf.IncomingWebhook{DialID: dial.ID, Name: "CI"}); wtf.ErrorCode(err) != wtf.EUNAUTHORIZED || wtf.ErrorMessage(err) != `Viewers cannot create incoming webhooks.` {
+			t.Fatal(err)
+		}
+	})
+}
+
+func testIncomingWebhookService_FindIncomingWebhooks(t *testing.T, open OpenFunc) {
+	// Ensure only the current user's webhooks are returned.
+	t.Run("OwnOnly", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial, InviteCode: dial.InviteCode})
+
+		MustCreateIncomingWebhook(t, ctx0, s, &wtf.IncomingWebhook{DialID: dial.ID, Name: "A"})
+		webhook := MustCreateIncomingWebhook(t, ctx1, s, &wtf.IncomingWebhook{DialID: dial.ID, Name: "B"})
+		MustCreateIncomingWebhook(t, ctx1, s, &wtf.IncomingWebhook{DialID: dial.ID, Name: "C"})
+
+		if a, n, err := s.IncomingWebhookService.FindIncomingWebhooks(ctx1, wtf.IncomingWebhookFilter{DialID: &dial.ID, Limit: 1}); err != nil {
+			t.Fatal(err)
+		} else if got, want := len(a), 1; got != want {
+			t.Fatalf("len=%v, want %v", got, want)
+		} else if got, want := a[0].Name, "B"; got != want {
+			t.Fatalf("Name=%v, want %v", got, want)
+		} else if got, want := a[0].Token, ""; got != want {
+			t.Fatalf("Token=%v, want %v", got, want)
+		} else if got, want := a[0].Prefix, webhook.Prefix; got != want {
+			t.Fatalf("Prefix=%v, want %v", got, want)
+		} else if got, want := n, 2; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		}
+	})
+}
+
+func testIncomingWebhookService_DeleteIncomingWebhook(t *testing.T, open OpenFunc) {
+	// Ensure a user can revoke their webhook.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		webhook := MustCreateIncomingWebhook(t, ctx0, s, &wtf.IncomingWebhook{DialID: dial.ID, Name: "CI"})
+
+		if err := s.IncomingWebhookService.DeleteIncomingWebhook(ctx0, webhook.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		// Ensure the token can no longer be used.
+		if _, err := s.IncomingWebhookService.InvokeIncomingWebhook(context.Background(), webhook.Token, wtf.IncomingWebhookUpdate{Value: intPtr(10)}); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure another user cannot revoke a webhook they do not own.
+	t.Run("ErrNotFound", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		webhook := MustCreateIncomingWebhook(t, ctx0, s, &wtf.IncomingWebhook{DialID: dial.ID, Name: "CI"})
+
+		if err := s.IncomingWebhookService.DeleteIncomingWebhook(ctx1, webhook.ID); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatal(err)
+		}
+	})
+}
+
+func testIncomingWebhookService_InvokeIncomingWebhook(t *testing.T, open OpenFunc) {
+	// Ensure a token can set an absolute value without a user on the context.
+	t.Run("Value", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		webhook := MustCreateIncomingWebhook(t, ctx0, s, &wtf.IncomingWebhook{DialID: dial.ID, Name: "CI"})
+
+		if membership, err := s.IncomingWebhookService.InvokeIncomingWebhook(context.Background(), webhook.Token, wtf.IncomingWebhookUpdate{Value: intPtr(80), Note: stringPtr("deploy failed")}); err != nil {
+			t.Fatal(err)
+		} else if got, want := membership.ID, webhook.DialMembershipID; got != want {
+			t.Fatalf("ID=%v, want %v", got, want)
+		} else if got, want := membership.Value, 80; got != want {
+			t.Fatalf("Value=%v, want %v", got, want)
+		} else if got, want := membership.Note, "deploy failed"; got != want {
+			t.Fatalf("Note=%v, want %v", got, want)
+		} else if membership.Dial != nil || membership.User != nil {
+			t.Fatal("expected dial & user to not be attached")
+		}
+
+		// Ensure the dial value is recomputed & the webhook usage is recorded.
+		if dial := MustFindDialByID(t, ctx0, s, dial.ID); dial.Value != 80 {
+			t.Fatalf("Dial.Value=%v, want %v", dial.Value, 80)
+		}
+		if a, _, err := s.IncomingWebhookService.FindIncomingWebhooks(ctx0, wtf.IncomingWebhookFilter{ID: &webhook.ID}); err != nil {
+			t.Fatal(err)
+		} else if a[0].LastUsedAt == nil {
+			t.Fatal("expected last used at")
+		}
+	})
+
+	// Ensure relative changes are applied to the current value & clamped.
+	t.Run("Delta", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		webhook := MustCreateIncomingWebhook(t, ctx0, s, &wtf.IncomingWebhook{DialID: dial.ID, Name: "CI"})
+		MustSetDialMembershipValue(t, ctx0, s, webhook.DialMembershipID, 50)
+
+		if membership, err := s.IncomingWebhookService.InvokeIncomingWebhook(context.Background(), webhook.Token, wtf.IncomingWebhookUpdate{Delta: intPtr(-10)}); err != nil {
+			t.Fatal(err)
+		} else if got, want := membership.Value, 40; got != want {
+			t.Fatalf("Value=%v, want %v", got, want)
+		}
+
+		if membership, err := s.IncomingWebhookService.InvokeIncomingWebhook(context.Background(), webhook.Token, wtf.IncomingWebhookUpdate{Delta: intPtr(100)}); err != nil {
+			t.Fatal(err)
+		} else if got, want := membership.Value, 100; got != want {
+			t.Fatalf("Value=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure an unknown token returns an error.
+	t.Run("ErrNotFound", func(t *testing.T) {
+		s := open(t)
+		if _, err := s.IncomingWebhookService.InvokeIncomingWebhook(context.Background(), "NO_SUCH_TOKEN", wtf.IncomingWebhookUpdate{Value: intPtr(10)}); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure the update must set either a value or a delta.
+	t.Run("ErrInvalidUpdate", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		webhook := MustCreateIncomingWebhook(t, ctx0, s, &wtf.IncomingWebhook{DialID: dial.ID, Name: "CI"})
+
+		if _, err := s.IncomingWebhookService.InvokeIncomingWebhook(context.Background(), webhook.Token, wtf.IncomingWebhookUpdate{}); wtf.ErrorCode(err) != wtf.EINVALID {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure absolute values outside the valid range are rejected.
+	t.Run("ErrValueOutOfRange", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		webhook := MustCreateIncomingWebhook(t, ctx0, s, &wtf.IncomingWebhook{DialID: dial.ID, Name: "CI"})
+
+		if _, err := s.IncomingWebhookService.InvokeIncomingWebhook(context.Background(), webhook.Token, wtf.IncomingWebhookUpdate{Value: intPtr(101)}); wtf.ErrorCode(err) != wtf.EINVALID {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure the token stops working once the user leaves the dial.
+	t.Run("ErrMembershipDeleted", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		membership := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial, InviteCode: dial.InviteCode})
+		webhook := MustCreateIncomingWebhook(t, ctx1, s, &wtf.IncomingWebhook{DialID: dial.ID, Name: "CI"})
+
+		if err := s.DialMembershipService.DeleteDialMembership(ctx1, membership.ID); err != nil {
+			t.Fatal(err)
+		} else if _, err := s.IncomingWebhookService.InvokeIncomingWebhook(context.Background(), webhook.Token, wtf.IncomingWebhookUpdate{Value: intPtr(10)}); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatal(err)
+		}
+	})
+}
+
+// MustCreateIncomingWebhook creates an incoming webhook. Fatal on error.
+func MustCreateIncomingWebhook(tb testing.TB, ctx context.Context, s *Services, webhook *wtf.IncomingWebhook) *wtf.IncomingWebhook {
+	tb.Helper()
+	if err := s.IncomingWebhookService.CreateIncomingWebhook(ctx, webhook); err != nil {
+		tb.Fatal(err)
+	}
+	return webhook
+}
@@ -0,0 +1,112 @@
+package wtftest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+)
+
+func testLoginTokenService(t *testing.T, open OpenFunc) {
+	t.Run("CreateLoginToken", func(t *testing.T) { testLoginTokenService_CreateLoginToken(t, open) })
+	t.Run("RedeemLoginToken", func(t *testing.T) { testLoginTokenService_RedeemLoginToken(t, open) })
+}
+
+func testLoginTokenService_CreateLoginToken(t *testing.T, open OpenFunc) {
+	// Ensure a token can be created for an email address without a user.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		token := &wtf.LoginToken{Email: " jane@example.com "}
+		if err := s.LoginTokenService.CreateLoginToken(context.Background(), token); err != nil {
+			t.Fatal(err)
+		} else if got, want := token.ID, 1; got != want {
+			t.Fatalf("ID=%v, want %v", got, want)
+		} else if got, want := token.Email, "jane@example.com"; got != want {
+			t.Fatalf("Email=%v, want %v", got, want)
+		} else if token.Token == "" {
+			t.Fatal("expected token")
+		} else if got, want := token.ExpiresAt, token.CreatedAt.Add(wtf.LoginTokenDuration); !got.Equal(want) {
+			t.Fatalf("ExpiresAt=%v, want %v", got, want)
+		} else if token.UsedAt != nil {
+			t.Fatalf("unexpected used at: %v", token.UsedAt)
+		}
+
+		// Ensure each token is unique.
+		other := MustCreateLoginToken(t, context.Background(), s, &wtf.LoginToken{Email: "jane@example.com"})
+		if token.Token == other.Token {
+			t.Fatal("expected unique token")
+		}
+	})
+
+	// Ensure an email address is required.
+	t.Run("ErrEmailRequired", func(t *testing.T) {
+		s := open(t)
+		if err := s.LoginTokenService.CreateLoginToken(context.Background(), &wtf.LoginToken{}); wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Email required.` {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure a malformed email address returns an error.
+	t.Run("ErrInvalidEmail", func(t *testing.T) {
+		s := open(t)
+		if err := s.LoginTokenService.CreateLoginToken(context.Background(), &wtf.LoginToken{Email: "jane"}); wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Invalid email address.` {
+			t.Fatal(err)
+		}
+	})
+}
+
+func testLoginTokenService_RedeemLoginToken(t *testing.T, open OpenFunc) {
+	// Ensure a token returns its email & can only be used once.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		token := MustCreateLoginToken(t, context.Background(), s, &wtf.LoginToken{Email: "jane@example.com"})
+
+		if other, err := s.LoginTokenService.RedeemLoginToken(context.Background(), token.Token); err != nil {
+			t.Fatal(err)
+		} else if got, want := other.ID, token.ID; got != want {
+			t.Fatalf("ID=%v, want %v", got, want)
+		} else if got, want := other.Email, "jane@example.com"; got != want {
+			t.Fatalf("Email=%v, want %v", got, want)
+		} else if other.UsedAt == nil {
+			t.Fatal("expected used at")
+		} else if other.Token != "" {
+			t.Fatal("unexpected plaintext token")
+		}
+
+		if _, err := s.LoginTokenService.RedeemLoginToken(context.Background(), token.Token); wtf.ErrorCode(err) != wtf.EUNAUTHORIZED || wtf.ErrorMessage(err) != `Login token already used.` {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure an unknown token returns an error.
+	t.Run("ErrNotFound", func(t *testing.T) {
+		s := open(t)
+		if _, err := s.LoginTokenService.RedeemLoginToken(context.Background(), "NO_SUCH_TOKEN"); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure a token cannot be used after it expires.
+	t.Run("ErrExpired", func(t *testing.T) {
+		s := open(t)
+		t0 := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		setNow(t, s, t0)
+
+		token := MustCreateLoginToken(t, context.Background(), s, &wtf.LoginToken{Email: "jane@example.com"})
+
+		setNow(t, s, t0.Add(wtf.LoginTokenDuration))
+		if _, err := s.LoginTokenService.RedeemLoginToken(context.Background(), token.Token); wtf.ErrorCode(err) != wtf.EUNAUTHORIZED || wtf.ErrorMessage(err) != `Login token expired.` {
+			t.Fatal(err)
+		}
+	})
+}
+
+// MustCreateLoginToken creates a login token. Fatal on error.
+func MustCreateLoginToken(tb testing.TB, ctx context.Context, s *Services, token *wtf.LoginToken) *wtf.LoginToken {
+	tb.Helper()
+	if err := s.LoginTokenService.CreateLoginToken(ctx, token); err != nil {
+		tb.Fatal(err)
+	}
+	return token
+}
@@ -0,0 +1,333 @@
+package wtftest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benbjohnson/wtf"
+)
+
+func testOrganizationService(t *testing.T, open OpenFunc) {
+	t.Run("CreateOrganization", func(t *testing.T) { testOrganizationService_CreateOrganization(t, open) })
+	t.Run("FindOrganizations", func(t *testing.T) { testOrganizationService_FindOrganizations(t, open) })
+	t.Run("UpdateOrganization", func(t *testing.T) { testOrganizationService_UpdateOrganization(t, open) })
+	t.Run("DeleteOrganization", func(t *testing.T) { testOrganizationService_DeleteOrganization(t, open) })
+	t.Run("CreateOrganizationMember", func(t *testing.T) { testOrganizationService_CreateOrganizationMember(t, open) })
+	t.Run("DeleteOrganizationMember", func(t *testing.T) { testOrganizationService_DeleteOrganizationMember(t, open) })
+}
+
+func testOrganizationService_CreateOrganization(t *testing.T, open OpenFunc) {
+	// Ensure an organization can be created & the owner is added as a member.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		user0, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+
+		org := &wtf.Organization{Name: "Acme"}
+		if err := s.OrganizationService.CreateOrganization(ctx0, org); err != nil {
+			t.Fatal(err)
+		} else if got, want := org.ID, 1; got != want {
+			t.Fatalf("ID=%v, want %v", got, want)
+		} else if got, want := org.UserID, user0.ID; got != want {
+			t.Fatalf("UserID=%v, want %v", got, want)
+		} else if got, want := org.Role, wtf.OrganizationRoleOwner; got != want {
+			t.Fatalf("Role=%v, want %v", got, want)
+		} else if org.InviteCode == "" {
+			t.Fatal("expected invite code")
+		} else if org.CreatedAt.IsZero() {
+			t.Fatal("expected created at")
+		}
+
+		// Fetch organization from database & verify membership.
+		other := MustFindOrganizationByID(t, ctx0, s, org.ID)
+		if got, want := other.Name, "Acme"; got != want {
+			t.Fatalf("Name=%v, want %v", got, want)
+		} else if got, want := len(other.Members), 1; got != want {
+			t.Fatalf("len(Members)=%v, want %v", got, want)
+		} else if got, want := other.Members[0].UserID, user0.ID; got != want {
+			t.Fatalf("Members[0].UserID=%v, want %v", got, want)
+		} else if got, want := other.Members[0].Role, wtf.OrganizationRoleOwner; got != want {
+			t.Fatalf("Members[0].Role=%v, want %v", got, want)
+		} else if other.Members[0].User == nil || other.Members[0].User.Name != "jane" {
+			t.Fatalf("unexpected member user: %#v", other.Members[0].User)
+		}
+	})
+
+	// Ensure an error is returned if the name is not set.
+	t.Run("ErrNameRequired", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		if err := s.OrganizationService.CreateOrganization(ctx0, &wtf.Organization{}); wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Organization name required.` {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure an error is returned if there is no current user.
+	t.Run("ErrUserRequired", func(t *testing.T) {
+		s := open(t)
+		if err := s.OrganizationService.CreateOrganization(context.Background(), &wtf.Organization{Name: "Acme"}); wtf.ErrorCode(err) != wtf.EUNAUTHORIZED {
+			t.Fatal(err)
+		}
+	})
+}
+
+func testOrganizationService_FindOrganizations(t *testing.T, open OpenFunc) {
+	// Ensure only organizations the user belongs to are returned.
+	t.Run("MemberOnly", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+
+		org0 := MustCreateOrganization(t, ctx0, s, &wtf.Organization{Name: "B"})
+		MustCreateOrganization(t, ctx0, s, &wtf.Organization{Name: "C"})
+		MustCreateOrganization(t, ctx1, s, &wtf.Organization{Name: "A"})
+		MustCreateOrganizationMember(t, ctx1, s, &wtf.OrganizationMember{OrganizationID: org0.ID, InviteCode: org0.InviteCode})
+
+		if a, n, err := s.OrganizationService.FindOrganizations(ctx1, wtf.OrganizationFilter{}); err != nil {
+			t.Fatal(err)
+		} else if got, want := n, 2; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		} else if got, want := a[0].Name, "A"; got != want {
+			t.Fatalf("Name=%v, want %v", got, want)
+		} else if got, want := a[0].Role, wtf.OrganizationRoleOwner; got != want {
+			t.Fatalf("Role=%v, want %v", got, want)
+		} else if got, want := a[1].Name, "B"; got != want {
+			t.Fatalf("Name=%v, want %v", got, want)
+		} else if got, want := a[1].Role, wtf.OrganizationRoleMember; got != want {
+			t.Fatalf("Role=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure an organization can be found by invite code by a non-member.
+	t.Run("InviteCode", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+		org := MustCreateOrganization(t, ctx0, s, &wtf.Organization{Name: "Acme"})
+
+		if a, _, err := s.OrganizationService.FindOrganizations(ctx1, wtf.OrganizationFilter{InviteCode: &org.InviteCode}); err != nil {
+			t.Fatal(err)
+		} else if got, want := len(a), 1; got != want {
+			t.Fatalf("len=%v, want %v", got, want)
+		} else if got, want := a[0].ID, org.ID; got != want {
+			t.Fatalf("ID=%v, want %v", got, want)
+		} else if got, want := a[0].Role, ""; got != want {
+			t.Fatalf("Role=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure a non-member cannot see an organization by ID.
+	t.Run("ErrNotFound", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+		org := MustCreateOrganization(t, ctx0, s, &wtf.Organization{Name: "Acme"})
+
+		if _, err := s.OrganizationService.FindOrganizationByID(ctx1, org.ID); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatal(err)
+		}
+	})
+}
+
+func testOrganizationService_UpdateOrganization(t *testing.T, open OpenFunc) {
+	// Ensure the owner can rename an organization.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		org := MustCreateOrganization(t, ctx0, s, &wtf.Organization{Name: "Acme"})
+
+		newName := "Acme Corp"
+		if other, err := s.OrganizationService.UpdateOrganization(ctx0, org.ID, wtf.OrganizationUpdate{Name: &newName}); err != nil {
+			t.Fatal(err)
+		} else if got, want := other.Name, "Acme Corp"; got != want {
+			t.Fatalf("Name=%v, want %v", got, want)
+		} else if got, want := MustFindOrganizationByID(t, ctx0, s, org.ID).Name, "Acme Corp"; got != want {
+			t.Fatalf("Name=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure a member who is not the owner cannot update the organization.
+	t.Run("ErrUnauthorized", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+		org := MustCreateOrganization(t, ctx0, s, &wtf.Organization{Name: "Acme"})
+		MustCreateOrganizationMember(t, ctx1, s, &wtf.OrganizationMember{OrganizationID: org.ID, InviteCode: org.InviteCode})
+
+		newName := "Evil Corp"
+		if _, err := s.OrganizationService.UpdateOrganization(ctx1, org.ID, wtf.OrganizationUpdate{Name: &newName}); wtf.ErrorCode(err) != wtf.EUNAUTHORIZED {
+			t.Fatal(err)
+		}
+	})
+}
+
+func testOrganizationService_DeleteOrganization(t *testing.T, open OpenFunc) {
+	// Ensure an organization can be deleted while its dials are kept.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		org := MustCreateOrganization(t, ctx0, s, &wtf.Organization{Name: "Acme"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL", OrganizationID: org.ID})
+
+		if err := s.OrganizationService.DeleteOrganization(ctx0, org.ID); err != nil {
+			t.Fatal(err)
+		} else if _, err := s.OrganizationService.FindOrganizationByID(ctx0, org.ID); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatal(err)
+		} else if got, want := MustFindDialByID(t, ctx0, s, dial.ID).OrganizationID, 0; got != want {
+			t.Fatalf("OrganizationID=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure a member who is not the owner cannot delete the organization.
+	t.Run("ErrUnauthorized", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+		org := MustCreateOrganization(t, ctx0, s, &wtf.Organization{Name: "Acme"})
+		MustCreateOrganizationMember(t, ctx1, s, &wtf.OrganizationMember{OrganizationID: org.ID, InviteCode: org.InviteCode})
+
+		if err := s.OrganizationService.DeleteOrganization(ctx1, org.ID); wtf.ErrorCode(err) != wtf.EUNAUTHORIZED {
+			t.Fatal(err)
+		}
+	})
+}
+
+func testOrganizationService_CreateOrganizationMember(t *testing.T, open OpenFunc) {
+	// Ensure a user can join an organization with its invite code.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		user1, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+		org := MustCreateOrganization(t, ctx0, s, &wtf.Organization{Name: "Acme"})
+
+		member := &wtf.OrganizationMember{OrganizationID: org.ID, InviteCode: org.InviteCode}
+		if err := s.OrganizationService.CreateOrganizationMember(ctx1, member); err != nil {
+			t.Fatal(err)
+		} else if member.ID == 0 {
+			t.Fatal("expected id")
+		} else if got, want := member.UserID, user1.ID; got != want {
+			t.Fatalf("UserID=%v, want %v", got, want)
+		} else if got, want := member.Role, wtf.OrganizationRoleMember; got != want {
+			t.Fatalf("Role=%v, want %v", got, want)
+		}
+
+		// Ensure the new member can see the organization & its members.
+		if other := MustFindOrganizationByID(t, ctx1, s, org.ID); len(other.Members) != 2 {
+			t.Fatalf("len(Members)=%v, want 2", len(other.Members))
+		} else if got, want := other.Members[0].Role, wtf.OrganizationRoleOwner; got != want {
+			t.Fatalf("Members[0].Role=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure a user cannot join with the wrong invite code.
+	t.Run("ErrInvalidInviteCode", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+		org := MustCreateOrganization(t, ctx0, s, &wtf.Organization{Name: "Acme"})
+
+		if err := s.OrganizationService.CreateOrganizationMember(ctx1, &wtf.OrganizationMember{OrganizationID: org.ID, InviteCode: "XXX"}); wtf.ErrorCode(err) != wtf.EINVALID {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure a user cannot join the same organization twice.
+	t.Run("ErrConflict", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		org := MustCreateOrganization(t, ctx0, s, &wtf.Organization{Name: "Acme"})
+
+		if err := s.OrganizationService.CreateOrganizationMember(ctx0, &wtf.OrganizationMember{OrganizationID: org.ID, InviteCode: org.InviteCode}); wtf.ErrorCode(err) != wtf.ECONFLICT {
+			t.Fatal(err)
+		}
+	})
+}
+
+func testOrganizationService_DeleteOrganizationMember(t *testing.T, open OpenFunc) {
+	// Ensure a member can leave an organization & loses access to its dials.
+	t.Run("Leave", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+		org := MustCreateOrganization(t, ctx0, s, &wtf.Organization{Name: "Acme"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL", OrganizationID: org.ID})
+		member := MustCreateOrganizationMember(t, ctx1, s, &wtf.OrganizationMember{OrganizationID: org.ID, InviteCode: org.InviteCode})
+
+		if err := s.OrganizationService.DeleteOrganizationMember(ctx1, member.ID); err != nil {
+			t.Fatal(err)
+		} else if _, err := s.OrganizationService.FindOrganizationByID(ctx1, org.ID); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatal(err)
+		} else if _, err := s.DialService.FindDialByID(ctx1, dial.ID); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure the owner can remove another member.
+	t.Run("RemoveByOwner", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+		org := MustCreateOrganization(t, ctx0, s, &wtf.Organization{Name: "Acme"})
+		member := MustCreateOrganizationMember(t, ctx1, s, &wtf.OrganizationMember{OrganizationID: org.ID, InviteCode: org.InviteCode})
+
+		if err := s.OrganizationService.DeleteOrganizationMember(ctx0, member.ID); err != nil {
+			t.Fatal(err)
+		} else if got, want := len(MustFindOrganizationByID(t, ctx0, s, org.ID).Members), 1; got != want {
+			t.Fatalf("len(Members)=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure the owner cannot leave their own organization.
+	t.Run("ErrOwner", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		org := MustCreateOrganization(t, ctx0, s, &wtf.Organization{Name: "Acme"})
+		owner := MustFindOrganizationByID(t, ctx0, s, org.ID).Members[0]
+
+		if err := s.OrganizationService.DeleteOrganizationMember(ctx0, owner.ID); wtf.ErrorCode(err) != wtf.EUNAUTHORIZED {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure a member cannot remove another member.
+	t.Run("ErrUnauthorized", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+		_, ctx2 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jim"})
+		org := MustCreateOrganization(t, ctx0, s, &wtf.Organization{Name: "Acme"})
+		MustCreateOrganizationMember(t, ctx1, s, &wtf.OrganizationMember{OrganizationID: org.ID, InviteCode: org.InviteCode})
+		member := MustCreateOrganizationMember(t, ctx2, s, &wtf.OrganizationMember{OrganizationID: org.ID, InviteCode: org.InviteCode})
+
+		if err := s.OrganizationService.DeleteOrganizationMember(ctx1, member.ID); wtf.ErrorCode(err) != wtf.EUNAUTHORIZED {
+			t.Fatal(err)
+		}
+	})
+}
+
+// MustCreateOrganization creates an organization. Fatal on error.
+func MustCreateOrganization(tb testing.TB, ctx context.Context, s *Services, org *wtf.Organization) *wtf.Organization {
+	tb.Helper()
+	if err := s.OrganizationService.CreateOrganization(ctx, org); err != nil {
+		tb.Fatal(err)
+	}
+	return org
+}
+
+// MustFindOrganizationByID finds an organization by ID. Fatal on error.
+func MustFindOrganizationByID(tb testing.TB, ctx context.Context, s *Services, id int) *wtf.Organization {
+	tb.Helper()
+	org, err := s.OrganizationService.FindOrganizationByID(ctx, id)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return org
+}
+
+// MustCreateOrganizationMember joins an organization. Fatal on error.
+func MustCreateOrganizationMember(tb testing.TB, ctx context.Context, s *Services, member *wtf.OrganizationMember) *wtf.OrganizationMember {
+	tb.Helper()
+	if err := s.OrganizationService.CreateOrganizationMember(ctx, member); err != nil {
+		tb.Fatal(err)
+	}
+	return member
+}
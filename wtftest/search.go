@@ -0,0 +1,184 @@
+package wtftest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/benbjohnson/wtf"
+)
+
+func testSearchService(t *testing.T, open OpenFunc) {
+	t.Run("Search", func(t *testing.T) { testSearchService_Search(t, open) })
+}
+
+func testSearchService_Search(t *testing.T, open OpenFunc) {
+	// Ensure dial names, member names & notes can be searched.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "Jane Smith"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "John Doe"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "API Latency"})
+		membership1 := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, InviteCode: dial.InviteCode})
+		if err := s.DialService.SetDialMembershipValue(ctx1, dial.ID, 80, "Database failover in progress"); err != nil {
+			t.Fatal(err)
+		}
+
+		// Ensure the dial name matches by word prefix.
+		if results, n := MustSearch(t, ctx0, s, "latenc"); n != 1 {
+			t.Fatalf("n=%v, want 1", n)
+		} else if got, want := results[0].DialID, dial.ID; got != want {
+			t.Fatalf("DialID=%v, want %v", got, want)
+		} else if got, want := results[0].DialMembershipID, 0; got != want {
+			t.Fatalf("DialMembershipID=%v, want %v", got, want)
+		} else if got, want := results[0].Dial.Name, "API Latency"; got != want {
+			t.Fatalf("Dial.Name=%v, want %v", got, want)
+		}
+
+		// Ensure a membership note matches & every term must match.
+		if results, n := MustSearch(t, ctx0, s, "FAILOVER database"); n != 1 {
+			t.Fatalf("n=%v, want 1", n)
+		} else if got, want := results[0].DialMembershipID, membership1.ID; got != want {
+			t.Fatalf("DialMembershipID=%v, want %v", got, want)
+		} else if got, want := results[0].Name, "John Doe"; got != want {
+			t.Fatalf("Name=%v, want %v", got, want)
+		} else if got, want := results[0].Note, "Database failover in progress"; got != want {
+			t.Fatalf("Note=%v, want %v", got, want)
+		}
+		if _, n := MustSearch(t, ctx0, s, "failover latency"); n != 0 {
+			t.Fatalf("n=%v, want 0", n)
+		}
+
+		// Ensure member names match the owner & other members.
+		results, n := MustSearch(t, ctx1, s, "j")
+		if n != 2 {
+			t.Fatalf("n=%v, want 2", n)
+		}
+		var names []string
+		for _, result := range results {
+			names = append(names, result.Name)
+		}
+		if sort.Strings(names); !reflect.DeepEqual(names, []string{"Jane Smith", "John Doe"}) {
+			t.Fatalf("names=%v", names)
+		}
+	})
+
+	// Ensure results only include dials the user can see. Organization members
+	// can see the organization's dials but not the dial's memberships.
+	t.Run("Visibility", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+		_, ctx2 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jim"})
+		org := MustCreateOrganization(t, ctx0, s, &wtf.Organization{Name: "Acme"})
+		MustCreateOrganizationMember(t, ctx1, s, &wtf.OrganizationMember{OrganizationID: org.ID, InviteCode: org.InviteCode})
+
+		dial0 := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "Release Train", OrganizationID: org.ID})
+		MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "Release Private"})
+
+		if got, want := searchResultKeys(MustSearch(t, ctx1, s, "release")), []string{fmt.Sprintf("%d/0", dial0.ID)}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("results=%v, want %v", got, want)
+		} else if _, n := MustSearch(t, ctx1, s, "jane"); n != 0 {
+			t.Fatalf("n=%v, want 0", n)
+		} else if _, n := MustSearch(t, ctx2, s, "release"); n != 0 {
+			t.Fatalf("n=%v, want 0", n)
+		}
+	})
+
+	// Ensure the index is updated when dials, memberships & users change.
+	t.Run("Reindex", func(t *testing.T) {
+		s := open(t)
+		user0, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "Oncall"})
+		membership := MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, InviteCode: dial.InviteCode})
+
+		// Rename the dial.
+		name := "Pager Fatigue"
+		if _, err := s.DialService.UpdateDial(ctx0, dial.ID, wtf.DialUpdate{Name: &name}); err != nil {
+			t.Fatal(err)
+		} else if _, n := MustSearch(t, ctx0, s, "oncall"); n != 0 {
+			t.Fatalf("n=%v, want 0", n)
+		} else if _, n := MustSearch(t, ctx0, s, "pager"); n != 1 {
+			t.Fatalf("n=%v, want 1", n)
+		}
+
+		// Rename the user.
+		userName := "Janet"
+		if _, err := s.UserService.UpdateUser(ctx0, user0.ID, wtf.UserUpdate{Name: &userName}); err != nil {
+			t.Fatal(err)
+		} else if _, n := MustSearch(t, ctx0, s, "janet"); n != 1 {
+			t.Fatalf("n=%v, want 1", n)
+		}
+
+		// Remove the member.
+		if err := s.DialMembershipService.DeleteDialMembership(ctx0, membership.ID); err != nil {
+			t.Fatal(err)
+		} else if _, n := MustSearch(t, ctx0, s, "john"); n != 0 {
+			t.Fatalf("n=%v, want 0", n)
+		}
+
+		// Delete the dial.
+		if err := s.DialService.DeleteDial(ctx0, dial.ID); err != nil {
+			t.Fatal(err)
+		} else if _, n := MustSearch(t, ctx0, s, "pager"); n != 0 {
+			t.Fatalf("n=%v, want 0", n)
+		}
+	})
+
+	// Ensure search results can be paginated.
+	t.Run("LimitOffset", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "dial one"})
+		MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "dial two"})
+		MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "dial three"})
+
+		results, n, err := s.SearchService.Search(ctx0, wtf.SearchFilter{Query: "dial", Offset: 1, Limit: 1})
+		skipIfNotImplemented(t, err)
+		if err != nil {
+			t.Fatal(err)
+		} else if got, want := len(results), 1; got != want {
+			t.Fatalf("len=%v, want %v", got, want)
+		} else if got, want := n, 3; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure an error is returned if the query has no search terms.
+	t.Run("ErrQueryRequired", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+
+		_, _, err := s.SearchService.Search(ctx0, wtf.SearchFilter{Query: " -- "})
+		skipIfNotImplemented(t, err)
+		if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != "Search query required." {
+			t.Fatal(err)
+		}
+	})
+}
+
+// MustSearch returns all search results for a query. Skips the test if search
+// is not implemented. Fatal on error.
+func MustSearch(tb testing.TB, ctx context.Context, s *Services, query string) ([]*wtf.SearchResult, int) {
+	tb.Helper()
+	results, n, err := s.SearchService.Search(ctx, wtf.SearchFilter{Query: query})
+	skipIfNotImplemented(tb, err)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return results, n
+}
+
+// searchResultKeys returns a sorted list of "DIALID/MEMBERSHIPID" keys for
+// comparing results independent of their relevance order.
+func searchResultKeys(results []*wtf.SearchResult, _ int) []string {
+	a := make([]string, 0, len(results))
+	for _, result := range results {
+		a = append(a, fmt.Sprintf("%d/%d", result.DialID, result.DialMembershipID))
+	}
+	sort.Strings(a)
+	return a
+}
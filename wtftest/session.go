@@ -0,0 +1,280 @@
+package wtftest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+)
+
+func testSessionService(t *testing.T, open OpenFunc) {
+	t.Run("CreateSession", func(t *testing.T) { testSessionService_CreateSession(t, open) })
+	t.Run("FindSessions", func(t *testing.T) { testSessionService_FindSessions(t, open) })
+	t.Run("AuthenticateSession", func(t *testing.T) { testSessionService_AuthenticateSession(t, open) })
+	t.Run("DeleteSession", func(t *testing.T) { testSessionService_DeleteSession(t, open) })
+	t.Run("DeleteAllSessions", func(t *testing.T) { testSessionService_DeleteAllSessions(t, open) })
+}
+
+func testSessionService_CreateSession(t *testing.T, open OpenFunc) {
+	// Ensure a session can be created with client details & an expiration.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		user0, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+
+		session := &wtf.Session{Device: "Firefox on Linux", IPAddress: "127.0.0.1", UserAgent: "Mozilla/5.0"}
+		if err := s.SessionService.CreateSession(ctx0, session); err != nil {
+			t.Fatal(err)
+		} else if got, want := session.ID, 1; got != want {
+			t.Fatalf("ID=%v, want %v", got, want)
+		} else if got, want := session.UserID, user0.ID; got != want {
+			t.Fatalf("UserID=%v, want %v", got, want)
+		} else if got, want := session.ExpiresAt, session.CreatedAt.Add(wtf.SessionDuration); !got.Equal(want) {
+			t.Fatalf("ExpiresAt=%v, want %v", got, want)
+		} else if session.LastSeenAt.IsZero() {
+			t.Fatal("expected last seen at")
+		}
+
+		// Fetch session from database & compare.
+		if a, _, err := s.SessionService.FindSessions(ctx0, wtf.SessionFilter{}); err != nil {
+			t.Fatal(err)
+		} else if got, want := len(a), 1; got != want {
+			t.Fatalf("len=%v, want %v", got, want)
+		} else if got, want := a[0].Device, "Firefox on Linux"; got != want {
+			t.Fatalf("Device=%v, want %v", got, want)
+		} else if got, want := a[0].IPAddress, "127.0.0.1"; got != want {
+			t.Fatalf("IPAddress=%v, want %v", got, want)
+		} else if got, want := a[0].UserAgent, "Mozilla/5.0"; got != want {
+			t.Fatalf("UserAgent=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure expired sessions of all users are removed on create.
+	t.Run("PurgeExpired", func(t *testing.T) {
+		s := open(t)
+		t0 := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		setNow(t, s, t0)
+
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+		expired := MustCreateSession(t, ctx0, s, &wtf.Session{Device: "A"})
+
+		setNow(t, s, t0.Add(time.Hour))
+		active := MustCreateSession(t, ctx0, s, &wtf.Session{Device: "B"})
+
+		setNow(t, s, t0.Add(wtf.SessionDuration))
+		MustCreateSession(t, ctx1, s, &wtf.Session{Device: "C"})
+
+		if _, err := s.SessionService.AuthenticateSession(context.Background(), expired.ID); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatalf("unexpected error: %v", err)
+		} else if _, err := s.SessionService.AuthenticateSession(context.Background(), active.ID); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure a session cannot be created without a user.
+	t.Run("ErrUnauthorized", func(t *testing.T) {
+		s := open(t)
+		if err := s.SessionService.CreateSession(context.Background(), &wtf.Session{}); wtf.ErrorCode(err) != wtf.EUNAUTHORIZED {
+			t.Fatal(err)
+		}
+	})
+}
+
+func testSessionService_FindSessions(t *testing.T, open OpenFunc) {
+	// Ensure only the current user's sessions are returned.
+	t.Run("OwnOnly", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+
+		MustCreateSession(t, ctx0, s, &wtf.Session{Device: "A"})
+		MustCreateSession(t, ctx1, s, &wtf.Session{Device: "B"})
+		MustCreateSession(t, ctx1, s, &wtf.Session{Device: "C"})
+
+		if a, n, err := s.SessionService.FindSessions(ctx1, wtf.SessionFilter{Limit: 1}); err != nil {
+			t.Fatal(err)
+		} else if got, want := len(a), 1; got != want {
+			t.Fatalf("len=%v, want %v", got, want)
+		} else if got, want := a[0].UserID, 2; got != want {
+			t.Fatalf("UserID=%v, want %v", got, want)
+		} else if got, want := n, 2; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure expired sessions are not listed.
+	t.Run("Expired", func(t *testing.T) {
+		s := open(t)
+		t0 := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		setNow(t, s, t0)
+
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		MustCreateSession(t, ctx0, s, &wtf.Session{Device: "A"})
+
+		setNow(t, s, t0.Add(wtf.SessionDuration))
+		if _, n, err := s.SessionService.FindSessions(ctx0, wtf.SessionFilter{}); err != nil {
+			t.Fatal(err)
+		} else if n != 0 {
+			t.Fatalf("n=%d, want 0", n)
+		}
+	})
+}
+
+func testSessionService_AuthenticateSession(t *testing.T, open OpenFunc) {
+	// Ensure a session returns its user & records when it was last seen.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		t0 := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		setNow(t, s, t0)
+
+		user0, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		session := MustCreateSession(t, ctx0, s, &wtf.Session{Device: "A"})
+
+		t1 := t0.Add(time.Hour)
+		setNow(t, s, t1)
+		if other, err := s.SessionService.AuthenticateSession(context.Background(), session.ID); err != nil {
+			t.Fatal(err)
+		} else if other.User == nil || other.User.ID != user0.ID {
+			t.Fatalf("unexpected user: %#v", other.User)
+		} else if got, want := other.LastSeenAt, t1; !got.Equal(want) {
+			t.Fatalf("LastSeenAt=%v, want %v", got, want)
+		}
+
+		if a, _, err := s.SessionService.FindSessions(ctx0, wtf.SessionFilter{ID: &session.ID}); err != nil {
+			t.Fatal(err)
+		} else if got, want := a[0].LastSeenAt, t1; !got.Equal(want) {
+			t.Fatalf("LastSeenAt=%v, want %v", got, want)
+		} else if a[0].User != nil {
+			t.Fatalf("unexpected user: %#v", a[0].User)
+		}
+	})
+
+	// Ensure an unknown session returns an error.
+	t.Run("ErrNotFound", func(t *testing.T) {
+		s := open(t)
+		if _, err := s.SessionService.AuthenticateSession(context.Background(), 1); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure a session cannot be used after it expires.
+	t.Run("ErrExpired", func(t *testing.T) {
+		s := open(t)
+		t0 := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		setNow(t, s, t0)
+
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		session := MustCreateSession(t, ctx0, s, &wtf.Session{Device: "A"})
+
+		setNow(t, s, t0.Add(wtf.SessionDuration))
+		if _, err := s.SessionService.AuthenticateSession(context.Background(), session.ID); wtf.ErrorCode(err) != wtf.EUNAUTHORIZED || wtf.ErrorMessage(err) != `Session expired.` {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure sessions are invalidated when their user is deleted.
+	t.Run("DeleteUser", func(t *testing.T) {
+		s := open(t)
+		user0, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		session := MustCreateSession(t, ctx0, s, &wtf.Session{Device: "A"})
+
+		if err := s.UserService.DeleteUser(ctx0, user0.ID); err != nil {
+			t.Fatal(err)
+		} else if _, err := s.SessionService.AuthenticateSession(context.Background(), session.ID); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure sessions are invalidated when the auth used to log in is deleted.
+	t.Run("DeleteAuth", func(t *testing.T) {
+		s := open(t)
+		auth0, ctx0 := MustCreateAuth(t, context.Background(), s, &wtf.Auth{
+			Source:      wtf.AuthSourceGitHub,
+			SourceID:    "X",
+			AccessToken: "X", User: &wtf.User{Name: "X"},
+		})
+		session := MustCreateSession(t, ctx0, s, &wtf.Session{AuthID: auth0.ID})
+		other := MustCreateSession(t, ctx0, s, &wtf.Session{})
+
+		// Ensure the user's auths are attached so their avatar can be displayed.
+		if v, err := s.SessionService.AuthenticateSession(context.Background(), session.ID); err != nil {
+			t.Fatal(err)
+		} else if got, want := len(v.User.Auths), 1; got != want {
+			t.Fatalf("len(Auths)=%v, want %v", got, want)
+		}
+
+		if err := s.AuthService.DeleteAuth(ctx0, auth0.ID); err != nil {
+			t.Fatal(err)
+		} else if _, err := s.SessionService.AuthenticateSession(context.Background(), session.ID); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatal(err)
+		} else if _, err := s.SessionService.AuthenticateSession(context.Background(), other.ID); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func testSessionService_DeleteSession(t *testing.T, open OpenFunc) {
+	// Ensure a revoked session can no longer be used.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		session := MustCreateSession(t, ctx0, s, &wtf.Session{Device: "A"})
+
+		if err := s.SessionService.DeleteSession(ctx0, session.ID); err != nil {
+			t.Fatal(err)
+		} else if _, err := s.SessionService.AuthenticateSession(context.Background(), session.ID); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure a user cannot revoke another user's session.
+	t.Run("ErrNotFound", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+		session := MustCreateSession(t, ctx0, s, &wtf.Session{Device: "A"})
+
+		if err := s.SessionService.DeleteSession(ctx1, session.ID); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatal(err)
+		}
+	})
+}
+
+func testSessionService_DeleteAllSessions(t *testing.T, open OpenFunc) {
+	// Ensure all of the current user's sessions are revoked but other users'
+	// sessions are left alone.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+		MustCreateSession(t, ctx0, s, &wtf.Session{Device: "A"})
+		MustCreateSession(t, ctx0, s, &wtf.Session{Device: "B"})
+		MustCreateSession(t, ctx1, s, &wtf.Session{Device: "C"})
+
+		if err := s.SessionService.DeleteAllSessions(ctx0); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, n, err := s.SessionService.FindSessions(ctx0, wtf.SessionFilter{}); err != nil {
+			t.Fatal(err)
+		} else if n != 0 {
+			t.Fatalf("n=%d, want 0", n)
+		}
+
+		if _, n, err := s.SessionService.FindSessions(ctx1, wtf.SessionFilter{}); err != nil {
+			t.Fatal(err)
+		} else if n != 1 {
+			t.Fatalf("n=%d, want 1", n)
+		}
+	})
+}
+
+// MustCreateSession creates a session. Fatal on error.
+func MustCreateSession(tb testing.TB, ctx context.Context, s *Services, session *wtf.Session) *wtf.Session {
+	tb.Helper()
+	if err := s.SessionService.CreateSession(ctx, session); err != nil {
+		tb.Fatal(err)
+	}
+	return session
+}
@@ -0,0 +1,280 @@
+package wtftest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/benbjohnson/wtf"
+)
+
+func testUserService(t *testing.T, open OpenFunc) {
+	t.Run("CreateUser", func(t *testing.T) { testUserService_CreateUser(t, open) })
+	t.Run("UpdateUser", func(t *testing.T) { testUserService_UpdateUser(t, open) })
+	t.Run("DeleteUser", func(t *testing.T) { testUserService_DeleteUser(t, open) })
+	t.Run("FindUser", func(t *testing.T) { testUserService_FindUser(t, open) })
+	t.Run("FindUsers", func(t *testing.T) { testUserService_FindUsers(t, open) })
+}
+
+func testUserService_CreateUser(t *testing.T, open OpenFunc) {
+	// Ensure user can be created.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		u := &wtf.User{
+			Name:  "susy",
+			Email: "susy@gmail.com",
+		}
+
+		// Create new user & verify ID and timestamps are set.
+		if err := s.UserService.CreateUser(context.Background(), u); err != nil {
+			t.Fatal(err)
+		} else if got, want := u.ID, 1; got != want {
+			t.Fatalf("ID=%v, want %v", got, want)
+		} else if u.CreatedAt.IsZero() {
+			t.Fatal("expected created at")
+		} else if u.UpdatedAt.IsZero() {
+			t.Fatal("expected updated at")
+		}
+
+		// Create second user without email.
+		u2 := &wtf.User{Name: "jane"}
+		if err := s.UserService.CreateUser(context.Background(), u2); err != nil {
+			t.Fatal(err)
+		} else if got, want := u2.ID, 2; got != want {
+			t.Fatalf("ID=%v, want %v", got, want)
+		}
+
+		// Fetch user & compare.
+		if other, err := s.UserService.FindUserByID(newContextWithUser(context.Background(), s, u), 1); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(u, other) {
+			t.Fatalf("mismatch: %#v != %#v", u, other)
+		}
+	})
+
+	// Ensure an error is returned if user name is not set.
+	t.Run("ErrNameRequired", func(t *testing.T) {
+		s := open(t)
+		if err := s.UserService.CreateUser(context.Background(), &wtf.User{}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `User name required.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+}
+
+func testUserService_UpdateUser(t *testing.T, open OpenFunc) {
+	// Ensure user name can be updated by current user but not their email.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		user0, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{
+			Name:  "susy",
+			Email: "susy@gmail.com",
+		})
+
+		// Update user.
+		newName := "jill"
+		uu, err := s.UserService.UpdateUser(ctx0, user0.ID, wtf.UserUpdate{
+			Name: &newName,
+		})
+		if err != nil {
+			t.Fatal(err)
+		} else if got, want := uu.Name, "jill"; got != want {
+			t.Fatalf("Name=%v, want %v", got, want)
+		} else if got, want := uu.Email, "susy@gmail.com"; got != want {
+			t.Fatalf("Email=%v, want %v", got, want)
+		}
+
+		// Fetch user & compare.
+		if other, err := s.UserService.FindUserByID(ctx0, 1); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(uu, other) {
+			t.Fatalf("mismatch: %#v != %#v", uu, other)
+		}
+	})
+
+	// Ensure the timezone preference can be updated.
+	t.Run("Timezone", func(t *testing.T) {
+		s := open(t)
+		user0, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "susy"})
+
+		if uu, err := s.UserService.UpdateUser(ctx0, user0.ID, wtf.UserUpdate{Timezone: stringPtr("America/Los_Angeles")}); err != nil {
+			t.Fatal(err)
+		} else if got, want := uu.Timezone, "America/Los_Angeles"; got != want {
+			t.Fatalf("Timezone=%v, want %v", got, want)
+		} else if got, want := uu.Location().String(), "America/Los_Angeles"; got != want {
+			t.Fatalf("Location=%v, want %v", got, want)
+		}
+
+		// Fetch user & ensure the timezone was persisted.
+		if other, err := s.UserService.FindUserByID(ctx0, user0.ID); err != nil {
+			t.Fatal(err)
+		} else if got, want := other.Timezone, "America/Los_Angeles"; got != want {
+			t.Fatalf("Timezone=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure the preferred avatar source can be set to a linked account.
+	t.Run("AvatarSource", func(t *testing.T) {
+		s := open(t)
+		auth0, ctx0 := MustCreateAuth(t, context.Background(), s, &wtf.Auth{
+			Source:      wtf.AuthSourceGitHub,
+			SourceID:    "1000",
+			AccessToken: "ACCESS",
+			User:        &wtf.User{Name: "susy"},
+		})
+
+		if uu, err := s.UserService.UpdateUser(ctx0, auth0.UserID, wtf.UserUpdate{AvatarSource: stringPtr(wtf.AuthSourceGitHub)}); err != nil {
+			t.Fatal(err)
+		} else if got, want := uu.AvatarSource, wtf.AuthSourceGitHub; got != want {
+			t.Fatalf("AvatarSource=%v, want %v", got, want)
+		} else if got, want := uu.AvatarURL(100), auth0.AvatarURL(100); got != want {
+			t.Fatalf("AvatarURL=%v, want %v", got, want)
+		}
+
+		// Ensure a source without a linked account is rejected.
+		if _, err := s.UserService.UpdateUser(ctx0, auth0.UserID, wtf.UserUpdate{AvatarSource: stringPtr("corp")}); wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Avatar must be from a linked account.` {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure an unknown timezone returns an error.
+	t.Run("ErrInvalidTimezone", func(t *testing.T) {
+		s := open(t)
+		user0, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "susy"})
+
+		if _, err := s.UserService.UpdateUser(ctx0, user0.ID, wtf.UserUpdate{Timezone: stringPtr("Mars/Olympus_Mons")}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Invalid timezone.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure updating a user is restricted only to the current user.
+	t.Run("ErrUnauthorized", func(t *testing.T) {
+		s := open(t)
+		user0, _ := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "NAME0"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "NAME1"})
+
+		// Update user as another user.
+		newName := "NEWNAME"
+		if _, err := s.UserService.UpdateUser(ctx1, user0.ID, wtf.UserUpdate{Name: &newName}); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EUNAUTHORIZED || wtf.ErrorMessage(err) != `You are not allowed to update this user.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+}
+
+func testUserService_DeleteUser(t *testing.T, open OpenFunc) {
+	// Ensure user can delete self.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		user0, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+
+		// Delete user & ensure it is actually gone.
+		if err := s.UserService.DeleteUser(ctx0, user0.ID); err != nil {
+			t.Fatal(err)
+		} else if _, err := s.UserService.FindUserByID(ctx1, user0.ID); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure owned dials are removed along with the user.
+	t.Run("Cascade", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		user0, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial})
+
+		if err := s.UserService.DeleteUser(ctx0, user0.ID); err != nil {
+			t.Fatal(err)
+		} else if _, n, err := s.DialService.FindDials(ctx1, wtf.DialFilter{}); err != nil {
+			t.Fatal(err)
+		} else if n != 0 {
+			t.Fatalf("n=%d, want 0", n)
+		}
+	})
+
+	// Ensure an error is returned if deleting a non-existent user.
+	t.Run("ErrNotFound", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+		if err := s.UserService.DeleteUser(ctx0, 2); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	// Ensure deleting a user is restricted only to the current user.
+	t.Run("ErrUnauthorized", func(t *testing.T) {
+		s := open(t)
+		user0, _ := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "NAME0"})
+		_, ctx1 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "NAME1"})
+
+		if err := s.UserService.DeleteUser(ctx1, user0.ID); err == nil {
+			t.Fatal("expected error")
+		} else if wtf.ErrorCode(err) != wtf.EUNAUTHORIZED || wtf.ErrorMessage(err) != `You are not allowed to delete this user.` {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+}
+
+func testUserService_FindUser(t *testing.T, open OpenFunc) {
+	// Ensure an error is returned if fetching a non-existent user.
+	t.Run("ErrNotFound", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "john"})
+		if _, err := s.UserService.FindUserByID(ctx0, 2); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+}
+
+func testUserService_FindUsers(t *testing.T, open OpenFunc) {
+	// Ensure users can be fetched by email address.
+	t.Run("Email", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		MustCreateUser(t, ctx, s, &wtf.User{Name: "john", Email: "john@gmail.com"})
+		MustCreateUser(t, ctx, s, &wtf.User{Name: "jane", Email: "jane@gmail.com"})
+		MustCreateUser(t, ctx, s, &wtf.User{Name: "frank", Email: "frank@gmail.com"})
+		MustCreateUser(t, ctx, s, &wtf.User{Name: "sue", Email: "sue@gmail.com"})
+
+		email := "jane@gmail.com"
+		a, n, err := s.UserService.FindUsers(ctx, wtf.UserFilter{Email: &email})
+		skipIfNotImplemented(t, err)
+		if err != nil {
+			t.Fatal(err)
+		} else if got, want := len(a), 1; got != want {
+			t.Fatalf("len=%v, want %v", got, want)
+		} else if got, want := a[0].Name, "jane"; got != want {
+			t.Fatalf("name=%v, want %v", got, want)
+		} else if got, want := n, 1; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure the total count is returned when the results are paginated.
+	t.Run("LimitOffset", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		MustCreateUser(t, ctx, s, &wtf.User{Name: "frank"})
+
+		a, n, err := s.UserService.FindUsers(ctx, wtf.UserFilter{Offset: 1, Limit: 1})
+		skipIfNotImplemented(t, err)
+		if err != nil {
+			t.Fatal(err)
+		} else if got, want := len(a), 1; got != want {
+			t.Fatalf("len=%v, want %v", got, want)
+		} else if got, want := a[0].Name, "jane"; got != want {
+			t.Fatalf("name=%v, want %v", got, want)
+		} else if got, want := n, 3; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		}
+	})
+}
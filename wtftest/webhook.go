@@ -0,0 +1,454 @@
+package wtftest
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+)
+
+func testWebhookService(t *testing.T, open OpenFunc) {
+	t.Run("CreateWebhook", func(t *testing.T) { testWebhookService_CreateWebhook(t, open) })
+	t.Run("FindWebhooks", func(t *testing.T) { testWebhookService_FindWebhooks(t, open) })
+	t.Run("DeleteWebhook", func(t *testing.T) { testWebhookService_DeleteWebhook(t, open) })
+	t.Run("Deliveries", func(t *testing.T) { testWebhookService_Deliveries(t, open) })
+	t.Run("RecordWebhookDeliveryAttempt", func(t *testing.T) { testWebhookService_RecordWebhookDeliveryAttempt(t, open) })
+	t.Run("RedeliverWebhookDelivery", func(t *testing.T) { testWebhookService_RedeliverWebhookDelivery(t, open) })
+}
+
+func testWebhookService_CreateWebhook(t *testing.T, open OpenFunc) {
+	// Ensure the dial owner can create a webhook.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		webhook := &wtf.Webhook{DialID: dial.ID, URL: "https://example.com/hook", Secret: "SECRET", EventTypes: []string{wtf.EventTypeDialValueChanged}}
+		if err := s.WebhookService.CreateWebhook(ctx0, webhook); err != nil {
+			t.Fatal(err)
+		} else if got, want := webhook.ID, 1; got != want {
+			t.Fatalf("ID=%v, want %v", got, want)
+		} else if webhook.Dial == nil || webhook.Dial.ID != dial.ID {
+			t.Fatalf("unexpected dial: %#v", webhook.Dial)
+		} else if webhook.CreatedAt.IsZero() {
+			t.Fatal("expected created at")
+		}
+
+		// Fetch webhook & compare.
+		if other, err := s.WebhookService.FindWebhookByID(ctx0, webhook.ID); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(webhook, other) {
+			t.Fatalf("mismatch: %#v != %#v", webhook, other)
+		}
+	})
+
+	// Ensure a secret is generated if one is not provided.
+	t.Run("GenerateSecret", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		webhook := MustCreateWebhook(t, ctx0, s, &wtf.Webhook{DialID: dial.ID, URL: "https://example.com/hook"})
+		if webhook.Secret == "" {
+			t.Fatal("expected secret")
+		} else if len(webhook.EventTypes) != 0 {
+			t.Fatalf("unexpected event types: %v", webhook.EventTypes)
+		}
+	})
+
+	// Ensure only HTTP URLs are accepted.
+	t.Run("ErrInvalidURL", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		if err := s.WebhookService.CreateWebhook(ctx0, &wtf.Webhook{DialID: dial.ID, URL: "ftp://example.com"}); wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Webhook URL must be an HTTP or HTTPS URL.` {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure URLs pointing at local or private addresses are rejected.
+	t.Run("ErrPrivateURL", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		for _, u := range []string{
+			"http://localhost:6060/debug/pprof",
+			"http://127.0.0.1/hook",
+			"http://169.254.169.254/latest/meta-data",
+			"https://10.0.0.1/hook",
+			"http://[::1]/hook",
+		} {
+			if err := s.WebhookService.CreateWebhook(ctx0, &wtf.Webhook{DialID: dial.ID, URL: u, Secret: "SECRET"}); wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Webhook URL must not point to a local or private address.` {
+				t.Fatalf("%s: unexpected error: %#v", u, err)
+			}
+		}
+	})
+
+	// Ensure unknown event types are rejected.
+	t.Run("ErrInvalidEventType", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		if err := s.WebhookService.CreateWebhook(ctx0, &wtf.Webhook{DialID: dial.ID, URL: "https://example.com/hook", EventTypes: []string{"dial:exploded"}}); wtf.ErrorCode(err) != wtf.EINVALID || wtf.ErrorMessage(err) != `Invalid webhook event type.` {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure regular members cannot create webhooks.
+	t.Run("ErrUnauthorized", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial, InviteCode: dial.InviteCode})
+
+		if err := s.WebhookService.CreateWebhook(ctx1, &wtf.Webhook{DialID: dial.ID, URL: "https://example.com/hook"}); wtf.ErrorCode(err) != wtf.EUNAUTHORIZED {
+			t.Fatal(err)
+		}
+	})
+
+	// Ensure non-members cannot see the dial to create a webhook.
+	t.Run("ErrDialNotFound", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+
+		if err := s.WebhookService.CreateWebhook(ctx1, &wtf.Webhook{DialID: dial.ID, URL: "https://example.com/hook"}); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatal(err)
+		}
+	})
+}
+
+func testWebhookService_FindWebhooks(t *testing.T, open OpenFunc) {
+	// Ensure webhooks are only visible to the dial owner & admins as they
+	// include the signing secret.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		_, ctx2 := MustCreateUser(t, ctx, s, &wtf.User{Name: "frank"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial, InviteCode: dial.InviteCode})
+		membership := MustCreateDialMembership(t, ctx2, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial, InviteCode: dial.InviteCode})
+		MustSetDialMembershipRole(t, ctx0, s, membership.ID, wtf.DialMembershipRoleAdmin)
+
+		webhook := MustCreateWebhook(t, ctx0, s, &wtf.Webhook{DialID: dial.ID, URL: "https://example.com/0"})
+		MustCreateWebhook(t, ctx2, s, &wtf.Webhook{DialID: dial.ID, URL: "https://example.com/1"})
+
+		// The owner & admin can see all webhooks on the dial.
+		for _, ctx := range []context.Context{ctx0, ctx2} {
+			if webhooks, n, err := s.WebhookService.FindWebhooks(ctx, wtf.WebhookFilter{DialID: &dial.ID}); err != nil {
+				t.Fatal(err)
+			} else if got, want := n, 2; got != want {
+				t.Fatalf("n=%v, want %v", got, want)
+			} else if got, want := webhooks[1].URL, "https://example.com/1"; got != want {
+				t.Fatalf("URL=%v, want %v", got, want)
+			}
+		}
+
+		// Regular members cannot see any webhooks.
+		if _, n, err := s.WebhookService.FindWebhooks(ctx1, wtf.WebhookFilter{}); err != nil {
+			t.Fatal(err)
+		} else if got, want := n, 0; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		} else if _, err := s.WebhookService.FindWebhookByID(ctx1, webhook.ID); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatal(err)
+		}
+	})
+}
+
+func testWebhookService_DeleteWebhook(t *testing.T, open OpenFunc) {
+	// Ensure the dial owner can delete a webhook.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		webhook := MustCreateWebhook(t, ctx0, s, &wtf.Webhook{DialID: dial.ID, URL: "https://example.com/hook"})
+		if err := s.DialService.SetDialMembershipValue(ctx0, dial.ID, 50, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.WebhookService.DeleteWebhook(ctx0, webhook.ID); err != nil {
+			t.Fatal(err)
+		} else if _, err := s.WebhookService.FindWebhookByID(ctx0, webhook.ID); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		// Deliveries should be removed along with the webhook.
+		if _, n, err := s.WebhookService.FindWebhookDeliveries(ctx0, wtf.WebhookDeliveryFilter{}); err != nil {
+			t.Fatal(err)
+		} else if got, want := n, 0; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure regular members cannot find the webhook to delete it.
+	t.Run("ErrNotFound", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial, InviteCode: dial.InviteCode})
+		webhook := MustCreateWebhook(t, ctx0, s, &wtf.Webhook{DialID: dial.ID, URL: "https://example.com/hook"})
+
+		if err := s.WebhookService.DeleteWebhook(ctx1, webhook.ID); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatal(err)
+		}
+	})
+}
+
+func testWebhookService_Deliveries(t *testing.T, open OpenFunc) {
+	// Ensure dial events are queued for delivery to subscribed webhooks.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		webhook0 := MustCreateWebhook(t, ctx0, s, &wtf.Webhook{DialID: dial.ID, URL: "https://example.com/0", EventTypes: []string{wtf.EventTypeDialValueChanged}})
+		webhook1 := MustCreateWebhook(t, ctx0, s, &wtf.Webhook{DialID: dial.ID, URL: "https://example.com/1", EventTypes: []string{wtf.EventTypeAlertFired}})
+		if err := s.DialService.SetDialMembershipValue(ctx0, dial.ID, 50, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		deliveries, n, err := s.WebhookService.FindWebhookDeliveries(ctx0, wtf.WebhookDeliveryFilter{DialID: &dial.ID})
+		if err != nil {
+			t.Fatal(err)
+		} else if got, want := n, 1; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		}
+
+		delivery := deliveries[0]
+		if got, want := delivery.WebhookID, webhook0.ID; got != want {
+			t.Fatalf("WebhookID=%v, want %v", got, want)
+		} else if got, want := delivery.EventType, wtf.EventTypeDialValueChanged; got != want {
+			t.Fatalf("EventType=%v, want %v", got, want)
+		} else if got, want := delivery.Status, wtf.WebhookDeliveryStatusPending; got != want {
+			t.Fatalf("Status=%v, want %v", got, want)
+		} else if delivery.NextAttemptAt == nil {
+			t.Fatal("expected next attempt time")
+		}
+
+		// The body should be the JSON-encoded event.
+		var event struct {
+			Type    string                      `json:"type"`
+			Payload wtf.DialValueChangedPayload `json:"payload"`
+		}
+		if err := json.Unmarshal(delivery.Body, &event); err != nil {
+			t.Fatal(err)
+		} else if got, want := event.Type, wtf.EventTypeDialValueChanged; got != want {
+			t.Fatalf("Type=%v, want %v", got, want)
+		} else if got, want := event.Payload, (wtf.DialValueChangedPayload{ID: dial.ID, Value: 50}); got != want {
+			t.Fatalf("Payload=%#v, want %#v", got, want)
+		}
+
+		// Filter by webhook.
+		if _, n, err := s.WebhookService.FindWebhookDeliveries(ctx0, wtf.WebhookDeliveryFilter{WebhookID: &webhook1.ID}); err != nil {
+			t.Fatal(err)
+		} else if got, want := n, 0; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure regular members cannot see deliveries.
+	t.Run("Unauthorized", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial, InviteCode: dial.InviteCode})
+		MustCreateWebhook(t, ctx0, s, &wtf.Webhook{DialID: dial.ID, URL: "https://example.com/hook"})
+		if err := s.DialService.SetDialMembershipValue(ctx0, dial.ID, 50, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, n, err := s.WebhookService.FindWebhookDeliveries(ctx1, wtf.WebhookDeliveryFilter{}); err != nil {
+			t.Fatal(err)
+		} else if got, want := n, 0; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		}
+	})
+}
+
+func testWebhookService_RecordWebhookDeliveryAttempt(t *testing.T, open OpenFunc) {
+	// Ensure failed attempts are retried with backoff until they succeed.
+	t.Run("Retry", func(t *testing.T) {
+		s := open(t)
+		t0 := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		setNow(t, s, t0)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		webhook := MustCreateWebhook(t, ctx0, s, &wtf.Webhook{DialID: dial.ID, URL: "https://example.com/hook", EventTypes: []string{wtf.EventTypeDialValueChanged}})
+		if err := s.DialService.SetDialMembershipValue(ctx0, dial.ID, 50, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		// The new delivery should be pending along with its webhook.
+		deliveries := MustFindPendingWebhookDeliveries(t, ctx0, s)
+		if got, want := len(deliveries), 1; got != want {
+			t.Fatalf("len=%v, want %v", got, want)
+		} else if deliveries[0].Webhook == nil || deliveries[0].Webhook.Secret != webhook.Secret {
+			t.Fatalf("unexpected webhook: %#v", deliveries[0].Webhook)
+		}
+		id := deliveries[0].ID
+
+		// A failed attempt should be retried after the base delay.
+		delivery, err := s.WebhookService.RecordWebhookDeliveryAttempt(ctx0, id, wtf.WebhookDeliveryResult{StatusCode: 500})
+		if err != nil {
+			t.Fatal(err)
+		} else if got, want := delivery.Status, wtf.WebhookDeliveryStatusPending; got != want {
+			t.Fatalf("Status=%v, want %v", got, want)
+		} else if got, want := delivery.Attempts, 1; got != want {
+			t.Fatalf("Attempts=%v, want %v", got, want)
+		} else if got, want := *delivery.NextAttemptAt, t0.Add(wtf.WebhookRetryBaseDelay); !got.Equal(want) {
+			t.Fatalf("NextAttemptAt=%v, want %v", got, want)
+		} else if deliveries := MustFindPendingWebhookDeliveries(t, ctx0, s); len(deliveries) != 0 {
+			t.Fatalf("unexpected pending deliveries: %d", len(deliveries))
+		}
+
+		// Once the delay passes, the delivery is pending again.
+		setNow(t, s, t0.Add(wtf.WebhookRetryBaseDelay))
+		if deliveries := MustFindPendingWebhookDeliveries(t, ctx0, s); len(deliveries) != 1 {
+			t.Fatalf("len=%v, want 1", len(deliveries))
+		}
+
+		// A successful attempt completes the delivery.
+		if delivery, err := s.WebhookService.RecordWebhookDeliveryAttempt(ctx0, id, wtf.WebhookDeliveryResult{StatusCode: 204}); err != nil {
+			t.Fatal(err)
+		} else if got, want := delivery.Status, wtf.WebhookDeliveryStatusSucceeded; got != want {
+			t.Fatalf("Status=%v, want %v", got, want)
+		} else if got, want := delivery.Attempts, 2; got != want {
+			t.Fatalf("Attempts=%v, want %v", got, want)
+		} else if delivery.NextAttemptAt != nil {
+			t.Fatalf("unexpected next attempt: %v", delivery.NextAttemptAt)
+		}
+
+		// The result should be persisted.
+		if deliveries, _, err := s.WebhookService.FindWebhookDeliveries(ctx0, wtf.WebhookDeliveryFilter{ID: &id}); err != nil {
+			t.Fatal(err)
+		} else if got, want := deliveries[0].StatusCode, 204; got != want {
+			t.Fatalf("StatusCode=%v, want %v", got, want)
+		} else if got, want := deliveries[0].Status, wtf.WebhookDeliveryStatusSucceeded; got != want {
+			t.Fatalf("Status=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure a delivery fails once it reaches the maximum number of attempts.
+	t.Run("Failed", func(t *testing.T) {
+		s := open(t)
+		t0 := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		setNow(t, s, t0)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		MustCreateWebhook(t, ctx0, s, &wtf.Webhook{DialID: dial.ID, URL: "https://example.com/hook", EventTypes: []string{wtf.EventTypeDialValueChanged}})
+		if err := s.DialService.SetDialMembershipValue(ctx0, dial.ID, 50, ""); err != nil {
+			t.Fatal(err)
+		}
+		id := MustFindPendingWebhookDeliveries(t, ctx0, s)[0].ID
+
+		var delivery *wtf.WebhookDelivery
+		for i := 0; i < wtf.MaxWebhookDeliveryAttempts; i++ {
+			var err error
+			if delivery, err = s.WebhookService.RecordWebhookDeliveryAttempt(ctx0, id, wtf.WebhookDeliveryResult{Error: "connection refused"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got, want := delivery.Status, wtf.WebhookDeliveryStatusFailed; got != want {
+			t.Fatalf("Status=%v, want %v", got, want)
+		} else if got, want := delivery.Error, "connection refused"; got != want {
+			t.Fatalf("Error=%v, want %v", got, want)
+		}
+
+		// Completed deliveries cannot be attempted again.
+		if _, err := s.WebhookService.RecordWebhookDeliveryAttempt(ctx0, id, wtf.WebhookDeliveryResult{StatusCode: 200}); wtf.ErrorCode(err) != wtf.ECONFLICT {
+			t.Fatal(err)
+		}
+	})
+}
+
+func testWebhookService_RedeliverWebhookDelivery(t *testing.T, open OpenFunc) {
+	// Ensure a delivery can be queued again with the same event.
+	t.Run("OK", func(t *testing.T) {
+		s := open(t)
+		_, ctx0 := MustCreateUser(t, context.Background(), s, &wtf.User{Name: "jane"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		MustCreateWebhook(t, ctx0, s, &wtf.Webhook{DialID: dial.ID, URL: "https://example.com/hook", EventTypes: []string{wtf.EventTypeDialValueChanged}})
+		if err := s.DialService.SetDialMembershipValue(ctx0, dial.ID, 50, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		deliveries, _, err := s.WebhookService.FindWebhookDeliveries(ctx0, wtf.WebhookDeliveryFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		delivery, err := s.WebhookService.RedeliverWebhookDelivery(ctx0, deliveries[0].ID)
+		if err != nil {
+			t.Fatal(err)
+		} else if delivery.ID == deliveries[0].ID {
+			t.Fatal("expected new delivery")
+		} else if got, want := delivery.Status, wtf.WebhookDeliveryStatusPending; got != want {
+			t.Fatalf("Status=%v, want %v", got, want)
+		} else if got, want := string(delivery.Body), string(deliveries[0].Body); got != want {
+			t.Fatalf("Body=%s, want %s", got, want)
+		}
+
+		if _, n, err := s.WebhookService.FindWebhookDeliveries(ctx0, wtf.WebhookDeliveryFilter{}); err != nil {
+			t.Fatal(err)
+		} else if got, want := n, 2; got != want {
+			t.Fatalf("n=%v, want %v", got, want)
+		}
+	})
+
+	// Ensure regular members cannot redeliver.
+	t.Run("ErrNotFound", func(t *testing.T) {
+		s := open(t)
+		ctx := context.Background()
+		_, ctx0 := MustCreateUser(t, ctx, s, &wtf.User{Name: "jane"})
+		_, ctx1 := MustCreateUser(t, ctx, s, &wtf.User{Name: "john"})
+		dial := MustCreateDial(t, ctx0, s, &wtf.Dial{Name: "DIAL"})
+		MustCreateDialMembership(t, ctx1, s, &wtf.DialMembership{DialID: dial.ID, Dial: dial, InviteCode: dial.InviteCode})
+		MustCreateWebhook(t, ctx0, s, &wtf.Webhook{DialID: dial.ID, URL: "https://example.com/hook"})
+		if err := s.DialService.SetDialMembershipValue(ctx0, dial.ID, 50, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		deliveries, _, err := s.WebhookService.FindWebhookDeliveries(ctx0, wtf.WebhookDeliveryFilter{})
+		if err != nil {
+			t.Fatal(err)
+		} else if _, err := s.WebhookService.RedeliverWebhookDelivery(ctx1, deliveries[0].ID); wtf.ErrorCode(err) != wtf.ENOTFOUND {
+			t.Fatal(err)
+		}
+	})
+}
+
+// MustCreateWebhook creates a webhook. Fatal on error.
+func MustCreateWebhook(tb testing.TB, ctx context.Context, s *Services, webhook *wtf.Webhook) *wtf.Webhook {
+	tb.Helper()
+	if err := s.WebhookService.CreateWebhook(ctx, webhook); err != nil {
+		tb.Fatal(err)
+	}
+	return webhook
+}
+
+// MustFindPendingWebhookDeliveries returns all pending deliveries which are
+// due. Skips the test if not implemented. Fatal on error.
+func MustFindPendingWebhookDeliveries(tb testing.TB, ctx context.Context, s *Services) []*wtf.WebhookDelivery {
+	tb.Helper()
+	deliveries, err := s.WebhookService.FindPendingWebhookDeliveries(ctx, 0)
+	skipIfNotImplemented(tb, err)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return deliveries
+}
@@ -0,0 +1,188 @@
+// Package wtftest provides a shared test suite for implementations of the
+// wtf service interfaces.
+//
+// Each implementation (e.g. sqlite, inmem, http) should call Run() from its
+// own tests with a function that returns a fresh set of services. This ensures
+// that all implementations share the same behavior for error codes, ownership
+// rules, pagination, & event publishing.
+package wtftest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/wtf"
+)
+
+// Services represents the set of services under test.
+type Services struct {
+	AlertService           wtf.AlertService
+	APITokenService        wtf.APITokenService
+	AuthService            wtf.AuthService
+	DialService            wtf.DialService
+	DialMembershipService  wtf.DialMembershipService
+	IncomingWebhookService wtf.IncomingWebhookService
+	LoginTokenService      wtf.LoginTokenService
+	OrganizationService    wtf.OrganizationService
+	SearchService          wtf.SearchService
+	SessionService         wtf.SessionService
+	UserService            wtf.UserService
+	WebhookService         wtf.WebhookService
+
+	// Event service that the implementation publishes events to.
+	// Event tests are skipped if this is nil.
+	EventService wtf.EventService
+
+	// Sets the function used by the implementation to return the current time.
+	// Time-dependent tests are skipped if this is nil.
+	SetNow func(fn func() time.Time)
+
+	// Returns a context for the user. Remote clients can use this to attach
+	// credentials for the user. Defaults to wtf.NewContextWithUser().
+	NewContextWithUser func(ctx context.Context, user *wtf.User) context.Context
+}
+
+// OpenFunc returns a new, empty set of services for a single test. Any cleanup
+// should be registered with tb.Cleanup().
+type OpenFunc func(tb testing.TB) *Services
+
+// Run executes the entire test suite against the services returned by open.
+func Run(t *testing.T, open OpenFunc) {
+	t.Run("AlertService", func(t *testing.T) { testAlertService(t, open) })
+	t.Run("APITokenService", func(t *testing.T) { testAPITokenService(t, open) })
+	t.Run("AuthService", func(t *testing.T) { testAuthService(t, open) })
+	t.Run("DialService", func(t *testing.T) { testDialService(t, open) })
+	t.Run("DialMembershipService", func(t *testing.T) { testDialMembershipService(t, open) })
+	t.Run("IncomingWebhookService", func(t *testing.T) { testIncomingWebhookService(t, open) })
+	t.Run("LoginTokenService", func(t *testing.T) { testLoginTokenService(t, open) })
+	t.Run("OrganizationService", func(t *testing.T) { testOrganizationService(t, open) })
+	t.Run("SearchService", func(t *testing.T) { testSearchService(t, open) })
+	t.Run("SessionService", func(t *testing.T) { testSessionService(t, open) })
+	t.Run("UserService", func(t *testing.T) { testUserService(t, open) })
+	t.Run("WebhookService", func(t *testing.T) { testWebhookService(t, open) })
+}
+
+// MustCreateUser creates a user. Returns the user & a context for the user. Fatal on error.
+func MustCreateUser(tb testing.TB, ctx context.Context, s *Services, user *wtf.User) (*wtf.User, context.Context) {
+	tb.Helper()
+	if err := s.UserService.CreateUser(ctx, user); err != nil {
+		tb.Fatal(err)
+	}
+	return user, newContextWithUser(ctx, s, user)
+}
+
+// MustCreateAuth creates an auth object. Returns the auth & a context for the
+// associated user. Fatal on error.
+func MustCreateAuth(tb testing.TB, ctx context.Context, s *Services, auth *wtf.Auth) (*wtf.Auth, context.Context) {
+	tb.Helper()
+	if err := s.AuthService.CreateAuth(ctx, auth); err != nil {
+		tb.Fatal(err)
+	}
+	return auth, newContextWithUser(ctx, s, auth.User)
+}
+
+// MustFindDialByID finds a dial by ID. Fatal on error.
+func MustFindDialByID(tb testing.TB, ctx context.Context, s *Services, id int) *wtf.Dial {
+	tb.Helper()
+	dial, err := s.DialService.FindDialByID(ctx, id)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return dial
+}
+
+// MustCreateDial creates a dial. Fatal on error.
+func MustCreateDial(tb testing.TB, ctx context.Context, s *Services, dial *wtf.Dial) *wtf.Dial {
+	tb.Helper()
+	if err := s.DialService.CreateDial(ctx, dial); err != nil {
+		tb.Fatal(err)
+	}
+	return dial
+}
+
+// MustFindDialMembershipByID finds a membership by ID. Fatal on error.
+func MustFindDialMembershipByID(tb testing.TB, ctx context.Context, s *Services, id int) *wtf.DialMembership {
+	tb.Helper()
+	membership, err := s.DialMembershipService.FindDialMembershipByID(ctx, id)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return membership
+}
+
+// MustCreateDialMembership creates a membership. The membership's Dial should
+// be set so that remote implementations can join using the dial's invite code.
+// Fatal on error.
+func MustCreateDialMembership(tb testing.TB, ctx context.Context, s *Services, membership *wtf.DialMembership) *wtf.DialMembership {
+	tb.Helper()
+	if err := s.DialMembershipService.CreateDialMembership(ctx, membership); err != nil {
+		tb.Fatal(err)
+	}
+	return membership
+}
+
+// MustSetDialMembershipValue updates the membership value. Fatal on error.
+func MustSetDialMembershipValue(tb testing.TB, ctx context.Context, s *Services, id, value int) {
+	tb.Helper()
+	if _, err := s.DialMembershipService.UpdateDialMembership(ctx, id, wtf.DialMembershipUpdate{Value: &value}); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+// MustSetDialMembershipRole updates the membership role. Fatal on error.
+func MustSetDialMembershipRole(tb testing.TB, ctx context.Context, s *Services, id int, role string) {
+	tb.Helper()
+	if _, err := s.DialMembershipService.SetDialMembershipRole(ctx, id, role); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+// MustSubscribe subscribes to events for the current user. Skips the test if
+// the services do not provide an event service. Fatal on error.
+func MustSubscribe(tb testing.TB, ctx context.Context, s *Services) wtf.Subscription {
+	tb.Helper()
+	if s.EventService == nil {
+		tb.Skip("event service not available")
+	}
+
+	sub, err := s.EventService.Subscribe(ctx)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { sub.Close() })
+	return sub
+}
+
+// setNow sets the current time for the services. Skips the test if the
+// services do not support setting the time.
+func setNow(tb testing.TB, s *Services, now time.Time) {
+	tb.Helper()
+	if s.SetNow == nil {
+		tb.Skip("setting current time not supported")
+	}
+	s.SetNow(func() time.Time { return now })
+}
+
+// newContextWithUser returns a context for the user. Uses s.NewContextWithUser, if set.
+func newContextWithUser(ctx context.Context, s *Services, user *wtf.User) context.Context {
+	if s.NewContextWithUser != nil {
+		return s.NewContextWithUser(ctx, user)
+	}
+	return wtf.NewContextWithUser(ctx, user)
+}
+
+// skipIfNotImplemented skips the test if err is an ENOTIMPLEMENTED error.
+// This allows partial implementations (such as remote clients) to run the suite.
+func skipIfNotImplemented(tb testing.TB, err error) {
+	tb.Helper()
+	if wtf.ErrorCode(err) == wtf.ENOTIMPLEMENTED {
+		tb.Skip(wtf.ErrorMessage(err))
+	}
+}
+
+// intPtr returns a pointer to v.
+func intPtr(v int) *int { return &v }
+
+// stringPtr returns a pointer to v.
+func stringPtr(v string) *string { return &v }